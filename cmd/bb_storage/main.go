@@ -8,12 +8,21 @@ import (
 	"github.com/buildbarn/bb-storage/pkg/auth"
 	auth_configuration "github.com/buildbarn/bb-storage/pkg/auth/configuration"
 	"github.com/buildbarn/bb-storage/pkg/blobstore"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/chunking"
 	blobstore_configuration "github.com/buildbarn/bb-storage/pkg/blobstore/configuration"
 	"github.com/buildbarn/bb-storage/pkg/blobstore/grpcservers"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/httpservers"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/quota"
 	"github.com/buildbarn/bb-storage/pkg/builder"
 	"github.com/buildbarn/bb-storage/pkg/capabilities"
+	"github.com/buildbarn/bb-storage/pkg/clock"
+	"github.com/buildbarn/bb-storage/pkg/digest"
+	"github.com/buildbarn/bb-storage/pkg/eviction"
+	"github.com/buildbarn/bb-storage/pkg/filesystem"
+	"github.com/buildbarn/bb-storage/pkg/filesystem/path"
 	"github.com/buildbarn/bb-storage/pkg/global"
 	bb_grpc "github.com/buildbarn/bb-storage/pkg/grpc"
+	http_server "github.com/buildbarn/bb-storage/pkg/http/server"
 	"github.com/buildbarn/bb-storage/pkg/program"
 	"github.com/buildbarn/bb-storage/pkg/proto/configuration/bb_storage"
 	"github.com/buildbarn/bb-storage/pkg/proto/fsac"
@@ -51,8 +60,10 @@ func main() {
 		// Content Addressable Storage (CAS).
 		var contentAddressableStorageInfo *blobstore_configuration.BlobAccessInfo
 		var contentAddressableStorage blobstore.BlobAccess
+		var contentAddressableStoragePutAuthorizer auth.Authorizer
+		var blobSplitter chunking.BlobSplitter
 		if configuration.ContentAddressableStorage != nil {
-			info, authorizedBackend, allAuthorizers, err := newScannableBlobAccess(
+			info, authorizedBackend, allAuthorizers, putAuthorizer, err := newScannableBlobAccess(
 				dependenciesGroup,
 				configuration.ContentAddressableStorage,
 				blobstore_configuration.NewCASBlobAccessCreator(
@@ -67,13 +78,41 @@ func main() {
 				info.BlobAccess,
 				capabilities.NewStaticProvider(&remoteexecution.ServerCapabilities{
 					CacheCapabilities: &remoteexecution.CacheCapabilities{
-						SupportedCompressors: configuration.SupportedCompressors,
+						SupportedCompressors:            configuration.SupportedCompressors,
+						SupportedBatchUpdateCompressors: configuration.SupportedCompressors,
 					},
 				}),
 			)
 			cacheCapabilitiesAuthorizers = append(cacheCapabilitiesAuthorizers, allAuthorizers...)
 			contentAddressableStorageInfo = &info
 			contentAddressableStorage = authorizedBackend
+			contentAddressableStoragePutAuthorizer = putAuthorizer
+
+			if chunkingConfiguration := configuration.ContentDefinedChunking; chunkingConfiguration != nil {
+				manifestStorage, err := blobstore_configuration.NewBlobAccessFromConfiguration(
+					dependenciesGroup,
+					chunkingConfiguration.ManifestStorage,
+					blobstore_configuration.NewChunkManifestBlobAccessCreator())
+				if err != nil {
+					return util.StatusWrap(err, "Failed to create chunk manifest storage")
+				}
+				blobSplitter, err = chunking.NewBlobSplitterFromConfiguration(
+					chunkingConfiguration,
+					contentAddressableStorage,
+					manifestStorage.BlobAccess,
+					int(configuration.MaximumMessageSizeBytes))
+				if err != nil {
+					return util.StatusWrap(err, "Failed to create blob splitter")
+				}
+				cacheCapabilitiesProviders = append(
+					cacheCapabilitiesProviders,
+					capabilities.NewStaticProvider(&remoteexecution.ServerCapabilities{
+						CacheCapabilities: &remoteexecution.CacheCapabilities{
+							BlobSplitSupport:  true,
+							BlobSpliceSupport: true,
+						},
+					}))
+			}
 		}
 
 		// Action Cache (AC).
@@ -98,10 +137,23 @@ func main() {
 			actionCache = authorizedBackend
 		}
 
+		// Optional: inlining of blobs into responses of
+		// GetActionResult(), which requires access to the CAS.
+		var actionResultInliningStorage blobstore.BlobAccess
+		if maximumInlinedSizeBytes := configuration.MaximumActionResultInlinedSizeBytes; maximumInlinedSizeBytes > 0 {
+			if actionCache == nil || contentAddressableStorage == nil {
+				return status.Error(codes.InvalidArgument, "Inlining of blobs into action results requires both an Action Cache and a Content Addressable Storage to be configured")
+			}
+			if maximumInlinedSizeBytes >= configuration.MaximumMessageSizeBytes {
+				return status.Errorf(codes.InvalidArgument, "Maximum inlined size of %d bytes must be lower than the maximum message size of %d bytes", maximumInlinedSizeBytes, configuration.MaximumMessageSizeBytes)
+			}
+			actionResultInliningStorage = contentAddressableStorage
+		}
+
 		// Buildbarn extension: Indirect Content Addressable Storage (ICAS).
 		var indirectContentAddressableStorage blobstore.BlobAccess
 		if configuration.IndirectContentAddressableStorage != nil {
-			_, authorizedBackend, _, err := newScannableBlobAccess(
+			_, authorizedBackend, _, _, err := newScannableBlobAccess(
 				dependenciesGroup,
 				configuration.IndirectContentAddressableStorage,
 				blobstore_configuration.NewICASBlobAccessCreator(
@@ -146,6 +198,38 @@ func main() {
 			fileSystemAccessCache = authorizedBackend
 		}
 
+		// Staging area for resumable ByteStream uploads.
+		var uploadStagingArea *grpcservers.UploadStagingArea
+		if resumableUploads := configuration.ResumableUploads; resumableUploads != nil {
+			if contentAddressableStorage == nil {
+				return status.Error(codes.InvalidArgument, "Resumable uploads require a Content Addressable Storage to be configured")
+			}
+			if resumableUploads.MaximumSizeBytes <= 0 {
+				return status.Error(codes.InvalidArgument, "Maximum size of the upload staging area must be positive")
+			}
+			if err := resumableUploads.Expiration.CheckValid(); err != nil {
+				return util.StatusWrap(err, "Failed to parse upload expiration")
+			}
+			expiration := resumableUploads.Expiration.AsDuration()
+			if expiration <= 0 {
+				return status.Error(codes.InvalidArgument, "Upload expiration must be positive")
+			}
+			stagingDirectory, err := filesystem.NewLocalDirectory(path.LocalFormat.NewParser(resumableUploads.StagingDirectoryPath))
+			if err != nil {
+				return util.StatusWrapf(err, "Failed to open upload staging directory %#v", resumableUploads.StagingDirectoryPath)
+			}
+			if err := stagingDirectory.RemoveAllChildren(); err != nil {
+				return util.StatusWrapf(err, "Failed to clear upload staging directory %#v", resumableUploads.StagingDirectoryPath)
+			}
+			uploadStagingArea = grpcservers.NewUploadStagingArea(
+				stagingDirectory,
+				clock.SystemClock,
+				contentAddressableStoragePutAuthorizer,
+				resumableUploads.MinimumBlobSizeBytes,
+				resumableUploads.MaximumSizeBytes,
+				expiration)
+		}
+
 		var capabilitiesProviders []capabilities.Provider
 		if len(cacheCapabilitiesProviders) > 0 {
 			capabilitiesProviders = append(
@@ -171,6 +255,76 @@ func main() {
 			capabilitiesProviders = append(capabilitiesProviders, buildQueue)
 		}
 
+		// Optional: expose the Action Cache and Content Addressable
+		// Storage using Bazel's HTTP caching protocol.
+		if bazelHTTPCache := configuration.BazelHttpCache; bazelHTTPCache != nil {
+			if actionCache == nil || contentAddressableStorage == nil {
+				return status.Error(codes.InvalidArgument, "Bazel's HTTP caching protocol requires both an Action Cache and a Content Addressable Storage to be configured")
+			}
+			if bazelHTTPCache.BlobSizeCacheSize <= 0 {
+				return status.Error(codes.InvalidArgument, "Blob size cache size must be positive")
+			}
+			evictionSet, err := eviction.NewSetFromConfiguration[string](bazelHTTPCache.BlobSizeCacheReplacementPolicy)
+			if err != nil {
+				return util.StatusWrap(err, "Failed to create blob size cache replacement policy")
+			}
+			var blobSizeIndexInstanceName *digest.InstanceName
+			if blobSizeIndex := bazelHTTPCache.BlobSizeIndex; blobSizeIndex != nil {
+				instanceName, err := digest.NewInstanceName(blobSizeIndex.InstanceName)
+				if err != nil {
+					return util.StatusWrap(err, "Invalid blob size index instance name")
+				}
+				blobSizeIndexInstanceName = &instanceName
+			}
+			http_server.NewServersFromConfigurationAndServe(
+				bazelHTTPCache.HttpServers,
+				http_server.NewMetricsHandler(
+					httpservers.NewBazelCacheHandler(
+						actionCache,
+						contentAddressableStorage,
+						httpservers.NewBlobSizeCache(
+							int(bazelHTTPCache.BlobSizeCacheSize),
+							eviction.NewMetricsSet(evictionSet, "BlobSizeCache")),
+						blobSizeIndexInstanceName,
+						int(configuration.MaximumMessageSizeBytes),
+						1<<16),
+					"BazelHTTPCache"),
+				siblingsGroup,
+				grpcClientFactory)
+		}
+
+		// Optional: expose generic key-value stores over HTTP, for
+		// use by tools such as ccache, sccache and Gradle.
+		for i, keyValueHTTPCache := range configuration.KeyValueHttpCaches {
+			if actionCache == nil || contentAddressableStorage == nil {
+				return status.Error(codes.InvalidArgument, "Key-value HTTP caches require both an Action Cache and a Content Addressable Storage to be configured")
+			}
+			instanceName, err := digest.NewInstanceName(keyValueHTTPCache.InstanceName)
+			if err != nil {
+				return util.StatusWrapf(err, "Invalid instance name for key-value HTTP cache at index %d", i)
+			}
+			digestFunction, err := instanceName.GetDigestFunction(keyValueHTTPCache.DigestFunction, 0)
+			if err != nil {
+				return util.StatusWrapf(err, "Invalid digest function for key-value HTTP cache at index %d", i)
+			}
+			if keyValueHTTPCache.MaximumValueSizeBytes <= 0 {
+				return status.Errorf(codes.InvalidArgument, "Maximum value size for key-value HTTP cache at index %d must be positive", i)
+			}
+			http_server.NewServersFromConfigurationAndServe(
+				keyValueHTTPCache.HttpServers,
+				http_server.NewMetricsHandler(
+					httpservers.NewKeyValueCacheHandler(
+						actionCache,
+						contentAddressableStorage,
+						digestFunction,
+						keyValueHTTPCache.MaximumValueSizeBytes,
+						int(configuration.MaximumMessageSizeBytes),
+						1<<16),
+					"KeyValueHTTPCache"),
+				siblingsGroup,
+				grpcClientFactory)
+		}
+
 		if err := bb_grpc.NewServersFromConfigurationAndServe(
 			configuration.GrpcServers,
 			func(s grpc.ServiceRegistrar) {
@@ -179,19 +333,23 @@ func main() {
 						s,
 						grpcservers.NewContentAddressableStorageServer(
 							contentAddressableStorage,
+							blobSplitter,
 							configuration.MaximumMessageSizeBytes))
 					bytestream.RegisterByteStreamServer(
 						s,
 						grpcservers.NewByteStreamServer(
 							contentAddressableStorage,
-							1<<16))
+							1<<16,
+							uploadStagingArea))
 				}
 				if actionCache != nil {
 					remoteexecution.RegisterActionCacheServer(
 						s,
 						grpcservers.NewActionCacheServer(
 							actionCache,
-							int(configuration.MaximumMessageSizeBytes)))
+							actionResultInliningStorage,
+							int(configuration.MaximumMessageSizeBytes),
+							int(configuration.MaximumActionResultInlinedSizeBytes)))
 				}
 				if indirectContentAddressableStorage != nil {
 					icas.RegisterIndirectContentAddressableStorageServer(
@@ -230,6 +388,7 @@ func main() {
 			return util.StatusWrap(err, "gRPC server failure")
 		}
 
+		lifecycleState.RegisterDiagnosticsHTTPHandler("/quota", quota.DiagnosticsHandler)
 		lifecycleState.MarkReadyAndWait(siblingsGroup)
 		return nil
 	})
@@ -257,27 +416,28 @@ func newNonScannableBlobAccess(dependenciesGroup program.Group, configuration *b
 		nil
 }
 
-func newScannableBlobAccess(dependenciesGroup program.Group, configuration *bb_storage.ScannableBlobAccessConfiguration, creator blobstore_configuration.BlobAccessCreator, grpcClientFactory bb_grpc.ClientFactory) (blobstore_configuration.BlobAccessInfo, blobstore.BlobAccess, []auth.Authorizer, error) {
+func newScannableBlobAccess(dependenciesGroup program.Group, configuration *bb_storage.ScannableBlobAccessConfiguration, creator blobstore_configuration.BlobAccessCreator, grpcClientFactory bb_grpc.ClientFactory) (blobstore_configuration.BlobAccessInfo, blobstore.BlobAccess, []auth.Authorizer, auth.Authorizer, error) {
 	info, err := blobstore_configuration.NewBlobAccessFromConfiguration(dependenciesGroup, configuration.Backend, creator)
 	if err != nil {
-		return blobstore_configuration.BlobAccessInfo{}, nil, nil, err
+		return blobstore_configuration.BlobAccessInfo{}, nil, nil, nil, err
 	}
 
 	getAuthorizer, err := auth_configuration.DefaultAuthorizerFactory.NewAuthorizerFromConfiguration(configuration.GetAuthorizer, dependenciesGroup, grpcClientFactory)
 	if err != nil {
-		return blobstore_configuration.BlobAccessInfo{}, nil, nil, util.StatusWrap(err, "Failed to create Get() authorizer")
+		return blobstore_configuration.BlobAccessInfo{}, nil, nil, nil, util.StatusWrap(err, "Failed to create Get() authorizer")
 	}
 	putAuthorizer, err := auth_configuration.DefaultAuthorizerFactory.NewAuthorizerFromConfiguration(configuration.PutAuthorizer, dependenciesGroup, grpcClientFactory)
 	if err != nil {
-		return blobstore_configuration.BlobAccessInfo{}, nil, nil, util.StatusWrap(err, "Failed to create Put() authorizer")
+		return blobstore_configuration.BlobAccessInfo{}, nil, nil, nil, util.StatusWrap(err, "Failed to create Put() authorizer")
 	}
 	findMissingAuthorizer, err := auth_configuration.DefaultAuthorizerFactory.NewAuthorizerFromConfiguration(configuration.FindMissingAuthorizer, dependenciesGroup, grpcClientFactory)
 	if err != nil {
-		return blobstore_configuration.BlobAccessInfo{}, nil, nil, util.StatusWrap(err, "Failed to create FindMissing() authorizer")
+		return blobstore_configuration.BlobAccessInfo{}, nil, nil, nil, util.StatusWrap(err, "Failed to create FindMissing() authorizer")
 	}
 
 	return info,
 		blobstore.NewAuthorizingBlobAccess(info.BlobAccess, getAuthorizer, putAuthorizer, findMissingAuthorizer),
 		[]auth.Authorizer{getAuthorizer, putAuthorizer, findMissingAuthorizer},
+		putAuthorizer,
 		nil
 }
@@ -32,3 +32,6 @@ type RoundTripper = http.RoundTripper
 
 // Writer is an alias of io.Writer.
 type Writer = io.Writer
+
+// WriteCloser is an alias of io.WriteCloser.
+type WriteCloser = io.WriteCloser
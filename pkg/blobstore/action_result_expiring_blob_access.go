@@ -44,12 +44,24 @@ func NewActionResultExpiringBlobAccess(blobAccess BlobAccess, clock clock.Clock,
 	}
 }
 
+// getJitteredExpirationTime computes the time at which an object
+// created at a given point in time expires. Jitter is added to the
+// expiration time to amortize the amount of work needed to recreate
+// expired objects. The amount of jitter only depends on the creation
+// time, meaning that all instances compute the same expiration time.
+func getJitteredExpirationTime(t time.Time, minimumValidity time.Duration, maximumValidityJitter uint64) time.Time {
+	expirationTime := t.Add(minimumValidity)
+	if maximumValidityJitter > 0 {
+		expirationTime = expirationTime.Add(time.Duration(uint64(t.Unix()) * 0x936a0d2a41e8c779 % maximumValidityJitter))
+	}
+	return expirationTime
+}
+
 func (ba *actionResultExpiringBlobAccess) checkWorkerCompletedTimestamp(t time.Time) error {
 	if t.Before(ba.minimumTimestamp) {
 		return status.Errorf(codes.NotFound, "Action result has worker completed timestamp %s, which is below the minimum of %s", t.Format(time.RFC3339), ba.minimumTimestamp.Format(time.RFC3339))
 	}
-	// Pick an expiration time that includes jitter.
-	expirationTime := t.Add(ba.minimumValidity).Add(time.Duration(uint64(t.Unix()) * 0x936a0d2a41e8c779 % ba.maximumValidityJitter))
+	expirationTime := getJitteredExpirationTime(t, ba.minimumValidity, ba.maximumValidityJitter)
 	if ba.clock.Now().After(expirationTime) {
 		return status.Errorf(codes.NotFound, "Action result with worker completed timestamp %s expired at %s", t.Format(time.RFC3339), expirationTime.Format(time.RFC3339))
 	}
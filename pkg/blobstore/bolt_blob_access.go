@@ -0,0 +1,472 @@
+package blobstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/buildbarn/bb-storage/pkg/blobstore/buffer"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/slicing"
+	"github.com/buildbarn/bb-storage/pkg/capabilities"
+	"github.com/buildbarn/bb-storage/pkg/clock"
+	"github.com/buildbarn/bb-storage/pkg/digest"
+	"github.com/buildbarn/bb-storage/pkg/util"
+	"go.etcd.io/bbolt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var boltBucketName = []byte("objects")
+
+const (
+	// Size of the header that is prepended to each value, containing
+	// the time at which the object was inserted.
+	boltValueHeaderSizeBytes = 8
+
+	// Number of keys to process in a single transaction when
+	// removing expired objects. This prevents the removal of expired
+	// objects from blocking calls to Put() for a long time.
+	boltRemoveExpiredBatchSize = 1000
+
+	// Maximum size of transactions performed by Compact() while
+	// copying objects into a new database.
+	boltCompactionTransactionSizeBytes = 64 * 1024 * 1024
+)
+
+// BoltBlobAccess is a BlobAccess that stores objects in a B+tree
+// backed database file, using bbolt. Unlike the 'local' backend, which
+// is a ring buffer, objects are only removed when they expire. This
+// makes it suitable for durably storing the Action Cache (AC), Initial
+// Size Class Cache (ISCC) and File System Access Cache (FSAC).
+//
+// Each object is stored together with the time at which it was
+// inserted. If a minimum validity is provided, objects expire in a way
+// that is identical to ActionResultExpiringBlobAccess, except that the
+// insertion time is used instead of the ActionResult's
+// worker_completed_timestamp.
+type BoltBlobAccess struct {
+	capabilities.Provider
+	readBufferFactory      ReadBufferFactory
+	digestKeyFormat        digest.KeyFormat
+	path                   string
+	clock                  clock.Clock
+	maximumObjectSizeBytes int
+	minimumValidity        time.Duration
+	maximumValidityJitter  uint64
+
+	// Compaction replaces the database by a new copy. Writes that
+	// are performed while objects are being copied are recorded in
+	// a journal, which is replayed against the new copy prior to
+	// replacing the database. writeLock is used to block writes
+	// while the journal is replayed, while dbLock is used to
+	// protect the database handle.
+	compactionLock sync.Mutex
+	writeLock      sync.RWMutex
+	dbLock         sync.RWMutex
+	db             *bbolt.DB
+
+	journalLock sync.Mutex
+	journal     map[string][]byte
+}
+
+var _ BlobAccess = (*BoltBlobAccess)(nil)
+
+func openBoltDatabase(path string) (*bbolt.DB, error) {
+	db, err := bbolt.Open(path, 0o600, &bbolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, util.StatusWrapfWithCode(err, codes.Internal, "Failed to open database %#v", path)
+	}
+	if err := db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucketName)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, util.StatusWrapfWithCode(err, codes.Internal, "Failed to create bucket in database %#v", path)
+	}
+	return db, nil
+}
+
+// NewBoltBlobAccess creates a BlobAccess that stores objects in a
+// bbolt database file at a given path. The file is created if it does
+// not exist. If minimumValidity is zero, objects never expire.
+func NewBoltBlobAccess(capabilitiesProvider capabilities.Provider, readBufferFactory ReadBufferFactory, digestKeyFormat digest.KeyFormat, path string, clock clock.Clock, maximumObjectSizeBytes int, minimumValidity, maximumValidityJitter time.Duration) (*BoltBlobAccess, error) {
+	db, err := openBoltDatabase(path)
+	if err != nil {
+		return nil, err
+	}
+	return &BoltBlobAccess{
+		Provider:               capabilitiesProvider,
+		readBufferFactory:      readBufferFactory,
+		digestKeyFormat:        digestKeyFormat,
+		path:                   path,
+		clock:                  clock,
+		maximumObjectSizeBytes: maximumObjectSizeBytes,
+		minimumValidity:        minimumValidity,
+		maximumValidityJitter:  uint64(maximumValidityJitter),
+		db:                     db,
+	}, nil
+}
+
+// isExpired returns whether a value stored in the database belongs to
+// an object that has expired.
+func (ba *BoltBlobAccess) isExpired(value []byte, now time.Time) bool {
+	if ba.minimumValidity == 0 {
+		return false
+	}
+	insertionTime := time.Unix(0, int64(binary.BigEndian.Uint64(value)))
+	return now.After(getJitteredExpirationTime(insertionTime, ba.minimumValidity, ba.maximumValidityJitter))
+}
+
+func (ba *BoltBlobAccess) Get(ctx context.Context, blobDigest digest.Digest) buffer.Buffer {
+	key := []byte(blobDigest.GetKey(ba.digestKeyFormat))
+	now := ba.clock.Now()
+	var data []byte
+	ba.dbLock.RLock()
+	err := ba.db.View(func(tx *bbolt.Tx) error {
+		value := tx.Bucket(boltBucketName).Get(key)
+		if len(value) < boltValueHeaderSizeBytes {
+			return status.Error(codes.NotFound, "Object not found")
+		}
+		if ba.isExpired(value, now) {
+			return status.Error(codes.NotFound, "Object has expired")
+		}
+		// Values are only valid for the lifetime of the
+		// transaction, so make a copy.
+		data = bytes.Clone(value[boltValueHeaderSizeBytes:])
+		return nil
+	})
+	ba.dbLock.RUnlock()
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return buffer.NewBufferFromError(err)
+		}
+		return buffer.NewBufferFromError(util.StatusWrapWithCode(err, codes.Internal, "Failed to read object"))
+	}
+	return ba.readBufferFactory.NewBufferFromByteSlice(
+		blobDigest,
+		data,
+		func(dataIsValid bool) {
+			// Remove corrupted objects, so that they may be
+			// replaced by a subsequent call to Put().
+			if !dataIsValid {
+				ba.writeLock.RLock()
+				ba.dbLock.RLock()
+				ba.db.Batch(func(tx *bbolt.Tx) error {
+					ba.recordWrite(key, nil)
+					return tx.Bucket(boltBucketName).Delete(key)
+				})
+				ba.dbLock.RUnlock()
+				ba.writeLock.RUnlock()
+			}
+		})
+}
+
+// recordWrite records a write in the journal if compaction is in
+// progress, so that it can be replayed against the compacted copy of
+// the database. A nil value denotes that the object was removed.
+//
+// This function is called from within write transactions. As bbolt
+// only permits a single write transaction at a time, this ensures that
+// the journal reflects the order in which writes are applied.
+func (ba *BoltBlobAccess) recordWrite(key, value []byte) {
+	ba.journalLock.Lock()
+	if ba.journal != nil {
+		ba.journal[string(key)] = value
+	}
+	ba.journalLock.Unlock()
+}
+
+func (ba *BoltBlobAccess) GetFromComposite(ctx context.Context, parentDigest, childDigest digest.Digest, slicer slicing.BlobSlicer) buffer.Buffer {
+	b, _ := slicer.Slice(ba.Get(ctx, parentDigest), childDigest)
+	return b
+}
+
+func (ba *BoltBlobAccess) Put(ctx context.Context, blobDigest digest.Digest, b buffer.Buffer) error {
+	data, err := b.ToByteSlice(ba.maximumObjectSizeBytes)
+	if err != nil {
+		return err
+	}
+	value := make([]byte, boltValueHeaderSizeBytes, boltValueHeaderSizeBytes+len(data))
+	binary.BigEndian.PutUint64(value, uint64(ba.clock.Now().UnixNano()))
+	value = append(value, data...)
+
+	key := []byte(blobDigest.GetKey(ba.digestKeyFormat))
+	ba.writeLock.RLock()
+	defer ba.writeLock.RUnlock()
+	ba.dbLock.RLock()
+	defer ba.dbLock.RUnlock()
+	// Use batching, so that concurrent calls to Put() share a
+	// single transaction, and thus a single call to fsync().
+	if err := ba.db.Batch(func(tx *bbolt.Tx) error {
+		ba.recordWrite(key, value)
+		return tx.Bucket(boltBucketName).Put(key, value)
+	}); err != nil {
+		return util.StatusWrapWithCode(err, codes.Internal, "Failed to write object")
+	}
+	return nil
+}
+
+func (ba *BoltBlobAccess) FindMissing(ctx context.Context, digests digest.Set) (digest.Set, error) {
+	if digests.Empty() {
+		return digest.EmptySet, nil
+	}
+
+	now := ba.clock.Now()
+	missing := digest.NewSetBuilder()
+	ba.dbLock.RLock()
+	defer ba.dbLock.RUnlock()
+	if err := ba.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(boltBucketName)
+		for _, blobDigest := range digests.Items() {
+			value := bucket.Get([]byte(blobDigest.GetKey(ba.digestKeyFormat)))
+			if len(value) < boltValueHeaderSizeBytes || ba.isExpired(value, now) {
+				missing.Add(blobDigest)
+			}
+		}
+		return nil
+	}); err != nil {
+		return digest.EmptySet, util.StatusWrapWithCode(err, codes.Internal, "Failed to check for the existence of objects")
+	}
+	return missing.Build(), nil
+}
+
+// RemoveExpired removes all objects from the database that have
+// expired. Objects are removed in small batches, so that calls to
+// Put() are not blocked for a long time. The number of objects
+// removed is returned.
+func (ba *BoltBlobAccess) RemoveExpired(ctx context.Context) (int, error) {
+	if ba.minimumValidity == 0 {
+		return 0, nil
+	}
+
+	removed := 0
+	var nextKey []byte
+	for {
+		if err := ctx.Err(); err != nil {
+			return removed, util.StatusFromContext(ctx)
+		}
+
+		now := ba.clock.Now()
+		done := false
+		ba.writeLock.RLock()
+		ba.dbLock.RLock()
+		err := ba.db.Update(func(tx *bbolt.Tx) error {
+			bucket := tx.Bucket(boltBucketName)
+			cursor := bucket.Cursor()
+			var key, value []byte
+			if nextKey == nil {
+				key, value = cursor.First()
+			} else {
+				key, value = cursor.Seek(nextKey)
+			}
+
+			// Deleting keys while iterating may cause the
+			// cursor to skip entries. Gather the keys to
+			// remove first.
+			var expiredKeys [][]byte
+			for i := 0; key != nil && i < boltRemoveExpiredBatchSize; i++ {
+				if len(value) < boltValueHeaderSizeBytes || ba.isExpired(value, now) {
+					expiredKeys = append(expiredKeys, bytes.Clone(key))
+				}
+				key, value = cursor.Next()
+			}
+			if key == nil {
+				done = true
+			} else {
+				nextKey = bytes.Clone(key)
+			}
+
+			for _, expiredKey := range expiredKeys {
+				ba.recordWrite(expiredKey, nil)
+				if err := bucket.Delete(expiredKey); err != nil {
+					return err
+				}
+			}
+			removed += len(expiredKeys)
+			return nil
+		})
+		ba.dbLock.RUnlock()
+		ba.writeLock.RUnlock()
+		if err != nil {
+			return removed, util.StatusWrapWithCode(err, codes.Internal, "Failed to remove expired objects")
+		}
+		if done {
+			return removed, nil
+		}
+	}
+}
+
+// Compact the database by copying all objects into a new database
+// file, and replacing the existing database by it. bbolt never shrinks
+// database files, meaning that this is needed to reclaim space after
+// large numbers of objects have expired.
+//
+// Compaction is only performed if at least half of the database file
+// consists of free pages. Calls to Get(), FindMissing() and Put()
+// continue to be processed while objects are copied into the new
+// database file. Writes performed in the meantime are replayed against
+// the new database file afterwards. All calls are blocked while the
+// writes are replayed, and the existing database is closed and
+// replaced by the new one. The return value indicates whether
+// compaction took place.
+func (ba *BoltBlobAccess) Compact() (bool, error) {
+	ba.compactionLock.Lock()
+	defer ba.compactionLock.Unlock()
+
+	ba.dbLock.RLock()
+	stats := ba.db.Stats()
+	freeSizeBytes := int64(stats.FreePageN+stats.PendingPageN) * int64(ba.db.Info().PageSize)
+	var totalSizeBytes int64
+	err := ba.db.View(func(tx *bbolt.Tx) error {
+		totalSizeBytes = tx.Size()
+		return nil
+	})
+	ba.dbLock.RUnlock()
+	if err != nil {
+		return false, util.StatusWrapWithCode(err, codes.Internal, "Failed to obtain database size")
+	}
+	if freeSizeBytes*2 < totalSizeBytes {
+		return false, nil
+	}
+
+	compactedPath := ba.path + ".compact"
+	if err := os.Remove(compactedPath); err != nil && !os.IsNotExist(err) {
+		return false, util.StatusWrapfWithCode(err, codes.Internal, "Failed to remove stale database %#v", compactedPath)
+	}
+	compactedDB, err := bbolt.Open(compactedPath, 0o600, nil)
+	if err != nil {
+		return false, util.StatusWrapfWithCode(err, codes.Internal, "Failed to create database %#v", compactedPath)
+	}
+
+	// Start recording writes. Briefly block writes while doing so,
+	// so that writes that are in progress are either part of the
+	// copy, or recorded in the journal.
+	ba.writeLock.Lock()
+	ba.journalLock.Lock()
+	ba.journal = map[string][]byte{}
+	ba.journalLock.Unlock()
+	ba.writeLock.Unlock()
+
+	// Copy all objects into the new database file.
+	ba.dbLock.RLock()
+	err = bbolt.Compact(compactedDB, ba.db, boltCompactionTransactionSizeBytes)
+	ba.dbLock.RUnlock()
+
+	// Block writes, and replay the writes that took place while
+	// objects were being copied.
+	ba.writeLock.Lock()
+	defer ba.writeLock.Unlock()
+	ba.journalLock.Lock()
+	journal := ba.journal
+	ba.journal = nil
+	ba.journalLock.Unlock()
+	if err == nil {
+		err = compactedDB.Update(func(tx *bbolt.Tx) error {
+			bucket := tx.Bucket(boltBucketName)
+			for key, value := range journal {
+				if value == nil {
+					if err := bucket.Delete([]byte(key)); err != nil {
+						return err
+					}
+				} else if err := bucket.Put([]byte(key), value); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+	if closeErr := compactedDB.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(compactedPath)
+		return false, util.StatusWrapfWithCode(err, codes.Internal, "Failed to compact database into %#v", compactedPath)
+	}
+
+	// Replace the existing database by the compacted copy.
+	ba.dbLock.Lock()
+	defer ba.dbLock.Unlock()
+	if err := ba.db.Close(); err != nil {
+		return false, util.StatusWrapfWithCode(err, codes.Internal, "Failed to close database %#v", ba.path)
+	}
+	if err := os.Rename(compactedPath, ba.path); err != nil {
+		// Continue to use the existing database.
+		db, openErr := openBoltDatabase(ba.path)
+		if openErr != nil {
+			return false, openErr
+		}
+		ba.db = db
+		return false, util.StatusWrapfWithCode(err, codes.Internal, "Failed to rename %#v to %#v", compactedPath, ba.path)
+	}
+	db, err := openBoltDatabase(ba.path)
+	if err != nil {
+		return false, err
+	}
+	ba.db = db
+	return true, nil
+}
+
+// WriteSnapshot writes a consistent copy of the database to a writer.
+// The resulting copy is a valid database file that can be used to
+// restore the contents of the database. Calls to Get(), FindMissing()
+// and Put() may continue while the snapshot is being written.
+func (ba *BoltBlobAccess) WriteSnapshot(w io.Writer) error {
+	ba.dbLock.RLock()
+	defer ba.dbLock.RUnlock()
+	if err := ba.db.View(func(tx *bbolt.Tx) error {
+		_, err := tx.WriteTo(w)
+		return err
+	}); err != nil {
+		return util.StatusWrapWithCode(err, codes.Internal, "Failed to write snapshot")
+	}
+	return nil
+}
+
+// WriteSnapshotToFile writes a consistent copy of the database to a
+// file at a given path. The copy is first written to a temporary file,
+// which is renamed once completed. This ensures that the file at the
+// provided path always contains a complete snapshot.
+func (ba *BoltBlobAccess) WriteSnapshotToFile(path string) error {
+	temporaryPath := path + ".tmp"
+	f, err := os.OpenFile(temporaryPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return util.StatusWrapfWithCode(err, codes.Internal, "Failed to create snapshot file %#v", temporaryPath)
+	}
+	if err := ba.WriteSnapshot(f); err != nil {
+		f.Close()
+		os.Remove(temporaryPath)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(temporaryPath)
+		return util.StatusWrapfWithCode(err, codes.Internal, "Failed to synchronize snapshot file %#v", temporaryPath)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(temporaryPath)
+		return util.StatusWrapfWithCode(err, codes.Internal, "Failed to close snapshot file %#v", temporaryPath)
+	}
+	if err := os.Rename(temporaryPath, path); err != nil {
+		os.Remove(temporaryPath)
+		return util.StatusWrapfWithCode(err, codes.Internal, "Failed to rename %#v to %#v", temporaryPath, path)
+	}
+	return nil
+}
+
+// Close the database. No calls against the BlobAccess may be made
+// afterwards.
+func (ba *BoltBlobAccess) Close() error {
+	ba.writeLock.Lock()
+	defer ba.writeLock.Unlock()
+	ba.dbLock.Lock()
+	defer ba.dbLock.Unlock()
+	if err := ba.db.Close(); err != nil {
+		return util.StatusWrapfWithCode(err, codes.Internal, "Failed to close database %#v", ba.path)
+	}
+	return nil
+}
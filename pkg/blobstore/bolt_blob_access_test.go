@@ -0,0 +1,237 @@
+package blobstore_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	remoteexecution "github.com/bazelbuild/remote-apis/build/bazel/remote/execution/v2"
+	"github.com/buildbarn/bb-storage/internal/mock"
+	"github.com/buildbarn/bb-storage/pkg/blobstore"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/buffer"
+	"github.com/buildbarn/bb-storage/pkg/digest"
+	"github.com/buildbarn/bb-storage/pkg/testutil"
+	"github.com/stretchr/testify/require"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestBoltBlobAccess(t *testing.T) {
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+
+	directory := t.TempDir()
+	databasePath := filepath.Join(directory, "database")
+	clock := mock.NewMockClock(ctrl)
+	newBlobAccess := func(t *testing.T) *blobstore.BoltBlobAccess {
+		blobAccess, err := blobstore.NewBoltBlobAccess(
+			nil,
+			blobstore.CASReadBufferFactory,
+			digest.KeyWithoutInstance,
+			databasePath,
+			clock,
+			1<<20,
+			time.Hour,
+			0)
+		require.NoError(t, err)
+		return blobAccess
+	}
+	blobAccess := newBlobAccess(t)
+	putObjects := func(t *testing.T, first, count int) {
+		var wg sync.WaitGroup
+		for i := first; i < first+count; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				data := []byte(fmt.Sprintf("%01000d", i))
+				require.NoError(t, blobAccess.Put(ctx, digest.MustNewDigest("hello", remoteexecution.DigestFunction_MD5, fmt.Sprintf("%032x", i), int64(len(data))), buffer.NewValidatedBufferFromByteSlice(data)))
+			}()
+		}
+		wg.Wait()
+	}
+
+	helloDigest := digest.MustNewDigest("hello", remoteexecution.DigestFunction_MD5, "8b1a9953c4611296a827abf8c47804d7", 5)
+	worldDigest := digest.MustNewDigest("hello", remoteexecution.DigestFunction_MD5, "f5a7924e621e84c9280a9a27e1bcb7f6", 5)
+
+	t.Run("GetNotFound", func(t *testing.T) {
+		clock.EXPECT().Now().Return(time.Unix(1000, 0))
+
+		_, err := blobAccess.Get(ctx, helloDigest).ToByteSlice(100)
+		testutil.RequireEqualStatus(t, status.Error(codes.NotFound, "Object not found"), err)
+	})
+
+	t.Run("PutAndGet", func(t *testing.T) {
+		clock.EXPECT().Now().Return(time.Unix(1000, 0))
+		require.NoError(t, blobAccess.Put(ctx, helloDigest, buffer.NewValidatedBufferFromByteSlice([]byte("Hello"))))
+
+		clock.EXPECT().Now().Return(time.Unix(1001, 0))
+		data, err := blobAccess.Get(ctx, helloDigest).ToByteSlice(100)
+		require.NoError(t, err)
+		require.Equal(t, []byte("Hello"), data)
+	})
+
+	t.Run("FindMissing", func(t *testing.T) {
+		clock.EXPECT().Now().Return(time.Unix(1002, 0))
+		missing, err := blobAccess.FindMissing(ctx, digest.NewSetBuilder().Add(helloDigest).Add(worldDigest).Build())
+		require.NoError(t, err)
+		require.Equal(t, worldDigest.ToSingletonSet(), missing)
+	})
+
+	t.Run("Snapshot", func(t *testing.T) {
+		// Snapshots should be usable as a database file.
+		snapshotPath := filepath.Join(directory, "snapshot")
+		require.NoError(t, blobAccess.WriteSnapshotToFile(snapshotPath))
+
+		snapshotBlobAccess, err := blobstore.NewBoltBlobAccess(
+			nil,
+			blobstore.CASReadBufferFactory,
+			digest.KeyWithoutInstance,
+			snapshotPath,
+			clock,
+			1<<20,
+			time.Hour,
+			0)
+		require.NoError(t, err)
+		defer snapshotBlobAccess.Close()
+
+		clock.EXPECT().Now().Return(time.Unix(1003, 0))
+		data, err := snapshotBlobAccess.Get(ctx, helloDigest).ToByteSlice(100)
+		require.NoError(t, err)
+		require.Equal(t, []byte("Hello"), data)
+
+		_, err = os.Stat(snapshotPath + ".tmp")
+		require.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("Restart", func(t *testing.T) {
+		// Objects should remain available after reopening the
+		// database.
+		require.NoError(t, blobAccess.Close())
+		blobAccess = newBlobAccess(t)
+
+		clock.EXPECT().Now().Return(time.Unix(1004, 0))
+		data, err := blobAccess.Get(ctx, helloDigest).ToByteSlice(100)
+		require.NoError(t, err)
+		require.Equal(t, []byte("Hello"), data)
+	})
+
+	t.Run("Expiration", func(t *testing.T) {
+		clock.EXPECT().Now().Return(time.Unix(1500, 0))
+		require.NoError(t, blobAccess.Put(ctx, worldDigest, buffer.NewValidatedBufferFromByteSlice([]byte("World"))))
+
+		// "Hello" was inserted at t=1000, meaning that it
+		// expires at t=4600.
+		clock.EXPECT().Now().Return(time.Unix(4601, 0))
+		_, err := blobAccess.Get(ctx, helloDigest).ToByteSlice(100)
+		testutil.RequireEqualStatus(t, status.Error(codes.NotFound, "Object has expired"), err)
+
+		clock.EXPECT().Now().Return(time.Unix(4601, 0))
+		missing, err := blobAccess.FindMissing(ctx, digest.NewSetBuilder().Add(helloDigest).Add(worldDigest).Build())
+		require.NoError(t, err)
+		require.Equal(t, helloDigest.ToSingletonSet(), missing)
+
+		clock.EXPECT().Now().Return(time.Unix(4601, 0))
+		removed, err := blobAccess.RemoveExpired(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1, removed)
+
+		clock.EXPECT().Now().Return(time.Unix(1000, 0))
+		_, err = blobAccess.Get(ctx, helloDigest).ToByteSlice(100)
+		testutil.RequireEqualStatus(t, status.Error(codes.NotFound, "Object not found"), err)
+	})
+
+	t.Run("Compact", func(t *testing.T) {
+		// Compaction should not be performed if the database
+		// contains little free space.
+		compacted, err := blobAccess.Compact()
+		require.NoError(t, err)
+		require.False(t, compacted)
+
+		// Insert and remove a large number of objects, so that
+		// the database mostly consists of free space. Objects
+		// are inserted concurrently, so that they are written
+		// in batches.
+		clock.EXPECT().Now().Return(time.Unix(2000, 0)).Times(1000)
+		putObjects(t, 0, 1000)
+		// Removal takes place in batches of 1000 objects,
+		// each of which obtains the current time.
+		clock.EXPECT().Now().Return(time.Unix(10000, 0)).Times(2)
+		removed, err := blobAccess.RemoveExpired(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1001, removed)
+
+		fileInfoBefore, err := os.Stat(databasePath)
+		require.NoError(t, err)
+		compacted, err = blobAccess.Compact()
+		require.NoError(t, err)
+		require.True(t, compacted)
+		fileInfoAfter, err := os.Stat(databasePath)
+		require.NoError(t, err)
+		require.Less(t, fileInfoAfter.Size(), fileInfoBefore.Size())
+
+		// The database should remain usable afterwards.
+		clock.EXPECT().Now().Return(time.Unix(10000, 0))
+		require.NoError(t, blobAccess.Put(ctx, helloDigest, buffer.NewValidatedBufferFromByteSlice([]byte("Hello"))))
+		clock.EXPECT().Now().Return(time.Unix(10001, 0))
+		data, err := blobAccess.Get(ctx, helloDigest).ToByteSlice(100)
+		require.NoError(t, err)
+		require.Equal(t, []byte("Hello"), data)
+	})
+
+	t.Run("PutDuringCompaction", func(t *testing.T) {
+		// Fill the database with objects that expire, so that
+		// compaction is performed.
+		clock.EXPECT().Now().Return(time.Unix(20000, 0)).Times(1000)
+		putObjects(t, 0, 1000)
+		clock.EXPECT().Now().Return(time.Unix(30000, 0)).Times(2)
+		removed, err := blobAccess.RemoveExpired(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1001, removed)
+
+		// Objects that are written while compaction is in
+		// progress should be retained.
+		clock.EXPECT().Now().Return(time.Unix(30000, 0)).Times(1000)
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			compacted, err := blobAccess.Compact()
+			require.NoError(t, err)
+			require.True(t, compacted)
+		}()
+		putObjects(t, 1000, 1000)
+		wg.Wait()
+
+		digests := digest.NewSetBuilder()
+		for i := 1000; i < 2000; i++ {
+			digests.Add(digest.MustNewDigest("hello", remoteexecution.DigestFunction_MD5, fmt.Sprintf("%032x", i), 1000))
+		}
+		clock.EXPECT().Now().Return(time.Unix(30001, 0))
+		missing, err := blobAccess.FindMissing(ctx, digests.Build())
+		require.NoError(t, err)
+		require.Equal(t, digest.EmptySet, missing)
+	})
+
+	t.Run("GetCorrupted", func(t *testing.T) {
+		// Objects with invalid contents should be removed.
+		clock.EXPECT().Now().Return(time.Unix(10000, 0))
+		require.NoError(t, blobAccess.Put(ctx, worldDigest, buffer.NewValidatedBufferFromByteSlice([]byte("Hallo"))))
+
+		clock.EXPECT().Now().Return(time.Unix(10001, 0))
+		_, err := blobAccess.Get(ctx, worldDigest).ToByteSlice(100)
+		testutil.RequireEqualStatus(t, status.Error(codes.Internal, "Buffer has checksum d1bf93299de1b68e6d382c893bf1215f, while f5a7924e621e84c9280a9a27e1bcb7f6 was expected"), err)
+
+		clock.EXPECT().Now().Return(time.Unix(10002, 0))
+		missing, err := blobAccess.FindMissing(ctx, worldDigest.ToSingletonSet())
+		require.NoError(t, err)
+		require.Equal(t, worldDigest.ToSingletonSet(), missing)
+	})
+
+	require.NoError(t, blobAccess.Close())
+}
@@ -0,0 +1,29 @@
+package blobstore
+
+import (
+	"io"
+
+	remoteexecution "github.com/bazelbuild/remote-apis/build/bazel/remote/execution/v2"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/buffer"
+	"github.com/buildbarn/bb-storage/pkg/digest"
+)
+
+type chunkManifestReadBufferFactory struct{}
+
+func (f chunkManifestReadBufferFactory) NewBufferFromByteSlice(digest digest.Digest, data []byte, dataIntegrityCallback buffer.DataIntegrityCallback) buffer.Buffer {
+	return buffer.NewProtoBufferFromByteSlice(&remoteexecution.SplitBlobResponse{}, data, buffer.BackendProvided(dataIntegrityCallback))
+}
+
+func (f chunkManifestReadBufferFactory) NewBufferFromReader(digest digest.Digest, r io.ReadCloser, dataIntegrityCallback buffer.DataIntegrityCallback) buffer.Buffer {
+	return buffer.NewProtoBufferFromReader(&remoteexecution.SplitBlobResponse{}, r, buffer.BackendProvided(dataIntegrityCallback))
+}
+
+func (f chunkManifestReadBufferFactory) NewBufferFromReaderAt(digest digest.Digest, r buffer.ReadAtCloser, sizeBytes int64, dataIntegrityCallback buffer.DataIntegrityCallback) buffer.Buffer {
+	return f.NewBufferFromReader(digest, newReaderFromReaderAt(r), dataIntegrityCallback)
+}
+
+// ChunkManifestReadBufferFactory is capable of creating identifiers
+// and buffers for chunk manifests of blobs that have been decomposed
+// using content-defined chunking. Manifests are stored in the form of
+// REv2 SplitBlobResponse messages.
+var ChunkManifestReadBufferFactory ReadBufferFactory = chunkManifestReadBufferFactory{}
@@ -0,0 +1,266 @@
+package chunking
+
+import (
+	"context"
+	"io"
+
+	remoteexecution "github.com/bazelbuild/remote-apis/build/bazel/remote/execution/v2"
+	"github.com/buildbarn/bb-storage/pkg/blobstore"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/buffer"
+	"github.com/buildbarn/bb-storage/pkg/digest"
+	"github.com/buildbarn/bb-storage/pkg/util"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// BlobSplitter is used by the Content Addressable Storage server to
+// implement the REv2 SplitBlob() and SpliceBlob() operations.
+type BlobSplitter interface {
+	// SplitBlob decomposes a blob stored in the Content
+	// Addressable Storage into chunks, ensuring that all of the
+	// chunks are present in the Content Addressable Storage as
+	// well. The digests of the chunks are returned in order.
+	SplitBlob(ctx context.Context, blobDigest digest.Digest) ([]digest.Digest, error)
+
+	// SpliceBlob concatenates a list of chunks stored in the
+	// Content Addressable Storage, and stores the resulting blob
+	// in the Content Addressable Storage, after validating that it
+	// matches the provided digest.
+	SpliceBlob(ctx context.Context, blobDigest digest.Digest, chunkDigests []digest.Digest) error
+}
+
+// pendingChunksMaximumSizeBytes is the maximum amount of chunk data
+// that SplitBlob() buffers, before checking for the existence of
+// chunks and uploading the ones that are missing.
+const pendingChunksMaximumSizeBytes = 16 * 1024 * 1024
+
+type chunkingBlobSplitter struct {
+	contentAddressableStorage blobstore.BlobAccess
+	chunker                   Chunker
+	manifestStorage           blobstore.BlobAccess
+	maximumManifestSizeBytes  int
+}
+
+// NewChunkingBlobSplitter creates a BlobSplitter that decomposes blobs
+// into chunks using a Chunker. The resulting chunk manifests are
+// written to a separate storage backend, keyed by the digest of the
+// blob, so that repeated calls to SplitBlob() for the same blob only
+// need to check for the existence of the chunks. Manifests are stored
+// in the form of SplitBlobResponse messages.
+func NewChunkingBlobSplitter(contentAddressableStorage blobstore.BlobAccess, chunker Chunker, manifestStorage blobstore.BlobAccess, maximumManifestSizeBytes int) BlobSplitter {
+	return &chunkingBlobSplitter{
+		contentAddressableStorage: contentAddressableStorage,
+		chunker:                   chunker,
+		manifestStorage:           manifestStorage,
+		maximumManifestSizeBytes:  maximumManifestSizeBytes,
+	}
+}
+
+// getStoredManifest returns the chunk manifest of a blob if it is
+// present in storage, and if both the blob and all of its chunks are
+// still present in the Content Addressable Storage. This also extends
+// the lifetime of the blob and its chunks.
+func (bs *chunkingBlobSplitter) getStoredManifest(ctx context.Context, blobDigest digest.Digest) ([]digest.Digest, bool, error) {
+	manifestMessage, err := bs.manifestStorage.Get(ctx, blobDigest).ToProto(&remoteexecution.SplitBlobResponse{}, bs.maximumManifestSizeBytes)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, false, nil
+		}
+		return nil, false, util.StatusWrap(err, "Failed to obtain chunk manifest")
+	}
+	manifest := manifestMessage.(*remoteexecution.SplitBlobResponse)
+
+	digestFunction := blobDigest.GetDigestFunction()
+	chunkDigests := make([]digest.Digest, 0, len(manifest.ChunkDigests))
+	digests := digest.NewSetBuilder().Add(blobDigest)
+	totalSizeBytes := int64(0)
+	for i, chunkDigestMessage := range manifest.ChunkDigests {
+		chunkDigest, err := digestFunction.NewDigestFromProto(chunkDigestMessage)
+		if err != nil {
+			return nil, false, util.StatusWrapfWithCode(err, codes.Internal, "Chunk manifest contains an invalid digest for chunk at index %d", i)
+		}
+		chunkDigests = append(chunkDigests, chunkDigest)
+		digests.Add(chunkDigest)
+		totalSizeBytes += chunkDigest.GetSizeBytes()
+	}
+	if totalSizeBytes != blobDigest.GetSizeBytes() {
+		return nil, false, status.Errorf(codes.Internal, "Chunks in chunk manifest have a total size of %d bytes, while the blob is %d bytes in size", totalSizeBytes, blobDigest.GetSizeBytes())
+	}
+
+	missing, err := bs.contentAddressableStorage.FindMissing(ctx, digests.Build())
+	if err != nil {
+		return nil, false, util.StatusWrap(err, "Failed to determine existence of blob and chunks")
+	}
+	if _, ok := missing.First(); ok {
+		return nil, false, nil
+	}
+	return chunkDigests, true, nil
+}
+
+// putManifest writes the chunk manifest of a blob into storage.
+func (bs *chunkingBlobSplitter) putManifest(ctx context.Context, blobDigest digest.Digest, chunkDigests []digest.Digest) error {
+	manifest := &remoteexecution.SplitBlobResponse{
+		ChunkDigests: make([]*remoteexecution.Digest, 0, len(chunkDigests)),
+	}
+	for _, chunkDigest := range chunkDigests {
+		manifest.ChunkDigests = append(manifest.ChunkDigests, chunkDigest.GetProto())
+	}
+	if err := bs.manifestStorage.Put(ctx, blobDigest, buffer.NewProtoBufferFromProto(manifest, buffer.UserProvided)); err != nil {
+		return util.StatusWrap(err, "Failed to store chunk manifest")
+	}
+	return nil
+}
+
+func (bs *chunkingBlobSplitter) SplitBlob(ctx context.Context, blobDigest digest.Digest) ([]digest.Digest, error) {
+	if chunkDigests, ok, err := bs.getStoredManifest(ctx, blobDigest); err != nil || ok {
+		return chunkDigests, err
+	}
+
+	// Read the blob and decompose it into chunks. Instead of
+	// uploading every chunk individually, buffer them and only
+	// upload the ones that are missing.
+	digestFunction := blobDigest.GetDigestFunction()
+	var chunkDigests []digest.Digest
+	var pendingDigests []digest.Digest
+	var pendingData [][]byte
+	pendingSizeBytes := 0
+	flush := func() error {
+		digests := digest.NewSetBuilder()
+		for _, pendingDigest := range pendingDigests {
+			digests.Add(pendingDigest)
+		}
+		missing, err := bs.contentAddressableStorage.FindMissing(ctx, digests.Build())
+		if err != nil {
+			return util.StatusWrap(err, "Failed to determine existence of chunks")
+		}
+		missingDigests := make(map[digest.Digest]struct{}, missing.Length())
+		for _, missingDigest := range missing.Items() {
+			missingDigests[missingDigest] = struct{}{}
+		}
+		for i, pendingDigest := range pendingDigests {
+			if _, ok := missingDigests[pendingDigest]; ok {
+				if err := bs.contentAddressableStorage.Put(ctx, pendingDigest, buffer.NewValidatedBufferFromByteSlice(pendingData[i])); err != nil {
+					return util.StatusWrapf(err, "Failed to store chunk %#v", pendingDigest.String())
+				}
+				// Prevent uploading the same chunk twice
+				// if it occurs multiple times.
+				delete(missingDigests, pendingDigest)
+			}
+		}
+		pendingDigests = pendingDigests[:0]
+		pendingData = pendingData[:0]
+		pendingSizeBytes = 0
+		return nil
+	}
+
+	r := bs.contentAddressableStorage.Get(ctx, blobDigest).ToReader()
+	defer r.Close()
+	if err := bs.chunker.Split(r, func(chunk []byte) error {
+		generator := digestFunction.NewGenerator(int64(len(chunk)))
+		if _, err := generator.Write(chunk); err != nil {
+			return err
+		}
+		chunkDigest := generator.Sum()
+		chunkDigests = append(chunkDigests, chunkDigest)
+
+		pendingDigests = append(pendingDigests, chunkDigest)
+		pendingData = append(pendingData, append([]byte(nil), chunk...))
+		pendingSizeBytes += len(chunk)
+		if pendingSizeBytes >= pendingChunksMaximumSizeBytes {
+			return flush()
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if len(pendingDigests) > 0 {
+		if err := flush(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := bs.putManifest(ctx, blobDigest, chunkDigests); err != nil {
+		return nil, err
+	}
+	return chunkDigests, nil
+}
+
+func (bs *chunkingBlobSplitter) SpliceBlob(ctx context.Context, blobDigest digest.Digest, chunkDigests []digest.Digest) error {
+	totalSizeBytes := int64(0)
+	for _, chunkDigest := range chunkDigests {
+		totalSizeBytes += chunkDigest.GetSizeBytes()
+	}
+	if totalSizeBytes != blobDigest.GetSizeBytes() {
+		return status.Errorf(codes.InvalidArgument, "Chunks have a total size of %d bytes, while the blob is %d bytes in size", totalSizeBytes, blobDigest.GetSizeBytes())
+	}
+
+	// There is no need to splice the blob if it is already present.
+	missing, err := bs.contentAddressableStorage.FindMissing(ctx, blobDigest.ToSingletonSet())
+	if err != nil {
+		return util.StatusWrap(err, "Failed to determine existence of blob")
+	}
+	if missing.Empty() {
+		return nil
+	}
+
+	// Concatenate all chunks and write them into the Content
+	// Addressable Storage. By using a CAS buffer, the resulting
+	// blob is validated against the provided digest.
+	if err := bs.contentAddressableStorage.Put(
+		ctx,
+		blobDigest,
+		buffer.NewCASBufferFromReader(
+			blobDigest,
+			&splicingReader{
+				context:                   ctx,
+				contentAddressableStorage: bs.contentAddressableStorage,
+				chunkDigests:              chunkDigests,
+			},
+			buffer.UserProvided)); err != nil {
+		return err
+	}
+
+	return bs.putManifest(ctx, blobDigest, chunkDigests)
+}
+
+// splicingReader is an io.ReadCloser that returns the concatenation of
+// a list of chunks stored in the Content Addressable Storage. Chunks
+// are only read from storage when needed.
+type splicingReader struct {
+	context                   context.Context
+	contentAddressableStorage blobstore.BlobAccess
+	chunkDigests              []digest.Digest
+	current                   io.ReadCloser
+}
+
+func (r *splicingReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if len(r.chunkDigests) == 0 {
+				return 0, io.EOF
+			}
+			r.current = r.contentAddressableStorage.Get(r.context, r.chunkDigests[0]).ToReader()
+			r.chunkDigests = r.chunkDigests[1:]
+		}
+		n, err := r.current.Read(p)
+		if err == io.EOF {
+			r.current.Close()
+			r.current = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (r *splicingReader) Close() error {
+	if r.current != nil {
+		r.current.Close()
+		r.current = nil
+	}
+	r.chunkDigests = nil
+	return nil
+}
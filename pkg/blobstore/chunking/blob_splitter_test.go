@@ -0,0 +1,254 @@
+package chunking_test
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	remoteexecution "github.com/bazelbuild/remote-apis/build/bazel/remote/execution/v2"
+	"github.com/buildbarn/bb-storage/internal/mock"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/buffer"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/chunking"
+	"github.com/buildbarn/bb-storage/pkg/digest"
+	"github.com/buildbarn/bb-storage/pkg/testutil"
+	"github.com/stretchr/testify/require"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestChunkingBlobSplitterSplitBlob(t *testing.T) {
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+
+	contentAddressableStorage := mock.NewMockBlobAccess(ctrl)
+	chunker := mock.NewMockChunker(ctrl)
+	manifestStorage := mock.NewMockBlobAccess(ctrl)
+	blobSplitter := chunking.NewChunkingBlobSplitter(
+		contentAddressableStorage,
+		chunker,
+		manifestStorage,
+		/* maximumManifestSizeBytes = */ 1000)
+
+	blobDigest := digest.MustNewDigest("example", remoteexecution.DigestFunction_MD5, "91db2d4279a42766759cfa87e9d633b4", 10)
+	chunkDigest := digest.MustNewDigest("example", remoteexecution.DigestFunction_MD5, "8b1a9953c4611296a827abf8c47804d7", 5)
+	manifest := &remoteexecution.SplitBlobResponse{
+		ChunkDigests: []*remoteexecution.Digest{
+			chunkDigest.GetProto(),
+			chunkDigest.GetProto(),
+		},
+	}
+
+	t.Run("ManifestStorageFailure", func(t *testing.T) {
+		manifestStorage.EXPECT().Get(ctx, blobDigest).
+			Return(buffer.NewBufferFromError(status.Error(codes.Unavailable, "Server offline")))
+
+		_, err := blobSplitter.SplitBlob(ctx, blobDigest)
+		testutil.RequireEqualStatus(t, status.Error(codes.Unavailable, "Failed to obtain chunk manifest: Server offline"), err)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		manifestStorage.EXPECT().Get(ctx, blobDigest).
+			Return(buffer.NewBufferFromError(status.Error(codes.NotFound, "Object not found")))
+		contentAddressableStorage.EXPECT().Get(ctx, blobDigest).
+			Return(buffer.NewBufferFromError(status.Error(codes.NotFound, "Object not found")))
+		chunker.EXPECT().Split(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(r io.Reader, handler func([]byte) error) error {
+				_, err := io.ReadAll(r)
+				return err
+			})
+
+		_, err := blobSplitter.SplitBlob(ctx, blobDigest)
+		testutil.RequireEqualStatus(t, status.Error(codes.NotFound, "Object not found"), err)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		// The blob consists of the same chunk twice. The chunk
+		// should only be uploaded once. The resulting manifest
+		// should be written to storage.
+		manifestStorage.EXPECT().Get(ctx, blobDigest).
+			Return(buffer.NewBufferFromError(status.Error(codes.NotFound, "Object not found")))
+		contentAddressableStorage.EXPECT().Get(ctx, blobDigest).
+			Return(buffer.NewValidatedBufferFromByteSlice([]byte("HelloHello")))
+		chunker.EXPECT().Split(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(r io.Reader, handler func([]byte) error) error {
+				data, err := io.ReadAll(r)
+				require.NoError(t, err)
+				require.NoError(t, handler(data[:5]))
+				return handler(data[5:])
+			})
+		contentAddressableStorage.EXPECT().FindMissing(ctx, chunkDigest.ToSingletonSet()).
+			Return(chunkDigest.ToSingletonSet(), nil)
+		contentAddressableStorage.EXPECT().Put(ctx, chunkDigest, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
+				data, err := b.ToByteSlice(100)
+				require.NoError(t, err)
+				require.Equal(t, []byte("Hello"), data)
+				return nil
+			})
+		manifestStorage.EXPECT().Put(ctx, blobDigest, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
+				m, err := b.ToProto(&remoteexecution.SplitBlobResponse{}, 1000)
+				require.NoError(t, err)
+				testutil.RequireEqualProto(t, manifest, m)
+				return nil
+			})
+
+		chunkDigests, err := blobSplitter.SplitBlob(ctx, blobDigest)
+		require.NoError(t, err)
+		require.Equal(t, []digest.Digest{chunkDigest, chunkDigest}, chunkDigests)
+	})
+
+	t.Run("StoredManifest", func(t *testing.T) {
+		// If a manifest is present in storage, it should be
+		// used, only checking for the existence of the blob
+		// and its chunks.
+		manifestStorage.EXPECT().Get(ctx, blobDigest).
+			Return(buffer.NewProtoBufferFromProto(manifest, buffer.UserProvided))
+		contentAddressableStorage.EXPECT().FindMissing(ctx, digest.NewSetBuilder().Add(blobDigest).Add(chunkDigest).Build()).
+			Return(digest.EmptySet, nil)
+
+		chunkDigests, err := blobSplitter.SplitBlob(ctx, blobDigest)
+		require.NoError(t, err)
+		require.Equal(t, []digest.Digest{chunkDigest, chunkDigest}, chunkDigests)
+	})
+
+	t.Run("StoredManifestInvalid", func(t *testing.T) {
+		manifestStorage.EXPECT().Get(ctx, blobDigest).
+			Return(buffer.NewProtoBufferFromProto(&remoteexecution.SplitBlobResponse{
+				ChunkDigests: []*remoteexecution.Digest{chunkDigest.GetProto()},
+			}, buffer.UserProvided))
+
+		_, err := blobSplitter.SplitBlob(ctx, blobDigest)
+		testutil.RequireEqualStatus(t, status.Error(codes.Internal, "Chunks in chunk manifest have a total size of 5 bytes, while the blob is 10 bytes in size"), err)
+	})
+
+	t.Run("StoredManifestStale", func(t *testing.T) {
+		// If the chunks have been evicted from storage, the
+		// blob must be chunked once more.
+		manifestStorage.EXPECT().Get(ctx, blobDigest).
+			Return(buffer.NewProtoBufferFromProto(manifest, buffer.UserProvided))
+		contentAddressableStorage.EXPECT().FindMissing(ctx, digest.NewSetBuilder().Add(blobDigest).Add(chunkDigest).Build()).
+			Return(chunkDigest.ToSingletonSet(), nil)
+		contentAddressableStorage.EXPECT().Get(ctx, blobDigest).
+			Return(buffer.NewValidatedBufferFromByteSlice([]byte("HelloHello")))
+		chunker.EXPECT().Split(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(r io.Reader, handler func([]byte) error) error {
+				data, err := io.ReadAll(r)
+				require.NoError(t, err)
+				require.NoError(t, handler(data[:5]))
+				return handler(data[5:])
+			})
+		contentAddressableStorage.EXPECT().FindMissing(ctx, chunkDigest.ToSingletonSet()).
+			Return(chunkDigest.ToSingletonSet(), nil)
+		contentAddressableStorage.EXPECT().Put(ctx, chunkDigest, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
+				b.Discard()
+				return nil
+			})
+		manifestStorage.EXPECT().Put(ctx, blobDigest, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
+				b.Discard()
+				return nil
+			})
+
+		chunkDigests, err := blobSplitter.SplitBlob(ctx, blobDigest)
+		require.NoError(t, err)
+		require.Equal(t, []digest.Digest{chunkDigest, chunkDigest}, chunkDigests)
+	})
+}
+
+func TestChunkingBlobSplitterSpliceBlob(t *testing.T) {
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+
+	contentAddressableStorage := mock.NewMockBlobAccess(ctrl)
+	manifestStorage := mock.NewMockBlobAccess(ctrl)
+	blobSplitter := chunking.NewChunkingBlobSplitter(
+		contentAddressableStorage,
+		mock.NewMockChunker(ctrl),
+		manifestStorage,
+		/* maximumManifestSizeBytes = */ 1000)
+
+	blobDigest := digest.MustNewDigest("example", remoteexecution.DigestFunction_MD5, "68e109f0f40ca72a15e05cc22786f8e6", 10)
+	chunkDigest1 := digest.MustNewDigest("example", remoteexecution.DigestFunction_MD5, "8b1a9953c4611296a827abf8c47804d7", 5)
+	chunkDigest2 := digest.MustNewDigest("example", remoteexecution.DigestFunction_MD5, "f5a7924e621e84c9280a9a27e1bcb7f6", 5)
+
+	t.Run("SizeMismatch", func(t *testing.T) {
+		err := blobSplitter.SpliceBlob(ctx, blobDigest, []digest.Digest{chunkDigest1})
+		testutil.RequireEqualStatus(t, status.Error(codes.InvalidArgument, "Chunks have a total size of 5 bytes, while the blob is 10 bytes in size"), err)
+	})
+
+	t.Run("AlreadyPresent", func(t *testing.T) {
+		contentAddressableStorage.EXPECT().FindMissing(ctx, blobDigest.ToSingletonSet()).
+			Return(digest.EmptySet, nil)
+
+		require.NoError(t, blobSplitter.SpliceBlob(ctx, blobDigest, []digest.Digest{chunkDigest1, chunkDigest2}))
+	})
+
+	t.Run("DigestMismatch", func(t *testing.T) {
+		// Chunks are provided in the wrong order, meaning the
+		// resulting blob does not match the expected digest.
+		contentAddressableStorage.EXPECT().FindMissing(ctx, blobDigest.ToSingletonSet()).
+			Return(blobDigest.ToSingletonSet(), nil)
+		contentAddressableStorage.EXPECT().Get(ctx, chunkDigest2).
+			Return(buffer.NewValidatedBufferFromByteSlice([]byte("World")))
+		contentAddressableStorage.EXPECT().Get(ctx, chunkDigest1).
+			Return(buffer.NewValidatedBufferFromByteSlice([]byte("Hello")))
+		contentAddressableStorage.EXPECT().Put(ctx, blobDigest, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
+				_, err := b.ToByteSlice(100)
+				return err
+			})
+
+		err := blobSplitter.SpliceBlob(ctx, blobDigest, []digest.Digest{chunkDigest2, chunkDigest1})
+		testutil.RequireEqualStatus(t, status.Error(codes.InvalidArgument, "Buffer has checksum aa1e70b237224fdeff0d04e86dec57ac, while 68e109f0f40ca72a15e05cc22786f8e6 was expected"), err)
+	})
+
+	t.Run("ChunkNotFound", func(t *testing.T) {
+		contentAddressableStorage.EXPECT().FindMissing(ctx, blobDigest.ToSingletonSet()).
+			Return(blobDigest.ToSingletonSet(), nil)
+		contentAddressableStorage.EXPECT().Get(ctx, chunkDigest1).
+			Return(buffer.NewValidatedBufferFromByteSlice([]byte("Hello")))
+		contentAddressableStorage.EXPECT().Get(ctx, chunkDigest2).
+			Return(buffer.NewBufferFromError(status.Error(codes.NotFound, "Object not found")))
+		contentAddressableStorage.EXPECT().Put(ctx, blobDigest, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
+				_, err := b.ToByteSlice(100)
+				return err
+			})
+
+		err := blobSplitter.SpliceBlob(ctx, blobDigest, []digest.Digest{chunkDigest1, chunkDigest2})
+		testutil.RequireEqualStatus(t, status.Error(codes.NotFound, "Object not found"), err)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		contentAddressableStorage.EXPECT().FindMissing(ctx, blobDigest.ToSingletonSet()).
+			Return(blobDigest.ToSingletonSet(), nil)
+		contentAddressableStorage.EXPECT().Get(ctx, chunkDigest1).
+			Return(buffer.NewValidatedBufferFromByteSlice([]byte("Hello")))
+		contentAddressableStorage.EXPECT().Get(ctx, chunkDigest2).
+			Return(buffer.NewValidatedBufferFromByteSlice([]byte("World")))
+		contentAddressableStorage.EXPECT().Put(ctx, blobDigest, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
+				data, err := b.ToByteSlice(100)
+				require.NoError(t, err)
+				require.Equal(t, []byte("HelloWorld"), data)
+				return nil
+			})
+		manifestStorage.EXPECT().Put(ctx, blobDigest, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
+				m, err := b.ToProto(&remoteexecution.SplitBlobResponse{}, 1000)
+				require.NoError(t, err)
+				testutil.RequireEqualProto(t, &remoteexecution.SplitBlobResponse{
+					ChunkDigests: []*remoteexecution.Digest{
+						chunkDigest1.GetProto(),
+						chunkDigest2.GetProto(),
+					},
+				}, m)
+				return nil
+			})
+
+		require.NoError(t, blobSplitter.SpliceBlob(ctx, blobDigest, []digest.Digest{chunkDigest1, chunkDigest2}))
+	})
+}
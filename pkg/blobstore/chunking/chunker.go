@@ -0,0 +1,17 @@
+package chunking
+
+import (
+	"io"
+)
+
+// Chunker is capable of decomposing a stream of data into smaller
+// chunks. Implementations are expected to be deterministic, meaning
+// that identical streams of data are always decomposed into identical
+// chunks.
+type Chunker interface {
+	// Split reads data from a reader until end-of-file is reached,
+	// and calls into a handler for every chunk of data that has
+	// been identified. The slice of data provided to the handler is
+	// only valid for the duration of the call.
+	Split(r io.Reader, handler func(chunk []byte) error) error
+}
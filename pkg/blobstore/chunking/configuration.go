@@ -0,0 +1,33 @@
+package chunking
+
+import (
+	"github.com/buildbarn/bb-storage/pkg/blobstore"
+	pb "github.com/buildbarn/bb-storage/pkg/proto/configuration/blobstore"
+	"github.com/buildbarn/bb-storage/pkg/util"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// NewBlobSplitterFromConfiguration creates a BlobSplitter that uses
+// content-defined chunking, based on parameters provided in a
+// configuration file.
+func NewBlobSplitterFromConfiguration(configuration *pb.ContentDefinedChunkingConfiguration, contentAddressableStorage, manifestStorage blobstore.BlobAccess, maximumMessageSizeBytes int) (BlobSplitter, error) {
+	// Clients may want to download chunks through
+	// BatchReadBlobs(), meaning chunks must fit in a single message.
+	if configuration.MaximumSizeBytes > int64(maximumMessageSizeBytes) {
+		return nil, status.Errorf(codes.InvalidArgument, "Maximum chunk size of %d bytes exceeds the maximum message size of %d bytes", configuration.MaximumSizeBytes, maximumMessageSizeBytes)
+	}
+	chunker, err := NewFastCDCChunker(
+		int(configuration.MinimumSizeBytes),
+		int(configuration.AverageSizeBytes),
+		int(configuration.MaximumSizeBytes))
+	if err != nil {
+		return nil, util.StatusWrap(err, "Failed to create chunker")
+	}
+	return NewChunkingBlobSplitter(
+		contentAddressableStorage,
+		chunker,
+		manifestStorage,
+		maximumMessageSizeBytes), nil
+}
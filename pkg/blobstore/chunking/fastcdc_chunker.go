@@ -0,0 +1,131 @@
+package chunking
+
+import (
+	"io"
+	"math/bits"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fastCDCGearTable contains the random values that are used by the
+// rolling hash function of FastCDC. The values are derived from a
+// SplitMix64 generator using a seed of zero, so that clients may
+// reproduce the same chunk boundaries.
+var fastCDCGearTable = func() (table [256]uint64) {
+	state := uint64(0)
+	for i := range table {
+		state += 0x9e3779b97f4a7c15
+		z := state
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		table[i] = z ^ (z >> 31)
+	}
+	return
+}()
+
+type fastCDCChunker struct {
+	minimumSizeBytes int
+	averageSizeBytes int
+	maximumSizeBytes int
+	maskSmall        uint64
+	maskLarge        uint64
+}
+
+// NewFastCDCChunker creates a Chunker that uses the FastCDC
+// content-defined chunking algorithm, as described in the paper
+// "FastCDC: a Fast and Efficient Content-Defined Chunking Approach for
+// Data Deduplication" by Xia et al. Normalized chunking (level 1) is
+// used to keep chunk sizes close to the average.
+//
+// As chunk boundaries depend on the contents of the data, inserting or
+// removing data in the middle of a blob only affects the chunks around
+// the location of the change. This makes it possible to deduplicate
+// large blobs that only differ slightly.
+func NewFastCDCChunker(minimumSizeBytes, averageSizeBytes, maximumSizeBytes int) (Chunker, error) {
+	if minimumSizeBytes <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "Minimum chunk size must be positive")
+	}
+	if averageSizeBytes <= minimumSizeBytes || averageSizeBytes >= maximumSizeBytes {
+		return nil, status.Error(codes.InvalidArgument, "Average chunk size must lie between the minimum and maximum chunk size")
+	}
+	if averageSizeBytes&(averageSizeBytes-1) != 0 {
+		return nil, status.Error(codes.InvalidArgument, "Average chunk size must be a power of two")
+	}
+	averageBits := bits.TrailingZeros(uint(averageSizeBytes))
+	return &fastCDCChunker{
+		minimumSizeBytes: minimumSizeBytes,
+		averageSizeBytes: averageSizeBytes,
+		maximumSizeBytes: maximumSizeBytes,
+		// Prior to reaching the average chunk size, a mask with
+		// more bits set is used to make cut points less likely.
+		// After reaching the average chunk size, a mask with
+		// fewer bits set is used. Bits are taken from the top of
+		// the hash, as those are affected by the most input.
+		maskSmall: ^uint64(0) << (64 - averageBits - 1),
+		maskLarge: ^uint64(0) << (64 - averageBits + 1),
+	}, nil
+}
+
+// getCutPoint returns the size of the first chunk that is contained
+// in a buffer of data.
+func (c *fastCDCChunker) getCutPoint(data []byte) int {
+	n := len(data)
+	if n <= c.minimumSizeBytes {
+		return n
+	}
+	if n > c.maximumSizeBytes {
+		n = c.maximumSizeBytes
+	}
+	normalSize := c.averageSizeBytes
+	if normalSize > n {
+		normalSize = n
+	}
+
+	hash := uint64(0)
+	i := c.minimumSizeBytes
+	for ; i < normalSize; i++ {
+		hash = (hash << 1) + fastCDCGearTable[data[i]]
+		if hash&c.maskSmall == 0 {
+			return i + 1
+		}
+	}
+	for ; i < n; i++ {
+		hash = (hash << 1) + fastCDCGearTable[data[i]]
+		if hash&c.maskLarge == 0 {
+			return i + 1
+		}
+	}
+	return n
+}
+
+func (c *fastCDCChunker) Split(r io.Reader, handler func(chunk []byte) error) error {
+	buf := make([]byte, 2*c.maximumSizeBytes)
+	start, end := 0, 0
+	atEOF := false
+	for {
+		// Ensure that at least one chunk of maximum size is
+		// present in the buffer, so that cut points don't depend
+		// on the sizes of reads.
+		if !atEOF && end-start < c.maximumSizeBytes {
+			end = copy(buf, buf[start:end])
+			start = 0
+			n, err := io.ReadFull(r, buf[end:])
+			end += n
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				atEOF = true
+			} else if err != nil {
+				return err
+			}
+		}
+		if start == end {
+			return nil
+		}
+
+		cutPoint := c.getCutPoint(buf[start:end])
+		if err := handler(buf[start : start+cutPoint]); err != nil {
+			return err
+		}
+		start += cutPoint
+	}
+}
@@ -0,0 +1,97 @@
+package chunking_test
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+	"testing/iotest"
+
+	"github.com/buildbarn/bb-storage/pkg/blobstore/chunking"
+	"github.com/buildbarn/bb-storage/pkg/testutil"
+	"github.com/stretchr/testify/require"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func splitAll(t *testing.T, chunker chunking.Chunker, data []byte, oneByteReads bool) [][]byte {
+	r := bytes.NewReader(data)
+	var chunks [][]byte
+	handler := func(chunk []byte) error {
+		chunks = append(chunks, append([]byte(nil), chunk...))
+		return nil
+	}
+	if oneByteReads {
+		require.NoError(t, chunker.Split(iotest.OneByteReader(r), handler))
+	} else {
+		require.NoError(t, chunker.Split(r, handler))
+	}
+	return chunks
+}
+
+func TestFastCDCChunker(t *testing.T) {
+	t.Run("InvalidParameters", func(t *testing.T) {
+		_, err := chunking.NewFastCDCChunker(0, 4096, 16384)
+		testutil.RequireEqualStatus(t, status.Error(codes.InvalidArgument, "Minimum chunk size must be positive"), err)
+
+		_, err = chunking.NewFastCDCChunker(1024, 32768, 16384)
+		testutil.RequireEqualStatus(t, status.Error(codes.InvalidArgument, "Average chunk size must lie between the minimum and maximum chunk size"), err)
+
+		_, err = chunking.NewFastCDCChunker(1024, 5000, 16384)
+		testutil.RequireEqualStatus(t, status.Error(codes.InvalidArgument, "Average chunk size must be a power of two"), err)
+	})
+
+	chunker, err := chunking.NewFastCDCChunker(1024, 4096, 16384)
+	require.NoError(t, err)
+
+	t.Run("Empty", func(t *testing.T) {
+		require.Empty(t, splitAll(t, chunker, nil, false))
+	})
+
+	t.Run("Small", func(t *testing.T) {
+		// Blobs below the minimum chunk size should be returned
+		// as a single chunk.
+		require.Equal(t, [][]byte{[]byte("Hello")}, splitAll(t, chunker, []byte("Hello"), false))
+	})
+
+	data := make([]byte, 1<<20)
+	rand.New(rand.NewSource(42)).Read(data)
+
+	t.Run("Bounds", func(t *testing.T) {
+		// All chunks except the last one must respect the
+		// minimum and maximum chunk size. Concatenating all
+		// chunks should yield the original data.
+		chunks := splitAll(t, chunker, data, false)
+		require.Greater(t, len(chunks), 1)
+		for _, chunk := range chunks[:len(chunks)-1] {
+			require.GreaterOrEqual(t, len(chunk), 1024)
+			require.LessOrEqual(t, len(chunk), 16384)
+		}
+		require.Equal(t, data, bytes.Join(chunks, nil))
+	})
+
+	t.Run("IndependentOfReadSizes", func(t *testing.T) {
+		// Cut points may not depend on how data is returned by
+		// the underlying reader.
+		require.Equal(t, splitAll(t, chunker, data, false), splitAll(t, chunker, data, true))
+	})
+
+	t.Run("ShiftResistance", func(t *testing.T) {
+		// Inserting data at the start of the blob should only
+		// affect the first few chunks. The vast majority of
+		// chunks should remain identical.
+		original := splitAll(t, chunker, data, false)
+		modified := splitAll(t, chunker, append([]byte("Some leading data"), data...), false)
+		originalChunks := map[string]struct{}{}
+		for _, chunk := range original {
+			originalChunks[string(chunk)] = struct{}{}
+		}
+		shared := 0
+		for _, chunk := range modified {
+			if _, ok := originalChunks[string(chunk)]; ok {
+				shared++
+			}
+		}
+		require.Greater(t, shared, len(original)-3)
+	})
+}
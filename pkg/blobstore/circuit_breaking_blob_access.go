@@ -0,0 +1,282 @@
+package blobstore
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	remoteexecution "github.com/bazelbuild/remote-apis/build/bazel/remote/execution/v2"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/buffer"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/slicing"
+	"github.com/buildbarn/bb-storage/pkg/clock"
+	"github.com/buildbarn/bb-storage/pkg/digest"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	circuitBreakingBlobAccessPrometheusMetrics sync.Once
+
+	circuitBreakingBlobAccessState = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "buildbarn",
+			Subsystem: "blobstore",
+			Name:      "circuit_breaking_blob_access_state",
+			Help:      "State of the circuit breaker, where 0 means closed, 1 means half-open and 2 means open",
+		},
+		[]string{"storage_type", "name"})
+
+	circuitBreakerNamesLock sync.Mutex
+	circuitBreakerNames     = map[circuitBreakerName]struct{}{}
+)
+
+type circuitBreakerName struct {
+	storageType string
+	name        string
+}
+
+// RegisterCircuitBreakerName claims the name of a circuit breaker for a
+// given storage type. As the name is used as a label for the metric
+// exposing the state of the circuit breaker, it must be non-empty and
+// unique. Otherwise the states of multiple circuit breakers would be
+// reported through the same metric.
+func RegisterCircuitBreakerName(storageType, name string) error {
+	if name == "" {
+		return status.Error(codes.InvalidArgument, "Circuit breaker name must be non-empty")
+	}
+
+	circuitBreakerNamesLock.Lock()
+	defer circuitBreakerNamesLock.Unlock()
+
+	key := circuitBreakerName{storageType: storageType, name: name}
+	if _, ok := circuitBreakerNames[key]; ok {
+		return status.Errorf(codes.AlreadyExists, "A circuit breaker with name %#v has already been registered for storage type %#v", name, storageType)
+	}
+	circuitBreakerNames[key] = struct{}{}
+	return nil
+}
+
+type circuitBreakerState int
+
+const (
+	// Requests are forwarded to the backend.
+	circuitBreakerStateClosed circuitBreakerState = iota
+	// A single request is forwarded to the backend to determine
+	// whether it has recovered.
+	circuitBreakerStateHalfOpen
+	// Requests fail immediately.
+	circuitBreakerStateOpen
+)
+
+type circuitBreakingBlobAccess struct {
+	backend             BlobAccess
+	clock               clock.Clock
+	failureThreshold    int
+	cooldown            time.Duration
+	degradedFindMissing bool
+	stateGauge          prometheus.Gauge
+
+	lock                sync.Mutex
+	state               circuitBreakerState
+	consecutiveFailures int
+	openUntil           time.Time
+	probeInFlight       bool
+}
+
+// NewCircuitBreakingBlobAccess creates a decorator for BlobAccess that
+// stops forwarding requests to a backend after it returned
+// UNAVAILABLE or DEADLINE_EXCEEDED for a number of consecutive
+// requests. While the circuit breaker is open, requests fail
+// immediately with UNAVAILABLE. This prevents callers from having to
+// wait for connection attempts against backends that are known to be
+// offline.
+//
+// If degradedFindMissing is set, FindMissing() reports all objects as
+// missing while the circuit breaker is open, instead of failing. Calls
+// to Put() still fail while the circuit breaker is open, meaning that
+// clients are only capable of uploading these objects if they are
+// also written to other backends.
+//
+// Requests that fail while the caller's context is cancelled or has
+// exceeded its deadline do not affect the state of the circuit
+// breaker, as such failures say nothing about the health of the
+// backend.
+//
+// After the cooldown period has passed, the circuit breaker becomes
+// half-open, meaning a single request is forwarded to the backend. If
+// it succeeds, the circuit breaker closes. Otherwise it reopens.
+func NewCircuitBreakingBlobAccess(backend BlobAccess, clock clock.Clock, failureThreshold int, cooldown time.Duration, degradedFindMissing bool, storageType, name string) BlobAccess {
+	circuitBreakingBlobAccessPrometheusMetrics.Do(func() {
+		prometheus.MustRegister(circuitBreakingBlobAccessState)
+	})
+
+	stateGauge := circuitBreakingBlobAccessState.WithLabelValues(storageType, name)
+	stateGauge.Set(float64(circuitBreakerStateClosed))
+	return &circuitBreakingBlobAccess{
+		backend:             backend,
+		clock:               clock,
+		failureThreshold:    failureThreshold,
+		cooldown:            cooldown,
+		degradedFindMissing: degradedFindMissing,
+		stateGauge:          stateGauge,
+	}
+}
+
+func (ba *circuitBreakingBlobAccess) setState(state circuitBreakerState) {
+	ba.state = state
+	ba.stateGauge.Set(float64(state))
+}
+
+// startRequest determines whether a request may be forwarded to the
+// backend. It returns whether the request is used to probe whether the
+// backend has recovered.
+func (ba *circuitBreakingBlobAccess) startRequest() (bool, error) {
+	ba.lock.Lock()
+	defer ba.lock.Unlock()
+
+	switch ba.state {
+	case circuitBreakerStateClosed:
+		return false, nil
+	case circuitBreakerStateOpen:
+		if ba.clock.Now().Before(ba.openUntil) {
+			return false, status.Error(codes.Unavailable, "Circuit breaker is open, as the backend failed recently")
+		}
+		ba.setState(circuitBreakerStateHalfOpen)
+	}
+
+	// Only allow a single request to probe the backend.
+	if ba.probeInFlight {
+		return false, status.Error(codes.Unavailable, "Circuit breaker is half-open, and is already probing the backend")
+	}
+	ba.probeInFlight = true
+	return true, nil
+}
+
+// finishRequest updates the state of the circuit breaker after a
+// request forwarded to the backend completes.
+func (ba *circuitBreakingBlobAccess) finishRequest(ctx context.Context, isProbe bool, err error) {
+	code := status.Code(err)
+	failed := code == codes.Unavailable || code == codes.DeadlineExceeded
+	// Errors caused by the caller cancelling the request or by the
+	// caller's deadline expiring should not be attributed to the
+	// backend.
+	ignored := err != nil && ctx.Err() != nil
+
+	ba.lock.Lock()
+	defer ba.lock.Unlock()
+
+	if ignored {
+		// If the request was used to probe the backend,
+		// permit another request to do so.
+		if isProbe {
+			ba.probeInFlight = false
+		}
+		return
+	}
+	if isProbe {
+		ba.probeInFlight = false
+		if failed {
+			ba.open()
+		} else {
+			ba.consecutiveFailures = 0
+			ba.setState(circuitBreakerStateClosed)
+		}
+	} else if ba.state == circuitBreakerStateClosed {
+		if failed {
+			ba.consecutiveFailures++
+			if ba.consecutiveFailures >= ba.failureThreshold {
+				ba.open()
+			}
+		} else {
+			ba.consecutiveFailures = 0
+		}
+	}
+}
+
+func (ba *circuitBreakingBlobAccess) open() {
+	ba.openUntil = ba.clock.Now().Add(ba.cooldown)
+	ba.setState(circuitBreakerStateOpen)
+}
+
+func (ba *circuitBreakingBlobAccess) Get(ctx context.Context, digest digest.Digest) buffer.Buffer {
+	isProbe, err := ba.startRequest()
+	if err != nil {
+		return buffer.NewBufferFromError(err)
+	}
+	return buffer.WithErrorHandler(
+		ba.backend.Get(ctx, digest),
+		&circuitBreakingErrorHandler{
+			blobAccess: ba,
+			ctx:        ctx,
+			isProbe:    isProbe,
+		})
+}
+
+func (ba *circuitBreakingBlobAccess) GetFromComposite(ctx context.Context, parentDigest, childDigest digest.Digest, slicer slicing.BlobSlicer) buffer.Buffer {
+	isProbe, err := ba.startRequest()
+	if err != nil {
+		return buffer.NewBufferFromError(err)
+	}
+	return buffer.WithErrorHandler(
+		ba.backend.GetFromComposite(ctx, parentDigest, childDigest, slicer),
+		&circuitBreakingErrorHandler{
+			blobAccess: ba,
+			ctx:        ctx,
+			isProbe:    isProbe,
+		})
+}
+
+func (ba *circuitBreakingBlobAccess) Put(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
+	isProbe, err := ba.startRequest()
+	if err != nil {
+		b.Discard()
+		return err
+	}
+	err = ba.backend.Put(ctx, digest, b)
+	ba.finishRequest(ctx, isProbe, err)
+	return err
+}
+
+func (ba *circuitBreakingBlobAccess) FindMissing(ctx context.Context, digests digest.Set) (digest.Set, error) {
+	isProbe, err := ba.startRequest()
+	if err != nil {
+		if ba.degradedFindMissing {
+			return digests, nil
+		}
+		return digest.EmptySet, err
+	}
+	missing, err := ba.backend.FindMissing(ctx, digests)
+	ba.finishRequest(ctx, isProbe, err)
+	return missing, err
+}
+
+func (ba *circuitBreakingBlobAccess) GetCapabilities(ctx context.Context, instanceName digest.InstanceName) (*remoteexecution.ServerCapabilities, error) {
+	isProbe, err := ba.startRequest()
+	if err != nil {
+		return nil, err
+	}
+	capabilities, err := ba.backend.GetCapabilities(ctx, instanceName)
+	ba.finishRequest(ctx, isProbe, err)
+	return capabilities, err
+}
+
+// circuitBreakingErrorHandler is used by CircuitBreakingBlobAccess to
+// update the state of the circuit breaker once a buffer returned by
+// Get() or GetFromComposite() has been consumed.
+type circuitBreakingErrorHandler struct {
+	blobAccess *circuitBreakingBlobAccess
+	ctx        context.Context
+	isProbe    bool
+	err        error
+}
+
+func (eh *circuitBreakingErrorHandler) OnError(err error) (buffer.Buffer, error) {
+	eh.err = err
+	return nil, err
+}
+
+func (eh *circuitBreakingErrorHandler) Done() {
+	eh.blobAccess.finishRequest(eh.ctx, eh.isProbe, eh.err)
+}
@@ -0,0 +1,184 @@
+package blobstore_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	remoteexecution "github.com/bazelbuild/remote-apis/build/bazel/remote/execution/v2"
+	"github.com/buildbarn/bb-storage/internal/mock"
+	"github.com/buildbarn/bb-storage/pkg/blobstore"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/buffer"
+	"github.com/buildbarn/bb-storage/pkg/digest"
+	"github.com/buildbarn/bb-storage/pkg/testutil"
+	"github.com/stretchr/testify/require"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestCircuitBreakingBlobAccess(t *testing.T) {
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+
+	baseBlobAccess := mock.NewMockBlobAccess(ctrl)
+	clock := mock.NewMockClock(ctrl)
+	blobAccess := blobstore.NewCircuitBreakingBlobAccess(baseBlobAccess, clock, 2, 30*time.Second, false, "cas", "shard0")
+
+	helloDigest := digest.MustNewDigest("example", remoteexecution.DigestFunction_MD5, "8b1a9953c4611296a827abf8c47804d7", 5)
+	digests := helloDigest.ToSingletonSet()
+
+	t.Run("NonConsecutiveFailures", func(t *testing.T) {
+		// Successful requests should reset the number of
+		// consecutive failures. Errors other than UNAVAILABLE
+		// and DEADLINE_EXCEEDED should be treated as successes.
+		baseBlobAccess.EXPECT().FindMissing(ctx, digests).Return(digest.EmptySet, status.Error(codes.Unavailable, "Server offline"))
+		baseBlobAccess.EXPECT().Get(ctx, helloDigest).
+			Return(buffer.NewBufferFromError(status.Error(codes.NotFound, "Object not found")))
+		baseBlobAccess.EXPECT().FindMissing(ctx, digests).Return(digest.EmptySet, status.Error(codes.DeadlineExceeded, "Connection timed out"))
+
+		_, err := blobAccess.FindMissing(ctx, digests)
+		testutil.RequireEqualStatus(t, status.Error(codes.Unavailable, "Server offline"), err)
+		_, err = blobAccess.Get(ctx, helloDigest).ToByteSlice(100)
+		testutil.RequireEqualStatus(t, status.Error(codes.NotFound, "Object not found"), err)
+		_, err = blobAccess.FindMissing(ctx, digests)
+		testutil.RequireEqualStatus(t, status.Error(codes.DeadlineExceeded, "Connection timed out"), err)
+	})
+
+	t.Run("CallerDeadlineExceeded", func(t *testing.T) {
+		// Failures caused by the caller's own deadline expiring
+		// should not cause the circuit breaker to open.
+		deadlineCtx, cancel := context.WithDeadline(ctx, time.Unix(0, 0))
+		defer cancel()
+		baseBlobAccess.EXPECT().FindMissing(deadlineCtx, digests).Return(digest.EmptySet, status.Error(codes.DeadlineExceeded, "Context deadline exceeded"))
+		baseBlobAccess.EXPECT().Get(deadlineCtx, helloDigest).
+			Return(buffer.NewBufferFromError(status.Error(codes.DeadlineExceeded, "Context deadline exceeded")))
+
+		_, err := blobAccess.FindMissing(deadlineCtx, digests)
+		testutil.RequireEqualStatus(t, status.Error(codes.DeadlineExceeded, "Context deadline exceeded"), err)
+		_, err = blobAccess.Get(deadlineCtx, helloDigest).ToByteSlice(100)
+		testutil.RequireEqualStatus(t, status.Error(codes.DeadlineExceeded, "Context deadline exceeded"), err)
+	})
+
+	t.Run("Trip", func(t *testing.T) {
+		// The second consecutive failure should cause the
+		// circuit breaker to open.
+		baseBlobAccess.EXPECT().Get(ctx, helloDigest).
+			Return(buffer.NewBufferFromError(status.Error(codes.Unavailable, "Server offline")))
+		clock.EXPECT().Now().Return(time.Unix(1000, 0))
+
+		_, err := blobAccess.Get(ctx, helloDigest).ToByteSlice(100)
+		testutil.RequireEqualStatus(t, status.Error(codes.Unavailable, "Server offline"), err)
+	})
+
+	t.Run("Open", func(t *testing.T) {
+		// While open, requests should fail immediately.
+		clock.EXPECT().Now().Return(time.Unix(1010, 0)).Times(3)
+
+		_, err := blobAccess.Get(ctx, helloDigest).ToByteSlice(100)
+		testutil.RequireEqualStatus(t, status.Error(codes.Unavailable, "Circuit breaker is open, as the backend failed recently"), err)
+		_, err = blobAccess.FindMissing(ctx, digests)
+		testutil.RequireEqualStatus(t, status.Error(codes.Unavailable, "Circuit breaker is open, as the backend failed recently"), err)
+		testutil.RequireEqualStatus(
+			t,
+			status.Error(codes.Unavailable, "Circuit breaker is open, as the backend failed recently"),
+			blobAccess.Put(ctx, helloDigest, buffer.NewValidatedBufferFromByteSlice([]byte("Hello"))))
+	})
+
+	t.Run("HalfOpenFailure", func(t *testing.T) {
+		// After the cooldown period, a single request should be
+		// forwarded to probe the backend. Other requests should
+		// still fail while the probe is in flight. If the probe
+		// fails, the circuit breaker should reopen.
+		clock.EXPECT().Now().Return(time.Unix(1030, 0))
+		baseBlobAccess.EXPECT().FindMissing(ctx, digests).DoAndReturn(
+			func(ctx context.Context, digests digest.Set) (digest.Set, error) {
+				_, err := blobAccess.FindMissing(ctx, digests)
+				testutil.RequireEqualStatus(t, status.Error(codes.Unavailable, "Circuit breaker is half-open, and is already probing the backend"), err)
+				return digest.EmptySet, status.Error(codes.Unavailable, "Server offline")
+			})
+		clock.EXPECT().Now().Return(time.Unix(1030, 0))
+
+		_, err := blobAccess.FindMissing(ctx, digests)
+		testutil.RequireEqualStatus(t, status.Error(codes.Unavailable, "Server offline"), err)
+
+		clock.EXPECT().Now().Return(time.Unix(1059, 0))
+		_, err = blobAccess.FindMissing(ctx, digests)
+		testutil.RequireEqualStatus(t, status.Error(codes.Unavailable, "Circuit breaker is open, as the backend failed recently"), err)
+	})
+
+	t.Run("HalfOpenCancelled", func(t *testing.T) {
+		// If the probe is cancelled by the caller, the circuit
+		// breaker should remain half-open, permitting another
+		// request to probe the backend.
+		cancelledCtx, cancel := context.WithCancel(ctx)
+		cancel()
+		clock.EXPECT().Now().Return(time.Unix(1060, 0))
+		baseBlobAccess.EXPECT().FindMissing(cancelledCtx, digests).Return(digest.EmptySet, status.Error(codes.Canceled, "Request cancelled"))
+
+		_, err := blobAccess.FindMissing(cancelledCtx, digests)
+		testutil.RequireEqualStatus(t, status.Error(codes.Canceled, "Request cancelled"), err)
+	})
+
+	t.Run("HalfOpenSuccess", func(t *testing.T) {
+		// If the probe succeeds, the circuit breaker should
+		// close.
+		baseBlobAccess.EXPECT().FindMissing(ctx, digests).Return(digests, nil)
+		baseBlobAccess.EXPECT().Put(ctx, helloDigest, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
+				data, err := b.ToByteSlice(100)
+				require.NoError(t, err)
+				require.Equal(t, []byte("Hello"), data)
+				return nil
+			})
+
+		missing, err := blobAccess.FindMissing(ctx, digests)
+		require.NoError(t, err)
+		require.Equal(t, digests, missing)
+		require.NoError(t, blobAccess.Put(ctx, helloDigest, buffer.NewValidatedBufferFromByteSlice([]byte("Hello"))))
+	})
+}
+
+func TestCircuitBreakingBlobAccessDegradedFindMissing(t *testing.T) {
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+
+	baseBlobAccess := mock.NewMockBlobAccess(ctrl)
+	clock := mock.NewMockClock(ctrl)
+	blobAccess := blobstore.NewCircuitBreakingBlobAccess(baseBlobAccess, clock, 1, 30*time.Second, true, "cas", "shard1")
+
+	helloDigest := digest.MustNewDigest("example", remoteexecution.DigestFunction_MD5, "8b1a9953c4611296a827abf8c47804d7", 5)
+	digests := helloDigest.ToSingletonSet()
+
+	baseBlobAccess.EXPECT().FindMissing(ctx, digests).Return(digest.EmptySet, status.Error(codes.Unavailable, "Server offline"))
+	clock.EXPECT().Now().Return(time.Unix(1000, 0))
+	_, err := blobAccess.FindMissing(ctx, digests)
+	testutil.RequireEqualStatus(t, status.Error(codes.Unavailable, "Server offline"), err)
+
+	// While the circuit breaker is open, all objects should be
+	// reported as missing.
+	clock.EXPECT().Now().Return(time.Unix(1010, 0))
+	missing, err := blobAccess.FindMissing(ctx, digests)
+	require.NoError(t, err)
+	require.Equal(t, digests, missing)
+}
+
+func TestRegisterCircuitBreakerName(t *testing.T) {
+	t.Run("Empty", func(t *testing.T) {
+		testutil.RequireEqualStatus(
+			t,
+			status.Error(codes.InvalidArgument, "Circuit breaker name must be non-empty"),
+			blobstore.RegisterCircuitBreakerName("cas", ""))
+	})
+
+	t.Run("Duplicate", func(t *testing.T) {
+		// Names only need to be unique per storage type, as
+		// the storage type is also used as a label.
+		require.NoError(t, blobstore.RegisterCircuitBreakerName("cas", "register-test"))
+		require.NoError(t, blobstore.RegisterCircuitBreakerName("ac", "register-test"))
+		testutil.RequireEqualStatus(
+			t,
+			status.Error(codes.AlreadyExists, "A circuit breaker with name \"register-test\" has already been registered for storage type \"cas\""),
+			blobstore.RegisterCircuitBreakerName("cas", "register-test"))
+	})
+}
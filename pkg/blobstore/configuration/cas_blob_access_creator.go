@@ -8,6 +8,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	remoteexecution "github.com/bazelbuild/remote-apis/build/bazel/remote/execution/v2"
 	"github.com/buildbarn/bb-storage/pkg/blobstore"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/erasurecoding"
 	"github.com/buildbarn/bb-storage/pkg/blobstore/grpcclients"
 	"github.com/buildbarn/bb-storage/pkg/blobstore/local"
 	"github.com/buildbarn/bb-storage/pkg/capabilities"
@@ -96,9 +97,23 @@ func (bac *casBlobAccessCreator) NewCustomBlobAccess(terminationGroup program.Gr
 		// TODO: Should we provide a configuration option, so
 		// that digest.KeyWithoutInstance can be used?
 		return BlobAccessInfo{
-			BlobAccess:      grpcclients.NewCASBlobAccess(client, uuid.NewRandom, 65536),
+			BlobAccess:      grpcclients.NewCASBlobAccess(client, uuid.NewRandom, 65536, remoteexecution.Compressor_IDENTITY),
 			DigestKeyFormat: digest.KeyWithInstance,
 		}, "grpc", nil
+	case *pb.BlobAccessConfiguration_CompressedGrpc:
+		switch compressor := backend.CompressedGrpc.Compressor; compressor {
+		case remoteexecution.Compressor_DEFLATE, remoteexecution.Compressor_ZSTD:
+		default:
+			return BlobAccessInfo{}, "", status.Errorf(codes.InvalidArgument, "Unsupported compressor: %s", compressor)
+		}
+		client, err := bac.grpcClientFactory.NewClientFromConfiguration(backend.CompressedGrpc.Client, terminationGroup)
+		if err != nil {
+			return BlobAccessInfo{}, "", err
+		}
+		return BlobAccessInfo{
+			BlobAccess:      grpcclients.NewCASBlobAccess(client, uuid.NewRandom, 65536, backend.CompressedGrpc.Compressor),
+			DigestKeyFormat: digest.KeyWithInstance,
+		}, "compressed_grpc", nil
 	case *pb.BlobAccessConfiguration_ReferenceExpanding:
 		// The backend used by ReferenceExpandingBlobAccess is
 		// an Indirect Content Addressable Storage (ICAS). This
@@ -161,6 +176,56 @@ func (bac *casBlobAccessCreator) NewCustomBlobAccess(terminationGroup program.Gr
 				bac.maximumMessageSizeBytes),
 			DigestKeyFormat: indirectContentAddressableStorage.DigestKeyFormat,
 		}, "reference_expanding", nil
+	case *pb.BlobAccessConfiguration_ErasureCoding:
+		if backend.ErasureCoding.MaximumObjectSizeBytes <= 0 {
+			return BlobAccessInfo{}, "", status.Error(codes.InvalidArgument, "Maximum object size must be positive")
+		}
+		if backend.ErasureCoding.RepairQueueSize < 0 {
+			return BlobAccessInfo{}, "", status.Error(codes.InvalidArgument, "Repair queue size cannot be negative")
+		}
+
+		// Backends of ErasureCodingBlobAccess store fragments
+		// of objects, which don't match their digests.
+		fragmentCreator := &casFragmentBlobAccessCreator{}
+		digestKeyFormat := digest.KeyWithoutInstance
+		newBackends := func(configurations []*pb.BlobAccessConfiguration) ([]blobstore.BlobAccess, error) {
+			backends := make([]blobstore.BlobAccess, 0, len(configurations))
+			for _, backendConfiguration := range configurations {
+				info, err := nestedCreator.NewNestedBlobAccess(backendConfiguration, fragmentCreator)
+				if err != nil {
+					return nil, err
+				}
+				backends = append(backends, info.BlobAccess)
+				digestKeyFormat = digestKeyFormat.Combine(info.DigestKeyFormat)
+			}
+			return backends, nil
+		}
+		dataBackends, err := newBackends(backend.ErasureCoding.DataBackends)
+		if err != nil {
+			return BlobAccessInfo{}, "", err
+		}
+		parityBackends, err := newBackends(backend.ErasureCoding.ParityBackends)
+		if err != nil {
+			return BlobAccessInfo{}, "", err
+		}
+		blobAccess, err := erasurecoding.NewErasureCodingBlobAccess(
+			dataBackends,
+			parityBackends,
+			int(backend.ErasureCoding.MaximumObjectSizeBytes),
+			int(backend.ErasureCoding.RepairQueueSize),
+			util.DefaultErrorLogger)
+		if err != nil {
+			return BlobAccessInfo{}, "", err
+		}
+		terminationGroup.Go(func(ctx context.Context, siblingsGroup, dependenciesGroup program.Group) error {
+			for blobAccess.ProcessRepair(ctx) {
+			}
+			return nil
+		})
+		return BlobAccessInfo{
+			BlobAccess:      blobAccess,
+			DigestKeyFormat: digestKeyFormat,
+		}, "erasure_coding", nil
 	default:
 		return BlobAccessInfo{}, "", status.Error(codes.InvalidArgument, "Configuration did not contain a supported storage backend")
 	}
@@ -0,0 +1,63 @@
+package configuration
+
+import (
+	"sync"
+
+	"github.com/buildbarn/bb-storage/pkg/blobstore"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/erasurecoding"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/local"
+	"github.com/buildbarn/bb-storage/pkg/capabilities"
+	"github.com/buildbarn/bb-storage/pkg/digest"
+	"github.com/buildbarn/bb-storage/pkg/program"
+	pb "github.com/buildbarn/bb-storage/pkg/proto/configuration/blobstore"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// casFragmentBlobAccessCreator is used to create the backends of
+// ErasureCodingBlobAccess. These backends store fragments of objects
+// in the Content Addressable Storage under the digest of the original
+// object. As the contents of fragments don't match their digests,
+// these backends may not validate the data they return, and cannot
+// forward objects to remote services that do.
+type casFragmentBlobAccessCreator struct {
+	protoBlobReplicatorCreator
+}
+
+func (bac *casFragmentBlobAccessCreator) GetBaseDigestKeyFormat() digest.KeyFormat {
+	return digest.KeyWithoutInstance
+}
+
+func (bac *casFragmentBlobAccessCreator) GetReadBufferFactory() blobstore.ReadBufferFactory {
+	return erasurecoding.FragmentReadBufferFactory
+}
+
+func (bac *casFragmentBlobAccessCreator) GetDefaultCapabilitiesProvider() capabilities.Provider {
+	return casCapabilitiesProvider
+}
+
+func (bac *casFragmentBlobAccessCreator) GetStorageTypeName() string {
+	return "cas_fragment"
+}
+
+func (bac *casFragmentBlobAccessCreator) NewBlockListGrowthPolicy(currentBlocks, newBlocks int) (local.BlockListGrowthPolicy, error) {
+	return local.NewImmutableBlockListGrowthPolicy(currentBlocks, newBlocks), nil
+}
+
+func (bac *casFragmentBlobAccessCreator) NewHierarchicalInstanceNamesLocalBlobAccess(keyLocationMap local.KeyLocationMap, locationBlobMap local.LocationBlobMap, globalLock *sync.RWMutex) (blobstore.BlobAccess, error) {
+	return nil, status.Error(codes.InvalidArgument, "The hierarchical instance names option cannot be used to store fragments of objects")
+}
+
+func (bac *casFragmentBlobAccessCreator) NewCustomBlobAccess(terminationGroup program.Group, configuration *pb.BlobAccessConfiguration, nestedCreator NestedBlobAccessCreator) (BlobAccessInfo, string, error) {
+	switch configuration.Backend.(type) {
+	case *pb.BlobAccessConfiguration_Grpc, *pb.BlobAccessConfiguration_CompressedGrpc:
+		return BlobAccessInfo{}, "", status.Error(codes.InvalidArgument, "Fragments of objects cannot be stored on remote servers, as these validate objects against their digests")
+	default:
+		return newProtoCustomBlobAccess(configuration, nestedCreator, bac)
+	}
+}
+
+func (bac *casFragmentBlobAccessCreator) WrapTopLevelBlobAccess(blobAccess blobstore.BlobAccess) blobstore.BlobAccess {
+	return blobAccess
+}
@@ -0,0 +1,45 @@
+package configuration
+
+import (
+	"github.com/buildbarn/bb-storage/pkg/blobstore"
+	"github.com/buildbarn/bb-storage/pkg/capabilities"
+	"github.com/buildbarn/bb-storage/pkg/program"
+	pb "github.com/buildbarn/bb-storage/pkg/proto/configuration/blobstore"
+)
+
+type chunkManifestBlobAccessCreator struct {
+	protoBlobAccessCreator
+	protoBlobReplicatorCreator
+}
+
+// NewChunkManifestBlobAccessCreator creates a BlobAccessCreator that
+// can be provided to NewBlobAccessFromConfiguration() to construct a
+// BlobAccess that is suitable for storing chunk manifests of blobs
+// that have been decomposed using content-defined chunking.
+//
+// As there is no gRPC service for accessing chunk manifests, they
+// can only be shared between instances of bb_storage by using storage
+// backends such as Redis or S3.
+func NewChunkManifestBlobAccessCreator() BlobAccessCreator {
+	return &chunkManifestBlobAccessCreator{}
+}
+
+func (bac *chunkManifestBlobAccessCreator) GetReadBufferFactory() blobstore.ReadBufferFactory {
+	return blobstore.ChunkManifestReadBufferFactory
+}
+
+func (bac *chunkManifestBlobAccessCreator) GetStorageTypeName() string {
+	return "chunk_manifest"
+}
+
+func (bac *chunkManifestBlobAccessCreator) GetDefaultCapabilitiesProvider() capabilities.Provider {
+	return nil
+}
+
+func (bac *chunkManifestBlobAccessCreator) NewCustomBlobAccess(terminationGroup program.Group, configuration *pb.BlobAccessConfiguration, nestedCreator NestedBlobAccessCreator) (BlobAccessInfo, string, error) {
+	return newProtoCustomBlobAccess(configuration, nestedCreator, bac)
+}
+
+func (bac *chunkManifestBlobAccessCreator) WrapTopLevelBlobAccess(blobAccess blobstore.BlobAccess) blobstore.BlobAccess {
+	return blobAccess
+}
@@ -3,31 +3,46 @@ package configuration
 import (
 	"archive/zip"
 	"context"
+	"net/http"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
+	"cloud.google.com/go/storage"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/buildbarn/bb-storage/pkg/blobstore"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/hedging"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/latencyaware"
 	"github.com/buildbarn/bb-storage/pkg/blobstore/local"
 	"github.com/buildbarn/bb-storage/pkg/blobstore/mirrored"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/quota"
 	"github.com/buildbarn/bb-storage/pkg/blobstore/readcaching"
 	"github.com/buildbarn/bb-storage/pkg/blobstore/readfallback"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/replicated"
 	"github.com/buildbarn/bb-storage/pkg/blobstore/sharding"
 	"github.com/buildbarn/bb-storage/pkg/blobstore/sharding/legacy"
 	"github.com/buildbarn/bb-storage/pkg/blockdevice"
 	"github.com/buildbarn/bb-storage/pkg/clock"
+	cloud_aws "github.com/buildbarn/bb-storage/pkg/cloud/aws"
+	"github.com/buildbarn/bb-storage/pkg/cloud/gcp"
 	"github.com/buildbarn/bb-storage/pkg/digest"
 	"github.com/buildbarn/bb-storage/pkg/eviction"
 	"github.com/buildbarn/bb-storage/pkg/filesystem"
 	"github.com/buildbarn/bb-storage/pkg/filesystem/path"
 	"github.com/buildbarn/bb-storage/pkg/grpc"
+	http_client "github.com/buildbarn/bb-storage/pkg/http/client"
+	"github.com/buildbarn/bb-storage/pkg/jmespath"
 	"github.com/buildbarn/bb-storage/pkg/program"
 	pb "github.com/buildbarn/bb-storage/pkg/proto/configuration/blobstore"
 	digest_pb "github.com/buildbarn/bb-storage/pkg/proto/configuration/digest"
 	"github.com/buildbarn/bb-storage/pkg/random"
 	"github.com/buildbarn/bb-storage/pkg/util"
 	"github.com/fxtlabs/primes"
+	"github.com/redis/go-redis/v9"
 
+	"golang.org/x/sync/semaphore"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -87,6 +102,9 @@ func (nc *simpleNestedBlobAccessCreator) newNestedBlobAccessBare(configuration *
 		}, "read_caching", nil
 	case *pb.BlobAccessConfiguration_Sharding:
 		if backend.Sharding.Legacy != nil {
+			if backend.Sharding.ReplicationFactor > 1 {
+				return BlobAccessInfo{}, "", status.Error(codes.InvalidArgument, "Replication is not supported when running in Legacy mode")
+			}
 			backends := make([]blobstore.BlobAccess, 0, len(backend.Sharding.Legacy.ShardOrder))
 			weights := make([]uint32, 0, len(backend.Sharding.Legacy.ShardOrder))
 			var combinedDigestKeyFormat *digest.KeyFormat
@@ -163,6 +181,9 @@ func (nc *simpleNestedBlobAccessCreator) newNestedBlobAccessBare(configuration *
 		if combinedDigestKeyFormat == nil {
 			return BlobAccessInfo{}, "", status.Errorf(codes.InvalidArgument, "Cannot create sharding blob access without any backends")
 		}
+		if int(backend.Sharding.ReplicationFactor) > len(shards) {
+			return BlobAccessInfo{}, "", status.Errorf(codes.InvalidArgument, "Replication factor %d exceeds the number of shards", backend.Sharding.ReplicationFactor)
+		}
 		shardSelector, err := sharding.NewRendezvousShardSelector(shards)
 		if err != nil {
 			return BlobAccessInfo{}, "", status.Errorf(codes.InvalidArgument, "Could not create rendezvous shard selector")
@@ -171,6 +192,8 @@ func (nc *simpleNestedBlobAccessCreator) newNestedBlobAccessBare(configuration *
 			BlobAccess: sharding.NewShardingBlobAccess(
 				backends,
 				shardSelector,
+				int(backend.Sharding.ReplicationFactor),
+				backend.Sharding.ReadRepair,
 			),
 			DigestKeyFormat: *combinedDigestKeyFormat,
 		}, "sharding", nil
@@ -195,6 +218,124 @@ func (nc *simpleNestedBlobAccessCreator) newNestedBlobAccessBare(configuration *
 			BlobAccess:      mirrored.NewMirroredBlobAccess(backendA.BlobAccess, backendB.BlobAccess, replicatorAToB, replicatorBToA),
 			DigestKeyFormat: backendA.DigestKeyFormat.Combine(backendB.DigestKeyFormat),
 		}, "mirrored", nil
+	case *pb.BlobAccessConfiguration_Replicated:
+		backends := make([]BlobAccessInfo, 0, len(backend.Replicated.Replicas))
+		blobAccesses := make([]blobstore.BlobAccess, 0, len(backend.Replicated.Replicas))
+		for _, replicaConfiguration := range backend.Replicated.Replicas {
+			replicaBackend, err := nc.NewNestedBlobAccess(replicaConfiguration.Backend, creator)
+			if err != nil {
+				return BlobAccessInfo{}, "", err
+			}
+			backends = append(backends, replicaBackend)
+			blobAccesses = append(blobAccesses, replicaBackend.BlobAccess)
+		}
+
+		// Objects are copied into replicas by reading them from
+		// all other replicas.
+		replicas := make([]replicated.Replica, 0, len(backends))
+		combinedDigestKeyFormat := digest.KeyWithoutInstance
+		for i, replicaBackend := range backends {
+			replicator, err := NewBlobReplicatorFromConfiguration(nc.terminationGroup, backend.Replicated.Replicas[i].Replicator, replicated.NewPeerBlobAccess(blobAccesses, i), replicaBackend, creator)
+			if err != nil {
+				return BlobAccessInfo{}, "", util.StatusWrapf(err, "Replica %d", i)
+			}
+			replicas = append(replicas, replicated.Replica{
+				Backend:    replicaBackend.BlobAccess,
+				Replicator: replicator,
+			})
+			combinedDigestKeyFormat = combinedDigestKeyFormat.Combine(replicaBackend.DigestKeyFormat)
+		}
+		blobAccess, err := replicated.NewReplicatedBlobAccess(replicas, int(backend.Replicated.WriteQuorum), int(backend.Replicated.ReadQuorum), util.DefaultErrorLogger)
+		if err != nil {
+			return BlobAccessInfo{}, "", err
+		}
+		return BlobAccessInfo{
+			BlobAccess:      blobAccess,
+			DigestKeyFormat: combinedDigestKeyFormat,
+		}, "replicated", nil
+	case *pb.BlobAccessConfiguration_Hedging:
+		config := backend.Hedging
+		if len(config.Backends) < 2 {
+			return BlobAccessInfo{}, "", status.Error(codes.InvalidArgument, "Hedging requires at least two backends")
+		}
+		if config.DelayPercentile <= 0 || config.DelayPercentile > 1 {
+			return BlobAccessInfo{}, "", status.Error(codes.InvalidArgument, "Delay percentile must be in range (0, 1]")
+		}
+		if config.LatencyWindowSize == 0 {
+			return BlobAccessInfo{}, "", status.Error(codes.InvalidArgument, "Latency window size must be positive")
+		}
+		if err := config.MinimumDelay.CheckValid(); err != nil {
+			return BlobAccessInfo{}, "", util.StatusWrapWithCode(err, codes.InvalidArgument, "Invalid minimum delay")
+		}
+		if err := config.MaximumDelay.CheckValid(); err != nil {
+			return BlobAccessInfo{}, "", util.StatusWrapWithCode(err, codes.InvalidArgument, "Invalid maximum delay")
+		}
+		minimumDelay, maximumDelay := config.MinimumDelay.AsDuration(), config.MaximumDelay.AsDuration()
+		if maximumDelay <= 0 {
+			return BlobAccessInfo{}, "", status.Error(codes.InvalidArgument, "Maximum delay must be positive")
+		}
+		if minimumDelay > maximumDelay {
+			return BlobAccessInfo{}, "", status.Error(codes.InvalidArgument, "Minimum delay exceeds maximum delay")
+		}
+
+		backends := make([]blobstore.BlobAccess, 0, len(config.Backends))
+		combinedDigestKeyFormat := digest.KeyWithoutInstance
+		for i, backendConfiguration := range config.Backends {
+			hedgingBackend, err := nc.NewNestedBlobAccess(backendConfiguration, creator)
+			if err != nil {
+				return BlobAccessInfo{}, "", util.StatusWrapf(err, "Backend %d", i)
+			}
+			backends = append(backends, hedgingBackend.BlobAccess)
+			combinedDigestKeyFormat = combinedDigestKeyFormat.Combine(hedgingBackend.DigestKeyFormat)
+		}
+		newDelayEstimator := func() hedging.DelayEstimator {
+			return hedging.NewPercentileDelayEstimator(config.DelayPercentile, int(config.LatencyWindowSize), minimumDelay, maximumDelay)
+		}
+		return BlobAccessInfo{
+			BlobAccess: hedging.NewHedgingBlobAccess(
+				backends,
+				readBufferFactory,
+				clock.SystemClock,
+				newDelayEstimator(),
+				newDelayEstimator()),
+			DigestKeyFormat: combinedDigestKeyFormat,
+		}, "hedging", nil
+	case *pb.BlobAccessConfiguration_LatencyAware:
+		config := backend.LatencyAware
+		if len(config.Backends) < 2 {
+			return BlobAccessInfo{}, "", status.Error(codes.InvalidArgument, "Latency aware replica selection requires at least two backends")
+		}
+		if config.SmoothingFactor <= 0 || config.SmoothingFactor > 1 {
+			return BlobAccessInfo{}, "", status.Error(codes.InvalidArgument, "Smoothing factor must be in range (0, 1]")
+		}
+		if config.MaximumErrorRate < 0 || config.MaximumErrorRate > 1 {
+			return BlobAccessInfo{}, "", status.Error(codes.InvalidArgument, "Maximum error rate must be in range [0, 1]")
+		}
+		if config.ProbeProbability < 0 || config.ProbeProbability >= 1 {
+			return BlobAccessInfo{}, "", status.Error(codes.InvalidArgument, "Probe probability must be in range [0, 1)")
+		}
+
+		backends := make([]blobstore.BlobAccess, 0, len(config.Backends))
+		combinedDigestKeyFormat := digest.KeyWithoutInstance
+		for i, backendConfiguration := range config.Backends {
+			replicaBackend, err := nc.NewNestedBlobAccess(backendConfiguration, creator)
+			if err != nil {
+				return BlobAccessInfo{}, "", util.StatusWrapf(err, "Backend %d", i)
+			}
+			backends = append(backends, replicaBackend.BlobAccess)
+			combinedDigestKeyFormat = combinedDigestKeyFormat.Combine(replicaBackend.DigestKeyFormat)
+		}
+		return BlobAccessInfo{
+			BlobAccess: latencyaware.NewLatencyAwareBlobAccess(
+				backends,
+				readBufferFactory,
+				clock.SystemClock,
+				random.FastThreadSafeGenerator,
+				config.SmoothingFactor,
+				config.MaximumErrorRate,
+				config.ProbeProbability),
+			DigestKeyFormat: combinedDigestKeyFormat,
+		}, "latency_aware", nil
 	case *pb.BlobAccessConfiguration_Local:
 		digestKeyFormat := digest.KeyWithInstance
 		if !backend.Local.HierarchicalInstanceNames {
@@ -557,6 +698,306 @@ func (nc *simpleNestedBlobAccessCreator) newNestedBlobAccessBare(configuration *
 			BlobAccess:      blobAccess,
 			DigestKeyFormat: digestKeyFormat,
 		}, "zip_writing", nil
+	case *pb.BlobAccessConfiguration_S3:
+		config := backend.S3
+		if config.MultipartUploadPartSizeBytes < 5*1024*1024 {
+			return BlobAccessInfo{}, "", status.Error(codes.InvalidArgument, "Multipart upload part size must be at least 5 MiB")
+		}
+		if config.FindMissingConcurrency <= 0 {
+			return BlobAccessInfo{}, "", status.Error(codes.InvalidArgument, "FindMissing() concurrency must be positive")
+		}
+		sliceEvictionSet, err := eviction.NewSetFromConfiguration[string](config.SliceCacheReplacementPolicy)
+		if err != nil {
+			return BlobAccessInfo{}, "", util.StatusWrap(err, "Failed to create slice cache replacement policy")
+		}
+		awsConfig, err := cloud_aws.NewConfigFromConfiguration(config.AwsSession, "S3BlobAccess")
+		if err != nil {
+			return BlobAccessInfo{}, "", util.StatusWrap(err, "Failed to create AWS config")
+		}
+		s3Client := s3.NewFromConfig(awsConfig, func(o *s3.Options) {
+			if config.EndpointUrl != "" {
+				o.BaseEndpoint = aws.String(config.EndpointUrl)
+			}
+			o.UsePathStyle = config.UsePathStyle
+		})
+
+		digestKeyFormat := creator.GetBaseDigestKeyFormat()
+		return BlobAccessInfo{
+			BlobAccess: blobstore.NewS3BlobAccess(
+				creator.GetDefaultCapabilitiesProvider(),
+				readBufferFactory,
+				digestKeyFormat,
+				s3Client,
+				config.Bucket,
+				config.KeyPrefix,
+				config.MultipartUploadPartSizeBytes,
+				int(config.FindMissingConcurrency),
+				int(config.SliceCacheSize),
+				eviction.NewMetricsSet(sliceEvictionSet, "S3BlobAccess")),
+			DigestKeyFormat: digestKeyFormat,
+		}, "s3", nil
+	case *pb.BlobAccessConfiguration_Gcs:
+		config := backend.Gcs
+		if config.ResumableUploadChunkSizeBytes < 0 {
+			return BlobAccessInfo{}, "", status.Error(codes.InvalidArgument, "Resumable upload chunk size cannot be negative")
+		}
+		if err := config.CustomTimeRefreshInterval.CheckValid(); err != nil {
+			return BlobAccessInfo{}, "", util.StatusWrapWithCode(err, codes.InvalidArgument, "Failed to parse custom time refresh interval")
+		}
+		if config.FindMissingConcurrency <= 0 {
+			return BlobAccessInfo{}, "", status.Error(codes.InvalidArgument, "FindMissing() concurrency must be positive")
+		}
+		clientOptions, err := gcp.NewClientOptionsFromConfiguration(config.ClientOptions, "GCSBlobAccess")
+		if err != nil {
+			return BlobAccessInfo{}, "", util.StatusWrap(err, "Failed to create GCP client options")
+		}
+		client, err := storage.NewClient(context.Background(), clientOptions...)
+		if err != nil {
+			return BlobAccessInfo{}, "", util.StatusWrap(err, "Failed to create GCS client")
+		}
+
+		digestKeyFormat := creator.GetBaseDigestKeyFormat()
+		return BlobAccessInfo{
+			BlobAccess: blobstore.NewGCSBlobAccess(
+				creator.GetDefaultCapabilitiesProvider(),
+				readBufferFactory,
+				digestKeyFormat,
+				gcp.NewWrappedStorageClient(client).Bucket(config.Bucket),
+				config.ObjectPrefix,
+				clock.SystemClock,
+				int(config.ResumableUploadChunkSizeBytes),
+				config.CustomTimeRefreshInterval.AsDuration(),
+				int(config.FindMissingConcurrency)),
+			DigestKeyFormat: digestKeyFormat,
+		}, "gcs", nil
+	case *pb.BlobAccessConfiguration_Directory:
+		config := backend.Directory
+		if config.MaximumSizeBytes <= 0 {
+			return BlobAccessInfo{}, "", status.Error(codes.InvalidArgument, "Maximum size must be positive")
+		}
+		evictionSet, err := eviction.NewSetFromConfiguration[string](config.CacheReplacementPolicy)
+		if err != nil {
+			return BlobAccessInfo{}, "", util.StatusWrap(err, "Failed to create eviction set")
+		}
+		root, err := os.OpenRoot(config.Path)
+		if err != nil {
+			return BlobAccessInfo{}, "", util.StatusWrapfWithCode(err, codes.Internal, "Failed to open directory %#v", config.Path)
+		}
+
+		digestKeyFormat := creator.GetBaseDigestKeyFormat()
+		blobAccess, err := blobstore.NewDirectoryBlobAccess(
+			creator.GetDefaultCapabilitiesProvider(),
+			readBufferFactory,
+			digestKeyFormat,
+			root,
+			clock.SystemClock,
+			util.DefaultErrorLogger,
+			eviction.NewMetricsSet(evictionSet, "DirectoryBlobAccess"),
+			config.MaximumSizeBytes)
+		if err != nil {
+			root.Close()
+			return BlobAccessInfo{}, "", util.StatusWrapf(err, "Failed to load contents of directory %#v", config.Path)
+		}
+		nc.terminationGroup.Go(func(ctx context.Context, siblingsGroup, dependenciesGroup program.Group) error {
+			for blobAccess.ProcessEvictions(ctx) {
+			}
+			return nil
+		})
+		return BlobAccessInfo{
+			BlobAccess:      blobAccess,
+			DigestKeyFormat: digestKeyFormat,
+		}, "directory", nil
+	case *pb.BlobAccessConfiguration_Redis:
+		config := backend.Redis
+		if len(config.Addresses) == 0 {
+			return BlobAccessInfo{}, "", status.Error(codes.InvalidArgument, "No server addresses provided")
+		}
+		if config.MaximumObjectSizeBytes <= 0 {
+			return BlobAccessInfo{}, "", status.Error(codes.InvalidArgument, "Maximum object size must be positive")
+		}
+		var expiration time.Duration
+		if config.Expiration != nil {
+			if err := config.Expiration.CheckValid(); err != nil {
+				return BlobAccessInfo{}, "", util.StatusWrapWithCode(err, codes.InvalidArgument, "Failed to parse expiration")
+			}
+			expiration = config.Expiration.AsDuration()
+		}
+		tlsConfig, err := util.NewTLSConfigFromClientConfiguration(config.Tls)
+		if err != nil {
+			return BlobAccessInfo{}, "", util.StatusWrap(err, "Failed to create TLS configuration")
+		}
+		client := redis.NewUniversalClient(&redis.UniversalOptions{
+			Addrs:         config.Addresses,
+			IsClusterMode: config.ClusterMode,
+			Username:      config.Username,
+			Password:      config.Password,
+			DB:            int(config.Database),
+			TLSConfig:     tlsConfig,
+		})
+
+		digestKeyFormat := creator.GetBaseDigestKeyFormat()
+		return BlobAccessInfo{
+			BlobAccess: blobstore.NewRedisBlobAccess(
+				creator.GetDefaultCapabilitiesProvider(),
+				client,
+				readBufferFactory,
+				digestKeyFormat,
+				config.KeyPrefix,
+				expiration,
+				int(config.MaximumObjectSizeBytes)),
+			DigestKeyFormat: digestKeyFormat,
+		}, "redis", nil
+	case *pb.BlobAccessConfiguration_Http:
+		config := backend.Http
+		storageTypeName := creator.GetStorageTypeName()
+		if storageTypeName != "ac" && storageTypeName != "cas" {
+			return BlobAccessInfo{}, "", status.Errorf(codes.InvalidArgument, "The HTTP caching protocol does not support storage type %#v", storageTypeName)
+		}
+		if config.FindMissingConcurrency <= 0 {
+			return BlobAccessInfo{}, "", status.Error(codes.InvalidArgument, "FindMissing() concurrency must be positive")
+		}
+		roundTripper, err := http_client.NewRoundTripperFromConfiguration(config.HttpClient)
+		if err != nil {
+			return BlobAccessInfo{}, "", util.StatusWrap(err, "Failed to create HTTP client")
+		}
+
+		return BlobAccessInfo{
+			BlobAccess: blobstore.NewHTTPBlobAccess(
+				creator.GetDefaultCapabilitiesProvider(),
+				readBufferFactory,
+				&http.Client{
+					Transport: http_client.NewMetricsRoundTripper(roundTripper, "HTTPBlobAccess"),
+				},
+				strings.TrimSuffix(config.Address, "/"),
+				storageTypeName,
+				int(config.FindMissingConcurrency)),
+			DigestKeyFormat: creator.GetBaseDigestKeyFormat(),
+		}, "http", nil
+	case *pb.BlobAccessConfiguration_Bolt:
+		config := backend.Bolt
+		if storageTypeName := creator.GetStorageTypeName(); storageTypeName == "cas" {
+			return BlobAccessInfo{}, "", status.Error(codes.InvalidArgument, "The bolt backend cannot be used to store the Content Addressable Storage")
+		}
+		if config.MaximumObjectSizeBytes <= 0 {
+			return BlobAccessInfo{}, "", status.Error(codes.InvalidArgument, "Maximum object size must be positive")
+		}
+		var minimumValidity, maximumValidityJitter time.Duration
+		if config.MinimumValidity != nil {
+			if err := config.MinimumValidity.CheckValid(); err != nil {
+				return BlobAccessInfo{}, "", util.StatusWrapWithCode(err, codes.InvalidArgument, "Invalid minimum validity")
+			}
+			minimumValidity = config.MinimumValidity.AsDuration()
+		}
+		if config.MaximumValidityJitter != nil {
+			if err := config.MaximumValidityJitter.CheckValid(); err != nil {
+				return BlobAccessInfo{}, "", util.StatusWrapWithCode(err, codes.InvalidArgument, "Invalid maximum validity jitter")
+			}
+			maximumValidityJitter = config.MaximumValidityJitter.AsDuration()
+		}
+		var maintenanceInterval, snapshotInterval time.Duration
+		if config.MaintenanceInterval != nil {
+			if err := config.MaintenanceInterval.CheckValid(); err != nil {
+				return BlobAccessInfo{}, "", util.StatusWrapWithCode(err, codes.InvalidArgument, "Invalid maintenance interval")
+			}
+			maintenanceInterval = config.MaintenanceInterval.AsDuration()
+			if maintenanceInterval <= 0 {
+				return BlobAccessInfo{}, "", status.Error(codes.InvalidArgument, "Maintenance interval must be positive")
+			}
+		}
+		if config.SnapshotPath != "" {
+			if err := config.SnapshotInterval.CheckValid(); err != nil {
+				return BlobAccessInfo{}, "", util.StatusWrapWithCode(err, codes.InvalidArgument, "Invalid snapshot interval")
+			}
+			snapshotInterval = config.SnapshotInterval.AsDuration()
+			if snapshotInterval <= 0 {
+				return BlobAccessInfo{}, "", status.Error(codes.InvalidArgument, "Snapshot interval must be positive")
+			}
+		}
+
+		digestKeyFormat := creator.GetBaseDigestKeyFormat()
+		blobAccess, err := blobstore.NewBoltBlobAccess(
+			creator.GetDefaultCapabilitiesProvider(),
+			readBufferFactory,
+			digestKeyFormat,
+			config.Path,
+			clock.SystemClock,
+			int(config.MaximumObjectSizeBytes),
+			minimumValidity,
+			maximumValidityJitter)
+		if err != nil {
+			return BlobAccessInfo{}, "", err
+		}
+		nc.terminationGroup.Go(func(ctx context.Context, siblingsGroup, dependenciesGroup program.Group) error {
+			// Periodically remove expired objects, compact the
+			// database and write snapshots. Channels of
+			// features that are disabled remain nil.
+			var maintenanceChannel, snapshotChannel <-chan time.Time
+			if maintenanceInterval > 0 {
+				maintenanceTicker, channel := clock.SystemClock.NewTicker(maintenanceInterval)
+				defer maintenanceTicker.Stop()
+				maintenanceChannel = channel
+			}
+			if snapshotInterval > 0 {
+				snapshotTicker, channel := clock.SystemClock.NewTicker(snapshotInterval)
+				defer snapshotTicker.Stop()
+				snapshotChannel = channel
+			}
+			for {
+				select {
+				case <-ctx.Done():
+					return blobAccess.Close()
+				case <-maintenanceChannel:
+					if _, err := blobAccess.RemoveExpired(ctx); err != nil {
+						util.DefaultErrorLogger.Log(util.StatusWrapf(err, "Failed to remove expired objects from database %#v", config.Path))
+					} else if _, err := blobAccess.Compact(); err != nil {
+						util.DefaultErrorLogger.Log(util.StatusWrapf(err, "Failed to compact database %#v", config.Path))
+					}
+				case <-snapshotChannel:
+					if err := blobAccess.WriteSnapshotToFile(config.SnapshotPath); err != nil {
+						util.DefaultErrorLogger.Log(err)
+					}
+				}
+			}
+		})
+		return BlobAccessInfo{
+			BlobAccess:      blobAccess,
+			DigestKeyFormat: digestKeyFormat,
+		}, "bolt", nil
+	case *pb.BlobAccessConfiguration_Oci:
+		config := backend.Oci
+		if storageTypeName := creator.GetStorageTypeName(); storageTypeName != "cas" {
+			return BlobAccessInfo{}, "", status.Error(codes.InvalidArgument, "The OCI backend can only be used to store the Content Addressable Storage")
+		}
+		if config.ChunkSizeBytes <= 0 {
+			return BlobAccessInfo{}, "", status.Error(codes.InvalidArgument, "Chunk size must be positive")
+		}
+		if config.FindMissingConcurrency <= 0 {
+			return BlobAccessInfo{}, "", status.Error(codes.InvalidArgument, "FindMissing() concurrency must be positive")
+		}
+		sliceEvictionSet, err := eviction.NewSetFromConfiguration[string](config.SliceCacheReplacementPolicy)
+		if err != nil {
+			return BlobAccessInfo{}, "", util.StatusWrap(err, "Failed to create slice cache replacement policy")
+		}
+		roundTripper, err := http_client.NewRoundTripperFromConfiguration(config.HttpClient)
+		if err != nil {
+			return BlobAccessInfo{}, "", util.StatusWrap(err, "Failed to create HTTP client")
+		}
+
+		return BlobAccessInfo{
+			BlobAccess: blobstore.NewOCIBlobAccess(
+				creator.GetDefaultCapabilitiesProvider(),
+				readBufferFactory,
+				&http.Client{
+					Transport: http_client.NewMetricsRoundTripper(roundTripper, "OCIBlobAccess"),
+				},
+				strings.TrimSuffix(config.Address, "/"),
+				config.Repository,
+				config.ChunkSizeBytes,
+				int(config.FindMissingConcurrency),
+				int(config.SliceCacheSize),
+				eviction.NewMetricsSet(sliceEvictionSet, "OCIBlobAccess")),
+			DigestKeyFormat: digest.KeyWithoutInstance,
+		}, "oci", nil
 	case *pb.BlobAccessConfiguration_DeadlineEnforcing:
 		base, err := nc.NewNestedBlobAccess(backend.DeadlineEnforcing.Backend, creator)
 		if err != nil {
@@ -572,6 +1013,137 @@ func (nc *simpleNestedBlobAccessCreator) newNestedBlobAccessBare(configuration *
 			BlobAccess:      blobstore.NewDeadlineEnforcingBlobAccess(base.BlobAccess, timeout.AsDuration()),
 			DigestKeyFormat: base.DigestKeyFormat,
 		}, "deadline_enforcing", nil
+	case *pb.BlobAccessConfiguration_CircuitBreaker:
+		config := backend.CircuitBreaker
+		base, err := nc.NewNestedBlobAccess(config.Backend, creator)
+		if err != nil {
+			return BlobAccessInfo{}, "", err
+		}
+
+		if config.FailureThreshold == 0 {
+			return BlobAccessInfo{}, "", status.Error(codes.InvalidArgument, "Failure threshold must be positive")
+		}
+		if err := config.Cooldown.CheckValid(); err != nil {
+			return BlobAccessInfo{}, "", util.StatusWrapWithCode(err, codes.InvalidArgument, "Invalid cooldown")
+		}
+		if err := blobstore.RegisterCircuitBreakerName(creator.GetStorageTypeName(), config.Name); err != nil {
+			return BlobAccessInfo{}, "", err
+		}
+
+		return BlobAccessInfo{
+			BlobAccess: blobstore.NewCircuitBreakingBlobAccess(
+				base.BlobAccess,
+				clock.SystemClock,
+				int(config.FailureThreshold),
+				config.Cooldown.AsDuration(),
+				config.DegradedFindMissing,
+				creator.GetStorageTypeName(),
+				config.Name),
+			DigestKeyFormat: base.DigestKeyFormat,
+		}, "circuit_breaker", nil
+	case *pb.BlobAccessConfiguration_RateLimiting:
+		config := backend.RateLimiting
+		base, err := nc.NewNestedBlobAccess(config.Backend, creator)
+		if err != nil {
+			return BlobAccessInfo{}, "", err
+		}
+
+		keyExpression, err := jmespath.NewExpressionFromConfiguration(config.KeyJmespathExpression, nc.terminationGroup, clock.SystemClock)
+		if err != nil {
+			return BlobAccessInfo{}, "", util.StatusWrap(err, "Failed to compile key JMESPath expression")
+		}
+		if config.MaximumKeys == 0 {
+			return BlobAccessInfo{}, "", status.Error(codes.InvalidArgument, "Maximum number of keys must be positive")
+		}
+		var limits blobstore.RateLimits
+		for _, limit := range []struct {
+			name          string
+			configuration *pb.RateLimitingBlobAccessConfiguration_Limit
+			limit         **blobstore.RateLimit
+		}{
+			{"Get", config.Get, &limits.Get},
+			{"Put", config.Put, &limits.Put},
+			{"FindMissing", config.FindMissing, &limits.FindMissing},
+		} {
+			if c := limit.configuration; c != nil {
+				if c.RequestsPerSecond < 0 || (c.RequestsPerSecond > 0 && c.RequestBurst == 0) {
+					return BlobAccessInfo{}, "", status.Errorf(codes.InvalidArgument, "Invalid request limit for operation %s", limit.name)
+				}
+				if c.BytesPerSecond < 0 || (c.BytesPerSecond > 0 && c.ByteBurst == 0) {
+					return BlobAccessInfo{}, "", status.Errorf(codes.InvalidArgument, "Invalid byte limit for operation %s", limit.name)
+				}
+				*limit.limit = &blobstore.RateLimit{
+					RequestsPerSecond: c.RequestsPerSecond,
+					RequestBurst:      int64(c.RequestBurst),
+					BytesPerSecond:    c.BytesPerSecond,
+					ByteBurst:         int64(c.ByteBurst),
+				}
+			}
+		}
+
+		return BlobAccessInfo{
+			BlobAccess: blobstore.NewRateLimitingBlobAccess(
+				base.BlobAccess,
+				clock.SystemClock,
+				keyExpression,
+				limits,
+				int(config.MaximumKeys),
+				creator.GetStorageTypeName()),
+			DigestKeyFormat: base.DigestKeyFormat,
+		}, "rate_limiting", nil
+	case *pb.BlobAccessConfiguration_QuotaEnforcing:
+		config := backend.QuotaEnforcing
+		base, err := nc.NewNestedBlobAccess(config.Backend, creator)
+		if err != nil {
+			return BlobAccessInfo{}, "", err
+		}
+
+		var tenantExtractor quota.TenantExtractor
+		switch tenant := config.Tenant.(type) {
+		case *pb.QuotaEnforcingBlobAccessConfiguration_TenantJmespathExpression:
+			expression, err := jmespath.NewExpressionFromConfiguration(tenant.TenantJmespathExpression, nc.terminationGroup, clock.SystemClock)
+			if err != nil {
+				return BlobAccessInfo{}, "", util.StatusWrap(err, "Failed to compile tenant JMESPath expression")
+			}
+			tenantExtractor = quota.NewJMESPathTenantExtractor(expression)
+		case *pb.QuotaEnforcingBlobAccessConfiguration_TenantInstanceNamePrefixComponents:
+			tenantExtractor = quota.NewInstanceNamePrefixTenantExtractor(int(tenant.TenantInstanceNamePrefixComponents))
+		default:
+			return BlobAccessInfo{}, "", status.Error(codes.InvalidArgument, "No method for determining the tenant specified")
+		}
+
+		if err := config.Window.CheckValid(); err != nil {
+			return BlobAccessInfo{}, "", util.StatusWrapWithCode(err, codes.InvalidArgument, "Invalid window")
+		}
+		window := config.Window.AsDuration()
+		if window <= 0 {
+			return BlobAccessInfo{}, "", status.Error(codes.InvalidArgument, "Window must be positive")
+		}
+		tenantQuotaBytes := make(map[string]int64, len(config.TenantQuotaBytes))
+		for tenant, quotaBytes := range config.TenantQuotaBytes {
+			tenantQuotaBytes[tenant] = int64(quotaBytes)
+		}
+		usageTracker := quota.NewUsageTracker(clock.SystemClock, window, tenantQuotaBytes, int64(config.DefaultQuotaBytes))
+		if err := quota.RegisterUsageTracker(config.Name, usageTracker); err != nil {
+			return BlobAccessInfo{}, "", err
+		}
+
+		var deprioritizedPuts *semaphore.Weighted
+		if config.DeprioritizedPutConcurrency < 0 {
+			return BlobAccessInfo{}, "", status.Error(codes.InvalidArgument, "Deprioritized put concurrency cannot be negative")
+		} else if config.DeprioritizedPutConcurrency > 0 {
+			deprioritizedPuts = semaphore.NewWeighted(config.DeprioritizedPutConcurrency)
+		}
+
+		return BlobAccessInfo{
+			BlobAccess: quota.NewQuotaEnforcingBlobAccess(
+				base.BlobAccess,
+				usageTracker,
+				tenantExtractor,
+				deprioritizedPuts,
+				config.Name),
+			DigestKeyFormat: base.DigestKeyFormat,
+		}, "quota_enforcing", nil
 	}
 	return creator.NewCustomBlobAccess(nc.terminationGroup, configuration, nc)
 }
@@ -0,0 +1,457 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/buildbarn/bb-storage/pkg/blobstore/buffer"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/slicing"
+	"github.com/buildbarn/bb-storage/pkg/capabilities"
+	"github.com/buildbarn/bb-storage/pkg/clock"
+	"github.com/buildbarn/bb-storage/pkg/digest"
+	"github.com/buildbarn/bb-storage/pkg/eviction"
+	"github.com/buildbarn/bb-storage/pkg/util"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// directoryTemporaryDirectory is the name of the subdirectory in which
+// objects are written, prior to being moved into their final location.
+const directoryTemporaryDirectory = "tmp"
+
+// directoryObject contains the state that DirectoryBlobAccess tracks
+// for every file stored in the directory.
+type directoryObject struct {
+	sizeBytes int64
+	// Whether the file is still present. This is set to false if
+	// the file has been removed due to it being corrupted. The
+	// entry itself is retained until evicted, as eviction.Set does
+	// not permit removing arbitrary elements.
+	valid bool
+}
+
+// DirectoryBlobAccess is an implementation of BlobAccess that stores
+// every object as a separate file in a directory on a local or network
+// file system. Files are placed in subdirectories named after the
+// first two characters of the hash of the object, so that directories
+// remain reasonably small.
+//
+// Writes are performed atomically by writing data into a temporary
+// file, followed by moving it into its final location. Upon access,
+// the modification time of files is updated. This allows the order in
+// which objects are evicted to be reconstructed upon startup, without
+// requiring a separate persistent state file.
+//
+// The directory is assumed to be owned by a single process.
+type DirectoryBlobAccess struct {
+	capabilities.Provider
+	readBufferFactory ReadBufferFactory
+	digestKeyFormat   digest.KeyFormat
+	root              *os.Root
+	clock             clock.Clock
+	errorLogger       util.ErrorLogger
+	maximumSizeBytes  int64
+
+	nextTemporaryFile atomic.Uint64
+	evictionWakeup    chan struct{}
+
+	lock                       sync.Mutex
+	objects                    map[string]*directoryObject
+	evictionSet                eviction.Set[string]
+	totalSizeBytes             int64
+	pendingRemovals            map[string]struct{}
+	pendingRenames             map[string]struct{}
+	pendingOperationsCompleted *sync.Cond
+}
+
+type directoryScannedFile struct {
+	name             string
+	sizeBytes        int64
+	modificationTime time.Time
+}
+
+// NewDirectoryBlobAccess creates a BlobAccess that stores objects as
+// files in a directory. The directory is scanned upon creation, so
+// that any objects stored previously remain accessible.
+//
+// The total size of all objects is bounded by evicting objects in
+// the background. Eviction is performed by calling ProcessEvictions()
+// in a loop.
+func NewDirectoryBlobAccess(capabilitiesProvider capabilities.Provider, readBufferFactory ReadBufferFactory, digestKeyFormat digest.KeyFormat, root *os.Root, clock clock.Clock, errorLogger util.ErrorLogger, evictionSet eviction.Set[string], maximumSizeBytes int64) (*DirectoryBlobAccess, error) {
+	ba := &DirectoryBlobAccess{
+		Provider:          capabilitiesProvider,
+		readBufferFactory: readBufferFactory,
+		digestKeyFormat:   digestKeyFormat,
+		root:              root,
+		clock:             clock,
+		errorLogger:       errorLogger,
+		maximumSizeBytes:  maximumSizeBytes,
+
+		evictionWakeup: make(chan struct{}, 1),
+
+		objects:         map[string]*directoryObject{},
+		evictionSet:     evictionSet,
+		pendingRemovals: map[string]struct{}{},
+		pendingRenames:  map[string]struct{}{},
+	}
+	ba.pendingOperationsCompleted = sync.NewCond(&ba.lock)
+
+	// Remove leftovers of uploads that were interrupted.
+	if err := root.RemoveAll(directoryTemporaryDirectory); err != nil {
+		return nil, util.StatusWrapfWithCode(err, codes.Internal, "Failed to remove directory %#v", directoryTemporaryDirectory)
+	}
+	if err := root.Mkdir(directoryTemporaryDirectory, 0o777); err != nil {
+		return nil, util.StatusWrapfWithCode(err, codes.Internal, "Failed to create directory %#v", directoryTemporaryDirectory)
+	}
+
+	// Insert existing files into the eviction set, ordered by the
+	// time at which they were last accessed.
+	files, err := ba.scanFiles()
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].modificationTime.Before(files[j].modificationTime)
+	})
+	for _, file := range files {
+		ba.objects[file.name] = &directoryObject{
+			sizeBytes: file.sizeBytes,
+			valid:     true,
+		}
+		ba.evictionSet.Insert(file.name)
+		ba.totalSizeBytes += file.sizeBytes
+	}
+	ba.wakeUpEvictionIfNeededLocked()
+	return ba, nil
+}
+
+// scanFiles returns the names, sizes and modification times of all
+// files that are stored in the directory.
+func (ba *DirectoryBlobAccess) scanFiles() ([]directoryScannedFile, error) {
+	subdirectories, err := fs.ReadDir(ba.root.FS(), ".")
+	if err != nil {
+		return nil, util.StatusWrapWithCode(err, codes.Internal, "Failed to read root directory")
+	}
+	var files []directoryScannedFile
+	for _, subdirectory := range subdirectories {
+		if !subdirectory.IsDir() || len(subdirectory.Name()) != 2 {
+			continue
+		}
+		entries, err := fs.ReadDir(ba.root.FS(), subdirectory.Name())
+		if err != nil {
+			return nil, util.StatusWrapfWithCode(err, codes.Internal, "Failed to read directory %#v", subdirectory.Name())
+		}
+		for _, entry := range entries {
+			if !entry.Type().IsRegular() {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					continue
+				}
+				return nil, util.StatusWrapfWithCode(err, codes.Internal, "Failed to obtain properties of file %#v", entry.Name())
+			}
+			files = append(files, directoryScannedFile{
+				name:             path.Join(subdirectory.Name(), entry.Name()),
+				sizeBytes:        info.Size(),
+				modificationTime: info.ModTime(),
+			})
+		}
+	}
+	return files, nil
+}
+
+func (ba *DirectoryBlobAccess) getDirectoryName(blobDigest digest.Digest) string {
+	return blobDigest.GetHashString()[:2]
+}
+
+func (ba *DirectoryBlobAccess) getFileName(blobDigest digest.Digest) string {
+	// Keys may contain slashes if they include the instance name.
+	return path.Join(ba.getDirectoryName(blobDigest), url.PathEscape(blobDigest.GetKey(ba.digestKeyFormat)))
+}
+
+// touchFile updates the modification time of a file, so that the
+// order in which objects are evicted can be reconstructed upon
+// startup. Failures are not reported, as the file may have been
+// evicted concurrently.
+func (ba *DirectoryBlobAccess) touchFile(name string) {
+	now := ba.clock.Now()
+	ba.root.Chtimes(name, now, now)
+}
+
+func (ba *DirectoryBlobAccess) wakeUpEvictionIfNeededLocked() {
+	if ba.totalSizeBytes > ba.maximumSizeBytes {
+		select {
+		case ba.evictionWakeup <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// invalidateFile is called when the contents of a file are corrupted.
+// It removes the file, and marks it as being absent.
+func (ba *DirectoryBlobAccess) invalidateFile(name string) {
+	ba.lock.Lock()
+	defer ba.lock.Unlock()
+
+	if object, ok := ba.objects[name]; ok && object.valid {
+		// Don't remove the file if a new copy is being moved
+		// into place, as that would remove the new copy.
+		if _, ok := ba.pendingRenames[name]; !ok {
+			if err := ba.root.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				ba.errorLogger.Log(util.StatusWrapfWithCode(err, codes.Internal, "Failed to remove corrupted file %#v", name))
+			}
+		}
+		ba.totalSizeBytes -= object.sizeBytes
+		object.sizeBytes = 0
+		object.valid = false
+	}
+}
+
+// Get the contents of an object from the directory.
+func (ba *DirectoryBlobAccess) Get(ctx context.Context, blobDigest digest.Digest) buffer.Buffer {
+	name := ba.getFileName(blobDigest)
+	ba.lock.Lock()
+	object, ok := ba.objects[name]
+	var sizeBytes int64
+	if ok && object.valid {
+		ba.evictionSet.Touch(name)
+		sizeBytes = object.sizeBytes
+	} else {
+		ok = false
+	}
+	ba.lock.Unlock()
+	if !ok {
+		return buffer.NewBufferFromError(status.Error(codes.NotFound, "Object not found"))
+	}
+
+	f, err := ba.root.Open(name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			// File got evicted concurrently.
+			return buffer.NewBufferFromError(status.Error(codes.NotFound, "Object not found"))
+		}
+		return buffer.NewBufferFromError(util.StatusWrapfWithCode(err, codes.Internal, "Failed to open file %#v", name))
+	}
+	ba.touchFile(name)
+	return ba.readBufferFactory.NewBufferFromReaderAt(
+		blobDigest,
+		f,
+		sizeBytes,
+		func(dataIsValid bool) {
+			if !dataIsValid {
+				ba.invalidateFile(name)
+			}
+		})
+}
+
+// GetFromComposite fetches an object that is contained within another
+// object from the directory.
+func (ba *DirectoryBlobAccess) GetFromComposite(ctx context.Context, parentDigest, childDigest digest.Digest, slicer slicing.BlobSlicer) buffer.Buffer {
+	b, _ := slicer.Slice(ba.Get(ctx, parentDigest), childDigest)
+	return b
+}
+
+// Put an object in the directory.
+func (ba *DirectoryBlobAccess) Put(ctx context.Context, blobDigest digest.Digest, b buffer.Buffer) error {
+	sizeBytes, err := b.GetSizeBytes()
+	if err != nil {
+		b.Discard()
+		return err
+	}
+
+	// Write the object into a temporary file.
+	temporaryName := path.Join(directoryTemporaryDirectory, strconv.FormatUint(ba.nextTemporaryFile.Add(1), 10))
+	f, err := ba.root.OpenFile(temporaryName, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o666)
+	if err != nil {
+		b.Discard()
+		return util.StatusWrapfWithCode(err, codes.Internal, "Failed to create file %#v", temporaryName)
+	}
+	if err := b.IntoWriter(f); err != nil {
+		f.Close()
+		ba.root.Remove(temporaryName)
+		return err
+	}
+	// Flush the contents of the file to disk before moving it into
+	// place. This prevents truncated files from appearing under
+	// their final name after a crash.
+	if err := f.Sync(); err != nil {
+		f.Close()
+		ba.root.Remove(temporaryName)
+		return util.StatusWrapfWithCode(err, codes.Internal, "Failed to synchronize file %#v", temporaryName)
+	}
+	if err := f.Close(); err != nil {
+		ba.root.Remove(temporaryName)
+		return util.StatusWrapfWithCode(err, codes.Internal, "Failed to close file %#v", temporaryName)
+	}
+
+	// Wait for any pending removal of a previous copy of the same
+	// object to complete, as it would otherwise remove the file
+	// that is about to be moved into place. The same holds for
+	// concurrent attempts to store the same object.
+	directoryName := ba.getDirectoryName(blobDigest)
+	name := ba.getFileName(blobDigest)
+	ba.lock.Lock()
+	for {
+		_, removalPending := ba.pendingRemovals[name]
+		_, renamePending := ba.pendingRenames[name]
+		if !removalPending && !renamePending {
+			break
+		}
+		ba.pendingOperationsCompleted.Wait()
+	}
+	ba.pendingRenames[name] = struct{}{}
+	ba.lock.Unlock()
+
+	// Move the file into its final location. This is done without
+	// holding the lock, so that other operations are not blocked
+	// on file system access. Eviction and removal of corrupted
+	// files skip objects that have a rename pending.
+	if err := ba.moveIntoPlace(temporaryName, directoryName, name); err != nil {
+		ba.root.Remove(temporaryName)
+
+		// A previous copy of the object may have been evicted
+		// or found to be corrupted while the rename was
+		// pending. As its file was not removed, remove it now.
+		ba.lock.Lock()
+		delete(ba.pendingRenames, name)
+		object, ok := ba.objects[name]
+		stale := !ok || !object.valid
+		if stale {
+			ba.pendingRemovals[name] = struct{}{}
+		}
+		ba.lock.Unlock()
+		if stale {
+			if err := ba.root.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				ba.errorLogger.Log(util.StatusWrapfWithCode(err, codes.Internal, "Failed to remove file %#v", name))
+			}
+			ba.lock.Lock()
+			delete(ba.pendingRemovals, name)
+			ba.lock.Unlock()
+		}
+		ba.pendingOperationsCompleted.Broadcast()
+		return err
+	}
+
+	ba.lock.Lock()
+	defer ba.pendingOperationsCompleted.Broadcast()
+	defer ba.lock.Unlock()
+	delete(ba.pendingRenames, name)
+	if object, ok := ba.objects[name]; ok {
+		ba.evictionSet.Touch(name)
+		ba.totalSizeBytes += sizeBytes - object.sizeBytes
+		object.sizeBytes = sizeBytes
+		object.valid = true
+	} else {
+		ba.objects[name] = &directoryObject{
+			sizeBytes: sizeBytes,
+			valid:     true,
+		}
+		ba.evictionSet.Insert(name)
+		ba.totalSizeBytes += sizeBytes
+	}
+	ba.wakeUpEvictionIfNeededLocked()
+	return nil
+}
+
+// moveIntoPlace moves a temporary file into its final location,
+// creating the directory containing it if needed.
+func (ba *DirectoryBlobAccess) moveIntoPlace(temporaryName, directoryName, name string) error {
+	if err := ba.root.Mkdir(directoryName, 0o777); err != nil && !errors.Is(err, fs.ErrExist) {
+		return util.StatusWrapfWithCode(err, codes.Internal, "Failed to create directory %#v", directoryName)
+	}
+	if err := ba.root.Rename(temporaryName, name); err != nil {
+		return util.StatusWrapfWithCode(err, codes.Internal, "Failed to rename file %#v to %#v", temporaryName, name)
+	}
+	return nil
+}
+
+// FindMissing reports which objects are absent from the directory.
+func (ba *DirectoryBlobAccess) FindMissing(ctx context.Context, digests digest.Set) (digest.Set, error) {
+	missing := digest.NewSetBuilder()
+	var present []string
+	ba.lock.Lock()
+	for _, blobDigest := range digests.Items() {
+		name := ba.getFileName(blobDigest)
+		if object, ok := ba.objects[name]; ok && object.valid {
+			ba.evictionSet.Touch(name)
+			present = append(present, name)
+		} else {
+			missing.Add(blobDigest)
+		}
+	}
+	ba.lock.Unlock()
+
+	for _, name := range present {
+		ba.touchFile(name)
+	}
+	return missing.Build(), nil
+}
+
+// ProcessEvictions waits for the total size of all objects stored in
+// the directory to exceed the configured maximum, and removes files
+// until the total size no longer exceeds it.
+//
+// This function must generally be called in a loop in a separate
+// goroutine, so that evictions are performed continuously. It returns
+// false when the provided context is canceled.
+func (ba *DirectoryBlobAccess) ProcessEvictions(ctx context.Context) bool {
+	// Check for cancelation explicitly, as select picks a random
+	// case if a wakeup is pending as well.
+	if ctx.Err() != nil {
+		return false
+	}
+	select {
+	case <-ctx.Done():
+		return false
+	case <-ba.evictionWakeup:
+	}
+
+	// Select the files to remove while holding the lock, but only
+	// remove them after releasing it. This prevents other
+	// operations from being blocked on file system access.
+	var names []string
+	ba.lock.Lock()
+	for ba.totalSizeBytes > ba.maximumSizeBytes && len(ba.objects) > 0 {
+		name := ba.evictionSet.Peek()
+		ba.evictionSet.Remove()
+		object := ba.objects[name]
+		delete(ba.objects, name)
+		ba.totalSizeBytes -= object.sizeBytes
+		// Files for which a rename is pending are about to
+		// be replaced by a new copy. Removing them would
+		// remove the new copy.
+		if _, renamePending := ba.pendingRenames[name]; object.valid && !renamePending {
+			names = append(names, name)
+			ba.pendingRemovals[name] = struct{}{}
+		}
+	}
+	ba.lock.Unlock()
+
+	if len(names) > 0 {
+		for _, name := range names {
+			if err := ba.root.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				ba.errorLogger.Log(util.StatusWrapfWithCode(err, codes.Internal, "Failed to remove file %#v", name))
+			}
+		}
+
+		ba.lock.Lock()
+		for _, name := range names {
+			delete(ba.pendingRemovals, name)
+		}
+		ba.lock.Unlock()
+		ba.pendingOperationsCompleted.Broadcast()
+	}
+	return true
+}
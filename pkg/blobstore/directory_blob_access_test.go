@@ -0,0 +1,247 @@
+package blobstore_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	remoteexecution "github.com/bazelbuild/remote-apis/build/bazel/remote/execution/v2"
+	"github.com/buildbarn/bb-storage/internal/mock"
+	"github.com/buildbarn/bb-storage/pkg/blobstore"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/buffer"
+	"github.com/buildbarn/bb-storage/pkg/digest"
+	"github.com/buildbarn/bb-storage/pkg/eviction"
+	"github.com/buildbarn/bb-storage/pkg/testutil"
+	"github.com/stretchr/testify/require"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestDirectoryBlobAccess(t *testing.T) {
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+
+	directory := t.TempDir()
+	clock := mock.NewMockClock(ctrl)
+	clock.EXPECT().Now().Return(time.Unix(1000, 0)).AnyTimes()
+	errorLogger := mock.NewMockErrorLogger(ctrl)
+
+	newBlobAccess := func(t *testing.T) *blobstore.DirectoryBlobAccess {
+		root, err := os.OpenRoot(directory)
+		require.NoError(t, err)
+		t.Cleanup(func() { root.Close() })
+		blobAccess, err := blobstore.NewDirectoryBlobAccess(
+			nil,
+			blobstore.CASReadBufferFactory,
+			digest.KeyWithoutInstance,
+			root,
+			clock,
+			errorLogger,
+			eviction.NewLRUSet[string](),
+			12)
+		require.NoError(t, err)
+		return blobAccess
+	}
+	blobAccess := newBlobAccess(t)
+
+	helloDigest := digest.MustNewDigest("hello", remoteexecution.DigestFunction_MD5, "8b1a9953c4611296a827abf8c47804d7", 5)
+	worldDigest := digest.MustNewDigest("hello", remoteexecution.DigestFunction_MD5, "f5a7924e621e84c9280a9a27e1bcb7f6", 5)
+	helloWorldDigest := digest.MustNewDigest("hello", remoteexecution.DigestFunction_MD5, "68e109f0f40ca72a15e05cc22786f8e6", 10)
+
+	t.Run("GetNotFound", func(t *testing.T) {
+		_, err := blobAccess.Get(ctx, helloDigest).ToByteSlice(100)
+		testutil.RequireEqualStatus(t, status.Error(codes.NotFound, "Object not found"), err)
+	})
+
+	t.Run("PutAndGet", func(t *testing.T) {
+		require.NoError(t, blobAccess.Put(ctx, helloDigest, buffer.NewValidatedBufferFromByteSlice([]byte("Hello"))))
+
+		data, err := os.ReadFile(filepath.Join(directory, "8b", "3-8b1a9953c4611296a827abf8c47804d7-5"))
+		require.NoError(t, err)
+		require.Equal(t, []byte("Hello"), data)
+
+		data, err = blobAccess.Get(ctx, helloDigest).ToByteSlice(100)
+		require.NoError(t, err)
+		require.Equal(t, []byte("Hello"), data)
+
+		// Temporary files should not be left behind.
+		entries, err := os.ReadDir(filepath.Join(directory, "tmp"))
+		require.NoError(t, err)
+		require.Empty(t, entries)
+	})
+
+	t.Run("PutCorrupted", func(t *testing.T) {
+		// Data that does not match the digest should not be
+		// stored, nor should temporary files be left behind.
+		testutil.RequireEqualStatus(
+			t,
+			status.Error(codes.InvalidArgument, "Buffer has checksum 8b1a9953c4611296a827abf8c47804d7, while f5a7924e621e84c9280a9a27e1bcb7f6 was expected"),
+			blobAccess.Put(ctx, worldDigest, buffer.NewCASBufferFromByteSlice(worldDigest, []byte("Hello"), buffer.UserProvided)))
+
+		missing, err := blobAccess.FindMissing(ctx, worldDigest.ToSingletonSet())
+		require.NoError(t, err)
+		require.Equal(t, worldDigest.ToSingletonSet(), missing)
+
+		entries, err := os.ReadDir(filepath.Join(directory, "tmp"))
+		require.NoError(t, err)
+		require.Empty(t, entries)
+	})
+
+	t.Run("FindMissing", func(t *testing.T) {
+		missing, err := blobAccess.FindMissing(ctx, digest.NewSetBuilder().Add(helloDigest).Add(worldDigest).Build())
+		require.NoError(t, err)
+		require.Equal(t, worldDigest.ToSingletonSet(), missing)
+
+		// The modification time of the file should have been
+		// updated.
+		fileInfo, err := os.Stat(filepath.Join(directory, "8b", "3-8b1a9953c4611296a827abf8c47804d7-5"))
+		require.NoError(t, err)
+		require.Equal(t, time.Unix(1000, 0), fileInfo.ModTime())
+	})
+
+	t.Run("Eviction", func(t *testing.T) {
+		// Storing "World" brings the total size to 10 bytes,
+		// which is below the limit. Storing "HelloWorld" causes
+		// the limit of 12 bytes to be exceeded, meaning that
+		// eviction should remove the least recently used
+		// objects.
+		require.NoError(t, blobAccess.Put(ctx, worldDigest, buffer.NewValidatedBufferFromByteSlice([]byte("World"))))
+		missing, err := blobAccess.FindMissing(ctx, helloDigest.ToSingletonSet())
+		require.NoError(t, err)
+		require.Equal(t, digest.EmptySet, missing)
+
+		require.NoError(t, blobAccess.Put(ctx, helloWorldDigest, buffer.NewValidatedBufferFromByteSlice([]byte("HelloWorld"))))
+		require.True(t, blobAccess.ProcessEvictions(ctx))
+
+		missing, err = blobAccess.FindMissing(ctx, digest.NewSetBuilder().Add(helloDigest).Add(worldDigest).Add(helloWorldDigest).Build())
+		require.NoError(t, err)
+		require.Equal(t, digest.NewSetBuilder().Add(helloDigest).Add(worldDigest).Build(), missing)
+
+		_, err = os.Stat(filepath.Join(directory, "f5", "3-f5a7924e621e84c9280a9a27e1bcb7f6-5"))
+		require.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("Restart", func(t *testing.T) {
+		// Objects should remain available after restarting.
+		// The order in which objects are evicted should be
+		// based on their modification times.
+		require.NoError(t, blobAccess.Put(ctx, helloDigest, buffer.NewValidatedBufferFromByteSlice([]byte("Hello"))))
+		require.NoError(t, os.Chtimes(filepath.Join(directory, "8b", "3-8b1a9953c4611296a827abf8c47804d7-5"), time.Unix(3000, 0), time.Unix(3000, 0)))
+		require.NoError(t, os.Chtimes(filepath.Join(directory, "68", "3-68e109f0f40ca72a15e05cc22786f8e6-10"), time.Unix(2000, 0), time.Unix(2000, 0)))
+		require.NoError(t, os.WriteFile(filepath.Join(directory, "tmp", "1"), []byte("Partial"), 0o666))
+
+		restartedBlobAccess := newBlobAccess(t)
+		require.True(t, restartedBlobAccess.ProcessEvictions(ctx))
+
+		missing, err := restartedBlobAccess.FindMissing(ctx, digest.NewSetBuilder().Add(helloDigest).Add(helloWorldDigest).Build())
+		require.NoError(t, err)
+		require.Equal(t, helloWorldDigest.ToSingletonSet(), missing)
+
+		data, err := restartedBlobAccess.Get(ctx, helloDigest).ToByteSlice(100)
+		require.NoError(t, err)
+		require.Equal(t, []byte("Hello"), data)
+
+		entries, err := os.ReadDir(filepath.Join(directory, "tmp"))
+		require.NoError(t, err)
+		require.Empty(t, entries)
+	})
+
+	t.Run("GetCorrupted", func(t *testing.T) {
+		// Files with invalid contents should be removed.
+		blobAccess := newBlobAccess(t)
+		require.NoError(t, os.WriteFile(filepath.Join(directory, "8b", "3-8b1a9953c4611296a827abf8c47804d7-5"), []byte("Hallo"), 0o666))
+
+		_, err := blobAccess.Get(ctx, helloDigest).ToByteSlice(100)
+		testutil.RequireEqualStatus(t, status.Error(codes.Internal, "Buffer has checksum d1bf93299de1b68e6d382c893bf1215f, while 8b1a9953c4611296a827abf8c47804d7 was expected"), err)
+
+		missing, err := blobAccess.FindMissing(ctx, helloDigest.ToSingletonSet())
+		require.NoError(t, err)
+		require.Equal(t, helloDigest.ToSingletonSet(), missing)
+
+		_, err = os.Stat(filepath.Join(directory, "8b", "3-8b1a9953c4611296a827abf8c47804d7-5"))
+		require.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("ProcessEvictionsCanceled", func(t *testing.T) {
+		canceledCtx, cancel := context.WithCancel(ctx)
+		cancel()
+		require.False(t, blobAccess.ProcessEvictions(canceledCtx))
+	})
+}
+
+func TestDirectoryBlobAccessConcurrentPutAndEviction(t *testing.T) {
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+
+	directory := t.TempDir()
+	clock := mock.NewMockClock(ctrl)
+	clock.EXPECT().Now().Return(time.Unix(1000, 0)).AnyTimes()
+	root, err := os.OpenRoot(directory)
+	require.NoError(t, err)
+	defer root.Close()
+	blobAccess, err := blobstore.NewDirectoryBlobAccess(
+		nil,
+		blobstore.CASReadBufferFactory,
+		digest.KeyWithoutInstance,
+		root,
+		clock,
+		mock.NewMockErrorLogger(ctrl),
+		eviction.NewLRUSet[string](),
+		12)
+	require.NoError(t, err)
+
+	helloDigest := digest.MustNewDigest("hello", remoteexecution.DigestFunction_MD5, "8b1a9953c4611296a827abf8c47804d7", 5)
+	worldDigest := digest.MustNewDigest("hello", remoteexecution.DigestFunction_MD5, "f5a7924e621e84c9280a9a27e1bcb7f6", 5)
+	helloWorldDigest := digest.MustNewDigest("hello", remoteexecution.DigestFunction_MD5, "68e109f0f40ca72a15e05cc22786f8e6", 10)
+
+	blobs := map[digest.Digest][]byte{
+		helloDigest:      []byte("Hello"),
+		worldDigest:      []byte("World"),
+		helloWorldDigest: []byte("HelloWorld"),
+	}
+
+	// Repeatedly store the same objects from multiple goroutines,
+	// while evicting them concurrently. This causes eviction to
+	// select objects for which a new copy is being moved into
+	// place. Eviction may not remove the files of these objects,
+	// as the new copy would then be reported as present, while
+	// its file is absent.
+	evictionCtx, cancelEviction := context.WithCancel(ctx)
+	var evictionWG sync.WaitGroup
+	evictionWG.Add(1)
+	go func() {
+		defer evictionWG.Done()
+		for blobAccess.ProcessEvictions(evictionCtx) {
+		}
+	}()
+
+	var putWG sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		putWG.Add(1)
+		go func() {
+			defer putWG.Done()
+			for j := 0; j < 100; j++ {
+				for blobDigest, data := range blobs {
+					require.NoError(t, blobAccess.Put(ctx, blobDigest, buffer.NewValidatedBufferFromByteSlice(data)))
+				}
+			}
+		}()
+	}
+	putWG.Wait()
+	cancelEviction()
+	evictionWG.Wait()
+
+	for blobDigest, expectedData := range blobs {
+		missing, err := blobAccess.FindMissing(ctx, blobDigest.ToSingletonSet())
+		require.NoError(t, err)
+		if missing.Empty() {
+			data, err := blobAccess.Get(ctx, blobDigest).ToByteSlice(100)
+			require.NoError(t, err)
+			require.Equal(t, expectedData, data)
+		}
+	}
+}
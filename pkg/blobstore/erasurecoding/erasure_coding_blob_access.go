@@ -0,0 +1,479 @@
+package erasurecoding
+
+import (
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"slices"
+	"sync"
+
+	remoteexecution "github.com/bazelbuild/remote-apis/build/bazel/remote/execution/v2"
+	"github.com/buildbarn/bb-storage/pkg/blobstore"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/buffer"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/slicing"
+	"github.com/buildbarn/bb-storage/pkg/digest"
+	"github.com/buildbarn/bb-storage/pkg/util"
+	"github.com/klauspost/reedsolomon"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	erasureCodingBlobAccessPrometheusMetrics sync.Once
+
+	erasureCodingBlobAccessReconstructions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "buildbarn",
+			Subsystem: "blobstore",
+			Name:      "erasure_coding_blob_access_reconstructions_total",
+			Help:      "Number of times fragments of blobs needed to be reconstructed from the remaining fragments",
+		},
+		[]string{"operation"})
+	erasureCodingBlobAccessReconstructionsGet    = erasureCodingBlobAccessReconstructions.WithLabelValues("Get")
+	erasureCodingBlobAccessReconstructionsRepair = erasureCodingBlobAccessReconstructions.WithLabelValues("Repair")
+
+	erasureCodingBlobAccessRepairsQueued = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "buildbarn",
+			Subsystem: "blobstore",
+			Name:      "erasure_coding_blob_access_repairs_queued_total",
+			Help:      "Number of blobs for which absent or corrupted fragments were detected, and whether repairing them could be queued",
+		},
+		[]string{"operation", "result"})
+	erasureCodingBlobAccessRepairsQueuedGetQueued            = erasureCodingBlobAccessRepairsQueued.WithLabelValues("Get", "Queued")
+	erasureCodingBlobAccessRepairsQueuedGetDiscarded         = erasureCodingBlobAccessRepairsQueued.WithLabelValues("Get", "Discarded")
+	erasureCodingBlobAccessRepairsQueuedFindMissingQueued    = erasureCodingBlobAccessRepairsQueued.WithLabelValues("FindMissing", "Queued")
+	erasureCodingBlobAccessRepairsQueuedFindMissingDiscarded = erasureCodingBlobAccessRepairsQueued.WithLabelValues("FindMissing", "Discarded")
+
+	erasureCodingBlobAccessFragmentsRepaired = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "buildbarn",
+			Subsystem: "blobstore",
+			Name:      "erasure_coding_blob_access_fragments_repaired_total",
+			Help:      "Number of fragments that were absent or corrupted, and were written to storage again",
+		})
+)
+
+// fragmentChecksumSizeBytes is the size of the CRC-32C checksum that
+// is appended to every fragment. It allows corrupted fragments to be
+// detected, so that they can be treated as if they were absent.
+const fragmentChecksumSizeBytes = 4
+
+var fragmentChecksumTable = crc32.MakeTable(crc32.Castagnoli)
+
+// repairRequest is an entry in the queue of objects for which
+// fragments need to be repaired.
+type repairRequest struct {
+	blobDigest    digest.Digest
+	repairIndices []int
+}
+
+// ErasureCodingBlobAccess is an implementation of BlobAccess that
+// stores objects across multiple storage backends using Reed-Solomon
+// erasure coding.
+type ErasureCodingBlobAccess struct {
+	backends               []blobstore.BlobAccess
+	dataFragmentsCount     int
+	parityFragmentsCount   int
+	encoder                reedsolomon.Encoder
+	maximumObjectSizeBytes int
+	errorLogger            util.ErrorLogger
+	repairQueue            chan repairRequest
+}
+
+var _ blobstore.BlobAccess = (*ErasureCodingBlobAccess)(nil)
+
+// NewErasureCodingBlobAccess creates a BlobAccess that stores objects
+// across multiple storage backends using Reed-Solomon erasure coding.
+// Every object is split into k data fragments, for which m parity
+// fragments are computed. Each of these k+m fragments is stored in its
+// own backend, under the digest of the original object.
+//
+// Objects can be reconstructed as long as any k fragments are
+// available. This means that the loss of up to m backends can be
+// tolerated, while only requiring (k+m)/k times the amount of storage
+// space of the original object.
+//
+// Objects for which fragments are found to be absent or corrupted while
+// reading objects or calling FindMissing() are placed in a queue of
+// bounded size. Repairs are performed by calling ProcessRepair(), which
+// recomputes the fragments and writes them back to their backends.
+// When backends are replaced, calling FindMissing() is thus sufficient
+// to restore full redundancy, without delaying the requests that
+// detected the absence of fragments. Repairs are discarded if the queue
+// is full.
+func NewErasureCodingBlobAccess(dataBackends, parityBackends []blobstore.BlobAccess, maximumObjectSizeBytes, repairQueueSize int, errorLogger util.ErrorLogger) (*ErasureCodingBlobAccess, error) {
+	encoder, err := reedsolomon.New(len(dataBackends), len(parityBackends))
+	if err != nil {
+		return nil, util.StatusWrapWithCode(err, codes.InvalidArgument, "Failed to create Reed-Solomon encoder")
+	}
+
+	erasureCodingBlobAccessPrometheusMetrics.Do(func() {
+		prometheus.MustRegister(erasureCodingBlobAccessReconstructions)
+		prometheus.MustRegister(erasureCodingBlobAccessRepairsQueued)
+		prometheus.MustRegister(erasureCodingBlobAccessFragmentsRepaired)
+	})
+
+	return &ErasureCodingBlobAccess{
+		backends:               append(append([]blobstore.BlobAccess(nil), dataBackends...), parityBackends...),
+		dataFragmentsCount:     len(dataBackends),
+		parityFragmentsCount:   len(parityBackends),
+		encoder:                encoder,
+		maximumObjectSizeBytes: maximumObjectSizeBytes,
+		errorLogger:            errorLogger,
+		repairQueue:            make(chan repairRequest, repairQueueSize),
+	}, nil
+}
+
+// getFragmentSizeBytes returns the size of the fragments of an object,
+// excluding the trailing checksum. This matches the size of the shards
+// returned by reedsolomon.Encoder.Split().
+func (ba *ErasureCodingBlobAccess) getFragmentSizeBytes(blobDigest digest.Digest) int {
+	return int((blobDigest.GetSizeBytes() + int64(ba.dataFragmentsCount) - 1) / int64(ba.dataFragmentsCount))
+}
+
+// getFragment reads a single fragment of an object from its backend.
+// The boolean return value indicates whether the fragment should be
+// written to the backend again, either because the backend reported
+// that it is absent, or because it is corrupted.
+func (ba *ErasureCodingBlobAccess) getFragment(ctx context.Context, blobDigest digest.Digest, index, fragmentSizeBytes int) ([]byte, bool, error) {
+	data, err := ba.backends[index].Get(ctx, blobDigest).ToByteSlice(fragmentSizeBytes + fragmentChecksumSizeBytes)
+	if err != nil {
+		return nil, status.Code(err) == codes.NotFound, err
+	}
+	if len(data) != fragmentSizeBytes+fragmentChecksumSizeBytes {
+		return nil, true, status.Errorf(codes.Internal, "Fragment is %d bytes in size, while %d bytes were expected", len(data), fragmentSizeBytes+fragmentChecksumSizeBytes)
+	}
+	if actual, expected := crc32.Checksum(data[:fragmentSizeBytes], fragmentChecksumTable), binary.BigEndian.Uint32(data[fragmentSizeBytes:]); actual != expected {
+		return nil, true, status.Errorf(codes.Internal, "Fragment has CRC-32C checksum %08x, while %08x was expected", actual, expected)
+	}
+	return data[:fragmentSizeBytes], false, nil
+}
+
+type fragmentResult struct {
+	index       int
+	data        []byte
+	needsRepair bool
+	err         error
+}
+
+// getFragments reads fragments of an object, until enough fragments
+// have been obtained to reconstruct it. Data fragments are requested
+// first, as they allow the object to be returned without performing
+// any decoding. Parity fragments are only requested when data
+// fragments cannot be obtained.
+//
+// This function returns a list of fragments, where absent entries are
+// nil, and the indices of fragments that need to be repaired.
+func (ba *ErasureCodingBlobAccess) getFragments(ctx context.Context, blobDigest digest.Digest) ([][]byte, []int, error) {
+	fragmentSizeBytes := ba.getFragmentSizeBytes(blobDigest)
+	results := make(chan fragmentResult, len(ba.backends))
+	fetchFragment := func(index int) {
+		go func() {
+			data, needsRepair, err := ba.getFragment(ctx, blobDigest, index, fragmentSizeBytes)
+			results <- fragmentResult{
+				index:       index,
+				data:        data,
+				needsRepair: needsRepair,
+				err:         err,
+			}
+		}()
+	}
+
+	for i := 0; i < ba.dataFragmentsCount; i++ {
+		fetchFragment(i)
+	}
+	nextIndex, pending, obtained := ba.dataFragmentsCount, ba.dataFragmentsCount, 0
+	fragments := make([][]byte, len(ba.backends))
+	fragmentErrors := make([]error, len(ba.backends))
+	var repairIndices []int
+	for pending > 0 {
+		result := <-results
+		pending--
+		if result.err == nil {
+			fragments[result.index] = result.data
+			obtained++
+			continue
+		}
+
+		// Fragment could not be obtained. Request the next
+		// parity fragment in its place.
+		fragmentErrors[result.index] = result.err
+		if result.needsRepair {
+			repairIndices = append(repairIndices, result.index)
+		}
+		if nextIndex < len(ba.backends) {
+			fetchFragment(nextIndex)
+			nextIndex++
+			pending++
+		}
+	}
+
+	if obtained < ba.dataFragmentsCount {
+		// Only report the object as being absent if all
+		// backends agree. Any other error may indicate that
+		// backends are unavailable.
+		for i, err := range fragmentErrors {
+			if err != nil && status.Code(err) != codes.NotFound {
+				return nil, nil, util.StatusWrapf(err, "Backend %d", i)
+			}
+		}
+		return nil, nil, status.Errorf(codes.NotFound, "Only %d out of %d required fragments are present", obtained, ba.dataFragmentsCount)
+	}
+	return fragments, repairIndices, nil
+}
+
+// getObject reads fragments of an object and decodes them. Fragments
+// that are absent or corrupted are reported to the caller, so that
+// they may be repaired.
+func (ba *ErasureCodingBlobAccess) getObject(ctx context.Context, blobDigest digest.Digest, reconstructions prometheus.Counter) ([]byte, []int, error) {
+	fragments, repairIndices, err := ba.getFragments(ctx, blobDigest)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Reconstruct data fragments from parity fragments if needed.
+	for _, fragment := range fragments[:ba.dataFragmentsCount] {
+		if fragment == nil {
+			reconstructions.Inc()
+			if err := ba.encoder.ReconstructData(fragments); err != nil {
+				return nil, nil, util.StatusWrapWithCode(err, codes.Internal, "Failed to reconstruct data fragments")
+			}
+			break
+		}
+	}
+
+	// Concatenate the data fragments and strip the padding that
+	// was added to the last data fragment. The result is validated
+	// against the digest, as the checksums of the fragments only
+	// protect against corruption of individual fragments.
+	sizeBytes := int(blobDigest.GetSizeBytes())
+	data := make([]byte, 0, len(fragments[0])*ba.dataFragmentsCount)
+	for _, fragment := range fragments[:ba.dataFragmentsCount] {
+		data = append(data, fragment...)
+	}
+	data, err = blobstore.CASReadBufferFactory.NewBufferFromByteSlice(blobDigest, data[:sizeBytes], buffer.Irreparable(blobDigest)).ToByteSlice(sizeBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, repairIndices, nil
+}
+
+// repairFragments recomputes fragments of an object and writes them
+// to their backends. Failures are only logged, as the object can
+// still be reconstructed from the remaining fragments.
+func (ba *ErasureCodingBlobAccess) repairFragments(ctx context.Context, blobDigest digest.Digest, fragments [][]byte, repairIndices []int) {
+	if err := ba.encoder.Reconstruct(fragments); err != nil {
+		ba.errorLogger.Log(util.StatusWrapfWithCode(err, codes.Internal, "Failed to recompute fragments of blob %#v", blobDigest.String()))
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, i := range repairIndices {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := ba.backends[i].Put(ctx, blobDigest, buffer.NewValidatedBufferFromByteSlice(appendFragmentChecksum(fragments[i]))); err != nil {
+				ba.errorLogger.Log(util.StatusWrapf(err, "Failed to repair fragment in backend %d of blob %#v", i, blobDigest.String()))
+				return
+			}
+			erasureCodingBlobAccessFragmentsRepaired.Inc()
+		}()
+	}
+	wg.Wait()
+}
+
+// appendFragmentChecksum creates a copy of a fragment that has its
+// checksum appended. A copy needs to be made, as the fragments
+// returned by reedsolomon.Encoder.Split() share the same underlying
+// array.
+func appendFragmentChecksum(fragment []byte) []byte {
+	data := make([]byte, len(fragment), len(fragment)+fragmentChecksumSizeBytes)
+	copy(data, fragment)
+	return binary.BigEndian.AppendUint32(data, crc32.Checksum(fragment, fragmentChecksumTable))
+}
+
+func (ba *ErasureCodingBlobAccess) Get(ctx context.Context, blobDigest digest.Digest) buffer.Buffer {
+	sizeBytes := blobDigest.GetSizeBytes()
+	if sizeBytes == 0 {
+		// Empty objects cannot be split into fragments.
+		return buffer.NewValidatedBufferFromByteSlice(nil)
+	}
+	if sizeBytes > int64(ba.maximumObjectSizeBytes) {
+		return buffer.NewBufferFromError(status.Errorf(codes.InvalidArgument, "Blob is %d bytes in size, while this backend is only capable of storing blobs of up to %d bytes in size", sizeBytes, ba.maximumObjectSizeBytes))
+	}
+
+	data, repairIndices, err := ba.getObject(ctx, blobDigest, erasureCodingBlobAccessReconstructionsGet)
+	if err != nil {
+		return buffer.NewBufferFromError(err)
+	}
+	if len(repairIndices) > 0 {
+		ba.enqueueRepair(blobDigest, repairIndices, erasureCodingBlobAccessRepairsQueuedGetQueued, erasureCodingBlobAccessRepairsQueuedGetDiscarded)
+	}
+	return buffer.NewValidatedBufferFromByteSlice(data)
+}
+
+func (ba *ErasureCodingBlobAccess) GetFromComposite(ctx context.Context, parentDigest, childDigest digest.Digest, slicer slicing.BlobSlicer) buffer.Buffer {
+	b, _ := slicer.Slice(ba.Get(ctx, parentDigest), childDigest)
+	return b
+}
+
+func (ba *ErasureCodingBlobAccess) Put(ctx context.Context, blobDigest digest.Digest, b buffer.Buffer) error {
+	data, err := b.ToByteSlice(ba.maximumObjectSizeBytes)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		// Empty objects cannot be split into fragments. There
+		// is also no need to store them, as Get() is capable
+		// of returning them without contacting any backends.
+		return nil
+	}
+
+	fragments, err := ba.encoder.Split(data)
+	if err != nil {
+		return util.StatusWrapWithCode(err, codes.Internal, "Failed to split blob into data fragments")
+	}
+	if err := ba.encoder.Encode(fragments); err != nil {
+		return util.StatusWrapWithCode(err, codes.Internal, "Failed to compute parity fragments")
+	}
+
+	// Store all fragments in their respective backends.
+	group, groupCtx := errgroup.WithContext(ctx)
+	for i, fragment := range fragments {
+		group.Go(func() error {
+			if err := ba.backends[i].Put(groupCtx, blobDigest, buffer.NewValidatedBufferFromByteSlice(appendFragmentChecksum(fragment))); err != nil {
+				return util.StatusWrapf(err, "Backend %d", i)
+			}
+			return nil
+		})
+	}
+	return group.Wait()
+}
+
+func (ba *ErasureCodingBlobAccess) FindMissing(ctx context.Context, digests digest.Set) (digest.Set, error) {
+	// Call FindMissing() on all backends. Failures of individual
+	// backends are tolerated, as long as no more than m backends
+	// fail.
+	var wg sync.WaitGroup
+	missingPerBackend := make([]digest.Set, len(ba.backends))
+	errs := make([]error, len(ba.backends))
+	for i, backend := range ba.backends {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			missingPerBackend[i], errs[i] = backend.FindMissing(ctx, digests)
+		}()
+	}
+	wg.Wait()
+
+	failedBackendsCount := 0
+	var firstErr error
+	for i, err := range errs {
+		if err != nil {
+			failedBackendsCount++
+			if firstErr == nil {
+				firstErr = util.StatusWrapf(err, "Backend %d", i)
+			}
+		}
+	}
+	if failedBackendsCount > ba.parityFragmentsCount {
+		return digest.EmptySet, firstErr
+	}
+
+	// Objects are reported as missing if there is a possibility
+	// that fewer than k fragments are present. Objects that are
+	// only missing a small number of fragments are queued for
+	// repair.
+	missingIndices := map[digest.Digest][]int{}
+	for i, missingFromBackend := range missingPerBackend {
+		if errs[i] == nil {
+			for _, blobDigest := range missingFromBackend.Items() {
+				missingIndices[blobDigest] = append(missingIndices[blobDigest], i)
+			}
+		}
+	}
+	missing := digest.NewSetBuilder()
+	for _, blobDigest := range digests.Items() {
+		if blobDigest.GetSizeBytes() == 0 {
+			continue
+		}
+		repairIndices := missingIndices[blobDigest]
+		if len(repairIndices)+failedBackendsCount > ba.parityFragmentsCount {
+			missing.Add(blobDigest)
+		} else if len(repairIndices) > 0 {
+			ba.enqueueRepair(blobDigest, repairIndices, erasureCodingBlobAccessRepairsQueuedFindMissingQueued, erasureCodingBlobAccessRepairsQueuedFindMissingDiscarded)
+		}
+	}
+	return missing.Build(), nil
+}
+
+// enqueueRepair schedules the repair of fragments of an object. It
+// does not block if the queue is full, as the object can still be
+// reconstructed from the remaining fragments.
+func (ba *ErasureCodingBlobAccess) enqueueRepair(blobDigest digest.Digest, repairIndices []int, queued, discarded prometheus.Counter) {
+	select {
+	case ba.repairQueue <- repairRequest{
+		blobDigest:    blobDigest,
+		repairIndices: repairIndices,
+	}:
+		queued.Inc()
+	default:
+		discarded.Inc()
+	}
+}
+
+// ProcessRepair waits for a single object to be placed in the repair
+// queue, and restores its absent or corrupted fragments. Failures are
+// only logged. This function returns false if the provided context is
+// cancelled, meaning that no further repairs should be processed.
+func (ba *ErasureCodingBlobAccess) ProcessRepair(ctx context.Context) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case request := <-ba.repairQueue:
+		if err := ba.repairObject(ctx, request.blobDigest, request.repairIndices); err != nil {
+			ba.errorLogger.Log(util.StatusWrapf(err, "Failed to repair blob %#v", request.blobDigest.String()))
+		}
+		return true
+	}
+}
+
+// repairObject restores fragments of an object that were reported as
+// absent or corrupted. As the object may have been changed in the
+// meantime, all fragments are read again.
+func (ba *ErasureCodingBlobAccess) repairObject(ctx context.Context, blobDigest digest.Digest, repairIndices []int) error {
+	if blobDigest.GetSizeBytes() > int64(ba.maximumObjectSizeBytes) {
+		return status.Errorf(codes.InvalidArgument, "Blob is %d bytes in size, while this backend is only capable of storing blobs of up to %d bytes in size", blobDigest.GetSizeBytes(), ba.maximumObjectSizeBytes)
+	}
+	fragments, additionalRepairIndices, err := ba.getFragments(ctx, blobDigest)
+	if err != nil {
+		return err
+	}
+	erasureCodingBlobAccessReconstructionsRepair.Inc()
+
+	// Only repair fragments that could not be read. Fragments that
+	// were reported as absent may have been written in the meantime.
+	// Fragments that were observed to be corrupted while reading
+	// need to be repaired as well. These are disjoint, as corrupted
+	// fragments are also absent from the list of fragments.
+	var absentIndices []int
+	for _, i := range repairIndices {
+		if fragments[i] == nil && !slices.Contains(additionalRepairIndices, i) {
+			absentIndices = append(absentIndices, i)
+		}
+	}
+	ba.repairFragments(ctx, blobDigest, fragments, append(absentIndices, additionalRepairIndices...))
+	return nil
+}
+
+func (ba *ErasureCodingBlobAccess) GetCapabilities(ctx context.Context, instanceName digest.InstanceName) (*remoteexecution.ServerCapabilities, error) {
+	capabilities, err := ba.backends[0].GetCapabilities(ctx, instanceName)
+	if err != nil {
+		return nil, util.StatusWrap(err, "Backend 0")
+	}
+	return capabilities, nil
+}
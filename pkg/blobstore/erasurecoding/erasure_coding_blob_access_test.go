@@ -0,0 +1,268 @@
+package erasurecoding_test
+
+import (
+	"context"
+	"testing"
+
+	remoteexecution "github.com/bazelbuild/remote-apis/build/bazel/remote/execution/v2"
+	"github.com/buildbarn/bb-storage/internal/mock"
+	"github.com/buildbarn/bb-storage/pkg/blobstore"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/buffer"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/erasurecoding"
+	"github.com/buildbarn/bb-storage/pkg/digest"
+	"github.com/buildbarn/bb-storage/pkg/testutil"
+	"github.com/stretchr/testify/require"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestErasureCodingBlobAccess(t *testing.T) {
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+
+	// Two data backends and a single parity backend.
+	backends := []*mock.MockBlobAccess{
+		mock.NewMockBlobAccess(ctrl),
+		mock.NewMockBlobAccess(ctrl),
+		mock.NewMockBlobAccess(ctrl),
+	}
+	errorLogger := mock.NewMockErrorLogger(ctrl)
+	blobAccess, err := erasurecoding.NewErasureCodingBlobAccess(
+		[]blobstore.BlobAccess{backends[0], backends[1]},
+		[]blobstore.BlobAccess{backends[2]},
+		100,
+		10,
+		errorLogger)
+	require.NoError(t, err)
+
+	helloDigest := digest.MustNewDigest("hello", remoteexecution.DigestFunction_MD5, "8b1a9953c4611296a827abf8c47804d7", 5)
+	helloWorldDigest := digest.MustNewDigest("hello", remoteexecution.DigestFunction_MD5, "68e109f0f40ca72a15e05cc22786f8e6", 10)
+
+	expectPutFragment := func(index int, fragment *[]byte) {
+		backends[index].EXPECT().Put(gomock.Any(), helloWorldDigest, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, blobDigest digest.Digest, b buffer.Buffer) error {
+				data, err := b.ToByteSlice(100)
+				require.NoError(t, err)
+				*fragment = data
+				return nil
+			})
+	}
+
+	// Store an object, so that the fragments can be used by the
+	// tests below.
+	fragments := make([][]byte, len(backends))
+	for i := range backends {
+		expectPutFragment(i, &fragments[i])
+	}
+	require.NoError(t, blobAccess.Put(ctx, helloWorldDigest, buffer.NewValidatedBufferFromByteSlice([]byte("HelloWorld"))))
+
+	t.Run("Fragments", func(t *testing.T) {
+		// Data fragments should contain the original data,
+		// followed by a CRC-32C checksum.
+		require.Equal(t, []byte("Hello\x81\xd9\x0e\x1b"), fragments[0])
+		require.Equal(t, []byte("World\x2a\x02\x34\x19"), fragments[1])
+		require.Len(t, fragments[2], 9)
+	})
+
+	t.Run("EmptyBlob", func(t *testing.T) {
+		// Empty objects cannot be split into fragments. They
+		// should be handled without contacting any backends.
+		emptyDigest := digest.MustNewDigest("hello", remoteexecution.DigestFunction_MD5, "d41d8cd98f00b204e9800998ecf8427e", 0)
+		require.NoError(t, blobAccess.Put(ctx, emptyDigest, buffer.NewValidatedBufferFromByteSlice(nil)))
+
+		data, err := blobAccess.Get(ctx, emptyDigest).ToByteSlice(100)
+		require.NoError(t, err)
+		require.Empty(t, data)
+	})
+
+	t.Run("GetSuccess", func(t *testing.T) {
+		// If all data fragments are present, there is no need to
+		// read the parity fragment.
+		backends[0].EXPECT().Get(ctx, helloWorldDigest).Return(buffer.NewValidatedBufferFromByteSlice(fragments[0]))
+		backends[1].EXPECT().Get(ctx, helloWorldDigest).Return(buffer.NewValidatedBufferFromByteSlice(fragments[1]))
+
+		data, err := blobAccess.Get(ctx, helloWorldDigest).ToByteSlice(100)
+		require.NoError(t, err)
+		require.Equal(t, []byte("HelloWorld"), data)
+	})
+
+	t.Run("GetBackendUnavailable", func(t *testing.T) {
+		// If a backend is unavailable, the object should be
+		// reconstructed using the parity fragment. There is no
+		// need to repair anything, as the fragment may still be
+		// present.
+		backends[0].EXPECT().Get(ctx, helloWorldDigest).Return(buffer.NewBufferFromError(status.Error(codes.Unavailable, "Server offline")))
+		backends[1].EXPECT().Get(ctx, helloWorldDigest).Return(buffer.NewValidatedBufferFromByteSlice(fragments[1]))
+		backends[2].EXPECT().Get(ctx, helloWorldDigest).Return(buffer.NewValidatedBufferFromByteSlice(fragments[2]))
+
+		data, err := blobAccess.Get(ctx, helloWorldDigest).ToByteSlice(100)
+		require.NoError(t, err)
+		require.Equal(t, []byte("HelloWorld"), data)
+	})
+
+	t.Run("GetRepairMissing", func(t *testing.T) {
+		// Fragments that are absent should be written to their
+		// backend again. This should not be done as part of
+		// Get(), but when processing the repair queue.
+		backends[0].EXPECT().Get(ctx, helloWorldDigest).Return(buffer.NewValidatedBufferFromByteSlice(fragments[0])).Times(2)
+		backends[1].EXPECT().Get(ctx, helloWorldDigest).Return(buffer.NewBufferFromError(status.Error(codes.NotFound, "Object not found"))).Times(2)
+		backends[2].EXPECT().Get(ctx, helloWorldDigest).Return(buffer.NewValidatedBufferFromByteSlice(fragments[2])).Times(2)
+
+		data, err := blobAccess.Get(ctx, helloWorldDigest).ToByteSlice(100)
+		require.NoError(t, err)
+		require.Equal(t, []byte("HelloWorld"), data)
+
+		var repairedFragment []byte
+		expectPutFragment(1, &repairedFragment)
+		require.True(t, blobAccess.ProcessRepair(ctx))
+		require.Equal(t, fragments[1], repairedFragment)
+	})
+
+	t.Run("GetRepairCorrupted", func(t *testing.T) {
+		// Fragments whose checksum doesn't match should be
+		// treated as if they were absent.
+		backends[0].EXPECT().Get(ctx, helloWorldDigest).Return(buffer.NewValidatedBufferFromByteSlice([]byte("Hallo\x81\xd9\x0e\x1b"))).Times(2)
+		backends[1].EXPECT().Get(ctx, helloWorldDigest).Return(buffer.NewValidatedBufferFromByteSlice(fragments[1])).Times(2)
+		backends[2].EXPECT().Get(ctx, helloWorldDigest).Return(buffer.NewValidatedBufferFromByteSlice(fragments[2])).Times(2)
+
+		data, err := blobAccess.Get(ctx, helloWorldDigest).ToByteSlice(100)
+		require.NoError(t, err)
+		require.Equal(t, []byte("HelloWorld"), data)
+
+		var repairedFragment []byte
+		expectPutFragment(0, &repairedFragment)
+		require.True(t, blobAccess.ProcessRepair(ctx))
+		require.Equal(t, fragments[0], repairedFragment)
+	})
+
+	t.Run("GetRepairFailure", func(t *testing.T) {
+		// Failures to repair fragments should only be logged,
+		// as the object itself could be obtained.
+		backends[0].EXPECT().Get(ctx, helloWorldDigest).Return(buffer.NewBufferFromError(status.Error(codes.NotFound, "Object not found"))).Times(2)
+		backends[1].EXPECT().Get(ctx, helloWorldDigest).Return(buffer.NewValidatedBufferFromByteSlice(fragments[1])).Times(2)
+		backends[2].EXPECT().Get(ctx, helloWorldDigest).Return(buffer.NewValidatedBufferFromByteSlice(fragments[2])).Times(2)
+
+		data, err := blobAccess.Get(ctx, helloWorldDigest).ToByteSlice(100)
+		require.NoError(t, err)
+		require.Equal(t, []byte("HelloWorld"), data)
+
+		backends[0].EXPECT().Put(gomock.Any(), helloWorldDigest, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, blobDigest digest.Digest, b buffer.Buffer) error {
+				b.Discard()
+				return status.Error(codes.Unavailable, "Server offline")
+			})
+		errorLogger.EXPECT().Log(testutil.EqStatus(t, status.Error(codes.Unavailable, "Failed to repair fragment in backend 0 of blob \"3-68e109f0f40ca72a15e05cc22786f8e6-10-hello\": Server offline")))
+		require.True(t, blobAccess.ProcessRepair(ctx))
+	})
+
+	t.Run("GetNotFound", func(t *testing.T) {
+		for _, backend := range backends {
+			backend.EXPECT().Get(ctx, helloDigest).Return(buffer.NewBufferFromError(status.Error(codes.NotFound, "Object not found")))
+		}
+
+		_, err := blobAccess.Get(ctx, helloDigest).ToByteSlice(100)
+		testutil.RequireEqualStatus(t, status.Error(codes.NotFound, "Only 0 out of 2 required fragments are present"), err)
+	})
+
+	t.Run("GetTooManyFailures", func(t *testing.T) {
+		// If too many backends fail, the error of the first
+		// failing backend should be returned, as the object may
+		// still be present.
+		backends[0].EXPECT().Get(ctx, helloWorldDigest).Return(buffer.NewBufferFromError(status.Error(codes.Unavailable, "Server offline")))
+		backends[1].EXPECT().Get(ctx, helloWorldDigest).Return(buffer.NewBufferFromError(status.Error(codes.NotFound, "Object not found")))
+		backends[2].EXPECT().Get(ctx, helloWorldDigest).Return(buffer.NewValidatedBufferFromByteSlice(fragments[2]))
+
+		_, err := blobAccess.Get(ctx, helloWorldDigest).ToByteSlice(100)
+		testutil.RequireEqualStatus(t, status.Error(codes.Unavailable, "Backend 0: Server offline"), err)
+	})
+
+	t.Run("PutFailure", func(t *testing.T) {
+		backends[0].EXPECT().Put(gomock.Any(), helloWorldDigest, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, blobDigest digest.Digest, b buffer.Buffer) error {
+				b.Discard()
+				return nil
+			})
+		backends[1].EXPECT().Put(gomock.Any(), helloWorldDigest, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, blobDigest digest.Digest, b buffer.Buffer) error {
+				b.Discard()
+				return status.Error(codes.Unavailable, "Server offline")
+			})
+		backends[2].EXPECT().Put(gomock.Any(), helloWorldDigest, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, blobDigest digest.Digest, b buffer.Buffer) error {
+				b.Discard()
+				return nil
+			})
+
+		testutil.RequireEqualStatus(
+			t,
+			status.Error(codes.Unavailable, "Backend 1: Server offline"),
+			blobAccess.Put(ctx, helloWorldDigest, buffer.NewValidatedBufferFromByteSlice([]byte("HelloWorld"))))
+	})
+
+	t.Run("FindMissingSuccess", func(t *testing.T) {
+		digests := digest.NewSetBuilder().Add(helloDigest).Add(helloWorldDigest).Build()
+		for _, backend := range backends {
+			backend.EXPECT().FindMissing(ctx, digests).Return(helloDigest.ToSingletonSet(), nil)
+		}
+
+		missing, err := blobAccess.FindMissing(ctx, digests)
+		require.NoError(t, err)
+		require.Equal(t, helloDigest.ToSingletonSet(), missing)
+	})
+
+	t.Run("FindMissingRepair", func(t *testing.T) {
+		// If a single fragment is absent, the object can still
+		// be reconstructed. It should be queued for repair, as
+		// opposed to being reported as missing. FindMissing()
+		// itself should not read any fragments.
+		digests := helloWorldDigest.ToSingletonSet()
+		backends[0].EXPECT().FindMissing(ctx, digests).Return(digest.EmptySet, nil)
+		backends[1].EXPECT().FindMissing(ctx, digests).Return(digest.EmptySet, nil)
+		backends[2].EXPECT().FindMissing(ctx, digests).Return(digests, nil)
+
+		missing, err := blobAccess.FindMissing(ctx, digests)
+		require.NoError(t, err)
+		require.Equal(t, digest.EmptySet, missing)
+
+		backends[0].EXPECT().Get(ctx, helloWorldDigest).Return(buffer.NewValidatedBufferFromByteSlice(fragments[0]))
+		backends[1].EXPECT().Get(ctx, helloWorldDigest).Return(buffer.NewValidatedBufferFromByteSlice(fragments[1]))
+		var repairedFragment []byte
+		expectPutFragment(2, &repairedFragment)
+		require.True(t, blobAccess.ProcessRepair(ctx))
+		require.Equal(t, fragments[2], repairedFragment)
+	})
+
+	t.Run("ProcessRepairCancelled", func(t *testing.T) {
+		// Processing of repairs should stop when the context
+		// is cancelled.
+		cancelledCtx, cancel := context.WithCancel(ctx)
+		cancel()
+		require.False(t, blobAccess.ProcessRepair(cancelledCtx))
+	})
+
+	t.Run("FindMissingTooManyAbsent", func(t *testing.T) {
+		// If a backend is unavailable and another backend
+		// reports the object as absent, the object cannot be
+		// guaranteed to be reconstructable.
+		digests := helloWorldDigest.ToSingletonSet()
+		backends[0].EXPECT().FindMissing(ctx, digests).Return(digest.EmptySet, status.Error(codes.Unavailable, "Server offline"))
+		backends[1].EXPECT().FindMissing(ctx, digests).Return(digests, nil)
+		backends[2].EXPECT().FindMissing(ctx, digests).Return(digest.EmptySet, nil)
+
+		missing, err := blobAccess.FindMissing(ctx, digests)
+		require.NoError(t, err)
+		require.Equal(t, digests, missing)
+	})
+
+	t.Run("FindMissingTooManyFailures", func(t *testing.T) {
+		digests := helloWorldDigest.ToSingletonSet()
+		backends[0].EXPECT().FindMissing(ctx, digests).Return(digest.EmptySet, nil)
+		backends[1].EXPECT().FindMissing(ctx, digests).Return(digest.EmptySet, status.Error(codes.Unavailable, "Server offline"))
+		backends[2].EXPECT().FindMissing(ctx, digests).Return(digest.EmptySet, status.Error(codes.Unavailable, "Server offline"))
+
+		_, err := blobAccess.FindMissing(ctx, digests)
+		testutil.RequireEqualStatus(t, status.Error(codes.Unavailable, "Backend 1: Server offline"), err)
+	})
+}
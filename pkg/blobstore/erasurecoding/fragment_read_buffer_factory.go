@@ -0,0 +1,37 @@
+package erasurecoding
+
+import (
+	"io"
+
+	"github.com/buildbarn/bb-storage/pkg/blobstore"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/buffer"
+	"github.com/buildbarn/bb-storage/pkg/digest"
+	"github.com/buildbarn/bb-storage/pkg/util"
+)
+
+type fragmentReadBufferFactory struct{}
+
+func (f fragmentReadBufferFactory) NewBufferFromByteSlice(digest digest.Digest, data []byte, dataIntegrityCallback buffer.DataIntegrityCallback) buffer.Buffer {
+	return buffer.NewValidatedBufferFromByteSlice(data)
+}
+
+func (f fragmentReadBufferFactory) NewBufferFromReader(digest digest.Digest, r io.ReadCloser, dataIntegrityCallback buffer.DataIntegrityCallback) buffer.Buffer {
+	data, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		return buffer.NewBufferFromError(util.StatusWrap(err, "Failed to read fragment"))
+	}
+	return buffer.NewValidatedBufferFromByteSlice(data)
+}
+
+func (f fragmentReadBufferFactory) NewBufferFromReaderAt(digest digest.Digest, r buffer.ReadAtCloser, sizeBytes int64, dataIntegrityCallback buffer.DataIntegrityCallback) buffer.Buffer {
+	return buffer.NewValidatedBufferFromReaderAt(r, sizeBytes)
+}
+
+// FragmentReadBufferFactory is capable of creating buffers for
+// fragments of objects stored by ErasureCodingBlobAccess. As the
+// contents of fragments don't match the digest under which they are
+// stored, no validation is performed. Fragments are validated by
+// ErasureCodingBlobAccess instead, using the checksum that is part of
+// every fragment.
+var FragmentReadBufferFactory blobstore.ReadBufferFactory = fragmentReadBufferFactory{}
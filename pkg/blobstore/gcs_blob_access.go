@@ -0,0 +1,165 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/buffer"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/slicing"
+	"github.com/buildbarn/bb-storage/pkg/capabilities"
+	"github.com/buildbarn/bb-storage/pkg/clock"
+	cloud_gcp "github.com/buildbarn/bb-storage/pkg/cloud/gcp"
+	"github.com/buildbarn/bb-storage/pkg/digest"
+	"github.com/buildbarn/bb-storage/pkg/util"
+
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type gcsBlobAccess struct {
+	capabilities.Provider
+	readBufferFactory         ReadBufferFactory
+	digestKeyFormat           digest.KeyFormat
+	bucket                    cloud_gcp.StorageBucketHandle
+	objectPrefix              string
+	clock                     clock.Clock
+	resumableUploadChunkSize  int
+	customTimeRefreshInterval time.Duration
+	findMissingConcurrency    int
+}
+
+// NewGCSBlobAccess creates a BlobAccess that stores objects in a Google
+// Cloud Storage bucket. Objects are stored under names that are
+// obtained by prepending a prefix to the result of Digest.GetKey().
+//
+// The custom time of objects is set when they are created, and updated
+// when their existence is checked through FindMissing(). This permits
+// the use of bucket lifecycle rules to remove objects that have not
+// been used recently.
+func NewGCSBlobAccess(capabilitiesProvider capabilities.Provider, readBufferFactory ReadBufferFactory, digestKeyFormat digest.KeyFormat, bucket cloud_gcp.StorageBucketHandle, objectPrefix string, clock clock.Clock, resumableUploadChunkSize int, customTimeRefreshInterval time.Duration, findMissingConcurrency int) BlobAccess {
+	return &gcsBlobAccess{
+		Provider:                  capabilitiesProvider,
+		readBufferFactory:         readBufferFactory,
+		digestKeyFormat:           digestKeyFormat,
+		bucket:                    bucket,
+		objectPrefix:              objectPrefix,
+		clock:                     clock,
+		resumableUploadChunkSize:  resumableUploadChunkSize,
+		customTimeRefreshInterval: customTimeRefreshInterval,
+		findMissingConcurrency:    findMissingConcurrency,
+	}
+}
+
+// gcsErrorToStatus converts an error returned by the GCS client to a
+// gRPC status, translating errors for nonexistent objects to NotFound.
+func gcsErrorToStatus(err error) error {
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	return errToStatus(err)
+}
+
+func (ba *gcsBlobAccess) getObjectName(blobDigest digest.Digest) string {
+	return ba.objectPrefix + blobDigest.GetKey(ba.digestKeyFormat)
+}
+
+func (ba *gcsBlobAccess) Get(ctx context.Context, blobDigest digest.Digest) buffer.Buffer {
+	name := ba.getObjectName(blobDigest)
+	object := ba.bucket.Object(name)
+	r, err := object.NewRangeReader(ctx, 0, cloud_gcp.ReadUntilEOF)
+	if err != nil {
+		return buffer.NewBufferFromError(util.StatusWrapf(gcsErrorToStatus(err), "Failed to get object %#v", name))
+	}
+	return ba.readBufferFactory.NewBufferFromReader(
+		blobDigest,
+		statusReturningReadCloser{r: r},
+		func(dataIsValid bool) {
+			// Remove corrupted objects, so that they may be
+			// replaced by a subsequent call to Put().
+			if !dataIsValid {
+				object.Delete(context.WithoutCancel(ctx))
+			}
+		})
+}
+
+func (ba *gcsBlobAccess) GetFromComposite(ctx context.Context, parentDigest, childDigest digest.Digest, slicer slicing.BlobSlicer) buffer.Buffer {
+	b, _ := slicer.Slice(ba.Get(ctx, parentDigest), childDigest)
+	return b
+}
+
+func (ba *gcsBlobAccess) Put(ctx context.Context, blobDigest digest.Digest, b buffer.Buffer) error {
+	// Canceling the context prior to calling Close() causes the
+	// upload to be abandoned. This prevents objects from being
+	// created if the data to be written turns out to be corrupted.
+	ctxWithCancel, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	name := ba.getObjectName(blobDigest)
+	w := ba.bucket.Object(name).NewWriter(
+		ctxWithCancel,
+		storage.ObjectAttrs{
+			ContentType: "application/octet-stream",
+			CustomTime:  ba.clock.Now(),
+		},
+		ba.resumableUploadChunkSize)
+	if err := b.IntoWriter(w); err != nil {
+		cancel()
+		w.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return util.StatusWrapf(errToStatus(err), "Failed to put object %#v", name)
+	}
+	return nil
+}
+
+// touchObject checks for the existence of an object. If the object
+// exists and its custom time has not been updated recently, it is
+// updated to prevent it from being removed by bucket lifecycle rules.
+func (ba *gcsBlobAccess) touchObject(ctx context.Context, name string) error {
+	object := ba.bucket.Object(name)
+	attrs, err := object.Attrs(ctx)
+	if err != nil {
+		return err
+	}
+	now := ba.clock.Now()
+	if attrs.CustomTime.Add(ba.customTimeRefreshInterval).Before(now) {
+		if _, err := object.Update(ctx, storage.ObjectAttrsToUpdate{
+			CustomTime: now,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ba *gcsBlobAccess) FindMissing(ctx context.Context, digests digest.Set) (digest.Set, error) {
+	var missingLock sync.Mutex
+	missing := digest.NewSetBuilder()
+
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(ba.findMissingConcurrency)
+	for _, blobDigest := range digests.Items() {
+		group.Go(func() error {
+			name := ba.getObjectName(blobDigest)
+			if err := ba.touchObject(groupCtx, name); err != nil {
+				err = gcsErrorToStatus(err)
+				if status.Code(err) != codes.NotFound {
+					return util.StatusWrapf(err, "Failed to touch object %#v", name)
+				}
+				missingLock.Lock()
+				missing.Add(blobDigest)
+				missingLock.Unlock()
+			}
+			return nil
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return digest.EmptySet, err
+	}
+	return missing.Build(), nil
+}
@@ -0,0 +1,169 @@
+package blobstore_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/storage"
+	remoteexecution "github.com/bazelbuild/remote-apis/build/bazel/remote/execution/v2"
+	"github.com/buildbarn/bb-storage/internal/mock"
+	"github.com/buildbarn/bb-storage/pkg/blobstore"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/buffer"
+	cloud_gcp "github.com/buildbarn/bb-storage/pkg/cloud/gcp"
+	"github.com/buildbarn/bb-storage/pkg/digest"
+	"github.com/buildbarn/bb-storage/pkg/testutil"
+	"github.com/stretchr/testify/require"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestGCSBlobAccess(t *testing.T) {
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+
+	bucket := mock.NewMockStorageBucketHandle(ctrl)
+	clock := mock.NewMockClock(ctrl)
+	blobAccess := blobstore.NewGCSBlobAccess(
+		nil,
+		blobstore.CASReadBufferFactory,
+		digest.KeyWithoutInstance,
+		bucket,
+		"cas/",
+		clock,
+		1024,
+		time.Hour,
+		2)
+
+	helloDigest := digest.MustNewDigest("hello", remoteexecution.DigestFunction_MD5, "8b1a9953c4611296a827abf8c47804d7", 5)
+	helloName := "cas/3-8b1a9953c4611296a827abf8c47804d7-5"
+
+	t.Run("GetNotFound", func(t *testing.T) {
+		object := mock.NewMockStorageObjectHandle(ctrl)
+		bucket.EXPECT().Object(helloName).Return(object)
+		object.EXPECT().NewRangeReader(ctx, int64(0), cloud_gcp.ReadUntilEOF).Return(nil, storage.ErrObjectNotExist)
+
+		_, err := blobAccess.Get(ctx, helloDigest).ToByteSlice(100)
+		testutil.RequireEqualStatus(t, status.Error(codes.NotFound, "Failed to get object \"cas/3-8b1a9953c4611296a827abf8c47804d7-5\": storage: object doesn't exist"), err)
+	})
+
+	t.Run("GetSuccess", func(t *testing.T) {
+		object := mock.NewMockStorageObjectHandle(ctrl)
+		bucket.EXPECT().Object(helloName).Return(object)
+		object.EXPECT().NewRangeReader(ctx, int64(0), cloud_gcp.ReadUntilEOF).
+			Return(io.NopCloser(bytes.NewBufferString("Hello")), nil)
+
+		data, err := blobAccess.Get(ctx, helloDigest).ToByteSlice(100)
+		require.NoError(t, err)
+		require.Equal(t, []byte("Hello"), data)
+	})
+
+	t.Run("GetCorrupted", func(t *testing.T) {
+		// Objects with invalid contents should be removed, so
+		// that they may be replaced.
+		object := mock.NewMockStorageObjectHandle(ctrl)
+		bucket.EXPECT().Object(helloName).Return(object)
+		object.EXPECT().NewRangeReader(ctx, int64(0), cloud_gcp.ReadUntilEOF).
+			Return(io.NopCloser(bytes.NewBufferString("Hallo")), nil)
+		object.EXPECT().Delete(gomock.Any())
+
+		_, err := blobAccess.Get(ctx, helloDigest).ToByteSlice(100)
+		testutil.RequireEqualStatus(t, status.Error(codes.Internal, "Buffer has checksum d1bf93299de1b68e6d382c893bf1215f, while 8b1a9953c4611296a827abf8c47804d7 was expected"), err)
+	})
+
+	t.Run("PutSuccess", func(t *testing.T) {
+		object := mock.NewMockStorageObjectHandle(ctrl)
+		bucket.EXPECT().Object(helloName).Return(object)
+		clock.EXPECT().Now().Return(time.Unix(1000, 0))
+		writer := mock.NewMockWriteCloser(ctrl)
+		object.EXPECT().NewWriter(gomock.Any(), storage.ObjectAttrs{
+			ContentType: "application/octet-stream",
+			CustomTime:  time.Unix(1000, 0),
+		}, 1024).Return(writer)
+		writer.EXPECT().Write([]byte("Hello")).Return(5, nil)
+		writer.EXPECT().Close()
+
+		require.NoError(t, blobAccess.Put(ctx, helloDigest, buffer.NewValidatedBufferFromByteSlice([]byte("Hello"))))
+	})
+
+	t.Run("PutCorrupted", func(t *testing.T) {
+		// If the data to be uploaded turns out to be corrupted,
+		// the context of the writer should be canceled prior to
+		// closing it, so that the upload is abandoned.
+		object := mock.NewMockStorageObjectHandle(ctrl)
+		bucket.EXPECT().Object(helloName).Return(object)
+		clock.EXPECT().Now().Return(time.Unix(1001, 0))
+		writer := mock.NewMockWriteCloser(ctrl)
+		var writerCtx context.Context
+		object.EXPECT().NewWriter(gomock.Any(), gomock.Any(), 1024).DoAndReturn(
+			func(ctx context.Context, attrs storage.ObjectAttrs, chunkSize int) io.WriteCloser {
+				writerCtx = ctx
+				return writer
+			})
+		writer.EXPECT().Write(gomock.Any()).AnyTimes().DoAndReturn(func(p []byte) (int, error) { return len(p), nil })
+		writer.EXPECT().Close().DoAndReturn(func() error {
+			require.Equal(t, context.Canceled, writerCtx.Err())
+			return context.Canceled
+		})
+
+		testutil.RequireEqualStatus(
+			t,
+			status.Error(codes.InvalidArgument, "Buffer has checksum d1bf93299de1b68e6d382c893bf1215f, while 8b1a9953c4611296a827abf8c47804d7 was expected"),
+			blobAccess.Put(ctx, helloDigest, buffer.NewCASBufferFromReader(helloDigest, io.NopCloser(bytes.NewBufferString("Hallo")), buffer.UserProvided)))
+	})
+
+	t.Run("PutFailure", func(t *testing.T) {
+		object := mock.NewMockStorageObjectHandle(ctrl)
+		bucket.EXPECT().Object(helloName).Return(object)
+		clock.EXPECT().Now().Return(time.Unix(1002, 0))
+		writer := mock.NewMockWriteCloser(ctrl)
+		object.EXPECT().NewWriter(gomock.Any(), gomock.Any(), 1024).Return(writer)
+		writer.EXPECT().Write([]byte("Hello")).Return(5, nil)
+		writer.EXPECT().Close().Return(status.Error(codes.Unavailable, "Server offline"))
+
+		testutil.RequireEqualStatus(
+			t,
+			status.Error(codes.Internal, "Failed to put object \"cas/3-8b1a9953c4611296a827abf8c47804d7-5\": rpc error: code = Unavailable desc = Server offline"),
+			blobAccess.Put(ctx, helloDigest, buffer.NewValidatedBufferFromByteSlice([]byte("Hello"))))
+	})
+
+	t.Run("FindMissing", func(t *testing.T) {
+		// Objects that have been touched recently should not
+		// have their custom time updated. Objects that have not
+		// been touched recently should.
+		worldDigest := digest.MustNewDigest("hello", remoteexecution.DigestFunction_MD5, "f5a7924e621e84c9280a9a27e1bcb7f6", 5)
+		helloWorldDigest := digest.MustNewDigest("hello", remoteexecution.DigestFunction_MD5, "68e109f0f40ca72a15e05cc22786f8e6", 10)
+		clock.EXPECT().Now().Return(time.Unix(5000, 0)).Times(2)
+
+		helloObject := mock.NewMockStorageObjectHandle(ctrl)
+		bucket.EXPECT().Object(helloName).Return(helloObject)
+		helloObject.EXPECT().Attrs(gomock.Any()).Return(&storage.ObjectAttrs{CustomTime: time.Unix(3000, 0)}, nil)
+
+		worldObject := mock.NewMockStorageObjectHandle(ctrl)
+		bucket.EXPECT().Object("cas/3-f5a7924e621e84c9280a9a27e1bcb7f6-5").Return(worldObject)
+		worldObject.EXPECT().Attrs(gomock.Any()).Return(&storage.ObjectAttrs{CustomTime: time.Unix(1000, 0)}, nil)
+		worldObject.EXPECT().Update(gomock.Any(), storage.ObjectAttrsToUpdate{CustomTime: time.Unix(5000, 0)}).
+			Return(&storage.ObjectAttrs{CustomTime: time.Unix(5000, 0)}, nil)
+
+		helloWorldObject := mock.NewMockStorageObjectHandle(ctrl)
+		bucket.EXPECT().Object("cas/3-68e109f0f40ca72a15e05cc22786f8e6-10").Return(helloWorldObject)
+		helloWorldObject.EXPECT().Attrs(gomock.Any()).Return(nil, storage.ErrObjectNotExist)
+
+		missing, err := blobAccess.FindMissing(ctx, digest.NewSetBuilder().Add(helloDigest).Add(worldDigest).Add(helloWorldDigest).Build())
+		require.NoError(t, err)
+		require.Equal(t, helloWorldDigest.ToSingletonSet(), missing)
+	})
+
+	t.Run("FindMissingFailure", func(t *testing.T) {
+		object := mock.NewMockStorageObjectHandle(ctrl)
+		bucket.EXPECT().Object(helloName).Return(object)
+		object.EXPECT().Attrs(gomock.Any()).Return(nil, status.Error(codes.Unavailable, "Server offline"))
+
+		_, err := blobAccess.FindMissing(ctx, helloDigest.ToSingletonSet())
+		testutil.RequireEqualStatus(t, status.Error(codes.Internal, "Failed to touch object \"cas/3-8b1a9953c4611296a827abf8c47804d7-5\": rpc error: code = Unavailable desc = Server offline"), err)
+	})
+}
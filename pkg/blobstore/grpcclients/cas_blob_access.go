@@ -1,6 +1,7 @@
 package grpcclients
 
 import (
+	"bytes"
 	"context"
 	"io"
 
@@ -23,6 +24,7 @@ type casBlobAccess struct {
 	capabilitiesClient              remoteexecution.CapabilitiesClient
 	uuidGenerator                   util.UUIDGenerator
 	readChunkSize                   int
+	compressor                      remoteexecution.Compressor_Value
 }
 
 // NewCASBlobAccess creates a BlobAccess handle that relays any requests
@@ -30,13 +32,18 @@ type casBlobAccess struct {
 // remoteexecution.ContentAddressableStorage services. Those are the
 // services that Bazel uses to access blobs stored in the Content
 // Addressable Storage.
-func NewCASBlobAccess(client grpc.ClientConnInterface, uuidGenerator util.UUIDGenerator, readChunkSize int) blobstore.BlobAccess {
+//
+// If a compressor other than IDENTITY is provided, the contents of
+// blobs are transferred through the ByteStream service in compressed
+// form.
+func NewCASBlobAccess(client grpc.ClientConnInterface, uuidGenerator util.UUIDGenerator, readChunkSize int, compressor remoteexecution.Compressor_Value) blobstore.BlobAccess {
 	return &casBlobAccess{
 		byteStreamClient:                bytestream.NewByteStreamClient(client),
 		contentAddressableStorageClient: remoteexecution.NewContentAddressableStorageClient(client),
 		capabilitiesClient:              remoteexecution.NewCapabilitiesClient(client),
 		uuidGenerator:                   uuidGenerator,
 		readChunkSize:                   readChunkSize,
+		compressor:                      compressor,
 	}
 }
 
@@ -64,9 +71,48 @@ func (r *byteStreamChunkReader) Close() {
 
 const resourceNameHeader = "build.bazel.remote.execution.v2.resource-name"
 
+// byteStreamReader is an adapter for byteStreamChunkReader that
+// implements io.ReadCloser. It is used to feed compressed data received
+// from the server into a decompressor.
+type byteStreamReader struct {
+	chunkReader byteStreamChunkReader
+	pendingData []byte
+}
+
+func (r *byteStreamReader) Read(p []byte) (int, error) {
+	for len(r.pendingData) == 0 {
+		chunk, err := r.chunkReader.Read()
+		if err != nil {
+			return 0, err
+		}
+		r.pendingData = chunk
+	}
+	n := copy(p, r.pendingData)
+	r.pendingData = r.pendingData[n:]
+	return n, nil
+}
+
+func (r *byteStreamReader) Close() error {
+	r.chunkReader.Close()
+	return nil
+}
+
+// decompressingReadCloser is returned by Get() when blobs are
+// downloaded in compressed form. Closing it causes both the
+// decompressor and the underlying stream to be closed.
+type decompressingReadCloser struct {
+	io.ReadCloser
+	underlyingReader io.Closer
+}
+
+func (r *decompressingReadCloser) Close() error {
+	r.ReadCloser.Close()
+	return r.underlyingReader.Close()
+}
+
 func (ba *casBlobAccess) Get(ctx context.Context, digest digest.Digest) buffer.Buffer {
 	ctxWithCancel, cancel := context.WithCancel(ctx)
-	resourceName := digest.GetByteStreamReadPath(remoteexecution.Compressor_IDENTITY)
+	resourceName := digest.GetByteStreamReadPath(ba.compressor)
 	client, err := ba.byteStreamClient.Read(
 		metadata.AppendToOutgoingContext(ctxWithCancel, resourceNameHeader, resourceName),
 		&bytestream.ReadRequest{
@@ -77,9 +123,23 @@ func (ba *casBlobAccess) Get(ctx context.Context, digest digest.Digest) buffer.B
 		cancel()
 		return buffer.NewBufferFromError(err)
 	}
-	return buffer.NewCASBufferFromChunkReader(digest, &byteStreamChunkReader{
+	chunkReader := byteStreamChunkReader{
 		client: client,
 		cancel: cancel,
+	}
+	if ba.compressor == remoteexecution.Compressor_IDENTITY {
+		return buffer.NewCASBufferFromChunkReader(digest, &chunkReader, buffer.BackendProvided(buffer.Irreparable(digest)))
+	}
+
+	compressedReader := &byteStreamReader{chunkReader: chunkReader}
+	decompressingReader, err := util.NewDecompressingReader(compressedReader, ba.compressor)
+	if err != nil {
+		compressedReader.Close()
+		return buffer.NewBufferFromError(err)
+	}
+	return buffer.NewCASBufferFromReader(digest, &decompressingReadCloser{
+		ReadCloser:       decompressingReader,
+		underlyingReader: compressedReader,
 	}, buffer.BackendProvided(buffer.Irreparable(digest)))
 }
 
@@ -88,12 +148,71 @@ func (ba *casBlobAccess) GetFromComposite(ctx context.Context, parentDigest, chi
 	return b
 }
 
+// compressingChunkReader is an implementation of buffer.ChunkReader
+// that reads data from a buffer, and returns it in compressed form. It
+// is used by Put() to upload blobs in compressed form.
+type compressingChunkReader struct {
+	r                 io.ReadCloser
+	readBuffer        []byte
+	compressedData    bytes.Buffer
+	compressingWriter io.WriteCloser
+	done              bool
+}
+
+func (r *compressingChunkReader) Read() ([]byte, error) {
+	for r.compressedData.Len() == 0 {
+		if r.done {
+			return nil, io.EOF
+		}
+		n, err := r.r.Read(r.readBuffer)
+		if n > 0 {
+			if _, err := r.compressingWriter.Write(r.readBuffer[:n]); err != nil {
+				return nil, err
+			}
+		}
+		if err == io.EOF {
+			// Flush any data that is still buffered by the
+			// compressor.
+			if err := r.compressingWriter.Close(); err != nil {
+				return nil, err
+			}
+			r.done = true
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	// Return the compressed data in chunks that don't exceed the
+	// configured chunk size.
+	chunk := make([]byte, min(r.compressedData.Len(), len(r.readBuffer)))
+	r.compressedData.Read(chunk)
+	return chunk, nil
+}
+
+func (r *compressingChunkReader) Close() {
+	r.r.Close()
+}
+
 func (ba *casBlobAccess) Put(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
-	r := b.ToChunkReader(0, ba.readChunkSize)
+	var r buffer.ChunkReader
+	if ba.compressor == remoteexecution.Compressor_IDENTITY {
+		r = b.ToChunkReader(0, ba.readChunkSize)
+	} else {
+		cr := &compressingChunkReader{
+			r:          b.ToReader(),
+			readBuffer: make([]byte, ba.readChunkSize),
+		}
+		compressingWriter, err := util.NewCompressingWriter(&cr.compressedData, ba.compressor)
+		if err != nil {
+			cr.Close()
+			return err
+		}
+		cr.compressingWriter = compressingWriter
+		r = cr
+	}
 	defer r.Close()
 
 	ctxWithCancel, cancel := context.WithCancel(ctx)
-	resourceName := digest.GetByteStreamWritePath(uuid.Must(ba.uuidGenerator()), remoteexecution.Compressor_IDENTITY)
+	resourceName := digest.GetByteStreamWritePath(uuid.Must(ba.uuidGenerator()), ba.compressor)
 	client, err := ba.byteStreamClient.Write(
 		metadata.AppendToOutgoingContext(ctxWithCancel, resourceNameHeader, resourceName),
 	)
@@ -1,6 +1,8 @@
 package grpcclients_test
 
 import (
+	"bytes"
+	"compress/flate"
 	"context"
 	"io"
 	"testing"
@@ -14,6 +16,7 @@ import (
 	"github.com/buildbarn/bb-storage/pkg/testutil"
 	"github.com/buildbarn/bb-storage/pkg/util"
 	"github.com/google/uuid"
+	"github.com/klauspost/compress/zstd"
 	"github.com/stretchr/testify/require"
 
 	"google.golang.org/genproto/googleapis/bytestream"
@@ -30,7 +33,7 @@ func TestCASBlobAccessPut(t *testing.T) {
 
 	client := mock.NewMockClientConnInterface(ctrl)
 	uuidGenerator := mock.NewMockUUIDGenerator(ctrl)
-	blobAccess := grpcclients.NewCASBlobAccess(client, uuidGenerator.Call, 10)
+	blobAccess := grpcclients.NewCASBlobAccess(client, uuidGenerator.Call, 10, remoteexecution.Compressor_IDENTITY)
 
 	blobDigest := digest.MustNewDigest("hello", remoteexecution.DigestFunction_MD5, "8b1a9953c4611296a827abf8c47804d7", 5)
 	uuid := uuid.Must(uuid.Parse("7d659e5f-0e4b-48f0-ad9f-3489db6e103b"))
@@ -163,12 +166,81 @@ func TestCASBlobAccessPut(t *testing.T) {
 	})
 }
 
+func TestCASBlobAccessCompressed(t *testing.T) {
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+
+	client := mock.NewMockClientConnInterface(ctrl)
+	uuidGenerator := mock.NewMockUUIDGenerator(ctrl)
+
+	blobDigest := digest.MustNewDigest("hello", remoteexecution.DigestFunction_MD5, "8b1a9953c4611296a827abf8c47804d7", 5)
+
+	t.Run("PutDeflate", func(t *testing.T) {
+		// Data should be compressed prior to being sent. The
+		// write offsets apply to the compressed data.
+		blobAccess := grpcclients.NewCASBlobAccess(client, uuidGenerator.Call, 10, remoteexecution.Compressor_DEFLATE)
+		clientStream := mock.NewMockClientStream(ctrl)
+		uuidGenerator.EXPECT().Call().Return(uuid.Must(uuid.Parse("7d659e5f-0e4b-48f0-ad9f-3489db6e103b")), nil)
+		client.EXPECT().NewStream(gomock.Any(), gomock.Any(), "/google.bytestream.ByteStream/Write").
+			Return(clientStream, nil)
+		var compressedData []byte
+		clientStream.EXPECT().SendMsg(gomock.Any()).DoAndReturn(func(m interface{}) error {
+			request := m.(*bytestream.WriteRequest)
+			if len(compressedData) == 0 {
+				require.Equal(t, "hello/uploads/7d659e5f-0e4b-48f0-ad9f-3489db6e103b/compressed-blobs/deflate/8b1a9953c4611296a827abf8c47804d7/5", request.ResourceName)
+			} else {
+				require.Empty(t, request.ResourceName)
+			}
+			require.Equal(t, int64(len(compressedData)), request.WriteOffset)
+			require.LessOrEqual(t, len(request.Data), 10)
+			compressedData = append(compressedData, request.Data...)
+			return nil
+		}).MinTimes(2)
+		clientStream.EXPECT().CloseSend()
+		clientStream.EXPECT().RecvMsg(gomock.Any())
+
+		require.NoError(t, blobAccess.Put(ctx, blobDigest, buffer.NewValidatedBufferFromByteSlice([]byte("Hello"))))
+
+		data, err := io.ReadAll(flate.NewReader(bytes.NewReader(compressedData)))
+		require.NoError(t, err)
+		require.Equal(t, []byte("Hello"), data)
+	})
+
+	t.Run("GetZSTD", func(t *testing.T) {
+		// Data returned by the server should be decompressed,
+		// and validated against the digest.
+		blobAccess := grpcclients.NewCASBlobAccess(client, uuidGenerator.Call, 10, remoteexecution.Compressor_ZSTD)
+		encoder, err := zstd.NewWriter(nil)
+		require.NoError(t, err)
+		compressedData := encoder.EncodeAll([]byte("Hello"), nil)
+		require.NoError(t, encoder.Close())
+
+		clientStream := mock.NewMockClientStream(ctrl)
+		client.EXPECT().NewStream(gomock.Any(), gomock.Any(), "/google.bytestream.ByteStream/Read").
+			Return(clientStream, nil)
+		clientStream.EXPECT().SendMsg(testutil.EqProto(t, &bytestream.ReadRequest{
+			ResourceName: "hello/compressed-blobs/zstd/8b1a9953c4611296a827abf8c47804d7/5",
+		}))
+		clientStream.EXPECT().CloseSend()
+		gomock.InOrder(
+			clientStream.EXPECT().RecvMsg(gomock.Any()).DoAndReturn(func(m interface{}) error {
+				proto.Merge(m.(proto.Message), &bytestream.ReadResponse{Data: compressedData})
+				return nil
+			}),
+			clientStream.EXPECT().RecvMsg(gomock.Any()).Return(io.EOF).AnyTimes(),
+		)
+
+		data, err := blobAccess.Get(ctx, blobDigest).ToByteSlice(100)
+		require.NoError(t, err)
+		require.Equal(t, []byte("Hello"), data)
+	})
+}
+
 func TestCASBlobAccessGetCapabilities(t *testing.T) {
 	ctrl, ctx := gomock.WithContext(context.Background(), t)
 
 	client := mock.NewMockClientConnInterface(ctrl)
 	uuidGenerator := mock.NewMockUUIDGenerator(ctrl)
-	blobAccess := grpcclients.NewCASBlobAccess(client, uuidGenerator.Call, 10)
+	blobAccess := grpcclients.NewCASBlobAccess(client, uuidGenerator.Call, 10, remoteexecution.Compressor_IDENTITY)
 
 	t.Run("BackendFailure", func(t *testing.T) {
 		client.EXPECT().Invoke(
@@ -2,25 +2,39 @@ package grpcservers
 
 import (
 	"context"
+	"sort"
 
 	remoteexecution "github.com/bazelbuild/remote-apis/build/bazel/remote/execution/v2"
 	"github.com/buildbarn/bb-storage/pkg/blobstore"
 	"github.com/buildbarn/bb-storage/pkg/blobstore/buffer"
 	"github.com/buildbarn/bb-storage/pkg/digest"
 	"github.com/buildbarn/bb-storage/pkg/util"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
 )
 
 type actionCacheServer struct {
-	blobAccess              blobstore.BlobAccess
-	maximumMessageSizeBytes int
+	blobAccess                blobstore.BlobAccess
+	contentAddressableStorage blobstore.BlobAccess
+	maximumMessageSizeBytes   int
+	maximumInlinedSizeBytes   int
 }
 
 // NewActionCacheServer creates a GRPC service for serving the contents
 // of a Bazel Action Cache (AC) to Bazel.
-func NewActionCacheServer(blobAccess blobstore.BlobAccess, maximumMessageSizeBytes int) remoteexecution.ActionCacheServer {
+//
+// If a Content Addressable Storage (CAS) is provided, GetActionResult()
+// respects the inline_stdout, inline_stderr and inline_output_files
+// fields of requests. The contents of the requested blobs are then
+// loaded from the CAS and embedded into the ActionResult, as long as
+// their total size does not exceed maximumInlinedSizeBytes.
+func NewActionCacheServer(blobAccess, contentAddressableStorage blobstore.BlobAccess, maximumMessageSizeBytes, maximumInlinedSizeBytes int) remoteexecution.ActionCacheServer {
 	return &actionCacheServer{
-		blobAccess:              blobAccess,
-		maximumMessageSizeBytes: maximumMessageSizeBytes,
+		blobAccess:                blobAccess,
+		contentAddressableStorage: contentAddressableStorage,
+		maximumMessageSizeBytes:   maximumMessageSizeBytes,
+		maximumInlinedSizeBytes:   maximumInlinedSizeBytes,
 	}
 }
 
@@ -43,9 +57,83 @@ func (s *actionCacheServer) GetActionResult(ctx context.Context, in *remoteexecu
 	if err != nil {
 		return nil, err
 	}
+	if s.contentAddressableStorage != nil {
+		s.inlineBlobs(ctx, digestFunction, in, actionResult.(*remoteexecution.ActionResult))
+	}
 	return actionResult.(*remoteexecution.ActionResult), nil
 }
 
+// inlineCandidate is a blob referenced by an ActionResult that the
+// client requested to be inlined.
+type inlineCandidate struct {
+	digest      digest.Digest
+	setContents func(contents []byte)
+}
+
+// inlineBlobs embeds the contents of standard output, standard error
+// and output files into an ActionResult, if requested by the client.
+//
+// REv2 permits servers to omit inlining, even if requested. Blobs are
+// therefore inlined on a best-effort basis. Smaller blobs are preferred
+// over larger ones, so that as many round trips as possible are
+// eliminated. Blobs that cannot be loaded from the CAS are skipped.
+func (s *actionCacheServer) inlineBlobs(ctx context.Context, digestFunction digest.Function, in *remoteexecution.GetActionResultRequest, actionResult *remoteexecution.ActionResult) {
+	var candidates []inlineCandidate
+	addCandidate := func(blobDigest *remoteexecution.Digest, setContents func(contents []byte)) {
+		if d, err := digestFunction.NewDigestFromProto(blobDigest); err == nil && d.GetSizeBytes() > 0 {
+			candidates = append(candidates, inlineCandidate{
+				digest:      d,
+				setContents: setContents,
+			})
+		}
+	}
+	if in.InlineStdout && actionResult.StdoutDigest != nil && len(actionResult.StdoutRaw) == 0 {
+		addCandidate(actionResult.StdoutDigest, func(contents []byte) { actionResult.StdoutRaw = contents })
+	}
+	if in.InlineStderr && actionResult.StderrDigest != nil && len(actionResult.StderrRaw) == 0 {
+		addCandidate(actionResult.StderrDigest, func(contents []byte) { actionResult.StderrRaw = contents })
+	}
+	if len(in.InlineOutputFiles) > 0 {
+		inlineOutputFiles := make(map[string]struct{}, len(in.InlineOutputFiles))
+		for _, path := range in.InlineOutputFiles {
+			inlineOutputFiles[path] = struct{}{}
+		}
+		for _, outputFile := range actionResult.OutputFiles {
+			if _, ok := inlineOutputFiles[outputFile.Path]; ok && outputFile.Digest != nil && len(outputFile.Contents) == 0 {
+				addCandidate(outputFile.Digest, func(contents []byte) { outputFile.Contents = contents })
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return
+	}
+
+	// Never let the response exceed the maximum message size.
+	budget := s.maximumMessageSizeBytes - proto.Size(actionResult)
+	if budget > s.maximumInlinedSizeBytes {
+		budget = s.maximumInlinedSizeBytes
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].digest.GetSizeBytes() < candidates[j].digest.GetSizeBytes()
+	})
+	for _, candidate := range candidates {
+		// Account for the field's tag and length prefix. For
+		// output files, the length prefix of the enclosing
+		// OutputFile message also grows.
+		sizeBytes := int(candidate.digest.GetSizeBytes())
+		cost := 1 + protowire.SizeBytes(sizeBytes) + protowire.SizeVarint(uint64(sizeBytes))
+		if cost > budget {
+			break
+		}
+		contents, err := s.contentAddressableStorage.Get(ctx, candidate.digest).ToByteSlice(sizeBytes)
+		if err != nil {
+			continue
+		}
+		candidate.setContents(contents)
+		budget -= cost
+	}
+}
+
 func (s *actionCacheServer) UpdateActionResult(ctx context.Context, in *remoteexecution.UpdateActionResultRequest) (*remoteexecution.ActionResult, error) {
 	instanceName, err := digest.NewInstanceName(in.InstanceName)
 	if err != nil {
@@ -0,0 +1,135 @@
+package grpcservers_test
+
+import (
+	"context"
+	"testing"
+
+	remoteexecution "github.com/bazelbuild/remote-apis/build/bazel/remote/execution/v2"
+	"github.com/buildbarn/bb-storage/internal/mock"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/buffer"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/grpcservers"
+	"github.com/buildbarn/bb-storage/pkg/digest"
+	"github.com/buildbarn/bb-storage/pkg/testutil"
+	"github.com/stretchr/testify/require"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestActionCacheServerGetActionResultInlining(t *testing.T) {
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+
+	actionCache := mock.NewMockBlobAccess(ctrl)
+	contentAddressableStorage := mock.NewMockBlobAccess(ctrl)
+	actionCacheServer := grpcservers.NewActionCacheServer(actionCache, contentAddressableStorage, 1<<16, 20)
+
+	actionDigest := digest.MustNewDigest("hello", remoteexecution.DigestFunction_MD5, "e8ad38b8e4d4d6d04e26a3e56bd1ec26", 123)
+	actionResult := &remoteexecution.ActionResult{
+		OutputFiles: []*remoteexecution.OutputFile{
+			{
+				Path: "large",
+				Digest: &remoteexecution.Digest{
+					Hash:      "68e109f0f40ca72a15e05cc22786f8e6",
+					SizeBytes: 10,
+				},
+			},
+			{
+				Path: "missing",
+				Digest: &remoteexecution.Digest{
+					Hash:      "f5a7924e621e84c9280a9a27e1bcb7f6",
+					SizeBytes: 5,
+				},
+			},
+			{
+				Path: "not_requested",
+				Digest: &remoteexecution.Digest{
+					Hash:      "8b1a9953c4611296a827abf8c47804d7",
+					SizeBytes: 5,
+				},
+			},
+		},
+		StdoutDigest: &remoteexecution.Digest{
+			Hash:      "8b1a9953c4611296a827abf8c47804d7",
+			SizeBytes: 5,
+		},
+		StderrDigest: &remoteexecution.Digest{
+			Hash:      "8b1a9953c4611296a827abf8c47804d7",
+			SizeBytes: 5,
+		},
+	}
+
+	t.Run("NoInliningRequested", func(t *testing.T) {
+		actionCache.EXPECT().Get(ctx, actionDigest).
+			Return(buffer.NewProtoBufferFromProto(actionResult, buffer.UserProvided))
+
+		response, err := actionCacheServer.GetActionResult(ctx, &remoteexecution.GetActionResultRequest{
+			InstanceName: "hello",
+			ActionDigest: &remoteexecution.Digest{
+				Hash:      "e8ad38b8e4d4d6d04e26a3e56bd1ec26",
+				SizeBytes: 123,
+			},
+		})
+		require.NoError(t, err)
+		testutil.RequireEqualProto(t, actionResult, response)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		// Standard output and the missing output file both fit
+		// in the budget, but the latter cannot be loaded from
+		// the CAS and should be skipped. The large output file
+		// does not fit in the remaining budget.
+		actionCache.EXPECT().Get(ctx, actionDigest).
+			Return(buffer.NewProtoBufferFromProto(actionResult, buffer.UserProvided))
+		contentAddressableStorage.EXPECT().Get(ctx, digest.MustNewDigest("hello", remoteexecution.DigestFunction_MD5, "8b1a9953c4611296a827abf8c47804d7", 5)).
+			Return(buffer.NewValidatedBufferFromByteSlice([]byte("Hello")))
+		contentAddressableStorage.EXPECT().Get(ctx, digest.MustNewDigest("hello", remoteexecution.DigestFunction_MD5, "f5a7924e621e84c9280a9a27e1bcb7f6", 5)).
+			Return(buffer.NewBufferFromError(status.Error(codes.NotFound, "Object not found")))
+
+		response, err := actionCacheServer.GetActionResult(ctx, &remoteexecution.GetActionResultRequest{
+			InstanceName: "hello",
+			ActionDigest: &remoteexecution.Digest{
+				Hash:      "e8ad38b8e4d4d6d04e26a3e56bd1ec26",
+				SizeBytes: 123,
+			},
+			InlineStdout:      true,
+			InlineOutputFiles: []string{"large", "missing"},
+		})
+		require.NoError(t, err)
+		testutil.RequireEqualProto(t, &remoteexecution.ActionResult{
+			OutputFiles: []*remoteexecution.OutputFile{
+				{
+					Path: "large",
+					Digest: &remoteexecution.Digest{
+						Hash:      "68e109f0f40ca72a15e05cc22786f8e6",
+						SizeBytes: 10,
+					},
+				},
+				{
+					Path: "missing",
+					Digest: &remoteexecution.Digest{
+						Hash:      "f5a7924e621e84c9280a9a27e1bcb7f6",
+						SizeBytes: 5,
+					},
+				},
+				{
+					Path: "not_requested",
+					Digest: &remoteexecution.Digest{
+						Hash:      "8b1a9953c4611296a827abf8c47804d7",
+						SizeBytes: 5,
+					},
+				},
+			},
+			StdoutDigest: &remoteexecution.Digest{
+				Hash:      "8b1a9953c4611296a827abf8c47804d7",
+				SizeBytes: 5,
+			},
+			StdoutRaw: []byte("Hello"),
+			StderrDigest: &remoteexecution.Digest{
+				Hash:      "8b1a9953c4611296a827abf8c47804d7",
+				SizeBytes: 5,
+			},
+		}, response)
+	})
+}
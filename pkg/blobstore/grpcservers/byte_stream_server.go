@@ -10,7 +10,6 @@ import (
 	"github.com/buildbarn/bb-storage/pkg/blobstore/buffer"
 	"github.com/buildbarn/bb-storage/pkg/digest"
 	"github.com/buildbarn/bb-storage/pkg/util"
-	"github.com/klauspost/compress/zstd"
 
 	"google.golang.org/genproto/googleapis/bytestream"
 	"google.golang.org/grpc/codes"
@@ -18,23 +17,29 @@ import (
 )
 
 type byteStreamServer struct {
-	blobAccess    blobstore.BlobAccess
-	readChunkSize int
+	blobAccess        blobstore.BlobAccess
+	readChunkSize     int
+	uploadStagingArea *UploadStagingArea
 }
 
 // NewByteStreamServer creates a GRPC service for reading blobs from and
 // writing blobs to a BlobAccess. It is used by Bazel to access the
 // Content Addressable Storage (CAS).
-func NewByteStreamServer(blobAccess blobstore.BlobAccess, readChunkSize int) bytestream.ByteStreamServer {
+//
+// If an UploadStagingArea is provided, data of uploads is stored in the
+// staging area prior to being written to the BlobAccess. This permits
+// clients to resume uploads that got interrupted.
+func NewByteStreamServer(blobAccess blobstore.BlobAccess, readChunkSize int, uploadStagingArea *UploadStagingArea) bytestream.ByteStreamServer {
 	return &byteStreamServer{
-		blobAccess:    blobAccess,
-		readChunkSize: readChunkSize,
+		blobAccess:        blobAccess,
+		readChunkSize:     readChunkSize,
+		uploadStagingArea: uploadStagingArea,
 	}
 }
 
 func (s *byteStreamServer) Read(in *bytestream.ReadRequest, out bytestream.ByteStream_ReadServer) error {
-	if in.ReadLimit != 0 {
-		return status.Error(codes.Unimplemented, "This service does not support downloading partial files")
+	if in.ReadLimit < 0 {
+		return status.Errorf(codes.InvalidArgument, "Negative read limit: %d", in.ReadLimit)
 	}
 	digest, compressor, err := digest.NewDigestFromByteStreamReadPath(in.ResourceName)
 	if err != nil {
@@ -42,7 +47,9 @@ func (s *byteStreamServer) Read(in *bytestream.ReadRequest, out bytestream.ByteS
 	}
 	switch compressor {
 	case remoteexecution.Compressor_IDENTITY:
-		r := s.blobAccess.Get(out.Context(), digest).ToChunkReader(in.ReadOffset, s.readChunkSize)
+		r := newLimitedChunkReader(
+			s.blobAccess.Get(out.Context(), digest).ToChunkReader(in.ReadOffset, s.readChunkSize),
+			in.ReadLimit)
 		defer r.Close()
 
 		for {
@@ -58,19 +65,83 @@ func (s *byteStreamServer) Read(in *bytestream.ReadRequest, out bytestream.ByteS
 			}
 		}
 
-	case remoteexecution.Compressor_ZSTD:
-		b := s.blobAccess.Get(out.Context(), digest)
-		zstdWriter, err := zstd.NewWriter(&readStreamWriter{out: out}, zstd.WithEncoderConcurrency(1))
+	case remoteexecution.Compressor_DEFLATE, remoteexecution.Compressor_ZSTD:
+		// As specified by REv2, the read offset and limit
+		// apply to the uncompressed data. Only compress the
+		// part of the blob that was requested.
+		r := newLimitedChunkReader(
+			s.blobAccess.Get(out.Context(), digest).ToChunkReader(in.ReadOffset, s.readChunkSize),
+			in.ReadLimit)
+		defer r.Close()
+
+		compressingWriter, err := util.NewCompressingWriter(&readStreamWriter{out: out}, compressor)
 		if err != nil {
-			return status.Errorf(codes.Internal, "Failed to create zstd writer: %v", err)
+			return err
+		}
+		for {
+			readBuf, readErr := r.Read()
+			if readErr == io.EOF {
+				return compressingWriter.Close()
+			}
+			if readErr != nil {
+				compressingWriter.Close()
+				return readErr
+			}
+			if _, writeErr := compressingWriter.Write(readBuf); writeErr != nil {
+				compressingWriter.Close()
+				return writeErr
+			}
 		}
-		defer zstdWriter.Close()
-		return b.IntoWriter(zstdWriter)
 	default:
 		return status.Errorf(codes.Unimplemented, "This service does not support downloading compression type: %s", compressor)
 	}
 }
 
+// limitedChunkReader is a decorator for ChunkReader that returns no
+// more than a fixed amount of data. It is used to implement the
+// read_limit field of ByteStream read requests.
+//
+// Once the limit is reached, the remainder of the blob is read and
+// discarded. This ensures that the integrity of the blob is still
+// validated, so that corrupted data is reported and repaired, even if
+// the client only requested part of it.
+type limitedChunkReader struct {
+	buffer.ChunkReader
+	remaining int64
+}
+
+// newLimitedChunkReader creates a limitedChunkReader. In accordance
+// with the ByteStream specification, a limit of zero indicates that no
+// limit should be applied.
+func newLimitedChunkReader(r buffer.ChunkReader, limit int64) buffer.ChunkReader {
+	if limit == 0 {
+		return r
+	}
+	return &limitedChunkReader{
+		ChunkReader: r,
+		remaining:   limit,
+	}
+}
+
+func (r *limitedChunkReader) Read() ([]byte, error) {
+	if r.remaining == 0 {
+		for {
+			if _, err := r.ChunkReader.Read(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	chunk, err := r.ChunkReader.Read()
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(chunk)) > r.remaining {
+		chunk = chunk[:r.remaining]
+	}
+	r.remaining -= int64(len(chunk))
+	return chunk, nil
+}
+
 // readStreamWriter adapts the ByteStream_ReadServer to an io.Writer.
 type readStreamWriter struct {
 	out bytestream.ByteStream_ReadServer
@@ -138,14 +209,107 @@ func (s *byteStreamServer) Write(stream bytestream.ByteStream_WriteServer) error
 	if err != nil {
 		return err
 	}
+	switch compressor {
+	case remoteexecution.Compressor_IDENTITY, remoteexecution.Compressor_DEFLATE, remoteexecution.Compressor_ZSTD:
+	default:
+		return status.Errorf(codes.Unimplemented, "This service does not support uploading compression type: %s", compressor)
+	}
+	if s.uploadStagingArea != nil && s.uploadStagingArea.shouldStage(digest.GetSizeBytes()) {
+		return s.writeStaged(stream, request, digest, compressor)
+	}
+
 	switch compressor {
 	case remoteexecution.Compressor_IDENTITY:
 		return s.writeIdentity(stream, request, digest)
-	case remoteexecution.Compressor_ZSTD:
-		return s.writeZstd(stream, request, digest)
 	default:
-		return status.Errorf(codes.Unimplemented, "This service does not support uploading compression type: %s", compressor)
+		return s.writeCompressed(stream, request, digest, compressor)
+	}
+}
+
+// writeStaged processes a ByteStream upload by first storing all of
+// its data in the upload staging area. Once the client has finished
+// the write, the data is decompressed if needed, and written to the
+// BlobAccess. If the client disconnects before finishing the write, the
+// data remains present in the staging area, so that the upload may be
+// resumed.
+func (s *byteStreamServer) writeStaged(stream bytestream.ByteStream_WriteServer, request *bytestream.WriteRequest, digest digest.Digest, compressor remoteexecution.Compressor_Value) error {
+	ctx := stream.Context()
+	upload, err := s.uploadStagingArea.acquire(ctx, digest.GetInstanceName(), request.ResourceName, request.WriteOffset)
+	if err != nil {
+		return err
+	}
+	completed := false
+	defer func() {
+		s.uploadStagingArea.release(upload, completed)
+	}()
+
+	for {
+		if request.WriteOffset != upload.sizeBytes {
+			return status.Errorf(codes.InvalidArgument, "Attempted to write at offset %d, while %d was expected", request.WriteOffset, upload.sizeBytes)
+		}
+		if ok, err := s.uploadStagingArea.append(upload, request.Data); err != nil {
+			return err
+		} else if !ok {
+			// The staging area is full. Write the data
+			// that was staged previously, followed by the
+			// remainder of the stream to the BlobAccess
+			// directly. This means that the upload can no
+			// longer be resumed.
+			completed = true
+			streamReader := &writeStreamReader{
+				stream:      stream,
+				nextOffset:  request.WriteOffset + int64(len(request.Data)),
+				finished:    request.FinishWrite,
+				pendingData: request.Data,
+			}
+			if err := s.putFromReader(
+				ctx,
+				digest,
+				compressor,
+				io.NopCloser(io.MultiReader(s.uploadStagingArea.newReader(upload), streamReader))); err != nil {
+				return err
+			}
+			return stream.SendAndClose(&bytestream.WriteResponse{
+				CommittedSize: streamReader.nextOffset,
+			})
+		}
+		if request.FinishWrite {
+			break
+		}
+		request, err = stream.Recv()
+		if err != nil {
+			if err == io.EOF {
+				return status.Error(codes.InvalidArgument, "Client closed stream without finishing write")
+			}
+			return err
+		}
+	}
+
+	// The client finished the write. Regardless of whether the data
+	// is valid, there is no point in retaining it.
+	completed = true
+	if err := s.putFromReader(ctx, digest, compressor, s.uploadStagingArea.newReader(upload)); err != nil {
+		return err
+	}
+	return stream.SendAndClose(&bytestream.WriteResponse{
+		CommittedSize: upload.sizeBytes,
+	})
+}
+
+// putFromReader writes the data of a ByteStream upload to the
+// BlobAccess, decompressing it if needed.
+func (s *byteStreamServer) putFromReader(ctx context.Context, digest digest.Digest, compressor remoteexecution.Compressor_Value, r io.ReadCloser) error {
+	if compressor != remoteexecution.Compressor_IDENTITY {
+		var err error
+		r, err = util.NewDecompressingReader(r, compressor)
+		if err != nil {
+			return err
+		}
 	}
+	return s.blobAccess.Put(
+		ctx,
+		digest,
+		buffer.NewCASBufferFromReader(digest, r, buffer.UserProvided))
 }
 
 func (s *byteStreamServer) writeIdentity(stream bytestream.ByteStream_WriteServer, request *bytestream.WriteRequest, digest digest.Digest) error {
@@ -164,14 +328,17 @@ func (s *byteStreamServer) writeIdentity(stream bytestream.ByteStream_WriteServe
 	})
 }
 
-type zstdWriteStreamReader struct {
+// writeStreamReader adapts the ByteStream_WriteServer to an
+// io.ReadCloser, returning the data of all write requests up to and
+// including the one that finishes the write.
+type writeStreamReader struct {
 	stream      bytestream.ByteStream_WriteServer
 	nextOffset  int64
 	finished    bool
 	pendingData []byte
 }
 
-func (r *zstdWriteStreamReader) Read(p []byte) (n int, err error) {
+func (r *writeStreamReader) Read(p []byte) (n int, err error) {
 	if len(r.pendingData) > 0 {
 		n = copy(p, r.pendingData)
 		r.pendingData = r.pendingData[n:]
@@ -202,28 +369,28 @@ func (r *zstdWriteStreamReader) Read(p []byte) (n int, err error) {
 	return n, nil
 }
 
-func (r *zstdWriteStreamReader) Close() error {
+func (r *writeStreamReader) Close() error {
 	return nil
 }
 
-func (s *byteStreamServer) writeZstd(stream bytestream.ByteStream_WriteServer, request *bytestream.WriteRequest, digest digest.Digest) error {
-	streamReader := &zstdWriteStreamReader{
+func (s *byteStreamServer) writeCompressed(stream bytestream.ByteStream_WriteServer, request *bytestream.WriteRequest, digest digest.Digest, compressor remoteexecution.Compressor_Value) error {
+	streamReader := &writeStreamReader{
 		stream:      stream,
 		nextOffset:  int64(len(request.Data)),
 		finished:    request.FinishWrite,
 		pendingData: request.Data,
 	}
 
-	zstdReader, err := util.NewZstdReadCloser(streamReader, zstd.WithDecoderConcurrency(1))
+	decompressingReader, err := util.NewDecompressingReader(streamReader, compressor)
 	if err != nil {
 		return err
 	}
-	defer zstdReader.Close()
+	defer decompressingReader.Close()
 
 	if err := s.blobAccess.Put(
 		stream.Context(),
 		digest,
-		buffer.NewCASBufferFromReader(digest, zstdReader, buffer.UserProvided)); err != nil {
+		buffer.NewCASBufferFromReader(digest, decompressingReader, buffer.UserProvided)); err != nil {
 		return err
 	}
 	return stream.SendAndClose(&bytestream.WriteResponse{
@@ -232,5 +399,40 @@ func (s *byteStreamServer) writeZstd(stream bytestream.ByteStream_WriteServer, r
 }
 
 func (s *byteStreamServer) QueryWriteStatus(ctx context.Context, in *bytestream.QueryWriteStatusRequest) (*bytestream.QueryWriteStatusResponse, error) {
-	return nil, status.Error(codes.Unimplemented, "This service does not support querying write status")
+	digest, compressor, err := digest.NewDigestFromByteStreamWritePath(in.ResourceName)
+	if err != nil {
+		return nil, err
+	}
+	if s.uploadStagingArea != nil {
+		committedSize, ok, err := s.uploadStagingArea.getCommittedSize(ctx, digest.GetInstanceName(), in.ResourceName)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return &bytestream.QueryWriteStatusResponse{
+				CommittedSize: committedSize,
+			}, nil
+		}
+	}
+
+	// The upload is not in progress. It may have completed
+	// already, either by this client or by another.
+	missing, err := s.blobAccess.FindMissing(ctx, digest.ToSingletonSet())
+	if err != nil {
+		return nil, err
+	}
+	if !missing.Empty() {
+		return nil, status.Errorf(codes.NotFound, "Upload %#v does not exist", in.ResourceName)
+	}
+	// As specified by REv2, the committed size of completed
+	// compressed uploads is reported as -1, as the size of the
+	// compressed data is not known.
+	committedSize := digest.GetSizeBytes()
+	if compressor != remoteexecution.Compressor_IDENTITY {
+		committedSize = -1
+	}
+	return &bytestream.QueryWriteStatusResponse{
+		CommittedSize: committedSize,
+		Complete:      true,
+	}, nil
 }
@@ -1,6 +1,8 @@
 package grpcservers_test
 
 import (
+	"bytes"
+	"compress/flate"
 	"context"
 	"fmt"
 	"io"
@@ -13,7 +15,10 @@ import (
 	"github.com/buildbarn/bb-storage/pkg/blobstore/buffer"
 	"github.com/buildbarn/bb-storage/pkg/blobstore/grpcservers"
 	"github.com/buildbarn/bb-storage/pkg/digest"
+	"github.com/buildbarn/bb-storage/pkg/filesystem"
+	"github.com/buildbarn/bb-storage/pkg/filesystem/path"
 	"github.com/buildbarn/bb-storage/pkg/testutil"
+	"github.com/buildbarn/bb-storage/pkg/util"
 	"github.com/klauspost/compress/zstd"
 	"github.com/stretchr/testify/require"
 
@@ -33,7 +38,7 @@ func TestByteStreamServer(t *testing.T) {
 	l := bufconn.Listen(1 << 20)
 	server := grpc.NewServer()
 	blobAccess := mock.NewMockBlobAccess(ctrl)
-	bytestream.RegisterByteStreamServer(server, grpcservers.NewByteStreamServer(blobAccess, 10))
+	bytestream.RegisterByteStreamServer(server, grpcservers.NewByteStreamServer(blobAccess, 10, nil))
 	go func() {
 		require.NoError(t, server.Serve(l))
 	}()
@@ -159,6 +164,34 @@ func TestByteStreamServer(t *testing.T) {
 		require.Equal(t, originalData, decompressedData)
 	})
 
+	t.Run("ReadDeflateCompression", func(t *testing.T) {
+		// Test reading with DEFLATE compression.
+		originalData := []byte("This is a test message that should be compressed with DEFLATE")
+		blobAccess.EXPECT().Get(
+			gomock.Any(),
+			digest.MustNewDigest("", remoteexecution.DigestFunction_SHA256, "8b2c3f8a9d0e1f2a3b4c5d6e7f8a9b0c1d2e3f4a5b6c7d8e9f0a1b2c3d4e5f61", 61),
+		).Return(buffer.NewValidatedBufferFromByteSlice(originalData))
+
+		req, err := client.Read(ctx, &bytestream.ReadRequest{
+			ResourceName: "compressed-blobs/deflate/8b2c3f8a9d0e1f2a3b4c5d6e7f8a9b0c1d2e3f4a5b6c7d8e9f0a1b2c3d4e5f61/61",
+		})
+		require.NoError(t, err)
+
+		var compressedData []byte
+		for {
+			response, err := req.Recv()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			compressedData = append(compressedData, response.Data...)
+		}
+
+		decompressedData, err := io.ReadAll(flate.NewReader(bytes.NewReader(compressedData)))
+		require.NoError(t, err)
+		require.Equal(t, originalData, decompressedData)
+	})
+
 	t.Run("ReadZSTDCompressionLargeData", func(t *testing.T) {
 		// Test reading large data with ZSTD compression.
 		originalData := make([]byte, 100000)
@@ -263,6 +296,141 @@ func TestByteStreamServer(t *testing.T) {
 		require.Equal(t, io.EOF, err)
 	})
 
+	t.Run("ReadNegativeReadLimit", func(t *testing.T) {
+		// Attempt to fetch a blob with a negative limit.
+		req, err := client.Read(ctx, &bytestream.ReadRequest{
+			ResourceName: "ubuntu1804/blobs/da39a3ee5e6b4b0d3255bfef95601890/19",
+			ReadLimit:    -1,
+		})
+		require.NoError(t, err)
+		_, err = req.Recv()
+		testutil.RequireEqualStatus(t, status.Error(codes.InvalidArgument, "Negative read limit: -1"), err)
+	})
+
+	t.Run("ReadSuccessWithOffsetAndLimit", func(t *testing.T) {
+		// Attempt to fetch a range of a blob. The limit is
+		// smaller than the chunk size, meaning only a part of
+		// the first chunk should be returned.
+		blobAccess.EXPECT().Get(
+			gomock.Any(),
+			digest.MustNewDigest("ubuntu1804", remoteexecution.DigestFunction_MD5, "da39a3ee5e6b4b0d3255bfef95601890", 19),
+		).Return(buffer.NewValidatedBufferFromByteSlice([]byte("This offset message")))
+
+		req, err := client.Read(ctx, &bytestream.ReadRequest{
+			ResourceName: "ubuntu1804/blobs/da39a3ee5e6b4b0d3255bfef95601890/19",
+			ReadOffset:   5,
+			ReadLimit:    6,
+		})
+		require.NoError(t, err)
+		readResponse, err := req.Recv()
+		require.NoError(t, err)
+		require.Equal(t, []byte("offset"), readResponse.Data)
+		_, err = req.Recv()
+		require.Equal(t, io.EOF, err)
+	})
+
+	t.Run("ReadSuccessWithLimitSpanningChunks", func(t *testing.T) {
+		// A limit that exceeds the chunk size should cause
+		// multiple chunks to be returned.
+		blobAccess.EXPECT().Get(
+			gomock.Any(),
+			digest.MustNewDigest("ubuntu1804", remoteexecution.DigestFunction_MD5, "da39a3ee5e6b4b0d3255bfef95601890", 19),
+		).Return(buffer.NewValidatedBufferFromByteSlice([]byte("This offset message")))
+
+		req, err := client.Read(ctx, &bytestream.ReadRequest{
+			ResourceName: "ubuntu1804/blobs/da39a3ee5e6b4b0d3255bfef95601890/19",
+			ReadLimit:    14,
+		})
+		require.NoError(t, err)
+		readResponse, err := req.Recv()
+		require.NoError(t, err)
+		require.Equal(t, []byte("This offse"), readResponse.Data)
+		readResponse, err = req.Recv()
+		require.NoError(t, err)
+		require.Equal(t, []byte("t me"), readResponse.Data)
+		_, err = req.Recv()
+		require.Equal(t, io.EOF, err)
+	})
+
+	t.Run("ReadSuccessWithLimitBeyondEnd", func(t *testing.T) {
+		// A limit that exceeds the size of the blob should
+		// cause the remainder of the blob to be returned.
+		blobAccess.EXPECT().Get(
+			gomock.Any(),
+			digest.MustNewDigest("ubuntu1804", remoteexecution.DigestFunction_MD5, "da39a3ee5e6b4b0d3255bfef95601890", 19),
+		).Return(buffer.NewValidatedBufferFromByteSlice([]byte("This offset message")))
+
+		req, err := client.Read(ctx, &bytestream.ReadRequest{
+			ResourceName: "ubuntu1804/blobs/da39a3ee5e6b4b0d3255bfef95601890/19",
+			ReadOffset:   12,
+			ReadLimit:    100,
+		})
+		require.NoError(t, err)
+		readResponse, err := req.Recv()
+		require.NoError(t, err)
+		require.Equal(t, []byte("message"), readResponse.Data)
+		_, err = req.Recv()
+		require.Equal(t, io.EOF, err)
+	})
+
+	t.Run("ReadCorruptedDataWithLimit", func(t *testing.T) {
+		// Even though the client only requests part of the
+		// blob, the entire blob should be validated. Data
+		// corruption should be reported after the requested
+		// data has been returned.
+		blobDigest := digest.MustNewDigest("ubuntu1804", remoteexecution.DigestFunction_MD5, "da39a3ee5e6b4b0d3255bfef95601890", 19)
+		dataIntegrityCallback := mock.NewMockDataIntegrityCallback(ctrl)
+		dataIntegrityCallback.EXPECT().Call(false)
+		blobAccess.EXPECT().Get(gomock.Any(), blobDigest).
+			Return(buffer.NewCASBufferFromReader(blobDigest, io.NopCloser(bytes.NewBufferString("This offset message")), buffer.BackendProvided(dataIntegrityCallback.Call)))
+
+		req, err := client.Read(ctx, &bytestream.ReadRequest{
+			ResourceName: "ubuntu1804/blobs/da39a3ee5e6b4b0d3255bfef95601890/19",
+			ReadLimit:    4,
+		})
+		require.NoError(t, err)
+		readResponse, err := req.Recv()
+		require.NoError(t, err)
+		require.Equal(t, []byte("This"), readResponse.Data)
+		_, err = req.Recv()
+		testutil.RequireEqualStatus(t, status.Error(codes.Internal, "Buffer has checksum cc5efe432d43289162c1f1d3e387fed2, while da39a3ee5e6b4b0d3255bfef95601890 was expected"), err)
+	})
+
+	t.Run("ReadZSTDCompressionWithOffsetAndLimit", func(t *testing.T) {
+		// The offset and limit should apply to the
+		// uncompressed data.
+		originalData := []byte("This is a test message that should be compressed with ZSTD")
+		blobAccess.EXPECT().Get(
+			gomock.Any(),
+			digest.MustNewDigest("", remoteexecution.DigestFunction_SHA256, "8b2c3f8a9d0e1f2a3b4c5d6e7f8a9b0c1d2e3f4a5b6c7d8e9f0a1b2c3d4e5f61", 58),
+		).Return(buffer.NewValidatedBufferFromByteSlice(originalData))
+
+		req, err := client.Read(ctx, &bytestream.ReadRequest{
+			ResourceName: "compressed-blobs/zstd/8b2c3f8a9d0e1f2a3b4c5d6e7f8a9b0c1d2e3f4a5b6c7d8e9f0a1b2c3d4e5f61/58",
+			ReadOffset:   10,
+			ReadLimit:    12,
+		})
+		require.NoError(t, err)
+
+		var compressedData []byte
+		for {
+			response, err := req.Recv()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			compressedData = append(compressedData, response.Data...)
+		}
+
+		decoder, err := zstd.NewReader(nil)
+		require.NoError(t, err)
+		defer decoder.Close()
+
+		decompressedData, err := decoder.DecodeAll(compressedData, nil)
+		require.NoError(t, err)
+		require.Equal(t, []byte("test message"), decompressedData)
+	})
+
 	t.Run("ReadNonexistentBlob", func(t *testing.T) {
 		// Attempt to fetch a nonexistent blob.
 		blobAccess.EXPECT().Get(
@@ -364,6 +532,45 @@ func TestByteStreamServer(t *testing.T) {
 		require.Equal(t, int64(len(compressedData)), response.CommittedSize)
 	})
 
+	t.Run("WriteDeflateDecompression", func(t *testing.T) {
+		// Test writing with DEFLATE decompression.
+		originalData := []byte("This is a test message that should be compressed with DEFLATE for upload")
+
+		var compressedData bytes.Buffer
+		deflateWriter, err := flate.NewWriter(&compressedData, flate.BestCompression)
+		require.NoError(t, err)
+		_, err = deflateWriter.Write(originalData)
+		require.NoError(t, err)
+		require.NoError(t, deflateWriter.Close())
+
+		digestFunction := digest.MustNewFunction("", remoteexecution.DigestFunction_SHA256)
+		generator := digestFunction.NewGenerator(int64(len(originalData)))
+		generator.Write(originalData)
+		actualDigest := generator.Sum()
+
+		blobAccess.EXPECT().Put(
+			gomock.Any(),
+			actualDigest,
+			gomock.Any(),
+		).DoAndReturn(func(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
+			data, err := b.ToByteSlice(1000)
+			require.NoError(t, err)
+			require.Equal(t, originalData, data)
+			return nil
+		})
+
+		stream, err := client.Write(ctx)
+		require.NoError(t, err)
+		require.NoError(t, stream.Send(&bytestream.WriteRequest{
+			ResourceName: "uploads/7de747e0-ab6b-4d83-90cb-11989f84c473/compressed-blobs/deflate/" + actualDigest.GetHashString() + "/" + fmt.Sprintf("%d", len(originalData)),
+			Data:         compressedData.Bytes(),
+			FinishWrite:  true,
+		}))
+		response, err := stream.CloseAndRecv()
+		require.NoError(t, err)
+		require.Equal(t, int64(compressedData.Len()), response.CommittedSize)
+	})
+
 	t.Run("WriteZSTDDecompressionChunked", func(t *testing.T) {
 		// Test writing with ZSTD decompression in multiple chunks.
 		originalData := []byte("This is a longer test message that should be compressed with ZSTD and sent in multiple chunks to test streaming decompression")
@@ -624,10 +831,331 @@ func TestByteStreamServer(t *testing.T) {
 		testutil.RequireEqualStatus(t, status.Error(codes.InvalidArgument, "Attempted to write at offset 4, while 5 was expected"), err)
 	})
 
-	t.Run("QueryWriteStatus", func(t *testing.T) {
+	t.Run("QueryWriteStatusNotFound", func(t *testing.T) {
+		// Without an upload staging area, uploads can only be
+		// reported as being complete if the blob is present.
+		blobDigest := digest.MustNewDigest("windows10", remoteexecution.DigestFunction_MD5, "68e109f0f40ca72a15e05cc22786f8e6", 10)
+		blobAccess.EXPECT().FindMissing(gomock.Any(), blobDigest.ToSingletonSet()).
+			Return(blobDigest.ToSingletonSet(), nil)
+
 		_, err := client.QueryWriteStatus(ctx, &bytestream.QueryWriteStatusRequest{
 			ResourceName: "windows10/uploads/d834d9c2-f3c9-4f30-a698-75fd4be9470d/blobs/68e109f0f40ca72a15e05cc22786f8e6/10",
 		})
-		testutil.RequireEqualStatus(t, status.Error(codes.Unimplemented, "This service does not support querying write status"), err)
+		testutil.RequireEqualStatus(t, status.Error(codes.NotFound, "Upload \"windows10/uploads/d834d9c2-f3c9-4f30-a698-75fd4be9470d/blobs/68e109f0f40ca72a15e05cc22786f8e6/10\" does not exist"), err)
+	})
+
+	t.Run("QueryWriteStatusComplete", func(t *testing.T) {
+		blobDigest := digest.MustNewDigest("windows10", remoteexecution.DigestFunction_MD5, "68e109f0f40ca72a15e05cc22786f8e6", 10)
+		blobAccess.EXPECT().FindMissing(gomock.Any(), blobDigest.ToSingletonSet()).
+			Return(digest.EmptySet, nil).
+			Times(2)
+
+		response, err := client.QueryWriteStatus(ctx, &bytestream.QueryWriteStatusRequest{
+			ResourceName: "windows10/uploads/d834d9c2-f3c9-4f30-a698-75fd4be9470d/blobs/68e109f0f40ca72a15e05cc22786f8e6/10",
+		})
+		require.NoError(t, err)
+		testutil.RequireEqualProto(t, &bytestream.QueryWriteStatusResponse{
+			CommittedSize: 10,
+			Complete:      true,
+		}, response)
+
+		// For compressed uploads, the size of the compressed
+		// data is unknown.
+		response, err = client.QueryWriteStatus(ctx, &bytestream.QueryWriteStatusRequest{
+			ResourceName: "windows10/uploads/d834d9c2-f3c9-4f30-a698-75fd4be9470d/compressed-blobs/zstd/68e109f0f40ca72a15e05cc22786f8e6/10",
+		})
+		require.NoError(t, err)
+		testutil.RequireEqualProto(t, &bytestream.QueryWriteStatusResponse{
+			CommittedSize: -1,
+			Complete:      true,
+		}, response)
+	})
+}
+
+func TestByteStreamServerResumableUploads(t *testing.T) {
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+
+	stagingDirectory, err := filesystem.NewLocalDirectory(path.LocalFormat.NewParser(t.TempDir()))
+	require.NoError(t, err)
+	defer stagingDirectory.Close()
+	clock := mock.NewMockClock(ctrl)
+	clock.EXPECT().Now().Return(time.Unix(1000, 0)).AnyTimes()
+
+	authorizer := mock.NewMockAuthorizer(ctrl)
+	authorizer.EXPECT().Authorize(gomock.Any(), []digest.InstanceName{util.Must(digest.NewInstanceName("windows10"))}).
+		Return([]error{nil}).
+		AnyTimes()
+	authorizer.EXPECT().Authorize(gomock.Any(), []digest.InstanceName{util.Must(digest.NewInstanceName("forbidden"))}).
+		Return([]error{status.Error(codes.PermissionDenied, "You shall not pass")}).
+		AnyTimes()
+
+	// Create an RPC server/client pair, using an upload staging
+	// area that can hold at most 20 bytes of data.
+	l := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	blobAccess := mock.NewMockBlobAccess(ctrl)
+	uploadStagingArea := grpcservers.NewUploadStagingArea(stagingDirectory, clock, authorizer, 5, 20, time.Hour)
+	bytestream.RegisterByteStreamServer(server, grpcservers.NewByteStreamServer(blobAccess, 10, uploadStagingArea))
+	go func() {
+		require.NoError(t, server.Serve(l))
+	}()
+	conn, err := grpc.DialContext(ctx, "bufnet", grpc.WithDialer(func(string, time.Duration) (net.Conn, error) {
+		return l.Dial()
+	}), grpc.WithInsecure())
+	require.NoError(t, err)
+	defer server.Stop()
+	defer conn.Close()
+	client := bytestream.NewByteStreamClient(conn)
+
+	resourceName := "windows10/uploads/d834d9c2-f3c9-4f30-a698-75fd4be9470d/blobs/68e109f0f40ca72a15e05cc22786f8e6/10"
+	blobDigest := digest.MustNewDigest("windows10", remoteexecution.DigestFunction_MD5, "68e109f0f40ca72a15e05cc22786f8e6", 10)
+
+	t.Run("ResumeAfterInterruption", func(t *testing.T) {
+		// Send the first half of the blob, and close the stream
+		// without finishing the write.
+		stream, err := client.Write(ctx)
+		require.NoError(t, err)
+		require.NoError(t, stream.Send(&bytestream.WriteRequest{
+			ResourceName: resourceName,
+			Data:         []byte("Hello"),
+		}))
+		_, err = stream.CloseAndRecv()
+		testutil.RequireEqualStatus(t, status.Error(codes.InvalidArgument, "Client closed stream without finishing write"), err)
+
+		// The data that was sent should be reported as being
+		// committed.
+		response, err := client.QueryWriteStatus(ctx, &bytestream.QueryWriteStatusRequest{
+			ResourceName: resourceName,
+		})
+		require.NoError(t, err)
+		testutil.RequireEqualProto(t, &bytestream.QueryWriteStatusResponse{
+			CommittedSize: 5,
+		}, response)
+
+		// Resuming at the wrong offset should not be permitted.
+		stream, err = client.Write(ctx)
+		require.NoError(t, err)
+		require.NoError(t, stream.Send(&bytestream.WriteRequest{
+			ResourceName: resourceName,
+			WriteOffset:  3,
+			Data:         []byte("loWorld"),
+			FinishWrite:  true,
+		}))
+		_, err = stream.CloseAndRecv()
+		testutil.RequireEqualStatus(t, status.Error(codes.InvalidArgument, "Attempted to write at offset 3, while 5 was expected"), err)
+
+		// Resuming at the committed offset should cause the
+		// full blob to be written.
+		blobAccess.EXPECT().Put(gomock.Any(), blobDigest, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
+				data, err := b.ToByteSlice(100)
+				require.NoError(t, err)
+				require.Equal(t, []byte("HelloWorld"), data)
+				return nil
+			})
+
+		stream, err = client.Write(ctx)
+		require.NoError(t, err)
+		require.NoError(t, stream.Send(&bytestream.WriteRequest{
+			ResourceName: resourceName,
+			WriteOffset:  5,
+			Data:         []byte("World"),
+			FinishWrite:  true,
+		}))
+		writeResponse, err := stream.CloseAndRecv()
+		require.NoError(t, err)
+		require.Equal(t, int64(10), writeResponse.CommittedSize)
+
+		// Once completed, the upload should be removed from
+		// the staging area.
+		blobAccess.EXPECT().FindMissing(gomock.Any(), blobDigest.ToSingletonSet()).
+			Return(digest.EmptySet, nil)
+		response, err = client.QueryWriteStatus(ctx, &bytestream.QueryWriteStatusRequest{
+			ResourceName: resourceName,
+		})
+		require.NoError(t, err)
+		testutil.RequireEqualProto(t, &bytestream.QueryWriteStatusResponse{
+			CommittedSize: 10,
+			Complete:      true,
+		}, response)
+	})
+
+	t.Run("ResumeNonexistentUpload", func(t *testing.T) {
+		stream, err := client.Write(ctx)
+		require.NoError(t, err)
+		require.NoError(t, stream.Send(&bytestream.WriteRequest{
+			ResourceName: "windows10/uploads/0f5b1a1e-3d0c-4a57-a1c7-7b76ae8b5e13/blobs/68e109f0f40ca72a15e05cc22786f8e6/10",
+			WriteOffset:  5,
+			Data:         []byte("World"),
+			FinishWrite:  true,
+		}))
+		_, err = stream.CloseAndRecv()
+		testutil.RequireEqualStatus(t, status.Error(codes.InvalidArgument, "Attempted to write at offset 5, while 0 was expected"), err)
+	})
+
+	t.Run("CorruptedData", func(t *testing.T) {
+		// Data should be validated against the digest once the
+		// write finishes.
+		blobAccess.EXPECT().Put(gomock.Any(), blobDigest, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
+				_, err := b.ToByteSlice(100)
+				return err
+			})
+
+		stream, err := client.Write(ctx)
+		require.NoError(t, err)
+		require.NoError(t, stream.Send(&bytestream.WriteRequest{
+			ResourceName: resourceName,
+			Data:         []byte("HelloHello"),
+			FinishWrite:  true,
+		}))
+		_, err = stream.CloseAndRecv()
+		testutil.RequireEqualStatus(t, status.Error(codes.InvalidArgument, "Buffer has checksum 91db2d4279a42766759cfa87e9d633b4, while 68e109f0f40ca72a15e05cc22786f8e6 was expected"), err)
+	})
+
+	t.Run("StagingAreaFull", func(t *testing.T) {
+		// Stage 10 bytes of data for two uploads, filling up
+		// the staging area.
+		for _, uploadID := range []string{
+			"0e6a8a5c-7f33-44cc-9b34-6b8c0f4ac7b3",
+			"5b3f1c2d-8e4a-4b6f-9c1d-2e3f4a5b6c7d",
+		} {
+			stream, err := client.Write(ctx)
+			require.NoError(t, err)
+			require.NoError(t, stream.Send(&bytestream.WriteRequest{
+				ResourceName: "windows10/uploads/" + uploadID + "/blobs/68e109f0f40ca72a15e05cc22786f8e6/20",
+				Data:         []byte("HelloWorld"),
+			}))
+			_, err = stream.CloseAndRecv()
+			testutil.RequireEqualStatus(t, status.Error(codes.InvalidArgument, "Client closed stream without finishing write"), err)
+		}
+
+		// Starting another upload should cause one of the
+		// existing uploads to be discarded.
+		stream, err := client.Write(ctx)
+		require.NoError(t, err)
+		require.NoError(t, stream.Send(&bytestream.WriteRequest{
+			ResourceName: "windows10/uploads/8a7b6c5d-4e3f-4a1b-9c8d-7e6f5a4b3c2d/blobs/68e109f0f40ca72a15e05cc22786f8e6/20",
+			Data:         []byte("Hello"),
+		}))
+		_, err = stream.CloseAndRecv()
+		testutil.RequireEqualStatus(t, status.Error(codes.InvalidArgument, "Client closed stream without finishing write"), err)
+
+		blobAccess.EXPECT().FindMissing(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, digests digest.Set) (digest.Set, error) {
+				return digests, nil
+			}).
+			Times(1)
+		found := 0
+		for _, uploadID := range []string{
+			"0e6a8a5c-7f33-44cc-9b34-6b8c0f4ac7b3",
+			"5b3f1c2d-8e4a-4b6f-9c1d-2e3f4a5b6c7d",
+		} {
+			if _, err := client.QueryWriteStatus(ctx, &bytestream.QueryWriteStatusRequest{
+				ResourceName: "windows10/uploads/" + uploadID + "/blobs/68e109f0f40ca72a15e05cc22786f8e6/20",
+			}); err == nil {
+				found++
+			} else {
+				require.Equal(t, codes.NotFound, status.Code(err))
+			}
+		}
+		require.Equal(t, 1, found)
+	})
+
+	t.Run("BlobLargerThanStagingArea", func(t *testing.T) {
+		// Uploads of blobs that don't fit in the staging area
+		// should be written to storage directly.
+		blobAccess.EXPECT().Put(gomock.Any(), digest.MustNewDigest("windows10", remoteexecution.DigestFunction_MD5, "68e109f0f40ca72a15e05cc22786f8e6", 30), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
+				_, err := b.ToByteSlice(100)
+				return err
+			})
+
+		stream, err := client.Write(ctx)
+		require.NoError(t, err)
+		require.NoError(t, stream.Send(&bytestream.WriteRequest{
+			ResourceName: "windows10/uploads/3c1f6e0a-9d2b-4f8e-8a7c-5b4d3e2f1a0b/blobs/68e109f0f40ca72a15e05cc22786f8e6/30",
+			Data:         []byte("Hello"),
+		}))
+		_, err = stream.CloseAndRecv()
+		testutil.RequireEqualStatus(t, status.Error(codes.InvalidArgument, "Client closed stream without finishing write"), err)
+	})
+
+	t.Run("StagingAreaFullOfUploadsInProgress", func(t *testing.T) {
+		// Start an upload that claims 15 bytes of the staging
+		// area, and keep it in progress.
+		resourceName1 := "windows10/uploads/9e8d7c6b-5a4f-4e3d-8c2b-1a0f9e8d7c6b/blobs/68e109f0f40ca72a15e05cc22786f8e6/20"
+		blobDigest1 := digest.MustNewDigest("windows10", remoteexecution.DigestFunction_MD5, "68e109f0f40ca72a15e05cc22786f8e6", 20)
+		blobAccess.EXPECT().FindMissing(gomock.Any(), blobDigest1.ToSingletonSet()).
+			Return(blobDigest1.ToSingletonSet(), nil).
+			AnyTimes()
+		stream1, err := client.Write(ctx)
+		require.NoError(t, err)
+		require.NoError(t, stream1.Send(&bytestream.WriteRequest{
+			ResourceName: resourceName1,
+			Data:         []byte("HelloHelloHello"),
+		}))
+		require.Eventually(t, func() bool {
+			response, err := client.QueryWriteStatus(ctx, &bytestream.QueryWriteStatusRequest{
+				ResourceName: resourceName1,
+			})
+			return err == nil && response.CommittedSize == 15
+		}, 10*time.Second, 10*time.Millisecond)
+
+		// A second upload can't be staged, as the first upload
+		// can't be discarded. Instead of failing, the second
+		// upload should be written to storage directly,
+		// including the data that was staged previously.
+		blobAccess.EXPECT().Put(gomock.Any(), blobDigest, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
+				data, err := b.ToByteSlice(100)
+				require.NoError(t, err)
+				require.Equal(t, []byte("HelloWorld"), data)
+				return nil
+			})
+
+		stream2, err := client.Write(ctx)
+		require.NoError(t, err)
+		require.NoError(t, stream2.Send(&bytestream.WriteRequest{
+			ResourceName: resourceName,
+			Data:         []byte("Hel"),
+		}))
+		require.NoError(t, stream2.Send(&bytestream.WriteRequest{
+			ResourceName: resourceName,
+			WriteOffset:  3,
+			Data:         []byte("loWo"),
+		}))
+		require.NoError(t, stream2.Send(&bytestream.WriteRequest{
+			ResourceName: resourceName,
+			WriteOffset:  7,
+			Data:         []byte("rld"),
+			FinishWrite:  true,
+		}))
+		writeResponse, err := stream2.CloseAndRecv()
+		require.NoError(t, err)
+		require.Equal(t, int64(10), writeResponse.CommittedSize)
+
+		_, err = stream1.CloseAndRecv()
+		testutil.RequireEqualStatus(t, status.Error(codes.InvalidArgument, "Client closed stream without finishing write"), err)
+	})
+
+	t.Run("PermissionDenied", func(t *testing.T) {
+		// Clients that are not permitted to write to an
+		// instance name should not be able to stage data, or
+		// to query the status of staged uploads.
+		forbiddenResourceName := "forbidden/uploads/d834d9c2-f3c9-4f30-a698-75fd4be9470d/blobs/68e109f0f40ca72a15e05cc22786f8e6/10"
+		stream, err := client.Write(ctx)
+		require.NoError(t, err)
+		require.NoError(t, stream.Send(&bytestream.WriteRequest{
+			ResourceName: forbiddenResourceName,
+			Data:         []byte("Hello"),
+		}))
+		_, err = stream.CloseAndRecv()
+		testutil.RequireEqualStatus(t, status.Error(codes.PermissionDenied, "Authorization: You shall not pass"), err)
+
+		_, err = client.QueryWriteStatus(ctx, &bytestream.QueryWriteStatusRequest{
+			ResourceName: forbiddenResourceName,
+		})
+		testutil.RequireEqualStatus(t, status.Error(codes.PermissionDenied, "Authorization: You shall not pass"), err)
 	})
 }
@@ -1,28 +1,39 @@
 package grpcservers
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
+	"math"
 
 	remoteexecution "github.com/bazelbuild/remote-apis/build/bazel/remote/execution/v2"
 	"github.com/buildbarn/bb-storage/pkg/blobstore"
 	"github.com/buildbarn/bb-storage/pkg/blobstore/buffer"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/chunking"
 	"github.com/buildbarn/bb-storage/pkg/digest"
 	"github.com/buildbarn/bb-storage/pkg/util"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
 )
 
 type contentAddressableStorageServer struct {
 	contentAddressableStorage blobstore.BlobAccess
+	blobSplitter              chunking.BlobSplitter
 	maximumMessageSizeBytes   int64
 }
 
 // NewContentAddressableStorageServer creates a GRPC service for serving
 // the contents of a Bazel Content Addressable Storage (CAS) to Bazel.
-func NewContentAddressableStorageServer(contentAddressableStorage blobstore.BlobAccess, maximumMessageSizeBytes int64) remoteexecution.ContentAddressableStorageServer {
+//
+// The SplitBlob() and SpliceBlob() operations are only supported if a
+// BlobSplitter is provided.
+func NewContentAddressableStorageServer(contentAddressableStorage blobstore.BlobAccess, blobSplitter chunking.BlobSplitter, maximumMessageSizeBytes int64) remoteexecution.ContentAddressableStorageServer {
 	return &contentAddressableStorageServer{
 		contentAddressableStorage: contentAddressableStorage,
+		blobSplitter:              blobSplitter,
 		maximumMessageSizeBytes:   maximumMessageSizeBytes,
 	}
 }
@@ -93,6 +104,17 @@ func (s *contentAddressableStorageServer) BatchReadBlobs(ctx context.Context, in
 		digests = append(digests, digest)
 	}
 
+	// Pick the first compression algorithm requested by the client
+	// that is supported. The limit above is applied against the
+	// uncompressed size of the blobs.
+	compressor := remoteexecution.Compressor_IDENTITY
+	for _, acceptableCompressor := range in.AcceptableCompressors {
+		if acceptableCompressor == remoteexecution.Compressor_DEFLATE || acceptableCompressor == remoteexecution.Compressor_ZSTD {
+			compressor = acceptableCompressor
+			break
+		}
+	}
+
 	response := &remoteexecution.BatchReadBlobsResponse{
 		Responses: make([]*remoteexecution.BatchReadBlobsResponse_Response, 0, len(in.Digests)),
 	}
@@ -100,11 +122,21 @@ func (s *contentAddressableStorageServer) BatchReadBlobs(ctx context.Context, in
 		data, err := s.contentAddressableStorage.Get(
 			ctx,
 			digests[i]).ToByteSlice(int(digests[i].GetSizeBytes()))
-		response.Responses = append(response.Responses, &remoteexecution.BatchReadBlobsResponse_Response{
+		blobResponse := &remoteexecution.BatchReadBlobsResponse_Response{
 			Digest: reqDigest,
-			Data:   data,
 			Status: status.Convert(err).Proto(),
-		})
+		}
+		if err == nil {
+			if compressor == remoteexecution.Compressor_IDENTITY {
+				blobResponse.Data = data
+			} else if compressedData, err := compressBatchReadBlob(data, compressor); err == nil {
+				blobResponse.Data = compressedData
+				blobResponse.Compressor = compressor
+			} else {
+				blobResponse.Status = status.Convert(err).Proto()
+			}
+		}
+		response.Responses = append(response.Responses, blobResponse)
 	}
 
 	return response, nil
@@ -129,10 +161,7 @@ func (s *contentAddressableStorageServer) BatchUpdateBlobs(ctx context.Context,
 	for _, request := range in.Requests {
 		digest, err := digestFunction.NewDigestFromProto(request.Digest)
 		if err == nil {
-			err = s.contentAddressableStorage.Put(
-				ctx,
-				digest,
-				buffer.NewCASBufferFromByteSlice(digest, request.Data, buffer.UserProvided))
+			err = s.putBatchUpdateBlob(ctx, digest, request)
 		}
 		response.Responses = append(response.Responses,
 			&remoteexecution.BatchUpdateBlobsResponse_Response{
@@ -143,14 +172,238 @@ func (s *contentAddressableStorageServer) BatchUpdateBlobs(ctx context.Context,
 	return response, nil
 }
 
-func (s *contentAddressableStorageServer) GetTree(in *remoteexecution.GetTreeRequest, stream remoteexecution.ContentAddressableStorage_GetTreeServer) error {
-	return status.Error(codes.Unimplemented, "This service does not support downloading directory trees")
+// putBatchUpdateBlob writes a single blob contained in a
+// BatchUpdateBlobs() request into the Content Addressable Storage. If
+// the blob is compressed, it is decompressed prior to being written,
+// so that it is validated against the uncompressed digest.
+func (s *contentAddressableStorageServer) putBatchUpdateBlob(ctx context.Context, digest digest.Digest, request *remoteexecution.BatchUpdateBlobsRequest_Request) error {
+	switch request.Compressor {
+	case remoteexecution.Compressor_IDENTITY:
+		return s.contentAddressableStorage.Put(
+			ctx,
+			digest,
+			buffer.NewCASBufferFromByteSlice(digest, request.Data, buffer.UserProvided))
+	case remoteexecution.Compressor_DEFLATE, remoteexecution.Compressor_ZSTD:
+		decompressingReader, err := util.NewDecompressingReader(bytes.NewReader(request.Data), request.Compressor)
+		if err != nil {
+			return err
+		}
+		return s.contentAddressableStorage.Put(
+			ctx,
+			digest,
+			buffer.NewCASBufferFromReader(digest, decompressingReader, buffer.UserProvided))
+	default:
+		return status.Errorf(codes.Unimplemented, "This service does not support uploading compression type: %s", request.Compressor)
+	}
+}
+
+// compressBatchReadBlob compresses a blob, so that it can be returned
+// as part of a BatchReadBlobs() response.
+func compressBatchReadBlob(data []byte, compressor remoteexecution.Compressor_Value) ([]byte, error) {
+	var compressed bytes.Buffer
+	compressingWriter, err := util.NewCompressingWriter(&compressed, compressor)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := compressingWriter.Write(data); err != nil {
+		compressingWriter.Close()
+		return nil, util.StatusWrapWithCode(err, codes.Internal, "Failed to compress data")
+	}
+	if err := compressingWriter.Close(); err != nil {
+		return nil, util.StatusWrapWithCode(err, codes.Internal, "Failed to compress data")
+	}
+	return compressed.Bytes(), nil
+}
+
+// newGetTreePageToken creates an opaque page token that can be
+// returned to clients of GetTree(). The page token contains the number
+// of directories in the breadth-first traversal of the tree that have
+// already been processed. This keeps the size of the page token
+// bounded, regardless of the width of the tree.
+func newGetTreePageToken(position int64) string {
+	return base64.RawURLEncoding.EncodeToString(protowire.AppendVarint(nil, uint64(position)))
+}
+
+// getGetTreePageTokenSizeBytes computes the size of the page token
+// returned by newGetTreePageToken(), when embedded in a GetTreeResponse.
+func getGetTreePageTokenSizeBytes(position int64) int64 {
+	return int64(protowire.SizeTag(2) + protowire.SizeBytes(base64.RawURLEncoding.EncodedLen(protowire.SizeVarint(uint64(position)))))
+}
+
+// parseGetTreePageToken converts a page token provided by a client of
+// GetTree() back to the number of directories in the traversal of the
+// tree that have already been processed.
+func parseGetTreePageToken(pageToken string) (int64, error) {
+	b, err := base64.RawURLEncoding.DecodeString(pageToken)
+	if err != nil {
+		return 0, status.Error(codes.InvalidArgument, "Page token is not valid base64")
+	}
+	position, n := protowire.ConsumeVarint(b)
+	if n < 0 || n != len(b) || position == 0 || position > math.MaxInt64 {
+		return 0, status.Error(codes.InvalidArgument, "Page token is malformed")
+	}
+	return int64(position), nil
+}
+
+func (s *contentAddressableStorageServer) GetTree(in *remoteexecution.GetTreeRequest, out remoteexecution.ContentAddressableStorage_GetTreeServer) error {
+	if in.PageSize < 0 {
+		return status.Errorf(codes.InvalidArgument, "Page size %d is negative", in.PageSize)
+	}
+	instanceName, err := digest.NewInstanceName(in.InstanceName)
+	if err != nil {
+		return util.StatusWrapf(err, "Invalid instance name %#v", in.InstanceName)
+	}
+	digestFunction, err := instanceName.GetDigestFunction(in.DigestFunction, len(in.RootDigest.GetHash()))
+	if err != nil {
+		return err
+	}
+	rootDigest, err := digestFunction.NewDigestFromProto(in.RootDigest)
+	if err != nil {
+		return util.StatusWrap(err, "Invalid root digest")
+	}
+
+	// Traverse the tree in breadth-first order. The page token
+	// contains the number of directories that have already been
+	// processed. Resuming a traversal thus requires the directories
+	// that were returned previously to be loaded once more, so
+	// that the remainder of the traversal can be derived.
+	//
+	// Changes to the tree between calls may cause the resumed
+	// traversal to differ from the original one. Directories may
+	// then be returned more than once or be omitted.
+	skipCount := int64(0)
+	if in.PageToken != "" {
+		skipCount, err = parseGetTreePageToken(in.PageToken)
+		if err != nil {
+			return err
+		}
+	}
+	queue := []digest.Digest{rootDigest}
+	seen := map[digest.Digest]struct{}{
+		rootDigest: {},
+	}
+
+	ctx := out.Context()
+	var page []*remoteexecution.Directory
+	pageSizeBytes := int64(0)
+	for position := int64(0); len(queue) > 0; position++ {
+		directoryDigest := queue[0]
+		queue = queue[1:]
+		directoryMessage, err := s.contentAddressableStorage.Get(ctx, directoryDigest).
+			ToProto(&remoteexecution.Directory{}, int(s.maximumMessageSizeBytes))
+		if err != nil {
+			if status.Code(err) == codes.NotFound && directoryDigest != rootDigest {
+				// Parts of the tree that are missing
+				// are omitted from the results.
+				continue
+			}
+			return util.StatusWrapf(err, "Failed to obtain directory %#v", directoryDigest.String())
+		}
+		directory := directoryMessage.(*remoteexecution.Directory)
+
+		for _, child := range directory.Directories {
+			childDigest, err := digestFunction.NewDigestFromProto(child.Digest)
+			if err != nil {
+				return util.StatusWrapf(err, "Directory %#v contains child directory %#v with an invalid digest", directoryDigest.String(), child.Name)
+			}
+			if _, ok := seen[childDigest]; !ok {
+				seen[childDigest] = struct{}{}
+				queue = append(queue, childDigest)
+			}
+		}
+		if position < skipCount {
+			// Directory was already returned as part of
+			// a previous page.
+			continue
+		}
+
+		// Flush the current page if adding this directory would
+		// cause it to exceed the page size or maximum message
+		// size. The page token causes the traversal to be
+		// resumed at the current directory.
+		directorySizeBytes := int64(protowire.SizeTag(1) + protowire.SizeBytes(proto.Size(directory)))
+		if len(page) > 0 && ((in.PageSize > 0 && len(page) >= int(in.PageSize)) || pageSizeBytes+directorySizeBytes+getGetTreePageTokenSizeBytes(position) > s.maximumMessageSizeBytes) {
+			if err := out.Send(&remoteexecution.GetTreeResponse{
+				Directories:   page,
+				NextPageToken: newGetTreePageToken(position),
+			}); err != nil {
+				return err
+			}
+			page = nil
+			pageSizeBytes = 0
+		}
+		page = append(page, directory)
+		pageSizeBytes += directorySizeBytes
+	}
+
+	// Send the final page, which has no page token.
+	return out.Send(&remoteexecution.GetTreeResponse{
+		Directories: page,
+	})
 }
 
 func (s *contentAddressableStorageServer) SpliceBlob(ctx context.Context, in *remoteexecution.SpliceBlobRequest) (*remoteexecution.SpliceBlobResponse, error) {
-	return nil, status.Error(codes.Unimplemented, "This service does not support splicing blobs")
+	if s.blobSplitter == nil {
+		return nil, status.Error(codes.Unimplemented, "This service does not support splicing blobs")
+	}
+	if in.BlobDigest == nil {
+		return nil, status.Error(codes.InvalidArgument, "No blob digest provided")
+	}
+	instanceName, err := digest.NewInstanceName(in.InstanceName)
+	if err != nil {
+		return nil, util.StatusWrapf(err, "Invalid instance name %#v", in.InstanceName)
+	}
+	digestFunction, err := instanceName.GetDigestFunction(in.DigestFunction, len(in.BlobDigest.GetHash()))
+	if err != nil {
+		return nil, err
+	}
+	blobDigest, err := digestFunction.NewDigestFromProto(in.BlobDigest)
+	if err != nil {
+		return nil, util.StatusWrap(err, "Invalid blob digest")
+	}
+	chunkDigests := make([]digest.Digest, 0, len(in.ChunkDigests))
+	for i, chunkDigest := range in.ChunkDigests {
+		d, err := digestFunction.NewDigestFromProto(chunkDigest)
+		if err != nil {
+			return nil, util.StatusWrapf(err, "Invalid digest for chunk at index %d", i)
+		}
+		chunkDigests = append(chunkDigests, d)
+	}
+
+	if err := s.blobSplitter.SpliceBlob(ctx, blobDigest, chunkDigests); err != nil {
+		return nil, err
+	}
+	return &remoteexecution.SpliceBlobResponse{
+		BlobDigest: blobDigest.GetProto(),
+	}, nil
 }
 
 func (s *contentAddressableStorageServer) SplitBlob(ctx context.Context, in *remoteexecution.SplitBlobRequest) (*remoteexecution.SplitBlobResponse, error) {
-	return nil, status.Error(codes.Unimplemented, "This service does not support splitting blobs")
+	if s.blobSplitter == nil {
+		return nil, status.Error(codes.Unimplemented, "This service does not support splitting blobs")
+	}
+	instanceName, err := digest.NewInstanceName(in.InstanceName)
+	if err != nil {
+		return nil, util.StatusWrapf(err, "Invalid instance name %#v", in.InstanceName)
+	}
+	digestFunction, err := instanceName.GetDigestFunction(in.DigestFunction, len(in.BlobDigest.GetHash()))
+	if err != nil {
+		return nil, err
+	}
+	blobDigest, err := digestFunction.NewDigestFromProto(in.BlobDigest)
+	if err != nil {
+		return nil, util.StatusWrap(err, "Invalid blob digest")
+	}
+
+	chunkDigests, err := s.blobSplitter.SplitBlob(ctx, blobDigest)
+	if err != nil {
+		return nil, err
+	}
+	response := &remoteexecution.SplitBlobResponse{
+		ChunkDigests: make([]*remoteexecution.Digest, 0, len(chunkDigests)),
+	}
+	for _, chunkDigest := range chunkDigests {
+		response.ChunkDigests = append(response.ChunkDigests, chunkDigest.GetProto())
+	}
+	return response, nil
 }
@@ -1,8 +1,13 @@
 package grpcservers_test
 
 import (
+	"bytes"
+	"compress/flate"
 	"context"
+	"io"
+	"net"
 	"testing"
+	"time"
 
 	remoteexecution "github.com/bazelbuild/remote-apis/build/bazel/remote/execution/v2"
 	"github.com/buildbarn/bb-storage/internal/mock"
@@ -10,11 +15,14 @@ import (
 	"github.com/buildbarn/bb-storage/pkg/blobstore/grpcservers"
 	"github.com/buildbarn/bb-storage/pkg/digest"
 	"github.com/buildbarn/bb-storage/pkg/testutil"
+	"github.com/klauspost/compress/zstd"
 	"github.com/stretchr/testify/require"
 
 	status_pb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
 
 	"go.uber.org/mock/gomock"
 )
@@ -55,7 +63,7 @@ func TestContentAddressableStorageServerBatchReadBlobsSuccess(t *testing.T) {
 	buf3 := buffer.NewBufferFromError(status.Error(codes.NotFound, "The object you requested could not be found"))
 	contentAddressableStorage.EXPECT().Get(ctx, digest3).Return(buf3)
 
-	contentAddressableStorageServer := grpcservers.NewContentAddressableStorageServer(contentAddressableStorage, 1<<16)
+	contentAddressableStorageServer := grpcservers.NewContentAddressableStorageServer(contentAddressableStorage, nil, 1<<16)
 
 	response, err := contentAddressableStorageServer.BatchReadBlobs(ctx, request)
 	require.NoError(t, err)
@@ -108,8 +116,474 @@ func TestContentAddressableStorageServerBatchReadBlobsFailure(t *testing.T) {
 
 	contentAddressableStorage := mock.NewMockBlobAccess(ctrl)
 
-	contentAddressableStorageServer := grpcservers.NewContentAddressableStorageServer(contentAddressableStorage, 200)
+	contentAddressableStorageServer := grpcservers.NewContentAddressableStorageServer(contentAddressableStorage, nil, 200)
 
 	_, err := contentAddressableStorageServer.BatchReadBlobs(ctx, request)
 	testutil.RequireEqualStatus(t, status.Error(codes.InvalidArgument, "Attempted to read a total of at least 357 bytes, while a maximum of 200 bytes is permitted"), err)
 }
+
+func TestContentAddressableStorageServerBatchReadBlobsCompressed(t *testing.T) {
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+
+	contentAddressableStorage := mock.NewMockBlobAccess(ctrl)
+	contentAddressableStorageServer := grpcservers.NewContentAddressableStorageServer(contentAddressableStorage, nil, 1<<16)
+
+	t.Run("ZSTD", func(t *testing.T) {
+		// The server should pick the first compressor that it
+		// supports, and only compress blobs that are present.
+		contentAddressableStorage.EXPECT().Get(ctx, digest.MustNewDigest("hello", remoteexecution.DigestFunction_MD5, "8b1a9953c4611296a827abf8c47804d7", 5)).
+			Return(buffer.NewValidatedBufferFromByteSlice([]byte("Hello")))
+		contentAddressableStorage.EXPECT().Get(ctx, digest.MustNewDigest("hello", remoteexecution.DigestFunction_MD5, "f5a7924e621e84c9280a9a27e1bcb7f6", 5)).
+			Return(buffer.NewBufferFromError(status.Error(codes.NotFound, "Object not found")))
+
+		response, err := contentAddressableStorageServer.BatchReadBlobs(ctx, &remoteexecution.BatchReadBlobsRequest{
+			InstanceName: "hello",
+			Digests: []*remoteexecution.Digest{
+				{Hash: "8b1a9953c4611296a827abf8c47804d7", SizeBytes: 5},
+				{Hash: "f5a7924e621e84c9280a9a27e1bcb7f6", SizeBytes: 5},
+			},
+			AcceptableCompressors: []remoteexecution.Compressor_Value{
+				remoteexecution.Compressor_BROTLI,
+				remoteexecution.Compressor_ZSTD,
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, response.Responses, 2)
+
+		require.Equal(t, remoteexecution.Compressor_ZSTD, response.Responses[0].Compressor)
+		decoder, err := zstd.NewReader(nil)
+		require.NoError(t, err)
+		defer decoder.Close()
+		data, err := decoder.DecodeAll(response.Responses[0].Data, nil)
+		require.NoError(t, err)
+		require.Equal(t, []byte("Hello"), data)
+
+		testutil.RequireEqualProto(t, &remoteexecution.BatchReadBlobsResponse_Response{
+			Digest: &remoteexecution.Digest{Hash: "f5a7924e621e84c9280a9a27e1bcb7f6", SizeBytes: 5},
+			Status: status.New(codes.NotFound, "Object not found").Proto(),
+		}, response.Responses[1])
+	})
+
+	t.Run("Deflate", func(t *testing.T) {
+		contentAddressableStorage.EXPECT().Get(ctx, digest.MustNewDigest("hello", remoteexecution.DigestFunction_MD5, "8b1a9953c4611296a827abf8c47804d7", 5)).
+			Return(buffer.NewValidatedBufferFromByteSlice([]byte("Hello")))
+
+		response, err := contentAddressableStorageServer.BatchReadBlobs(ctx, &remoteexecution.BatchReadBlobsRequest{
+			InstanceName: "hello",
+			Digests: []*remoteexecution.Digest{
+				{Hash: "8b1a9953c4611296a827abf8c47804d7", SizeBytes: 5},
+			},
+			AcceptableCompressors: []remoteexecution.Compressor_Value{
+				remoteexecution.Compressor_DEFLATE,
+				remoteexecution.Compressor_ZSTD,
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, response.Responses, 1)
+		require.Equal(t, remoteexecution.Compressor_DEFLATE, response.Responses[0].Compressor)
+		data, err := io.ReadAll(flate.NewReader(bytes.NewReader(response.Responses[0].Data)))
+		require.NoError(t, err)
+		require.Equal(t, []byte("Hello"), data)
+	})
+
+	t.Run("NoSupportedCompressor", func(t *testing.T) {
+		// If none of the acceptable compressors are supported,
+		// data should be returned uncompressed.
+		contentAddressableStorage.EXPECT().Get(ctx, digest.MustNewDigest("hello", remoteexecution.DigestFunction_MD5, "8b1a9953c4611296a827abf8c47804d7", 5)).
+			Return(buffer.NewValidatedBufferFromByteSlice([]byte("Hello")))
+
+		response, err := contentAddressableStorageServer.BatchReadBlobs(ctx, &remoteexecution.BatchReadBlobsRequest{
+			InstanceName: "hello",
+			Digests: []*remoteexecution.Digest{
+				{Hash: "8b1a9953c4611296a827abf8c47804d7", SizeBytes: 5},
+			},
+			AcceptableCompressors: []remoteexecution.Compressor_Value{
+				remoteexecution.Compressor_BROTLI,
+			},
+		})
+		require.NoError(t, err)
+		testutil.RequireEqualProto(t, &remoteexecution.BatchReadBlobsResponse{
+			Responses: []*remoteexecution.BatchReadBlobsResponse_Response{
+				{
+					Digest: &remoteexecution.Digest{Hash: "8b1a9953c4611296a827abf8c47804d7", SizeBytes: 5},
+					Data:   []byte("Hello"),
+				},
+			},
+		}, response)
+	})
+}
+
+func TestContentAddressableStorageServerBatchUpdateBlobs(t *testing.T) {
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+
+	contentAddressableStorage := mock.NewMockBlobAccess(ctrl)
+	contentAddressableStorageServer := grpcservers.NewContentAddressableStorageServer(contentAddressableStorage, nil, 1<<16)
+
+	encoder, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+	defer encoder.Close()
+
+	t.Run("Mixed", func(t *testing.T) {
+		// Both uncompressed and compressed blobs should be
+		// written in uncompressed form.
+		helloDigest := digest.MustNewDigest("hello", remoteexecution.DigestFunction_MD5, "8b1a9953c4611296a827abf8c47804d7", 5)
+		worldDigest := digest.MustNewDigest("hello", remoteexecution.DigestFunction_MD5, "f5a7924e621e84c9280a9a27e1bcb7f6", 5)
+		contentAddressableStorage.EXPECT().Put(ctx, helloDigest, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
+				data, err := b.ToByteSlice(100)
+				require.NoError(t, err)
+				require.Equal(t, []byte("Hello"), data)
+				return nil
+			})
+		contentAddressableStorage.EXPECT().Put(ctx, worldDigest, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
+				data, err := b.ToByteSlice(100)
+				require.NoError(t, err)
+				require.Equal(t, []byte("World"), data)
+				return nil
+			})
+
+		response, err := contentAddressableStorageServer.BatchUpdateBlobs(ctx, &remoteexecution.BatchUpdateBlobsRequest{
+			InstanceName: "hello",
+			Requests: []*remoteexecution.BatchUpdateBlobsRequest_Request{
+				{
+					Digest: &remoteexecution.Digest{Hash: "8b1a9953c4611296a827abf8c47804d7", SizeBytes: 5},
+					Data:   []byte("Hello"),
+				},
+				{
+					Digest:     &remoteexecution.Digest{Hash: "f5a7924e621e84c9280a9a27e1bcb7f6", SizeBytes: 5},
+					Data:       encoder.EncodeAll([]byte("World"), nil),
+					Compressor: remoteexecution.Compressor_ZSTD,
+				},
+			},
+		})
+		require.NoError(t, err)
+		testutil.RequireEqualProto(t, &remoteexecution.BatchUpdateBlobsResponse{
+			Responses: []*remoteexecution.BatchUpdateBlobsResponse_Response{
+				{
+					Digest: &remoteexecution.Digest{Hash: "8b1a9953c4611296a827abf8c47804d7", SizeBytes: 5},
+				},
+				{
+					Digest: &remoteexecution.Digest{Hash: "f5a7924e621e84c9280a9a27e1bcb7f6", SizeBytes: 5},
+				},
+			},
+		}, response)
+	})
+
+	t.Run("CompressedDigestMismatch", func(t *testing.T) {
+		// Compressed data should be validated against the
+		// digest of the uncompressed data.
+		contentAddressableStorage.EXPECT().Put(ctx, digest.MustNewDigest("hello", remoteexecution.DigestFunction_MD5, "8b1a9953c4611296a827abf8c47804d7", 5), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
+				_, err := b.ToByteSlice(100)
+				return err
+			})
+
+		response, err := contentAddressableStorageServer.BatchUpdateBlobs(ctx, &remoteexecution.BatchUpdateBlobsRequest{
+			InstanceName: "hello",
+			Requests: []*remoteexecution.BatchUpdateBlobsRequest_Request{
+				{
+					Digest:     &remoteexecution.Digest{Hash: "8b1a9953c4611296a827abf8c47804d7", SizeBytes: 5},
+					Data:       encoder.EncodeAll([]byte("World"), nil),
+					Compressor: remoteexecution.Compressor_ZSTD,
+				},
+			},
+		})
+		require.NoError(t, err)
+		testutil.RequireEqualProto(t, &remoteexecution.BatchUpdateBlobsResponse{
+			Responses: []*remoteexecution.BatchUpdateBlobsResponse_Response{
+				{
+					Digest: &remoteexecution.Digest{Hash: "8b1a9953c4611296a827abf8c47804d7", SizeBytes: 5},
+					Status: status.New(codes.InvalidArgument, "Buffer has checksum f5a7924e621e84c9280a9a27e1bcb7f6, while 8b1a9953c4611296a827abf8c47804d7 was expected").Proto(),
+				},
+			},
+		}, response)
+	})
+
+	t.Run("UnsupportedCompressor", func(t *testing.T) {
+		response, err := contentAddressableStorageServer.BatchUpdateBlobs(ctx, &remoteexecution.BatchUpdateBlobsRequest{
+			InstanceName: "hello",
+			Requests: []*remoteexecution.BatchUpdateBlobsRequest_Request{
+				{
+					Digest:     &remoteexecution.Digest{Hash: "8b1a9953c4611296a827abf8c47804d7", SizeBytes: 5},
+					Data:       []byte("Hello"),
+					Compressor: remoteexecution.Compressor_BROTLI,
+				},
+			},
+		})
+		require.NoError(t, err)
+		testutil.RequireEqualProto(t, &remoteexecution.BatchUpdateBlobsResponse{
+			Responses: []*remoteexecution.BatchUpdateBlobsResponse_Response{
+				{
+					Digest: &remoteexecution.Digest{Hash: "8b1a9953c4611296a827abf8c47804d7", SizeBytes: 5},
+					Status: status.New(codes.Unimplemented, "This service does not support uploading compression type: BROTLI").Proto(),
+				},
+			},
+		}, response)
+	})
+}
+
+func TestContentAddressableStorageServerGetTree(t *testing.T) {
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+
+	// Create an RPC server/client pair.
+	l := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	contentAddressableStorage := mock.NewMockBlobAccess(ctrl)
+	remoteexecution.RegisterContentAddressableStorageServer(server, grpcservers.NewContentAddressableStorageServer(contentAddressableStorage, nil, 1<<16))
+	go func() {
+		require.NoError(t, server.Serve(l))
+	}()
+	conn, err := grpc.DialContext(ctx, "bufnet", grpc.WithDialer(func(string, time.Duration) (net.Conn, error) {
+		return l.Dial()
+	}), grpc.WithInsecure())
+	require.NoError(t, err)
+	defer server.Stop()
+	defer conn.Close()
+	client := remoteexecution.NewContentAddressableStorageClient(conn)
+
+	// Directory hierarchy used by the tests below. Directory "c"
+	// is referenced twice, but should only be returned once.
+	// Directory "d" is absent from the CAS.
+	digestC := digest.MustNewDigest("example", remoteexecution.DigestFunction_MD5, "b1b1c1b0f3e2d6cd9dcbe4cb8a30a3e3", 0)
+	digestD := digest.MustNewDigest("example", remoteexecution.DigestFunction_MD5, "4ed8347a0b6a5fdb8e1c4bc4c5f7d4ea", 100)
+	directoryC := &remoteexecution.Directory{
+		Files: []*remoteexecution.FileNode{
+			{Name: "file", Digest: &remoteexecution.Digest{Hash: "8b1a9953c4611296a827abf8c47804d7", SizeBytes: 5}},
+		},
+	}
+	digestA := digest.MustNewDigest("example", remoteexecution.DigestFunction_MD5, "0cc175b9c0f1b6a831c399e269772661", 42)
+	directoryA := &remoteexecution.Directory{
+		Directories: []*remoteexecution.DirectoryNode{
+			{Name: "c", Digest: digestC.GetProto()},
+		},
+	}
+	digestB := digest.MustNewDigest("example", remoteexecution.DigestFunction_MD5, "92eb5ffee6ae2fec3ad71c777531578f", 42)
+	directoryB := &remoteexecution.Directory{
+		Directories: []*remoteexecution.DirectoryNode{
+			{Name: "c", Digest: digestC.GetProto()},
+			{Name: "d", Digest: digestD.GetProto()},
+		},
+	}
+	digestRoot := digest.MustNewDigest("example", remoteexecution.DigestFunction_MD5, "d41d8cd98f00b204e9800998ecf8427e", 84)
+	directoryRoot := &remoteexecution.Directory{
+		Directories: []*remoteexecution.DirectoryNode{
+			{Name: "a", Digest: digestA.GetProto()},
+			{Name: "b", Digest: digestB.GetProto()},
+		},
+	}
+	expectTree := func() {
+		contentAddressableStorage.EXPECT().Get(gomock.Any(), digestRoot).
+			Return(buffer.NewProtoBufferFromProto(directoryRoot, buffer.UserProvided))
+		contentAddressableStorage.EXPECT().Get(gomock.Any(), digestA).
+			Return(buffer.NewProtoBufferFromProto(directoryA, buffer.UserProvided))
+		contentAddressableStorage.EXPECT().Get(gomock.Any(), digestB).
+			Return(buffer.NewProtoBufferFromProto(directoryB, buffer.UserProvided))
+		contentAddressableStorage.EXPECT().Get(gomock.Any(), digestC).
+			Return(buffer.NewProtoBufferFromProto(directoryC, buffer.UserProvided))
+		contentAddressableStorage.EXPECT().Get(gomock.Any(), digestD).
+			Return(buffer.NewBufferFromError(status.Error(codes.NotFound, "Object not found")))
+	}
+	receiveAll := func(t *testing.T, stream remoteexecution.ContentAddressableStorage_GetTreeClient) []*remoteexecution.GetTreeResponse {
+		var responses []*remoteexecution.GetTreeResponse
+		for {
+			response, err := stream.Recv()
+			if err == io.EOF {
+				return responses
+			}
+			require.NoError(t, err)
+			responses = append(responses, response)
+		}
+	}
+
+	t.Run("InvalidPageToken", func(t *testing.T) {
+		stream, err := client.GetTree(ctx, &remoteexecution.GetTreeRequest{
+			InstanceName: "example",
+			RootDigest:   digestRoot.GetProto(),
+			PageToken:    "This is not a page token",
+		})
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		testutil.RequireEqualStatus(t, status.Error(codes.InvalidArgument, "Page token is not valid base64"), err)
+	})
+
+	t.Run("RootNotFound", func(t *testing.T) {
+		contentAddressableStorage.EXPECT().Get(gomock.Any(), digestRoot).
+			Return(buffer.NewBufferFromError(status.Error(codes.NotFound, "Object not found")))
+
+		stream, err := client.GetTree(ctx, &remoteexecution.GetTreeRequest{
+			InstanceName: "example",
+			RootDigest:   digestRoot.GetProto(),
+		})
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		testutil.RequireEqualStatus(t, status.Error(codes.NotFound, "Failed to obtain directory \"3-d41d8cd98f00b204e9800998ecf8427e-84-example\": Object not found"), err)
+	})
+
+	t.Run("SinglePage", func(t *testing.T) {
+		expectTree()
+
+		stream, err := client.GetTree(ctx, &remoteexecution.GetTreeRequest{
+			InstanceName: "example",
+			RootDigest:   digestRoot.GetProto(),
+		})
+		require.NoError(t, err)
+		responses := receiveAll(t, stream)
+		require.Len(t, responses, 1)
+		testutil.RequireEqualProto(t, &remoteexecution.GetTreeResponse{
+			Directories: []*remoteexecution.Directory{
+				directoryRoot,
+				directoryA,
+				directoryB,
+				directoryC,
+			},
+		}, responses[0])
+	})
+
+	t.Run("Pagination", func(t *testing.T) {
+		// With a page size of three, the tree should be split
+		// into two responses. The first response should carry a
+		// page token that can be used to resume the traversal.
+		expectTree()
+
+		stream, err := client.GetTree(ctx, &remoteexecution.GetTreeRequest{
+			InstanceName: "example",
+			RootDigest:   digestRoot.GetProto(),
+			PageSize:     3,
+		})
+		require.NoError(t, err)
+		responses := receiveAll(t, stream)
+		require.Len(t, responses, 2)
+		require.Len(t, responses[0].Directories, 3)
+		require.NotEmpty(t, responses[0].NextPageToken)
+		require.LessOrEqual(t, len(responses[0].NextPageToken), 16)
+		testutil.RequireEqualProto(t, &remoteexecution.GetTreeResponse{
+			Directories: []*remoteexecution.Directory{directoryC},
+		}, responses[1])
+
+		// Resuming at the page token should only yield the
+		// directories that were not part of the first page. As
+		// the page token only contains the position in the
+		// traversal, the tree needs to be traversed from the
+		// root once more.
+		expectTree()
+
+		stream, err = client.GetTree(ctx, &remoteexecution.GetTreeRequest{
+			InstanceName: "example",
+			RootDigest:   digestRoot.GetProto(),
+			PageToken:    responses[0].NextPageToken,
+		})
+		require.NoError(t, err)
+		resumedResponses := receiveAll(t, stream)
+		require.Len(t, resumedResponses, 1)
+		testutil.RequireEqualProto(t, responses[1], resumedResponses[0])
+
+		// Directories going missing between calls should not
+		// cause other directories to be skipped.
+		contentAddressableStorage.EXPECT().Get(gomock.Any(), digestRoot).
+			Return(buffer.NewProtoBufferFromProto(directoryRoot, buffer.UserProvided))
+		contentAddressableStorage.EXPECT().Get(gomock.Any(), digestA).
+			Return(buffer.NewProtoBufferFromProto(directoryA, buffer.UserProvided))
+		contentAddressableStorage.EXPECT().Get(gomock.Any(), digestB).
+			Return(buffer.NewProtoBufferFromProto(directoryB, buffer.UserProvided))
+		contentAddressableStorage.EXPECT().Get(gomock.Any(), digestC).
+			Return(buffer.NewBufferFromError(status.Error(codes.NotFound, "Object not found")))
+		contentAddressableStorage.EXPECT().Get(gomock.Any(), digestD).
+			Return(buffer.NewProtoBufferFromProto(&remoteexecution.Directory{}, buffer.UserProvided))
+
+		stream, err = client.GetTree(ctx, &remoteexecution.GetTreeRequest{
+			InstanceName: "example",
+			RootDigest:   digestRoot.GetProto(),
+			PageToken:    responses[0].NextPageToken,
+		})
+		require.NoError(t, err)
+		resumedResponses = receiveAll(t, stream)
+		require.Len(t, resumedResponses, 1)
+		testutil.RequireEqualProto(t, &remoteexecution.GetTreeResponse{
+			Directories: []*remoteexecution.Directory{{}},
+		}, resumedResponses[0])
+	})
+}
+
+func TestContentAddressableStorageServerSplitBlob(t *testing.T) {
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+
+	blobDigest := digest.MustNewDigest("example", remoteexecution.DigestFunction_MD5, "91db2d4279a42766759cfa87e9d633b4", 10)
+	chunkDigest := digest.MustNewDigest("example", remoteexecution.DigestFunction_MD5, "8b1a9953c4611296a827abf8c47804d7", 5)
+
+	t.Run("Unimplemented", func(t *testing.T) {
+		contentAddressableStorageServer := grpcservers.NewContentAddressableStorageServer(mock.NewMockBlobAccess(ctrl), nil, 1<<16)
+
+		_, err := contentAddressableStorageServer.SplitBlob(ctx, &remoteexecution.SplitBlobRequest{
+			InstanceName: "example",
+			BlobDigest:   blobDigest.GetProto(),
+		})
+		testutil.RequireEqualStatus(t, status.Error(codes.Unimplemented, "This service does not support splitting blobs"), err)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		blobSplitter := mock.NewMockBlobSplitter(ctrl)
+		contentAddressableStorageServer := grpcservers.NewContentAddressableStorageServer(mock.NewMockBlobAccess(ctrl), blobSplitter, 1<<16)
+
+		blobSplitter.EXPECT().SplitBlob(ctx, blobDigest).Return([]digest.Digest{chunkDigest, chunkDigest}, nil)
+
+		response, err := contentAddressableStorageServer.SplitBlob(ctx, &remoteexecution.SplitBlobRequest{
+			InstanceName: "example",
+			BlobDigest:   blobDigest.GetProto(),
+		})
+		require.NoError(t, err)
+		testutil.RequireEqualProto(t, &remoteexecution.SplitBlobResponse{
+			ChunkDigests: []*remoteexecution.Digest{
+				chunkDigest.GetProto(),
+				chunkDigest.GetProto(),
+			},
+		}, response)
+	})
+}
+
+func TestContentAddressableStorageServerSpliceBlob(t *testing.T) {
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+
+	blobDigest := digest.MustNewDigest("example", remoteexecution.DigestFunction_MD5, "91db2d4279a42766759cfa87e9d633b4", 10)
+	chunkDigest := digest.MustNewDigest("example", remoteexecution.DigestFunction_MD5, "8b1a9953c4611296a827abf8c47804d7", 5)
+
+	blobSplitter := mock.NewMockBlobSplitter(ctrl)
+	contentAddressableStorageServer := grpcservers.NewContentAddressableStorageServer(mock.NewMockBlobAccess(ctrl), blobSplitter, 1<<16)
+
+	t.Run("MissingBlobDigest", func(t *testing.T) {
+		_, err := contentAddressableStorageServer.SpliceBlob(ctx, &remoteexecution.SpliceBlobRequest{
+			InstanceName: "example",
+			ChunkDigests: []*remoteexecution.Digest{chunkDigest.GetProto()},
+		})
+		testutil.RequireEqualStatus(t, status.Error(codes.InvalidArgument, "No blob digest provided"), err)
+	})
+
+	t.Run("InvalidChunkDigest", func(t *testing.T) {
+		_, err := contentAddressableStorageServer.SpliceBlob(ctx, &remoteexecution.SpliceBlobRequest{
+			InstanceName: "example",
+			BlobDigest:   blobDigest.GetProto(),
+			ChunkDigests: []*remoteexecution.Digest{
+				chunkDigest.GetProto(),
+				{Hash: "8b1a9953c4611296a827abf8c47804d7", SizeBytes: -1},
+			},
+		})
+		testutil.RequireEqualStatus(t, status.Error(codes.InvalidArgument, "Invalid digest for chunk at index 1: Invalid digest size: -1 bytes"), err)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		blobSplitter.EXPECT().SpliceBlob(ctx, blobDigest, []digest.Digest{chunkDigest, chunkDigest})
+
+		response, err := contentAddressableStorageServer.SpliceBlob(ctx, &remoteexecution.SpliceBlobRequest{
+			InstanceName: "example",
+			BlobDigest:   blobDigest.GetProto(),
+			ChunkDigests: []*remoteexecution.Digest{
+				chunkDigest.GetProto(),
+				chunkDigest.GetProto(),
+			},
+		})
+		require.NoError(t, err)
+		testutil.RequireEqualProto(t, &remoteexecution.SpliceBlobResponse{
+			BlobDigest: blobDigest.GetProto(),
+		}, response)
+	})
+}
@@ -0,0 +1,246 @@
+package grpcservers
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/buildbarn/bb-storage/pkg/auth"
+	"github.com/buildbarn/bb-storage/pkg/clock"
+	"github.com/buildbarn/bb-storage/pkg/digest"
+	"github.com/buildbarn/bb-storage/pkg/filesystem"
+	"github.com/buildbarn/bb-storage/pkg/filesystem/path"
+	"github.com/buildbarn/bb-storage/pkg/util"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UploadStagingArea stores the data of ByteStream uploads that are in
+// progress in a directory on disk. This permits clients to resume
+// uploads that got interrupted, by calling QueryWriteStatus() and
+// issuing a new Write() call starting at the offset that was returned.
+//
+// Uploads are identified by their ByteStream resource name, which
+// contains the upload UUID chosen by the client. The total amount of
+// data stored is bounded. Uploads that have not received any data for
+// a configurable amount of time are discarded.
+//
+// As data is stored in the staging area before it is written to
+// storage, access to uploads is authorized separately, using the same
+// Authorizer that is used to authorize writes to storage.
+type UploadStagingArea struct {
+	directory            filesystem.Directory
+	clock                clock.Clock
+	authorizer           auth.Authorizer
+	minimumBlobSizeBytes int64
+	maximumSizeBytes     int64
+	expiration           time.Duration
+
+	lock           sync.Mutex
+	uploads        map[string]*stagedUpload
+	totalSizeBytes int64
+	nextFileID     uint64
+}
+
+// NewUploadStagingArea creates an UploadStagingArea that stores data
+// in a given directory. The directory is assumed to be empty and not
+// be used for any other purpose.
+//
+// Only uploads of blobs that are at least minimumBlobSizeBytes in size
+// are staged, as restarting uploads of small blobs is cheap.
+func NewUploadStagingArea(directory filesystem.Directory, clock clock.Clock, authorizer auth.Authorizer, minimumBlobSizeBytes, maximumSizeBytes int64, expiration time.Duration) *UploadStagingArea {
+	return &UploadStagingArea{
+		directory:            directory,
+		clock:                clock,
+		authorizer:           authorizer,
+		minimumBlobSizeBytes: minimumBlobSizeBytes,
+		maximumSizeBytes:     maximumSizeBytes,
+		expiration:           expiration,
+
+		uploads: map[string]*stagedUpload{},
+	}
+}
+
+// stagedUpload contains the state of a single upload in the staging
+// area. Its fields are protected by UploadStagingArea.lock, with the
+// exception of the file's contents, which may only be accessed by the
+// Write() call that acquired the upload.
+type stagedUpload struct {
+	resourceName     string
+	fileName         path.Component
+	file             filesystem.FileReadWriter
+	sizeBytes        int64
+	lastModification time.Time
+	inUse            bool
+}
+
+// shouldStage returns whether uploads of blobs of a given size should
+// be stored in the staging area. Blobs that don't fit in the staging
+// area are written to storage directly.
+func (sa *UploadStagingArea) shouldStage(blobSizeBytes int64) bool {
+	return blobSizeBytes >= sa.minimumBlobSizeBytes && blobSizeBytes <= sa.maximumSizeBytes
+}
+
+// authorize checks whether the client is permitted to access uploads
+// for a given instance name.
+func (sa *UploadStagingArea) authorize(ctx context.Context, instanceName digest.InstanceName) error {
+	if err := auth.AuthorizeSingleInstanceName(ctx, sa.authorizer, instanceName); err != nil {
+		return util.StatusWrap(err, "Authorization")
+	}
+	return nil
+}
+
+// removeLocked removes an upload from the staging area, and deletes
+// its data from disk.
+func (sa *UploadStagingArea) removeLocked(upload *stagedUpload) {
+	delete(sa.uploads, upload.resourceName)
+	sa.totalSizeBytes -= upload.sizeBytes
+	upload.file.Close()
+	sa.directory.Remove(upload.fileName)
+}
+
+// removeExpiredLocked removes all uploads that are not in use and have
+// not received any data for the configured expiration time.
+func (sa *UploadStagingArea) removeExpiredLocked() {
+	cutoff := sa.clock.Now().Add(-sa.expiration)
+	for _, upload := range sa.uploads {
+		if !upload.inUse && !upload.lastModification.After(cutoff) {
+			sa.removeLocked(upload)
+		}
+	}
+}
+
+// acquire an upload for writing, starting at a given offset. A new
+// upload is created if the offset is zero. Otherwise, the offset must
+// match the amount of data that has been staged previously.
+func (sa *UploadStagingArea) acquire(ctx context.Context, instanceName digest.InstanceName, resourceName string, writeOffset int64) (*stagedUpload, error) {
+	if err := sa.authorize(ctx, instanceName); err != nil {
+		return nil, err
+	}
+
+	sa.lock.Lock()
+	defer sa.lock.Unlock()
+
+	sa.removeExpiredLocked()
+	upload, ok := sa.uploads[resourceName]
+	if ok && upload.inUse {
+		return nil, status.Errorf(codes.Aborted, "Upload %#v is already in progress", resourceName)
+	}
+
+	if writeOffset == 0 {
+		// Client is starting a new upload. Discard any data
+		// that was uploaded previously.
+		if ok {
+			sa.removeLocked(upload)
+		}
+		fileName := path.MustNewComponent(strconv.FormatUint(sa.nextFileID, 10))
+		sa.nextFileID++
+		file, err := sa.directory.OpenReadWrite(fileName, filesystem.CreateExcl(0o600))
+		if err != nil {
+			return nil, util.StatusWrapfWithCode(err, codes.Internal, "Failed to create staging file %#v", fileName.String())
+		}
+		upload = &stagedUpload{
+			resourceName:     resourceName,
+			fileName:         fileName,
+			file:             file,
+			lastModification: sa.clock.Now(),
+			inUse:            true,
+		}
+		sa.uploads[resourceName] = upload
+		return upload, nil
+	}
+
+	// Client is resuming an existing upload.
+	var sizeBytes int64
+	if ok {
+		sizeBytes = upload.sizeBytes
+	}
+	if writeOffset != sizeBytes {
+		return nil, status.Errorf(codes.InvalidArgument, "Attempted to write at offset %d, while %d was expected", writeOffset, sizeBytes)
+	}
+	upload.inUse = true
+	return upload, nil
+}
+
+// append data to an upload that was previously acquired. If the
+// staging area is full, uploads that are not in use are discarded,
+// starting with the ones that were modified least recently. If this
+// does not free up enough space, false is returned. The caller should
+// then write the data to storage directly.
+func (sa *UploadStagingArea) append(upload *stagedUpload, data []byte) (bool, error) {
+	if len(data) == 0 {
+		return true, nil
+	}
+
+	sa.lock.Lock()
+	sa.removeExpiredLocked()
+	for sa.totalSizeBytes+int64(len(data)) > sa.maximumSizeBytes {
+		var oldestUpload *stagedUpload
+		for _, candidate := range sa.uploads {
+			if !candidate.inUse && (oldestUpload == nil || candidate.lastModification.Before(oldestUpload.lastModification)) {
+				oldestUpload = candidate
+			}
+		}
+		if oldestUpload == nil {
+			sa.lock.Unlock()
+			return false, nil
+		}
+		sa.removeLocked(oldestUpload)
+	}
+	// Reserve space prior to writing, so that concurrent uploads
+	// don't exceed the maximum size.
+	sa.totalSizeBytes += int64(len(data))
+	sa.lock.Unlock()
+
+	_, err := upload.file.WriteAt(data, upload.sizeBytes)
+
+	sa.lock.Lock()
+	defer sa.lock.Unlock()
+	if err != nil {
+		sa.totalSizeBytes -= int64(len(data))
+		return false, util.StatusWrapWithCode(err, codes.Internal, "Failed to write to staging file")
+	}
+	upload.sizeBytes += int64(len(data))
+	upload.lastModification = sa.clock.Now()
+	return true, nil
+}
+
+// newReader returns a reader for all of the data that has been staged
+// for an upload.
+func (sa *UploadStagingArea) newReader(upload *stagedUpload) io.ReadCloser {
+	return io.NopCloser(io.NewSectionReader(upload.file, 0, upload.sizeBytes))
+}
+
+// release an upload that was previously acquired. If the upload did
+// not complete, its data is retained, so that the client may resume
+// the upload at a later point in time.
+func (sa *UploadStagingArea) release(upload *stagedUpload, completed bool) {
+	sa.lock.Lock()
+	defer sa.lock.Unlock()
+
+	upload.inUse = false
+	if completed {
+		sa.removeLocked(upload)
+	}
+}
+
+// getCommittedSize returns the amount of data that has been staged for
+// an upload, if the upload exists.
+func (sa *UploadStagingArea) getCommittedSize(ctx context.Context, instanceName digest.InstanceName, resourceName string) (int64, bool, error) {
+	if err := sa.authorize(ctx, instanceName); err != nil {
+		return 0, false, err
+	}
+
+	sa.lock.Lock()
+	defer sa.lock.Unlock()
+
+	sa.removeExpiredLocked()
+	upload, ok := sa.uploads[resourceName]
+	if !ok {
+		return 0, false, nil
+	}
+	return upload.sizeBytes, true, nil
+}
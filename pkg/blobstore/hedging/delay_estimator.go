@@ -0,0 +1,89 @@
+package hedging
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// DelayEstimator is used by HedgingBlobAccess to determine how long it
+// should wait for a backend to respond, before sending the same request
+// to another backend.
+type DelayEstimator interface {
+	// GetDelay returns the amount of time to wait before issuing a
+	// hedged request.
+	GetDelay() time.Duration
+	// RecordLatency informs the DelayEstimator of the amount of
+	// time it took for a backend to respond to a request.
+	RecordLatency(latency time.Duration)
+}
+
+type percentileDelayEstimator struct {
+	percentile   float64
+	minimumDelay time.Duration
+	maximumDelay time.Duration
+
+	lock                       sync.Mutex
+	latencies                  []time.Duration
+	nextIndex                  int
+	samplesUntilRecomputeDelay int
+	delay                      time.Duration
+}
+
+// NewPercentileDelayEstimator creates a DelayEstimator that computes
+// the delay by taking a percentile of the latencies of the most recent
+// requests. For example, if the percentile is 0.95, hedged requests
+// are only issued for the 5% slowest requests, meaning that the load on
+// the backends is only increased by a small amount.
+//
+// Until a sufficient number of latencies has been recorded, the
+// maximum delay is returned. The delay is always kept between the
+// provided minimum and maximum.
+func NewPercentileDelayEstimator(percentile float64, windowSize int, minimumDelay, maximumDelay time.Duration) DelayEstimator {
+	return &percentileDelayEstimator{
+		percentile:   percentile,
+		minimumDelay: minimumDelay,
+		maximumDelay: maximumDelay,
+		latencies:    make([]time.Duration, 0, windowSize),
+		delay:        maximumDelay,
+	}
+}
+
+func (de *percentileDelayEstimator) GetDelay() time.Duration {
+	de.lock.Lock()
+	defer de.lock.Unlock()
+	return de.delay
+}
+
+func (de *percentileDelayEstimator) RecordLatency(latency time.Duration) {
+	de.lock.Lock()
+	defer de.lock.Unlock()
+
+	// Store the latency in a ring buffer.
+	if len(de.latencies) < cap(de.latencies) {
+		de.latencies = append(de.latencies, latency)
+	} else {
+		de.latencies[de.nextIndex] = latency
+	}
+	de.nextIndex = (de.nextIndex + 1) % cap(de.latencies)
+	if len(de.latencies) < cap(de.latencies) {
+		return
+	}
+
+	// Sorting the latencies is relatively expensive. Only
+	// recompute the delay after a fraction of the window has been
+	// replaced.
+	if de.samplesUntilRecomputeDelay > 0 {
+		de.samplesUntilRecomputeDelay--
+		return
+	}
+	de.samplesUntilRecomputeDelay = len(de.latencies)/16 - 1
+
+	sorted := slices.Clone(de.latencies)
+	slices.Sort(sorted)
+	index := int(de.percentile * float64(len(sorted)))
+	if index >= len(sorted) {
+		index = len(sorted) - 1
+	}
+	de.delay = min(max(sorted[index], de.minimumDelay), de.maximumDelay)
+}
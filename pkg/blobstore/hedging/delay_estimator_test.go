@@ -0,0 +1,38 @@
+package hedging_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/buildbarn/bb-storage/pkg/blobstore/hedging"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPercentileDelayEstimator(t *testing.T) {
+	delayEstimator := hedging.NewPercentileDelayEstimator(0.75, 4, 10*time.Millisecond, time.Second)
+
+	// Until the window is filled, the maximum delay should be used.
+	require.Equal(t, time.Second, delayEstimator.GetDelay())
+	delayEstimator.RecordLatency(40 * time.Millisecond)
+	delayEstimator.RecordLatency(20 * time.Millisecond)
+	delayEstimator.RecordLatency(30 * time.Millisecond)
+	require.Equal(t, time.Second, delayEstimator.GetDelay())
+
+	delayEstimator.RecordLatency(50 * time.Millisecond)
+	require.Equal(t, 50*time.Millisecond, delayEstimator.GetDelay())
+
+	// The oldest latencies should be discarded.
+	delayEstimator.RecordLatency(1 * time.Millisecond)
+	require.Equal(t, 50*time.Millisecond, delayEstimator.GetDelay())
+	delayEstimator.RecordLatency(2 * time.Millisecond)
+	delayEstimator.RecordLatency(3 * time.Millisecond)
+	require.Equal(t, 50*time.Millisecond, delayEstimator.GetDelay())
+
+	// The delay should be kept between the minimum and maximum.
+	delayEstimator.RecordLatency(4 * time.Millisecond)
+	require.Equal(t, 10*time.Millisecond, delayEstimator.GetDelay())
+	for i := 0; i < 4; i++ {
+		delayEstimator.RecordLatency(time.Minute)
+	}
+	require.Equal(t, time.Second, delayEstimator.GetDelay())
+}
@@ -0,0 +1,344 @@
+package hedging
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	remoteexecution "github.com/bazelbuild/remote-apis/build/bazel/remote/execution/v2"
+	"github.com/buildbarn/bb-storage/pkg/blobstore"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/buffer"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/slicing"
+	"github.com/buildbarn/bb-storage/pkg/clock"
+	"github.com/buildbarn/bb-storage/pkg/digest"
+	"github.com/buildbarn/bb-storage/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	hedgingBlobAccessPrometheusMetrics sync.Once
+
+	hedgingBlobAccessHedgesIssued = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "buildbarn",
+			Subsystem: "blobstore",
+			Name:      "hedging_blob_access_hedges_issued_total",
+			Help:      "Number of hedged requests sent to another backend, because the previous backend did not respond in time",
+		},
+		[]string{"operation"})
+	hedgingBlobAccessHedgesWon = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "buildbarn",
+			Subsystem: "blobstore",
+			Name:      "hedging_blob_access_hedges_won_total",
+			Help:      "Number of hedged requests that responded before the requests that were sent earlier",
+		},
+		[]string{"operation"})
+)
+
+type hedgingOperation struct {
+	delayEstimator DelayEstimator
+	hedgesIssued   prometheus.Counter
+	hedgesWon      prometheus.Counter
+}
+
+func newHedgingOperation(name string, delayEstimator DelayEstimator) hedgingOperation {
+	return hedgingOperation{
+		delayEstimator: delayEstimator,
+		hedgesIssued:   hedgingBlobAccessHedgesIssued.WithLabelValues(name),
+		hedgesWon:      hedgingBlobAccessHedgesWon.WithLabelValues(name),
+	}
+}
+
+type hedgingBlobAccess struct {
+	backends          []blobstore.BlobAccess
+	readBufferFactory blobstore.ReadBufferFactory
+	clock             clock.Clock
+	round             atomic.Uint32
+
+	get              hedgingOperation
+	getFromComposite hedgingOperation
+	findMissing      hedgingOperation
+}
+
+// NewHedgingBlobAccess creates a BlobAccess that forwards requests to
+// one of multiple equivalent backends, such as multiple frontends in
+// front of the same storage cluster. If a backend does not respond to
+// Get(), GetFromComposite() or FindMissing() within a delay computed by
+// a DelayEstimator, the same request is sent to the next backend. The
+// response of the backend that responds first is used, while the
+// requests to the other backends are cancelled. This reduces tail
+// latency in case individual backends stall.
+//
+// Get() and GetFromComposite() are considered to have responded as
+// soon as the first byte of data has been received. Only errors that
+// indicate that a backend is unhealthy (e.g., UNAVAILABLE) cause the
+// request to be sent to the next backend without waiting for the delay
+// to expire. Other errors (e.g., NOT_FOUND) are returned immediately.
+// Put() and GetCapabilities() are not hedged.
+func NewHedgingBlobAccess(backends []blobstore.BlobAccess, readBufferFactory blobstore.ReadBufferFactory, clock clock.Clock, getDelayEstimator, findMissingDelayEstimator DelayEstimator) blobstore.BlobAccess {
+	hedgingBlobAccessPrometheusMetrics.Do(func() {
+		prometheus.MustRegister(hedgingBlobAccessHedgesIssued)
+		prometheus.MustRegister(hedgingBlobAccessHedgesWon)
+	})
+
+	return &hedgingBlobAccess{
+		backends:          backends,
+		readBufferFactory: readBufferFactory,
+		clock:             clock,
+
+		get:              newHedgingOperation("Get", getDelayEstimator),
+		getFromComposite: newHedgingOperation("GetFromComposite", getDelayEstimator),
+		findMissing:      newHedgingOperation("FindMissing", findMissingDelayEstimator),
+	}
+}
+
+// getBackendOrder returns the order in which backends should be
+// contacted. Requests alternate between backends to spread the load
+// between them equally.
+func (ba *hedgingBlobAccess) getBackendOrder() []blobstore.BlobAccess {
+	first := int(ba.round.Add(1)-1) % len(ba.backends)
+	return append(append([]blobstore.BlobAccess(nil), ba.backends[first:]...), ba.backends[:first]...)
+}
+
+// hedgedAttempt is the outcome of sending a request to a single
+// backend.
+type hedgedAttempt[T any] struct {
+	index int
+	value T
+	err   error
+}
+
+// hedgedRequest keeps track of the requests sent to backends as part
+// of a single call against HedgingBlobAccess.
+type hedgedRequest[T any] struct {
+	blobAccess *hedgingBlobAccess
+	operation  *hedgingOperation
+	backends   []blobstore.BlobAccess
+	send       func(ctx context.Context, backend blobstore.BlobAccess) (T, error)
+	discard    func(T)
+
+	results    chan hedgedAttempt[T]
+	cancels    []context.CancelFunc
+	startTimes []time.Time
+	hedged     []bool
+	completed  int
+
+	timer        clock.Timer
+	timerChannel <-chan time.Time
+}
+
+// isRetriableError returns whether an error returned by a backend
+// indicates that the backend itself is unhealthy, meaning that other
+// backends may still be capable of processing the request. Other
+// errors, such as NOT_FOUND, apply to all backends equally.
+func isRetriableError(err error) bool {
+	switch status.Code(err) {
+	case codes.DeadlineExceeded, codes.ResourceExhausted, codes.Unavailable:
+		return true
+	default:
+		return false
+	}
+}
+
+// hedge sends a request to the first backend. If it fails to respond
+// before the delay expires, the request is sent to the next backend.
+// If all backends that were contacted return a retriable error, the
+// request is sent to the next backend immediately. Any other error is
+// returned immediately, as the backends are equivalent. The value of
+// the first
+// successful response is returned, together with a function that must
+// be called to release the context used to obtain it. Values of
+// successful responses that lose the race are released using the
+// discard function.
+func hedge[T any](ctx context.Context, ba *hedgingBlobAccess, operation *hedgingOperation, send func(ctx context.Context, backend blobstore.BlobAccess) (T, error), discard func(T)) (T, context.CancelFunc, error) {
+	backends := ba.getBackendOrder()
+	r := hedgedRequest[T]{
+		blobAccess: ba,
+		operation:  operation,
+		backends:   backends,
+		send:       send,
+		discard:    discard,
+		results:    make(chan hedgedAttempt[T], len(backends)),
+	}
+	r.sendNext(ctx, false)
+	defer r.stopTimer()
+
+	var firstErr error
+	for {
+		select {
+		case <-ctx.Done():
+			r.cancelRemaining(-1)
+			var zero T
+			return zero, nil, util.StatusFromContext(ctx)
+		case <-r.timerChannel:
+			// The backends that were contacted did not
+			// respond in time.
+			r.timer, r.timerChannel = nil, nil
+			operation.hedgesIssued.Inc()
+			r.sendNext(ctx, true)
+		case result := <-r.results:
+			r.completed++
+			if result.err == nil {
+				operation.delayEstimator.RecordLatency(ba.clock.Now().Sub(r.startTimes[result.index]))
+				if r.hedged[result.index] {
+					operation.hedgesWon.Inc()
+				}
+				r.cancelRemaining(result.index)
+				return result.value, r.cancels[result.index], nil
+			}
+			if !isRetriableError(result.err) {
+				r.cancelRemaining(-1)
+				var zero T
+				return zero, nil, result.err
+			}
+			if firstErr == nil {
+				firstErr = result.err
+			}
+			if r.completed == len(backends) {
+				r.cancelRemaining(-1)
+				var zero T
+				return zero, nil, firstErr
+			}
+			if r.completed == len(r.cancels) {
+				// All backends that were contacted
+				// failed. There is no need to wait for
+				// the delay to expire.
+				r.stopTimer()
+				r.sendNext(ctx, false)
+			}
+		}
+	}
+}
+
+func (r *hedgedRequest[T]) sendNext(ctx context.Context, hedged bool) {
+	index := len(r.cancels)
+	attemptCtx, cancel := context.WithCancel(ctx)
+	r.cancels = append(r.cancels, cancel)
+	r.startTimes = append(r.startTimes, r.blobAccess.clock.Now())
+	r.hedged = append(r.hedged, hedged)
+	go func() {
+		value, err := r.send(attemptCtx, r.backends[index])
+		r.results <- hedgedAttempt[T]{
+			index: index,
+			value: value,
+			err:   err,
+		}
+	}()
+
+	if len(r.cancels) < len(r.backends) {
+		r.timer, r.timerChannel = r.blobAccess.clock.NewTimer(r.operation.delayEstimator.GetDelay())
+	}
+}
+
+func (r *hedgedRequest[T]) stopTimer() {
+	if r.timer != nil {
+		r.timer.Stop()
+		r.timer, r.timerChannel = nil, nil
+	}
+}
+
+// cancelRemaining cancels all requests other than the one that
+// succeeded. Values of requests that still complete successfully are
+// discarded in the background.
+func (r *hedgedRequest[T]) cancelRemaining(winner int) {
+	for i, cancel := range r.cancels {
+		if i != winner {
+			cancel()
+		}
+	}
+	if remaining := len(r.cancels) - r.completed; remaining > 0 {
+		go func() {
+			for i := 0; i < remaining; i++ {
+				if result := <-r.results; result.err == nil {
+					r.discard(result.value)
+				}
+			}
+		}()
+	}
+}
+
+// contextCancelingReader is a decorator for io.ReadCloser that releases
+// the context used to create the reader upon closure.
+type contextCancelingReader struct {
+	*bufio.Reader
+	closer io.Closer
+	cancel context.CancelFunc
+}
+
+func (r *contextCancelingReader) Close() error {
+	err := r.closer.Close()
+	r.cancel()
+	return err
+}
+
+func (ba *hedgingBlobAccess) getHedged(ctx context.Context, blobDigest digest.Digest, operation *hedgingOperation, get func(ctx context.Context, backend blobstore.BlobAccess) buffer.Buffer) buffer.Buffer {
+	r, cancel, err := hedge(
+		ctx,
+		ba,
+		operation,
+		func(ctx context.Context, backend blobstore.BlobAccess) (*contextCancelingReader, error) {
+			// Wait for the first byte of data to arrive, so
+			// that stalled backends can be detected.
+			rawReader := get(ctx, backend).ToReader()
+			r := &contextCancelingReader{
+				Reader: bufio.NewReader(rawReader),
+				closer: rawReader,
+			}
+			if _, err := r.Peek(1); err != nil && err != io.EOF {
+				rawReader.Close()
+				return nil, err
+			}
+			return r, nil
+		},
+		func(r *contextCancelingReader) {
+			r.closer.Close()
+		})
+	if err != nil {
+		return buffer.NewBufferFromError(err)
+	}
+	r.cancel = cancel
+	return ba.readBufferFactory.NewBufferFromReader(blobDigest, r, buffer.Irreparable(blobDigest))
+}
+
+func (ba *hedgingBlobAccess) Get(ctx context.Context, digest digest.Digest) buffer.Buffer {
+	return ba.getHedged(ctx, digest, &ba.get, func(ctx context.Context, backend blobstore.BlobAccess) buffer.Buffer {
+		return backend.Get(ctx, digest)
+	})
+}
+
+func (ba *hedgingBlobAccess) GetFromComposite(ctx context.Context, parentDigest, childDigest digest.Digest, slicer slicing.BlobSlicer) buffer.Buffer {
+	return ba.getHedged(ctx, childDigest, &ba.getFromComposite, func(ctx context.Context, backend blobstore.BlobAccess) buffer.Buffer {
+		return backend.GetFromComposite(ctx, parentDigest, childDigest, slicer)
+	})
+}
+
+func (ba *hedgingBlobAccess) Put(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
+	return ba.getBackendOrder()[0].Put(ctx, digest, b)
+}
+
+func (ba *hedgingBlobAccess) FindMissing(ctx context.Context, digests digest.Set) (digest.Set, error) {
+	missing, cancel, err := hedge(
+		ctx,
+		ba,
+		&ba.findMissing,
+		func(ctx context.Context, backend blobstore.BlobAccess) (digest.Set, error) {
+			return backend.FindMissing(ctx, digests)
+		},
+		func(digest.Set) {})
+	if err != nil {
+		return digest.EmptySet, err
+	}
+	cancel()
+	return missing, nil
+}
+
+func (ba *hedgingBlobAccess) GetCapabilities(ctx context.Context, instanceName digest.InstanceName) (*remoteexecution.ServerCapabilities, error) {
+	return ba.getBackendOrder()[0].GetCapabilities(ctx, instanceName)
+}
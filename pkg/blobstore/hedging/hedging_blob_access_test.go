@@ -0,0 +1,226 @@
+package hedging_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	remoteexecution "github.com/bazelbuild/remote-apis/build/bazel/remote/execution/v2"
+	"github.com/buildbarn/bb-storage/internal/mock"
+	"github.com/buildbarn/bb-storage/pkg/blobstore"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/buffer"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/hedging"
+	"github.com/buildbarn/bb-storage/pkg/digest"
+	"github.com/buildbarn/bb-storage/pkg/testutil"
+	"github.com/stretchr/testify/require"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestHedgingBlobAccess(t *testing.T) {
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+
+	backend0 := mock.NewMockBlobAccess(ctrl)
+	backend1 := mock.NewMockBlobAccess(ctrl)
+	clock := mock.NewMockClock(ctrl)
+	clock.EXPECT().Now().Return(time.Unix(1000, 0)).AnyTimes()
+	newBlobAccess := func() blobstore.BlobAccess {
+		return hedging.NewHedgingBlobAccess(
+			[]blobstore.BlobAccess{backend0, backend1},
+			blobstore.CASReadBufferFactory,
+			clock,
+			hedging.NewPercentileDelayEstimator(0.95, 100, time.Millisecond, 100*time.Millisecond),
+			hedging.NewPercentileDelayEstimator(0.95, 100, time.Millisecond, 100*time.Millisecond))
+	}
+
+	helloDigest := digest.MustNewDigest("example", remoteexecution.DigestFunction_MD5, "8b1a9953c4611296a827abf8c47804d7", 5)
+
+	t.Run("GetFirstBackendResponds", func(t *testing.T) {
+		blobAccess := newBlobAccess()
+		timer := mock.NewMockTimer(ctrl)
+		clock.EXPECT().NewTimer(100*time.Millisecond).Return(timer, nil)
+		backend0.EXPECT().Get(gomock.Any(), helloDigest).
+			Return(buffer.NewValidatedBufferFromByteSlice([]byte("Hello")))
+		timer.EXPECT().Stop()
+
+		data, err := blobAccess.Get(ctx, helloDigest).ToByteSlice(100)
+		require.NoError(t, err)
+		require.Equal(t, []byte("Hello"), data)
+	})
+
+	t.Run("GetHedgeWins", func(t *testing.T) {
+		// If the first backend stalls, the request should be
+		// sent to the second backend after the delay expires.
+		// The request to the first backend should be cancelled.
+		blobAccess := newBlobAccess()
+		timerChannel := make(chan time.Time, 1)
+		clock.EXPECT().NewTimer(100*time.Millisecond).Return(mock.NewMockTimer(ctrl), timerChannel)
+		backend0.EXPECT().Get(gomock.Any(), helloDigest).DoAndReturn(
+			func(ctx context.Context, digest digest.Digest) buffer.Buffer {
+				timerChannel <- time.Unix(1000, 100000000)
+				<-ctx.Done()
+				return buffer.NewBufferFromError(status.Error(codes.Canceled, "Request cancelled"))
+			})
+		backend1.EXPECT().Get(gomock.Any(), helloDigest).
+			Return(buffer.NewValidatedBufferFromByteSlice([]byte("Hello")))
+
+		data, err := blobAccess.Get(ctx, helloDigest).ToByteSlice(100)
+		require.NoError(t, err)
+		require.Equal(t, []byte("Hello"), data)
+	})
+
+	t.Run("GetFirstBackendFails", func(t *testing.T) {
+		// If the first backend fails, the request should be
+		// sent to the second backend without waiting for the
+		// delay to expire.
+		blobAccess := newBlobAccess()
+		timer := mock.NewMockTimer(ctrl)
+		clock.EXPECT().NewTimer(100*time.Millisecond).Return(timer, nil)
+		backend0.EXPECT().Get(gomock.Any(), helloDigest).
+			Return(buffer.NewBufferFromError(status.Error(codes.Unavailable, "Server offline")))
+		timer.EXPECT().Stop()
+		backend1.EXPECT().Get(gomock.Any(), helloDigest).
+			Return(buffer.NewValidatedBufferFromByteSlice([]byte("Hello")))
+
+		data, err := blobAccess.Get(ctx, helloDigest).ToByteSlice(100)
+		require.NoError(t, err)
+		require.Equal(t, []byte("Hello"), data)
+	})
+
+	t.Run("GetAllBackendsFail", func(t *testing.T) {
+		blobAccess := newBlobAccess()
+		timer := mock.NewMockTimer(ctrl)
+		clock.EXPECT().NewTimer(100*time.Millisecond).Return(timer, nil)
+		backend0.EXPECT().Get(gomock.Any(), helloDigest).
+			Return(buffer.NewBufferFromError(status.Error(codes.Unavailable, "Server offline")))
+		timer.EXPECT().Stop()
+		backend1.EXPECT().Get(gomock.Any(), helloDigest).
+			Return(buffer.NewBufferFromError(status.Error(codes.ResourceExhausted, "Out of memory")))
+
+		_, err := blobAccess.Get(ctx, helloDigest).ToByteSlice(100)
+		testutil.RequireEqualStatus(t, status.Error(codes.Unavailable, "Server offline"), err)
+	})
+
+	t.Run("GetNotFound", func(t *testing.T) {
+		// The backends are equivalent, meaning that there is no
+		// point in contacting the second backend if the object
+		// is absent.
+		blobAccess := newBlobAccess()
+		timer := mock.NewMockTimer(ctrl)
+		clock.EXPECT().NewTimer(100*time.Millisecond).Return(timer, nil)
+		backend0.EXPECT().Get(gomock.Any(), helloDigest).
+			Return(buffer.NewBufferFromError(status.Error(codes.NotFound, "Object not found")))
+		timer.EXPECT().Stop()
+
+		_, err := blobAccess.Get(ctx, helloDigest).ToByteSlice(100)
+		testutil.RequireEqualStatus(t, status.Error(codes.NotFound, "Object not found"), err)
+	})
+
+	t.Run("GetNotFoundAfterHedge", func(t *testing.T) {
+		// If the hedged request reports that the object is
+		// absent, there is no need to wait for the stalled
+		// request to complete.
+		blobAccess := newBlobAccess()
+		timerChannel := make(chan time.Time, 1)
+		clock.EXPECT().NewTimer(100*time.Millisecond).Return(mock.NewMockTimer(ctrl), timerChannel)
+		backend0.EXPECT().Get(gomock.Any(), helloDigest).DoAndReturn(
+			func(ctx context.Context, digest digest.Digest) buffer.Buffer {
+				timerChannel <- time.Unix(1000, 100000000)
+				<-ctx.Done()
+				return buffer.NewBufferFromError(status.Error(codes.Canceled, "Request cancelled"))
+			})
+		backend1.EXPECT().Get(gomock.Any(), helloDigest).
+			Return(buffer.NewBufferFromError(status.Error(codes.NotFound, "Object not found")))
+
+		_, err := blobAccess.Get(ctx, helloDigest).ToByteSlice(100)
+		testutil.RequireEqualStatus(t, status.Error(codes.NotFound, "Object not found"), err)
+	})
+
+	t.Run("GetAlternation", func(t *testing.T) {
+		// Successive requests should alternate between backends.
+		blobAccess := newBlobAccess()
+		timer := mock.NewMockTimer(ctrl)
+		clock.EXPECT().NewTimer(100*time.Millisecond).Return(timer, nil).Times(2)
+		timer.EXPECT().Stop().Times(2)
+		gomock.InOrder(
+			backend0.EXPECT().Get(gomock.Any(), helloDigest).
+				Return(buffer.NewValidatedBufferFromByteSlice([]byte("Hello"))),
+			backend1.EXPECT().Get(gomock.Any(), helloDigest).
+				Return(buffer.NewValidatedBufferFromByteSlice([]byte("Hello"))))
+
+		for i := 0; i < 2; i++ {
+			data, err := blobAccess.Get(ctx, helloDigest).ToByteSlice(100)
+			require.NoError(t, err)
+			require.Equal(t, []byte("Hello"), data)
+		}
+	})
+
+	t.Run("GetCorrupted", func(t *testing.T) {
+		// Data returned by the backends should be validated.
+		blobAccess := newBlobAccess()
+		timer := mock.NewMockTimer(ctrl)
+		clock.EXPECT().NewTimer(100*time.Millisecond).Return(timer, nil)
+		backend0.EXPECT().Get(gomock.Any(), helloDigest).
+			Return(buffer.NewValidatedBufferFromByteSlice([]byte("Jello")))
+		timer.EXPECT().Stop()
+
+		_, err := blobAccess.Get(ctx, helloDigest).ToByteSlice(100)
+		testutil.RequireEqualStatus(t, status.Error(codes.Internal, "Buffer has checksum bedad9eef4de4b391cc5aeb8ddbe6387, while 8b1a9953c4611296a827abf8c47804d7 was expected"), err)
+	})
+
+	digests := digest.NewSetBuilder().
+		Add(helloDigest).
+		Add(digest.MustNewDigest("example", remoteexecution.DigestFunction_MD5, "6fc422233a40a75a1f028e11c3cd1140", 7)).
+		Build()
+
+	t.Run("FindMissingFirstBackendResponds", func(t *testing.T) {
+		blobAccess := newBlobAccess()
+		timer := mock.NewMockTimer(ctrl)
+		clock.EXPECT().NewTimer(100*time.Millisecond).Return(timer, nil)
+		backend0.EXPECT().FindMissing(gomock.Any(), digests).Return(helloDigest.ToSingletonSet(), nil)
+		timer.EXPECT().Stop()
+
+		missing, err := blobAccess.FindMissing(ctx, digests)
+		require.NoError(t, err)
+		require.Equal(t, helloDigest.ToSingletonSet(), missing)
+	})
+
+	t.Run("FindMissingHedgeWins", func(t *testing.T) {
+		blobAccess := newBlobAccess()
+		timerChannel := make(chan time.Time, 1)
+		clock.EXPECT().NewTimer(100*time.Millisecond).Return(mock.NewMockTimer(ctrl), timerChannel)
+		backend0.EXPECT().FindMissing(gomock.Any(), digests).DoAndReturn(
+			func(ctx context.Context, digests digest.Set) (digest.Set, error) {
+				timerChannel <- time.Unix(1000, 100000000)
+				<-ctx.Done()
+				return digest.EmptySet, status.Error(codes.Canceled, "Request cancelled")
+			})
+		backend1.EXPECT().FindMissing(gomock.Any(), digests).Return(helloDigest.ToSingletonSet(), nil)
+
+		missing, err := blobAccess.FindMissing(ctx, digests)
+		require.NoError(t, err)
+		require.Equal(t, helloDigest.ToSingletonSet(), missing)
+	})
+
+	t.Run("FindMissingContextCancelled", func(t *testing.T) {
+		// If the caller gives up, all outstanding requests
+		// should be cancelled.
+		blobAccess := newBlobAccess()
+		timer := mock.NewMockTimer(ctrl)
+		clock.EXPECT().NewTimer(100*time.Millisecond).Return(timer, nil)
+		timer.EXPECT().Stop()
+		cancelledCtx, cancel := context.WithCancel(ctx)
+		backend0.EXPECT().FindMissing(gomock.Any(), digests).DoAndReturn(
+			func(ctx context.Context, digests digest.Set) (digest.Set, error) {
+				cancel()
+				<-ctx.Done()
+				return digest.EmptySet, status.Error(codes.Canceled, "Request cancelled")
+			})
+
+		_, err := blobAccess.FindMissing(cancelledCtx, digests)
+		testutil.RequireEqualStatus(t, status.Error(codes.Canceled, "context canceled"), err)
+	})
+}
@@ -0,0 +1,191 @@
+package blobstore
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/buildbarn/bb-storage/pkg/blobstore/buffer"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/slicing"
+	"github.com/buildbarn/bb-storage/pkg/capabilities"
+	"github.com/buildbarn/bb-storage/pkg/digest"
+	"github.com/buildbarn/bb-storage/pkg/util"
+
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type httpBlobAccess struct {
+	capabilities.Provider
+	readBufferFactory      ReadBufferFactory
+	httpClient             *http.Client
+	address                string
+	storageType            string
+	findMissingConcurrency int
+}
+
+// NewHTTPBlobAccess creates a BlobAccess that stores objects on a
+// server that implements the HTTP caching protocol used by Bazel, such
+// as nginx with WebDAV enabled. Objects are stored at URLs of the form
+// ${address}/${instanceName}/${storageType}/${hash}, where storageType
+// is either "ac" or "cas". The instance name is omitted if empty.
+//
+// As the protocol provides no facilities for checking the existence
+// of multiple objects at once, FindMissing() issues one HEAD request
+// per object, of which at most findMissingConcurrency are issued in
+// parallel.
+func NewHTTPBlobAccess(capabilitiesProvider capabilities.Provider, readBufferFactory ReadBufferFactory, httpClient *http.Client, address, storageType string, findMissingConcurrency int) BlobAccess {
+	return &httpBlobAccess{
+		Provider:               capabilitiesProvider,
+		readBufferFactory:      readBufferFactory,
+		httpClient:             httpClient,
+		address:                address,
+		storageType:            storageType,
+		findMissingConcurrency: findMissingConcurrency,
+	}
+}
+
+func (ba *httpBlobAccess) getURL(blobDigest digest.Digest) string {
+	if instanceName := blobDigest.GetInstanceName().String(); instanceName != "" {
+		return ba.address + "/" + instanceName + "/" + ba.storageType + "/" + blobDigest.GetHashString()
+	}
+	return ba.address + "/" + ba.storageType + "/" + blobDigest.GetHashString()
+}
+
+// httpResponseToStatus converts the status code of an HTTP response
+// that did not succeed to a gRPC status.
+func httpResponseToStatus(resp *http.Response) error {
+	code := codes.Internal
+	switch resp.StatusCode {
+	case http.StatusBadRequest:
+		code = codes.InvalidArgument
+	case http.StatusUnauthorized:
+		code = codes.Unauthenticated
+	case http.StatusForbidden:
+		code = codes.PermissionDenied
+	case http.StatusNotFound:
+		code = codes.NotFound
+	case http.StatusRequestEntityTooLarge, http.StatusInsufficientStorage, http.StatusTooManyRequests:
+		code = codes.ResourceExhausted
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		code = codes.Unavailable
+	}
+	return status.Errorf(code, "HTTP request failed with status %#v", resp.Status)
+}
+
+func (ba *httpBlobAccess) Get(ctx context.Context, blobDigest digest.Digest) buffer.Buffer {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ba.getURL(blobDigest), nil)
+	if err != nil {
+		return buffer.NewBufferFromError(util.StatusWrapWithCode(err, codes.Internal, "Failed to create HTTP request"))
+	}
+	resp, err := ba.httpClient.Do(req)
+	if err != nil {
+		return buffer.NewBufferFromError(util.StatusWrap(errToStatus(err), "HTTP request failed"))
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return buffer.NewBufferFromError(httpResponseToStatus(resp))
+	}
+	return ba.readBufferFactory.NewBufferFromReader(
+		blobDigest,
+		statusReturningReadCloser{r: resp.Body},
+		buffer.Irreparable(blobDigest))
+}
+
+func (ba *httpBlobAccess) GetFromComposite(ctx context.Context, parentDigest, childDigest digest.Digest, slicer slicing.BlobSlicer) buffer.Buffer {
+	b, _ := slicer.Slice(ba.Get(ctx, parentDigest), childDigest)
+	return b
+}
+
+// errorCapturingReader is a decorator for io.ReadCloser that retains
+// the first error returned by the underlying reader, other than EOF.
+// This is used by Put() to return errors caused by the buffer (e.g.,
+// checksum mismatches) as is, as opposed to having them be wrapped by
+// the HTTP client.
+type errorCapturingReader struct {
+	r   io.ReadCloser
+	err error
+}
+
+func (r *errorCapturingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if err != nil && err != io.EOF && r.err == nil {
+		r.err = err
+	}
+	return n, err
+}
+
+func (r *errorCapturingReader) Close() error {
+	return r.r.Close()
+}
+
+func (ba *httpBlobAccess) Put(ctx context.Context, blobDigest digest.Digest, b buffer.Buffer) error {
+	sizeBytes, err := b.GetSizeBytes()
+	if err != nil {
+		b.Discard()
+		return err
+	}
+	body := &errorCapturingReader{r: b.ToReader()}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, ba.getURL(blobDigest), body)
+	if err != nil {
+		body.Close()
+		return util.StatusWrapWithCode(err, codes.Internal, "Failed to create HTTP request")
+	}
+	req.ContentLength = sizeBytes
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp, err := ba.httpClient.Do(req)
+	if body.err != nil {
+		if err == nil {
+			resp.Body.Close()
+		}
+		return body.err
+	}
+	if err != nil {
+		return util.StatusWrap(errToStatus(err), "HTTP request failed")
+	}
+	resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusNoContent:
+		return nil
+	default:
+		return httpResponseToStatus(resp)
+	}
+}
+
+func (ba *httpBlobAccess) FindMissing(ctx context.Context, digests digest.Set) (digest.Set, error) {
+	var missingLock sync.Mutex
+	missing := digest.NewSetBuilder()
+
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(ba.findMissingConcurrency)
+	for _, blobDigest := range digests.Items() {
+		group.Go(func() error {
+			blobURL := ba.getURL(blobDigest)
+			req, err := http.NewRequestWithContext(groupCtx, http.MethodHead, blobURL, nil)
+			if err != nil {
+				return util.StatusWrapWithCode(err, codes.Internal, "Failed to create HTTP request")
+			}
+			resp, err := ba.httpClient.Do(req)
+			if err != nil {
+				return util.StatusWrapf(errToStatus(err), "Failed to check for the existence of %#v", blobURL)
+			}
+			resp.Body.Close()
+			switch resp.StatusCode {
+			case http.StatusOK:
+			case http.StatusNotFound:
+				missingLock.Lock()
+				missing.Add(blobDigest)
+				missingLock.Unlock()
+			default:
+				return util.StatusWrapf(httpResponseToStatus(resp), "Failed to check for the existence of %#v", blobURL)
+			}
+			return nil
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return digest.EmptySet, err
+	}
+	return missing.Build(), nil
+}
@@ -0,0 +1,126 @@
+package blobstore_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	remoteexecution "github.com/bazelbuild/remote-apis/build/bazel/remote/execution/v2"
+	"github.com/buildbarn/bb-storage/pkg/blobstore"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/buffer"
+	"github.com/buildbarn/bb-storage/pkg/digest"
+	"github.com/buildbarn/bb-storage/pkg/testutil"
+	"github.com/stretchr/testify/require"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestHTTPBlobAccess(t *testing.T) {
+	ctx := context.Background()
+
+	// Simple in-memory implementation of the HTTP caching protocol.
+	var lock sync.Mutex
+	objects := map[string][]byte{}
+	unavailable := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		if unavailable {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			data, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(data)
+		case http.MethodPut:
+			data, err := io.ReadAll(r.Body)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			objects[r.URL.Path] = data
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	defer server.Close()
+
+	blobAccess := blobstore.NewHTTPBlobAccess(
+		nil,
+		blobstore.CASReadBufferFactory,
+		server.Client(),
+		server.URL,
+		"cas",
+		2)
+
+	helloDigest := digest.MustNewDigest("", remoteexecution.DigestFunction_MD5, "8b1a9953c4611296a827abf8c47804d7", 5)
+	worldDigest := digest.MustNewDigest("hello", remoteexecution.DigestFunction_MD5, "f5a7924e621e84c9280a9a27e1bcb7f6", 5)
+
+	t.Run("GetNotFound", func(t *testing.T) {
+		_, err := blobAccess.Get(ctx, helloDigest).ToByteSlice(100)
+		testutil.RequireEqualStatus(t, status.Error(codes.NotFound, "HTTP request failed with status \"404 Not Found\""), err)
+	})
+
+	t.Run("PutAndGet", func(t *testing.T) {
+		require.NoError(t, blobAccess.Put(ctx, helloDigest, buffer.NewValidatedBufferFromByteSlice([]byte("Hello"))))
+		require.Equal(t, []byte("Hello"), objects["/cas/8b1a9953c4611296a827abf8c47804d7"])
+
+		data, err := blobAccess.Get(ctx, helloDigest).ToByteSlice(100)
+		require.NoError(t, err)
+		require.Equal(t, []byte("Hello"), data)
+	})
+
+	t.Run("PutWithInstanceName", func(t *testing.T) {
+		require.NoError(t, blobAccess.Put(ctx, worldDigest, buffer.NewValidatedBufferFromByteSlice([]byte("World"))))
+		require.Equal(t, []byte("World"), objects["/hello/cas/f5a7924e621e84c9280a9a27e1bcb7f6"])
+		delete(objects, "/hello/cas/f5a7924e621e84c9280a9a27e1bcb7f6")
+	})
+
+	t.Run("PutCorrupted", func(t *testing.T) {
+		// Data that does not match the digest should cause the
+		// upload to be aborted, and the original error to be
+		// returned.
+		testutil.RequireEqualStatus(
+			t,
+			status.Error(codes.InvalidArgument, "Buffer has checksum 8b1a9953c4611296a827abf8c47804d7, while f5a7924e621e84c9280a9a27e1bcb7f6 was expected"),
+			blobAccess.Put(ctx, worldDigest, buffer.NewCASBufferFromReader(worldDigest, io.NopCloser(bytes.NewBufferString("Hello")), buffer.UserProvided)))
+		require.NotContains(t, objects, "/hello/cas/f5a7924e621e84c9280a9a27e1bcb7f6")
+	})
+
+	t.Run("FindMissing", func(t *testing.T) {
+		missing, err := blobAccess.FindMissing(ctx, digest.NewSetBuilder().Add(helloDigest).Add(worldDigest).Build())
+		require.NoError(t, err)
+		require.Equal(t, worldDigest.ToSingletonSet(), missing)
+
+		missing, err = blobAccess.FindMissing(ctx, digest.EmptySet)
+		require.NoError(t, err)
+		require.Equal(t, digest.EmptySet, missing)
+	})
+
+	t.Run("GetCorrupted", func(t *testing.T) {
+		objects["/cas/8b1a9953c4611296a827abf8c47804d7"] = []byte("Hallo")
+
+		_, err := blobAccess.Get(ctx, helloDigest).ToByteSlice(100)
+		testutil.RequireEqualStatus(t, status.Error(codes.Internal, "Buffer has checksum d1bf93299de1b68e6d382c893bf1215f, while 8b1a9953c4611296a827abf8c47804d7 was expected"), err)
+	})
+
+	t.Run("ServerUnavailable", func(t *testing.T) {
+		lock.Lock()
+		unavailable = true
+		lock.Unlock()
+
+		_, err := blobAccess.FindMissing(ctx, helloDigest.ToSingletonSet())
+		testutil.RequireEqualStatus(t, status.Errorf(codes.Unavailable, "Failed to check for the existence of \"%s/cas/8b1a9953c4611296a827abf8c47804d7\": HTTP request failed with status \"503 Service Unavailable\"", server.URL), err)
+	})
+}
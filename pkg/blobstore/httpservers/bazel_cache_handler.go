@@ -0,0 +1,334 @@
+package httpservers
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	remoteexecution "github.com/bazelbuild/remote-apis/build/bazel/remote/execution/v2"
+	"github.com/buildbarn/bb-storage/pkg/blobstore"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/buffer"
+	"github.com/buildbarn/bb-storage/pkg/digest"
+	http_server "github.com/buildbarn/bb-storage/pkg/http/server"
+	"github.com/buildbarn/bb-storage/pkg/util"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// blobSizeIndexOutputPath is the path of the output file in action
+// results that is used to store the size of an object in the blob size
+// index.
+const blobSizeIndexOutputPath = "blob"
+
+type bazelCacheHandler struct {
+	actionCache               blobstore.BlobAccess
+	contentAddressableStorage blobstore.BlobAccess
+	blobSizeCache             *BlobSizeCache
+	blobSizeIndexInstanceName *digest.InstanceName
+	maximumMessageSizeBytes   int
+	readChunkSize             int
+}
+
+// NewBazelCacheHandler creates an HTTP handler that exposes the Action
+// Cache and Content Addressable Storage using the protocol that Bazel
+// uses when invoked with --remote_cache=http://... Requests are of the
+// form GET or PUT /${instance_name}/ac/${hash} and
+// /${instance_name}/cas/${hash}.
+//
+// As this protocol does not include the sizes of objects, sizes are
+// obtained from a BlobSizeCache. As the BlobSizeCache is only held in
+// memory, an instance name may be provided under which the sizes of
+// objects uploaded through this handler are also stored in the Action
+// Cache. This blob size index is consulted if the BlobSizeCache does
+// not contain the size of an object, making sizes available across
+// restarts and to other instances of this handler. As the Action
+// Cache may be written to by clients, sizes obtained from the blob size
+// index are only trusted if the Content Addressable Storage confirms
+// that an object with that size exists.
+//
+// Action Cache entries are stored under digests that have a size of
+// zero. They are thus not shared with clients that use REv2.
+func NewBazelCacheHandler(actionCache, contentAddressableStorage blobstore.BlobAccess, blobSizeCache *BlobSizeCache, blobSizeIndexInstanceName *digest.InstanceName, maximumMessageSizeBytes, readChunkSize int) http.Handler {
+	return &bazelCacheHandler{
+		actionCache:               actionCache,
+		contentAddressableStorage: contentAddressableStorage,
+		blobSizeCache:             blobSizeCache,
+		blobSizeIndexInstanceName: blobSizeIndexInstanceName,
+		maximumMessageSizeBytes:   maximumMessageSizeBytes,
+		readChunkSize:             readChunkSize,
+	}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	http.Error(w, err.Error(), http_server.StatusCodeFromGRPCCode(status.Code(err)))
+}
+
+// writeBlob writes the contents of a buffer into the body of an HTTP
+// response.
+func writeBlob(w http.ResponseWriter, b buffer.Buffer, sizeBytes int64, readChunkSize int) error {
+	// Read the first chunk prior to writing the response header,
+	// so that errors can still be reported with a proper status
+	// code.
+	r := b.ToChunkReader(0, readChunkSize)
+	defer r.Close()
+	chunk, err := r.Read()
+	if err != nil && err != io.EOF {
+		return err
+	}
+
+	w.Header().Set("Content-Length", strconv.FormatInt(sizeBytes, 10))
+	w.Header().Set("Content-Type", "application/octet-stream")
+	for err == nil {
+		if _, writeErr := w.Write(chunk); writeErr != nil {
+			return nil
+		}
+		chunk, err = r.Read()
+	}
+	if err != io.EOF {
+		// The response header has already been sent. Abort the
+		// response, so that the client does not mistake the
+		// truncated response for the full object.
+		panic(http.ErrAbortHandler)
+	}
+	return nil
+}
+
+// parsePath extracts the storage type ("ac" or "cas"), digest function
+// and hash from the path of a request.
+func parsePath(urlPath string) (string, digest.Function, string, error) {
+	components := strings.FieldsFunc(urlPath, func(r rune) bool { return r == '/' })
+	if len(components) < 2 {
+		return "", digest.Function{}, "", status.Error(codes.InvalidArgument, "Invalid resource naming scheme")
+	}
+	storageType, hash := components[len(components)-2], components[len(components)-1]
+	if storageType != "ac" && storageType != "cas" {
+		return "", digest.Function{}, "", status.Error(codes.InvalidArgument, "Invalid resource naming scheme")
+	}
+	instanceName, err := digest.NewInstanceNameFromComponents(components[:len(components)-2])
+	if err != nil {
+		return "", digest.Function{}, "", util.StatusWrapf(err, "Invalid instance name %#v", strings.Join(components[:len(components)-2], "/"))
+	}
+	digestFunction, err := instanceName.GetDigestFunction(remoteexecution.DigestFunction_UNKNOWN, len(hash))
+	if err != nil {
+		return "", digest.Function{}, "", err
+	}
+	return storageType, digestFunction, hash, nil
+}
+
+func (h *bazelCacheHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	storageType, digestFunction, hash, err := parsePath(r.URL.Path)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	ctx := r.Context()
+	switch r.Method {
+	case http.MethodGet:
+		if storageType == "ac" {
+			err = h.getActionResult(ctx, w, digestFunction, hash)
+		} else {
+			err = h.getBlob(ctx, w, digestFunction, hash)
+		}
+	case http.MethodPut:
+		if storageType == "ac" {
+			err = h.putActionResult(ctx, r, digestFunction, hash)
+		} else {
+			err = h.putBlob(ctx, r, digestFunction, hash)
+		}
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err != nil {
+		writeError(w, err)
+	}
+}
+
+// addActionResultDigests adds the digests of all objects referenced by
+// an ActionResult to the BlobSizeCache, so that clients may
+// subsequently download them. As clients download the contents of
+// output directories by first downloading their Tree objects, the
+// digests of files contained in these Tree objects are added as well.
+func (h *bazelCacheHandler) addActionResultDigests(ctx context.Context, digestFunction digest.Function, actionResult *remoteexecution.ActionResult) {
+	digests := digest.NewSetBuilder()
+	addDigest := func(blobDigest *remoteexecution.Digest) {
+		if blobDigest != nil {
+			if d, err := digestFunction.NewDigestFromProto(blobDigest); err == nil {
+				digests.Add(d)
+			}
+		}
+	}
+	for _, outputFile := range actionResult.OutputFiles {
+		addDigest(outputFile.Digest)
+	}
+	addDigest(actionResult.StdoutDigest)
+	addDigest(actionResult.StderrDigest)
+	for _, outputDirectory := range actionResult.OutputDirectories {
+		treeDigest, err := digestFunction.NewDigestFromProto(outputDirectory.TreeDigest)
+		if err != nil {
+			continue
+		}
+		digests.Add(treeDigest)
+
+		// Tree objects that cannot be loaded are skipped. The
+		// client will observe that they are absent when
+		// attempting to download them.
+		treeMessage, err := h.contentAddressableStorage.Get(ctx, treeDigest).ToProto(&remoteexecution.Tree{}, h.maximumMessageSizeBytes)
+		if err != nil {
+			continue
+		}
+		tree := treeMessage.(*remoteexecution.Tree)
+		for _, directory := range append([]*remoteexecution.Directory{tree.Root}, tree.Children...) {
+			for _, file := range directory.GetFiles() {
+				addDigest(file.Digest)
+			}
+		}
+	}
+	h.blobSizeCache.Add(digests.Build())
+}
+
+func (h *bazelCacheHandler) getActionResult(ctx context.Context, w http.ResponseWriter, digestFunction digest.Function, hash string) error {
+	actionDigest, err := digestFunction.NewDigest(hash, 0)
+	if err != nil {
+		return err
+	}
+	actionResultMessage, err := h.actionCache.Get(ctx, actionDigest).ToProto(&remoteexecution.ActionResult{}, h.maximumMessageSizeBytes)
+	if err != nil {
+		return err
+	}
+	actionResult := actionResultMessage.(*remoteexecution.ActionResult)
+	data, err := proto.Marshal(actionResult)
+	if err != nil {
+		return util.StatusWrapWithCode(err, codes.Internal, "Failed to marshal action result")
+	}
+	h.addActionResultDigests(ctx, digestFunction, actionResult)
+
+	w.Header().Set("Content-Length", strconv.FormatInt(int64(len(data)), 10))
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write(data)
+	return nil
+}
+
+// getBlobSizeIndexDigest returns the digest under which the size of an
+// object is stored in the blob size index.
+func (h *bazelCacheHandler) getBlobSizeIndexDigest(digestFunction digest.Function, hash string) (digest.Digest, error) {
+	indexDigestFunction, err := h.blobSizeIndexInstanceName.GetDigestFunction(digestFunction.GetEnumValue(), 0)
+	if err != nil {
+		return digest.BadDigest, err
+	}
+	return indexDigestFunction.NewDigest(hash, 0)
+}
+
+// getBlobDigest converts the hash of an object to a digest, by looking
+// up its size in the BlobSizeCache, followed by the blob size index.
+func (h *bazelCacheHandler) getBlobDigest(ctx context.Context, digestFunction digest.Function, hash string) (digest.Digest, error) {
+	if blobDigest, ok := h.blobSizeCache.Get(digestFunction, hash); ok {
+		return blobDigest, nil
+	}
+	if h.blobSizeIndexInstanceName == nil {
+		return digest.BadDigest, status.Errorf(codes.NotFound, "Size of object with hash %#v is unknown", hash)
+	}
+
+	indexDigest, err := h.getBlobSizeIndexDigest(digestFunction, hash)
+	if err != nil {
+		return digest.BadDigest, err
+	}
+	actionResultMessage, err := h.actionCache.Get(ctx, indexDigest).ToProto(&remoteexecution.ActionResult{}, h.maximumMessageSizeBytes)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return digest.BadDigest, status.Errorf(codes.NotFound, "Size of object with hash %#v is unknown", hash)
+		}
+		return digest.BadDigest, util.StatusWrap(err, "Failed to obtain object size from blob size index")
+	}
+	outputFiles := actionResultMessage.(*remoteexecution.ActionResult).OutputFiles
+	if len(outputFiles) != 1 || outputFiles[0].Path != blobSizeIndexOutputPath || outputFiles[0].Digest.GetHash() != hash {
+		return digest.BadDigest, status.Errorf(codes.Internal, "Blob size index entry for object with hash %#v is malformed", hash)
+	}
+	blobDigest, err := digestFunction.NewDigestFromProto(outputFiles[0].Digest)
+	if err != nil {
+		return digest.BadDigest, util.StatusWrapfWithCode(err, codes.Internal, "Blob size index entry for object with hash %#v contains an invalid digest", hash)
+	}
+
+	// Entries in the blob size index may be overwritten by clients
+	// that have write access to the Action Cache. Only use the size
+	// if the object is actually present in the Content Addressable
+	// Storage.
+	missing, err := h.contentAddressableStorage.FindMissing(ctx, blobDigest.ToSingletonSet())
+	if err != nil {
+		return digest.BadDigest, util.StatusWrap(err, "Failed to validate object size obtained from blob size index")
+	}
+	if !missing.Empty() {
+		return digest.BadDigest, status.Errorf(codes.NotFound, "Size of object with hash %#v is unknown", hash)
+	}
+	h.blobSizeCache.Add(blobDigest.ToSingletonSet())
+	return blobDigest, nil
+}
+
+func (h *bazelCacheHandler) getBlob(ctx context.Context, w http.ResponseWriter, digestFunction digest.Function, hash string) error {
+	blobDigest, err := h.getBlobDigest(ctx, digestFunction, hash)
+	if err != nil {
+		return err
+	}
+	return writeBlob(w, h.contentAddressableStorage.Get(ctx, blobDigest), blobDigest.GetSizeBytes(), h.readChunkSize)
+}
+
+func (h *bazelCacheHandler) putActionResult(ctx context.Context, r *http.Request, digestFunction digest.Function, hash string) error {
+	actionDigest, err := digestFunction.NewDigest(hash, 0)
+	if err != nil {
+		return err
+	}
+	return h.actionCache.Put(
+		ctx,
+		actionDigest,
+		buffer.NewProtoBufferFromReader(&remoteexecution.ActionResult{}, r.Body, buffer.UserProvided))
+}
+
+func (h *bazelCacheHandler) putBlob(ctx context.Context, r *http.Request, digestFunction digest.Function, hash string) error {
+	if r.ContentLength < 0 {
+		return status.Error(codes.InvalidArgument, "Uploads to the Content Addressable Storage require a Content-Length")
+	}
+	blobDigest, err := digestFunction.NewDigest(hash, r.ContentLength)
+	if err != nil {
+		return err
+	}
+	if err := h.contentAddressableStorage.Put(
+		ctx,
+		blobDigest,
+		buffer.NewCASBufferFromReader(blobDigest, r.Body, buffer.UserProvided),
+	); err != nil {
+		return err
+	}
+	h.blobSizeCache.Add(blobDigest.ToSingletonSet())
+
+	// Storing the size of the object in the blob size index is
+	// performed on a best-effort basis. The object has already been
+	// stored successfully, and remains accessible through this
+	// instance of the handler.
+	if h.blobSizeIndexInstanceName != nil {
+		indexDigest, err := h.getBlobSizeIndexDigest(digestFunction, hash)
+		if err != nil {
+			log.Printf("Failed to store size of object with hash %#v in blob size index: %s", hash, err)
+			return nil
+		}
+		if err := h.actionCache.Put(
+			ctx,
+			indexDigest,
+			buffer.NewProtoBufferFromProto(&remoteexecution.ActionResult{
+				OutputFiles: []*remoteexecution.OutputFile{{
+					Path:   blobSizeIndexOutputPath,
+					Digest: blobDigest.GetProto(),
+				}},
+			}, buffer.UserProvided),
+		); err != nil {
+			log.Printf("Failed to store size of object with hash %#v in blob size index: %s", hash, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,324 @@
+package httpservers_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	remoteexecution "github.com/bazelbuild/remote-apis/build/bazel/remote/execution/v2"
+	"github.com/buildbarn/bb-storage/internal/mock"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/buffer"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/httpservers"
+	"github.com/buildbarn/bb-storage/pkg/digest"
+	"github.com/buildbarn/bb-storage/pkg/eviction"
+	"github.com/buildbarn/bb-storage/pkg/testutil"
+	"github.com/buildbarn/bb-storage/pkg/util"
+	"github.com/stretchr/testify/require"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestBazelCacheHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	actionCache := mock.NewMockBlobAccess(ctrl)
+	contentAddressableStorage := mock.NewMockBlobAccess(ctrl)
+	handler := httpservers.NewBazelCacheHandler(
+		actionCache,
+		contentAddressableStorage,
+		httpservers.NewBlobSizeCache(10, eviction.NewLRUSet[string]()),
+		/* blobSizeIndexInstanceName = */ nil,
+		1<<16,
+		3)
+
+	serve := func(method, target string, body []byte) *httptest.ResponseRecorder {
+		var r *http.Request
+		if body == nil {
+			r = httptest.NewRequest(method, target, nil)
+		} else {
+			r = httptest.NewRequest(method, target, bytes.NewReader(body))
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("InvalidPath", func(t *testing.T) {
+		w := serve(http.MethodGet, "/hello/8b1a9953c4611296a827abf8c47804d7", nil)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.Equal(t, "rpc error: code = InvalidArgument desc = Invalid resource naming scheme\n", w.Body.String())
+	})
+
+	t.Run("InvalidInstanceName", func(t *testing.T) {
+		w := serve(http.MethodGet, "/blobs/ac/8b1a9953c4611296a827abf8c47804d7", nil)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("MethodNotAllowed", func(t *testing.T) {
+		w := serve(http.MethodDelete, "/ac/8b1a9953c4611296a827abf8c47804d7", nil)
+		require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		require.Equal(t, "GET, PUT", w.Header().Get("Allow"))
+	})
+
+	t.Run("GetBlobUnknownSize", func(t *testing.T) {
+		// Sizes of objects are only known after they have been
+		// uploaded, or referenced by an action result.
+		w := serve(http.MethodGet, "/hello/cas/8b1a9953c4611296a827abf8c47804d7", nil)
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("PutBlob", func(t *testing.T) {
+		blobDigest := digest.MustNewDigest("hello/world", remoteexecution.DigestFunction_MD5, "8b1a9953c4611296a827abf8c47804d7", 5)
+		contentAddressableStorage.EXPECT().Put(gomock.Any(), blobDigest, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
+				data, err := b.ToByteSlice(100)
+				require.NoError(t, err)
+				require.Equal(t, []byte("Hello"), data)
+				return nil
+			})
+
+		w := serve(http.MethodPut, "/hello/world/cas/8b1a9953c4611296a827abf8c47804d7", []byte("Hello"))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		// As the size of the object is now known, it can be
+		// downloaded.
+		contentAddressableStorage.EXPECT().Get(gomock.Any(), digest.MustNewDigest("", remoteexecution.DigestFunction_MD5, "8b1a9953c4611296a827abf8c47804d7", 5)).
+			Return(buffer.NewValidatedBufferFromByteSlice([]byte("Hello")))
+
+		w = serve(http.MethodGet, "/cas/8b1a9953c4611296a827abf8c47804d7", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "5", w.Header().Get("Content-Length"))
+		require.Equal(t, "Hello", w.Body.String())
+	})
+
+	t.Run("PutBlobFailure", func(t *testing.T) {
+		contentAddressableStorage.EXPECT().Put(gomock.Any(), digest.MustNewDigest("", remoteexecution.DigestFunction_MD5, "f5a7924e621e84c9280a9a27e1bcb7f6", 5), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
+				b.Discard()
+				return status.Error(codes.PermissionDenied, "Permission denied")
+			})
+
+		w := serve(http.MethodPut, "/cas/f5a7924e621e84c9280a9a27e1bcb7f6", []byte("World"))
+		require.Equal(t, http.StatusForbidden, w.Code)
+		require.Equal(t, "rpc error: code = PermissionDenied desc = Permission denied\n", w.Body.String())
+
+		// The size of the object should not have been recorded.
+		w = serve(http.MethodGet, "/cas/f5a7924e621e84c9280a9a27e1bcb7f6", nil)
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("GetActionResultNotFound", func(t *testing.T) {
+		actionCache.EXPECT().Get(gomock.Any(), digest.MustNewDigest("hello", remoteexecution.DigestFunction_MD5, "e8ad38b8e4d4d6d04e26a3e56bd1ec26", 0)).
+			Return(buffer.NewBufferFromError(status.Error(codes.NotFound, "Object not found")))
+
+		w := serve(http.MethodGet, "/hello/ac/e8ad38b8e4d4d6d04e26a3e56bd1ec26", nil)
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("GetActionResultSuccess", func(t *testing.T) {
+		actionResult := &remoteexecution.ActionResult{
+			OutputFiles: []*remoteexecution.OutputFile{
+				{
+					Path: "file",
+					Digest: &remoteexecution.Digest{
+						Hash:      "68e109f0f40ca72a15e05cc22786f8e6",
+						SizeBytes: 10,
+					},
+				},
+			},
+			OutputDirectories: []*remoteexecution.OutputDirectory{
+				{
+					Path: "directory",
+					TreeDigest: &remoteexecution.Digest{
+						Hash:      "2d6b2a8b6d4fe1f62c7b0a5e7a8c9d2e",
+						SizeBytes: 42,
+					},
+				},
+			},
+		}
+		actionCache.EXPECT().Get(gomock.Any(), digest.MustNewDigest("", remoteexecution.DigestFunction_MD5, "e8ad38b8e4d4d6d04e26a3e56bd1ec26", 0)).
+			Return(buffer.NewProtoBufferFromProto(actionResult, buffer.UserProvided))
+		contentAddressableStorage.EXPECT().Get(gomock.Any(), digest.MustNewDigest("", remoteexecution.DigestFunction_MD5, "2d6b2a8b6d4fe1f62c7b0a5e7a8c9d2e", 42)).
+			Return(buffer.NewProtoBufferFromProto(&remoteexecution.Tree{
+				Root: &remoteexecution.Directory{
+					Files: []*remoteexecution.FileNode{
+						{
+							Name: "a",
+							Digest: &remoteexecution.Digest{
+								Hash:      "91db2d4279a42766759cfa87e9d633b4",
+								SizeBytes: 10,
+							},
+						},
+					},
+				},
+			}, buffer.UserProvided))
+
+		w := serve(http.MethodGet, "/ac/e8ad38b8e4d4d6d04e26a3e56bd1ec26", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var receivedActionResult remoteexecution.ActionResult
+		require.NoError(t, proto.Unmarshal(w.Body.Bytes(), &receivedActionResult))
+		testutil.RequireEqualProto(t, actionResult, &receivedActionResult)
+
+		// Both the output file and files contained in the output
+		// directory may now be downloaded. Errors that occur
+		// prior to writing the response should be reported.
+		contentAddressableStorage.EXPECT().Get(gomock.Any(), digest.MustNewDigest("", remoteexecution.DigestFunction_MD5, "68e109f0f40ca72a15e05cc22786f8e6", 10)).
+			Return(buffer.NewValidatedBufferFromByteSlice([]byte("HelloWorld")))
+
+		w = serve(http.MethodGet, "/cas/68e109f0f40ca72a15e05cc22786f8e6", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "HelloWorld", w.Body.String())
+
+		contentAddressableStorage.EXPECT().Get(gomock.Any(), digest.MustNewDigest("", remoteexecution.DigestFunction_MD5, "91db2d4279a42766759cfa87e9d633b4", 10)).
+			Return(buffer.NewBufferFromError(status.Error(codes.NotFound, "Object not found")))
+
+		w = serve(http.MethodGet, "/cas/91db2d4279a42766759cfa87e9d633b4", nil)
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("PutActionResult", func(t *testing.T) {
+		actionResult := &remoteexecution.ActionResult{
+			ExitCode: 1,
+		}
+		actionCache.EXPECT().Put(gomock.Any(), digest.MustNewDigest("hello", remoteexecution.DigestFunction_MD5, "e8ad38b8e4d4d6d04e26a3e56bd1ec26", 0), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
+				m, err := b.ToProto(&remoteexecution.ActionResult{}, 100)
+				require.NoError(t, err)
+				testutil.RequireEqualProto(t, actionResult, m)
+				return nil
+			})
+
+		data, err := proto.Marshal(actionResult)
+		require.NoError(t, err)
+		w := serve(http.MethodPut, "/hello/ac/e8ad38b8e4d4d6d04e26a3e56bd1ec26", data)
+		require.Equal(t, http.StatusOK, w.Code)
+	})
+}
+
+func TestBazelCacheHandlerBlobSizeIndex(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	actionCache := mock.NewMockBlobAccess(ctrl)
+	contentAddressableStorage := mock.NewMockBlobAccess(ctrl)
+	blobSizeIndexInstanceName := util.Must(digest.NewInstanceName("blob-sizes"))
+	newHandler := func() http.Handler {
+		return httpservers.NewBazelCacheHandler(
+			actionCache,
+			contentAddressableStorage,
+			httpservers.NewBlobSizeCache(10, eviction.NewLRUSet[string]()),
+			&blobSizeIndexInstanceName,
+			1<<16,
+			3)
+	}
+	serve := func(handler http.Handler, method, target string, body []byte) *httptest.ResponseRecorder {
+		var r *http.Request
+		if body == nil {
+			r = httptest.NewRequest(method, target, nil)
+		} else {
+			r = httptest.NewRequest(method, target, bytes.NewReader(body))
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	indexDigest := digest.MustNewDigest("blob-sizes", remoteexecution.DigestFunction_MD5, "8b1a9953c4611296a827abf8c47804d7", 0)
+	indexEntry := &remoteexecution.ActionResult{
+		OutputFiles: []*remoteexecution.OutputFile{{
+			Path: "blob",
+			Digest: &remoteexecution.Digest{
+				Hash:      "8b1a9953c4611296a827abf8c47804d7",
+				SizeBytes: 5,
+			},
+		}},
+	}
+
+	t.Run("GetBlobNotInIndex", func(t *testing.T) {
+		actionCache.EXPECT().Get(gomock.Any(), indexDigest).
+			Return(buffer.NewBufferFromError(status.Error(codes.NotFound, "Object not found")))
+
+		w := serve(newHandler(), http.MethodGet, "/hello/cas/8b1a9953c4611296a827abf8c47804d7", nil)
+		require.Equal(t, http.StatusNotFound, w.Code)
+		require.Equal(t, "rpc error: code = NotFound desc = Size of object with hash \"8b1a9953c4611296a827abf8c47804d7\" is unknown\n", w.Body.String())
+	})
+
+	t.Run("PutBlob", func(t *testing.T) {
+		// Uploading an object should cause its size to be
+		// stored in the blob size index.
+		contentAddressableStorage.EXPECT().Put(gomock.Any(), digest.MustNewDigest("hello", remoteexecution.DigestFunction_MD5, "8b1a9953c4611296a827abf8c47804d7", 5), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
+				b.Discard()
+				return nil
+			})
+		actionCache.EXPECT().Put(gomock.Any(), indexDigest, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
+				m, err := b.ToProto(&remoteexecution.ActionResult{}, 100)
+				require.NoError(t, err)
+				testutil.RequireEqualProto(t, indexEntry, m)
+				return nil
+			})
+
+		w := serve(newHandler(), http.MethodPut, "/hello/cas/8b1a9953c4611296a827abf8c47804d7", []byte("Hello"))
+		require.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("GetBlobFromIndex", func(t *testing.T) {
+		// Instances of the handler that did not observe the
+		// upload should obtain the size from the index. The
+		// size should subsequently be cached in memory.
+		handler := newHandler()
+		actionCache.EXPECT().Get(gomock.Any(), indexDigest).
+			Return(buffer.NewProtoBufferFromProto(indexEntry, buffer.UserProvided))
+		blobDigest := digest.MustNewDigest("hello", remoteexecution.DigestFunction_MD5, "8b1a9953c4611296a827abf8c47804d7", 5)
+		contentAddressableStorage.EXPECT().FindMissing(gomock.Any(), blobDigest.ToSingletonSet()).
+			Return(digest.EmptySet, nil)
+		contentAddressableStorage.EXPECT().Get(gomock.Any(), blobDigest).
+			Return(buffer.NewValidatedBufferFromByteSlice([]byte("Hello"))).
+			Times(2)
+
+		for i := 0; i < 2; i++ {
+			w := serve(handler, http.MethodGet, "/hello/cas/8b1a9953c4611296a827abf8c47804d7", nil)
+			require.Equal(t, http.StatusOK, w.Code)
+			require.Equal(t, "Hello", w.Body.String())
+		}
+	})
+
+	t.Run("GetBlobFromIndexNotInStorage", func(t *testing.T) {
+		// Entries in the index may have been written by
+		// clients. They should not be trusted if the object is
+		// not present in the Content Addressable Storage.
+		blobDigest := digest.MustNewDigest("hello", remoteexecution.DigestFunction_MD5, "8b1a9953c4611296a827abf8c47804d7", 5)
+		actionCache.EXPECT().Get(gomock.Any(), indexDigest).
+			Return(buffer.NewProtoBufferFromProto(indexEntry, buffer.UserProvided))
+		contentAddressableStorage.EXPECT().FindMissing(gomock.Any(), blobDigest.ToSingletonSet()).
+			Return(blobDigest.ToSingletonSet(), nil)
+
+		w := serve(newHandler(), http.MethodGet, "/hello/cas/8b1a9953c4611296a827abf8c47804d7", nil)
+		require.Equal(t, http.StatusNotFound, w.Code)
+		require.Equal(t, "rpc error: code = NotFound desc = Size of object with hash \"8b1a9953c4611296a827abf8c47804d7\" is unknown\n", w.Body.String())
+	})
+
+	t.Run("PutBlobIndexFailure", func(t *testing.T) {
+		// Failing to store the size of the object in the index
+		// should not cause the upload to fail.
+		contentAddressableStorage.EXPECT().Put(gomock.Any(), digest.MustNewDigest("hello", remoteexecution.DigestFunction_MD5, "8b1a9953c4611296a827abf8c47804d7", 5), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
+				b.Discard()
+				return nil
+			})
+		actionCache.EXPECT().Put(gomock.Any(), indexDigest, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
+				b.Discard()
+				return status.Error(codes.Unavailable, "Server offline")
+			})
+
+		w := serve(newHandler(), http.MethodPut, "/hello/cas/8b1a9953c4611296a827abf8c47804d7", []byte("Hello"))
+		require.Equal(t, http.StatusOK, w.Code)
+	})
+}
@@ -0,0 +1,89 @@
+package httpservers
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/buildbarn/bb-storage/pkg/digest"
+	"github.com/buildbarn/bb-storage/pkg/eviction"
+)
+
+// getBlobSizeCacheKey returns the key under which the size of an
+// object is stored in BlobSizeCache. The key has the same format as
+// the leading part of Digest.GetKey().
+func getBlobSizeCacheKey(digestFunction digest.Function, hash string) string {
+	return strconv.FormatInt(int64(digestFunction.GetEnumValue()), 10) + "-" + hash
+}
+
+// BlobSizeCache keeps track of the sizes of objects, keyed by digest
+// function and hash. It is used by the HTTP caching protocol handler to
+// convert hashes provided by clients to digests, as Bazel's HTTP
+// caching protocol does not include the sizes of objects in requests.
+//
+// As hashes uniquely identify the contents of objects, instance names
+// are not taken into account.
+//
+// It is safe to access BlobSizeCache concurrently.
+type BlobSizeCache struct {
+	cacheSize int
+
+	lock        sync.Mutex
+	sizes       map[string]int64
+	evictionSet eviction.Set[string]
+}
+
+// NewBlobSizeCache creates a new BlobSizeCache that is empty.
+func NewBlobSizeCache(cacheSize int, evictionSet eviction.Set[string]) *BlobSizeCache {
+	return &BlobSizeCache{
+		cacheSize: cacheSize,
+
+		sizes:       map[string]int64{},
+		evictionSet: evictionSet,
+	}
+}
+
+// Add the sizes of a set of digests to the cache.
+func (bsc *BlobSizeCache) Add(digests digest.Set) {
+	bsc.lock.Lock()
+	defer bsc.lock.Unlock()
+
+	for _, d := range digests.Items() {
+		key := getBlobSizeCacheKey(d.GetDigestFunction(), d.GetHashString())
+		if _, ok := bsc.sizes[key]; ok {
+			bsc.evictionSet.Touch(key)
+		} else {
+			// Free up space to insert the size.
+			if len(bsc.sizes) >= bsc.cacheSize {
+				if bsc.cacheSize <= 0 {
+					return
+				}
+				delete(bsc.sizes, bsc.evictionSet.Peek())
+				bsc.evictionSet.Remove()
+			}
+			bsc.evictionSet.Insert(key)
+		}
+		bsc.sizes[key] = d.GetSizeBytes()
+	}
+}
+
+// Get a digest for a given hash, using the size of the object that was
+// previously stored in the cache.
+func (bsc *BlobSizeCache) Get(digestFunction digest.Function, hash string) (digest.Digest, bool) {
+	key := getBlobSizeCacheKey(digestFunction, hash)
+
+	bsc.lock.Lock()
+	sizeBytes, ok := bsc.sizes[key]
+	if ok {
+		bsc.evictionSet.Touch(key)
+	}
+	bsc.lock.Unlock()
+
+	if !ok {
+		return digest.BadDigest, false
+	}
+	d, err := digestFunction.NewDigest(hash, sizeBytes)
+	if err != nil {
+		return digest.BadDigest, false
+	}
+	return d, true
+}
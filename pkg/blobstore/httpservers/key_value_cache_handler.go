@@ -0,0 +1,148 @@
+package httpservers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+
+	remoteexecution "github.com/bazelbuild/remote-apis/build/bazel/remote/execution/v2"
+	"github.com/buildbarn/bb-storage/pkg/blobstore"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/buffer"
+	"github.com/buildbarn/bb-storage/pkg/digest"
+	"github.com/buildbarn/bb-storage/pkg/util"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// keyValueCacheOutputPath is the path of the output file in action
+// results that is used to refer to the value associated with a key.
+const keyValueCacheOutputPath = "value"
+
+type keyValueCacheHandler struct {
+	actionCache               blobstore.BlobAccess
+	contentAddressableStorage blobstore.BlobAccess
+	digestFunction            digest.Function
+	maximumValueSizeBytes     int64
+	maximumMessageSizeBytes   int
+	readChunkSize             int
+}
+
+// NewKeyValueCacheHandler creates an HTTP handler that implements a
+// generic key-value store, where the path of the request is used as
+// the key. Values can be stored and retrieved using PUT and GET
+// requests, respectively. This is sufficient to act as a backend for
+// tools such as ccache (HTTP storage), sccache (WebDAV) and Gradle's
+// HTTP build cache.
+//
+// Values are stored in the Content Addressable Storage. The mapping
+// from keys to values is stored in the Action Cache, using action
+// results that contain a single output file. The digest under which
+// such an action result is stored is obtained by hashing the key. As
+// keys start with a slash, which cannot occur at the start of a
+// serialized Action message, these digests cannot collide with those
+// of actual actions.
+func NewKeyValueCacheHandler(actionCache, contentAddressableStorage blobstore.BlobAccess, digestFunction digest.Function, maximumValueSizeBytes int64, maximumMessageSizeBytes, readChunkSize int) http.Handler {
+	return &keyValueCacheHandler{
+		actionCache:               actionCache,
+		contentAddressableStorage: contentAddressableStorage,
+		digestFunction:            digestFunction,
+		maximumValueSizeBytes:     maximumValueSizeBytes,
+		maximumMessageSizeBytes:   maximumMessageSizeBytes,
+		readChunkSize:             readChunkSize,
+	}
+}
+
+func (h *keyValueCacheHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Path
+	generator := h.digestFunction.NewGenerator(int64(len(key)))
+	generator.Write([]byte(key))
+	keyDigest := generator.Sum()
+
+	ctx := r.Context()
+	var err error
+	switch r.Method {
+	case http.MethodGet:
+		err = h.get(ctx, w, keyDigest, true)
+	case http.MethodHead:
+		err = h.get(ctx, w, keyDigest, false)
+	case http.MethodPut:
+		err = h.put(ctx, r, keyDigest)
+	default:
+		w.Header().Set("Allow", "GET, HEAD, PUT")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err != nil {
+		writeError(w, err)
+	}
+}
+
+func (h *keyValueCacheHandler) get(ctx context.Context, w http.ResponseWriter, keyDigest digest.Digest, includeBody bool) error {
+	actionResultMessage, err := h.actionCache.Get(ctx, keyDigest).ToProto(&remoteexecution.ActionResult{}, h.maximumMessageSizeBytes)
+	if err != nil {
+		return err
+	}
+	outputFiles := actionResultMessage.(*remoteexecution.ActionResult).OutputFiles
+	if len(outputFiles) != 1 || outputFiles[0].Path != keyValueCacheOutputPath {
+		return status.Errorf(codes.Internal, "Action result for key with digest %#v does not refer to a value", keyDigest.String())
+	}
+	valueDigest, err := h.digestFunction.NewDigestFromProto(outputFiles[0].Digest)
+	if err != nil {
+		return util.StatusWrapfWithCode(err, codes.Internal, "Action result for key with digest %#v contains an invalid value digest", keyDigest.String())
+	}
+
+	if !includeBody {
+		// The Action Cache entry may refer to a value that has
+		// already been evicted from the Content Addressable
+		// Storage. Check for its existence, so that a successful
+		// HEAD request implies that a GET request succeeds.
+		missing, err := h.contentAddressableStorage.FindMissing(ctx, valueDigest.ToSingletonSet())
+		if err != nil {
+			return util.StatusWrap(err, "Failed to check existence of value")
+		}
+		if !missing.Empty() {
+			return status.Errorf(codes.NotFound, "Value with digest %#v is not present in the Content Addressable Storage", valueDigest.String())
+		}
+		w.Header().Set("Content-Length", strconv.FormatInt(valueDigest.GetSizeBytes(), 10))
+		w.Header().Set("Content-Type", "application/octet-stream")
+		return nil
+	}
+	return writeBlob(w, h.contentAddressableStorage.Get(ctx, valueDigest), valueDigest.GetSizeBytes(), h.readChunkSize)
+}
+
+func (h *keyValueCacheHandler) put(ctx context.Context, r *http.Request, keyDigest digest.Digest) error {
+	if r.ContentLength > h.maximumValueSizeBytes {
+		return status.Errorf(codes.InvalidArgument, "Value is %d bytes in size, while a maximum of %d bytes is permitted", r.ContentLength, h.maximumValueSizeBytes)
+	}
+	value, err := io.ReadAll(io.LimitReader(r.Body, h.maximumValueSizeBytes+1))
+	if err != nil {
+		return util.StatusWrapWithCode(err, codes.Internal, "Failed to read value")
+	}
+	if int64(len(value)) > h.maximumValueSizeBytes {
+		return status.Errorf(codes.InvalidArgument, "Value exceeds the maximum permitted size of %d bytes", h.maximumValueSizeBytes)
+	}
+
+	// Store the value in the Content Addressable Storage, followed
+	// by creating the mapping from the key to the value.
+	generator := h.digestFunction.NewGenerator(int64(len(value)))
+	generator.Write(value)
+	valueDigest := generator.Sum()
+	if err := h.contentAddressableStorage.Put(ctx, valueDigest, buffer.NewValidatedBufferFromByteSlice(value)); err != nil {
+		return util.StatusWrap(err, "Failed to store value")
+	}
+	if err := h.actionCache.Put(
+		ctx,
+		keyDigest,
+		buffer.NewProtoBufferFromProto(&remoteexecution.ActionResult{
+			OutputFiles: []*remoteexecution.OutputFile{{
+				Path:   keyValueCacheOutputPath,
+				Digest: valueDigest.GetProto(),
+			}},
+		}, buffer.UserProvided),
+	); err != nil {
+		return util.StatusWrap(err, "Failed to store key")
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package httpservers_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	remoteexecution "github.com/bazelbuild/remote-apis/build/bazel/remote/execution/v2"
+	"github.com/buildbarn/bb-storage/internal/mock"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/buffer"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/httpservers"
+	"github.com/buildbarn/bb-storage/pkg/digest"
+	"github.com/buildbarn/bb-storage/pkg/testutil"
+	"github.com/stretchr/testify/require"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestKeyValueCacheHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	actionCache := mock.NewMockBlobAccess(ctrl)
+	contentAddressableStorage := mock.NewMockBlobAccess(ctrl)
+	handler := httpservers.NewKeyValueCacheHandler(
+		actionCache,
+		contentAddressableStorage,
+		digest.MustNewFunction("hello", remoteexecution.DigestFunction_MD5),
+		10,
+		1<<16,
+		3)
+
+	keyDigest := digest.MustNewDigest("hello", remoteexecution.DigestFunction_MD5, "af002198262079a2b4743c0a527ce2ad", 15)
+	valueDigest := digest.MustNewDigest("hello", remoteexecution.DigestFunction_MD5, "8b1a9953c4611296a827abf8c47804d7", 5)
+	keyActionResult := &remoteexecution.ActionResult{
+		OutputFiles: []*remoteexecution.OutputFile{{
+			Path: "value",
+			Digest: &remoteexecution.Digest{
+				Hash:      "8b1a9953c4611296a827abf8c47804d7",
+				SizeBytes: 5,
+			},
+		}},
+	}
+
+	t.Run("GetNotFound", func(t *testing.T) {
+		actionCache.EXPECT().Get(gomock.Any(), digest.MustNewDigest("hello", remoteexecution.DigestFunction_MD5, "0426414ceb7175abbbc6b99910bf4556", 14)).
+			Return(buffer.NewBufferFromError(status.Error(codes.NotFound, "Object not found")))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/cache/missing", nil))
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("GetSuccess", func(t *testing.T) {
+		actionCache.EXPECT().Get(gomock.Any(), keyDigest).
+			Return(buffer.NewProtoBufferFromProto(keyActionResult, buffer.UserProvided))
+		contentAddressableStorage.EXPECT().Get(gomock.Any(), valueDigest).
+			Return(buffer.NewValidatedBufferFromByteSlice([]byte("Hello")))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ccache/ab/cdef", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "5", w.Header().Get("Content-Length"))
+		require.Equal(t, "Hello", w.Body.String())
+	})
+
+	t.Run("HeadValueMissing", func(t *testing.T) {
+		// If the value has been evicted from the Content
+		// Addressable Storage, HEAD should report the key as
+		// being absent, as a subsequent GET would fail.
+		actionCache.EXPECT().Get(gomock.Any(), keyDigest).
+			Return(buffer.NewProtoBufferFromProto(keyActionResult, buffer.UserProvided))
+		contentAddressableStorage.EXPECT().FindMissing(gomock.Any(), valueDigest.ToSingletonSet()).
+			Return(valueDigest.ToSingletonSet(), nil)
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/ccache/ab/cdef", nil))
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("HeadSuccess", func(t *testing.T) {
+		actionCache.EXPECT().Get(gomock.Any(), keyDigest).
+			Return(buffer.NewProtoBufferFromProto(keyActionResult, buffer.UserProvided))
+		contentAddressableStorage.EXPECT().FindMissing(gomock.Any(), valueDigest.ToSingletonSet()).
+			Return(digest.EmptySet, nil)
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/ccache/ab/cdef", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "5", w.Header().Get("Content-Length"))
+		require.Empty(t, w.Body.String())
+	})
+
+	t.Run("GetMalformedActionResult", func(t *testing.T) {
+		actionCache.EXPECT().Get(gomock.Any(), keyDigest).
+			Return(buffer.NewProtoBufferFromProto(&remoteexecution.ActionResult{}, buffer.UserProvided))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ccache/ab/cdef", nil))
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+
+	t.Run("PutTooLarge", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/ccache/ab/cdef", bytes.NewReader([]byte("Hello World!"))))
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("PutCASFailure", func(t *testing.T) {
+		contentAddressableStorage.EXPECT().Put(gomock.Any(), valueDigest, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
+				b.Discard()
+				return status.Error(codes.Unavailable, "Server offline")
+			})
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/ccache/ab/cdef", bytes.NewReader([]byte("Hello"))))
+		require.Equal(t, http.StatusServiceUnavailable, w.Code)
+		require.Equal(t, "rpc error: code = Unavailable desc = Failed to store value: Server offline\n", w.Body.String())
+	})
+
+	t.Run("PutSuccess", func(t *testing.T) {
+		contentAddressableStorage.EXPECT().Put(gomock.Any(), valueDigest, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
+				data, err := b.ToByteSlice(100)
+				require.NoError(t, err)
+				require.Equal(t, []byte("Hello"), data)
+				return nil
+			})
+		actionCache.EXPECT().Put(gomock.Any(), keyDigest, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
+				m, err := b.ToProto(&remoteexecution.ActionResult{}, 100)
+				require.NoError(t, err)
+				testutil.RequireEqualProto(t, keyActionResult, m)
+				return nil
+			})
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/ccache/ab/cdef", bytes.NewReader([]byte("Hello"))))
+		require.Equal(t, http.StatusOK, w.Code)
+	})
+}
@@ -0,0 +1,310 @@
+package latencyaware
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	remoteexecution "github.com/bazelbuild/remote-apis/build/bazel/remote/execution/v2"
+	"github.com/buildbarn/bb-storage/pkg/blobstore"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/buffer"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/slicing"
+	"github.com/buildbarn/bb-storage/pkg/clock"
+	"github.com/buildbarn/bb-storage/pkg/digest"
+	"github.com/buildbarn/bb-storage/pkg/random"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// backendStatistics contains the exponentially weighted moving
+// averages of the latency and error rate of a single backend.
+type backendStatistics struct {
+	hasLatency     bool
+	latencySeconds float64
+	hasErrorRate   bool
+	errorRate      float64
+}
+
+type latencyAwareBlobAccess struct {
+	backends              []blobstore.BlobAccess
+	readBufferFactory     blobstore.ReadBufferFactory
+	clock                 clock.Clock
+	randomNumberGenerator random.ThreadSafeGenerator
+	smoothingFactor       float64
+	maximumErrorRate      float64
+	probeProbability      float64
+
+	lock sync.Mutex
+	// Estimates are tracked separately for reads and for calls to
+	// FindMissing(), as their latencies tend to differ
+	// significantly. Each slice contains one entry per backend.
+	getStatistics         []backendStatistics
+	findMissingStatistics []backendStatistics
+}
+
+// NewLatencyAwareBlobAccess creates a BlobAccess that forwards requests
+// to one of multiple replicas containing the same data, such as
+// replicas located in different zones. For every replica it tracks an
+// exponentially weighted moving average (EWMA) of the latency and the
+// error rate of requests. Requests are sent to the replica that
+// currently has the lowest latency, out of the replicas whose error
+// rate does not exceed a given maximum.
+//
+// Estimates are tracked separately for Get() and FindMissing(). The
+// latency of Get() and GetFromComposite() is measured until the first
+// byte of data is received, so that it is not affected by the size of
+// the object or the rate at which the caller consumes it.
+//
+// To ensure that the estimates of the other replicas remain fresh, a
+// fraction of the requests is sent to a randomly chosen other replica.
+// This also allows replicas that were previously considered unhealthy
+// to be taken into use again.
+//
+// As the replicas are assumed to contain the same data, writes are sent
+// to a single replica as well, selected using the estimates of Get().
+// Keeping the replicas consistent is the responsibility of the replicas
+// themselves. As replicas may lag behind, calls to Get() and
+// GetFromComposite() fall back to the other replicas if the object is
+// absent, similar to ReadFallbackBlobAccess and MirroredBlobAccess.
+func NewLatencyAwareBlobAccess(backends []blobstore.BlobAccess, readBufferFactory blobstore.ReadBufferFactory, clock clock.Clock, randomNumberGenerator random.ThreadSafeGenerator, smoothingFactor, maximumErrorRate, probeProbability float64) blobstore.BlobAccess {
+	return &latencyAwareBlobAccess{
+		backends:              backends,
+		readBufferFactory:     readBufferFactory,
+		clock:                 clock,
+		randomNumberGenerator: randomNumberGenerator,
+		smoothingFactor:       smoothingFactor,
+		maximumErrorRate:      maximumErrorRate,
+		probeProbability:      probeProbability,
+		getStatistics:         make([]backendStatistics, len(backends)),
+		findMissingStatistics: make([]backendStatistics, len(backends)),
+	}
+}
+
+// selectBackend returns the index of the backend to which a request
+// should be sent, based on the estimates of a given operation.
+func (ba *latencyAwareBlobAccess) selectBackend(statistics []backendStatistics) int {
+	ba.lock.Lock()
+	defer ba.lock.Unlock()
+
+	best := -1
+	for i, s := range statistics {
+		if !s.hasErrorRate {
+			// No requests have been sent to this backend
+			// yet. Send requests to it until an estimate
+			// is available.
+			return i
+		}
+		if best < 0 {
+			best = i
+			continue
+		}
+		sBest := &statistics[best]
+		if healthy, bestHealthy := s.errorRate <= ba.maximumErrorRate, sBest.errorRate <= ba.maximumErrorRate; healthy != bestHealthy {
+			// Prefer healthy backends.
+			if healthy {
+				best = i
+			}
+		} else if healthy {
+			// Prefer the fastest healthy backend.
+			if s.hasLatency && (!sBest.hasLatency || s.latencySeconds < sBest.latencySeconds) {
+				best = i
+			}
+		} else if s.errorRate < sBest.errorRate {
+			// None of the backends are healthy. Prefer the
+			// one that fails the least.
+			best = i
+		}
+	}
+
+	// Occasionally probe one of the other backends.
+	if len(ba.backends) > 1 && ba.randomNumberGenerator.Float64() < ba.probeProbability {
+		probe := ba.randomNumberGenerator.IntN(len(ba.backends) - 1)
+		if probe >= best {
+			probe++
+		}
+		return probe
+	}
+	return best
+}
+
+func (ba *latencyAwareBlobAccess) updateEWMA(hasValue *bool, value *float64, sample float64) {
+	if *hasValue {
+		*value += ba.smoothingFactor * (sample - *value)
+	} else {
+		*hasValue = true
+		*value = sample
+	}
+}
+
+// recordLatency updates the latency estimate of a backend after it
+// has responded to a request successfully.
+func (ba *latencyAwareBlobAccess) recordLatency(s *backendStatistics, timeStart time.Time) {
+	latency := ba.clock.Now().Sub(timeStart)
+
+	ba.lock.Lock()
+	defer ba.lock.Unlock()
+
+	ba.updateEWMA(&s.hasLatency, &s.latencySeconds, latency.Seconds())
+}
+
+// recordCompletion updates the error rate estimate of a backend after
+// a request sent to it completes. The return value indicates whether
+// the request completed successfully.
+func (ba *latencyAwareBlobAccess) recordCompletion(s *backendStatistics, err error) bool {
+	errorSample := 0.0
+	switch status.Code(err) {
+	case codes.OK, codes.NotFound:
+		// Objects being absent is not an indication of the
+		// backend being unhealthy.
+	case codes.Canceled:
+		// Requests cancelled by the client say nothing about
+		// the backend.
+		return false
+	default:
+		errorSample = 1.0
+	}
+
+	ba.lock.Lock()
+	defer ba.lock.Unlock()
+
+	ba.updateEWMA(&s.hasErrorRate, &s.errorRate, errorSample)
+	return errorSample == 0
+}
+
+// getWithLatencyRecording forwards a read request to the backend that
+// is currently the fastest. The buffer returned by the backend is
+// wrapped, so that the time to the first byte can be measured. If the
+// object is absent, the request is forwarded to the other backends.
+func (ba *latencyAwareBlobAccess) getWithLatencyRecording(blobDigest digest.Digest, get func(backend blobstore.BlobAccess) buffer.Buffer) buffer.Buffer {
+	index := ba.selectBackend(ba.getStatistics)
+	fallbacks := make([]int, 0, len(ba.backends)-1)
+	for i := range ba.backends {
+		if i != index {
+			fallbacks = append(fallbacks, i)
+		}
+	}
+	return ba.readBufferFactory.NewBufferFromReader(
+		blobDigest,
+		&notFoundFallbackReader{
+			ReadCloser: ba.newLatencyRecordingReader(index, get),
+			blobAccess: ba,
+			get:        get,
+			fallbacks:  fallbacks,
+		},
+		buffer.Irreparable(blobDigest))
+}
+
+// newLatencyRecordingReader sends a read request to a single backend,
+// and returns a reader that updates the estimates of the backend as
+// data is consumed.
+func (ba *latencyAwareBlobAccess) newLatencyRecordingReader(index int, get func(backend blobstore.BlobAccess) buffer.Buffer) io.ReadCloser {
+	timeStart := ba.clock.Now()
+	return &latencyRecordingReader{
+		ReadCloser: get(ba.backends[index]).ToReader(),
+		blobAccess: ba,
+		statistics: &ba.getStatistics[index],
+		timeStart:  timeStart,
+	}
+}
+
+func (ba *latencyAwareBlobAccess) Get(ctx context.Context, digest digest.Digest) buffer.Buffer {
+	return ba.getWithLatencyRecording(digest, func(backend blobstore.BlobAccess) buffer.Buffer {
+		return backend.Get(ctx, digest)
+	})
+}
+
+func (ba *latencyAwareBlobAccess) GetFromComposite(ctx context.Context, parentDigest, childDigest digest.Digest, slicer slicing.BlobSlicer) buffer.Buffer {
+	return ba.getWithLatencyRecording(childDigest, func(backend blobstore.BlobAccess) buffer.Buffer {
+		return backend.GetFromComposite(ctx, parentDigest, childDigest, slicer)
+	})
+}
+
+func (ba *latencyAwareBlobAccess) Put(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
+	return ba.backends[ba.selectBackend(ba.getStatistics)].Put(ctx, digest, b)
+}
+
+func (ba *latencyAwareBlobAccess) FindMissing(ctx context.Context, digests digest.Set) (digest.Set, error) {
+	index := ba.selectBackend(ba.findMissingStatistics)
+	s := &ba.findMissingStatistics[index]
+	timeStart := ba.clock.Now()
+	missing, err := ba.backends[index].FindMissing(ctx, digests)
+	if ba.recordCompletion(s, err) {
+		// Failing requests tend to have unrepresentative
+		// latencies, as they may fail immediately or time out.
+		ba.recordLatency(s, timeStart)
+	}
+	return missing, err
+}
+
+func (ba *latencyAwareBlobAccess) GetCapabilities(ctx context.Context, instanceName digest.InstanceName) (*remoteexecution.ServerCapabilities, error) {
+	return ba.backends[ba.selectBackend(ba.getStatistics)].GetCapabilities(ctx, instanceName)
+}
+
+// latencyRecordingReader is used by LatencyAwareBlobAccess to update
+// the estimates of a backend while a buffer returned by Get() or
+// GetFromComposite() is consumed. The latency is recorded as soon as
+// the first byte of data is received, while the error rate is updated
+// once reading completes.
+type latencyRecordingReader struct {
+	io.ReadCloser
+	blobAccess *latencyAwareBlobAccess
+	statistics *backendStatistics
+	timeStart  time.Time
+
+	receivedData bool
+	completed    bool
+}
+
+func (r *latencyRecordingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 && !r.receivedData {
+		r.receivedData = true
+		r.blobAccess.recordLatency(r.statistics, r.timeStart)
+	}
+	if err != nil && !r.completed {
+		r.completed = true
+		completionErr := err
+		if err == io.EOF {
+			completionErr = nil
+		}
+		if r.blobAccess.recordCompletion(r.statistics, completionErr) && !r.receivedData {
+			// Empty objects don't yield any data, meaning
+			// the latency can only be recorded upon
+			// completion.
+			r.receivedData = true
+			r.blobAccess.recordLatency(r.statistics, r.timeStart)
+		}
+	}
+	return n, err
+}
+
+// notFoundFallbackReader is used by LatencyAwareBlobAccess to forward
+// read requests to other backends if the object is absent in the
+// backend that was selected. This prevents objects from temporarily
+// becoming unavailable if replicas are not strongly consistent, e.g.
+// when FindMissing() and Get() are sent to different replicas.
+type notFoundFallbackReader struct {
+	io.ReadCloser
+	blobAccess *latencyAwareBlobAccess
+	get        func(backend blobstore.BlobAccess) buffer.Buffer
+	fallbacks  []int
+
+	receivedData bool
+}
+
+func (r *notFoundFallbackReader) Read(p []byte) (int, error) {
+	for {
+		n, err := r.ReadCloser.Read(p)
+		if n > 0 {
+			r.receivedData = true
+		}
+		if r.receivedData || status.Code(err) != codes.NotFound || len(r.fallbacks) == 0 {
+			return n, err
+		}
+		r.ReadCloser.Close()
+		r.ReadCloser = r.blobAccess.newLatencyRecordingReader(r.fallbacks[0], r.get)
+		r.fallbacks = r.fallbacks[1:]
+	}
+}
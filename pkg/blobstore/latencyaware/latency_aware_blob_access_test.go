@@ -0,0 +1,242 @@
+package latencyaware_test
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	remoteexecution "github.com/bazelbuild/remote-apis/build/bazel/remote/execution/v2"
+	"github.com/buildbarn/bb-storage/internal/mock"
+	"github.com/buildbarn/bb-storage/pkg/blobstore"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/buffer"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/latencyaware"
+	"github.com/buildbarn/bb-storage/pkg/digest"
+	"github.com/buildbarn/bb-storage/pkg/testutil"
+	"github.com/stretchr/testify/require"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestLatencyAwareBlobAccess(t *testing.T) {
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+
+	backend0 := mock.NewMockBlobAccess(ctrl)
+	backend1 := mock.NewMockBlobAccess(ctrl)
+	clock := mock.NewMockClock(ctrl)
+	randomNumberGenerator := mock.NewMockThreadSafeGenerator(ctrl)
+	blobAccess := latencyaware.NewLatencyAwareBlobAccess(
+		[]blobstore.BlobAccess{backend0, backend1},
+		blobstore.CASReadBufferFactory,
+		clock,
+		randomNumberGenerator,
+		/* smoothingFactor = */ 0.5,
+		/* maximumErrorRate = */ 0.25,
+		/* probeProbability = */ 0.1)
+
+	helloDigest := digest.MustNewDigest("example", remoteexecution.DigestFunction_MD5, "8b1a9953c4611296a827abf8c47804d7", 5)
+	digests := helloDigest.ToSingletonSet()
+
+	expectRequest := func(latency time.Duration) {
+		clock.EXPECT().Now().Return(time.Unix(1000, 0))
+		clock.EXPECT().Now().Return(time.Unix(1000, 0).Add(latency))
+	}
+
+	t.Run("InitialEstimates", func(t *testing.T) {
+		// Requests should be sent to backends for which no
+		// estimates are available, regardless of their latency.
+		expectRequest(100 * time.Millisecond)
+		backend0.EXPECT().FindMissing(ctx, digests).Return(digest.EmptySet, nil)
+		expectRequest(60 * time.Millisecond)
+		backend1.EXPECT().FindMissing(ctx, digests).Return(digest.EmptySet, nil)
+
+		for i := 0; i < 2; i++ {
+			missing, err := blobAccess.FindMissing(ctx, digests)
+			require.NoError(t, err)
+			require.Equal(t, digest.EmptySet, missing)
+		}
+	})
+
+	t.Run("GetInitialEstimates", func(t *testing.T) {
+		// Estimates of Get() are tracked separately from those
+		// of FindMissing(). Even though the first backend is
+		// slower at processing FindMissing(), Get() should
+		// still be sent to it, as no estimates are available.
+		expectRequest(20 * time.Millisecond)
+		backend0.EXPECT().Get(ctx, helloDigest).
+			Return(buffer.NewValidatedBufferFromByteSlice([]byte("Hello")))
+		expectRequest(80 * time.Millisecond)
+		backend1.EXPECT().Get(ctx, helloDigest).
+			Return(buffer.NewValidatedBufferFromByteSlice([]byte("Hello")))
+
+		for i := 0; i < 2; i++ {
+			data, err := blobAccess.Get(ctx, helloDigest).ToByteSlice(100)
+			require.NoError(t, err)
+			require.Equal(t, []byte("Hello"), data)
+		}
+	})
+
+	t.Run("FastestBackend", func(t *testing.T) {
+		// Once estimates are available, requests should be
+		// sent to the fastest backend for that operation.
+		randomNumberGenerator.EXPECT().Float64().Return(0.5)
+		expectRequest(20 * time.Millisecond)
+		backend0.EXPECT().Get(ctx, helloDigest).
+			Return(buffer.NewValidatedBufferFromByteSlice([]byte("Hello")))
+		randomNumberGenerator.EXPECT().Float64().Return(0.5)
+		expectRequest(60 * time.Millisecond)
+		backend1.EXPECT().FindMissing(ctx, digests).Return(digest.EmptySet, nil)
+
+		data, err := blobAccess.Get(ctx, helloDigest).ToByteSlice(100)
+		require.NoError(t, err)
+		require.Equal(t, []byte("Hello"), data)
+		missing, err := blobAccess.FindMissing(ctx, digests)
+		require.NoError(t, err)
+		require.Equal(t, digest.EmptySet, missing)
+	})
+
+	t.Run("TimeToFirstByte", func(t *testing.T) {
+		// The latency of Get() should be measured when the
+		// first byte of data is received, as opposed to when
+		// the caller finishes consuming the buffer.
+		randomNumberGenerator.EXPECT().Float64().Return(0.5)
+		clock.EXPECT().Now().Return(time.Unix(1000, 0))
+		backend0.EXPECT().Get(ctx, helloDigest).
+			Return(buffer.NewValidatedBufferFromByteSlice([]byte("Hello")))
+
+		r := blobAccess.Get(ctx, helloDigest).ToReader()
+		clock.EXPECT().Now().Return(time.Unix(1000, 20000000))
+		var data [1]byte
+		n, err := r.Read(data[:])
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+		require.Equal(t, byte('H'), data[0])
+
+		// Consuming the remainder should not cause any further
+		// measurements to be taken.
+		rest, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, []byte("ello"), rest)
+		require.NoError(t, r.Close())
+	})
+
+	t.Run("Probing", func(t *testing.T) {
+		// Occasionally, requests should be sent to other
+		// backends. If they turn out to have become faster,
+		// they should be preferred.
+		randomNumberGenerator.EXPECT().Float64().Return(0.05)
+		randomNumberGenerator.EXPECT().IntN(1).Return(0)
+		expectRequest(10 * time.Millisecond)
+		backend0.EXPECT().FindMissing(ctx, digests).Return(digest.EmptySet, nil)
+		randomNumberGenerator.EXPECT().Float64().Return(0.5)
+		expectRequest(10 * time.Millisecond)
+		backend0.EXPECT().FindMissing(ctx, digests).Return(digest.EmptySet, nil)
+
+		for i := 0; i < 2; i++ {
+			missing, err := blobAccess.FindMissing(ctx, digests)
+			require.NoError(t, err)
+			require.Equal(t, digest.EmptySet, missing)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		// Objects being absent should not cause the backend to
+		// be considered unhealthy.
+		randomNumberGenerator.EXPECT().Float64().Return(0.5)
+		expectRequest(10 * time.Millisecond)
+		backend0.EXPECT().Get(ctx, helloDigest).
+			Return(buffer.NewBufferFromError(status.Error(codes.NotFound, "Object not found")))
+		expectRequest(10 * time.Millisecond)
+		backend1.EXPECT().Get(ctx, helloDigest).
+			Return(buffer.NewBufferFromError(status.Error(codes.NotFound, "Object not found")))
+		randomNumberGenerator.EXPECT().Float64().Return(0.5)
+		backend0.EXPECT().Put(ctx, helloDigest, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
+				b.Discard()
+				return nil
+			})
+
+		_, err := blobAccess.Get(ctx, helloDigest).ToByteSlice(100)
+		testutil.RequireEqualStatus(t, status.Error(codes.NotFound, "Object not found"), err)
+		require.NoError(t, blobAccess.Put(ctx, helloDigest, buffer.NewValidatedBufferFromByteSlice([]byte("Hello"))))
+	})
+
+	t.Run("UnhealthyBackend", func(t *testing.T) {
+		// Backends whose error rate exceeds the maximum should
+		// no longer be used, even if they are faster. This
+		// should only apply to the operation that failed.
+		randomNumberGenerator.EXPECT().Float64().Return(0.5)
+		clock.EXPECT().Now().Return(time.Unix(1000, 0))
+		backend0.EXPECT().Get(ctx, helloDigest).
+			Return(buffer.NewBufferFromError(status.Error(codes.Unavailable, "Server offline")))
+		randomNumberGenerator.EXPECT().Float64().Return(0.5)
+		expectRequest(50 * time.Millisecond)
+		backend1.EXPECT().Get(ctx, helloDigest).
+			Return(buffer.NewValidatedBufferFromByteSlice([]byte("Hello")))
+		randomNumberGenerator.EXPECT().Float64().Return(0.5)
+		expectRequest(10 * time.Millisecond)
+		backend0.EXPECT().FindMissing(ctx, digests).Return(digest.EmptySet, nil)
+
+		_, err := blobAccess.Get(ctx, helloDigest).ToByteSlice(100)
+		testutil.RequireEqualStatus(t, status.Error(codes.Unavailable, "Server offline"), err)
+		data, err := blobAccess.Get(ctx, helloDigest).ToByteSlice(100)
+		require.NoError(t, err)
+		require.Equal(t, []byte("Hello"), data)
+		missing, err := blobAccess.FindMissing(ctx, digests)
+		require.NoError(t, err)
+		require.Equal(t, digest.EmptySet, missing)
+	})
+
+	t.Run("Cancellation", func(t *testing.T) {
+		// Requests cancelled by the client should not affect
+		// the estimates.
+		randomNumberGenerator.EXPECT().Float64().Return(0.5)
+		clock.EXPECT().Now().Return(time.Unix(1000, 0))
+		backend0.EXPECT().FindMissing(ctx, digests).Return(digest.EmptySet, status.Error(codes.Canceled, "Request cancelled"))
+		randomNumberGenerator.EXPECT().Float64().Return(0.5)
+		expectRequest(10 * time.Millisecond)
+		backend0.EXPECT().FindMissing(ctx, digests).Return(digest.EmptySet, nil)
+
+		_, err := blobAccess.FindMissing(ctx, digests)
+		testutil.RequireEqualStatus(t, status.Error(codes.Canceled, "Request cancelled"), err)
+		_, err = blobAccess.FindMissing(ctx, digests)
+		require.NoError(t, err)
+	})
+
+	t.Run("NotFoundFallback", func(t *testing.T) {
+		// Replicas may not be strongly consistent. Objects
+		// that are absent in the selected backend should be
+		// read from the other backends.
+		randomNumberGenerator.EXPECT().Float64().Return(0.5)
+		expectRequest(10 * time.Millisecond)
+		backend1.EXPECT().Get(ctx, helloDigest).
+			Return(buffer.NewBufferFromError(status.Error(codes.NotFound, "Object not found")))
+		expectRequest(10 * time.Millisecond)
+		backend0.EXPECT().Get(ctx, helloDigest).
+			Return(buffer.NewValidatedBufferFromByteSlice([]byte("Hello")))
+
+		data, err := blobAccess.Get(ctx, helloDigest).ToByteSlice(100)
+		require.NoError(t, err)
+		require.Equal(t, []byte("Hello"), data)
+	})
+
+	t.Run("NotFoundEverywhere", func(t *testing.T) {
+		// If the object is absent in all backends, NOT_FOUND
+		// should be returned. As the previous request caused
+		// the first backend to become healthy again, it should
+		// be tried first.
+		randomNumberGenerator.EXPECT().Float64().Return(0.5)
+		expectRequest(10 * time.Millisecond)
+		backend0.EXPECT().Get(ctx, helloDigest).
+			Return(buffer.NewBufferFromError(status.Error(codes.NotFound, "Object not found")))
+		expectRequest(10 * time.Millisecond)
+		backend1.EXPECT().Get(ctx, helloDigest).
+			Return(buffer.NewBufferFromError(status.Error(codes.NotFound, "Object not found")))
+
+		_, err := blobAccess.Get(ctx, helloDigest).ToByteSlice(100)
+		testutil.RequireEqualStatus(t, status.Error(codes.NotFound, "Object not found"), err)
+	})
+}
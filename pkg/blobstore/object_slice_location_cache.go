@@ -0,0 +1,78 @@
+package blobstore
+
+import (
+	"sync"
+
+	"github.com/buildbarn/bb-storage/pkg/blobstore/slicing"
+	"github.com/buildbarn/bb-storage/pkg/eviction"
+)
+
+// objectSliceLocation describes the location of an object that is
+// contained within another object, as reported by BlobSlicer.
+type objectSliceLocation struct {
+	parentKey   string
+	offsetBytes int64
+	sizeBytes   int64
+}
+
+// objectSliceLocationCache retains the locations of objects that were
+// obtained by slicing parent objects in GetFromComposite(). Backends
+// that support ranged reads may use this to read child objects
+// directly, instead of downloading and slicing the parent object
+// again.
+type objectSliceLocationCache struct {
+	maximumSize int
+
+	lock        sync.Mutex
+	locations   map[string]objectSliceLocation
+	evictionSet eviction.Set[string]
+}
+
+func newObjectSliceLocationCache(maximumSize int, evictionSet eviction.Set[string]) *objectSliceLocationCache {
+	return &objectSliceLocationCache{
+		maximumSize: maximumSize,
+		locations:   map[string]objectSliceLocation{},
+		evictionSet: evictionSet,
+	}
+}
+
+// get the location of a child object. The location is only returned
+// if the child object is non-empty, as empty objects cannot be
+// obtained using ranged reads.
+func (c *objectSliceLocationCache) get(childKey string) (objectSliceLocation, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	location, ok := c.locations[childKey]
+	if !ok {
+		return objectSliceLocation{}, false
+	}
+	c.evictionSet.Touch(childKey)
+	return location, location.sizeBytes > 0
+}
+
+// insert the locations of all slices of a parent object.
+func (c *objectSliceLocationCache) insert(parentKey string, slices []slicing.BlobSlice, getChildKey func(slice slicing.BlobSlice) string) {
+	if c.maximumSize <= 0 {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, slice := range slices {
+		key := getChildKey(slice)
+		if _, ok := c.locations[key]; ok {
+			c.evictionSet.Touch(key)
+		} else {
+			if len(c.locations) >= c.maximumSize {
+				delete(c.locations, c.evictionSet.Peek())
+				c.evictionSet.Remove()
+			}
+			c.evictionSet.Insert(key)
+		}
+		c.locations[key] = objectSliceLocation{
+			parentKey:   parentKey,
+			offsetBytes: slice.OffsetBytes,
+			sizeBytes:   slice.SizeBytes,
+		}
+	}
+}
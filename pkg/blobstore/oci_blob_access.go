@@ -0,0 +1,341 @@
+package blobstore
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+
+	remoteexecution "github.com/bazelbuild/remote-apis/build/bazel/remote/execution/v2"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/buffer"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/slicing"
+	"github.com/buildbarn/bb-storage/pkg/capabilities"
+	"github.com/buildbarn/bb-storage/pkg/digest"
+	"github.com/buildbarn/bb-storage/pkg/eviction"
+	"github.com/buildbarn/bb-storage/pkg/util"
+
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type ociBlobAccess struct {
+	capabilities.Provider
+	readBufferFactory      ReadBufferFactory
+	httpClient             *http.Client
+	repositoryURL          string
+	chunkSizeBytes         int64
+	findMissingConcurrency int
+	slices                 *objectSliceLocationCache
+}
+
+// NewOCIBlobAccess creates a BlobAccess that stores objects in a
+// repository of a registry that implements the OCI Distribution
+// Specification. Objects are stored as blobs, meaning that they are
+// not referenced by any manifest. The registry must therefore be
+// configured not to garbage collect unreferenced blobs.
+//
+// As OCI registries identify blobs by their SHA-256 or SHA-512 hash,
+// this backend can only be used to store the Content Addressable
+// Storage (CAS), using those digest functions. Instance names are
+// ignored.
+//
+// Objects that are larger than chunkSizeBytes are uploaded using
+// chunked uploads. When objects are sliced as part of
+// GetFromComposite(), the locations of at most sliceCacheSize slices
+// are retained in memory. This permits subsequent requests for the
+// same child objects to be served using ranged reads.
+func NewOCIBlobAccess(capabilitiesProvider capabilities.Provider, readBufferFactory ReadBufferFactory, httpClient *http.Client, address, repository string, chunkSizeBytes int64, findMissingConcurrency, sliceCacheSize int, sliceEvictionSet eviction.Set[string]) BlobAccess {
+	return &ociBlobAccess{
+		Provider:               capabilitiesProvider,
+		readBufferFactory:      readBufferFactory,
+		httpClient:             httpClient,
+		repositoryURL:          address + "/v2/" + repository,
+		chunkSizeBytes:         chunkSizeBytes,
+		findMissingConcurrency: findMissingConcurrency,
+		slices:                 newObjectSliceLocationCache(sliceCacheSize, sliceEvictionSet),
+	}
+}
+
+// getOCIDigest converts a REv2 digest to the format used by the OCI
+// Distribution Specification (e.g., "sha256:e3b0c442...").
+func getOCIDigest(blobDigest digest.Digest) (string, error) {
+	switch digestFunction := blobDigest.GetDigestFunction().GetEnumValue(); digestFunction {
+	case remoteexecution.DigestFunction_SHA256:
+		return "sha256:" + blobDigest.GetHashString(), nil
+	case remoteexecution.DigestFunction_SHA512:
+		return "sha512:" + blobDigest.GetHashString(), nil
+	default:
+		return "", status.Errorf(codes.InvalidArgument, "Digest function %s is not supported by OCI registries", digestFunction)
+	}
+}
+
+func (ba *ociBlobAccess) getBlobURL(ociDigest string) string {
+	return ba.repositoryURL + "/blobs/" + ociDigest
+}
+
+// doRequest issues a HTTP request against the registry.
+func (ba *ociBlobAccess) doRequest(ctx context.Context, method, requestURL string, body io.Reader, contentLength int64, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, requestURL, body)
+	if err != nil {
+		return nil, util.StatusWrapWithCode(err, codes.Internal, "Failed to create HTTP request")
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if body != nil {
+		req.ContentLength = contentLength
+		req.Header.Set("Content-Type", "application/octet-stream")
+	}
+	resp, err := ba.httpClient.Do(req)
+	if err != nil {
+		return nil, util.StatusWrap(errToStatus(err), "HTTP request failed")
+	}
+	return resp, nil
+}
+
+// getDataIntegrityCallback returns a callback that deletes a blob from
+// the repository in case its contents are corrupted, so that it may be
+// replaced by a subsequent call to Put(). Registries may not permit
+// deletion of blobs, meaning that this is performed on a best effort
+// basis.
+func (ba *ociBlobAccess) getDataIntegrityCallback(ctx context.Context, blobURL string) buffer.DataIntegrityCallback {
+	return func(dataIsValid bool) {
+		if !dataIsValid {
+			if resp, err := ba.doRequest(context.WithoutCancel(ctx), http.MethodDelete, blobURL, nil, 0, nil); err == nil {
+				resp.Body.Close()
+			}
+		}
+	}
+}
+
+func (ba *ociBlobAccess) Get(ctx context.Context, blobDigest digest.Digest) buffer.Buffer {
+	ociDigest, err := getOCIDigest(blobDigest)
+	if err != nil {
+		return buffer.NewBufferFromError(err)
+	}
+	blobURL := ba.getBlobURL(ociDigest)
+	resp, err := ba.doRequest(ctx, http.MethodGet, blobURL, nil, 0, nil)
+	if err != nil {
+		return buffer.NewBufferFromError(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return buffer.NewBufferFromError(httpResponseToStatus(resp))
+	}
+	return ba.readBufferFactory.NewBufferFromReader(
+		blobDigest,
+		statusReturningReadCloser{r: resp.Body},
+		ba.getDataIntegrityCallback(ctx, blobURL))
+}
+
+func (ba *ociBlobAccess) GetFromComposite(ctx context.Context, parentDigest, childDigest digest.Digest, slicer slicing.BlobSlicer) buffer.Buffer {
+	parentOCIDigest, err := getOCIDigest(parentDigest)
+	if err != nil {
+		return buffer.NewBufferFromError(err)
+	}
+	childOCIDigest, err := getOCIDigest(childDigest)
+	if err != nil {
+		return buffer.NewBufferFromError(err)
+	}
+
+	// If the parent object has been sliced before, read the child
+	// object directly by issuing a ranged read.
+	if slice, ok := ba.slices.get(childOCIDigest); ok {
+		parentURL := ba.getBlobURL(slice.parentKey)
+		resp, err := ba.doRequest(ctx, http.MethodGet, parentURL, nil, 0, http.Header{
+			"Range": []string{fmt.Sprintf("bytes=%d-%d", slice.offsetBytes, slice.offsetBytes+slice.sizeBytes-1)},
+		})
+		if err != nil {
+			return buffer.NewBufferFromError(err)
+		}
+		if resp.StatusCode == http.StatusPartialContent {
+			return ba.readBufferFactory.NewBufferFromReader(
+				childDigest,
+				statusReturningReadCloser{r: resp.Body},
+				ba.getDataIntegrityCallback(ctx, parentURL))
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return buffer.NewBufferFromError(httpResponseToStatus(resp))
+		}
+		// The registry does not support ranged reads. Fall
+		// back to downloading the parent object.
+	}
+
+	// Download and slice the parent object, and retain the
+	// locations of all slices.
+	b, slices := slicer.Slice(ba.Get(ctx, parentDigest), childDigest)
+	ba.slices.insert(parentOCIDigest, slices, func(slice slicing.BlobSlice) string {
+		// Children use the same digest function as their
+		// parent, meaning that this cannot fail.
+		ociDigest, _ := getOCIDigest(slice.Digest)
+		return ociDigest
+	})
+	return b
+}
+
+// startUpload creates a new upload session, returning the URL to which
+// data should be sent.
+func (ba *ociBlobAccess) startUpload(ctx context.Context) (*url.URL, error) {
+	resp, err := ba.doRequest(ctx, http.MethodPost, ba.repositoryURL+"/blobs/uploads/", nil, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return nil, util.StatusWrap(httpResponseToStatus(resp), "Failed to start upload")
+	}
+	location, err := resp.Location()
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Registry did not return the location of the upload")
+	}
+	return location, nil
+}
+
+// uploadChunk uploads a chunk of data as part of an upload session,
+// returning the URL to which subsequent data should be sent.
+func (ba *ociBlobAccess) uploadChunk(ctx context.Context, location *url.URL, offsetBytes int64, chunk []byte) (*url.URL, error) {
+	resp, err := ba.doRequest(ctx, http.MethodPatch, location.String(), bytes.NewReader(chunk), int64(len(chunk)), http.Header{
+		"Content-Range": []string{fmt.Sprintf("%d-%d", offsetBytes, offsetBytes+int64(len(chunk))-1)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return nil, util.StatusWrap(httpResponseToStatus(resp), "Failed to upload chunk")
+	}
+	newLocation, err := resp.Location()
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Registry did not return the location of the upload")
+	}
+	return newLocation, nil
+}
+
+// finishUpload completes an upload session by uploading the final
+// chunk of data, and providing the digest of the blob.
+func (ba *ociBlobAccess) finishUpload(ctx context.Context, location *url.URL, ociDigest string, chunk []byte) error {
+	finalLocation := *location
+	query := finalLocation.Query()
+	query.Set("digest", ociDigest)
+	finalLocation.RawQuery = query.Encode()
+	resp, err := ba.doRequest(ctx, http.MethodPut, finalLocation.String(), bytes.NewReader(chunk), int64(len(chunk)), nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return util.StatusWrap(httpResponseToStatus(resp), "Failed to complete upload")
+	}
+	return nil
+}
+
+// cancelUpload cancels an upload session, so that the registry may
+// release any resources associated with it.
+func (ba *ociBlobAccess) cancelUpload(ctx context.Context, location *url.URL) {
+	if resp, err := ba.doRequest(context.WithoutCancel(ctx), http.MethodDelete, location.String(), nil, 0, nil); err == nil {
+		resp.Body.Close()
+	}
+}
+
+func (ba *ociBlobAccess) Put(ctx context.Context, blobDigest digest.Digest, b buffer.Buffer) error {
+	ociDigest, err := getOCIDigest(blobDigest)
+	if err != nil {
+		b.Discard()
+		return err
+	}
+	sizeBytes, err := b.GetSizeBytes()
+	if err != nil {
+		b.Discard()
+		return err
+	}
+	location, err := ba.startUpload(ctx)
+	if err != nil {
+		b.Discard()
+		return err
+	}
+
+	// Upload all but the last chunk of data using PATCH requests.
+	// The last chunk is sent as part of the PUT request that
+	// completes the upload. This means that small objects are
+	// uploaded using only two requests.
+	r := b.ToReader()
+	defer r.Close()
+	chunk := make([]byte, min(sizeBytes, ba.chunkSizeBytes))
+	for offsetBytes := int64(0); ; {
+		n, err := io.ReadFull(r, chunk[:min(sizeBytes-offsetBytes, ba.chunkSizeBytes)])
+		if err != nil {
+			ba.cancelUpload(ctx, location)
+			return err
+		}
+		if offsetBytes+int64(n) == sizeBytes {
+			// Only complete the upload after the buffer has
+			// validated the data.
+			var p [1]byte
+			if _, err := r.Read(p[:]); err != io.EOF {
+				ba.cancelUpload(ctx, location)
+				if err == nil {
+					return status.Error(codes.Internal, "Buffer is larger than its reported size")
+				}
+				return err
+			}
+			if err := ba.finishUpload(ctx, location, ociDigest, chunk[:n]); err != nil {
+				ba.cancelUpload(ctx, location)
+				return err
+			}
+			return nil
+		}
+		newLocation, err := ba.uploadChunk(ctx, location, offsetBytes, chunk[:n])
+		if err != nil {
+			ba.cancelUpload(ctx, location)
+			return err
+		}
+		location = newLocation
+		offsetBytes += int64(n)
+	}
+}
+
+func (ba *ociBlobAccess) FindMissing(ctx context.Context, digests digest.Set) (digest.Set, error) {
+	var missingLock sync.Mutex
+	missing := digest.NewSetBuilder()
+
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(ba.findMissingConcurrency)
+	for _, blobDigest := range digests.Items() {
+		group.Go(func() error {
+			ociDigest, err := getOCIDigest(blobDigest)
+			if err != nil {
+				return err
+			}
+			resp, err := ba.doRequest(groupCtx, http.MethodHead, ba.getBlobURL(ociDigest), nil, 0, nil)
+			if err != nil {
+				return util.StatusWrapf(err, "Failed to check for the existence of blob %#v", ociDigest)
+			}
+			resp.Body.Close()
+			switch resp.StatusCode {
+			case http.StatusOK:
+				// Registries are expected to return the
+				// size of the blob. Treat blobs having a
+				// different size as being absent.
+				if resp.ContentLength < 0 || resp.ContentLength == blobDigest.GetSizeBytes() {
+					return nil
+				}
+			case http.StatusNotFound:
+			default:
+				return util.StatusWrapf(httpResponseToStatus(resp), "Failed to check for the existence of blob %#v", ociDigest)
+			}
+			missingLock.Lock()
+			missing.Add(blobDigest)
+			missingLock.Unlock()
+			return nil
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return digest.EmptySet, err
+	}
+	return missing.Build(), nil
+}
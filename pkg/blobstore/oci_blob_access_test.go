@@ -0,0 +1,233 @@
+package blobstore_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	remoteexecution "github.com/bazelbuild/remote-apis/build/bazel/remote/execution/v2"
+	"github.com/buildbarn/bb-storage/internal/mock"
+	"github.com/buildbarn/bb-storage/pkg/blobstore"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/buffer"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/slicing"
+	"github.com/buildbarn/bb-storage/pkg/digest"
+	"github.com/buildbarn/bb-storage/pkg/eviction"
+	"github.com/buildbarn/bb-storage/pkg/testutil"
+	"github.com/stretchr/testify/require"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"go.uber.org/mock/gomock"
+)
+
+// fakeOCIRegistry is a minimal in-memory implementation of the parts
+// of the OCI Distribution Specification that are used by
+// OCIBlobAccess.
+type fakeOCIRegistry struct {
+	lock          sync.Mutex
+	blobs         map[string][]byte
+	uploads       map[string][]byte
+	nextUploadID  int
+	requests      []string
+	rangesEnabled bool
+}
+
+func (r *fakeOCIRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.requests = append(r.requests, req.Method+" "+req.URL.Path)
+
+	const blobsPrefix = "/v2/my/repo/blobs/"
+	const uploadsPrefix = blobsPrefix + "uploads/"
+	switch {
+	case req.Method == http.MethodPost && req.URL.Path == uploadsPrefix:
+		r.nextUploadID++
+		uploadID := fmt.Sprintf("%d", r.nextUploadID)
+		r.uploads[uploadID] = nil
+		w.Header().Set("Location", uploadsPrefix+uploadID)
+		w.WriteHeader(http.StatusAccepted)
+	case strings.HasPrefix(req.URL.Path, uploadsPrefix):
+		uploadID := strings.TrimPrefix(req.URL.Path, uploadsPrefix)
+		data, ok := r.uploads[uploadID]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch req.Method {
+		case http.MethodPatch:
+			if req.Header.Get("Content-Range") != fmt.Sprintf("%d-%d", len(data), len(data)+len(body)-1) {
+				w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+				return
+			}
+			r.uploads[uploadID] = append(data, body...)
+			w.Header().Set("Location", uploadsPrefix+uploadID)
+			w.WriteHeader(http.StatusAccepted)
+		case http.MethodPut:
+			data = append(data, body...)
+			hash := sha256.Sum256(data)
+			ociDigest := "sha256:" + hex.EncodeToString(hash[:])
+			if req.URL.Query().Get("digest") != ociDigest {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			delete(r.uploads, uploadID)
+			r.blobs[ociDigest] = data
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodDelete:
+			delete(r.uploads, uploadID)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	case strings.HasPrefix(req.URL.Path, blobsPrefix):
+		data, ok := r.blobs[strings.TrimPrefix(req.URL.Path, blobsPrefix)]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if !r.rangesEnabled {
+			req.Header.Del("Range")
+		}
+		http.ServeContent(w, req, "", time.Time{}, bytes.NewReader(data))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestOCIBlobAccess(t *testing.T) {
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+
+	registry := &fakeOCIRegistry{
+		blobs:         map[string][]byte{},
+		uploads:       map[string][]byte{},
+		rangesEnabled: true,
+	}
+	server := httptest.NewServer(registry)
+	defer server.Close()
+
+	blobAccess := blobstore.NewOCIBlobAccess(
+		nil,
+		blobstore.CASReadBufferFactory,
+		server.Client(),
+		server.URL,
+		"my/repo",
+		2,
+		2,
+		10,
+		eviction.NewLRUSet[string]())
+
+	helloDigest := digest.MustNewDigest("hello", remoteexecution.DigestFunction_SHA256, "185f8db32271fe25f561a6fc938b2e264306ec304eda518007d1764826381969", 5)
+	worldDigest := digest.MustNewDigest("hello", remoteexecution.DigestFunction_SHA256, "78ae647dc5544d227130a0682a51e30bc7777fbb6d8a8f17007463a3ecd1d524", 5)
+	helloWorldDigest := digest.MustNewDigest("hello", remoteexecution.DigestFunction_SHA256, "872e4e50ce9990d8b041330c47c9ddd11bec6b503ae9386a99da8584e9bb12c4", 10)
+
+	t.Run("UnsupportedDigestFunction", func(t *testing.T) {
+		md5Digest := digest.MustNewDigest("hello", remoteexecution.DigestFunction_MD5, "8b1a9953c4611296a827abf8c47804d7", 5)
+
+		_, err := blobAccess.Get(ctx, md5Digest).ToByteSlice(100)
+		testutil.RequireEqualStatus(t, status.Error(codes.InvalidArgument, "Digest function MD5 is not supported by OCI registries"), err)
+
+		testutil.RequireEqualStatus(
+			t,
+			status.Error(codes.InvalidArgument, "Digest function MD5 is not supported by OCI registries"),
+			blobAccess.Put(ctx, md5Digest, buffer.NewValidatedBufferFromByteSlice([]byte("Hello"))))
+	})
+
+	t.Run("GetNotFound", func(t *testing.T) {
+		_, err := blobAccess.Get(ctx, helloDigest).ToByteSlice(100)
+		testutil.RequireEqualStatus(t, status.Error(codes.NotFound, "HTTP request failed with status \"404 Not Found\""), err)
+	})
+
+	t.Run("PutAndGet", func(t *testing.T) {
+		// Objects should be uploaded in chunks, where the last
+		// chunk is sent as part of the request that completes
+		// the upload.
+		registry.requests = nil
+		require.NoError(t, blobAccess.Put(ctx, helloDigest, buffer.NewValidatedBufferFromByteSlice([]byte("Hello"))))
+		require.Equal(t, []string{
+			"POST /v2/my/repo/blobs/uploads/",
+			"PATCH /v2/my/repo/blobs/uploads/1",
+			"PATCH /v2/my/repo/blobs/uploads/1",
+			"PUT /v2/my/repo/blobs/uploads/1",
+		}, registry.requests)
+		require.Equal(t, []byte("Hello"), registry.blobs["sha256:185f8db32271fe25f561a6fc938b2e264306ec304eda518007d1764826381969"])
+
+		data, err := blobAccess.Get(ctx, helloDigest).ToByteSlice(100)
+		require.NoError(t, err)
+		require.Equal(t, []byte("Hello"), data)
+	})
+
+	t.Run("PutCorrupted", func(t *testing.T) {
+		// Data that does not match the digest should cause the
+		// upload to be canceled.
+		testutil.RequireEqualStatus(
+			t,
+			status.Error(codes.InvalidArgument, "Buffer has checksum 185f8db32271fe25f561a6fc938b2e264306ec304eda518007d1764826381969, while 78ae647dc5544d227130a0682a51e30bc7777fbb6d8a8f17007463a3ecd1d524 was expected"),
+			blobAccess.Put(ctx, worldDigest, buffer.NewCASBufferFromReader(worldDigest, io.NopCloser(bytes.NewBufferString("Hello")), buffer.UserProvided)))
+		require.Empty(t, registry.uploads)
+		require.NotContains(t, registry.blobs, "sha256:78ae647dc5544d227130a0682a51e30bc7777fbb6d8a8f17007463a3ecd1d524")
+	})
+
+	t.Run("FindMissing", func(t *testing.T) {
+		missing, err := blobAccess.FindMissing(ctx, digest.NewSetBuilder().Add(helloDigest).Add(worldDigest).Build())
+		require.NoError(t, err)
+		require.Equal(t, worldDigest.ToSingletonSet(), missing)
+
+		missing, err = blobAccess.FindMissing(ctx, digest.EmptySet)
+		require.NoError(t, err)
+		require.Equal(t, digest.EmptySet, missing)
+	})
+
+	t.Run("GetFromComposite", func(t *testing.T) {
+		require.NoError(t, blobAccess.Put(ctx, helloWorldDigest, buffer.NewValidatedBufferFromByteSlice([]byte("HelloWorld"))))
+
+		// The first time a child object is requested, the parent
+		// object needs to be downloaded and sliced.
+		slicer := mock.NewMockBlobSlicer(ctrl)
+		slicer.EXPECT().Slice(gomock.Any(), worldDigest).DoAndReturn(
+			func(b buffer.Buffer, childDigest digest.Digest) (buffer.Buffer, []slicing.BlobSlice) {
+				data, err := b.ToByteSlice(100)
+				require.NoError(t, err)
+				require.Equal(t, []byte("HelloWorld"), data)
+				return buffer.NewValidatedBufferFromByteSlice([]byte("World")), []slicing.BlobSlice{
+					{Digest: helloDigest, OffsetBytes: 0, SizeBytes: 5},
+					{Digest: worldDigest, OffsetBytes: 5, SizeBytes: 5},
+				}
+			})
+
+		data, err := blobAccess.GetFromComposite(ctx, helloWorldDigest, worldDigest, slicer).ToByteSlice(100)
+		require.NoError(t, err)
+		require.Equal(t, []byte("World"), data)
+
+		// Subsequent requests for children of the same parent
+		// object should be served using ranged reads.
+		data, err = blobAccess.GetFromComposite(ctx, helloWorldDigest, worldDigest, slicer).ToByteSlice(100)
+		require.NoError(t, err)
+		require.Equal(t, []byte("World"), data)
+
+		// If the registry does not support ranged reads, the
+		// parent object should be downloaded and sliced again.
+		registry.rangesEnabled = false
+		slicer.EXPECT().Slice(gomock.Any(), worldDigest).DoAndReturn(
+			func(b buffer.Buffer, childDigest digest.Digest) (buffer.Buffer, []slicing.BlobSlice) {
+				b.Discard()
+				return buffer.NewValidatedBufferFromByteSlice([]byte("World")), nil
+			})
+
+		data, err = blobAccess.GetFromComposite(ctx, helloWorldDigest, worldDigest, slicer).ToByteSlice(100)
+		require.NoError(t, err)
+		require.Equal(t, []byte("World"), data)
+	})
+}
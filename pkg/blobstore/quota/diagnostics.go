@@ -0,0 +1,120 @@
+package quota
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	usageTrackersLock sync.Mutex
+	usageTrackers     = map[string]*UsageTracker{}
+
+	usageTrackersPrometheusMetrics sync.Once
+
+	usageBytesDesc = prometheus.NewDesc(
+		"buildbarn_blobstore_quota_usage_bytes",
+		"Number of bytes written by a tenant within the sliding window",
+		[]string{"name", "tenant"},
+		nil)
+	quotaBytesDesc = prometheus.NewDesc(
+		"buildbarn_blobstore_quota_limit_bytes",
+		"Storage quota of a tenant within the sliding window",
+		[]string{"name", "tenant"},
+		nil)
+)
+
+// RegisterUsageTracker registers a UsageTracker under a given name,
+// causing the usage of its tenants to be exposed through Prometheus
+// metrics and DiagnosticsHandler. The cardinality of the metrics is
+// bounded by the number of tenants that wrote data within the sliding
+// window.
+func RegisterUsageTracker(name string, usageTracker *UsageTracker) error {
+	usageTrackersPrometheusMetrics.Do(func() {
+		prometheus.MustRegister(usageTrackersCollector{})
+	})
+
+	usageTrackersLock.Lock()
+	defer usageTrackersLock.Unlock()
+
+	if _, ok := usageTrackers[name]; ok {
+		return status.Errorf(codes.AlreadyExists, "A quota with name %#v has already been registered", name)
+	}
+	usageTrackers[name] = usageTracker
+	return nil
+}
+
+// getAllUsage returns the usage of all registered instances of
+// UsageTracker, keyed by name.
+func getAllUsage() map[string][]TenantUsage {
+	usageTrackersLock.Lock()
+	defer usageTrackersLock.Unlock()
+
+	usage := make(map[string][]TenantUsage, len(usageTrackers))
+	for name, usageTracker := range usageTrackers {
+		usage[name] = usageTracker.GetUsage()
+	}
+	return usage
+}
+
+// usageTrackersCollector is a Prometheus collector that exposes the
+// usage of all registered instances of UsageTracker.
+type usageTrackersCollector struct{}
+
+func (usageTrackersCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- usageBytesDesc
+	ch <- quotaBytesDesc
+}
+
+func (usageTrackersCollector) Collect(ch chan<- prometheus.Metric) {
+	for name, tenants := range getAllUsage() {
+		for _, tenant := range tenants {
+			ch <- prometheus.MustNewConstMetric(usageBytesDesc, prometheus.GaugeValue, float64(tenant.UsageBytes), name, tenant.Tenant)
+			if tenant.QuotaBytes > 0 {
+				ch <- prometheus.MustNewConstMetric(quotaBytesDesc, prometheus.GaugeValue, float64(tenant.QuotaBytes), name, tenant.Tenant)
+			}
+		}
+	}
+}
+
+type quotaUsage struct {
+	Name    string        `json:"name"`
+	Tenants []TenantUsage `json:"tenants"`
+}
+
+type diagnosticsHandler struct{}
+
+// DiagnosticsHandler is a HTTP handler that returns the usage of all
+// tenants of all registered instances of UsageTracker in JSON form.
+// It can be exposed through the diagnostics web server.
+var DiagnosticsHandler http.Handler = diagnosticsHandler{}
+
+func (diagnosticsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	allUsage := getAllUsage()
+	quotas := make([]quotaUsage, 0, len(allUsage))
+	for name, tenants := range allUsage {
+		quotas = append(quotas, quotaUsage{
+			Name:    name,
+			Tenants: tenants,
+		})
+	}
+	sort.Slice(quotas, func(i, j int) bool {
+		return quotas[i].Name < quotas[j].Name
+	})
+
+	body, err := json.Marshal(quotas)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.Write(body)
+}
@@ -0,0 +1,48 @@
+package quota_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/buildbarn/bb-storage/internal/mock"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/quota"
+	"github.com/buildbarn/bb-storage/pkg/testutil"
+	"github.com/stretchr/testify/require"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestDiagnosticsHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	clock := mock.NewMockClock(ctrl)
+	clock.EXPECT().Now().Return(time.Unix(1000, 0)).AnyTimes()
+	usageTracker := quota.NewUsageTracker(clock, time.Minute, map[string]int64{"team-a": 100}, 0)
+	require.NoError(t, quota.RegisterUsageTracker("diagnostics-test", usageTracker))
+	testutil.RequireEqualStatus(
+		t,
+		status.Error(codes.AlreadyExists, "A quota with name \"diagnostics-test\" has already been registered"),
+		quota.RegisterUsageTracker("diagnostics-test", usageTracker))
+
+	usageTracker.RecordUsage("team-a", 42)
+	usageTracker.RecordUsage("team-b", 7)
+
+	w := httptest.NewRecorder()
+	quota.DiagnosticsHandler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/quota", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	require.JSONEq(t, `[
+		{
+			"name": "diagnostics-test",
+			"tenants": [
+				{"tenant": "team-a", "usage_bytes": 42, "quota_bytes": 100},
+				{"tenant": "team-b", "usage_bytes": 7, "quota_bytes": 0}
+			]
+		}
+	]`, w.Body.String())
+}
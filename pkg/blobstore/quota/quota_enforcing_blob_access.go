@@ -0,0 +1,94 @@
+package quota
+
+import (
+	"context"
+	"sync"
+
+	"github.com/buildbarn/bb-storage/pkg/blobstore"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/buffer"
+	"github.com/buildbarn/bb-storage/pkg/digest"
+	"github.com/buildbarn/bb-storage/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"golang.org/x/sync/semaphore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	quotaEnforcingBlobAccessPrometheusMetrics sync.Once
+
+	quotaEnforcingBlobAccessPutsOverQuota = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "buildbarn",
+			Subsystem: "blobstore",
+			Name:      "quota_enforcing_blob_access_puts_over_quota_total",
+			Help:      "Number of Put() calls performed by tenants that exceeded their quota",
+		},
+		[]string{"name", "action"})
+)
+
+type quotaEnforcingBlobAccess struct {
+	blobstore.BlobAccess
+	usageTracker      *UsageTracker
+	tenantExtractor   TenantExtractor
+	deprioritizedPuts *semaphore.Weighted
+
+	putsOverQuota prometheus.Counter
+}
+
+// NewQuotaEnforcingBlobAccess creates a decorator for BlobAccess that
+// tracks the number of bytes written by tenants using a UsageTracker.
+// This prevents a single tenant from filling up storage, causing data
+// of other tenants to be evicted.
+//
+// Put() calls performed by tenants that exceeded their quota are
+// either rejected with RESOURCE_EXHAUSTED or, if a semaphore is
+// provided, deprioritized. Deprioritized calls are only performed while
+// holding the semaphore, limiting the number of concurrent writes by
+// tenants that exceeded their quota.
+func NewQuotaEnforcingBlobAccess(base blobstore.BlobAccess, usageTracker *UsageTracker, tenantExtractor TenantExtractor, deprioritizedPuts *semaphore.Weighted, name string) blobstore.BlobAccess {
+	quotaEnforcingBlobAccessPrometheusMetrics.Do(func() {
+		prometheus.MustRegister(quotaEnforcingBlobAccessPutsOverQuota)
+	})
+
+	action := "Rejected"
+	if deprioritizedPuts != nil {
+		action = "Deprioritized"
+	}
+	return &quotaEnforcingBlobAccess{
+		BlobAccess:        base,
+		usageTracker:      usageTracker,
+		tenantExtractor:   tenantExtractor,
+		deprioritizedPuts: deprioritizedPuts,
+
+		putsOverQuota: quotaEnforcingBlobAccessPutsOverQuota.WithLabelValues(name, action),
+	}
+}
+
+func (ba *quotaEnforcingBlobAccess) Put(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
+	tenant, err := ba.tenantExtractor(ctx, digest.GetInstanceName())
+	if err != nil {
+		b.Discard()
+		return err
+	}
+
+	if ba.usageTracker.IsOverQuota(tenant) {
+		ba.putsOverQuota.Inc()
+		if ba.deprioritizedPuts == nil {
+			b.Discard()
+			return status.Errorf(codes.ResourceExhausted, "Tenant %#v has exceeded its storage quota", tenant)
+		}
+		if err := util.AcquireSemaphore(ctx, ba.deprioritizedPuts, 1); err != nil {
+			b.Discard()
+			return err
+		}
+		defer ba.deprioritizedPuts.Release(1)
+	}
+
+	if err := ba.BlobAccess.Put(ctx, digest, b); err != nil {
+		return err
+	}
+	ba.usageTracker.RecordUsage(tenant, digest.GetSizeBytes())
+	return nil
+}
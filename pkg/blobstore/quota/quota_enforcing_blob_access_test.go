@@ -0,0 +1,133 @@
+package quota_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	remoteexecution "github.com/bazelbuild/remote-apis/build/bazel/remote/execution/v2"
+	"github.com/buildbarn/bb-storage/internal/mock"
+	"github.com/buildbarn/bb-storage/pkg/auth"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/buffer"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/quota"
+	"github.com/buildbarn/bb-storage/pkg/digest"
+	"github.com/buildbarn/bb-storage/pkg/jmespath"
+	"github.com/buildbarn/bb-storage/pkg/testutil"
+	"github.com/stretchr/testify/require"
+
+	"golang.org/x/sync/semaphore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestQuotaEnforcingBlobAccessReject(t *testing.T) {
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+
+	baseBlobAccess := mock.NewMockBlobAccess(ctrl)
+	clock := mock.NewMockClock(ctrl)
+	clock.EXPECT().Now().Return(time.Unix(1000, 0)).AnyTimes()
+	usageTracker := quota.NewUsageTracker(clock, time.Minute, map[string]int64{"team-a": 5}, 0)
+	blobAccess := quota.NewQuotaEnforcingBlobAccess(
+		baseBlobAccess,
+		usageTracker,
+		quota.NewInstanceNamePrefixTenantExtractor(1),
+		/* deprioritizedPuts = */ nil,
+		"reject")
+
+	helloDigest := digest.MustNewDigest("team-a/linux", remoteexecution.DigestFunction_MD5, "8b1a9953c4611296a827abf8c47804d7", 5)
+
+	t.Run("BackendFailure", func(t *testing.T) {
+		// Failed writes should not count towards the quota.
+		baseBlobAccess.EXPECT().Put(ctx, helloDigest, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
+				b.Discard()
+				return status.Error(codes.Unavailable, "Server offline")
+			})
+
+		testutil.RequireEqualStatus(
+			t,
+			status.Error(codes.Unavailable, "Server offline"),
+			blobAccess.Put(ctx, helloDigest, buffer.NewValidatedBufferFromByteSlice([]byte("Hello"))))
+		require.Empty(t, usageTracker.GetUsage())
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		baseBlobAccess.EXPECT().Put(ctx, helloDigest, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
+				data, err := b.ToByteSlice(100)
+				require.NoError(t, err)
+				require.Equal(t, []byte("Hello"), data)
+				return nil
+			})
+
+		require.NoError(t, blobAccess.Put(ctx, helloDigest, buffer.NewValidatedBufferFromByteSlice([]byte("Hello"))))
+		require.Equal(t, []quota.TenantUsage{
+			{Tenant: "team-a", UsageBytes: 5, QuotaBytes: 5},
+		}, usageTracker.GetUsage())
+	})
+
+	t.Run("OverQuota", func(t *testing.T) {
+		testutil.RequireEqualStatus(
+			t,
+			status.Error(codes.ResourceExhausted, "Tenant \"team-a\" has exceeded its storage quota"),
+			blobAccess.Put(ctx, helloDigest, buffer.NewValidatedBufferFromByteSlice([]byte("Hello"))))
+	})
+}
+
+func TestQuotaEnforcingBlobAccessDeprioritize(t *testing.T) {
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+
+	baseBlobAccess := mock.NewMockBlobAccess(ctrl)
+	clock := mock.NewMockClock(ctrl)
+	clock.EXPECT().Now().Return(time.Unix(1000, 0)).AnyTimes()
+	usageTracker := quota.NewUsageTracker(clock, time.Minute, nil, 1)
+	deprioritizedPuts := semaphore.NewWeighted(1)
+	blobAccess := quota.NewQuotaEnforcingBlobAccess(
+		baseBlobAccess,
+		usageTracker,
+		quota.NewJMESPathTenantExtractor(jmespath.MustCompile("authenticationMetadata.public.team")),
+		deprioritizedPuts,
+		"deprioritize")
+
+	authenticationMetadata, err := auth.NewAuthenticationMetadataFromRaw(map[string]any{
+		"public": map[string]any{
+			"team": "team-a",
+		},
+	})
+	require.NoError(t, err)
+	teamCtx := auth.NewContextWithAuthenticationMetadata(ctx, authenticationMetadata)
+	helloDigest := digest.MustNewDigest("example", remoteexecution.DigestFunction_MD5, "8b1a9953c4611296a827abf8c47804d7", 5)
+
+	// The first write is not subject to the semaphore, as the
+	// tenant has not exceeded its quota yet.
+	baseBlobAccess.EXPECT().Put(teamCtx, helloDigest, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
+			b.Discard()
+			require.True(t, deprioritizedPuts.TryAcquire(1))
+			deprioritizedPuts.Release(1)
+			return nil
+		})
+	require.NoError(t, blobAccess.Put(teamCtx, helloDigest, buffer.NewValidatedBufferFromByteSlice([]byte("Hello"))))
+
+	// Successive writes should only be performed while holding the
+	// semaphore.
+	baseBlobAccess.EXPECT().Put(teamCtx, helloDigest, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
+			b.Discard()
+			require.False(t, deprioritizedPuts.TryAcquire(1))
+			return nil
+		})
+	require.NoError(t, blobAccess.Put(teamCtx, helloDigest, buffer.NewValidatedBufferFromByteSlice([]byte("Hello"))))
+	require.True(t, deprioritizedPuts.TryAcquire(1))
+
+	// If the semaphore cannot be acquired before the context is
+	// cancelled, the write should fail.
+	cancelledCtx, cancel := context.WithCancel(teamCtx)
+	cancel()
+	testutil.RequireEqualStatus(
+		t,
+		status.Error(codes.Canceled, "context canceled"),
+		blobAccess.Put(cancelledCtx, helloDigest, buffer.NewValidatedBufferFromByteSlice([]byte("Hello"))))
+}
@@ -0,0 +1,55 @@
+package quota
+
+import (
+	"context"
+	"strings"
+
+	"github.com/buildbarn/bb-storage/pkg/auth"
+	"github.com/buildbarn/bb-storage/pkg/digest"
+	"github.com/buildbarn/bb-storage/pkg/jmespath"
+	"github.com/buildbarn/bb-storage/pkg/util"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TenantExtractor is called by QuotaEnforcingBlobAccess to determine
+// the tenant to which the usage of a request should be attributed.
+type TenantExtractor func(ctx context.Context, instanceName digest.InstanceName) (string, error)
+
+// NewJMESPathTenantExtractor creates a TenantExtractor that determines
+// the tenant by evaluating a JMESPath expression. The expression is
+// called with a JSON object that includes both the REv2 instance name
+// and authentication metadata. It must yield a string, or null if the
+// request does not belong to a specific tenant.
+func NewJMESPathTenantExtractor(expression *jmespath.Expression) TenantExtractor {
+	return func(ctx context.Context, instanceName digest.InstanceName) (string, error) {
+		result, err := expression.Search(map[string]any{
+			"authenticationMetadata": auth.AuthenticationMetadataFromContext(ctx).GetRaw(),
+			"instanceName":           instanceName.String(),
+		})
+		if err != nil {
+			return "", util.StatusWrapWithCode(err, codes.Internal, "Failed to evaluate tenant JMESPath expression")
+		}
+		switch tenant := result.(type) {
+		case nil:
+			return "", nil
+		case string:
+			return tenant, nil
+		default:
+			return "", status.Errorf(codes.Internal, "Tenant JMESPath expression yielded a value of type %T, while a string was expected", result)
+		}
+	}
+}
+
+// NewInstanceNamePrefixTenantExtractor creates a TenantExtractor that
+// uses the leading components of the REv2 instance name as the tenant.
+// For example, if the number of components is one, requests for
+// instance names "team-a/linux" and "team-a/macos" are both
+// attributed to tenant "team-a".
+func NewInstanceNamePrefixTenantExtractor(components int) TenantExtractor {
+	return func(ctx context.Context, instanceName digest.InstanceName) (string, error) {
+		instanceNameComponents := instanceName.GetComponents()
+		return strings.Join(instanceNameComponents[:min(components, len(instanceNameComponents))], "/"), nil
+	}
+}
@@ -0,0 +1,160 @@
+package quota
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/buildbarn/bb-storage/pkg/clock"
+)
+
+// usageTrackerSlotsPerWindow is the number of slots into which the
+// sliding window is partitioned. Usage expires at the granularity of
+// a single slot.
+const usageTrackerSlotsPerWindow = 60
+
+// TenantUsage contains the number of bytes written by a tenant within
+// the sliding window, and the quota that applies to the tenant. A
+// quota of zero indicates that the tenant has no quota.
+type TenantUsage struct {
+	Tenant     string `json:"tenant"`
+	UsageBytes int64  `json:"usage_bytes"`
+	QuotaBytes int64  `json:"quota_bytes"`
+}
+
+type tenantSlots struct {
+	slotBytes   [usageTrackerSlotsPerWindow]int64
+	slotIndices [usageTrackerSlotsPerWindow]int64
+}
+
+// UsageTracker keeps track of the number of bytes written per tenant
+// within a sliding window of time, and compares it against the quota
+// of the tenant.
+type UsageTracker struct {
+	clock             clock.Clock
+	slotDuration      time.Duration
+	tenantQuotaBytes  map[string]int64
+	defaultQuotaBytes int64
+
+	lock                sync.Mutex
+	tenants             map[string]*tenantSlots
+	lastPrunedSlotIndex int64
+}
+
+// NewUsageTracker creates a UsageTracker that tracks usage within a
+// sliding window of a given duration. Tenants for which no explicit
+// quota is provided are subject to the default quota. A quota of zero
+// means that the tenant has no quota.
+func NewUsageTracker(clock clock.Clock, window time.Duration, tenantQuotaBytes map[string]int64, defaultQuotaBytes int64) *UsageTracker {
+	return &UsageTracker{
+		clock:             clock,
+		slotDuration:      max(window/usageTrackerSlotsPerWindow, 1),
+		tenantQuotaBytes:  tenantQuotaBytes,
+		defaultQuotaBytes: defaultQuotaBytes,
+		tenants:           map[string]*tenantSlots{},
+	}
+}
+
+func (ut *UsageTracker) getQuotaBytes(tenant string) int64 {
+	if quotaBytes, ok := ut.tenantQuotaBytes[tenant]; ok {
+		return quotaBytes
+	}
+	return ut.defaultQuotaBytes
+}
+
+func (ut *UsageTracker) getCurrentSlotIndex() int64 {
+	return ut.clock.Now().UnixNano() / int64(ut.slotDuration)
+}
+
+// getUsageBytes sums the usage of all slots that are part of the
+// sliding window. This function must be called with the lock held.
+func (ut *UsageTracker) getUsageBytes(ts *tenantSlots, currentSlotIndex int64) int64 {
+	var usageBytes int64
+	for i, slotIndex := range ts.slotIndices {
+		if slotIndex > currentSlotIndex-usageTrackerSlotsPerWindow {
+			usageBytes += ts.slotBytes[i]
+		}
+	}
+	return usageBytes
+}
+
+// IsOverQuota returns whether the number of bytes written by a tenant
+// within the sliding window has reached its quota.
+func (ut *UsageTracker) IsOverQuota(tenant string) bool {
+	quotaBytes := ut.getQuotaBytes(tenant)
+	if quotaBytes == 0 {
+		return false
+	}
+	currentSlotIndex := ut.getCurrentSlotIndex()
+
+	ut.lock.Lock()
+	defer ut.lock.Unlock()
+
+	ts, ok := ut.tenants[tenant]
+	return ok && ut.getUsageBytes(ts, currentSlotIndex) >= quotaBytes
+}
+
+// pruneTenants forgets tenants that have not written any data within
+// the sliding window. As usage only expires at the granularity of a
+// single slot, this is done at most once per slot. This function must
+// be called with the lock held.
+func (ut *UsageTracker) pruneTenants(currentSlotIndex int64) {
+	if ut.lastPrunedSlotIndex == currentSlotIndex {
+		return
+	}
+	ut.lastPrunedSlotIndex = currentSlotIndex
+	for tenant, ts := range ut.tenants {
+		if ut.getUsageBytes(ts, currentSlotIndex) == 0 {
+			delete(ut.tenants, tenant)
+		}
+	}
+}
+
+// RecordUsage adds a number of bytes written to the usage of a tenant.
+// Tenants that have not written any data within the sliding window
+// are forgotten.
+func (ut *UsageTracker) RecordUsage(tenant string, sizeBytes int64) {
+	currentSlotIndex := ut.getCurrentSlotIndex()
+
+	ut.lock.Lock()
+	defer ut.lock.Unlock()
+
+	ut.pruneTenants(currentSlotIndex)
+	ts, ok := ut.tenants[tenant]
+	if !ok {
+		ts = &tenantSlots{}
+		ut.tenants[tenant] = ts
+	}
+	i := currentSlotIndex % usageTrackerSlotsPerWindow
+	if ts.slotIndices[i] != currentSlotIndex {
+		ts.slotIndices[i] = currentSlotIndex
+		ts.slotBytes[i] = 0
+	}
+	ts.slotBytes[i] += sizeBytes
+}
+
+// GetUsage returns the usage of all tenants that wrote data within the
+// sliding window, sorted by tenant.
+func (ut *UsageTracker) GetUsage() []TenantUsage {
+	currentSlotIndex := ut.getCurrentSlotIndex()
+
+	ut.lock.Lock()
+	defer ut.lock.Unlock()
+
+	usage := make([]TenantUsage, 0, len(ut.tenants))
+	for tenant, ts := range ut.tenants {
+		usageBytes := ut.getUsageBytes(ts, currentSlotIndex)
+		if usageBytes == 0 {
+			continue
+		}
+		usage = append(usage, TenantUsage{
+			Tenant:     tenant,
+			UsageBytes: usageBytes,
+			QuotaBytes: ut.getQuotaBytes(tenant),
+		})
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		return usage[i].Tenant < usage[j].Tenant
+	})
+	return usage
+}
@@ -0,0 +1,72 @@
+package quota_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/buildbarn/bb-storage/internal/mock"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/quota"
+	"github.com/stretchr/testify/require"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestUsageTracker(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	clock := mock.NewMockClock(ctrl)
+	usageTracker := quota.NewUsageTracker(
+		clock,
+		time.Minute,
+		map[string]int64{
+			"team-a": 100,
+			"team-b": 0,
+		},
+		/* defaultQuotaBytes = */ 50)
+
+	// Tenants that have not written any data are not over quota.
+	clock.EXPECT().Now().Return(time.Unix(1000, 0))
+	require.False(t, usageTracker.IsOverQuota("team-a"))
+
+	// Usage should accumulate until the quota is reached.
+	clock.EXPECT().Now().Return(time.Unix(1000, 0))
+	usageTracker.RecordUsage("team-a", 60)
+	clock.EXPECT().Now().Return(time.Unix(1010, 0))
+	require.False(t, usageTracker.IsOverQuota("team-a"))
+	clock.EXPECT().Now().Return(time.Unix(1020, 0))
+	usageTracker.RecordUsage("team-a", 40)
+	clock.EXPECT().Now().Return(time.Unix(1030, 0))
+	require.True(t, usageTracker.IsOverQuota("team-a"))
+
+	// Tenants without an explicit quota should be subject to the
+	// default quota. A quota of zero means the tenant is
+	// unlimited.
+	clock.EXPECT().Now().Return(time.Unix(1030, 0))
+	usageTracker.RecordUsage("team-b", 1000)
+	require.False(t, usageTracker.IsOverQuota("team-b"))
+	clock.EXPECT().Now().Return(time.Unix(1030, 0))
+	usageTracker.RecordUsage("team-c", 50)
+	clock.EXPECT().Now().Return(time.Unix(1030, 0))
+	require.True(t, usageTracker.IsOverQuota("team-c"))
+
+	clock.EXPECT().Now().Return(time.Unix(1030, 0))
+	require.Equal(t, []quota.TenantUsage{
+		{Tenant: "team-a", UsageBytes: 100, QuotaBytes: 100},
+		{Tenant: "team-b", UsageBytes: 1000, QuotaBytes: 0},
+		{Tenant: "team-c", UsageBytes: 50, QuotaBytes: 50},
+	}, usageTracker.GetUsage())
+
+	// Usage should expire once it falls outside the sliding
+	// window.
+	clock.EXPECT().Now().Return(time.Unix(1065, 0))
+	require.False(t, usageTracker.IsOverQuota("team-a"))
+	clock.EXPECT().Now().Return(time.Unix(1065, 0))
+	require.Equal(t, []quota.TenantUsage{
+		{Tenant: "team-a", UsageBytes: 40, QuotaBytes: 100},
+		{Tenant: "team-b", UsageBytes: 1000, QuotaBytes: 0},
+		{Tenant: "team-c", UsageBytes: 50, QuotaBytes: 50},
+	}, usageTracker.GetUsage())
+
+	clock.EXPECT().Now().Return(time.Unix(1100, 0))
+	require.Empty(t, usageTracker.GetUsage())
+}
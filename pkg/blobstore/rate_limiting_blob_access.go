@@ -0,0 +1,339 @@
+package blobstore
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/buildbarn/bb-storage/pkg/auth"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/buffer"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/slicing"
+	"github.com/buildbarn/bb-storage/pkg/clock"
+	"github.com/buildbarn/bb-storage/pkg/digest"
+	"github.com/buildbarn/bb-storage/pkg/eviction"
+	"github.com/buildbarn/bb-storage/pkg/jmespath"
+	"github.com/buildbarn/bb-storage/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+var (
+	rateLimitingBlobAccessPrometheusMetrics sync.Once
+
+	rateLimitingBlobAccessRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "buildbarn",
+			Subsystem: "blobstore",
+			Name:      "rate_limiting_blob_access_requests_total",
+			Help:      "Number of requests processed by the rate limiter, per key for which rate limiting state is tracked",
+		},
+		[]string{"storage_type", "key", "operation", "result"})
+)
+
+// RateLimit of a single operation of RateLimitingBlobAccess. Limits
+// that are zero are not enforced.
+type RateLimit struct {
+	// The number of requests per second, and the number of requests
+	// that may be performed in a burst.
+	RequestsPerSecond float64
+	RequestBurst      int64
+	// The number of bytes per second, and the number of bytes that
+	// may be transferred in a burst. Objects larger than the burst
+	// size are permitted if the bucket is full.
+	BytesPerSecond float64
+	ByteBurst      int64
+}
+
+// RateLimits of all operations of RateLimitingBlobAccess. Operations
+// for which no limits are provided are not rate limited.
+// GetFromComposite() is subject to the limits of Get().
+type RateLimits struct {
+	Get         *RateLimit
+	Put         *RateLimit
+	FindMissing *RateLimit
+}
+
+// tokenBucket implements the token bucket algorithm.
+type tokenBucket struct {
+	tokens     float64
+	lastRefill time.Time
+}
+
+// getDelay refills the bucket and returns the amount of time to wait
+// until the bucket contains the requested number of tokens.
+func (tb *tokenBucket) getDelay(now time.Time, rate float64, burst int64, amount int64) time.Duration {
+	if tb.lastRefill.IsZero() {
+		tb.tokens = float64(burst)
+	} else if elapsed := now.Sub(tb.lastRefill); elapsed > 0 {
+		tb.tokens = math.Min(float64(burst), tb.tokens+elapsed.Seconds()*rate)
+	}
+	tb.lastRefill = now
+
+	if shortage := float64(min(amount, burst)) - tb.tokens; shortage > 0 {
+		return time.Duration(math.Ceil(shortage / rate * float64(time.Second)))
+	}
+	return 0
+}
+
+// isFull returns whether the bucket would be full if it were refilled
+// at a given point in time.
+func (tb *tokenBucket) isFull(now time.Time, rate float64, burst int64) bool {
+	if tb.lastRefill.IsZero() {
+		return true
+	}
+	return tb.tokens+max(now.Sub(tb.lastRefill).Seconds(), 0)*rate >= float64(burst)
+}
+
+func (tb *tokenBucket) take(amount, burst int64) {
+	tb.tokens -= float64(min(amount, burst))
+}
+
+// newDrainedTokenBucket creates a token bucket that is empty, as
+// opposed to a bucket that is full upon first use.
+func newDrainedTokenBucket(now time.Time) tokenBucket {
+	return tokenBucket{lastRefill: now}
+}
+
+type rateLimitingOperation struct {
+	name  string
+	limit *RateLimit
+}
+
+// rateLimitingBuckets contains the token buckets of a single operation
+// for a single key.
+type rateLimitingBuckets struct {
+	requests tokenBucket
+	bytes    tokenBucket
+
+	allowed  prometheus.Counter
+	rejected prometheus.Counter
+}
+
+// rateLimitingKeyState contains the token buckets of all operations
+// for a single key.
+type rateLimitingKeyState struct {
+	operations map[*rateLimitingOperation]*rateLimitingBuckets
+	// Whether state for this key was created by discarding the
+	// state of another key whose buckets were not full. If set,
+	// buckets are created empty.
+	drained bool
+}
+
+// isFull returns whether all buckets of a key would be full if they
+// were refilled at a given point in time. Discarding the state of
+// such keys has no effect, as their buckets would be full upon next
+// use either way.
+func (ks *rateLimitingKeyState) isFull(now time.Time) bool {
+	for operation, buckets := range ks.operations {
+		limit := operation.limit
+		if limit.RequestsPerSecond > 0 && !buckets.requests.isFull(now, limit.RequestsPerSecond, limit.RequestBurst) {
+			return false
+		}
+		if limit.BytesPerSecond > 0 && !buckets.bytes.isFull(now, limit.BytesPerSecond, limit.ByteBurst) {
+			return false
+		}
+	}
+	return true
+}
+
+type rateLimitingBlobAccess struct {
+	BlobAccess
+	clock         clock.Clock
+	keyExpression *jmespath.Expression
+	maximumKeys   int
+	storageType   string
+
+	get         rateLimitingOperation
+	put         rateLimitingOperation
+	findMissing rateLimitingOperation
+
+	lock        sync.Mutex
+	keys        map[string]*rateLimitingKeyState
+	evictionSet eviction.Set[string]
+}
+
+// NewRateLimitingBlobAccess creates a decorator for BlobAccess that
+// limits the rate at which requests may be performed, using token
+// buckets. Requests are grouped by a key that is computed by
+// evaluating a JMESPath expression. The expression is called with a
+// JSON object that includes both the REv2 instance name and
+// authentication metadata, making it possible to limit the rate at
+// which individual users or CI jobs access storage.
+//
+// Separate buckets are used for every operation. When a bucket is
+// empty, requests fail with RESOURCE_EXHAUSTED, with RetryInfo
+// attached indicating when the request may be retried.
+//
+// State is only tracked for a bounded number of keys. When exceeded,
+// the state of the least recently used key is discarded, together with
+// its metrics. Buckets are normally full when first used. To prevent
+// keys from obtaining full buckets by having their state discarded,
+// keys for which state needs to be created by discarding the state of
+// another key start with empty buckets instead. This is only done if
+// the buckets of the discarded key were not full, so that new keys
+// are not penalized when the discarded keys have been idle.
+func NewRateLimitingBlobAccess(base BlobAccess, clock clock.Clock, keyExpression *jmespath.Expression, limits RateLimits, maximumKeys int, storageType string) BlobAccess {
+	rateLimitingBlobAccessPrometheusMetrics.Do(func() {
+		prometheus.MustRegister(rateLimitingBlobAccessRequests)
+	})
+
+	return &rateLimitingBlobAccess{
+		BlobAccess:    base,
+		clock:         clock,
+		keyExpression: keyExpression,
+		maximumKeys:   maximumKeys,
+		storageType:   storageType,
+
+		get:         rateLimitingOperation{name: "Get", limit: limits.Get},
+		put:         rateLimitingOperation{name: "Put", limit: limits.Put},
+		findMissing: rateLimitingOperation{name: "FindMissing", limit: limits.FindMissing},
+
+		keys:        map[string]*rateLimitingKeyState{},
+		evictionSet: eviction.NewLRUSet[string](),
+	}
+}
+
+func (ba *rateLimitingBlobAccess) getKey(ctx context.Context, instanceName digest.InstanceName) (string, error) {
+	result, err := ba.keyExpression.Search(map[string]any{
+		"authenticationMetadata": auth.AuthenticationMetadataFromContext(ctx).GetRaw(),
+		"instanceName":           instanceName.String(),
+	})
+	if err != nil {
+		return "", util.StatusWrapWithCode(err, codes.Internal, "Failed to evaluate rate limiting key expression")
+	}
+	if key, ok := result.(string); ok {
+		return key, nil
+	}
+	key, err := json.Marshal(result)
+	if err != nil {
+		return "", util.StatusWrapWithCode(err, codes.Internal, "Failed to convert rate limiting key to a string")
+	}
+	return string(key), nil
+}
+
+// getBuckets returns the token buckets of an operation for a given
+// key, creating them if needed. This function must be called with the
+// lock held.
+func (ba *rateLimitingBlobAccess) getBuckets(key string, operation *rateLimitingOperation, now time.Time) *rateLimitingBuckets {
+	keyState, ok := ba.keys[key]
+	if ok {
+		ba.evictionSet.Touch(key)
+	} else {
+		drained := false
+		for len(ba.keys) >= ba.maximumKeys {
+			evictedKey := ba.evictionSet.Peek()
+			ba.evictionSet.Remove()
+			if !ba.keys[evictedKey].isFull(now) {
+				drained = true
+			}
+			delete(ba.keys, evictedKey)
+			rateLimitingBlobAccessRequests.DeletePartialMatch(prometheus.Labels{
+				"storage_type": ba.storageType,
+				"key":          evictedKey,
+			})
+		}
+		keyState = &rateLimitingKeyState{
+			operations: map[*rateLimitingOperation]*rateLimitingBuckets{},
+			drained:    drained,
+		}
+		ba.keys[key] = keyState
+		ba.evictionSet.Insert(key)
+	}
+
+	buckets, ok := keyState.operations[operation]
+	if !ok {
+		buckets = &rateLimitingBuckets{
+			allowed:  rateLimitingBlobAccessRequests.WithLabelValues(ba.storageType, key, operation.name, "Allowed"),
+			rejected: rateLimitingBlobAccessRequests.WithLabelValues(ba.storageType, key, operation.name, "Rejected"),
+		}
+		if keyState.drained {
+			buckets.requests = newDrainedTokenBucket(now)
+			buckets.bytes = newDrainedTokenBucket(now)
+		}
+		keyState.operations[operation] = buckets
+	}
+	return buckets
+}
+
+// allow determines whether a request may be performed. Tokens are only
+// taken from the buckets if the request is permitted.
+func (ba *rateLimitingBlobAccess) allow(ctx context.Context, operation *rateLimitingOperation, instanceName digest.InstanceName, sizeBytes int64) error {
+	limit := operation.limit
+	if limit == nil {
+		return nil
+	}
+	key, err := ba.getKey(ctx, instanceName)
+	if err != nil {
+		return err
+	}
+
+	now := ba.clock.Now()
+	ba.lock.Lock()
+	defer ba.lock.Unlock()
+
+	buckets := ba.getBuckets(key, operation, now)
+	var delay time.Duration
+	if limit.RequestsPerSecond > 0 {
+		delay = max(delay, buckets.requests.getDelay(now, limit.RequestsPerSecond, limit.RequestBurst, 1))
+	}
+	if limit.BytesPerSecond > 0 {
+		delay = max(delay, buckets.bytes.getDelay(now, limit.BytesPerSecond, limit.ByteBurst, sizeBytes))
+	}
+	if delay > 0 {
+		buckets.rejected.Inc()
+		s, err := status.Newf(codes.ResourceExhausted, "Rate limit for operation %s exceeded", operation.name).
+			WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(delay)})
+		if err != nil {
+			return util.StatusWrapWithCode(err, codes.Internal, "Failed to attach retry information")
+		}
+		return s.Err()
+	}
+
+	if limit.RequestsPerSecond > 0 {
+		buckets.requests.take(1, limit.RequestBurst)
+	}
+	if limit.BytesPerSecond > 0 {
+		buckets.bytes.take(sizeBytes, limit.ByteBurst)
+	}
+	buckets.allowed.Inc()
+	return nil
+}
+
+func (ba *rateLimitingBlobAccess) Get(ctx context.Context, digest digest.Digest) buffer.Buffer {
+	if err := ba.allow(ctx, &ba.get, digest.GetInstanceName(), digest.GetSizeBytes()); err != nil {
+		return buffer.NewBufferFromError(err)
+	}
+	return ba.BlobAccess.Get(ctx, digest)
+}
+
+func (ba *rateLimitingBlobAccess) GetFromComposite(ctx context.Context, parentDigest, childDigest digest.Digest, slicer slicing.BlobSlicer) buffer.Buffer {
+	if err := ba.allow(ctx, &ba.get, childDigest.GetInstanceName(), childDigest.GetSizeBytes()); err != nil {
+		return buffer.NewBufferFromError(err)
+	}
+	return ba.BlobAccess.GetFromComposite(ctx, parentDigest, childDigest, slicer)
+}
+
+func (ba *rateLimitingBlobAccess) Put(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
+	if err := ba.allow(ctx, &ba.put, digest.GetInstanceName(), digest.GetSizeBytes()); err != nil {
+		b.Discard()
+		return err
+	}
+	return ba.BlobAccess.Put(ctx, digest, b)
+}
+
+func (ba *rateLimitingBlobAccess) FindMissing(ctx context.Context, digests digest.Set) (digest.Set, error) {
+	// All digests in a request share the same instance name, as
+	// FindMissingBlobs() only accepts a single instance name.
+	if firstDigest, ok := digests.First(); ok {
+		if err := ba.allow(ctx, &ba.findMissing, firstDigest.GetInstanceName(), 0); err != nil {
+			return digest.EmptySet, err
+		}
+	}
+	return ba.BlobAccess.FindMissing(ctx, digests)
+}
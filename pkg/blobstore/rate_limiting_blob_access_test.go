@@ -0,0 +1,172 @@
+package blobstore_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	remoteexecution "github.com/bazelbuild/remote-apis/build/bazel/remote/execution/v2"
+	"github.com/buildbarn/bb-storage/internal/mock"
+	"github.com/buildbarn/bb-storage/pkg/auth"
+	"github.com/buildbarn/bb-storage/pkg/blobstore"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/buffer"
+	"github.com/buildbarn/bb-storage/pkg/digest"
+	"github.com/buildbarn/bb-storage/pkg/jmespath"
+	"github.com/buildbarn/bb-storage/pkg/testutil"
+	"github.com/stretchr/testify/require"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"go.uber.org/mock/gomock"
+)
+
+func newRateLimitExceededError(t *testing.T, operation string, retryDelay time.Duration) error {
+	s, err := status.Newf(codes.ResourceExhausted, "Rate limit for operation %s exceeded", operation).
+		WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(retryDelay)})
+	require.NoError(t, err)
+	return s.Err()
+}
+
+func newContextWithUser(t *testing.T, ctx context.Context, user string) context.Context {
+	authenticationMetadata, err := auth.NewAuthenticationMetadataFromRaw(map[string]any{
+		"public": map[string]any{
+			"user": user,
+		},
+	})
+	require.NoError(t, err)
+	return auth.NewContextWithAuthenticationMetadata(ctx, authenticationMetadata)
+}
+
+func TestRateLimitingBlobAccess(t *testing.T) {
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+
+	baseBlobAccess := mock.NewMockBlobAccess(ctrl)
+	clock := mock.NewMockClock(ctrl)
+	blobAccess := blobstore.NewRateLimitingBlobAccess(
+		baseBlobAccess,
+		clock,
+		jmespath.MustCompile("join('|', [authenticationMetadata.public.user, instanceName])"),
+		blobstore.RateLimits{
+			Get: &blobstore.RateLimit{
+				RequestsPerSecond: 2,
+				RequestBurst:      2,
+				BytesPerSecond:    10,
+				ByteBurst:         10,
+			},
+			FindMissing: &blobstore.RateLimit{
+				RequestsPerSecond: 1,
+				RequestBurst:      1,
+			},
+		},
+		/* maximumKeys = */ 3,
+		"cas")
+
+	aliceCtx := newContextWithUser(t, ctx, "alice")
+	bobCtx := newContextWithUser(t, ctx, "bob")
+	helloDigest := digest.MustNewDigest("example", remoteexecution.DigestFunction_MD5, "8b1a9953c4611296a827abf8c47804d7", 5)
+	otherInstanceDigest := digest.MustNewDigest("other", remoteexecution.DigestFunction_MD5, "8b1a9953c4611296a827abf8c47804d7", 5)
+
+	t.Run("RequestsPerSecond", func(t *testing.T) {
+		// Alice should be permitted to perform a burst of two
+		// FindMissing() calls, as long as one second passes
+		// between them.
+		clock.EXPECT().Now().Return(time.Unix(1000, 0))
+		baseBlobAccess.EXPECT().FindMissing(aliceCtx, helloDigest.ToSingletonSet()).Return(digest.EmptySet, nil)
+		_, err := blobAccess.FindMissing(aliceCtx, helloDigest.ToSingletonSet())
+		require.NoError(t, err)
+
+		clock.EXPECT().Now().Return(time.Unix(1000, 250000000))
+		_, err = blobAccess.FindMissing(aliceCtx, helloDigest.ToSingletonSet())
+		testutil.RequireEqualStatus(t, newRateLimitExceededError(t, "FindMissing", 750*time.Millisecond), err)
+
+		clock.EXPECT().Now().Return(time.Unix(1001, 0))
+		baseBlobAccess.EXPECT().FindMissing(aliceCtx, helloDigest.ToSingletonSet()).Return(digest.EmptySet, nil)
+		_, err = blobAccess.FindMissing(aliceCtx, helloDigest.ToSingletonSet())
+		require.NoError(t, err)
+	})
+
+	t.Run("SeparateKeys", func(t *testing.T) {
+		// Requests from other users or for other instance
+		// names should use separate buckets.
+		clock.EXPECT().Now().Return(time.Unix(1001, 0))
+		baseBlobAccess.EXPECT().FindMissing(bobCtx, helloDigest.ToSingletonSet()).Return(digest.EmptySet, nil)
+		_, err := blobAccess.FindMissing(bobCtx, helloDigest.ToSingletonSet())
+		require.NoError(t, err)
+
+		clock.EXPECT().Now().Return(time.Unix(1001, 0))
+		baseBlobAccess.EXPECT().FindMissing(aliceCtx, otherInstanceDigest.ToSingletonSet()).Return(digest.EmptySet, nil)
+		_, err = blobAccess.FindMissing(aliceCtx, otherInstanceDigest.ToSingletonSet())
+		require.NoError(t, err)
+	})
+
+	t.Run("SeparateOperations", func(t *testing.T) {
+		// Every operation should have its own buckets. Put()
+		// has no limits.
+		clock.EXPECT().Now().Return(time.Unix(1001, 0))
+		baseBlobAccess.EXPECT().Get(bobCtx, helloDigest).
+			Return(buffer.NewValidatedBufferFromByteSlice([]byte("Hello")))
+		baseBlobAccess.EXPECT().Put(bobCtx, helloDigest, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
+				b.Discard()
+				return nil
+			})
+
+		data, err := blobAccess.Get(bobCtx, helloDigest).ToByteSlice(100)
+		require.NoError(t, err)
+		require.Equal(t, []byte("Hello"), data)
+		require.NoError(t, blobAccess.Put(bobCtx, helloDigest, buffer.NewValidatedBufferFromByteSlice([]byte("Hello"))))
+	})
+
+	t.Run("BytesPerSecond", func(t *testing.T) {
+		// Bob has already transferred five bytes. Transferring
+		// another ten bytes should only be permitted once the
+		// bucket has been refilled.
+		clock.EXPECT().Now().Return(time.Unix(1001, 0))
+		baseBlobAccess.EXPECT().Get(bobCtx, helloDigest).
+			Return(buffer.NewValidatedBufferFromByteSlice([]byte("Hello")))
+		_, err := blobAccess.Get(bobCtx, helloDigest).ToByteSlice(100)
+		require.NoError(t, err)
+
+		clock.EXPECT().Now().Return(time.Unix(1001, 200000000))
+		_, err = blobAccess.Get(bobCtx, helloDigest).ToByteSlice(100)
+		testutil.RequireEqualStatus(t, newRateLimitExceededError(t, "Get", 300*time.Millisecond), err)
+	})
+
+	t.Run("Eviction", func(t *testing.T) {
+		// State is only tracked for three keys. Creating state
+		// for Carol requires discarding the state of Alice's
+		// first instance name, as it was used least recently.
+		// Carol's buckets should start empty, as the maximum
+		// number of keys has been exceeded.
+		carolCtx := newContextWithUser(t, ctx, "carol")
+		clock.EXPECT().Now().Return(time.Unix(1001, 0))
+		_, err := blobAccess.FindMissing(carolCtx, helloDigest.ToSingletonSet())
+		testutil.RequireEqualStatus(t, newRateLimitExceededError(t, "FindMissing", time.Second), err)
+
+		// Alice's buckets should not become full once again as
+		// a result of its state being discarded.
+		clock.EXPECT().Now().Return(time.Unix(1001, 0))
+		_, err = blobAccess.FindMissing(aliceCtx, helloDigest.ToSingletonSet())
+		testutil.RequireEqualStatus(t, newRateLimitExceededError(t, "FindMissing", time.Second), err)
+
+		clock.EXPECT().Now().Return(time.Unix(1002, 0))
+		baseBlobAccess.EXPECT().FindMissing(aliceCtx, helloDigest.ToSingletonSet()).Return(digest.EmptySet, nil)
+		_, err = blobAccess.FindMissing(aliceCtx, helloDigest.ToSingletonSet())
+		require.NoError(t, err)
+	})
+
+	t.Run("EvictionOfIdleKey", func(t *testing.T) {
+		// Creating state for Dave requires discarding the
+		// state of Bob. As Bob has been idle for a long time,
+		// his buckets would have been full. Dave's buckets
+		// should thus start full as well.
+		daveCtx := newContextWithUser(t, ctx, "dave")
+		clock.EXPECT().Now().Return(time.Unix(1100, 0))
+		baseBlobAccess.EXPECT().FindMissing(daveCtx, helloDigest.ToSingletonSet()).Return(digest.EmptySet, nil)
+		_, err := blobAccess.FindMissing(daveCtx, helloDigest.ToSingletonSet())
+		require.NoError(t, err)
+	})
+}
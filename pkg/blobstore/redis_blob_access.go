@@ -0,0 +1,137 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/buildbarn/bb-storage/pkg/blobstore/buffer"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/slicing"
+	"github.com/buildbarn/bb-storage/pkg/capabilities"
+	"github.com/buildbarn/bb-storage/pkg/digest"
+	"github.com/buildbarn/bb-storage/pkg/util"
+	"github.com/redis/go-redis/v9"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type redisBlobAccess struct {
+	capabilities.Provider
+	client                 redis.UniversalClient
+	readBufferFactory      ReadBufferFactory
+	digestKeyFormat        digest.KeyFormat
+	keyPrefix              string
+	expiration             time.Duration
+	maximumObjectSizeBytes int
+}
+
+// NewRedisBlobAccess creates a BlobAccess that stores objects in a
+// Redis compatible key-value store, which may either be a single
+// server or a cluster. Objects are stored under keys that are obtained
+// by prepending a prefix to the result of Digest.GetKey().
+//
+// As values are held in memory by both the client and the server, this
+// backend is only suitable for storing small objects, such as entries
+// of the Action Cache (AC), Initial Size Class Cache (ISCC) and File
+// System Access Cache (FSAC).
+//
+// If an expiration is provided, the expiration time of keys is
+// extended whenever they are accessed through Get() or FindMissing().
+// This ensures that objects reported as present by FindMissing() do not
+// expire shortly after.
+func NewRedisBlobAccess(capabilitiesProvider capabilities.Provider, client redis.UniversalClient, readBufferFactory ReadBufferFactory, digestKeyFormat digest.KeyFormat, keyPrefix string, expiration time.Duration, maximumObjectSizeBytes int) BlobAccess {
+	return &redisBlobAccess{
+		Provider:               capabilitiesProvider,
+		client:                 client,
+		readBufferFactory:      readBufferFactory,
+		digestKeyFormat:        digestKeyFormat,
+		keyPrefix:              keyPrefix,
+		expiration:             expiration,
+		maximumObjectSizeBytes: maximumObjectSizeBytes,
+	}
+}
+
+func (ba *redisBlobAccess) getKey(blobDigest digest.Digest) string {
+	return ba.keyPrefix + blobDigest.GetKey(ba.digestKeyFormat)
+}
+
+func (ba *redisBlobAccess) Get(ctx context.Context, blobDigest digest.Digest) buffer.Buffer {
+	key := ba.getKey(blobDigest)
+	var command *redis.StringCmd
+	if ba.expiration > 0 {
+		command = ba.client.GetEx(ctx, key, ba.expiration)
+	} else {
+		command = ba.client.Get(ctx, key)
+	}
+	value, err := command.Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return buffer.NewBufferFromError(status.Errorf(codes.NotFound, "Key %#v not found", key))
+		}
+		return buffer.NewBufferFromError(util.StatusWrapf(errToStatus(err), "Failed to get key %#v", key))
+	}
+	return ba.readBufferFactory.NewBufferFromByteSlice(
+		blobDigest,
+		value,
+		func(dataIsValid bool) {
+			// Remove corrupted values, so that they may be
+			// replaced by a subsequent call to Put().
+			if !dataIsValid {
+				ba.client.Del(context.WithoutCancel(ctx), key)
+			}
+		})
+}
+
+func (ba *redisBlobAccess) GetFromComposite(ctx context.Context, parentDigest, childDigest digest.Digest, slicer slicing.BlobSlicer) buffer.Buffer {
+	b, _ := slicer.Slice(ba.Get(ctx, parentDigest), childDigest)
+	return b
+}
+
+func (ba *redisBlobAccess) Put(ctx context.Context, blobDigest digest.Digest, b buffer.Buffer) error {
+	value, err := b.ToByteSlice(ba.maximumObjectSizeBytes)
+	if err != nil {
+		return err
+	}
+	key := ba.getKey(blobDigest)
+	if err := ba.client.Set(ctx, key, value, ba.expiration).Err(); err != nil {
+		return util.StatusWrapf(errToStatus(err), "Failed to set key %#v", key)
+	}
+	return nil
+}
+
+func (ba *redisBlobAccess) FindMissing(ctx context.Context, digests digest.Set) (digest.Set, error) {
+	if digests.Empty() {
+		return digest.EmptySet, nil
+	}
+
+	// Check for the existence of all keys using a single pipeline.
+	// When used against a cluster, the client splits up the
+	// pipeline into one per node. If keys expire, use EXPIRE
+	// instead of EXISTS, so that the expiration time of keys that
+	// are present is extended as well.
+	items := digests.Items()
+	pipeline := ba.client.Pipeline()
+	commands := make([]func() bool, 0, len(items))
+	for _, blobDigest := range items {
+		key := ba.getKey(blobDigest)
+		if ba.expiration > 0 {
+			command := pipeline.Expire(ctx, key, ba.expiration)
+			commands = append(commands, command.Val)
+		} else {
+			command := pipeline.Exists(ctx, key)
+			commands = append(commands, func() bool { return command.Val() != 0 })
+		}
+	}
+	if _, err := pipeline.Exec(ctx); err != nil {
+		return digest.EmptySet, util.StatusWrap(errToStatus(err), "Failed to check for the existence of keys")
+	}
+
+	missing := digest.NewSetBuilder()
+	for i, isPresent := range commands {
+		if !isPresent() {
+			missing.Add(items[i])
+		}
+	}
+	return missing.Build(), nil
+}
@@ -0,0 +1,115 @@
+package blobstore_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	remoteexecution "github.com/bazelbuild/remote-apis/build/bazel/remote/execution/v2"
+	"github.com/buildbarn/bb-storage/pkg/blobstore"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/buffer"
+	"github.com/buildbarn/bb-storage/pkg/digest"
+	"github.com/buildbarn/bb-storage/pkg/testutil"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRedisBlobAccess(t *testing.T) {
+	ctx := context.Background()
+
+	server := miniredis.RunT(t)
+	client := redis.NewUniversalClient(&redis.UniversalOptions{
+		Addrs: []string{server.Addr()},
+	})
+	defer client.Close()
+	blobAccess := blobstore.NewRedisBlobAccess(
+		nil,
+		client,
+		blobstore.CASReadBufferFactory,
+		digest.KeyWithoutInstance,
+		"cas:",
+		time.Hour,
+		100)
+
+	helloDigest := digest.MustNewDigest("hello", remoteexecution.DigestFunction_MD5, "8b1a9953c4611296a827abf8c47804d7", 5)
+	helloKey := "cas:3-8b1a9953c4611296a827abf8c47804d7-5"
+	worldDigest := digest.MustNewDigest("hello", remoteexecution.DigestFunction_MD5, "f5a7924e621e84c9280a9a27e1bcb7f6", 5)
+
+	t.Run("GetNotFound", func(t *testing.T) {
+		_, err := blobAccess.Get(ctx, helloDigest).ToByteSlice(100)
+		testutil.RequireEqualStatus(t, status.Error(codes.NotFound, "Key \"cas:3-8b1a9953c4611296a827abf8c47804d7-5\" not found"), err)
+	})
+
+	t.Run("PutAndGet", func(t *testing.T) {
+		require.NoError(t, blobAccess.Put(ctx, helloDigest, buffer.NewValidatedBufferFromByteSlice([]byte("Hello"))))
+
+		value, err := server.Get(helloKey)
+		require.NoError(t, err)
+		require.Equal(t, "Hello", value)
+		require.Equal(t, time.Hour, server.TTL(helloKey))
+
+		data, err := blobAccess.Get(ctx, helloDigest).ToByteSlice(100)
+		require.NoError(t, err)
+		require.Equal(t, []byte("Hello"), data)
+	})
+
+	t.Run("PutTooLarge", func(t *testing.T) {
+		largeDigest := digest.MustNewDigest("hello", remoteexecution.DigestFunction_MD5, "0f343b0931126a20f133d67c2b018a3b", 1024)
+		testutil.RequireEqualStatus(
+			t,
+			status.Error(codes.InvalidArgument, "Buffer is 1024 bytes in size, while a maximum of 100 bytes is permitted"),
+			blobAccess.Put(ctx, largeDigest, buffer.NewValidatedBufferFromByteSlice(make([]byte, 1024))))
+	})
+
+	t.Run("FindMissing", func(t *testing.T) {
+		missing, err := blobAccess.FindMissing(ctx, digest.NewSetBuilder().Add(helloDigest).Add(worldDigest).Build())
+		require.NoError(t, err)
+		require.Equal(t, worldDigest.ToSingletonSet(), missing)
+
+		missing, err = blobAccess.FindMissing(ctx, digest.EmptySet)
+		require.NoError(t, err)
+		require.Equal(t, digest.EmptySet, missing)
+	})
+
+	t.Run("ExpirationRefresh", func(t *testing.T) {
+		// Accessing keys should extend their expiration time.
+		server.SetTTL(helloKey, time.Minute)
+		_, err := blobAccess.FindMissing(ctx, helloDigest.ToSingletonSet())
+		require.NoError(t, err)
+		require.Equal(t, time.Hour, server.TTL(helloKey))
+
+		server.SetTTL(helloKey, time.Minute)
+		_, err = blobAccess.Get(ctx, helloDigest).ToByteSlice(100)
+		require.NoError(t, err)
+		require.Equal(t, time.Hour, server.TTL(helloKey))
+	})
+
+	t.Run("Expiration", func(t *testing.T) {
+		server.FastForward(2 * time.Hour)
+
+		missing, err := blobAccess.FindMissing(ctx, helloDigest.ToSingletonSet())
+		require.NoError(t, err)
+		require.Equal(t, helloDigest.ToSingletonSet(), missing)
+	})
+
+	t.Run("GetCorrupted", func(t *testing.T) {
+		// Values with invalid contents should be removed, so
+		// that they may be replaced.
+		require.NoError(t, server.Set(helloKey, "Hallo"))
+
+		_, err := blobAccess.Get(ctx, helloDigest).ToByteSlice(100)
+		testutil.RequireEqualStatus(t, status.Error(codes.Internal, "Buffer has checksum d1bf93299de1b68e6d382c893bf1215f, while 8b1a9953c4611296a827abf8c47804d7 was expected"), err)
+		require.False(t, server.Exists(helloKey))
+	})
+
+	t.Run("ServerUnavailable", func(t *testing.T) {
+		server.SetError("LOADING Redis is loading the dataset in memory")
+
+		_, err := blobAccess.FindMissing(ctx, helloDigest.ToSingletonSet())
+		testutil.RequireEqualStatus(t, status.Error(codes.Internal, "Failed to check for the existence of keys: LOADING Redis is loading the dataset in memory"), err)
+	})
+}
@@ -0,0 +1,131 @@
+package replicated
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	remoteexecution "github.com/bazelbuild/remote-apis/build/bazel/remote/execution/v2"
+	"github.com/buildbarn/bb-storage/pkg/blobstore"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/buffer"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/slicing"
+	"github.com/buildbarn/bb-storage/pkg/digest"
+	"github.com/buildbarn/bb-storage/pkg/util"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type peerBlobAccess struct {
+	peers     []blobstore.BlobAccess
+	peerNames []string
+}
+
+// NewPeerBlobAccess creates a BlobAccess that provides read access to
+// all replicas, except the one at a given index. It can be used as the
+// source of the BlobReplicator that is used by ReplicatedBlobAccess to
+// copy objects into that replica.
+//
+// Reads are attempted against the peers in order, until one of them
+// returns the object. Writes are not supported, as BlobReplicator only
+// reads from its source.
+func NewPeerBlobAccess(replicas []blobstore.BlobAccess, index int) blobstore.BlobAccess {
+	ba := &peerBlobAccess{}
+	for i := 1; i < len(replicas); i++ {
+		peer := (index + i) % len(replicas)
+		ba.peers = append(ba.peers, replicas[peer])
+		ba.peerNames = append(ba.peerNames, fmt.Sprintf("Replica %d", peer))
+	}
+	return ba
+}
+
+func (ba *peerBlobAccess) getFromPeers(get func(peer blobstore.BlobAccess) buffer.Buffer) buffer.Buffer {
+	if len(ba.peers) == 0 {
+		return buffer.NewBufferFromError(status.Error(codes.NotFound, "Replica has no peers"))
+	}
+	return buffer.WithErrorHandler(
+		get(ba.peers[0]),
+		&peerErrorHandler{
+			blobAccess: ba,
+			get:        get,
+		})
+}
+
+func (ba *peerBlobAccess) Get(ctx context.Context, digest digest.Digest) buffer.Buffer {
+	return ba.getFromPeers(func(peer blobstore.BlobAccess) buffer.Buffer {
+		return peer.Get(ctx, digest)
+	})
+}
+
+func (ba *peerBlobAccess) GetFromComposite(ctx context.Context, parentDigest, childDigest digest.Digest, slicer slicing.BlobSlicer) buffer.Buffer {
+	return ba.getFromPeers(func(peer blobstore.BlobAccess) buffer.Buffer {
+		return peer.GetFromComposite(ctx, parentDigest, childDigest, slicer)
+	})
+}
+
+func (ba *peerBlobAccess) Put(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
+	b.Discard()
+	return status.Error(codes.Unimplemented, "Peers of a replica cannot be written to")
+}
+
+func (ba *peerBlobAccess) FindMissing(ctx context.Context, digests digest.Set) (digest.Set, error) {
+	// Objects are only missing if they are absent in all peers.
+	var wg sync.WaitGroup
+	missingPerPeer := make([]digest.Set, len(ba.peers))
+	errs := make([]error, len(ba.peers))
+	for i, peer := range ba.peers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			missingPerPeer[i], errs[i] = peer.FindMissing(ctx, digests)
+		}()
+	}
+	wg.Wait()
+
+	missing := digests
+	for i, err := range errs {
+		if err != nil {
+			return digest.EmptySet, util.StatusWrap(err, ba.peerNames[i])
+		}
+		_, missing, _ = digest.GetDifferenceAndIntersection(missing, missingPerPeer[i])
+	}
+	return missing, nil
+}
+
+func (ba *peerBlobAccess) GetCapabilities(ctx context.Context, instanceName digest.InstanceName) (*remoteexecution.ServerCapabilities, error) {
+	if len(ba.peers) == 0 {
+		return nil, status.Error(codes.Unavailable, "Replica has no peers")
+	}
+	capabilities, err := ba.peers[0].GetCapabilities(ctx, instanceName)
+	if err != nil {
+		return nil, util.StatusWrap(err, ba.peerNames[0])
+	}
+	return capabilities, nil
+}
+
+// peerErrorHandler is used by peerBlobAccess to fall back to successive
+// peers if reading an object from a peer fails.
+type peerErrorHandler struct {
+	blobAccess *peerBlobAccess
+	get        func(peer blobstore.BlobAccess) buffer.Buffer
+	index      int
+	firstErr   error
+}
+
+func (eh *peerErrorHandler) OnError(observedErr error) (buffer.Buffer, error) {
+	// Retain the first error other than NOT_FOUND, as it may
+	// indicate that the object is present, but unavailable.
+	if eh.firstErr == nil && status.Code(observedErr) != codes.NotFound {
+		eh.firstErr = util.StatusWrap(observedErr, eh.blobAccess.peerNames[eh.index])
+	}
+	eh.index++
+	if eh.index >= len(eh.blobAccess.peers) {
+		if eh.firstErr != nil {
+			return nil, eh.firstErr
+		}
+		return nil, observedErr
+	}
+	return eh.get(eh.blobAccess.peers[eh.index]), nil
+}
+
+func (eh *peerErrorHandler) Done() {}
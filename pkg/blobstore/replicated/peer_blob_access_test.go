@@ -0,0 +1,75 @@
+package replicated_test
+
+import (
+	"context"
+	"testing"
+
+	remoteexecution "github.com/bazelbuild/remote-apis/build/bazel/remote/execution/v2"
+	"github.com/buildbarn/bb-storage/internal/mock"
+	"github.com/buildbarn/bb-storage/pkg/blobstore"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/buffer"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/replicated"
+	"github.com/buildbarn/bb-storage/pkg/digest"
+	"github.com/buildbarn/bb-storage/pkg/testutil"
+	"github.com/stretchr/testify/require"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestPeerBlobAccess(t *testing.T) {
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+
+	backends := []*mock.MockBlobAccess{
+		mock.NewMockBlobAccess(ctrl),
+		mock.NewMockBlobAccess(ctrl),
+		mock.NewMockBlobAccess(ctrl),
+	}
+	blobAccess := replicated.NewPeerBlobAccess([]blobstore.BlobAccess{backends[0], backends[1], backends[2]}, 1)
+
+	blobDigest := digest.MustNewDigest("default", remoteexecution.DigestFunction_SHA256, "64ec88ca00b268e5ba1a35678a1b5316d212f4f366b2477232534a8aeca37f3c", 11)
+
+	t.Run("GetFallback", func(t *testing.T) {
+		// Peers should be tried in order, starting with the
+		// replica following the excluded one.
+		gomock.InOrder(
+			backends[2].EXPECT().Get(ctx, blobDigest).Return(buffer.NewBufferFromError(status.Error(codes.NotFound, "Object not found"))),
+			backends[0].EXPECT().Get(ctx, blobDigest).Return(buffer.NewValidatedBufferFromByteSlice([]byte("Hello world"))),
+		)
+
+		data, err := blobAccess.Get(ctx, blobDigest).ToByteSlice(100)
+		require.NoError(t, err)
+		require.Equal(t, []byte("Hello world"), data)
+	})
+
+	t.Run("GetNotFound", func(t *testing.T) {
+		backends[2].EXPECT().Get(ctx, blobDigest).Return(buffer.NewBufferFromError(status.Error(codes.NotFound, "Object not found")))
+		backends[0].EXPECT().Get(ctx, blobDigest).Return(buffer.NewBufferFromError(status.Error(codes.NotFound, "Object not found")))
+
+		_, err := blobAccess.Get(ctx, blobDigest).ToByteSlice(100)
+		testutil.RequireEqualStatus(t, status.Error(codes.NotFound, "Object not found"), err)
+	})
+
+	t.Run("GetUnavailable", func(t *testing.T) {
+		// Errors other than NOT_FOUND should take precedence.
+		backends[2].EXPECT().Get(ctx, blobDigest).Return(buffer.NewBufferFromError(status.Error(codes.Unavailable, "Server offline")))
+		backends[0].EXPECT().Get(ctx, blobDigest).Return(buffer.NewBufferFromError(status.Error(codes.NotFound, "Object not found")))
+
+		_, err := blobAccess.Get(ctx, blobDigest).ToByteSlice(100)
+		testutil.RequireEqualStatus(t, status.Error(codes.Unavailable, "Replica 2: Server offline"), err)
+	})
+
+	t.Run("FindMissing", func(t *testing.T) {
+		// Objects are only missing if all peers lack them.
+		otherDigest := digest.MustNewDigest("default", remoteexecution.DigestFunction_SHA256, "185f8db32271fe25f561a6fc938b2e264306ec304eda518007d1764826381969", 5)
+		digests := digest.NewSetBuilder().Add(blobDigest).Add(otherDigest).Build()
+		backends[2].EXPECT().FindMissing(ctx, digests).Return(digests, nil)
+		backends[0].EXPECT().FindMissing(ctx, digests).Return(otherDigest.ToSingletonSet(), nil)
+
+		missing, err := blobAccess.FindMissing(ctx, digests)
+		require.NoError(t, err)
+		require.Equal(t, otherDigest.ToSingletonSet(), missing)
+	})
+}
@@ -0,0 +1,318 @@
+package replicated
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	remoteexecution "github.com/bazelbuild/remote-apis/build/bazel/remote/execution/v2"
+	"github.com/buildbarn/bb-storage/pkg/blobstore"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/buffer"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/replication"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/slicing"
+	"github.com/buildbarn/bb-storage/pkg/digest"
+	"github.com/buildbarn/bb-storage/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	replicatedBlobAccessPrometheusMetrics sync.Once
+
+	replicatedBlobAccessFindMissingSynchronizations = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "buildbarn",
+			Subsystem: "blobstore",
+			Name:      "replicated_blob_access_find_missing_synchronizations",
+			Help:      "Number of blobs synchronized in FindMissing()",
+			Buckets:   append([]float64{0}, prometheus.ExponentialBuckets(1.0, 2.0, 16)...),
+		},
+		[]string{"replica"})
+)
+
+// Replica of ReplicatedBlobAccess.
+type Replica struct {
+	// The backend in which copies of objects are stored.
+	Backend blobstore.BlobAccess
+	// The replication strategy that should be used to copy objects
+	// from other replicas into this replica. The source of this
+	// BlobReplicator should be created using NewPeerBlobAccess().
+	Replicator replication.BlobReplicator
+}
+
+type replica struct {
+	backend          blobstore.BlobAccess
+	name             string
+	replicator       replication.BlobReplicator
+	peerReplicator   replication.BlobReplicator
+	synchronizations prometheus.Observer
+}
+
+type replicatedBlobAccess struct {
+	replicas    []replica
+	writeQuorum int
+	readQuorum  int
+	errorLogger util.ErrorLogger
+	round       atomic.Uint32
+}
+
+type putResult struct {
+	index int
+	err   error
+}
+
+// NewReplicatedBlobAccess creates a BlobAccess that stores copies of
+// objects in an arbitrary number of storage backends. It is a
+// generalization of MirroredBlobAccess, allowing objects to be stored
+// in multiple availability zones.
+//
+// Writes are considered successful if they succeed for at least the
+// write quorum of replicas. Once the write quorum is reached, Put()
+// returns, while writes to the remaining replicas continue in the
+// background. Failures of these writes are logged. As the object is
+// streamed to all replicas simultaneously, a replica that is slow to
+// accept data still delays the other replicas. The write quorum only
+// reduces latency if replicas are slow to complete writes, or fail.
+//
+// FindMissing() only reports objects as being
+// present if they are present in at least the read quorum of replicas.
+// Reads are served by a single replica, falling back to other replicas
+// if the object cannot be obtained. When inconsistencies between
+// replicas are detected, objects are copied into the replicas from
+// which they are absent.
+func NewReplicatedBlobAccess(replicas []Replica, writeQuorum, readQuorum int, errorLogger util.ErrorLogger) (blobstore.BlobAccess, error) {
+	if len(replicas) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "At least one replica must be provided")
+	}
+	if writeQuorum < 1 || writeQuorum > len(replicas) {
+		return nil, status.Errorf(codes.InvalidArgument, "Write quorum must be between 1 and %d", len(replicas))
+	}
+	if readQuorum < 1 || readQuorum > len(replicas) {
+		return nil, status.Errorf(codes.InvalidArgument, "Read quorum must be between 1 and %d", len(replicas))
+	}
+
+	replicatedBlobAccessPrometheusMetrics.Do(func() {
+		prometheus.MustRegister(replicatedBlobAccessFindMissingSynchronizations)
+	})
+
+	backends := make([]blobstore.BlobAccess, 0, len(replicas))
+	for _, r := range replicas {
+		backends = append(backends, r.Backend)
+	}
+	ba := &replicatedBlobAccess{
+		replicas:    make([]replica, 0, len(replicas)),
+		writeQuorum: writeQuorum,
+		readQuorum:  readQuorum,
+		errorLogger: errorLogger,
+	}
+	for i, r := range replicas {
+		ba.replicas = append(ba.replicas, replica{
+			backend:          r.Backend,
+			name:             fmt.Sprintf("Replica %d", i),
+			replicator:       r.Replicator,
+			peerReplicator:   replication.NewNoopBlobReplicator(NewPeerBlobAccess(backends, i)),
+			synchronizations: replicatedBlobAccessFindMissingSynchronizations.WithLabelValues(strconv.FormatInt(int64(i), 10)),
+		})
+	}
+	return ba, nil
+}
+
+func (ba *replicatedBlobAccess) getBlobReplicatorSelector() (blobstore.BlobAccess, replication.BlobReplicatorSelector) {
+	// Alternate requests between replicas.
+	r := &ba.replicas[int(ba.round.Add(1)-1)%len(ba.replicas)]
+	attempted := false
+	var replicaErr error
+	return r.backend, func(observedErr error) (replication.BlobReplicator, error) {
+		if attempted {
+			// Obtaining the object from the other replicas
+			// failed as well. If the object was absent,
+			// prefer returning the error of the replica
+			// that was contacted first, as the object may
+			// still be present there.
+			if replicaErr != nil && status.Code(observedErr) == codes.NotFound {
+				return nil, replicaErr
+			}
+			return nil, observedErr
+		}
+		attempted = true
+
+		if len(ba.replicas) == 1 {
+			if status.Code(observedErr) == codes.NotFound {
+				return nil, observedErr
+			}
+			return nil, util.StatusWrap(observedErr, r.name)
+		}
+		if status.Code(observedErr) == codes.NotFound {
+			// The object is absent. Attempt to copy it
+			// from one of the other replicas, so that this
+			// inconsistency is repaired.
+			return r.replicator, nil
+		}
+
+		// The replica failed for another reason, meaning it
+		// cannot be repaired. Only read the object from the
+		// other replicas.
+		replicaErr = util.StatusWrap(observedErr, r.name)
+		return r.peerReplicator, nil
+	}
+}
+
+func (ba *replicatedBlobAccess) Get(ctx context.Context, digest digest.Digest) buffer.Buffer {
+	firstBackend, successiveBackends := ba.getBlobReplicatorSelector()
+	return replication.GetWithBlobReplicator(ctx, digest, firstBackend, successiveBackends)
+}
+
+func (ba *replicatedBlobAccess) GetFromComposite(ctx context.Context, parentDigest, childDigest digest.Digest, slicer slicing.BlobSlicer) buffer.Buffer {
+	firstBackend, successiveBackends := ba.getBlobReplicatorSelector()
+	return replication.GetFromCompositeWithBlobReplicator(ctx, parentDigest, childDigest, slicer, firstBackend, successiveBackends)
+}
+
+func (ba *replicatedBlobAccess) Put(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
+	// Store the object in all replicas. Unlike errgroup.Group, we
+	// don't cancel writes to other replicas when one of them fails,
+	// as the write quorum may still be reached. Writes may outlive
+	// this call, meaning they should not be cancelled when the
+	// caller's context is.
+	buffers := make([]buffer.Buffer, len(ba.replicas))
+	for i := 0; i < len(ba.replicas)-1; i++ {
+		buffers[i], b = b.CloneStream()
+	}
+	buffers[len(ba.replicas)-1] = b
+
+	putCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	results := make(chan putResult, len(ba.replicas))
+	for i := range ba.replicas {
+		go func() {
+			results <- putResult{
+				index: i,
+				err:   ba.replicas[i].backend.Put(putCtx, digest, buffers[i]),
+			}
+		}()
+	}
+
+	// Wait until the write quorum is reached, or until so many
+	// replicas have failed that it can no longer be reached.
+	errs := make([]error, len(ba.replicas))
+	succeeded, failed := 0, 0
+	for succeeded < ba.writeQuorum && failed <= len(ba.replicas)-ba.writeQuorum {
+		result := <-results
+		if result.err == nil {
+			succeeded++
+		} else {
+			errs[result.index] = util.StatusWrap(result.err, ba.replicas[result.index].name)
+			failed++
+		}
+	}
+	if succeeded < ba.writeQuorum {
+		cancel()
+		for _, err := range errs {
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// The write quorum has been reached. Log failures of other
+	// replicas, and let pending writes complete in the background.
+	for _, err := range errs {
+		if err != nil {
+			ba.errorLogger.Log(util.StatusWrapf(err, "Failed to store blob %#v", digest.String()))
+		}
+	}
+	go func() {
+		for pending := len(ba.replicas) - succeeded - failed; pending > 0; pending-- {
+			if result := <-results; result.err != nil {
+				ba.errorLogger.Log(util.StatusWrapf(util.StatusWrap(result.err, ba.replicas[result.index].name), "Failed to store blob %#v", digest.String()))
+			}
+		}
+		cancel()
+	}()
+	return nil
+}
+
+func (ba *replicatedBlobAccess) FindMissing(ctx context.Context, digests digest.Set) (digest.Set, error) {
+	// Call FindMissing() on all replicas.
+	var wg sync.WaitGroup
+	missingPerReplica := make([]digest.Set, len(ba.replicas))
+	errs := make([]error, len(ba.replicas))
+	for i := range ba.replicas {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			missingPerReplica[i], errs[i] = ba.replicas[i].backend.FindMissing(ctx, digests)
+		}()
+	}
+	wg.Wait()
+
+	// Objects can only be reported as present if a sufficient
+	// number of replicas responded.
+	succeeded := 0
+	var firstErr error
+	for i, err := range errs {
+		if err == nil {
+			succeeded++
+		} else if firstErr == nil {
+			firstErr = util.StatusWrap(err, ba.replicas[i].name)
+		}
+	}
+	if succeeded < ba.readQuorum {
+		return digest.EmptySet, firstErr
+	}
+
+	// Count the number of replicas in which objects are absent.
+	missingCounts := map[digest.Digest]int{}
+	for i, missingFromReplica := range missingPerReplica {
+		if errs[i] == nil {
+			for _, blobDigest := range missingFromReplica.Items() {
+				missingCounts[blobDigest]++
+			}
+		}
+	}
+	missing := digest.NewSetBuilder()
+	for blobDigest, missingCount := range missingCounts {
+		if succeeded-missingCount < ba.readQuorum {
+			missing.Add(blobDigest)
+		}
+	}
+	missingFromQuorum := missing.Build()
+
+	// Copy objects that are present into the replicas from which
+	// they are absent.
+	replicateGroup, replicateCtx := errgroup.WithContext(ctx)
+	for i := range ba.replicas {
+		if errs[i] != nil {
+			continue
+		}
+		r := &ba.replicas[i]
+		toReplicate, _, _ := digest.GetDifferenceAndIntersection(missingPerReplica[i], missingFromQuorum)
+		r.synchronizations.Observe(float64(toReplicate.Length()))
+		replicateGroup.Go(func() error {
+			if err := r.replicator.ReplicateMultiple(replicateCtx, toReplicate); err != nil {
+				if status.Code(err) == codes.NotFound {
+					return util.StatusWrapWithCode(err, codes.Internal, "Replicas returned inconsistent results while synchronizing")
+				}
+				return util.StatusWrapf(err, "Failed to synchronize to replica %d", i)
+			}
+			return nil
+		})
+	}
+	if err := replicateGroup.Wait(); err != nil {
+		return digest.EmptySet, err
+	}
+	return missingFromQuorum, nil
+}
+
+func (ba *replicatedBlobAccess) GetCapabilities(ctx context.Context, instanceName digest.InstanceName) (*remoteexecution.ServerCapabilities, error) {
+	// Alternate requests between replicas.
+	r := &ba.replicas[int(ba.round.Add(1)-1)%len(ba.replicas)]
+	capabilities, err := r.backend.GetCapabilities(ctx, instanceName)
+	if err != nil {
+		return nil, util.StatusWrap(err, r.name)
+	}
+	return capabilities, nil
+}
@@ -0,0 +1,243 @@
+package replicated_test
+
+import (
+	"context"
+	"testing"
+
+	remoteexecution "github.com/bazelbuild/remote-apis/build/bazel/remote/execution/v2"
+	"github.com/buildbarn/bb-storage/internal/mock"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/buffer"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/replicated"
+	"github.com/buildbarn/bb-storage/pkg/digest"
+	"github.com/buildbarn/bb-storage/pkg/testutil"
+	"github.com/stretchr/testify/require"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestReplicatedBlobAccess(t *testing.T) {
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+
+	backends := []*mock.MockBlobAccess{
+		mock.NewMockBlobAccess(ctrl),
+		mock.NewMockBlobAccess(ctrl),
+		mock.NewMockBlobAccess(ctrl),
+	}
+	replicators := []*mock.MockBlobReplicator{
+		mock.NewMockBlobReplicator(ctrl),
+		mock.NewMockBlobReplicator(ctrl),
+		mock.NewMockBlobReplicator(ctrl),
+	}
+	replicas := make([]replicated.Replica, 0, len(backends))
+	for i := range backends {
+		replicas = append(replicas, replicated.Replica{
+			Backend:    backends[i],
+			Replicator: replicators[i],
+		})
+	}
+	errorLogger := mock.NewMockErrorLogger(ctrl)
+	blobAccess, err := replicated.NewReplicatedBlobAccess(replicas, 2, 2, errorLogger)
+	require.NoError(t, err)
+
+	blobDigest := digest.MustNewDigest("default", remoteexecution.DigestFunction_SHA256, "64ec88ca00b268e5ba1a35678a1b5316d212f4f366b2477232534a8aeca37f3c", 11)
+	otherDigest := digest.MustNewDigest("default", remoteexecution.DigestFunction_SHA256, "185f8db32271fe25f561a6fc938b2e264306ec304eda518007d1764826381969", 5)
+
+	t.Run("InvalidQuorum", func(t *testing.T) {
+		_, err := replicated.NewReplicatedBlobAccess(replicas, 4, 2, errorLogger)
+		testutil.RequireEqualStatus(t, status.Error(codes.InvalidArgument, "Write quorum must be between 1 and 3"), err)
+
+		_, err = replicated.NewReplicatedBlobAccess(replicas, 2, 0, errorLogger)
+		testutil.RequireEqualStatus(t, status.Error(codes.InvalidArgument, "Read quorum must be between 1 and 3"), err)
+	})
+
+	t.Run("GetSuccess", func(t *testing.T) {
+		// Requests should alternate between replicas to spread
+		// the load between them equally.
+		gomock.InOrder(
+			backends[0].EXPECT().Get(ctx, blobDigest).Return(buffer.NewValidatedBufferFromByteSlice([]byte("Hello world"))),
+			backends[1].EXPECT().Get(ctx, blobDigest).Return(buffer.NewValidatedBufferFromByteSlice([]byte("Hello world"))),
+			backends[2].EXPECT().Get(ctx, blobDigest).Return(buffer.NewValidatedBufferFromByteSlice([]byte("Hello world"))),
+		)
+
+		for i := 0; i < 3; i++ {
+			data, err := blobAccess.Get(ctx, blobDigest).ToByteSlice(100)
+			require.NoError(t, err)
+			require.Equal(t, []byte("Hello world"), data)
+		}
+	})
+
+	t.Run("GetRepair", func(t *testing.T) {
+		// If the object is absent in the replica, it should be
+		// copied into it from the other replicas.
+		backends[0].EXPECT().Get(ctx, blobDigest).Return(buffer.NewBufferFromError(status.Error(codes.NotFound, "Object not found")))
+		replicators[0].EXPECT().ReplicateSingle(ctx, blobDigest).Return(buffer.NewValidatedBufferFromByteSlice([]byte("Hello world")))
+
+		data, err := blobAccess.Get(ctx, blobDigest).ToByteSlice(100)
+		require.NoError(t, err)
+		require.Equal(t, []byte("Hello world"), data)
+	})
+
+	t.Run("GetNotFound", func(t *testing.T) {
+		backends[1].EXPECT().Get(ctx, blobDigest).Return(buffer.NewBufferFromError(status.Error(codes.NotFound, "Object not found")))
+		replicators[1].EXPECT().ReplicateSingle(ctx, blobDigest).Return(buffer.NewBufferFromError(status.Error(codes.NotFound, "Replica 2: Object not found")))
+
+		_, err := blobAccess.Get(ctx, blobDigest).ToByteSlice(100)
+		testutil.RequireEqualStatus(t, status.Error(codes.NotFound, "Replica 2: Object not found"), err)
+	})
+
+	t.Run("GetUnavailable", func(t *testing.T) {
+		// If the replica is unavailable, it cannot be repaired.
+		// The object should be read from the other replicas
+		// directly.
+		backends[2].EXPECT().Get(ctx, blobDigest).Return(buffer.NewBufferFromError(status.Error(codes.Unavailable, "Server offline")))
+		backends[0].EXPECT().Get(ctx, blobDigest).Return(buffer.NewBufferFromError(status.Error(codes.NotFound, "Object not found")))
+		backends[1].EXPECT().Get(ctx, blobDigest).Return(buffer.NewValidatedBufferFromByteSlice([]byte("Hello world")))
+
+		data, err := blobAccess.Get(ctx, blobDigest).ToByteSlice(100)
+		require.NoError(t, err)
+		require.Equal(t, []byte("Hello world"), data)
+	})
+
+	t.Run("GetUnavailableNotFound", func(t *testing.T) {
+		// If the object is absent in all other replicas, the
+		// error of the unavailable replica should be returned,
+		// as the object may still be present there.
+		backends[0].EXPECT().Get(ctx, blobDigest).Return(buffer.NewBufferFromError(status.Error(codes.Unavailable, "Server offline")))
+		backends[1].EXPECT().Get(ctx, blobDigest).Return(buffer.NewBufferFromError(status.Error(codes.NotFound, "Object not found")))
+		backends[2].EXPECT().Get(ctx, blobDigest).Return(buffer.NewBufferFromError(status.Error(codes.NotFound, "Object not found")))
+
+		_, err := blobAccess.Get(ctx, blobDigest).ToByteSlice(100)
+		testutil.RequireEqualStatus(t, status.Error(codes.Unavailable, "Replica 0: Server offline"), err)
+	})
+
+	t.Run("PutQuorumReached", func(t *testing.T) {
+		// Failures of individual replicas should be logged if
+		// the write quorum is reached.
+		logged := make(chan struct{})
+		errorLogger.EXPECT().Log(testutil.EqStatus(t, status.Error(codes.Unavailable, "Failed to store blob \"1-64ec88ca00b268e5ba1a35678a1b5316d212f4f366b2477232534a8aeca37f3c-11-default\": Replica 1: Server offline"))).
+			Do(func(err error) { close(logged) })
+		for i, backend := range backends {
+			backend.EXPECT().Put(gomock.Any(), blobDigest, gomock.Any()).DoAndReturn(
+				func(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
+					data, err := b.ToByteSlice(100)
+					require.NoError(t, err)
+					require.Equal(t, []byte("Hello world"), data)
+					if i == 1 {
+						return status.Error(codes.Unavailable, "Server offline")
+					}
+					return nil
+				})
+		}
+
+		require.NoError(t, blobAccess.Put(ctx, blobDigest, buffer.NewValidatedBufferFromByteSlice([]byte("Hello world"))))
+		<-logged
+	})
+
+	t.Run("PutReturnsAfterQuorum", func(t *testing.T) {
+		// Put() should return as soon as the write quorum is
+		// reached. Writes to the remaining replicas should
+		// continue in the background, even if the caller's
+		// context is cancelled.
+		putCtx, cancel := context.WithCancel(ctx)
+		release := make(chan struct{})
+		logged := make(chan struct{})
+		for i, backend := range backends {
+			backend.EXPECT().Put(gomock.Any(), blobDigest, gomock.Any()).DoAndReturn(
+				func(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
+					data, err := b.ToByteSlice(100)
+					require.NoError(t, err)
+					require.Equal(t, []byte("Hello world"), data)
+					if i == 2 {
+						<-release
+						require.NoError(t, ctx.Err())
+						return status.Error(codes.Internal, "Disk on fire")
+					}
+					return nil
+				})
+		}
+		errorLogger.EXPECT().Log(testutil.EqStatus(t, status.Error(codes.Internal, "Failed to store blob \"1-64ec88ca00b268e5ba1a35678a1b5316d212f4f366b2477232534a8aeca37f3c-11-default\": Replica 2: Disk on fire"))).
+			Do(func(err error) { close(logged) })
+
+		require.NoError(t, blobAccess.Put(putCtx, blobDigest, buffer.NewValidatedBufferFromByteSlice([]byte("Hello world"))))
+		cancel()
+		close(release)
+		<-logged
+	})
+
+	t.Run("PutQuorumNotReached", func(t *testing.T) {
+		for i, backend := range backends {
+			backend.EXPECT().Put(gomock.Any(), blobDigest, gomock.Any()).DoAndReturn(
+				func(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
+					b.Discard()
+					if i > 0 {
+						return status.Error(codes.Unavailable, "Server offline")
+					}
+					return nil
+				})
+		}
+
+		testutil.RequireEqualStatus(
+			t,
+			status.Error(codes.Unavailable, "Replica 1: Server offline"),
+			blobAccess.Put(ctx, blobDigest, buffer.NewValidatedBufferFromByteSlice([]byte("Hello world"))))
+	})
+
+	t.Run("FindMissingRepair", func(t *testing.T) {
+		// blobDigest is present in two replicas, meaning it
+		// should be reported as present and be copied into the
+		// third. otherDigest is only present in one replica,
+		// meaning it should be reported as missing.
+		digests := digest.NewSetBuilder().Add(blobDigest).Add(otherDigest).Build()
+		backends[0].EXPECT().FindMissing(ctx, digests).Return(otherDigest.ToSingletonSet(), nil)
+		backends[1].EXPECT().FindMissing(ctx, digests).Return(digests, nil)
+		backends[2].EXPECT().FindMissing(ctx, digests).Return(otherDigest.ToSingletonSet(), nil)
+		replicators[0].EXPECT().ReplicateMultiple(gomock.Any(), digest.EmptySet)
+		replicators[1].EXPECT().ReplicateMultiple(gomock.Any(), blobDigest.ToSingletonSet())
+		replicators[2].EXPECT().ReplicateMultiple(gomock.Any(), digest.EmptySet)
+
+		missing, err := blobAccess.FindMissing(ctx, digests)
+		require.NoError(t, err)
+		require.Equal(t, otherDigest.ToSingletonSet(), missing)
+	})
+
+	t.Run("FindMissingReplicaUnavailable", func(t *testing.T) {
+		// Replicas that are unavailable should be ignored, as
+		// long as the read quorum can still be reached.
+		digests := blobDigest.ToSingletonSet()
+		backends[0].EXPECT().FindMissing(ctx, digests).Return(digest.EmptySet, nil)
+		backends[1].EXPECT().FindMissing(ctx, digests).Return(digest.EmptySet, status.Error(codes.Unavailable, "Server offline"))
+		backends[2].EXPECT().FindMissing(ctx, digests).Return(digest.EmptySet, nil)
+		replicators[0].EXPECT().ReplicateMultiple(gomock.Any(), digest.EmptySet)
+		replicators[2].EXPECT().ReplicateMultiple(gomock.Any(), digest.EmptySet)
+
+		missing, err := blobAccess.FindMissing(ctx, digests)
+		require.NoError(t, err)
+		require.Equal(t, digest.EmptySet, missing)
+	})
+
+	t.Run("FindMissingQuorumNotReached", func(t *testing.T) {
+		digests := blobDigest.ToSingletonSet()
+		backends[0].EXPECT().FindMissing(ctx, digests).Return(digest.EmptySet, status.Error(codes.Unavailable, "Server offline"))
+		backends[1].EXPECT().FindMissing(ctx, digests).Return(digest.EmptySet, status.Error(codes.Unavailable, "Server offline"))
+		backends[2].EXPECT().FindMissing(ctx, digests).Return(digest.EmptySet, nil)
+
+		_, err := blobAccess.FindMissing(ctx, digests)
+		testutil.RequireEqualStatus(t, status.Error(codes.Unavailable, "Replica 0: Server offline"), err)
+	})
+
+	t.Run("FindMissingReplicationFailure", func(t *testing.T) {
+		digests := blobDigest.ToSingletonSet()
+		backends[0].EXPECT().FindMissing(ctx, digests).Return(digests, nil)
+		backends[1].EXPECT().FindMissing(ctx, digests).Return(digest.EmptySet, nil)
+		backends[2].EXPECT().FindMissing(ctx, digests).Return(digest.EmptySet, nil)
+		replicators[0].EXPECT().ReplicateMultiple(gomock.Any(), digests).Return(status.Error(codes.NotFound, "Object not found"))
+		replicators[1].EXPECT().ReplicateMultiple(gomock.Any(), digest.EmptySet).AnyTimes()
+		replicators[2].EXPECT().ReplicateMultiple(gomock.Any(), digest.EmptySet).AnyTimes()
+
+		_, err := blobAccess.FindMissing(ctx, digests)
+		testutil.RequireEqualStatus(t, status.Error(codes.Internal, "Replicas returned inconsistent results while synchronizing: Object not found"), err)
+	})
+}
@@ -0,0 +1,267 @@
+package blobstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/buffer"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/slicing"
+	"github.com/buildbarn/bb-storage/pkg/capabilities"
+	cloud_aws "github.com/buildbarn/bb-storage/pkg/cloud/aws"
+	"github.com/buildbarn/bb-storage/pkg/digest"
+	"github.com/buildbarn/bb-storage/pkg/eviction"
+	"github.com/buildbarn/bb-storage/pkg/util"
+
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type s3BlobAccess struct {
+	capabilities.Provider
+	readBufferFactory      ReadBufferFactory
+	digestKeyFormat        digest.KeyFormat
+	s3Client               cloud_aws.S3Client
+	bucket                 string
+	keyPrefix              string
+	partSizeBytes          int64
+	findMissingConcurrency int
+	slices                 *objectSliceLocationCache
+}
+
+// NewS3BlobAccess creates a BlobAccess that stores objects in an S3
+// bucket, or a bucket of an S3 compatible object store such as MinIO.
+// Objects are stored under keys that are obtained by prepending a
+// prefix to the result of Digest.GetKey().
+//
+// Objects that are larger than the part size are uploaded using
+// multipart uploads. Existence of objects is checked by issuing
+// HeadObject requests, of which at most findMissingConcurrency are
+// issued in parallel.
+//
+// When objects are sliced as part of GetFromComposite(), the locations
+// of at most sliceCacheSize slices are retained in memory. This
+// permits subsequent requests for the same child objects to be served
+// using ranged reads.
+func NewS3BlobAccess(capabilitiesProvider capabilities.Provider, readBufferFactory ReadBufferFactory, digestKeyFormat digest.KeyFormat, s3Client cloud_aws.S3Client, bucket, keyPrefix string, partSizeBytes int64, findMissingConcurrency, sliceCacheSize int, sliceEvictionSet eviction.Set[string]) BlobAccess {
+	return &s3BlobAccess{
+		Provider:               capabilitiesProvider,
+		readBufferFactory:      readBufferFactory,
+		digestKeyFormat:        digestKeyFormat,
+		s3Client:               s3Client,
+		bucket:                 bucket,
+		keyPrefix:              keyPrefix,
+		partSizeBytes:          partSizeBytes,
+		findMissingConcurrency: findMissingConcurrency,
+		slices:                 newObjectSliceLocationCache(sliceCacheSize, sliceEvictionSet),
+	}
+}
+
+// s3ErrorToStatus converts an error returned by the S3 client to a
+// gRPC status, translating errors for nonexistent objects to NotFound.
+func s3ErrorToStatus(err error) error {
+	var noSuchKey *types.NoSuchKey
+	var notFound *types.NotFound
+	if errors.As(err, &noSuchKey) || errors.As(err, &notFound) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	return errToStatus(err)
+}
+
+func (ba *s3BlobAccess) getObjectKey(blobDigest digest.Digest) string {
+	return ba.keyPrefix + blobDigest.GetKey(ba.digestKeyFormat)
+}
+
+// getDataIntegrityCallback returns a callback that deletes an object
+// from the bucket in case its contents are corrupted, so that it may
+// be replaced by a subsequent call to Put().
+func (ba *s3BlobAccess) getDataIntegrityCallback(ctx context.Context, key string) buffer.DataIntegrityCallback {
+	return func(dataIsValid bool) {
+		if !dataIsValid {
+			ba.s3Client.DeleteObject(context.WithoutCancel(ctx), &s3.DeleteObjectInput{
+				Bucket: aws.String(ba.bucket),
+				Key:    aws.String(key),
+			})
+		}
+	}
+}
+
+func (ba *s3BlobAccess) Get(ctx context.Context, blobDigest digest.Digest) buffer.Buffer {
+	key := ba.getObjectKey(blobDigest)
+	getObjectOutput, err := ba.s3Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(ba.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return buffer.NewBufferFromError(util.StatusWrapf(s3ErrorToStatus(err), "Failed to get object %#v", key))
+	}
+	return ba.readBufferFactory.NewBufferFromReader(
+		blobDigest,
+		statusReturningReadCloser{r: getObjectOutput.Body},
+		ba.getDataIntegrityCallback(ctx, key))
+}
+
+func (ba *s3BlobAccess) GetFromComposite(ctx context.Context, parentDigest, childDigest digest.Digest, slicer slicing.BlobSlicer) buffer.Buffer {
+	// If the parent object has been sliced before, read the child
+	// object directly by issuing a ranged read.
+	if slice, ok := ba.slices.get(ba.getObjectKey(childDigest)); ok {
+		getObjectOutput, err := ba.s3Client.GetObject(ctx, &s3.GetObjectInput{
+			Bucket: aws.String(ba.bucket),
+			Key:    aws.String(slice.parentKey),
+			Range:  aws.String(fmt.Sprintf("bytes=%d-%d", slice.offsetBytes, slice.offsetBytes+slice.sizeBytes-1)),
+		})
+		if err != nil {
+			return buffer.NewBufferFromError(util.StatusWrapf(s3ErrorToStatus(err), "Failed to get object %#v", slice.parentKey))
+		}
+		return ba.readBufferFactory.NewBufferFromReader(
+			childDigest,
+			statusReturningReadCloser{r: getObjectOutput.Body},
+			ba.getDataIntegrityCallback(ctx, slice.parentKey))
+	}
+
+	// Download and slice the parent object, and retain the
+	// locations of all slices.
+	parentKey := ba.getObjectKey(parentDigest)
+	b, slices := slicer.Slice(ba.Get(ctx, parentDigest), childDigest)
+	ba.slices.insert(parentKey, slices, func(slice slicing.BlobSlice) string {
+		return ba.getObjectKey(slice.Digest)
+	})
+	return b
+}
+
+func (ba *s3BlobAccess) Put(ctx context.Context, blobDigest digest.Digest, b buffer.Buffer) error {
+	key := ba.getObjectKey(blobDigest)
+	sizeBytes, err := b.GetSizeBytes()
+	if err != nil {
+		b.Discard()
+		return err
+	}
+	if sizeBytes > ba.partSizeBytes {
+		return ba.putMultipart(ctx, key, b)
+	}
+
+	data, err := b.ToByteSlice(int(ba.partSizeBytes))
+	if err != nil {
+		return err
+	}
+	if _, err := ba.s3Client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(ba.bucket),
+		Key:           aws.String(key),
+		Body:          bytes.NewReader(data),
+		ContentLength: aws.Int64(int64(len(data))),
+	}); err != nil {
+		return util.StatusWrapf(errToStatus(err), "Failed to put object %#v", key)
+	}
+	return nil
+}
+
+// putMultipart uploads an object that is larger than the part size by
+// using a multipart upload. The upload is only completed if all data
+// has been read from the buffer successfully, meaning that objects
+// with invalid contents are never stored.
+func (ba *s3BlobAccess) putMultipart(ctx context.Context, key string, b buffer.Buffer) error {
+	r := b.ToReader()
+	defer r.Close()
+
+	createMultipartUploadOutput, err := ba.s3Client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket: aws.String(ba.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return util.StatusWrapf(errToStatus(err), "Failed to create multipart upload for object %#v", key)
+	}
+	uploadID := createMultipartUploadOutput.UploadId
+
+	if err := ba.uploadAndCompleteParts(ctx, key, uploadID, r); err != nil {
+		// Attempt to clean up the parts that have been uploaded
+		// so far, even if the request was canceled. Multipart
+		// uploads that are not aborted will continue to consume
+		// space, unless the bucket has a lifecycle rule for
+		// aborting incomplete multipart uploads.
+		ba.s3Client.AbortMultipartUpload(context.WithoutCancel(ctx), &s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(ba.bucket),
+			Key:      aws.String(key),
+			UploadId: uploadID,
+		})
+		return err
+	}
+	return nil
+}
+
+func (ba *s3BlobAccess) uploadAndCompleteParts(ctx context.Context, key string, uploadID *string, r io.Reader) error {
+	var completedParts []types.CompletedPart
+	part := make([]byte, ba.partSizeBytes)
+	for partNumber := int32(1); ; partNumber++ {
+		n, err := io.ReadFull(r, part)
+		if err == io.EOF {
+			break
+		} else if err != nil && err != io.ErrUnexpectedEOF {
+			return err
+		}
+
+		uploadPartOutput, err := ba.s3Client.UploadPart(ctx, &s3.UploadPartInput{
+			Bucket:        aws.String(ba.bucket),
+			Key:           aws.String(key),
+			UploadId:      uploadID,
+			PartNumber:    aws.Int32(partNumber),
+			Body:          bytes.NewReader(part[:n]),
+			ContentLength: aws.Int64(int64(n)),
+		})
+		if err != nil {
+			return util.StatusWrapf(errToStatus(err), "Failed to upload part %d of object %#v", partNumber, key)
+		}
+		completedParts = append(completedParts, types.CompletedPart{
+			ETag:       uploadPartOutput.ETag,
+			PartNumber: aws.Int32(partNumber),
+		})
+	}
+
+	if _, err := ba.s3Client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:   aws.String(ba.bucket),
+		Key:      aws.String(key),
+		UploadId: uploadID,
+		MultipartUpload: &types.CompletedMultipartUpload{
+			Parts: completedParts,
+		},
+	}); err != nil {
+		return util.StatusWrapf(errToStatus(err), "Failed to complete multipart upload for object %#v", key)
+	}
+	return nil
+}
+
+func (ba *s3BlobAccess) FindMissing(ctx context.Context, digests digest.Set) (digest.Set, error) {
+	var missingLock sync.Mutex
+	missing := digest.NewSetBuilder()
+
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(ba.findMissingConcurrency)
+	for _, blobDigest := range digests.Items() {
+		group.Go(func() error {
+			key := ba.getObjectKey(blobDigest)
+			if _, err := ba.s3Client.HeadObject(groupCtx, &s3.HeadObjectInput{
+				Bucket: aws.String(ba.bucket),
+				Key:    aws.String(key),
+			}); err != nil {
+				err = s3ErrorToStatus(err)
+				if status.Code(err) != codes.NotFound {
+					return util.StatusWrapf(err, "Failed to obtain properties of object %#v", key)
+				}
+				missingLock.Lock()
+				missing.Add(blobDigest)
+				missingLock.Unlock()
+			}
+			return nil
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return digest.EmptySet, err
+	}
+	return missing.Build(), nil
+}
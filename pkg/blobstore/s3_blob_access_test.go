@@ -0,0 +1,225 @@
+package blobstore_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	remoteexecution "github.com/bazelbuild/remote-apis/build/bazel/remote/execution/v2"
+	"github.com/buildbarn/bb-storage/internal/mock"
+	"github.com/buildbarn/bb-storage/pkg/blobstore"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/buffer"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/slicing"
+	"github.com/buildbarn/bb-storage/pkg/digest"
+	"github.com/buildbarn/bb-storage/pkg/eviction"
+	"github.com/buildbarn/bb-storage/pkg/testutil"
+	"github.com/stretchr/testify/require"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestS3BlobAccess(t *testing.T) {
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+
+	s3Client := mock.NewMockS3Client(ctrl)
+	blobAccess := blobstore.NewS3BlobAccess(
+		nil,
+		blobstore.CASReadBufferFactory,
+		digest.KeyWithoutInstance,
+		s3Client,
+		"mybucket",
+		"cas/",
+		8,
+		2,
+		10,
+		eviction.NewLRUSet[string]())
+
+	helloDigest := digest.MustNewDigest("hello", remoteexecution.DigestFunction_MD5, "8b1a9953c4611296a827abf8c47804d7", 5)
+	helloKey := "cas/3-8b1a9953c4611296a827abf8c47804d7-5"
+
+	t.Run("GetNotFound", func(t *testing.T) {
+		s3Client.EXPECT().GetObject(ctx, &s3.GetObjectInput{
+			Bucket: aws.String("mybucket"),
+			Key:    aws.String(helloKey),
+		}).Return(nil, &types.NoSuchKey{Message: aws.String("The specified key does not exist.")})
+
+		_, err := blobAccess.Get(ctx, helloDigest).ToByteSlice(100)
+		testutil.RequireEqualStatus(t, status.Error(codes.NotFound, "Failed to get object \"cas/3-8b1a9953c4611296a827abf8c47804d7-5\": NoSuchKey: The specified key does not exist."), err)
+	})
+
+	t.Run("GetSuccess", func(t *testing.T) {
+		s3Client.EXPECT().GetObject(ctx, &s3.GetObjectInput{
+			Bucket: aws.String("mybucket"),
+			Key:    aws.String(helloKey),
+		}).Return(&s3.GetObjectOutput{
+			Body: io.NopCloser(bytes.NewBufferString("Hello")),
+		}, nil)
+
+		data, err := blobAccess.Get(ctx, helloDigest).ToByteSlice(100)
+		require.NoError(t, err)
+		require.Equal(t, []byte("Hello"), data)
+	})
+
+	t.Run("GetCorrupted", func(t *testing.T) {
+		// Objects with invalid contents should be removed, so
+		// that they may be replaced.
+		s3Client.EXPECT().GetObject(ctx, &s3.GetObjectInput{
+			Bucket: aws.String("mybucket"),
+			Key:    aws.String(helloKey),
+		}).Return(&s3.GetObjectOutput{
+			Body: io.NopCloser(bytes.NewBufferString("Hallo")),
+		}, nil)
+		s3Client.EXPECT().DeleteObject(gomock.Any(), &s3.DeleteObjectInput{
+			Bucket: aws.String("mybucket"),
+			Key:    aws.String(helloKey),
+		})
+
+		_, err := blobAccess.Get(ctx, helloDigest).ToByteSlice(100)
+		testutil.RequireEqualStatus(t, status.Error(codes.Internal, "Buffer has checksum d1bf93299de1b68e6d382c893bf1215f, while 8b1a9953c4611296a827abf8c47804d7 was expected"), err)
+	})
+
+	t.Run("PutSinglePart", func(t *testing.T) {
+		s3Client.EXPECT().PutObject(ctx, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+				require.Equal(t, "mybucket", *params.Bucket)
+				require.Equal(t, helloKey, *params.Key)
+				require.Equal(t, int64(5), *params.ContentLength)
+				data, err := io.ReadAll(params.Body)
+				require.NoError(t, err)
+				require.Equal(t, []byte("Hello"), data)
+				return &s3.PutObjectOutput{}, nil
+			})
+
+		require.NoError(t, blobAccess.Put(ctx, helloDigest, buffer.NewValidatedBufferFromByteSlice([]byte("Hello"))))
+	})
+
+	helloHelloDigest := digest.MustNewDigest("hello", remoteexecution.DigestFunction_MD5, "91db2d4279a42766759cfa87e9d633b4", 10)
+	helloHelloKey := "cas/3-91db2d4279a42766759cfa87e9d633b4-10"
+
+	t.Run("PutMultipartSuccess", func(t *testing.T) {
+		s3Client.EXPECT().CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+			Bucket: aws.String("mybucket"),
+			Key:    aws.String(helloHelloKey),
+		}).Return(&s3.CreateMultipartUploadOutput{
+			UploadId: aws.String("upload1"),
+		}, nil)
+		for i, part := range []string{"HelloHel", "lo"} {
+			partNumber := int32(i + 1)
+			etag := part
+			s3Client.EXPECT().UploadPart(ctx, gomock.Any()).DoAndReturn(
+				func(ctx context.Context, params *s3.UploadPartInput, optFns ...func(*s3.Options)) (*s3.UploadPartOutput, error) {
+					require.Equal(t, "upload1", *params.UploadId)
+					require.Equal(t, partNumber, *params.PartNumber)
+					data, err := io.ReadAll(params.Body)
+					require.NoError(t, err)
+					require.Equal(t, []byte(part), data)
+					return &s3.UploadPartOutput{ETag: aws.String(etag)}, nil
+				})
+		}
+		s3Client.EXPECT().CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+			Bucket:   aws.String("mybucket"),
+			Key:      aws.String(helloHelloKey),
+			UploadId: aws.String("upload1"),
+			MultipartUpload: &types.CompletedMultipartUpload{
+				Parts: []types.CompletedPart{
+					{ETag: aws.String("HelloHel"), PartNumber: aws.Int32(1)},
+					{ETag: aws.String("lo"), PartNumber: aws.Int32(2)},
+				},
+			},
+		}).Return(&s3.CompleteMultipartUploadOutput{}, nil)
+
+		require.NoError(t, blobAccess.Put(ctx, helloHelloDigest, buffer.NewValidatedBufferFromByteSlice([]byte("HelloHello"))))
+	})
+
+	t.Run("PutMultipartCorrupted", func(t *testing.T) {
+		// If the data to be uploaded turns out to be corrupted,
+		// the multipart upload should be aborted.
+		s3Client.EXPECT().CreateMultipartUpload(ctx, gomock.Any()).Return(&s3.CreateMultipartUploadOutput{
+			UploadId: aws.String("upload2"),
+		}, nil)
+		s3Client.EXPECT().UploadPart(ctx, gomock.Any()).Return(&s3.UploadPartOutput{ETag: aws.String("etag")}, nil)
+		s3Client.EXPECT().AbortMultipartUpload(gomock.Any(), &s3.AbortMultipartUploadInput{
+			Bucket:   aws.String("mybucket"),
+			Key:      aws.String(helloHelloKey),
+			UploadId: aws.String("upload2"),
+		}).Return(&s3.AbortMultipartUploadOutput{}, nil)
+
+		testutil.RequireEqualStatus(
+			t,
+			status.Error(codes.InvalidArgument, "Buffer has checksum 68e109f0f40ca72a15e05cc22786f8e6, while 91db2d4279a42766759cfa87e9d633b4 was expected"),
+			blobAccess.Put(ctx, helloHelloDigest, buffer.NewCASBufferFromReader(helloHelloDigest, io.NopCloser(bytes.NewBufferString("HelloWorld")), buffer.UserProvided)))
+	})
+
+	t.Run("FindMissing", func(t *testing.T) {
+		s3Client.EXPECT().HeadObject(gomock.Any(), &s3.HeadObjectInput{
+			Bucket: aws.String("mybucket"),
+			Key:    aws.String(helloKey),
+		}).Return(&s3.HeadObjectOutput{}, nil)
+		s3Client.EXPECT().HeadObject(gomock.Any(), &s3.HeadObjectInput{
+			Bucket: aws.String("mybucket"),
+			Key:    aws.String(helloHelloKey),
+		}).Return(nil, &types.NotFound{})
+
+		missing, err := blobAccess.FindMissing(ctx, digest.NewSetBuilder().Add(helloDigest).Add(helloHelloDigest).Build())
+		require.NoError(t, err)
+		require.Equal(t, helloHelloDigest.ToSingletonSet(), missing)
+	})
+
+	t.Run("FindMissingFailure", func(t *testing.T) {
+		s3Client.EXPECT().HeadObject(gomock.Any(), &s3.HeadObjectInput{
+			Bucket: aws.String("mybucket"),
+			Key:    aws.String(helloKey),
+		}).Return(nil, errors.New("connection refused"))
+
+		_, err := blobAccess.FindMissing(ctx, helloDigest.ToSingletonSet())
+		testutil.RequireEqualStatus(t, status.Error(codes.Internal, "Failed to obtain properties of object \"cas/3-8b1a9953c4611296a827abf8c47804d7-5\": connection refused"), err)
+	})
+
+	t.Run("GetFromComposite", func(t *testing.T) {
+		// The first time a child object is requested, the parent
+		// object needs to be downloaded and sliced.
+		worldDigest := digest.MustNewDigest("hello", remoteexecution.DigestFunction_MD5, "f5a7924e621e84c9280a9a27e1bcb7f6", 5)
+		helloWorldDigest := digest.MustNewDigest("hello", remoteexecution.DigestFunction_MD5, "68e109f0f40ca72a15e05cc22786f8e6", 10)
+		s3Client.EXPECT().GetObject(ctx, &s3.GetObjectInput{
+			Bucket: aws.String("mybucket"),
+			Key:    aws.String("cas/3-68e109f0f40ca72a15e05cc22786f8e6-10"),
+		}).Return(&s3.GetObjectOutput{
+			Body: io.NopCloser(bytes.NewBufferString("HelloWorld")),
+		}, nil)
+		slicer := mock.NewMockBlobSlicer(ctrl)
+		slicer.EXPECT().Slice(gomock.Any(), worldDigest).DoAndReturn(
+			func(b buffer.Buffer, childDigest digest.Digest) (buffer.Buffer, []slicing.BlobSlice) {
+				b.Discard()
+				return buffer.NewValidatedBufferFromByteSlice([]byte("World")), []slicing.BlobSlice{
+					{Digest: helloDigest, OffsetBytes: 0, SizeBytes: 5},
+					{Digest: worldDigest, OffsetBytes: 5, SizeBytes: 5},
+				}
+			})
+
+		data, err := blobAccess.GetFromComposite(ctx, helloWorldDigest, worldDigest, slicer).ToByteSlice(100)
+		require.NoError(t, err)
+		require.Equal(t, []byte("World"), data)
+
+		// Subsequent requests for children of the same parent
+		// object should be served using ranged reads.
+		s3Client.EXPECT().GetObject(ctx, &s3.GetObjectInput{
+			Bucket: aws.String("mybucket"),
+			Key:    aws.String("cas/3-68e109f0f40ca72a15e05cc22786f8e6-10"),
+			Range:  aws.String("bytes=0-4"),
+		}).Return(&s3.GetObjectOutput{
+			Body: io.NopCloser(bytes.NewBufferString("Hello")),
+		}, nil)
+
+		data, err = blobAccess.GetFromComposite(ctx, helloWorldDigest, helloDigest, slicer).ToByteSlice(100)
+		require.NoError(t, err)
+		require.Equal(t, []byte("Hello"), data)
+	})
+}
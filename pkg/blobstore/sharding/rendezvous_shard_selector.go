@@ -153,3 +153,31 @@ func (s *rendezvousShardSelector) GetShard(hash uint64) int {
 	}
 	return bestIndex
 }
+
+func (s *rendezvousShardSelector) GetShards(hash uint64, count int) []int {
+	type scoredShard struct {
+		score uint64
+		index int
+	}
+	scoredShards := make([]scoredShard, 0, len(s.shards))
+	for _, shard := range s.shards {
+		scoredShards = append(scoredShards, scoredShard{
+			score: score(splitmix64(shard.hash^hash), shard.weight),
+			index: shard.index,
+		})
+	}
+	// Use a stable sort, so that ties are broken in the same way
+	// as GetShard() does.
+	sort.SliceStable(scoredShards, func(i, j int) bool {
+		return scoredShards[i].score > scoredShards[j].score
+	})
+
+	if count > len(scoredShards) {
+		count = len(scoredShards)
+	}
+	indices := make([]int, 0, count)
+	for _, shard := range scoredShards[:count] {
+		indices = append(indices, shard.index)
+	}
+	return indices
+}
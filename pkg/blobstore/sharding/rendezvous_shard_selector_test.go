@@ -91,6 +91,33 @@ func TestRendezvousShardSelectorDistribution(t *testing.T) {
 	})
 }
 
+func TestRendezvousShardSelectorGetShards(t *testing.T) {
+	s, err := sharding.NewRendezvousShardSelector([]sharding.Shard{
+		{Key: "a", Weight: 1},
+		{Key: "b", Weight: 2},
+		{Key: "c", Weight: 4},
+		{Key: "d", Weight: 7},
+		{Key: "e", Weight: 1},
+	})
+	require.NoError(t, err)
+
+	for i := uint64(0); i < 1000; i++ {
+		// The first shard should be identical to the one
+		// returned by GetShard(), and all shards should be
+		// distinct.
+		shards := s.GetShards(i, 3)
+		require.Len(t, shards, 3)
+		require.Equal(t, s.GetShard(i), shards[0])
+		require.NotEqual(t, shards[0], shards[1])
+		require.NotEqual(t, shards[0], shards[2])
+		require.NotEqual(t, shards[1], shards[2])
+
+		// Requesting more shards than available should return
+		// all shards.
+		require.Len(t, s.GetShards(i, 10), 5)
+	}
+}
+
 func BenchmarkRendezvousShardSelector(b *testing.B) {
 	SHARD_COUNT := 1000
 	weights := make([]sharding.Shard, 0, SHARD_COUNT)
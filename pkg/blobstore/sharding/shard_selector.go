@@ -9,6 +9,11 @@ package sharding
 // architecture.
 type ShardSelector interface {
 	GetShard(hash uint64) int
+	// GetShards returns the indices of up to count distinct shards
+	// for a hash, ordered by preference. The first index is equal
+	// to the one returned by GetShard(). This is used to store
+	// multiple replicas of objects.
+	GetShards(hash uint64, count int) []int
 }
 
 // Shard is a description of a shard. The shard selector will resolve to the
@@ -3,21 +3,27 @@ package sharding
 import (
 	"context"
 	"encoding/binary"
+	"sync"
 	"sync/atomic"
 
 	remoteexecution "github.com/bazelbuild/remote-apis/build/bazel/remote/execution/v2"
 	"github.com/buildbarn/bb-storage/pkg/blobstore"
 	"github.com/buildbarn/bb-storage/pkg/blobstore/buffer"
+	"github.com/buildbarn/bb-storage/pkg/blobstore/replication"
 	"github.com/buildbarn/bb-storage/pkg/blobstore/slicing"
 	"github.com/buildbarn/bb-storage/pkg/digest"
 	"github.com/buildbarn/bb-storage/pkg/util"
 
 	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type shardingBlobAccess struct {
 	backends             []ShardBackend
 	shardSelector        ShardSelector
+	replicationFactor    int
+	readRepair           bool
 	getCapabilitiesRound atomic.Uint64
 }
 
@@ -31,40 +37,100 @@ type ShardBackend struct {
 // NewShardingBlobAccess is an adapter for BlobAccess that partitions
 // requests across backends by hashing the digest. A ShardSelector is
 // used to map hashes to backends.
-func NewShardingBlobAccess(backends []ShardBackend, shardSelector ShardSelector) blobstore.BlobAccess {
+//
+// If the replication factor is greater than one, objects are stored in
+// multiple shards, so that they remain available if a shard is lost.
+// Writes succeed if they succeed for at least one of the shards. Reads
+// fall back to successive shards if the object cannot be obtained. If
+// read repair is enabled, objects that are absent in the preferred
+// shard are copied into it when read from another shard. FindMissing()
+// only reports objects as missing if they are absent in all shards.
+func NewShardingBlobAccess(backends []ShardBackend, shardSelector ShardSelector, replicationFactor int, readRepair bool) blobstore.BlobAccess {
 	return &shardingBlobAccess{
-		backends:      backends,
-		shardSelector: shardSelector,
+		backends:          backends,
+		shardSelector:     shardSelector,
+		replicationFactor: replicationFactor,
+		readRepair:        readRepair,
 	}
 }
 
-func (ba *shardingBlobAccess) getBackendIndexByDigest(blobDigest digest.Digest) int {
+func (ba *shardingBlobAccess) getBackendIndicesByDigest(blobDigest digest.Digest) []int {
 	// Use the first 8 bytes of the digest hash for calculating backend.
 	hb := blobDigest.GetHashBytes()
 	h := binary.BigEndian.Uint64(hb[:8])
-	return ba.shardSelector.GetShard(h)
+	if ba.replicationFactor <= 1 {
+		return []int{ba.shardSelector.GetShard(h)}
+	}
+	return ba.shardSelector.GetShards(h, ba.replicationFactor)
 }
 
 func (ba *shardingBlobAccess) Get(ctx context.Context, digest digest.Digest) buffer.Buffer {
-	index := ba.getBackendIndexByDigest(digest)
+	indices := ba.getBackendIndicesByDigest(digest)
 	return buffer.WithErrorHandler(
-		ba.backends[index].Backend.Get(ctx, digest),
-		shardKeyAddingErrorHandler{key: ba.backends[index].Key})
+		ba.backends[indices[0]].Backend.Get(ctx, digest),
+		&shardFallbackErrorHandler{
+			blobAccess: ba,
+			indices:    indices,
+			get: func(backend blobstore.BlobAccess) buffer.Buffer {
+				return backend.Get(ctx, digest)
+			},
+			replicate: func(replicator replication.BlobReplicator) buffer.Buffer {
+				return replicator.ReplicateSingle(ctx, digest)
+			},
+		})
 }
 
 func (ba *shardingBlobAccess) GetFromComposite(ctx context.Context, parentDigest, childDigest digest.Digest, slicer slicing.BlobSlicer) buffer.Buffer {
-	index := ba.getBackendIndexByDigest(parentDigest)
+	indices := ba.getBackendIndicesByDigest(parentDigest)
 	return buffer.WithErrorHandler(
-		ba.backends[index].Backend.GetFromComposite(ctx, parentDigest, childDigest, slicer),
-		shardKeyAddingErrorHandler{key: ba.backends[index].Key})
+		ba.backends[indices[0]].Backend.GetFromComposite(ctx, parentDigest, childDigest, slicer),
+		&shardFallbackErrorHandler{
+			blobAccess: ba,
+			indices:    indices,
+			get: func(backend blobstore.BlobAccess) buffer.Buffer {
+				return backend.GetFromComposite(ctx, parentDigest, childDigest, slicer)
+			},
+			replicate: func(replicator replication.BlobReplicator) buffer.Buffer {
+				return replicator.ReplicateComposite(ctx, parentDigest, childDigest, slicer)
+			},
+		})
 }
 
 func (ba *shardingBlobAccess) Put(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
-	index := ba.getBackendIndexByDigest(digest)
-	if err := ba.backends[index].Backend.Put(ctx, digest, b); err != nil {
-		return util.StatusWrapf(err, "Shard %s", ba.backends[index].Key)
+	indices := ba.getBackendIndicesByDigest(digest)
+	if len(indices) == 1 {
+		if err := ba.backends[indices[0]].Backend.Put(ctx, digest, b); err != nil {
+			return util.StatusWrapf(err, "Shard %s", ba.backends[indices[0]].Key)
+		}
+		return nil
+	}
+
+	// Store the object in all shards. Writes to other shards are
+	// not canceled if one of them fails, as it is sufficient for
+	// the object to be stored in at least one of the shards.
+	buffers := make([]buffer.Buffer, len(indices))
+	for i := 0; i < len(indices)-1; i++ {
+		buffers[i], b = b.CloneStream()
+	}
+	buffers[len(indices)-1] = b
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(indices))
+	for i, index := range indices {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = ba.backends[index].Backend.Put(ctx, digest, buffers[i])
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err == nil {
+			return nil
+		}
 	}
-	return nil
+	return util.StatusWrapf(errs[0], "Shard %s", ba.backends[indices[0]].Key)
 }
 
 func (ba *shardingBlobAccess) FindMissing(ctx context.Context, digests digest.Set) (digest.Set, error) {
@@ -73,10 +139,75 @@ func (ba *shardingBlobAccess) FindMissing(ctx context.Context, digests digest.Se
 	for range ba.backends {
 		digestsPerBackend = append(digestsPerBackend, digest.NewSetBuilder())
 	}
+	if ba.replicationFactor <= 1 {
+		for _, blobDigest := range digests.Items() {
+			digestsPerBackend[ba.getBackendIndicesByDigest(blobDigest)[0]].Add(blobDigest)
+		}
+		return ba.findMissingUnreplicated(ctx, digestsPerBackend)
+	}
+
+	indicesPerDigest := make(map[digest.Digest][]int, digests.Length())
+	for _, blobDigest := range digests.Items() {
+		indices := ba.getBackendIndicesByDigest(blobDigest)
+		indicesPerDigest[blobDigest] = indices
+		for _, index := range indices {
+			digestsPerBackend[index].Add(blobDigest)
+		}
+	}
+
+	// Asynchronously call FindMissing() on backends. Failures of
+	// individual backends are tolerated, as long as every object
+	// is stored in at least one backend that responded.
+	var wg sync.WaitGroup
+	missingPerBackend := make([]map[digest.Digest]struct{}, len(ba.backends))
+	errs := make([]error, len(ba.backends))
+	for index, digests := range digestsPerBackend {
+		if digests.Length() > 0 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				missing, err := ba.backends[index].Backend.FindMissing(ctx, digests.Build())
+				if err != nil {
+					errs[index] = util.StatusWrapf(err, "Shard %s", ba.backends[index].Key)
+					return
+				}
+				missingPerBackend[index] = make(map[digest.Digest]struct{}, missing.Length())
+				for _, blobDigest := range missing.Items() {
+					missingPerBackend[index][blobDigest] = struct{}{}
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	// Objects are only missing if they are absent in all shards.
+	missing := digest.NewSetBuilder()
 	for _, blobDigest := range digests.Items() {
-		digestsPerBackend[ba.getBackendIndexByDigest(blobDigest)].Add(blobDigest)
+		responded, present := false, false
+		var firstErr error
+		for _, index := range indicesPerDigest[blobDigest] {
+			if err := errs[index]; err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+			responded = true
+			if _, ok := missingPerBackend[index][blobDigest]; !ok {
+				present = true
+			}
+		}
+		if !responded {
+			return digest.EmptySet, firstErr
+		}
+		if !present {
+			missing.Add(blobDigest)
+		}
 	}
+	return missing.Build(), nil
+}
 
+func (ba *shardingBlobAccess) findMissingUnreplicated(ctx context.Context, digestsPerBackend []digest.SetBuilder) (digest.Set, error) {
 	// Asynchronously call FindMissing() on backends.
 	missingPerBackend := make([]digest.Set, 0, len(ba.backends))
 	group, ctxWithCancel := errgroup.WithContext(ctx)
@@ -113,12 +244,45 @@ func (ba *shardingBlobAccess) GetCapabilities(ctx context.Context, instanceName
 	return capabilities, nil
 }
 
-type shardKeyAddingErrorHandler struct {
-	key string
+// shardFallbackErrorHandler is used by ShardingBlobAccess to read
+// objects from successive shards in case the object cannot be obtained
+// from the preferred shard. If the object is absent in the preferred
+// shard and read repair is enabled, the object is copied into it.
+type shardFallbackErrorHandler struct {
+	blobAccess  *shardingBlobAccess
+	indices     []int
+	current     int
+	firstErr    error
+	notFoundErr error
+	get         func(backend blobstore.BlobAccess) buffer.Buffer
+	replicate   func(replicator replication.BlobReplicator) buffer.Buffer
 }
 
-func (eh shardKeyAddingErrorHandler) OnError(err error) (buffer.Buffer, error) {
-	return nil, util.StatusWrapf(err, "Shard %s", eh.key)
+func (eh *shardFallbackErrorHandler) OnError(observedErr error) (buffer.Buffer, error) {
+	// Retain the first error other than NOT_FOUND, as it may
+	// indicate that the object is present, but unavailable.
+	backends := eh.blobAccess.backends
+	wrappedErr := util.StatusWrapf(observedErr, "Shard %s", backends[eh.indices[eh.current]].Key)
+	if status.Code(observedErr) == codes.NotFound {
+		if eh.notFoundErr == nil {
+			eh.notFoundErr = wrappedErr
+		}
+	} else if eh.firstErr == nil {
+		eh.firstErr = wrappedErr
+	}
+
+	eh.current++
+	if eh.current >= len(eh.indices) {
+		if eh.firstErr != nil {
+			return nil, eh.firstErr
+		}
+		return nil, eh.notFoundErr
+	}
+	next := backends[eh.indices[eh.current]].Backend
+	if eh.current == 1 && eh.blobAccess.readRepair && status.Code(observedErr) == codes.NotFound {
+		return eh.replicate(replication.NewLocalBlobReplicator(next, backends[eh.indices[0]].Backend)), nil
+	}
+	return eh.get(next), nil
 }
 
-func (eh shardKeyAddingErrorHandler) Done() {}
+func (eh *shardFallbackErrorHandler) Done() {}
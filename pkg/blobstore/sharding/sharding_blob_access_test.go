@@ -37,6 +37,8 @@ func TestShardingBlobAccess(t *testing.T) {
 			},
 		},
 		shardSelector,
+		1,
+		false,
 	)
 
 	helloDigest := digest.MustNewDigest("example", remoteexecution.DigestFunction_MD5, "8b1a9953c4611296a827abf8c47804d7", 5)
@@ -162,3 +164,174 @@ func TestShardingBlobAccess(t *testing.T) {
 		require.Equal(t, digest.NewSetBuilder().Add(digest1).Add(digest3).Build(), missing)
 	})
 }
+
+func TestShardingBlobAccessReplication(t *testing.T) {
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+
+	shard0 := mock.NewMockBlobAccess(ctrl)
+	shard1 := mock.NewMockBlobAccess(ctrl)
+	shard2 := mock.NewMockBlobAccess(ctrl)
+	shardSelector := mock.NewMockShardSelector(ctrl)
+	blobAccess := sharding.NewShardingBlobAccess(
+		[]sharding.ShardBackend{
+			{
+				Backend: shard0,
+				Key:     "shard0",
+			},
+			{
+				Backend: shard1,
+				Key:     "shard1",
+			},
+			{
+				Backend: shard2,
+				Key:     "shard2",
+			},
+		},
+		shardSelector,
+		2,
+		true,
+	)
+
+	helloDigest := digest.MustNewDigest("example", remoteexecution.DigestFunction_MD5, "8b1a9953c4611296a827abf8c47804d7", 5)
+
+	t.Run("GetSuccess", func(t *testing.T) {
+		shardSelector.EXPECT().GetShards(uint64(0x8b1a9953c4611296), 2).Return([]int{2, 0})
+		shard2.EXPECT().Get(ctx, helloDigest).
+			Return(buffer.NewValidatedBufferFromByteSlice([]byte("Hello")))
+
+		data, err := blobAccess.Get(ctx, helloDigest).ToByteSlice(1000)
+		require.NoError(t, err)
+		require.Equal(t, []byte("Hello"), data)
+	})
+
+	t.Run("GetReadRepair", func(t *testing.T) {
+		// If the object is absent in the preferred shard, it
+		// should be read from the next shard and copied into
+		// the preferred shard.
+		shardSelector.EXPECT().GetShards(uint64(0x8b1a9953c4611296), 2).Return([]int{2, 0})
+		shard2.EXPECT().Get(ctx, helloDigest).
+			Return(buffer.NewBufferFromError(status.Error(codes.NotFound, "Object not found")))
+		shard0.EXPECT().Get(ctx, helloDigest).
+			Return(buffer.NewValidatedBufferFromByteSlice([]byte("Hello")))
+		shard2.EXPECT().Put(ctx, helloDigest, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
+				data, err := b.ToByteSlice(1000)
+				require.NoError(t, err)
+				require.Equal(t, []byte("Hello"), data)
+				return nil
+			})
+
+		data, err := blobAccess.Get(ctx, helloDigest).ToByteSlice(1000)
+		require.NoError(t, err)
+		require.Equal(t, []byte("Hello"), data)
+	})
+
+	t.Run("GetUnavailable", func(t *testing.T) {
+		// If the preferred shard is unavailable, it cannot be
+		// repaired. The object should be read from the next
+		// shard directly.
+		shardSelector.EXPECT().GetShards(uint64(0x8b1a9953c4611296), 2).Return([]int{2, 0})
+		shard2.EXPECT().Get(ctx, helloDigest).
+			Return(buffer.NewBufferFromError(status.Error(codes.Unavailable, "Server offline")))
+		shard0.EXPECT().Get(ctx, helloDigest).
+			Return(buffer.NewValidatedBufferFromByteSlice([]byte("Hello")))
+
+		data, err := blobAccess.Get(ctx, helloDigest).ToByteSlice(1000)
+		require.NoError(t, err)
+		require.Equal(t, []byte("Hello"), data)
+	})
+
+	t.Run("GetFailure", func(t *testing.T) {
+		// If the object cannot be obtained from any shard,
+		// errors other than NOT_FOUND should take precedence.
+		shardSelector.EXPECT().GetShards(uint64(0x8b1a9953c4611296), 2).Return([]int{2, 0})
+		shard2.EXPECT().Get(ctx, helloDigest).
+			Return(buffer.NewBufferFromError(status.Error(codes.Unavailable, "Server offline")))
+		shard0.EXPECT().Get(ctx, helloDigest).
+			Return(buffer.NewBufferFromError(status.Error(codes.NotFound, "Object not found")))
+
+		_, err := blobAccess.Get(ctx, helloDigest).ToByteSlice(1000)
+		testutil.RequireEqualStatus(t, status.Error(codes.Unavailable, "Shard shard2: Server offline"), err)
+	})
+
+	t.Run("PutPartialFailure", func(t *testing.T) {
+		// Writes should succeed if the object could be stored
+		// in at least one of the shards.
+		shardSelector.EXPECT().GetShards(uint64(0x8b1a9953c4611296), 2).Return([]int{2, 0})
+		shard2.EXPECT().Put(ctx, helloDigest, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
+				b.Discard()
+				return status.Error(codes.Unavailable, "Server offline")
+			})
+		shard0.EXPECT().Put(ctx, helloDigest, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
+				data, err := b.ToByteSlice(1000)
+				require.NoError(t, err)
+				require.Equal(t, []byte("Hello"), data)
+				return nil
+			})
+
+		require.NoError(t, blobAccess.Put(ctx, helloDigest, buffer.NewValidatedBufferFromByteSlice([]byte("Hello"))))
+	})
+
+	t.Run("PutFailure", func(t *testing.T) {
+		shardSelector.EXPECT().GetShards(uint64(0x8b1a9953c4611296), 2).Return([]int{2, 0})
+		for _, shard := range []*mock.MockBlobAccess{shard2, shard0} {
+			shard.EXPECT().Put(ctx, helloDigest, gomock.Any()).DoAndReturn(
+				func(ctx context.Context, digest digest.Digest, b buffer.Buffer) error {
+					b.Discard()
+					return status.Error(codes.Unavailable, "Server offline")
+				})
+		}
+
+		testutil.RequireEqualStatus(
+			t,
+			status.Error(codes.Unavailable, "Shard shard2: Server offline"),
+			blobAccess.Put(ctx, helloDigest, buffer.NewValidatedBufferFromByteSlice([]byte("Hello"))))
+	})
+
+	digest1 := digest.MustNewDigest("", remoteexecution.DigestFunction_MD5, "21f843aefbfb88627ec2cad9e8f1f49a", 1)
+	digest2 := digest.MustNewDigest("", remoteexecution.DigestFunction_MD5, "48f2503cf369373b0631da97fb9de1c1", 2)
+	digest3 := digest.MustNewDigest("", remoteexecution.DigestFunction_MD5, "942a5b4164c26ae5d57a4f9526dcfca4", 3)
+
+	t.Run("FindMissingSuccess", func(t *testing.T) {
+		// Objects should only be reported as missing if they
+		// are absent in all of the shards in which they are
+		// stored. Shards that fail are ignored, as long as
+		// another shard responded.
+		shardSelector.EXPECT().GetShards(uint64(0x21f843aefbfb8862), 2).Return([]int{0, 1})
+		shardSelector.EXPECT().GetShards(uint64(0x48f2503cf369373b), 2).Return([]int{1, 2})
+		shardSelector.EXPECT().GetShards(uint64(0x942a5b4164c26ae5), 2).Return([]int{2, 0})
+		shard0.EXPECT().FindMissing(
+			ctx,
+			digest.NewSetBuilder().Add(digest1).Add(digest3).Build(),
+		).Return(digest.NewSetBuilder().Add(digest1).Add(digest3).Build(), nil)
+		shard1.EXPECT().FindMissing(
+			ctx,
+			digest.NewSetBuilder().Add(digest1).Add(digest2).Build(),
+		).Return(digest2.ToSingletonSet(), nil)
+		shard2.EXPECT().FindMissing(
+			ctx,
+			digest.NewSetBuilder().Add(digest2).Add(digest3).Build(),
+		).Return(digest.EmptySet, status.Error(codes.Unavailable, "Server offline"))
+
+		missing, err := blobAccess.FindMissing(
+			ctx,
+			digest.NewSetBuilder().Add(digest1).Add(digest2).Add(digest3).Build())
+		require.NoError(t, err)
+		require.Equal(t, digest.NewSetBuilder().Add(digest2).Add(digest3).Build(), missing)
+	})
+
+	t.Run("FindMissingFailure", func(t *testing.T) {
+		// If none of the shards in which an object is stored
+		// responded, an error should be returned.
+		shardSelector.EXPECT().GetShards(uint64(0x21f843aefbfb8862), 2).Return([]int{0, 1})
+		shard0.EXPECT().FindMissing(ctx, digest1.ToSingletonSet()).
+			Return(digest.EmptySet, status.Error(codes.Unavailable, "Server offline"))
+		shard1.EXPECT().FindMissing(ctx, digest1.ToSingletonSet()).
+			Return(digest.EmptySet, status.Error(codes.Unavailable, "Server offline"))
+
+		_, err := blobAccess.FindMissing(ctx, digest1.ToSingletonSet())
+		testutil.RequireEqualStatus(t, status.Error(codes.Unavailable, "Shard shard0: Server offline"), err)
+	})
+}
@@ -54,7 +54,11 @@ func (w wrappedStorageBucketHandle) Object(name string) StorageObjectHandle {
 // storage.ObjectHandle type that are used by this code base. This
 // interface has been added to permit unit testing.
 type StorageObjectHandle interface {
+	Attrs(ctx context.Context) (*storage.ObjectAttrs, error)
+	Delete(ctx context.Context) error
 	NewRangeReader(ctx context.Context, offset, length int64) (io.ReadCloser, error)
+	NewWriter(ctx context.Context, attrs storage.ObjectAttrs, chunkSize int) io.WriteCloser
+	Update(ctx context.Context, uattrs storage.ObjectAttrsToUpdate) (*storage.ObjectAttrs, error)
 }
 
 type wrappedStorageObjectHandle struct {
@@ -65,6 +69,35 @@ type wrappedStorageObjectHandle struct {
 // argument to request reading the object until the end.
 const ReadUntilEOF int64 = -1
 
+func (w wrappedStorageObjectHandle) Attrs(ctx context.Context) (*storage.ObjectAttrs, error) {
+	return w.impl.Attrs(ctx)
+}
+
+func (w wrappedStorageObjectHandle) Delete(ctx context.Context) error {
+	return w.impl.Delete(ctx)
+}
+
 func (w wrappedStorageObjectHandle) NewRangeReader(ctx context.Context, offset, length int64) (io.ReadCloser, error) {
 	return w.impl.NewRangeReader(ctx, offset, length)
 }
+
+// NewWriter returns a writer that uploads data into the object. The
+// object is only created upon successful completion of Close(). To
+// abandon an upload, the context must be canceled prior to calling
+// Close().
+//
+// The name of the bucket and the object contained in the provided
+// attributes are ignored. If chunkSize is non-zero, data is uploaded
+// using a resumable upload.
+func (w wrappedStorageObjectHandle) NewWriter(ctx context.Context, attrs storage.ObjectAttrs, chunkSize int) io.WriteCloser {
+	writer := w.impl.NewWriter(ctx)
+	attrs.Bucket = writer.Bucket
+	attrs.Name = writer.Name
+	writer.ObjectAttrs = attrs
+	writer.ChunkSize = chunkSize
+	return writer
+}
+
+func (w wrappedStorageObjectHandle) Update(ctx context.Context, uattrs storage.ObjectAttrsToUpdate) (*storage.ObjectAttrs, error) {
+	return w.impl.Update(ctx, uattrs)
+}
@@ -9,12 +9,15 @@ import (
 
 	remoteexecution "github.com/bazelbuild/remote-apis/build/bazel/remote/execution/v2"
 	"github.com/buildbarn/go-sha256tree"
+
+	"lukechampine.com/blake3"
 )
 
 // SupportedDigestFunctions is the list of digest functions supported by
 // digest.Digest, using the enumeration values that are part of the
 // Remote Execution protocol.
 var SupportedDigestFunctions = []remoteexecution.DigestFunction_Value{
+	remoteexecution.DigestFunction_BLAKE3,
 	remoteexecution.DigestFunction_MD5,
 	remoteexecution.DigestFunction_SHA1,
 	remoteexecution.DigestFunction_SHA256,
@@ -37,7 +40,18 @@ type bareFunction struct {
 	hashBytesSize int
 }
 
+// blake3Size is the size of BLAKE3 hashes used by the Remote Execution
+// protocol, which uses the default output length of 256 bits.
+const blake3Size = 32
+
 var (
+	blake3BareFunction = bareFunction{
+		enumValue: remoteexecution.DigestFunction_BLAKE3,
+		hasherFactory: func(expectedSizeBytes int64) hash.Hash {
+			return blake3.New(blake3Size, nil)
+		},
+		hashBytesSize: blake3Size,
+	}
 	md5BareFunction = bareFunction{
 		enumValue: remoteexecution.DigestFunction_MD5,
 		hasherFactory: func(expectedSizeBytes int64) hash.Hash {
@@ -100,6 +114,8 @@ func getBareFunction(digestFunction remoteexecution.DigestFunction_Value, hashSt
 		case sha512.Size * 2:
 			return &sha512BareFunction
 		}
+	case remoteexecution.DigestFunction_BLAKE3:
+		return &blake3BareFunction
 	case remoteexecution.DigestFunction_MD5:
 		return &md5BareFunction
 	case remoteexecution.DigestFunction_SHA1:
@@ -48,6 +48,13 @@ func TestNewDigestFromByteStreamReadPath(t *testing.T) {
 			require.Equal(t, remoteexecution.Compressor_IDENTITY, compressor)
 		})
 
+		t.Run("BLAKE3", func(t *testing.T) {
+			d, compressor, err := digest.NewDigestFromByteStreamReadPath("blobs/blake3/fbc2b0516ee8744d293b980779178a3508850fdcfe965985782c39601b65794f/5")
+			require.NoError(t, err)
+			require.Equal(t, digest.MustNewDigest("", remoteexecution.DigestFunction_BLAKE3, "fbc2b0516ee8744d293b980779178a3508850fdcfe965985782c39601b65794f", 5), d)
+			require.Equal(t, remoteexecution.Compressor_IDENTITY, compressor)
+		})
+
 		t.Run("SHA256TREE", func(t *testing.T) {
 			d, compressor, err := digest.NewDigestFromByteStreamReadPath("blobs/sha256tree/0f7b3dc589fa10959e9507ad24e7e1197dd56f2ebbc006d4c9a2a3074a72fc8c/123")
 			require.NoError(t, err)
@@ -432,6 +439,20 @@ func TestDigestGetKey(t *testing.T) {
 			"8-5d8242df5726318bec51ccc6166a284ce40850cb7e9f4b041ce3df8a7fa61dc4-123-hello",
 			d.GetKey(digest.KeyWithInstance))
 	})
+
+	t.Run("BLAKE3", func(t *testing.T) {
+		// BLAKE3 and SHA-256 hashes have the same length. Keys
+		// should remain distinct.
+		d := digest.MustNewDigest("hello", remoteexecution.DigestFunction_BLAKE3, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", 123)
+		require.Equal(
+			t,
+			"9-e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855-123",
+			d.GetKey(digest.KeyWithoutInstance))
+		require.Equal(
+			t,
+			"9-e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855-123-hello",
+			d.GetKey(digest.KeyWithInstance))
+	})
 }
 
 func TestDigestString(t *testing.T) {
@@ -503,11 +524,13 @@ func TestRemoveUnsupportedDigestFunctions(t *testing.T) {
 	require.Equal(
 		t,
 		[]remoteexecution.DigestFunction_Value{
+			remoteexecution.DigestFunction_BLAKE3,
 			remoteexecution.DigestFunction_MD5,
 			remoteexecution.DigestFunction_SHA1,
 			remoteexecution.DigestFunction_SHA256,
 		},
 		digest.RemoveUnsupportedDigestFunctions([]remoteexecution.DigestFunction_Value{
+			remoteexecution.DigestFunction_BLAKE3,
 			remoteexecution.DigestFunction_MD5,
 			remoteexecution.DigestFunction_SHA256,
 			remoteexecution.DigestFunction_SHA1,
@@ -75,6 +75,19 @@ func TestInstanceNameGetDigestFunction(t *testing.T) {
 		require.False(t, digest.MustNewDigest("hello", remoteexecution.DigestFunction_MD5, "1f69e2d170a0ada2b853fe2adc6d1c47", 789).UsesDigestFunction(digestFunction))
 	})
 
+	t.Run("BLAKE3", func(t *testing.T) {
+		digestFunction, err := instanceName.GetDigestFunction(remoteexecution.DigestFunction_BLAKE3, 0)
+		require.NoError(t, err)
+
+		g := digestFunction.NewGenerator(5)
+		g.Write([]byte("Hello"))
+		require.Equal(t, digest.MustNewDigest("hello", remoteexecution.DigestFunction_BLAKE3, "fbc2b0516ee8744d293b980779178a3508850fdcfe965985782c39601b65794f", 5), g.Sum())
+
+		require.True(t, digest.MustNewDigest("hello", remoteexecution.DigestFunction_BLAKE3, "c1b1c3e4000faffe4c9f325a251554a19442b3cd8f5c5b80ce34d9cad257fcd7", 123).UsesDigestFunction(digestFunction))
+		require.False(t, digest.MustNewDigest("bye", remoteexecution.DigestFunction_BLAKE3, "c1b1c3e4000faffe4c9f325a251554a19442b3cd8f5c5b80ce34d9cad257fcd7", 456).UsesDigestFunction(digestFunction))
+		require.False(t, digest.MustNewDigest("hello", remoteexecution.DigestFunction_SHA256, "c1b1c3e4000faffe4c9f325a251554a19442b3cd8f5c5b80ce34d9cad257fcd7", 789).UsesDigestFunction(digestFunction))
+	})
+
 	t.Run("SHA256TREE", func(t *testing.T) {
 		digestFunction, err := instanceName.GetDigestFunction(remoteexecution.DigestFunction_SHA256TREE, 0)
 		require.NoError(t, err)
@@ -56,6 +56,18 @@ type LifecycleState struct {
 	config                          *pb.DiagnosticsHTTPServerConfiguration
 	activeSpansReportingHTTPHandler *bb_otel.ActiveSpansReportingHTTPHandler
 	grpcClientFactory               bb_grpc.ClientFactory
+	diagnosticsHTTPHandlers         map[string]http.Handler
+}
+
+// RegisterDiagnosticsHTTPHandler can be called to expose an additional
+// endpoint through the diagnostics web server. This allows binaries
+// to provide endpoints that are specific to their purpose. It must be
+// called prior to MarkReadyAndWait().
+func (ls *LifecycleState) RegisterDiagnosticsHTTPHandler(path string, handler http.Handler) {
+	if ls.diagnosticsHTTPHandlers == nil {
+		ls.diagnosticsHTTPHandlers = map[string]http.Handler{}
+	}
+	ls.diagnosticsHTTPHandlers[path] = handler
 }
 
 // MarkReadyAndWait can be called to report that the program has started
@@ -76,6 +88,9 @@ func (ls *LifecycleState) MarkReadyAndWait(group program.Group) {
 		if httpHandler := ls.activeSpansReportingHTTPHandler; httpHandler != nil {
 			router.Handle("/active_spans", httpHandler)
 		}
+		for path, httpHandler := range ls.diagnosticsHTTPHandlers {
+			router.Handle(path, httpHandler)
+		}
 
 		http_server.NewServersFromConfigurationAndServe(
 			ls.config.HttpServers,
@@ -11,10 +11,13 @@ import (
 	auth "github.com/buildbarn/bb-storage/pkg/proto/configuration/auth"
 	blobstore "github.com/buildbarn/bb-storage/pkg/proto/configuration/blobstore"
 	builder "github.com/buildbarn/bb-storage/pkg/proto/configuration/builder"
+	eviction "github.com/buildbarn/bb-storage/pkg/proto/configuration/eviction"
 	global "github.com/buildbarn/bb-storage/pkg/proto/configuration/global"
 	grpc "github.com/buildbarn/bb-storage/pkg/proto/configuration/grpc"
+	server "github.com/buildbarn/bb-storage/pkg/proto/configuration/http/server"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -28,20 +31,104 @@ const (
 )
 
 type ApplicationConfiguration struct {
-	state                             protoimpl.MessageState                     `protogen:"open.v1"`
-	GrpcServers                       []*grpc.ServerConfiguration                `protobuf:"bytes,4,rep,name=grpc_servers,json=grpcServers,proto3" json:"grpc_servers,omitempty"`
-	Schedulers                        map[string]*builder.SchedulerConfiguration `protobuf:"bytes,5,rep,name=schedulers,proto3" json:"schedulers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	MaximumMessageSizeBytes           int64                                      `protobuf:"varint,8,opt,name=maximum_message_size_bytes,json=maximumMessageSizeBytes,proto3" json:"maximum_message_size_bytes,omitempty"`
-	Global                            *global.Configuration                      `protobuf:"bytes,9,opt,name=global,proto3" json:"global,omitempty"`
-	ContentAddressableStorage         *ScannableBlobAccessConfiguration          `protobuf:"bytes,17,opt,name=content_addressable_storage,json=contentAddressableStorage,proto3" json:"content_addressable_storage,omitempty"`
-	ActionCache                       *NonScannableBlobAccessConfiguration       `protobuf:"bytes,18,opt,name=action_cache,json=actionCache,proto3" json:"action_cache,omitempty"`
-	IndirectContentAddressableStorage *ScannableBlobAccessConfiguration          `protobuf:"bytes,10,opt,name=indirect_content_addressable_storage,json=indirectContentAddressableStorage,proto3" json:"indirect_content_addressable_storage,omitempty"`
-	InitialSizeClassCache             *NonScannableBlobAccessConfiguration       `protobuf:"bytes,11,opt,name=initial_size_class_cache,json=initialSizeClassCache,proto3" json:"initial_size_class_cache,omitempty"`
-	FileSystemAccessCache             *NonScannableBlobAccessConfiguration       `protobuf:"bytes,19,opt,name=file_system_access_cache,json=fileSystemAccessCache,proto3" json:"file_system_access_cache,omitempty"`
-	ExecuteAuthorizer                 *auth.AuthorizerConfiguration              `protobuf:"bytes,16,opt,name=execute_authorizer,json=executeAuthorizer,proto3" json:"execute_authorizer,omitempty"`
-	SupportedCompressors              []v2.Compressor_Value                      `protobuf:"varint,20,rep,packed,name=supported_compressors,json=supportedCompressors,proto3,enum=build.bazel.remote.execution.v2.Compressor_Value" json:"supported_compressors,omitempty"`
-	unknownFields                     protoimpl.UnknownFields
-	sizeCache                         protoimpl.SizeCache
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// gRPC servers to spawn to listen for requests from clients.
+	GrpcServers []*grpc.ServerConfiguration `protobuf:"bytes,4,rep,name=grpc_servers,json=grpcServers,proto3" json:"grpc_servers,omitempty"`
+	// Map of schedulers available capable of running build actions, where
+	// the key corresponds to the instance name prefix to match. In case
+	// of multiple matches, the scheduler with the longest matching prefix
+	// is used. The matching prefix is removed from the resulting instance
+	// name.
+	//
+	// For example, if schedulers for instance name prefixes "acmecorp"
+	// and "acmecorp/rockets" are declared, requests for instance name
+	// "acmecorp/rockets/mars" will be forwarded to the latter. This
+	// scheduler will receive requests with instance name "mars".
+	//
+	// The empty string can be used to match all instance names, thereby
+	// causing all requests to be forwarded to a single scheduler.
+	Schedulers map[string]*builder.SchedulerConfiguration `protobuf:"bytes,5,rep,name=schedulers,proto3" json:"schedulers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Maximum Protobuf message size to unmarshal.
+	MaximumMessageSizeBytes int64 `protobuf:"varint,8,opt,name=maximum_message_size_bytes,json=maximumMessageSizeBytes,proto3" json:"maximum_message_size_bytes,omitempty"`
+	// Common configuration options that apply to all Buildbarn binaries.
+	Global *global.Configuration `protobuf:"bytes,9,opt,name=global,proto3" json:"global,omitempty"`
+	// Optional: Blobstore configuration for the Content Addressable
+	// Storage (CAS).
+	ContentAddressableStorage *ScannableBlobAccessConfiguration `protobuf:"bytes,17,opt,name=content_addressable_storage,json=contentAddressableStorage,proto3" json:"content_addressable_storage,omitempty"`
+	// Optional: Blobstore configuration for the Action Cache (AC).
+	ActionCache *NonScannableBlobAccessConfiguration `protobuf:"bytes,18,opt,name=action_cache,json=actionCache,proto3" json:"action_cache,omitempty"`
+	// Optional: Blobstore configuration for the Indirect Content
+	// Addressable Storage (ICAS).
+	IndirectContentAddressableStorage *ScannableBlobAccessConfiguration `protobuf:"bytes,10,opt,name=indirect_content_addressable_storage,json=indirectContentAddressableStorage,proto3" json:"indirect_content_addressable_storage,omitempty"`
+	// Optional: Blobstore configuration for the Initial Size Class Cache
+	// (ISCC).
+	InitialSizeClassCache *NonScannableBlobAccessConfiguration `protobuf:"bytes,11,opt,name=initial_size_class_cache,json=initialSizeClassCache,proto3" json:"initial_size_class_cache,omitempty"`
+	// Optional: Blobstore configuration for the File System Access Cache
+	// (FSAC).
+	FileSystemAccessCache *NonScannableBlobAccessConfiguration `protobuf:"bytes,19,opt,name=file_system_access_cache,json=fileSystemAccessCache,proto3" json:"file_system_access_cache,omitempty"`
+	// Authorization requirements applied to Execute() requests via schedulers.
+	//
+	// Note that this does not apply any authorization to WaitExecution() -
+	// any scheduler is expected to perform authorization on WaitExecution(),
+	// but in bb_storage we can't reliably know the instance name from an
+	// operation. This is hopefully safe, as operation names are hard to guess,
+	// and the forwarded-to scheduler should perform its own authorization.
+	ExecuteAuthorizer *auth.AuthorizerConfiguration `protobuf:"bytes,16,opt,name=execute_authorizer,json=executeAuthorizer,proto3" json:"execute_authorizer,omitempty"`
+	// List of compression algorithms supported by the Content Addressable
+	// Storage to announce as part of the server's cache capabilities. The
+	// list is announced both for the ByteStream service and for the
+	// BatchUpdateBlobs() operation. This does not affect the compression
+	// algorithm used by the server when reading or writing data, as only
+	// uncompressed data, DEFLATE and ZSTD are supported. Valid values
+	// include:
+	//
+	// DEFLATE: DEFLATE compression (RFC 1951)
+	// ZSTD: Zstandard compression
+	//
+	// Support for IDENTITY (i.e., no compression) is implied.
+	SupportedCompressors []v2.Compressor_Value `protobuf:"varint,20,rep,packed,name=supported_compressors,json=supportedCompressors,proto3,enum=build.bazel.remote.execution.v2.Compressor_Value" json:"supported_compressors,omitempty"`
+	// Optional: Enable support for the ContentAddressableStorage
+	// SplitBlob() and SpliceBlob() operations, using content-defined
+	// chunking to decompose blobs. When enabled, this is announced as
+	// part of the server's cache capabilities.
+	ContentDefinedChunking *blobstore.ContentDefinedChunkingConfiguration `protobuf:"bytes,21,opt,name=content_defined_chunking,json=contentDefinedChunking,proto3" json:"content_defined_chunking,omitempty"`
+	// Optional: Enable resumable uploads through the ByteStream service.
+	// When enabled, data of uploads is stored in a staging directory on
+	// local disk, prior to being written to the Content Addressable
+	// Storage. If an upload gets interrupted, clients may call
+	// ByteStream.QueryWriteStatus() to obtain the amount of data that
+	// has been received, and continue the upload from that point.
+	//
+	// Access to uploads in the staging directory is authorized using the
+	// Content Addressable Storage's put_authorizer.
+	ResumableUploads *ResumableUploadsConfiguration `protobuf:"bytes,22,opt,name=resumable_uploads,json=resumableUploads,proto3" json:"resumable_uploads,omitempty"`
+	// The maximum total size of blobs that ActionCache.GetActionResult()
+	// may embed into an ActionResult, when requested by clients through
+	// the inline_stdout, inline_stderr and inline_output_files fields.
+	// Inlining eliminates the need for clients to download small blobs
+	// from the Content Addressable Storage separately.
+	//
+	// This option requires that both the Action Cache and Content
+	// Addressable Storage are configured. Blobs are only inlined if the
+	// client is permitted to read them from the Content Addressable
+	// Storage. This value must be lower than maximum_message_size_bytes.
+	// If zero, inlining is disabled.
+	MaximumActionResultInlinedSizeBytes int64 `protobuf:"varint,23,opt,name=maximum_action_result_inlined_size_bytes,json=maximumActionResultInlinedSizeBytes,proto3" json:"maximum_action_result_inlined_size_bytes,omitempty"`
+	// Optional: Expose the Action Cache and Content Addressable Storage
+	// over HTTP, using the protocol that Bazel uses when invoked with
+	// --remote_cache=http://... or https://... This requires that both
+	// the Action Cache and Content Addressable Storage are configured.
+	// The same authorizers as the gRPC services are applied.
+	BazelHttpCache *BazelHTTPCacheConfiguration `protobuf:"bytes,24,opt,name=bazel_http_cache,json=bazelHttpCache,proto3" json:"bazel_http_cache,omitempty"`
+	// Optional: Expose generic key-value stores over HTTP, which can be
+	// used by tools such as ccache, sccache and Gradle's HTTP build
+	// cache. Values are stored in the Content Addressable Storage, while
+	// the mapping from keys to values is stored in the Action Cache. This
+	// requires that both of these are configured. The same authorizers
+	// as the gRPC services are applied.
+	KeyValueHttpCaches []*KeyValueHTTPCacheConfiguration `protobuf:"bytes,25,rep,name=key_value_http_caches,json=keyValueHttpCaches,proto3" json:"key_value_http_caches,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
 }
 
 func (x *ApplicationConfiguration) Reset() {
@@ -151,18 +238,258 @@ func (x *ApplicationConfiguration) GetSupportedCompressors() []v2.Compressor_Val
 	return nil
 }
 
+func (x *ApplicationConfiguration) GetContentDefinedChunking() *blobstore.ContentDefinedChunkingConfiguration {
+	if x != nil {
+		return x.ContentDefinedChunking
+	}
+	return nil
+}
+
+func (x *ApplicationConfiguration) GetResumableUploads() *ResumableUploadsConfiguration {
+	if x != nil {
+		return x.ResumableUploads
+	}
+	return nil
+}
+
+func (x *ApplicationConfiguration) GetMaximumActionResultInlinedSizeBytes() int64 {
+	if x != nil {
+		return x.MaximumActionResultInlinedSizeBytes
+	}
+	return 0
+}
+
+func (x *ApplicationConfiguration) GetBazelHttpCache() *BazelHTTPCacheConfiguration {
+	if x != nil {
+		return x.BazelHttpCache
+	}
+	return nil
+}
+
+func (x *ApplicationConfiguration) GetKeyValueHttpCaches() []*KeyValueHTTPCacheConfiguration {
+	if x != nil {
+		return x.KeyValueHttpCaches
+	}
+	return nil
+}
+
+type BazelHTTPCacheConfiguration struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// HTTP servers to spawn to listen for requests from clients.
+	//
+	// Requests are of the form GET or PUT /${instance_name}/ac/${hash}
+	// and /${instance_name}/cas/${hash}, where the instance name may be
+	// empty or consist of multiple pathname components. The digest
+	// function is inferred from the length of the hash.
+	//
+	// NOTE: Bazel's HTTP caching protocol identifies Action Cache
+	// entries by the hash of the Action message, while REv2 also
+	// includes its size. As the size of the Action message is not
+	// known, Action Cache entries are stored under digests that have a
+	// size of zero. The Action Cache entries of clients using HTTP and
+	// REv2 are therefore disjoint. Objects in the Content Addressable
+	// Storage are shared.
+	HttpServers []*server.Configuration `protobuf:"bytes,1,rep,name=http_servers,json=httpServers,proto3" json:"http_servers,omitempty"`
+	// Unlike REv2, Bazel's HTTP caching protocol does not include the
+	// sizes of objects in requests. As the storage backends of
+	// Buildbarn use digests that include sizes, the HTTP server keeps
+	// track of the sizes of objects it has observed in memory. Sizes
+	// are learned when objects are uploaded to the Content Addressable
+	// Storage, and when action results and output directories are
+	// returned by the Action Cache. Requests for objects of which the
+	// size is unknown are treated as cache misses.
+	//
+	// The maximum number of object sizes to track.
+	BlobSizeCacheSize int64 `protobuf:"varint,2,opt,name=blob_size_cache_size,json=blobSizeCacheSize,proto3" json:"blob_size_cache_size,omitempty"`
+	// The cache replacement policy that should be applied to the object
+	// sizes that are tracked. It is advised that this is set to
+	// LEAST_RECENTLY_USED.
+	BlobSizeCacheReplacementPolicy eviction.CacheReplacementPolicy `protobuf:"varint,3,opt,name=blob_size_cache_replacement_policy,json=blobSizeCacheReplacementPolicy,proto3,enum=buildbarn.configuration.eviction.CacheReplacementPolicy" json:"blob_size_cache_replacement_policy,omitempty"`
+	// Optional: Store the sizes of objects uploaded through this server
+	// in the Action Cache, and consult these entries for objects of
+	// which the size is not tracked in memory. This makes objects
+	// available after restarts, and when multiple instances of this
+	// server are placed behind a load balancer.
+	//
+	// Sizes are stored on a best-effort basis. Failures to update the
+	// index are logged, but do not cause uploads to fail. As clients
+	// with write access to the Action Cache may alter entries in the
+	// index, sizes obtained from it are only used if the Content
+	// Addressable Storage confirms that the object exists.
+	//
+	// If this option is not set, objects can only be downloaded from
+	// the instance that observed their size since it was last started.
+	//
+	// Even with this option set, objects that are uploaded through
+	// REv2 can only be downloaded after an action result or output
+	// directory referencing them has been returned by the Action Cache.
+	BlobSizeIndex *BazelHTTPCacheConfiguration_BlobSizeIndexConfiguration `protobuf:"bytes,4,opt,name=blob_size_index,json=blobSizeIndex,proto3" json:"blob_size_index,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BazelHTTPCacheConfiguration) Reset() {
+	*x = BazelHTTPCacheConfiguration{}
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_bb_storage_bb_storage_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BazelHTTPCacheConfiguration) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BazelHTTPCacheConfiguration) ProtoMessage() {}
+
+func (x *BazelHTTPCacheConfiguration) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_bb_storage_bb_storage_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BazelHTTPCacheConfiguration.ProtoReflect.Descriptor instead.
+func (*BazelHTTPCacheConfiguration) Descriptor() ([]byte, []int) {
+	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_bb_storage_bb_storage_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *BazelHTTPCacheConfiguration) GetHttpServers() []*server.Configuration {
+	if x != nil {
+		return x.HttpServers
+	}
+	return nil
+}
+
+func (x *BazelHTTPCacheConfiguration) GetBlobSizeCacheSize() int64 {
+	if x != nil {
+		return x.BlobSizeCacheSize
+	}
+	return 0
+}
+
+func (x *BazelHTTPCacheConfiguration) GetBlobSizeCacheReplacementPolicy() eviction.CacheReplacementPolicy {
+	if x != nil {
+		return x.BlobSizeCacheReplacementPolicy
+	}
+	return eviction.CacheReplacementPolicy(0)
+}
+
+func (x *BazelHTTPCacheConfiguration) GetBlobSizeIndex() *BazelHTTPCacheConfiguration_BlobSizeIndexConfiguration {
+	if x != nil {
+		return x.BlobSizeIndex
+	}
+	return nil
+}
+
+type ResumableUploadsConfiguration struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Path of the directory in which data of uploads that are in
+	// progress is stored. Any existing contents of this directory are
+	// removed upon startup.
+	StagingDirectoryPath string `protobuf:"bytes,1,opt,name=staging_directory_path,json=stagingDirectoryPath,proto3" json:"staging_directory_path,omitempty"`
+	// The maximum amount of data to store in the staging directory,
+	// which must be positive. When exceeded, uploads that are not in
+	// progress are discarded, starting with the ones that received data
+	// least recently.
+	//
+	// Uploads of blobs that are larger than this size are written to
+	// storage directly. The same holds for uploads that receive data
+	// while the staging directory is filled with uploads that are in
+	// progress. Such uploads cannot be resumed.
+	MaximumSizeBytes int64 `protobuf:"varint,2,opt,name=maximum_size_bytes,json=maximumSizeBytes,proto3" json:"maximum_size_bytes,omitempty"`
+	// The amount of time after which uploads that did not receive any
+	// data are discarded, which must be positive.
+	Expiration *durationpb.Duration `protobuf:"bytes,3,opt,name=expiration,proto3" json:"expiration,omitempty"`
+	// Uploads of blobs smaller than this size are written to storage
+	// directly, as restarting such uploads is cheap.
+	MinimumBlobSizeBytes int64 `protobuf:"varint,4,opt,name=minimum_blob_size_bytes,json=minimumBlobSizeBytes,proto3" json:"minimum_blob_size_bytes,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *ResumableUploadsConfiguration) Reset() {
+	*x = ResumableUploadsConfiguration{}
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_bb_storage_bb_storage_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResumableUploadsConfiguration) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResumableUploadsConfiguration) ProtoMessage() {}
+
+func (x *ResumableUploadsConfiguration) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_bb_storage_bb_storage_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResumableUploadsConfiguration.ProtoReflect.Descriptor instead.
+func (*ResumableUploadsConfiguration) Descriptor() ([]byte, []int) {
+	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_bb_storage_bb_storage_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ResumableUploadsConfiguration) GetStagingDirectoryPath() string {
+	if x != nil {
+		return x.StagingDirectoryPath
+	}
+	return ""
+}
+
+func (x *ResumableUploadsConfiguration) GetMaximumSizeBytes() int64 {
+	if x != nil {
+		return x.MaximumSizeBytes
+	}
+	return 0
+}
+
+func (x *ResumableUploadsConfiguration) GetExpiration() *durationpb.Duration {
+	if x != nil {
+		return x.Expiration
+	}
+	return nil
+}
+
+func (x *ResumableUploadsConfiguration) GetMinimumBlobSizeBytes() int64 {
+	if x != nil {
+		return x.MinimumBlobSizeBytes
+	}
+	return 0
+}
+
+// Storage configuration for backends which don't allow batch digest
+// scanning.
 type NonScannableBlobAccessConfiguration struct {
-	state         protoimpl.MessageState             `protogen:"open.v1"`
-	Backend       *blobstore.BlobAccessConfiguration `protobuf:"bytes,1,opt,name=backend,proto3" json:"backend,omitempty"`
-	GetAuthorizer *auth.AuthorizerConfiguration      `protobuf:"bytes,2,opt,name=get_authorizer,json=getAuthorizer,proto3" json:"get_authorizer,omitempty"`
-	PutAuthorizer *auth.AuthorizerConfiguration      `protobuf:"bytes,3,opt,name=put_authorizer,json=putAuthorizer,proto3" json:"put_authorizer,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Storage backend.
+	Backend *blobstore.BlobAccessConfiguration `protobuf:"bytes,1,opt,name=backend,proto3" json:"backend,omitempty"`
+	// The authorizer for determining whether a client may read from storage.
+	GetAuthorizer *auth.AuthorizerConfiguration `protobuf:"bytes,2,opt,name=get_authorizer,json=getAuthorizer,proto3" json:"get_authorizer,omitempty"`
+	// The authorizer for determining whether a client may write to storage.
+	// For example, in case of the Content Addressable Storage (CAS),
+	// it pertains to ByteStream.Write() and BatchUpdateBlobs() operations,
+	// while for the Action Cache (AC) it pertains to UpdateActionResult().
+	PutAuthorizer *auth.AuthorizerConfiguration `protobuf:"bytes,3,opt,name=put_authorizer,json=putAuthorizer,proto3" json:"put_authorizer,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *NonScannableBlobAccessConfiguration) Reset() {
 	*x = NonScannableBlobAccessConfiguration{}
-	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_bb_storage_bb_storage_proto_msgTypes[1]
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_bb_storage_bb_storage_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -174,7 +501,7 @@ func (x *NonScannableBlobAccessConfiguration) String() string {
 func (*NonScannableBlobAccessConfiguration) ProtoMessage() {}
 
 func (x *NonScannableBlobAccessConfiguration) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_bb_storage_bb_storage_proto_msgTypes[1]
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_bb_storage_bb_storage_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -187,7 +514,7 @@ func (x *NonScannableBlobAccessConfiguration) ProtoReflect() protoreflect.Messag
 
 // Deprecated: Use NonScannableBlobAccessConfiguration.ProtoReflect.Descriptor instead.
 func (*NonScannableBlobAccessConfiguration) Descriptor() ([]byte, []int) {
-	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_bb_storage_bb_storage_proto_rawDescGZIP(), []int{1}
+	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_bb_storage_bb_storage_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *NonScannableBlobAccessConfiguration) GetBackend() *blobstore.BlobAccessConfiguration {
@@ -211,19 +538,28 @@ func (x *NonScannableBlobAccessConfiguration) GetPutAuthorizer() *auth.Authorize
 	return nil
 }
 
+// Storage configuration for backends which allow batch digest scanning.
 type ScannableBlobAccessConfiguration struct {
-	state                 protoimpl.MessageState             `protogen:"open.v1"`
-	Backend               *blobstore.BlobAccessConfiguration `protobuf:"bytes,1,opt,name=backend,proto3" json:"backend,omitempty"`
-	GetAuthorizer         *auth.AuthorizerConfiguration      `protobuf:"bytes,2,opt,name=get_authorizer,json=getAuthorizer,proto3" json:"get_authorizer,omitempty"`
-	PutAuthorizer         *auth.AuthorizerConfiguration      `protobuf:"bytes,3,opt,name=put_authorizer,json=putAuthorizer,proto3" json:"put_authorizer,omitempty"`
-	FindMissingAuthorizer *auth.AuthorizerConfiguration      `protobuf:"bytes,4,opt,name=find_missing_authorizer,json=findMissingAuthorizer,proto3" json:"find_missing_authorizer,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Storage backend.
+	Backend *blobstore.BlobAccessConfiguration `protobuf:"bytes,1,opt,name=backend,proto3" json:"backend,omitempty"`
+	// The authorizer for determining whether a client may read from storage.
+	GetAuthorizer *auth.AuthorizerConfiguration `protobuf:"bytes,2,opt,name=get_authorizer,json=getAuthorizer,proto3" json:"get_authorizer,omitempty"`
+	// The authorizer for determining whether a client may write to storage.
+	// For example, in case of the Content Addressable Storage (CAS),
+	// it pertains to ByteStream.Write() and BatchUpdateBlobs() operations,
+	// while for the Action Cache (AC) it pertains to UpdateActionResult().
+	PutAuthorizer *auth.AuthorizerConfiguration `protobuf:"bytes,3,opt,name=put_authorizer,json=putAuthorizer,proto3" json:"put_authorizer,omitempty"`
+	// The authorizer for determining whether a client may scan storage
+	// for the existence of a batch of digests.
+	FindMissingAuthorizer *auth.AuthorizerConfiguration `protobuf:"bytes,4,opt,name=find_missing_authorizer,json=findMissingAuthorizer,proto3" json:"find_missing_authorizer,omitempty"`
 	unknownFields         protoimpl.UnknownFields
 	sizeCache             protoimpl.SizeCache
 }
 
 func (x *ScannableBlobAccessConfiguration) Reset() {
 	*x = ScannableBlobAccessConfiguration{}
-	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_bb_storage_bb_storage_proto_msgTypes[2]
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_bb_storage_bb_storage_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -235,7 +571,7 @@ func (x *ScannableBlobAccessConfiguration) String() string {
 func (*ScannableBlobAccessConfiguration) ProtoMessage() {}
 
 func (x *ScannableBlobAccessConfiguration) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_bb_storage_bb_storage_proto_msgTypes[2]
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_bb_storage_bb_storage_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -248,7 +584,7 @@ func (x *ScannableBlobAccessConfiguration) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ScannableBlobAccessConfiguration.ProtoReflect.Descriptor instead.
 func (*ScannableBlobAccessConfiguration) Descriptor() ([]byte, []int) {
-	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_bb_storage_bb_storage_proto_rawDescGZIP(), []int{2}
+	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_bb_storage_bb_storage_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *ScannableBlobAccessConfiguration) GetBackend() *blobstore.BlobAccessConfiguration {
@@ -279,12 +615,139 @@ func (x *ScannableBlobAccessConfiguration) GetFindMissingAuthorizer() *auth.Auth
 	return nil
 }
 
+type KeyValueHTTPCacheConfiguration struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// HTTP servers to spawn to listen for requests from clients.
+	//
+	// Values are stored by issuing PUT requests, and retrieved by issuing
+	// GET and HEAD requests. The full path of the request is used as the
+	// key.
+	HttpServers []*server.Configuration `protobuf:"bytes,1,rep,name=http_servers,json=httpServers,proto3" json:"http_servers,omitempty"`
+	// The instance name under which keys and values are stored in the
+	// Action Cache and Content Addressable Storage, respectively.
+	InstanceName string `protobuf:"bytes,2,opt,name=instance_name,json=instanceName,proto3" json:"instance_name,omitempty"`
+	// The digest function that is used to compute the digests of keys
+	// and values.
+	DigestFunction v2.DigestFunction_Value `protobuf:"varint,3,opt,name=digest_function,json=digestFunction,proto3,enum=build.bazel.remote.execution.v2.DigestFunction_Value" json:"digest_function,omitempty"`
+	// The maximum size of values that may be stored. As values are
+	// buffered in memory during uploads, this value should be kept
+	// reasonably small.
+	MaximumValueSizeBytes int64 `protobuf:"varint,4,opt,name=maximum_value_size_bytes,json=maximumValueSizeBytes,proto3" json:"maximum_value_size_bytes,omitempty"`
+	unknownFields         protoimpl.UnknownFields
+	sizeCache             protoimpl.SizeCache
+}
+
+func (x *KeyValueHTTPCacheConfiguration) Reset() {
+	*x = KeyValueHTTPCacheConfiguration{}
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_bb_storage_bb_storage_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KeyValueHTTPCacheConfiguration) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeyValueHTTPCacheConfiguration) ProtoMessage() {}
+
+func (x *KeyValueHTTPCacheConfiguration) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_bb_storage_bb_storage_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeyValueHTTPCacheConfiguration.ProtoReflect.Descriptor instead.
+func (*KeyValueHTTPCacheConfiguration) Descriptor() ([]byte, []int) {
+	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_bb_storage_bb_storage_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *KeyValueHTTPCacheConfiguration) GetHttpServers() []*server.Configuration {
+	if x != nil {
+		return x.HttpServers
+	}
+	return nil
+}
+
+func (x *KeyValueHTTPCacheConfiguration) GetInstanceName() string {
+	if x != nil {
+		return x.InstanceName
+	}
+	return ""
+}
+
+func (x *KeyValueHTTPCacheConfiguration) GetDigestFunction() v2.DigestFunction_Value {
+	if x != nil {
+		return x.DigestFunction
+	}
+	return v2.DigestFunction_Value(0)
+}
+
+func (x *KeyValueHTTPCacheConfiguration) GetMaximumValueSizeBytes() int64 {
+	if x != nil {
+		return x.MaximumValueSizeBytes
+	}
+	return 0
+}
+
+type BazelHTTPCacheConfiguration_BlobSizeIndexConfiguration struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The instance name under which the sizes of objects are stored
+	// in the Action Cache. This instance name should not be used by
+	// clients, as entries stored by clients using Bazel's HTTP
+	// caching protocol may otherwise collide with the index.
+	InstanceName  string `protobuf:"bytes,1,opt,name=instance_name,json=instanceName,proto3" json:"instance_name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BazelHTTPCacheConfiguration_BlobSizeIndexConfiguration) Reset() {
+	*x = BazelHTTPCacheConfiguration_BlobSizeIndexConfiguration{}
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_bb_storage_bb_storage_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BazelHTTPCacheConfiguration_BlobSizeIndexConfiguration) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BazelHTTPCacheConfiguration_BlobSizeIndexConfiguration) ProtoMessage() {}
+
+func (x *BazelHTTPCacheConfiguration_BlobSizeIndexConfiguration) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_bb_storage_bb_storage_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BazelHTTPCacheConfiguration_BlobSizeIndexConfiguration.ProtoReflect.Descriptor instead.
+func (*BazelHTTPCacheConfiguration_BlobSizeIndexConfiguration) Descriptor() ([]byte, []int) {
+	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_bb_storage_bb_storage_proto_rawDescGZIP(), []int{1, 0}
+}
+
+func (x *BazelHTTPCacheConfiguration_BlobSizeIndexConfiguration) GetInstanceName() string {
+	if x != nil {
+		return x.InstanceName
+	}
+	return ""
+}
+
 var File_github_com_buildbarn_bb_storage_pkg_proto_configuration_bb_storage_bb_storage_proto protoreflect.FileDescriptor
 
 const file_github_com_buildbarn_bb_storage_pkg_proto_configuration_bb_storage_bb_storage_proto_rawDesc = "" +
 	"\n" +
-	"Sgithub.com/buildbarn/bb-storage/pkg/proto/configuration/bb_storage/bb_storage.proto\x12\"buildbarn.configuration.bb_storage\x1a6build/bazel/remote/execution/v2/remote_execution.proto\x1aGgithub.com/buildbarn/bb-storage/pkg/proto/configuration/auth/auth.proto\x1aQgithub.com/buildbarn/bb-storage/pkg/proto/configuration/blobstore/blobstore.proto\x1aMgithub.com/buildbarn/bb-storage/pkg/proto/configuration/builder/builder.proto\x1aKgithub.com/buildbarn/bb-storage/pkg/proto/configuration/global/global.proto\x1aGgithub.com/buildbarn/bb-storage/pkg/proto/configuration/grpc/grpc.proto\"\xef\n" +
-	"\n" +
+	"Sgithub.com/buildbarn/bb-storage/pkg/proto/configuration/bb_storage/bb_storage.proto\x12\"buildbarn.configuration.bb_storage\x1a6build/bazel/remote/execution/v2/remote_execution.proto\x1aGgithub.com/buildbarn/bb-storage/pkg/proto/configuration/auth/auth.proto\x1aQgithub.com/buildbarn/bb-storage/pkg/proto/configuration/blobstore/blobstore.proto\x1aMgithub.com/buildbarn/bb-storage/pkg/proto/configuration/builder/builder.proto\x1aOgithub.com/buildbarn/bb-storage/pkg/proto/configuration/eviction/eviction.proto\x1aKgithub.com/buildbarn/bb-storage/pkg/proto/configuration/global/global.proto\x1aGgithub.com/buildbarn/bb-storage/pkg/proto/configuration/grpc/grpc.proto\x1aPgithub.com/buildbarn/bb-storage/pkg/proto/configuration/http/server/server.proto\x1a\x1egoogle/protobuf/duration.proto\"\x9b\x0f\n" +
 	"\x18ApplicationConfiguration\x12T\n" +
 	"\fgrpc_servers\x18\x04 \x03(\v21.buildbarn.configuration.grpc.ServerConfigurationR\vgrpcServers\x12l\n" +
 	"\n" +
@@ -299,10 +762,29 @@ const file_github_com_buildbarn_bb_storage_pkg_proto_configuration_bb_storage_bb
 	"\x18initial_size_class_cache\x18\v \x01(\v2G.buildbarn.configuration.bb_storage.NonScannableBlobAccessConfigurationR\x15initialSizeClassCache\x12\x80\x01\n" +
 	"\x18file_system_access_cache\x18\x13 \x01(\v2G.buildbarn.configuration.bb_storage.NonScannableBlobAccessConfigurationR\x15fileSystemAccessCache\x12d\n" +
 	"\x12execute_authorizer\x18\x10 \x01(\v25.buildbarn.configuration.auth.AuthorizerConfigurationR\x11executeAuthorizer\x12f\n" +
-	"\x15supported_compressors\x18\x14 \x03(\x0e21.build.bazel.remote.execution.v2.Compressor.ValueR\x14supportedCompressors\x1av\n" +
+	"\x15supported_compressors\x18\x14 \x03(\x0e21.build.bazel.remote.execution.v2.Compressor.ValueR\x14supportedCompressors\x12\x80\x01\n" +
+	"\x18content_defined_chunking\x18\x15 \x01(\v2F.buildbarn.configuration.blobstore.ContentDefinedChunkingConfigurationR\x16contentDefinedChunking\x12n\n" +
+	"\x11resumable_uploads\x18\x16 \x01(\v2A.buildbarn.configuration.bb_storage.ResumableUploadsConfigurationR\x10resumableUploads\x12U\n" +
+	"(maximum_action_result_inlined_size_bytes\x18\x17 \x01(\x03R#maximumActionResultInlinedSizeBytes\x12i\n" +
+	"\x10bazel_http_cache\x18\x18 \x01(\v2?.buildbarn.configuration.bb_storage.BazelHTTPCacheConfigurationR\x0ebazelHttpCache\x12u\n" +
+	"\x15key_value_http_caches\x18\x19 \x03(\v2B.buildbarn.configuration.bb_storage.KeyValueHTTPCacheConfigurationR\x12keyValueHttpCaches\x1av\n" +
 	"\x0fSchedulersEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12M\n" +
-	"\x05value\x18\x02 \x01(\v27.buildbarn.configuration.builder.SchedulerConfigurationR\x05value:\x028\x01J\x04\b\x01\x10\x02J\x04\b\x02\x10\x03J\x04\b\x03\x10\x04J\x04\b\x06\x10\aJ\x04\b\a\x10\bJ\x04\b\f\x10\rJ\x04\b\r\x10\x0eJ\x04\b\x0e\x10\x0fJ\x04\b\x0f\x10\x10\"\xb7\x02\n" +
+	"\x05value\x18\x02 \x01(\v27.buildbarn.configuration.builder.SchedulerConfigurationR\x05value:\x028\x01J\x04\b\x01\x10\x02J\x04\b\x02\x10\x03J\x04\b\x03\x10\x04J\x04\b\x06\x10\aJ\x04\b\a\x10\bJ\x04\b\f\x10\rJ\x04\b\r\x10\x0eJ\x04\b\x0e\x10\x0fJ\x04\b\x0f\x10\x10\"\xf4\x03\n" +
+	"\x1bBazelHTTPCacheConfiguration\x12U\n" +
+	"\fhttp_servers\x18\x01 \x03(\v22.buildbarn.configuration.http.server.ConfigurationR\vhttpServers\x12/\n" +
+	"\x14blob_size_cache_size\x18\x02 \x01(\x03R\x11blobSizeCacheSize\x12\x84\x01\n" +
+	"\"blob_size_cache_replacement_policy\x18\x03 \x01(\x0e28.buildbarn.configuration.eviction.CacheReplacementPolicyR\x1eblobSizeCacheReplacementPolicy\x12\x82\x01\n" +
+	"\x0fblob_size_index\x18\x04 \x01(\v2Z.buildbarn.configuration.bb_storage.BazelHTTPCacheConfiguration.BlobSizeIndexConfigurationR\rblobSizeIndex\x1aA\n" +
+	"\x1aBlobSizeIndexConfiguration\x12#\n" +
+	"\rinstance_name\x18\x01 \x01(\tR\finstanceName\"\xf5\x01\n" +
+	"\x1dResumableUploadsConfiguration\x124\n" +
+	"\x16staging_directory_path\x18\x01 \x01(\tR\x14stagingDirectoryPath\x12,\n" +
+	"\x12maximum_size_bytes\x18\x02 \x01(\x03R\x10maximumSizeBytes\x129\n" +
+	"\n" +
+	"expiration\x18\x03 \x01(\v2\x19.google.protobuf.DurationR\n" +
+	"expiration\x125\n" +
+	"\x17minimum_blob_size_bytes\x18\x04 \x01(\x03R\x14minimumBlobSizeBytes\"\xb7\x02\n" +
 	"#NonScannableBlobAccessConfiguration\x12T\n" +
 	"\abackend\x18\x01 \x01(\v2:.buildbarn.configuration.blobstore.BlobAccessConfigurationR\abackend\x12\\\n" +
 	"\x0eget_authorizer\x18\x02 \x01(\v25.buildbarn.configuration.auth.AuthorizerConfigurationR\rgetAuthorizer\x12\\\n" +
@@ -311,7 +793,12 @@ const file_github_com_buildbarn_bb_storage_pkg_proto_configuration_bb_storage_bb
 	"\abackend\x18\x01 \x01(\v2:.buildbarn.configuration.blobstore.BlobAccessConfigurationR\abackend\x12\\\n" +
 	"\x0eget_authorizer\x18\x02 \x01(\v25.buildbarn.configuration.auth.AuthorizerConfigurationR\rgetAuthorizer\x12\\\n" +
 	"\x0eput_authorizer\x18\x03 \x01(\v25.buildbarn.configuration.auth.AuthorizerConfigurationR\rputAuthorizer\x12m\n" +
-	"\x17find_missing_authorizer\x18\x04 \x01(\v25.buildbarn.configuration.auth.AuthorizerConfigurationR\x15findMissingAuthorizerBDZBgithub.com/buildbarn/bb-storage/pkg/proto/configuration/bb_storageb\x06proto3"
+	"\x17find_missing_authorizer\x18\x04 \x01(\v25.buildbarn.configuration.auth.AuthorizerConfigurationR\x15findMissingAuthorizer\"\xb5\x02\n" +
+	"\x1eKeyValueHTTPCacheConfiguration\x12U\n" +
+	"\fhttp_servers\x18\x01 \x03(\v22.buildbarn.configuration.http.server.ConfigurationR\vhttpServers\x12#\n" +
+	"\rinstance_name\x18\x02 \x01(\tR\finstanceName\x12^\n" +
+	"\x0fdigest_function\x18\x03 \x01(\x0e25.build.bazel.remote.execution.v2.DigestFunction.ValueR\x0edigestFunction\x127\n" +
+	"\x18maximum_value_size_bytes\x18\x04 \x01(\x03R\x15maximumValueSizeBytesBDZBgithub.com/buildbarn/bb-storage/pkg/proto/configuration/bb_storageb\x06proto3"
 
 var (
 	file_github_com_buildbarn_bb_storage_pkg_proto_configuration_bb_storage_bb_storage_proto_rawDescOnce sync.Once
@@ -325,43 +812,62 @@ func file_github_com_buildbarn_bb_storage_pkg_proto_configuration_bb_storage_bb_
 	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_bb_storage_bb_storage_proto_rawDescData
 }
 
-var file_github_com_buildbarn_bb_storage_pkg_proto_configuration_bb_storage_bb_storage_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_github_com_buildbarn_bb_storage_pkg_proto_configuration_bb_storage_bb_storage_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
 var file_github_com_buildbarn_bb_storage_pkg_proto_configuration_bb_storage_bb_storage_proto_goTypes = []any{
-	(*ApplicationConfiguration)(nil),            // 0: buildbarn.configuration.bb_storage.ApplicationConfiguration
-	(*NonScannableBlobAccessConfiguration)(nil), // 1: buildbarn.configuration.bb_storage.NonScannableBlobAccessConfiguration
-	(*ScannableBlobAccessConfiguration)(nil),    // 2: buildbarn.configuration.bb_storage.ScannableBlobAccessConfiguration
-	nil,                                         // 3: buildbarn.configuration.bb_storage.ApplicationConfiguration.SchedulersEntry
-	(*grpc.ServerConfiguration)(nil),            // 4: buildbarn.configuration.grpc.ServerConfiguration
-	(*global.Configuration)(nil),                // 5: buildbarn.configuration.global.Configuration
-	(*auth.AuthorizerConfiguration)(nil),        // 6: buildbarn.configuration.auth.AuthorizerConfiguration
-	(v2.Compressor_Value)(0),                    // 7: build.bazel.remote.execution.v2.Compressor.Value
-	(*blobstore.BlobAccessConfiguration)(nil),   // 8: buildbarn.configuration.blobstore.BlobAccessConfiguration
-	(*builder.SchedulerConfiguration)(nil),      // 9: buildbarn.configuration.builder.SchedulerConfiguration
+	(*ApplicationConfiguration)(nil),                               // 0: buildbarn.configuration.bb_storage.ApplicationConfiguration
+	(*BazelHTTPCacheConfiguration)(nil),                            // 1: buildbarn.configuration.bb_storage.BazelHTTPCacheConfiguration
+	(*ResumableUploadsConfiguration)(nil),                          // 2: buildbarn.configuration.bb_storage.ResumableUploadsConfiguration
+	(*NonScannableBlobAccessConfiguration)(nil),                    // 3: buildbarn.configuration.bb_storage.NonScannableBlobAccessConfiguration
+	(*ScannableBlobAccessConfiguration)(nil),                       // 4: buildbarn.configuration.bb_storage.ScannableBlobAccessConfiguration
+	(*KeyValueHTTPCacheConfiguration)(nil),                         // 5: buildbarn.configuration.bb_storage.KeyValueHTTPCacheConfiguration
+	nil,                                                            // 6: buildbarn.configuration.bb_storage.ApplicationConfiguration.SchedulersEntry
+	(*BazelHTTPCacheConfiguration_BlobSizeIndexConfiguration)(nil), // 7: buildbarn.configuration.bb_storage.BazelHTTPCacheConfiguration.BlobSizeIndexConfiguration
+	(*grpc.ServerConfiguration)(nil),                               // 8: buildbarn.configuration.grpc.ServerConfiguration
+	(*global.Configuration)(nil),                                   // 9: buildbarn.configuration.global.Configuration
+	(*auth.AuthorizerConfiguration)(nil),                           // 10: buildbarn.configuration.auth.AuthorizerConfiguration
+	(v2.Compressor_Value)(0),                                       // 11: build.bazel.remote.execution.v2.Compressor.Value
+	(*blobstore.ContentDefinedChunkingConfiguration)(nil),          // 12: buildbarn.configuration.blobstore.ContentDefinedChunkingConfiguration
+	(*server.Configuration)(nil),                                   // 13: buildbarn.configuration.http.server.Configuration
+	(eviction.CacheReplacementPolicy)(0),                           // 14: buildbarn.configuration.eviction.CacheReplacementPolicy
+	(*durationpb.Duration)(nil),                                    // 15: google.protobuf.Duration
+	(*blobstore.BlobAccessConfiguration)(nil),                      // 16: buildbarn.configuration.blobstore.BlobAccessConfiguration
+	(v2.DigestFunction_Value)(0),                                   // 17: build.bazel.remote.execution.v2.DigestFunction.Value
+	(*builder.SchedulerConfiguration)(nil),                         // 18: buildbarn.configuration.builder.SchedulerConfiguration
 }
 var file_github_com_buildbarn_bb_storage_pkg_proto_configuration_bb_storage_bb_storage_proto_depIdxs = []int32{
-	4,  // 0: buildbarn.configuration.bb_storage.ApplicationConfiguration.grpc_servers:type_name -> buildbarn.configuration.grpc.ServerConfiguration
-	3,  // 1: buildbarn.configuration.bb_storage.ApplicationConfiguration.schedulers:type_name -> buildbarn.configuration.bb_storage.ApplicationConfiguration.SchedulersEntry
-	5,  // 2: buildbarn.configuration.bb_storage.ApplicationConfiguration.global:type_name -> buildbarn.configuration.global.Configuration
-	2,  // 3: buildbarn.configuration.bb_storage.ApplicationConfiguration.content_addressable_storage:type_name -> buildbarn.configuration.bb_storage.ScannableBlobAccessConfiguration
-	1,  // 4: buildbarn.configuration.bb_storage.ApplicationConfiguration.action_cache:type_name -> buildbarn.configuration.bb_storage.NonScannableBlobAccessConfiguration
-	2,  // 5: buildbarn.configuration.bb_storage.ApplicationConfiguration.indirect_content_addressable_storage:type_name -> buildbarn.configuration.bb_storage.ScannableBlobAccessConfiguration
-	1,  // 6: buildbarn.configuration.bb_storage.ApplicationConfiguration.initial_size_class_cache:type_name -> buildbarn.configuration.bb_storage.NonScannableBlobAccessConfiguration
-	1,  // 7: buildbarn.configuration.bb_storage.ApplicationConfiguration.file_system_access_cache:type_name -> buildbarn.configuration.bb_storage.NonScannableBlobAccessConfiguration
-	6,  // 8: buildbarn.configuration.bb_storage.ApplicationConfiguration.execute_authorizer:type_name -> buildbarn.configuration.auth.AuthorizerConfiguration
-	7,  // 9: buildbarn.configuration.bb_storage.ApplicationConfiguration.supported_compressors:type_name -> build.bazel.remote.execution.v2.Compressor.Value
-	8,  // 10: buildbarn.configuration.bb_storage.NonScannableBlobAccessConfiguration.backend:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
-	6,  // 11: buildbarn.configuration.bb_storage.NonScannableBlobAccessConfiguration.get_authorizer:type_name -> buildbarn.configuration.auth.AuthorizerConfiguration
-	6,  // 12: buildbarn.configuration.bb_storage.NonScannableBlobAccessConfiguration.put_authorizer:type_name -> buildbarn.configuration.auth.AuthorizerConfiguration
-	8,  // 13: buildbarn.configuration.bb_storage.ScannableBlobAccessConfiguration.backend:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
-	6,  // 14: buildbarn.configuration.bb_storage.ScannableBlobAccessConfiguration.get_authorizer:type_name -> buildbarn.configuration.auth.AuthorizerConfiguration
-	6,  // 15: buildbarn.configuration.bb_storage.ScannableBlobAccessConfiguration.put_authorizer:type_name -> buildbarn.configuration.auth.AuthorizerConfiguration
-	6,  // 16: buildbarn.configuration.bb_storage.ScannableBlobAccessConfiguration.find_missing_authorizer:type_name -> buildbarn.configuration.auth.AuthorizerConfiguration
-	9,  // 17: buildbarn.configuration.bb_storage.ApplicationConfiguration.SchedulersEntry.value:type_name -> buildbarn.configuration.builder.SchedulerConfiguration
-	18, // [18:18] is the sub-list for method output_type
-	18, // [18:18] is the sub-list for method input_type
-	18, // [18:18] is the sub-list for extension type_name
-	18, // [18:18] is the sub-list for extension extendee
-	0,  // [0:18] is the sub-list for field type_name
+	8,  // 0: buildbarn.configuration.bb_storage.ApplicationConfiguration.grpc_servers:type_name -> buildbarn.configuration.grpc.ServerConfiguration
+	6,  // 1: buildbarn.configuration.bb_storage.ApplicationConfiguration.schedulers:type_name -> buildbarn.configuration.bb_storage.ApplicationConfiguration.SchedulersEntry
+	9,  // 2: buildbarn.configuration.bb_storage.ApplicationConfiguration.global:type_name -> buildbarn.configuration.global.Configuration
+	4,  // 3: buildbarn.configuration.bb_storage.ApplicationConfiguration.content_addressable_storage:type_name -> buildbarn.configuration.bb_storage.ScannableBlobAccessConfiguration
+	3,  // 4: buildbarn.configuration.bb_storage.ApplicationConfiguration.action_cache:type_name -> buildbarn.configuration.bb_storage.NonScannableBlobAccessConfiguration
+	4,  // 5: buildbarn.configuration.bb_storage.ApplicationConfiguration.indirect_content_addressable_storage:type_name -> buildbarn.configuration.bb_storage.ScannableBlobAccessConfiguration
+	3,  // 6: buildbarn.configuration.bb_storage.ApplicationConfiguration.initial_size_class_cache:type_name -> buildbarn.configuration.bb_storage.NonScannableBlobAccessConfiguration
+	3,  // 7: buildbarn.configuration.bb_storage.ApplicationConfiguration.file_system_access_cache:type_name -> buildbarn.configuration.bb_storage.NonScannableBlobAccessConfiguration
+	10, // 8: buildbarn.configuration.bb_storage.ApplicationConfiguration.execute_authorizer:type_name -> buildbarn.configuration.auth.AuthorizerConfiguration
+	11, // 9: buildbarn.configuration.bb_storage.ApplicationConfiguration.supported_compressors:type_name -> build.bazel.remote.execution.v2.Compressor.Value
+	12, // 10: buildbarn.configuration.bb_storage.ApplicationConfiguration.content_defined_chunking:type_name -> buildbarn.configuration.blobstore.ContentDefinedChunkingConfiguration
+	2,  // 11: buildbarn.configuration.bb_storage.ApplicationConfiguration.resumable_uploads:type_name -> buildbarn.configuration.bb_storage.ResumableUploadsConfiguration
+	1,  // 12: buildbarn.configuration.bb_storage.ApplicationConfiguration.bazel_http_cache:type_name -> buildbarn.configuration.bb_storage.BazelHTTPCacheConfiguration
+	5,  // 13: buildbarn.configuration.bb_storage.ApplicationConfiguration.key_value_http_caches:type_name -> buildbarn.configuration.bb_storage.KeyValueHTTPCacheConfiguration
+	13, // 14: buildbarn.configuration.bb_storage.BazelHTTPCacheConfiguration.http_servers:type_name -> buildbarn.configuration.http.server.Configuration
+	14, // 15: buildbarn.configuration.bb_storage.BazelHTTPCacheConfiguration.blob_size_cache_replacement_policy:type_name -> buildbarn.configuration.eviction.CacheReplacementPolicy
+	7,  // 16: buildbarn.configuration.bb_storage.BazelHTTPCacheConfiguration.blob_size_index:type_name -> buildbarn.configuration.bb_storage.BazelHTTPCacheConfiguration.BlobSizeIndexConfiguration
+	15, // 17: buildbarn.configuration.bb_storage.ResumableUploadsConfiguration.expiration:type_name -> google.protobuf.Duration
+	16, // 18: buildbarn.configuration.bb_storage.NonScannableBlobAccessConfiguration.backend:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
+	10, // 19: buildbarn.configuration.bb_storage.NonScannableBlobAccessConfiguration.get_authorizer:type_name -> buildbarn.configuration.auth.AuthorizerConfiguration
+	10, // 20: buildbarn.configuration.bb_storage.NonScannableBlobAccessConfiguration.put_authorizer:type_name -> buildbarn.configuration.auth.AuthorizerConfiguration
+	16, // 21: buildbarn.configuration.bb_storage.ScannableBlobAccessConfiguration.backend:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
+	10, // 22: buildbarn.configuration.bb_storage.ScannableBlobAccessConfiguration.get_authorizer:type_name -> buildbarn.configuration.auth.AuthorizerConfiguration
+	10, // 23: buildbarn.configuration.bb_storage.ScannableBlobAccessConfiguration.put_authorizer:type_name -> buildbarn.configuration.auth.AuthorizerConfiguration
+	10, // 24: buildbarn.configuration.bb_storage.ScannableBlobAccessConfiguration.find_missing_authorizer:type_name -> buildbarn.configuration.auth.AuthorizerConfiguration
+	13, // 25: buildbarn.configuration.bb_storage.KeyValueHTTPCacheConfiguration.http_servers:type_name -> buildbarn.configuration.http.server.Configuration
+	17, // 26: buildbarn.configuration.bb_storage.KeyValueHTTPCacheConfiguration.digest_function:type_name -> build.bazel.remote.execution.v2.DigestFunction.Value
+	18, // 27: buildbarn.configuration.bb_storage.ApplicationConfiguration.SchedulersEntry.value:type_name -> buildbarn.configuration.builder.SchedulerConfiguration
+	28, // [28:28] is the sub-list for method output_type
+	28, // [28:28] is the sub-list for method input_type
+	28, // [28:28] is the sub-list for extension type_name
+	28, // [28:28] is the sub-list for extension extendee
+	0,  // [0:28] is the sub-list for field type_name
 }
 
 func init() {
@@ -377,7 +883,7 @@ func file_github_com_buildbarn_bb_storage_pkg_proto_configuration_bb_storage_bb_
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_github_com_buildbarn_bb_storage_pkg_proto_configuration_bb_storage_bb_storage_proto_rawDesc), len(file_github_com_buildbarn_bb_storage_pkg_proto_configuration_bb_storage_bb_storage_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   4,
+			NumMessages:   8,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -7,12 +7,16 @@
 package blobstore
 
 import (
+	v2 "github.com/bazelbuild/remote-apis/build/bazel/remote/execution/v2"
 	blockdevice "github.com/buildbarn/bb-storage/pkg/proto/configuration/blockdevice"
 	aws "github.com/buildbarn/bb-storage/pkg/proto/configuration/cloud/aws"
 	gcp "github.com/buildbarn/bb-storage/pkg/proto/configuration/cloud/gcp"
 	digest "github.com/buildbarn/bb-storage/pkg/proto/configuration/digest"
+	eviction "github.com/buildbarn/bb-storage/pkg/proto/configuration/eviction"
 	grpc "github.com/buildbarn/bb-storage/pkg/proto/configuration/grpc"
 	client "github.com/buildbarn/bb-storage/pkg/proto/configuration/http/client"
+	jmespath "github.com/buildbarn/bb-storage/pkg/proto/configuration/jmespath"
+	tls "github.com/buildbarn/bb-storage/pkg/proto/configuration/tls"
 	status "google.golang.org/genproto/googleapis/rpc/status"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
@@ -31,12 +35,15 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// Storage configuration for Bazel Buildbarn.
 type BlobstoreConfiguration struct {
-	state                     protoimpl.MessageState   `protogen:"open.v1"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Storage configuration for the Content Addressable Storage (CAS).
 	ContentAddressableStorage *BlobAccessConfiguration `protobuf:"bytes,1,opt,name=content_addressable_storage,json=contentAddressableStorage,proto3" json:"content_addressable_storage,omitempty"`
-	ActionCache               *BlobAccessConfiguration `protobuf:"bytes,2,opt,name=action_cache,json=actionCache,proto3" json:"action_cache,omitempty"`
-	unknownFields             protoimpl.UnknownFields
-	sizeCache                 protoimpl.SizeCache
+	// Storage configuration for the Action Cache (AC).
+	ActionCache   *BlobAccessConfiguration `protobuf:"bytes,2,opt,name=action_cache,json=actionCache,proto3" json:"action_cache,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *BlobstoreConfiguration) Reset() {
@@ -106,6 +113,21 @@ type BlobAccessConfiguration struct {
 	//	*BlobAccessConfiguration_WithLabels
 	//	*BlobAccessConfiguration_Label
 	//	*BlobAccessConfiguration_DeadlineEnforcing
+	//	*BlobAccessConfiguration_CompressedGrpc
+	//	*BlobAccessConfiguration_S3
+	//	*BlobAccessConfiguration_Gcs
+	//	*BlobAccessConfiguration_Directory
+	//	*BlobAccessConfiguration_Redis
+	//	*BlobAccessConfiguration_Http
+	//	*BlobAccessConfiguration_Bolt
+	//	*BlobAccessConfiguration_Oci
+	//	*BlobAccessConfiguration_ErasureCoding
+	//	*BlobAccessConfiguration_Replicated
+	//	*BlobAccessConfiguration_Hedging
+	//	*BlobAccessConfiguration_LatencyAware
+	//	*BlobAccessConfiguration_CircuitBreaker
+	//	*BlobAccessConfiguration_RateLimiting
+	//	*BlobAccessConfiguration_QuotaEnforcing
 	Backend       isBlobAccessConfiguration_Backend `protobuf_oneof:"backend"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
@@ -319,86 +341,555 @@ func (x *BlobAccessConfiguration) GetDeadlineEnforcing() *DeadlineEnforcingBlobA
 	return nil
 }
 
+func (x *BlobAccessConfiguration) GetCompressedGrpc() *CompressedGrpcBlobAccessConfiguration {
+	if x != nil {
+		if x, ok := x.Backend.(*BlobAccessConfiguration_CompressedGrpc); ok {
+			return x.CompressedGrpc
+		}
+	}
+	return nil
+}
+
+func (x *BlobAccessConfiguration) GetS3() *S3BlobAccessConfiguration {
+	if x != nil {
+		if x, ok := x.Backend.(*BlobAccessConfiguration_S3); ok {
+			return x.S3
+		}
+	}
+	return nil
+}
+
+func (x *BlobAccessConfiguration) GetGcs() *GCSBlobAccessConfiguration {
+	if x != nil {
+		if x, ok := x.Backend.(*BlobAccessConfiguration_Gcs); ok {
+			return x.Gcs
+		}
+	}
+	return nil
+}
+
+func (x *BlobAccessConfiguration) GetDirectory() *DirectoryBlobAccessConfiguration {
+	if x != nil {
+		if x, ok := x.Backend.(*BlobAccessConfiguration_Directory); ok {
+			return x.Directory
+		}
+	}
+	return nil
+}
+
+func (x *BlobAccessConfiguration) GetRedis() *RedisBlobAccessConfiguration {
+	if x != nil {
+		if x, ok := x.Backend.(*BlobAccessConfiguration_Redis); ok {
+			return x.Redis
+		}
+	}
+	return nil
+}
+
+func (x *BlobAccessConfiguration) GetHttp() *HTTPBlobAccessConfiguration {
+	if x != nil {
+		if x, ok := x.Backend.(*BlobAccessConfiguration_Http); ok {
+			return x.Http
+		}
+	}
+	return nil
+}
+
+func (x *BlobAccessConfiguration) GetBolt() *BoltBlobAccessConfiguration {
+	if x != nil {
+		if x, ok := x.Backend.(*BlobAccessConfiguration_Bolt); ok {
+			return x.Bolt
+		}
+	}
+	return nil
+}
+
+func (x *BlobAccessConfiguration) GetOci() *OCIBlobAccessConfiguration {
+	if x != nil {
+		if x, ok := x.Backend.(*BlobAccessConfiguration_Oci); ok {
+			return x.Oci
+		}
+	}
+	return nil
+}
+
+func (x *BlobAccessConfiguration) GetErasureCoding() *ErasureCodingBlobAccessConfiguration {
+	if x != nil {
+		if x, ok := x.Backend.(*BlobAccessConfiguration_ErasureCoding); ok {
+			return x.ErasureCoding
+		}
+	}
+	return nil
+}
+
+func (x *BlobAccessConfiguration) GetReplicated() *ReplicatedBlobAccessConfiguration {
+	if x != nil {
+		if x, ok := x.Backend.(*BlobAccessConfiguration_Replicated); ok {
+			return x.Replicated
+		}
+	}
+	return nil
+}
+
+func (x *BlobAccessConfiguration) GetHedging() *HedgingBlobAccessConfiguration {
+	if x != nil {
+		if x, ok := x.Backend.(*BlobAccessConfiguration_Hedging); ok {
+			return x.Hedging
+		}
+	}
+	return nil
+}
+
+func (x *BlobAccessConfiguration) GetLatencyAware() *LatencyAwareBlobAccessConfiguration {
+	if x != nil {
+		if x, ok := x.Backend.(*BlobAccessConfiguration_LatencyAware); ok {
+			return x.LatencyAware
+		}
+	}
+	return nil
+}
+
+func (x *BlobAccessConfiguration) GetCircuitBreaker() *CircuitBreakingBlobAccessConfiguration {
+	if x != nil {
+		if x, ok := x.Backend.(*BlobAccessConfiguration_CircuitBreaker); ok {
+			return x.CircuitBreaker
+		}
+	}
+	return nil
+}
+
+func (x *BlobAccessConfiguration) GetRateLimiting() *RateLimitingBlobAccessConfiguration {
+	if x != nil {
+		if x, ok := x.Backend.(*BlobAccessConfiguration_RateLimiting); ok {
+			return x.RateLimiting
+		}
+	}
+	return nil
+}
+
+func (x *BlobAccessConfiguration) GetQuotaEnforcing() *QuotaEnforcingBlobAccessConfiguration {
+	if x != nil {
+		if x, ok := x.Backend.(*BlobAccessConfiguration_QuotaEnforcing); ok {
+			return x.QuotaEnforcing
+		}
+	}
+	return nil
+}
+
 type isBlobAccessConfiguration_Backend interface {
 	isBlobAccessConfiguration_Backend()
 }
 
 type BlobAccessConfiguration_ReadCaching struct {
+	// Cache reads from a slow remote storage backend into a fast
+	// local storage backend.
 	ReadCaching *ReadCachingBlobAccessConfiguration `protobuf:"bytes,4,opt,name=read_caching,json=readCaching,proto3,oneof"`
 }
 
 type BlobAccessConfiguration_Grpc struct {
+	// Read objects from/write objects to a GRPC service that
+	// implements the remote execution protocol.
 	Grpc *grpc.ClientConfiguration `protobuf:"bytes,7,opt,name=grpc,proto3,oneof"`
 }
 
 type BlobAccessConfiguration_Error struct {
+	// Always fail with a fixed error response.
 	Error *status.Status `protobuf:"bytes,8,opt,name=error,proto3,oneof"`
 }
 
 type BlobAccessConfiguration_Sharding struct {
+	// Fan out requests across multiple storage backends to spread
+	// out load.
 	Sharding *ShardingBlobAccessConfiguration `protobuf:"bytes,9,opt,name=sharding,proto3,oneof"`
 }
 
 type BlobAccessConfiguration_Mirrored struct {
+	// Store blobs in two backends. Blobs present in exactly one backend
+	// are automatically replicated to the other backend.
+	//
+	// This backend does not guarantee high availability, as it does not
+	// function in case one backend is unavailable. Crashed backends
+	// need to be replaced with functional empty instances. These will
+	// be refilled automatically.
 	Mirrored *MirroredBlobAccessConfiguration `protobuf:"bytes,14,opt,name=mirrored,proto3,oneof"`
 }
 
 type BlobAccessConfiguration_Local struct {
+	// Store blobs on the local system.
 	Local *LocalBlobAccessConfiguration `protobuf:"bytes,15,opt,name=local,proto3,oneof"`
 }
 
 type BlobAccessConfiguration_ExistenceCaching struct {
+	// Cache knowledge of which blobs exist locally.
+	//
+	// Bazel doesn't have a client-side cache with knowledge on which
+	// objects are present inside a remote cache. This means that it
+	// will often call ContentAddressableStorage.FindMissingBlobs() with
+	// sets that have a strong overlap with what was requested
+	// previously.
+	//
+	// This decorator can be used to introduce such a cache server side.
+	// It is especially useful for multi-level storage setups. It can
+	// cause a reduction in load on storage nodes when this cache
+	// enabled on frontend nodes.
+	//
+	// It only makes sense to use this decorator for the Content
+	// Addressable Storage, as FindMissingBlobs() is never called
+	// against the Action Cache. The storage backend must also be robust
+	// enough to guarantee that objects don't disappear shortly after
+	// calling ContentAddressableStorage.FindMissingBlobs(), as that
+	// would cause this decorator to cache invalid data.
 	ExistenceCaching *ExistenceCachingBlobAccessConfiguration `protobuf:"bytes,16,opt,name=existence_caching,json=existenceCaching,proto3,oneof"`
 }
 
 type BlobAccessConfiguration_CompletenessChecking struct {
+	// Only return ActionResult messages for which all output files are
+	// present in the Content Addressable Storage (CAS). Certain
+	// clients, such as Bazel, require the use of this decorator. To
+	// reduce latency, it is advised that this decorator is used at the
+	// lowest level that has a full view of the entire CAS.
+	//
+	// This decorator must be placed on the Action Cache.
 	CompletenessChecking *CompletenessCheckingBlobAccessConfiguration `protobuf:"bytes,17,opt,name=completeness_checking,json=completenessChecking,proto3,oneof"`
 }
 
 type BlobAccessConfiguration_ReadFallback struct {
+	// Fall back to reading data from a secondary backend when not found
+	// in the primary backend. Data is written to the primary backend only.
+	//
+	// This backend can be used to integrate external data sets into the
+	// system, e.g. by combining it with reference_expanding.
 	ReadFallback *ReadFallbackBlobAccessConfiguration `protobuf:"bytes,18,opt,name=read_fallback,json=readFallback,proto3,oneof"`
 }
 
 type BlobAccessConfiguration_ReferenceExpanding struct {
+	// Load Reference messages from an Indirect Content Addressable
+	// Storage (ICAS). Expand them by fetching the object from the
+	// location stored in the Reference message. This backend is only
+	// supported for the CAS.
+	//
+	// This backend can be used to integrate external data sets into the
+	// system by combining it with read_fallback.
 	ReferenceExpanding *ReferenceExpandingBlobAccessConfiguration `protobuf:"bytes,19,opt,name=reference_expanding,json=referenceExpanding,proto3,oneof"`
 }
 
 type BlobAccessConfiguration_Demultiplexing struct {
+	// Demultiplex requests across multiple storage backends, based on
+	// the instance name prefix.
+	//
+	// The logic for matching incoming requests and mutating the
+	// instance name in outgoing requests is identical to bb_storage's
+	// 'schedulers' configuration option.
 	Demultiplexing *DemultiplexingBlobAccessConfiguration `protobuf:"bytes,20,opt,name=demultiplexing,proto3,oneof"`
 }
 
 type BlobAccessConfiguration_HierarchicalInstanceNames struct {
+	// Read objects using instance names in a hierarchical fashion. This
+	// means that if an object is written using instance name "foo/bar",
+	// it will be possible to read it using instance names "foo/bar",
+	// "foo/bar/baz", "foo/bar/baz/qux", but not instance names "",
+	// "foo", "foo/xyzzy". In other words, non-empty instance names will
+	// have contents inherited from their parent instance names. In case
+	// multiple instance names contain an object of a given digest, the
+	// one with the longest instance name is preferred.
+	//
+	// For the Action Cache (AC), it is recommended that this decorator
+	// is placed above CompletenessCheckingBlobAccess. This ensures that
+	// resolution continues, even if one or more instance names store an
+	// incomplete ActionResult.
+	//
+	// For every read operation, this decorator may generate a linear
+	// number of operations against the backend, based on the number of
+	// components in the instance name. This is acceptable for
+	// low-throughput data stores such as the Action Cache (AC) and
+	// Initial Size Class Cache (ISCC). For the Content Addressable
+	// Storage (CAS), this approach tends to be too inefficient. For the
+	// CAS, it would also be better to prefer the object with the
+	// shortest instance name, so that sharing of data between instance
+	// names is maximised. This is why this implementation does not
+	// allow enabling this option for the CAS. It is recommended that
+	// the LocalBlobAccessConfiguration.hierarchical_instance_names
+	// option is used instead.
 	HierarchicalInstanceNames *BlobAccessConfiguration `protobuf:"bytes,21,opt,name=hierarchical_instance_names,json=hierarchicalInstanceNames,proto3,oneof"`
 }
 
 type BlobAccessConfiguration_ActionResultExpiring struct {
+	// Hide ActionResult messages in the Action Cache (AC) where the
+	// 'worker_completed_timestamp' field in the ExecutedActionMetadata
+	// is too far in the past. This decorator can be used to ensure that
+	// all targets are rebuilt periodically.
 	ActionResultExpiring *ActionResultExpiringBlobAccessConfiguration `protobuf:"bytes,22,opt,name=action_result_expiring,json=actionResultExpiring,proto3,oneof"`
 }
 
 type BlobAccessConfiguration_ReadCanarying struct {
+	// Send read traffic to a read-only replica, while sending write
+	// traffic to a source of truth. Read traffic may be sent to the
+	// source of truth if the replica is unavailable.
+	//
+	// By default, all requests are sent to the source. For read
+	// requests, this backend periodically sends a single canary request
+	// to the replica. Upon success, all subsequent read requests are
+	// sent to the replica as well. Upon failure, all requests will
+	// continue to go to the source.
+	//
+	// Only infrastructure errors (RPCs failing with INTERNAL,
+	// UNAVAILABLE and UNKNOWN) are considered failures.
 	ReadCanarying *ReadCanaryingBlobAccessConfiguration `protobuf:"bytes,23,opt,name=read_canarying,json=readCanarying,proto3,oneof"`
 }
 
 type BlobAccessConfiguration_ZipReading struct {
+	// Read objects from a ZIP file. Example use cases of this backend
+	// include the following:
+	//
+	// - When used in combination with ReadFallbackBlobAccess, it may be
+	//   used to augment a data store with a set of objects that are
+	//   guaranteed to remain present.
+	// - It may be used to access historical build actions that have
+	//   been archived, so that they can be inspected or rerun.
+	//
+	// If this backend is used as a Content Addressable Storage (CAS),
+	// it will search for files named:
+	//
+	//     ${digestFunction}-${hash}-${sizeBytes}
+	//
+	// For other storage types it will search for files named:
+	//
+	//     ${digestFunction}-${hash}-${sizeBytes}-${instanceName}
 	ZipReading *ZIPBlobAccessConfiguration `protobuf:"bytes,24,opt,name=zip_reading,json=zipReading,proto3,oneof"`
 }
 
 type BlobAccessConfiguration_ZipWriting struct {
+	// Write objects to an uncompressed ZIP file. The resulting ZIP
+	// files can be read back using the 'zip_reading' option.
+	//
+	// This backend does not support reopening existing ZIP files. ZIP
+	// files will always be truncated upon startup. The trailing central
+	// directory is only written upon graceful termination, meaning that
+	// interrupting execution will create a malformed ZIP file.
 	ZipWriting *ZIPBlobAccessConfiguration `protobuf:"bytes,25,opt,name=zip_writing,json=zipWriting,proto3,oneof"`
 }
 
 type BlobAccessConfiguration_WithLabels struct {
+	// Prevent repetition in the BlobAccess configuration by introducing
+	// one or more BlobAccess objects that can later be referred to
+	// using string labels.
+	//
+	// This option does not introduce new kind of actual backend; it's
+	// merely present to allow creating BlobAccess setups that are DAG
+	// (Directed Acyclic Graph) shaped, as opposed to just trees.
 	WithLabels *WithLabelsBlobAccessConfiguration `protobuf:"bytes,26,opt,name=with_labels,json=withLabels,proto3,oneof"`
 }
 
 type BlobAccessConfiguration_Label struct {
+	// Refer to a BlobAccess object declared through 'with_labels'.
 	Label string `protobuf:"bytes,27,opt,name=label,proto3,oneof"`
 }
 
 type BlobAccessConfiguration_DeadlineEnforcing struct {
+	// Sets the timeout of contexts passed to other backends to a known
+	// value. When gRPC calls are timed out a `DEADLINE_EXCEEDED` error
+	// code will be returned.
 	DeadlineEnforcing *DeadlineEnforcingBlobAccess `protobuf:"bytes,28,opt,name=deadline_enforcing,json=deadlineEnforcing,proto3,oneof"`
 }
 
+type BlobAccessConfiguration_CompressedGrpc struct {
+	// Read objects from/write objects to a GRPC service that
+	// implements the remote execution protocol, similar to 'grpc'.
+	// The contents of blobs are transferred through the ByteStream
+	// service in compressed form. This reduces network bandwidth at
+	// the cost of additional CPU usage on both ends.
+	//
+	// This backend is only supported for the Content Addressable
+	// Storage (CAS). The server must support the compression
+	// algorithm that is configured.
+	CompressedGrpc *CompressedGrpcBlobAccessConfiguration `protobuf:"bytes,29,opt,name=compressed_grpc,json=compressedGrpc,proto3,oneof"`
+}
+
+type BlobAccessConfiguration_S3 struct {
+	// Store objects in an S3 bucket, or a bucket of an S3 compatible
+	// object store such as MinIO. Objects are stored under keys of the
+	// following format:
+	//
+	//     ${keyPrefix}${digestFunction}-${hash}-${sizeBytes}
+	//
+	// or, for storage types that are keyed by instance name:
+	//
+	//     ${keyPrefix}${digestFunction}-${hash}-${sizeBytes}-${instanceName}
+	//
+	// As S3 has a relatively high time to first byte and no facilities
+	// for performing bulk existence checks, it is recommended to only
+	// use this backend as a durable second tier, placed behind a
+	// 'local' backend using 'read_caching' or 'mirrored'.
+	S3 *S3BlobAccessConfiguration `protobuf:"bytes,30,opt,name=s3,proto3,oneof"`
+}
+
+type BlobAccessConfiguration_Gcs struct {
+	// Store objects in a Google Cloud Storage (GCS) bucket. Objects
+	// are named using the same scheme as the 's3' backend.
+	//
+	// Upon creation and when their existence is checked, the custom
+	// time of objects is updated. This permits the use of bucket
+	// lifecycle rules having the 'daysSinceCustomTime' condition to
+	// remove objects that have not been used recently. As objects are
+	// not refreshed when only being read, such rules should not be
+	// used when this backend is used for the Action Cache.
+	Gcs *GCSBlobAccessConfiguration `protobuf:"bytes,31,opt,name=gcs,proto3,oneof"`
+}
+
+type BlobAccessConfiguration_Directory struct {
+	// Store objects as individual files in a directory on a local or
+	// network file system. Unlike 'local', this backend does not
+	// require storage to be preallocated, making it suitable for
+	// small deployments and personal use.
+	//
+	// Files are stored in subdirectories named after the first two
+	// characters of the hash of the object. Upon access, the
+	// modification time of files is updated, which allows the state of
+	// the cache replacement policy to be reconstructed after restarts.
+	// The directory must not be shared between multiple processes.
+	Directory *DirectoryBlobAccessConfiguration `protobuf:"bytes,32,opt,name=directory,proto3,oneof"`
+}
+
+type BlobAccessConfiguration_Redis struct {
+	// Store objects in a Redis compatible key-value store, such as
+	// Redis, Valkey or KeyDB. Both standalone servers and clusters are
+	// supported. Objects are stored under keys that use the same
+	// naming scheme as the 's3' backend.
+	//
+	// As objects are held in memory in their entirety, this backend is
+	// only suitable for storing small objects. It can be used to store
+	// the Action Cache (AC), Initial Size Class Cache (ISCC) and File
+	// System Access Cache (FSAC) in a location that is shared by
+	// multiple frontends.
+	Redis *RedisBlobAccessConfiguration `protobuf:"bytes,33,opt,name=redis,proto3,oneof"`
+}
+
+type BlobAccessConfiguration_Http struct {
+	// Store objects on a server that implements the HTTP caching
+	// protocol supported by Bazel's --remote_cache=https://... flag,
+	// such as nginx with the WebDAV module enabled. Action Cache (AC)
+	// entries are stored under /ac/ and Content Addressable Storage
+	// (CAS) objects under /cas/.
+	//
+	// This protocol does not provide any facilities for performing
+	// batch existence checks, meaning that FindMissingBlobs() is
+	// implemented by issuing a HEAD request for every object. It is
+	// therefore advised to place this backend behind an
+	// 'existence_caching' backend when used for the CAS.
+	Http *HTTPBlobAccessConfiguration `protobuf:"bytes,34,opt,name=http,proto3,oneof"`
+}
+
+type BlobAccessConfiguration_Bolt struct {
+	// Store objects in an embedded database file, using bbolt. Unlike
+	// the 'local' backend, which is a ring buffer, objects are only
+	// removed when they expire. This makes it suitable for durably
+	// storing the Action Cache (AC), Initial Size Class Cache (ISCC)
+	// and File System Access Cache (FSAC), e.g., for builds of release
+	// branches.
+	//
+	// This backend cannot be used to store the Content Addressable
+	// Storage (CAS).
+	Bolt *BoltBlobAccessConfiguration `protobuf:"bytes,35,opt,name=bolt,proto3,oneof"`
+}
+
+type BlobAccessConfiguration_Oci struct {
+	// Store objects as blobs in a repository of a registry that
+	// implements the OCI Distribution Specification, such as the
+	// CNCF Distribution registry. As blobs are identified by their
+	// SHA-256 or SHA-512 hash, this backend can only be used to store
+	// the Content Addressable Storage (CAS), using those digest
+	// functions.
+	//
+	// Blobs stored by this backend are not referenced by any
+	// manifest. The registry must therefore be configured not to
+	// garbage collect unreferenced blobs.
+	Oci *OCIBlobAccessConfiguration `protobuf:"bytes,36,opt,name=oci,proto3,oneof"`
+}
+
+type BlobAccessConfiguration_ErasureCoding struct {
+	// Store objects in the Content Addressable Storage (CAS) across
+	// multiple backends using Reed-Solomon erasure coding. Every
+	// object is split into a number of data fragments, for which a
+	// number of parity fragments is computed. Each fragment is stored
+	// in a separate backend. Objects can be reconstructed as long as
+	// any set of fragments equal in size to the number of data
+	// fragments is available.
+	//
+	// Compared to 'mirrored', this allows tolerating the loss of
+	// backends at lower storage overhead. For example, using four
+	// data backends and two parity backends allows tolerating the
+	// loss of two backends, while only requiring 1.5 times the
+	// storage space of the original objects.
+	ErasureCoding *ErasureCodingBlobAccessConfiguration `protobuf:"bytes,37,opt,name=erasure_coding,json=erasureCoding,proto3,oneof"`
+}
+
+type BlobAccessConfiguration_Replicated struct {
+	// Store copies of objects in an arbitrary number of backends, such
+	// as backends located in different availability zones. This is a
+	// generalization of 'mirrored', offering configurable write and
+	// read quorums.
+	Replicated *ReplicatedBlobAccessConfiguration `protobuf:"bytes,38,opt,name=replicated,proto3,oneof"`
+}
+
+type BlobAccessConfiguration_Hedging struct {
+	// Forward requests to one of multiple equivalent backends, such as
+	// multiple frontends in front of the same storage cluster. If a
+	// backend does not respond to Get() or FindMissing() in time, the
+	// same request is sent to another backend. The response that
+	// arrives first is used. This reduces tail latency in case
+	// individual backends stall.
+	Hedging *HedgingBlobAccessConfiguration `protobuf:"bytes,39,opt,name=hedging,proto3,oneof"`
+}
+
+type BlobAccessConfiguration_LatencyAware struct {
+	// Forward requests to one of multiple replicas containing the same
+	// data, such as replicas located in different zones. Requests are
+	// sent to the replica that currently has the lowest latency, out
+	// of the replicas that are healthy. Estimates are tracked
+	// separately for Get() and FindMissing(). The latency of Get() is
+	// measured until the first byte of data is received.
+	//
+	// Replicas are not required to be strongly consistent. As Get()
+	// and FindMissing() may be sent to different replicas, Get() is
+	// forwarded to the other replicas if the object is absent in the
+	// replica that was selected. FindMissing() does not fall back, as
+	// reporting objects as missing only causes clients to upload them
+	// again. Writes are sent to a single replica.
+	//
+	// In combination with 'with_labels', the same replicas may also be
+	// used by other backends. For example, 'replicated' may be used to
+	// keep the replicas consistent, while this backend is used to
+	// serve reads.
+	LatencyAware *LatencyAwareBlobAccessConfiguration `protobuf:"bytes,40,opt,name=latency_aware,json=latencyAware,proto3,oneof"`
+}
+
+type BlobAccessConfiguration_CircuitBreaker struct {
+	// Stop forwarding requests to a backend after it repeatedly
+	// returned UNAVAILABLE or DEADLINE_EXCEEDED, causing requests to
+	// fail immediately. This is useful when placed in front of the
+	// shards of 'sharding', as it prevents requests from having to
+	// wait for connection attempts against shards that are offline.
+	CircuitBreaker *CircuitBreakingBlobAccessConfiguration `protobuf:"bytes,41,opt,name=circuit_breaker,json=circuitBreaker,proto3,oneof"`
+}
+
+type BlobAccessConfiguration_RateLimiting struct {
+	// Limit the rate at which requests may be performed, using token
+	// buckets. Requests are grouped by a key computed from the
+	// authentication metadata and instance name, making it possible to
+	// prevent individual users or CI jobs from saturating storage.
+	RateLimiting *RateLimitingBlobAccessConfiguration `protobuf:"bytes,42,opt,name=rate_limiting,json=rateLimiting,proto3,oneof"`
+}
+
+type BlobAccessConfiguration_QuotaEnforcing struct {
+	// Enforce per-tenant quotas on the number of bytes written within
+	// a sliding window of time. Writes performed by tenants that
+	// exceeded their quota are either rejected with RESOURCE_EXHAUSTED
+	// or deprioritized. The usage of every tenant is exposed through
+	// Prometheus metrics and the /quota page of the diagnostics HTTP
+	// server.
+	QuotaEnforcing *QuotaEnforcingBlobAccessConfiguration `protobuf:"bytes,43,opt,name=quota_enforcing,json=quotaEnforcing,proto3,oneof"`
+}
+
 func (*BlobAccessConfiguration_ReadCaching) isBlobAccessConfiguration_Backend() {}
 
 func (*BlobAccessConfiguration_Grpc) isBlobAccessConfiguration_Backend() {}
@@ -437,10 +928,48 @@ func (*BlobAccessConfiguration_Label) isBlobAccessConfiguration_Backend() {}
 
 func (*BlobAccessConfiguration_DeadlineEnforcing) isBlobAccessConfiguration_Backend() {}
 
+func (*BlobAccessConfiguration_CompressedGrpc) isBlobAccessConfiguration_Backend() {}
+
+func (*BlobAccessConfiguration_S3) isBlobAccessConfiguration_Backend() {}
+
+func (*BlobAccessConfiguration_Gcs) isBlobAccessConfiguration_Backend() {}
+
+func (*BlobAccessConfiguration_Directory) isBlobAccessConfiguration_Backend() {}
+
+func (*BlobAccessConfiguration_Redis) isBlobAccessConfiguration_Backend() {}
+
+func (*BlobAccessConfiguration_Http) isBlobAccessConfiguration_Backend() {}
+
+func (*BlobAccessConfiguration_Bolt) isBlobAccessConfiguration_Backend() {}
+
+func (*BlobAccessConfiguration_Oci) isBlobAccessConfiguration_Backend() {}
+
+func (*BlobAccessConfiguration_ErasureCoding) isBlobAccessConfiguration_Backend() {}
+
+func (*BlobAccessConfiguration_Replicated) isBlobAccessConfiguration_Backend() {}
+
+func (*BlobAccessConfiguration_Hedging) isBlobAccessConfiguration_Backend() {}
+
+func (*BlobAccessConfiguration_LatencyAware) isBlobAccessConfiguration_Backend() {}
+
+func (*BlobAccessConfiguration_CircuitBreaker) isBlobAccessConfiguration_Backend() {}
+
+func (*BlobAccessConfiguration_RateLimiting) isBlobAccessConfiguration_Backend() {}
+
+func (*BlobAccessConfiguration_QuotaEnforcing) isBlobAccessConfiguration_Backend() {}
+
 type ReadCachingBlobAccessConfiguration struct {
-	state         protoimpl.MessageState       `protogen:"open.v1"`
-	Slow          *BlobAccessConfiguration     `protobuf:"bytes,1,opt,name=slow,proto3" json:"slow,omitempty"`
-	Fast          *BlobAccessConfiguration     `protobuf:"bytes,2,opt,name=fast,proto3" json:"fast,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// A remote storage backend that can only be accessed slowly. This
+	// storage backend is treated as the source of truth. Write
+	// operations are forwarded to this backend.
+	Slow *BlobAccessConfiguration `protobuf:"bytes,1,opt,name=slow,proto3" json:"slow,omitempty"`
+	// A local storage backend that can be accessed quickly. This
+	// storage backend is treated as a cache. Objects will only be
+	// written into it when requested for reading.
+	Fast *BlobAccessConfiguration `protobuf:"bytes,2,opt,name=fast,proto3" json:"fast,omitempty"`
+	// The replication strategy that should be used to copy objects from
+	// the slow backend to the fast backend.
 	Replicator    *BlobReplicatorConfiguration `protobuf:"bytes,3,opt,name=replicator,proto3" json:"replicator,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
@@ -498,9 +1027,42 @@ func (x *ReadCachingBlobAccessConfiguration) GetReplicator() *BlobReplicatorConf
 }
 
 type ShardingBlobAccessConfiguration struct {
-	state         protoimpl.MessageState                            `protogen:"open.v1"`
-	Shards        map[string]*ShardingBlobAccessConfiguration_Shard `protobuf:"bytes,2,rep,name=shards,proto3" json:"shards,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	Legacy        *ShardingBlobAccessConfiguration_Legacy           `protobuf:"bytes,3,opt,name=legacy,proto3" json:"legacy,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Shards identified by a key within the context of this sharding
+	// configuration. The key is a freeform string which describes the identity
+	// of the shard in the context of the current sharding configuration.
+	// Shards are chosen via Rendezvous hashing based on the digest, weight and
+	// key of the configuration.
+	//
+	// When removing a shard from the map it is guaranteed that only blobs
+	// which resolved to the removed shard will get a different shard. When
+	// adding shards there is a weight/total_weight probability that any given
+	// blob will be resolved to the new shards.
+	Shards map[string]*ShardingBlobAccessConfiguration_Shard `protobuf:"bytes,2,rep,name=shards,proto3" json:"shards,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// A temporary legacy mode which allows clients to use storage backends which
+	// are sharded with the old sharding topology implementation. Consumers are
+	// expected to migrate in a timely fashion and support for the legacy schema
+	// will be removed by 2025-12-31.
+	Legacy *ShardingBlobAccessConfiguration_Legacy `protobuf:"bytes,3,opt,name=legacy,proto3" json:"legacy,omitempty"`
+	// The number of shards in which every object is stored. Shards are
+	// chosen by picking the shards with the highest scores computed by
+	// Rendezvous hashing. This means that removing a shard only causes
+	// objects to lose a single replica. If unset, objects are only
+	// stored in a single shard.
+	//
+	// Writes are considered successful if they succeed for at least
+	// one of the shards. Reads fall back to successive shards if the
+	// object cannot be obtained from the preferred shard.
+	// FindMissingBlobs() only reports objects as missing if they are
+	// absent in all shards.
+	//
+	// This option cannot be used in combination with 'legacy'.
+	ReplicationFactor uint32 `protobuf:"varint,4,opt,name=replication_factor,json=replicationFactor,proto3" json:"replication_factor,omitempty"`
+	// If set, objects that are absent in the preferred shard, but
+	// present in one of the other shards, are copied into the
+	// preferred shard when read. This option only has an effect if
+	// 'replication_factor' is greater than one.
+	ReadRepair    bool `protobuf:"varint,5,opt,name=read_repair,json=readRepair,proto3" json:"read_repair,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -549,11 +1111,33 @@ func (x *ShardingBlobAccessConfiguration) GetLegacy() *ShardingBlobAccessConfigu
 	return nil
 }
 
+func (x *ShardingBlobAccessConfiguration) GetReplicationFactor() uint32 {
+	if x != nil {
+		return x.ReplicationFactor
+	}
+	return 0
+}
+
+func (x *ShardingBlobAccessConfiguration) GetReadRepair() bool {
+	if x != nil {
+		return x.ReadRepair
+	}
+	return false
+}
+
 type MirroredBlobAccessConfiguration struct {
-	state          protoimpl.MessageState       `protogen:"open.v1"`
-	BackendA       *BlobAccessConfiguration     `protobuf:"bytes,1,opt,name=backend_a,json=backendA,proto3" json:"backend_a,omitempty"`
-	BackendB       *BlobAccessConfiguration     `protobuf:"bytes,2,opt,name=backend_b,json=backendB,proto3" json:"backend_b,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Primary backend.
+	BackendA *BlobAccessConfiguration `protobuf:"bytes,1,opt,name=backend_a,json=backendA,proto3" json:"backend_a,omitempty"`
+	// Secondary backend.
+	BackendB *BlobAccessConfiguration `protobuf:"bytes,2,opt,name=backend_b,json=backendB,proto3" json:"backend_b,omitempty"`
+	// The replication strategy that should be used to copy objects from
+	// the primary backend to the secondary backend in case of
+	// inconsistencies.
 	ReplicatorAToB *BlobReplicatorConfiguration `protobuf:"bytes,3,opt,name=replicator_a_to_b,json=replicatorAToB,proto3" json:"replicator_a_to_b,omitempty"`
+	// The replication strategy that should be used to copy objects from
+	// the secondary backend to the primary backend in case of
+	// inconsistencies.
 	ReplicatorBToA *BlobReplicatorConfiguration `protobuf:"bytes,4,opt,name=replicator_b_to_a,json=replicatorBToA,proto3" json:"replicator_b_to_a,omitempty"`
 	unknownFields  protoimpl.UnknownFields
 	sizeCache      protoimpl.SizeCache
@@ -617,43 +1201,49 @@ func (x *MirroredBlobAccessConfiguration) GetReplicatorBToA() *BlobReplicatorCon
 	return nil
 }
 
-type LocalBlobAccessConfiguration struct {
+type ReplicatedBlobAccessConfiguration struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
-	// Types that are valid to be assigned to KeyLocationMapBackend:
+	// The replicas in which copies of objects are stored. Reads are
+	// alternated between replicas to spread the load equally.
+	Replicas []*ReplicatedBlobAccessConfiguration_Replica `protobuf:"bytes,1,rep,name=replicas,proto3" json:"replicas,omitempty"`
+	// The number of replicas in which objects need to be stored for
+	// writes to be considered successful. Writes are always attempted
+	// against all replicas. Once the write quorum is reached, the write
+	// is reported as successful, while writes to the remaining replicas
+	// continue in the background. Failures of these writes are logged.
 	//
-	//	*LocalBlobAccessConfiguration_KeyLocationMapInMemory_
-	//	*LocalBlobAccessConfiguration_KeyLocationMapOnBlockDevice
-	KeyLocationMapBackend            isLocalBlobAccessConfiguration_KeyLocationMapBackend `protobuf_oneof:"key_location_map_backend"`
-	KeyLocationMapMaximumGetAttempts uint32                                               `protobuf:"varint,2,opt,name=key_location_map_maximum_get_attempts,json=keyLocationMapMaximumGetAttempts,proto3" json:"key_location_map_maximum_get_attempts,omitempty"`
-	KeyLocationMapMaximumPutAttempts int64                                                `protobuf:"varint,3,opt,name=key_location_map_maximum_put_attempts,json=keyLocationMapMaximumPutAttempts,proto3" json:"key_location_map_maximum_put_attempts,omitempty"`
-	OldBlocks                        int32                                                `protobuf:"varint,5,opt,name=old_blocks,json=oldBlocks,proto3" json:"old_blocks,omitempty"`
-	CurrentBlocks                    int32                                                `protobuf:"varint,6,opt,name=current_blocks,json=currentBlocks,proto3" json:"current_blocks,omitempty"`
-	NewBlocks                        int32                                                `protobuf:"varint,7,opt,name=new_blocks,json=newBlocks,proto3" json:"new_blocks,omitempty"`
-	// Types that are valid to be assigned to BlocksBackend:
+	// Objects are streamed to all replicas simultaneously. Replicas
+	// that are slow to accept data therefore still delay writes. A write
+	// quorum below the number of replicas only reduces latency if
+	// replicas are slow to complete writes, or fail.
+	WriteQuorum uint32 `protobuf:"varint,2,opt,name=write_quorum,json=writeQuorum,proto3" json:"write_quorum,omitempty"`
+	// The number of replicas in which objects need to be present for
+	// FindMissing() to report them as being present. Objects that are
+	// present in at least this number of replicas, but absent in
+	// others, are copied into the replicas from which they are absent.
 	//
-	//	*LocalBlobAccessConfiguration_BlocksInMemory_
-	//	*LocalBlobAccessConfiguration_BlocksOnBlockDevice_
-	BlocksBackend             isLocalBlobAccessConfiguration_BlocksBackend `protobuf_oneof:"blocks_backend"`
-	Persistent                *LocalBlobAccessConfiguration_Persistent     `protobuf:"bytes,13,opt,name=persistent,proto3" json:"persistent,omitempty"`
-	HierarchicalInstanceNames bool                                         `protobuf:"varint,14,opt,name=hierarchical_instance_names,json=hierarchicalInstanceNames,proto3" json:"hierarchical_instance_names,omitempty"`
-	unknownFields             protoimpl.UnknownFields
-	sizeCache                 protoimpl.SizeCache
+	// Reads are served by a single replica, as the contents of objects
+	// are validated. Reads fall back to other replicas if the object
+	// cannot be obtained.
+	ReadQuorum    uint32 `protobuf:"varint,3,opt,name=read_quorum,json=readQuorum,proto3" json:"read_quorum,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *LocalBlobAccessConfiguration) Reset() {
-	*x = LocalBlobAccessConfiguration{}
+func (x *ReplicatedBlobAccessConfiguration) Reset() {
+	*x = ReplicatedBlobAccessConfiguration{}
 	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *LocalBlobAccessConfiguration) String() string {
+func (x *ReplicatedBlobAccessConfiguration) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*LocalBlobAccessConfiguration) ProtoMessage() {}
+func (*ReplicatedBlobAccessConfiguration) ProtoMessage() {}
 
-func (x *LocalBlobAccessConfiguration) ProtoReflect() protoreflect.Message {
+func (x *ReplicatedBlobAccessConfiguration) ProtoReflect() protoreflect.Message {
 	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -665,98 +1255,551 @@ func (x *LocalBlobAccessConfiguration) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use LocalBlobAccessConfiguration.ProtoReflect.Descriptor instead.
-func (*LocalBlobAccessConfiguration) Descriptor() ([]byte, []int) {
+// Deprecated: Use ReplicatedBlobAccessConfiguration.ProtoReflect.Descriptor instead.
+func (*ReplicatedBlobAccessConfiguration) Descriptor() ([]byte, []int) {
 	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{5}
 }
 
-func (x *LocalBlobAccessConfiguration) GetKeyLocationMapBackend() isLocalBlobAccessConfiguration_KeyLocationMapBackend {
+func (x *ReplicatedBlobAccessConfiguration) GetReplicas() []*ReplicatedBlobAccessConfiguration_Replica {
 	if x != nil {
-		return x.KeyLocationMapBackend
+		return x.Replicas
 	}
 	return nil
 }
 
-func (x *LocalBlobAccessConfiguration) GetKeyLocationMapInMemory() *LocalBlobAccessConfiguration_KeyLocationMapInMemory {
+func (x *ReplicatedBlobAccessConfiguration) GetWriteQuorum() uint32 {
 	if x != nil {
-		if x, ok := x.KeyLocationMapBackend.(*LocalBlobAccessConfiguration_KeyLocationMapInMemory_); ok {
-			return x.KeyLocationMapInMemory
-		}
+		return x.WriteQuorum
 	}
-	return nil
+	return 0
 }
 
-func (x *LocalBlobAccessConfiguration) GetKeyLocationMapOnBlockDevice() *blockdevice.Configuration {
+func (x *ReplicatedBlobAccessConfiguration) GetReadQuorum() uint32 {
 	if x != nil {
-		if x, ok := x.KeyLocationMapBackend.(*LocalBlobAccessConfiguration_KeyLocationMapOnBlockDevice); ok {
-			return x.KeyLocationMapOnBlockDevice
-		}
+		return x.ReadQuorum
 	}
-	return nil
+	return 0
 }
 
-func (x *LocalBlobAccessConfiguration) GetKeyLocationMapMaximumGetAttempts() uint32 {
-	if x != nil {
-		return x.KeyLocationMapMaximumGetAttempts
-	}
-	return 0
+type HedgingBlobAccessConfiguration struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The equivalent backends to which requests are forwarded. Requests
+	// alternate between backends to spread the load between them
+	// equally. At least two backends must be provided.
+	Backends []*BlobAccessConfiguration `protobuf:"bytes,1,rep,name=backends,proto3" json:"backends,omitempty"`
+	// The percentile of recently observed latencies after which a
+	// hedged request is sent to the next backend, in the range (0, 1].
+	// For example, if set to 0.95, hedged requests are only sent for the
+	// 5% slowest requests, meaning the load on the backends only
+	// increases slightly. Latencies of Get() and FindMissing() are
+	// tracked separately.
+	DelayPercentile float64 `protobuf:"fixed64,2,opt,name=delay_percentile,json=delayPercentile,proto3" json:"delay_percentile,omitempty"`
+	// The number of most recently observed latencies of which the
+	// percentile is computed. Until this number of latencies has been
+	// observed, the maximum delay is used.
+	LatencyWindowSize uint32 `protobuf:"varint,3,opt,name=latency_window_size,json=latencyWindowSize,proto3" json:"latency_window_size,omitempty"`
+	// Lower bound on the delay after which hedged requests are sent.
+	// This prevents the load on the backends from increasing
+	// significantly if the latency distribution is tight.
+	MinimumDelay *durationpb.Duration `protobuf:"bytes,4,opt,name=minimum_delay,json=minimumDelay,proto3" json:"minimum_delay,omitempty"`
+	// Upper bound on the delay after which hedged requests are sent.
+	// This option is required, and must be positive.
+	MaximumDelay  *durationpb.Duration `protobuf:"bytes,5,opt,name=maximum_delay,json=maximumDelay,proto3" json:"maximum_delay,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *LocalBlobAccessConfiguration) GetKeyLocationMapMaximumPutAttempts() int64 {
-	if x != nil {
-		return x.KeyLocationMapMaximumPutAttempts
-	}
-	return 0
+func (x *HedgingBlobAccessConfiguration) Reset() {
+	*x = HedgingBlobAccessConfiguration{}
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-func (x *LocalBlobAccessConfiguration) GetOldBlocks() int32 {
+func (x *HedgingBlobAccessConfiguration) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HedgingBlobAccessConfiguration) ProtoMessage() {}
+
+func (x *HedgingBlobAccessConfiguration) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[6]
 	if x != nil {
-		return x.OldBlocks
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return 0
+	return mi.MessageOf(x)
 }
 
-func (x *LocalBlobAccessConfiguration) GetCurrentBlocks() int32 {
+// Deprecated: Use HedgingBlobAccessConfiguration.ProtoReflect.Descriptor instead.
+func (*HedgingBlobAccessConfiguration) Descriptor() ([]byte, []int) {
+	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *HedgingBlobAccessConfiguration) GetBackends() []*BlobAccessConfiguration {
 	if x != nil {
-		return x.CurrentBlocks
+		return x.Backends
 	}
-	return 0
+	return nil
 }
 
-func (x *LocalBlobAccessConfiguration) GetNewBlocks() int32 {
+func (x *HedgingBlobAccessConfiguration) GetDelayPercentile() float64 {
 	if x != nil {
-		return x.NewBlocks
+		return x.DelayPercentile
 	}
 	return 0
 }
 
-func (x *LocalBlobAccessConfiguration) GetBlocksBackend() isLocalBlobAccessConfiguration_BlocksBackend {
+func (x *HedgingBlobAccessConfiguration) GetLatencyWindowSize() uint32 {
 	if x != nil {
-		return x.BlocksBackend
+		return x.LatencyWindowSize
 	}
-	return nil
+	return 0
 }
 
-func (x *LocalBlobAccessConfiguration) GetBlocksInMemory() *LocalBlobAccessConfiguration_BlocksInMemory {
+func (x *HedgingBlobAccessConfiguration) GetMinimumDelay() *durationpb.Duration {
 	if x != nil {
-		if x, ok := x.BlocksBackend.(*LocalBlobAccessConfiguration_BlocksInMemory_); ok {
-			return x.BlocksInMemory
-		}
+		return x.MinimumDelay
 	}
 	return nil
 }
 
-func (x *LocalBlobAccessConfiguration) GetBlocksOnBlockDevice() *LocalBlobAccessConfiguration_BlocksOnBlockDevice {
+func (x *HedgingBlobAccessConfiguration) GetMaximumDelay() *durationpb.Duration {
 	if x != nil {
-		if x, ok := x.BlocksBackend.(*LocalBlobAccessConfiguration_BlocksOnBlockDevice_); ok {
-			return x.BlocksOnBlockDevice
-		}
+		return x.MaximumDelay
 	}
 	return nil
 }
 
-func (x *LocalBlobAccessConfiguration) GetPersistent() *LocalBlobAccessConfiguration_Persistent {
-	if x != nil {
+type LatencyAwareBlobAccessConfiguration struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The replicas to which requests are forwarded. At least two
+	// replicas must be provided.
+	Backends []*BlobAccessConfiguration `protobuf:"bytes,1,rep,name=backends,proto3" json:"backends,omitempty"`
+	// The weight of the most recent request when updating the
+	// exponentially weighted moving averages (EWMA) of the latency and
+	// error rate of a replica, in the range (0, 1]. Higher values cause
+	// the estimates to adjust more quickly, but make them more noisy.
+	SmoothingFactor float64 `protobuf:"fixed64,2,opt,name=smoothing_factor,json=smoothingFactor,proto3" json:"smoothing_factor,omitempty"`
+	// The maximum error rate of a replica, in the range [0, 1], for it
+	// to be considered healthy. Requests are only sent to unhealthy
+	// replicas if none of the replicas are healthy. Objects being absent
+	// and requests being cancelled by the client are not considered
+	// errors.
+	MaximumErrorRate float64 `protobuf:"fixed64,3,opt,name=maximum_error_rate,json=maximumErrorRate,proto3" json:"maximum_error_rate,omitempty"`
+	// The probability at which requests are sent to a randomly chosen
+	// replica other than the fastest one, in the range [0, 1). This
+	// keeps the estimates of the other replicas fresh, and allows
+	// replicas that were previously unhealthy to be taken into use
+	// again.
+	ProbeProbability float64 `protobuf:"fixed64,4,opt,name=probe_probability,json=probeProbability,proto3" json:"probe_probability,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *LatencyAwareBlobAccessConfiguration) Reset() {
+	*x = LatencyAwareBlobAccessConfiguration{}
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LatencyAwareBlobAccessConfiguration) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LatencyAwareBlobAccessConfiguration) ProtoMessage() {}
+
+func (x *LatencyAwareBlobAccessConfiguration) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LatencyAwareBlobAccessConfiguration.ProtoReflect.Descriptor instead.
+func (*LatencyAwareBlobAccessConfiguration) Descriptor() ([]byte, []int) {
+	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *LatencyAwareBlobAccessConfiguration) GetBackends() []*BlobAccessConfiguration {
+	if x != nil {
+		return x.Backends
+	}
+	return nil
+}
+
+func (x *LatencyAwareBlobAccessConfiguration) GetSmoothingFactor() float64 {
+	if x != nil {
+		return x.SmoothingFactor
+	}
+	return 0
+}
+
+func (x *LatencyAwareBlobAccessConfiguration) GetMaximumErrorRate() float64 {
+	if x != nil {
+		return x.MaximumErrorRate
+	}
+	return 0
+}
+
+func (x *LatencyAwareBlobAccessConfiguration) GetProbeProbability() float64 {
+	if x != nil {
+		return x.ProbeProbability
+	}
+	return 0
+}
+
+// LocalBlobAccess stores all data onto disk inside blocks. A block can
+// contain multiple blobs, but blob cannot span multiple blocks. This
+// means that a block needs to be at least as large as the maximum blob
+// size you're willing to accept. For example, if you have a 512 GiB
+// cache, having a total of 32 blocks means you can store objects up to
+// 16 GiB in size. This means that for small instances of
+// LocalBlobAccess, the number of blocks may need to be reduced.
+//
+// Blocks are the unit at which LocalBlobAccess performs garbage
+// collection. If storage space in all used blocks is exhausted,
+// LocalBlobAccess discards the contents of a block so that space may be
+// reused. Therefore, the total number of blocks does not need to be
+// very high. If the total number of blocks is 32, LocalBlobAccess will
+// only discard 100% / 32 = 3.125% of its data at a time. This means
+// that 96.875% of storage space remains in continuous use, which is
+// more than adequate.
+//
+// Blocks are partitioned into three groups based on their creation
+// time, named "old", "current" and "new". Blobs provided to Put() will
+// always be stored in a block in the "new" group. When the oldest block
+// in the "new" group becomes full, it is moved to the "current" group.
+// This causes the oldest block in the "current" group to be displaced
+// to the "old" group. The oldest block in the "old" group is discarded.
+//
+// The difference between the "current" group and the "old" group is
+// that data is refreshed when accessed. Data in the "old" group is at
+// risk of being removed in the nearby future, which is why it needs to
+// be copied into the "new" group when requested to be retained. Data
+// in the "current" group is assumed to remain present for the time
+// being, which is why it is left in place.
+//
+// Below is an illustration of how the blocks of data may be laid out at
+// a given point in time. Every column of █ characters corresponds to a
+// single block. The number of characters indicates the amount of data
+// stored within.
+//
+//	← Over time, blocks move from "new" to "current" to "old" ←
+//
+//	              Old         Current        New
+//	            █ █ █ █ │ █ █ █ █ █ █ █ █ │
+//	            █ █ █ █ │ █ █ █ █ █ █ █ █ │
+//	            █ █ █ █ │ █ █ █ █ █ █ █ █ │
+//	            █ █ █ █ │ █ █ █ █ █ █ █ █ │
+//	            █ █ █ █ │ █ █ █ █ █ █ █ █ │ █
+//	            █ █ █ █ │ █ █ █ █ █ █ █ █ │ █
+//	            █ █ █ █ │ █ █ █ █ █ █ █ █ │ █ █
+//	            █ █ █ █ │ █ █ █ █ █ █ █ █ │ █ █ █
+//	            ↓ ↓ ↓ ↓                     ↑ ↑ ↑ ↑
+//	            └─┴─┴─┴─────────────────────┴─┴─┴─┘
+//	   Data gets copied from "old" to "new" when requested.
+//
+// Blobs get stored in blocks in the "new" group with an inverse
+// exponential probability. This is done to reduce the probability of
+// multiple block rotations close after each other, as this might put
+// excessive pressure on the garbage collector. Because the placement
+// distribution decreases rapidly, having more than three or four "new"
+// blocks would be wasteful. Having fewer is also not recommended, as
+// that increases the chance of placing objects that are used together
+// inside the same block. This may cause 'tidal waves' of I/O whenever
+// such data ends up in the "old" group at once.
+//
+// After initialization, there will be fewer blocks in the "current"
+// group than configured, due to there simply being no data. This is
+// compensated by adding more blocks to the "new" group. Unlike the
+// regular blocks in this group, these will have a uniform placement
+// distribution that is twice as high as normal. This is done to ensure
+// the "current" blocks are randomly seeded to reduce 'tidal waves'
+// later on.
+//
+// The number of blocks in the "old" group should not be too low, as
+// this would cause this storage backend to become a FIFO instead of
+// being LRU-like. Setting it too high is also not recommended, as this
+// would increase redundancy in the data stored. The "current" group
+// should likely be two or three times as large as the "old" group.
+type LocalBlobAccessConfiguration struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Data store for the metadata of objects. The following Prometheus
+	// queries may be used to determine whether insertion into the
+	// key-location map caused other entries to be displaced prematurely:
+	//
+	// buildbarn_blobstore_hashing_key_location_map_put_iterations_count{outcome="TooManyAttempts"}
+	// buildbarn_blobstore_hashing_key_location_map_put_too_many_iterations_total
+	//
+	// If this query yields values greater than zero, you may need to
+	// increase this data store's size (or reduce the size of the blocks
+	// backend).
+	//
+	// Note that restarting bb_storage causes these metrics to be reset,
+	// meaning that you may need to run bb_storage for a longer amount of
+	// time to get better insight in whether objects are discarded
+	// prematurely.
+	//
+	// Types that are valid to be assigned to KeyLocationMapBackend:
+	//
+	//	*LocalBlobAccessConfiguration_KeyLocationMapInMemory_
+	//	*LocalBlobAccessConfiguration_KeyLocationMapOnBlockDevice
+	KeyLocationMapBackend isLocalBlobAccessConfiguration_KeyLocationMapBackend `protobuf_oneof:"key_location_map_backend"`
+	// The number of indices a Get() call on the key-location map may
+	// attempt to access. The lower the utilization rate of the
+	// key-location map, the lower this value may be set. For example, if
+	// the size of the key-location map is set in such a way that it is
+	// only utilized by 10% (factor 0.1), setting this field to 16 means
+	// there is only a 0.1^16 chance that inserting an entry prematurely
+	// displaces another object from storage.
+	//
+	// Recommended value: 16
+	KeyLocationMapMaximumGetAttempts uint32 `protobuf:"varint,2,opt,name=key_location_map_maximum_get_attempts,json=keyLocationMapMaximumGetAttempts,proto3" json:"key_location_map_maximum_get_attempts,omitempty"`
+	// The number of mutations that a Put() on the key-location map may
+	// perform. Because the key-location map uses a scheme similar to
+	// Robin Hood hashing, insertions may cause other entries to be
+	// displaced. Those entries may then cause even more entries to be
+	// displaced. Because of that, it is recommended to set this field to
+	// a small multiple of the maximum Get() attempts.
+	//
+	// Recommended value: 64
+	KeyLocationMapMaximumPutAttempts int64 `protobuf:"varint,3,opt,name=key_location_map_maximum_put_attempts,json=keyLocationMapMaximumPutAttempts,proto3" json:"key_location_map_maximum_put_attempts,omitempty"`
+	// The number of blocks, where attempting to access any data stored
+	// within will cause it to be refreshed (i.e., copied into new
+	// blocks).
+	//
+	// Setting the number of old blocks too low may cause builds to fail,
+	// due to data disappearing prematurely. Setting the number of old
+	// blocks too high may cause an excessive amount of duplication in the
+	// data set. For example, if old_blocks == current_blocks + new_blocks,
+	// there may be a redundancy in the data set up to a factor of two.
+	//
+	// Recommended value: 8
+	OldBlocks int32 `protobuf:"varint,5,opt,name=old_blocks,json=oldBlocks,proto3" json:"old_blocks,omitempty"`
+	// The number of blocks, where attempting to access data stored within
+	// will not cause data to be refreshed immediately. The containing
+	// block will first need to become old for data to be eligible for
+	// refreshes.
+	//
+	// Recommended value: 24
+	CurrentBlocks int32 `protobuf:"varint,6,opt,name=current_blocks,json=currentBlocks,proto3" json:"current_blocks,omitempty"`
+	// The number of blocks where new data needs to be written. It is
+	// valid to set this to just 1. Setting it to a slightly higher value
+	// has the advantage that frequently used objects will over time get
+	// smeared out across the data set. This spreads out the cost
+	// refreshing data from old to new blocks.
+	//
+	// Because the probability of storing objects in new blocks has an
+	// inverse exponential distribution, it is not recommended to set this
+	// to any value higher than 4. Whereas the first new block will at
+	// times be somewhere between 50% and 100% full, the fourth new block
+	// will only be between 6.25% and 12.5% full, which is wasteful.
+	//
+	// Setting this to any value other than 1 is only supported for the
+	// Content Addressable Storage (CAS). Other storage types such as the
+	// Action Cache (AC) need to support updates to existing objects,
+	// which can only be done reliably if new objects are written into a
+	// single block.
+	//
+	// Recommended value: 3 for the CAS, 1 for other storage types.
+	NewBlocks int32 `protobuf:"varint,7,opt,name=new_blocks,json=newBlocks,proto3" json:"new_blocks,omitempty"`
+	// Data store for the contents of objects. The following Prometheus
+	// query may be used to determine the worst-case retention of this
+	// data store in seconds:
+	//
+	// time() -
+	// buildbarn_blobstore_old_current_new_location_blob_map_last_removed_old_block_insertion_time_seconds
+	//
+	// If this query yields a value that is lower than desired, you may
+	// need to increase this data store's size.
+	//
+	// Note that restarting bb_storage causes this metric to be reset,
+	// meaning that you may need to run bb_storage for a longer amount of
+	// time to get better insight in the worst-case retention.
+	//
+	// Types that are valid to be assigned to BlocksBackend:
+	//
+	//	*LocalBlobAccessConfiguration_BlocksInMemory_
+	//	*LocalBlobAccessConfiguration_BlocksOnBlockDevice_
+	BlocksBackend isLocalBlobAccessConfiguration_BlocksBackend `protobuf_oneof:"blocks_backend"`
+	// When set, persist data across restarts. This feature is only
+	// available when both the key-location map and blocks are stored on a
+	// block device.
+	//
+	// When not set, data is not persisted. The data store will be empty
+	// every time the application is restarted. Existing entries in the
+	// key-location map and data in blocks will be ignored, even if their
+	// contents are valid.
+	Persistent *LocalBlobAccessConfiguration_Persistent `protobuf:"bytes,13,opt,name=persistent,proto3" json:"persistent,omitempty"`
+	// For all data stores except for the Content Addressable Storage
+	// (CAS), this storage backend always fully respects the REv2 instance
+	// name. This means that every instance name may store a separate copy
+	// of an object. Reads and writes are fully isolated.
+	//
+	// For the Content Addressable Storage, this option determines to what
+	// extent the instance name should be respected. When set to false,
+	// the instance name is completely ignored, meaning that all instance
+	// names share all objects. This is great from a performance point of
+	// view, as it means that users of multi-tenant setups need to upload
+	// objects less frequently, and that storage space usage is minimised.
+	// Unfortunately, it does mean that all tenants can access each
+	// other's objects once they get their hands on their digests.
+	//
+	// When this option is set to true, the instance name is respected in
+	// a hierarchical fashion. This means that if an object is written
+	// using instance name "foo/bar", it will be possible to read it using
+	// instance names "foo/bar", "foo/bar/baz", "foo/bar/baz/qux", but not
+	// instance names "", "foo", "foo/xyzzy". In other words, non-empty
+	// instance names will have Content Addressable Storage contents
+	// inherited from their parent instance names.
+	//
+	// This feature is implemented in such a way that object contents are
+	// still shared across all instance names. Enabling this option does
+	// not cause more data to be written into blocks, as uploads for
+	// objects that already exist under another instance name are treated
+	// as no-ops. It does cause at least a twofold increase in
+	// key-location map usage to track which instance name prefixes may
+	// access an object, proportional to the number of instance names
+	// used.
+	//
+	// This option is only supported for the Content Addressable Storage,
+	// as only for this data store it is safe to provide such behaviour at
+	// the individual storage node level. For the Action Cache, you may
+	// only want to do hierarchical instance name matching at a higher
+	// level, e.g., on top of CompletenessCheckingBlobAccess. This can be
+	// achieved by using HierarchicalInstanceNamesBlobAccess.
+	HierarchicalInstanceNames bool `protobuf:"varint,14,opt,name=hierarchical_instance_names,json=hierarchicalInstanceNames,proto3" json:"hierarchical_instance_names,omitempty"`
+	unknownFields             protoimpl.UnknownFields
+	sizeCache                 protoimpl.SizeCache
+}
+
+func (x *LocalBlobAccessConfiguration) Reset() {
+	*x = LocalBlobAccessConfiguration{}
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LocalBlobAccessConfiguration) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LocalBlobAccessConfiguration) ProtoMessage() {}
+
+func (x *LocalBlobAccessConfiguration) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LocalBlobAccessConfiguration.ProtoReflect.Descriptor instead.
+func (*LocalBlobAccessConfiguration) Descriptor() ([]byte, []int) {
+	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *LocalBlobAccessConfiguration) GetKeyLocationMapBackend() isLocalBlobAccessConfiguration_KeyLocationMapBackend {
+	if x != nil {
+		return x.KeyLocationMapBackend
+	}
+	return nil
+}
+
+func (x *LocalBlobAccessConfiguration) GetKeyLocationMapInMemory() *LocalBlobAccessConfiguration_KeyLocationMapInMemory {
+	if x != nil {
+		if x, ok := x.KeyLocationMapBackend.(*LocalBlobAccessConfiguration_KeyLocationMapInMemory_); ok {
+			return x.KeyLocationMapInMemory
+		}
+	}
+	return nil
+}
+
+func (x *LocalBlobAccessConfiguration) GetKeyLocationMapOnBlockDevice() *blockdevice.Configuration {
+	if x != nil {
+		if x, ok := x.KeyLocationMapBackend.(*LocalBlobAccessConfiguration_KeyLocationMapOnBlockDevice); ok {
+			return x.KeyLocationMapOnBlockDevice
+		}
+	}
+	return nil
+}
+
+func (x *LocalBlobAccessConfiguration) GetKeyLocationMapMaximumGetAttempts() uint32 {
+	if x != nil {
+		return x.KeyLocationMapMaximumGetAttempts
+	}
+	return 0
+}
+
+func (x *LocalBlobAccessConfiguration) GetKeyLocationMapMaximumPutAttempts() int64 {
+	if x != nil {
+		return x.KeyLocationMapMaximumPutAttempts
+	}
+	return 0
+}
+
+func (x *LocalBlobAccessConfiguration) GetOldBlocks() int32 {
+	if x != nil {
+		return x.OldBlocks
+	}
+	return 0
+}
+
+func (x *LocalBlobAccessConfiguration) GetCurrentBlocks() int32 {
+	if x != nil {
+		return x.CurrentBlocks
+	}
+	return 0
+}
+
+func (x *LocalBlobAccessConfiguration) GetNewBlocks() int32 {
+	if x != nil {
+		return x.NewBlocks
+	}
+	return 0
+}
+
+func (x *LocalBlobAccessConfiguration) GetBlocksBackend() isLocalBlobAccessConfiguration_BlocksBackend {
+	if x != nil {
+		return x.BlocksBackend
+	}
+	return nil
+}
+
+func (x *LocalBlobAccessConfiguration) GetBlocksInMemory() *LocalBlobAccessConfiguration_BlocksInMemory {
+	if x != nil {
+		if x, ok := x.BlocksBackend.(*LocalBlobAccessConfiguration_BlocksInMemory_); ok {
+			return x.BlocksInMemory
+		}
+	}
+	return nil
+}
+
+func (x *LocalBlobAccessConfiguration) GetBlocksOnBlockDevice() *LocalBlobAccessConfiguration_BlocksOnBlockDevice {
+	if x != nil {
+		if x, ok := x.BlocksBackend.(*LocalBlobAccessConfiguration_BlocksOnBlockDevice_); ok {
+			return x.BlocksOnBlockDevice
+		}
+	}
+	return nil
+}
+
+func (x *LocalBlobAccessConfiguration) GetPersistent() *LocalBlobAccessConfiguration_Persistent {
+	if x != nil {
 		return x.Persistent
 	}
 	return nil
@@ -774,10 +1817,13 @@ type isLocalBlobAccessConfiguration_KeyLocationMapBackend interface {
 }
 
 type LocalBlobAccessConfiguration_KeyLocationMapInMemory_ struct {
+	// Store the key-location map in memory.
 	KeyLocationMapInMemory *LocalBlobAccessConfiguration_KeyLocationMapInMemory `protobuf:"bytes,11,opt,name=key_location_map_in_memory,json=keyLocationMapInMemory,proto3,oneof"`
 }
 
 type LocalBlobAccessConfiguration_KeyLocationMapOnBlockDevice struct {
+	// Store the key-location map on a block device. The size of the
+	// block device determines the number of entries stored.
 	KeyLocationMapOnBlockDevice *blockdevice.Configuration `protobuf:"bytes,12,opt,name=key_location_map_on_block_device,json=keyLocationMapOnBlockDevice,proto3,oneof"`
 }
 
@@ -792,10 +1838,14 @@ type isLocalBlobAccessConfiguration_BlocksBackend interface {
 }
 
 type LocalBlobAccessConfiguration_BlocksInMemory_ struct {
+	// Store all data in memory. For larger setups, this may place a lot
+	// of pressure on Go's garbage collector. It may be necessary to
+	// reduce the value of GOGC to use this option reliably.
 	BlocksInMemory *LocalBlobAccessConfiguration_BlocksInMemory `protobuf:"bytes,9,opt,name=blocks_in_memory,json=blocksInMemory,proto3,oneof"`
 }
 
 type LocalBlobAccessConfiguration_BlocksOnBlockDevice_ struct {
+	// Store the blocks containing data on a block device.
 	BlocksOnBlockDevice *LocalBlobAccessConfiguration_BlocksOnBlockDevice `protobuf:"bytes,10,opt,name=blocks_on_block_device,json=blocksOnBlockDevice,proto3,oneof"`
 }
 
@@ -805,8 +1855,13 @@ func (*LocalBlobAccessConfiguration_BlocksOnBlockDevice_) isLocalBlobAccessConfi
 }
 
 type ExistenceCachingBlobAccessConfiguration struct {
-	state          protoimpl.MessageState              `protogen:"open.v1"`
-	Backend        *BlobAccessConfiguration            `protobuf:"bytes,1,opt,name=backend,proto3" json:"backend,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The backend for which results of
+	// ContentAddressableStorage.FindMissingBlobs() results need to be
+	// cached.
+	Backend *BlobAccessConfiguration `protobuf:"bytes,1,opt,name=backend,proto3" json:"backend,omitempty"`
+	// Parameters for the cache data structure that is used by this
+	// decorator.
 	ExistenceCache *digest.ExistenceCacheConfiguration `protobuf:"bytes,2,opt,name=existence_cache,json=existenceCache,proto3" json:"existence_cache,omitempty"`
 	unknownFields  protoimpl.UnknownFields
 	sizeCache      protoimpl.SizeCache
@@ -814,7 +1869,7 @@ type ExistenceCachingBlobAccessConfiguration struct {
 
 func (x *ExistenceCachingBlobAccessConfiguration) Reset() {
 	*x = ExistenceCachingBlobAccessConfiguration{}
-	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[6]
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -826,7 +1881,7 @@ func (x *ExistenceCachingBlobAccessConfiguration) String() string {
 func (*ExistenceCachingBlobAccessConfiguration) ProtoMessage() {}
 
 func (x *ExistenceCachingBlobAccessConfiguration) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[6]
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -839,7 +1894,7 @@ func (x *ExistenceCachingBlobAccessConfiguration) ProtoReflect() protoreflect.Me
 
 // Deprecated: Use ExistenceCachingBlobAccessConfiguration.ProtoReflect.Descriptor instead.
 func (*ExistenceCachingBlobAccessConfiguration) Descriptor() ([]byte, []int) {
-	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{6}
+	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *ExistenceCachingBlobAccessConfiguration) GetBackend() *BlobAccessConfiguration {
@@ -857,16 +1912,26 @@ func (x *ExistenceCachingBlobAccessConfiguration) GetExistenceCache() *digest.Ex
 }
 
 type CompletenessCheckingBlobAccessConfiguration struct {
-	state                     protoimpl.MessageState   `protogen:"open.v1"`
-	Backend                   *BlobAccessConfiguration `protobuf:"bytes,1,opt,name=backend,proto3" json:"backend,omitempty"`
-	MaximumTotalTreeSizeBytes int64                    `protobuf:"varint,2,opt,name=maximum_total_tree_size_bytes,json=maximumTotalTreeSizeBytes,proto3" json:"maximum_total_tree_size_bytes,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The Action Cache (AC) backend from which ActionResult messages are
+	// loaded.
+	Backend *BlobAccessConfiguration `protobuf:"bytes,1,opt,name=backend,proto3" json:"backend,omitempty"`
+	// The maximum combined size of Tree objects that may be referenced by
+	// the ActionResult message. ActionResults having output directories
+	// whose combined size exceeds that exceeds this limit are suppressed
+	// (i.e., a NOT_FOUND error will be returned).
+	//
+	// This option places a limit on the amount of data that is read from
+	// the Content Addressable Storage (CAS) while processing a call to
+	// GetActionResult().
+	MaximumTotalTreeSizeBytes int64 `protobuf:"varint,2,opt,name=maximum_total_tree_size_bytes,json=maximumTotalTreeSizeBytes,proto3" json:"maximum_total_tree_size_bytes,omitempty"`
 	unknownFields             protoimpl.UnknownFields
 	sizeCache                 protoimpl.SizeCache
 }
 
 func (x *CompletenessCheckingBlobAccessConfiguration) Reset() {
 	*x = CompletenessCheckingBlobAccessConfiguration{}
-	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[7]
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -878,7 +1943,7 @@ func (x *CompletenessCheckingBlobAccessConfiguration) String() string {
 func (*CompletenessCheckingBlobAccessConfiguration) ProtoMessage() {}
 
 func (x *CompletenessCheckingBlobAccessConfiguration) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[7]
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -891,7 +1956,7 @@ func (x *CompletenessCheckingBlobAccessConfiguration) ProtoReflect() protoreflec
 
 // Deprecated: Use CompletenessCheckingBlobAccessConfiguration.ProtoReflect.Descriptor instead.
 func (*CompletenessCheckingBlobAccessConfiguration) Descriptor() ([]byte, []int) {
-	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{7}
+	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *CompletenessCheckingBlobAccessConfiguration) GetBackend() *BlobAccessConfiguration {
@@ -909,9 +1974,15 @@ func (x *CompletenessCheckingBlobAccessConfiguration) GetMaximumTotalTreeSizeByt
 }
 
 type ReadFallbackBlobAccessConfiguration struct {
-	state         protoimpl.MessageState       `protogen:"open.v1"`
-	Primary       *BlobAccessConfiguration     `protobuf:"bytes,1,opt,name=primary,proto3" json:"primary,omitempty"`
-	Secondary     *BlobAccessConfiguration     `protobuf:"bytes,2,opt,name=secondary,proto3" json:"secondary,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Backend from which data is attempted to be read first, and to which
+	// data is written.
+	Primary *BlobAccessConfiguration `protobuf:"bytes,1,opt,name=primary,proto3" json:"primary,omitempty"`
+	// Backend from which data is attempted to be read last.
+	Secondary *BlobAccessConfiguration `protobuf:"bytes,2,opt,name=secondary,proto3" json:"secondary,omitempty"`
+	// The replication strategy that should be used to copy objects from
+	// the secondary backend to the primary backend. If unset, objects
+	// will not be copied.
 	Replicator    *BlobReplicatorConfiguration `protobuf:"bytes,3,opt,name=replicator,proto3" json:"replicator,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
@@ -919,7 +1990,7 @@ type ReadFallbackBlobAccessConfiguration struct {
 
 func (x *ReadFallbackBlobAccessConfiguration) Reset() {
 	*x = ReadFallbackBlobAccessConfiguration{}
-	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[8]
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -931,7 +2002,7 @@ func (x *ReadFallbackBlobAccessConfiguration) String() string {
 func (*ReadFallbackBlobAccessConfiguration) ProtoMessage() {}
 
 func (x *ReadFallbackBlobAccessConfiguration) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[8]
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -944,7 +2015,7 @@ func (x *ReadFallbackBlobAccessConfiguration) ProtoReflect() protoreflect.Messag
 
 // Deprecated: Use ReadFallbackBlobAccessConfiguration.ProtoReflect.Descriptor instead.
 func (*ReadFallbackBlobAccessConfiguration) Descriptor() ([]byte, []int) {
-	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{8}
+	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *ReadFallbackBlobAccessConfiguration) GetPrimary() *BlobAccessConfiguration {
@@ -969,19 +2040,28 @@ func (x *ReadFallbackBlobAccessConfiguration) GetReplicator() *BlobReplicatorCon
 }
 
 type ReferenceExpandingBlobAccessConfiguration struct {
-	state                             protoimpl.MessageState          `protogen:"open.v1"`
-	IndirectContentAddressableStorage *BlobAccessConfiguration        `protobuf:"bytes,1,opt,name=indirect_content_addressable_storage,json=indirectContentAddressableStorage,proto3" json:"indirect_content_addressable_storage,omitempty"`
-	AwsSession                        *aws.SessionConfiguration       `protobuf:"bytes,2,opt,name=aws_session,json=awsSession,proto3" json:"aws_session,omitempty"`
-	HttpClient                        *client.Configuration           `protobuf:"bytes,3,opt,name=http_client,json=httpClient,proto3" json:"http_client,omitempty"`
-	GcpClientOptions                  *gcp.ClientOptionsConfiguration `protobuf:"bytes,4,opt,name=gcp_client_options,json=gcpClientOptions,proto3" json:"gcp_client_options,omitempty"`
-	ContentAddressableStorage         *BlobAccessConfiguration        `protobuf:"bytes,5,opt,name=content_addressable_storage,json=contentAddressableStorage,proto3" json:"content_addressable_storage,omitempty"`
-	unknownFields                     protoimpl.UnknownFields
-	sizeCache                         protoimpl.SizeCache
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The Indirect Content Addressable Storage (ICAS) backend from which
+	// Reference objects are loaded.
+	IndirectContentAddressableStorage *BlobAccessConfiguration `protobuf:"bytes,1,opt,name=indirect_content_addressable_storage,json=indirectContentAddressableStorage,proto3" json:"indirect_content_addressable_storage,omitempty"`
+	// Optional: AWS access options and credentials for objects loaded
+	// from S3.
+	AwsSession *aws.SessionConfiguration `protobuf:"bytes,2,opt,name=aws_session,json=awsSession,proto3" json:"aws_session,omitempty"`
+	// Optional: Options to be used by the HTTP client.
+	HttpClient *client.Configuration `protobuf:"bytes,3,opt,name=http_client,json=httpClient,proto3" json:"http_client,omitempty"`
+	// Optional: Google Cloud Platform (GCP) client options for objects
+	// loaded from GCS. Support for GCS is disabled if left unspecified.
+	GcpClientOptions *gcp.ClientOptionsConfiguration `protobuf:"bytes,4,opt,name=gcp_client_options,json=gcpClientOptions,proto3" json:"gcp_client_options,omitempty"`
+	// Optional: Storage backend to use when Reference objects refer to
+	// objects stored in another Content Addressable Storage.
+	ContentAddressableStorage *BlobAccessConfiguration `protobuf:"bytes,5,opt,name=content_addressable_storage,json=contentAddressableStorage,proto3" json:"content_addressable_storage,omitempty"`
+	unknownFields             protoimpl.UnknownFields
+	sizeCache                 protoimpl.SizeCache
 }
 
 func (x *ReferenceExpandingBlobAccessConfiguration) Reset() {
 	*x = ReferenceExpandingBlobAccessConfiguration{}
-	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[9]
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -993,7 +2073,7 @@ func (x *ReferenceExpandingBlobAccessConfiguration) String() string {
 func (*ReferenceExpandingBlobAccessConfiguration) ProtoMessage() {}
 
 func (x *ReferenceExpandingBlobAccessConfiguration) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[9]
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1006,7 +2086,7 @@ func (x *ReferenceExpandingBlobAccessConfiguration) ProtoReflect() protoreflect.
 
 // Deprecated: Use ReferenceExpandingBlobAccessConfiguration.ProtoReflect.Descriptor instead.
 func (*ReferenceExpandingBlobAccessConfiguration) Descriptor() ([]byte, []int) {
-	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{9}
+	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *ReferenceExpandingBlobAccessConfiguration) GetIndirectContentAddressableStorage() *BlobAccessConfiguration {
@@ -1061,7 +2141,7 @@ type BlobReplicatorConfiguration struct {
 
 func (x *BlobReplicatorConfiguration) Reset() {
 	*x = BlobReplicatorConfiguration{}
-	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[10]
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1073,7 +2153,7 @@ func (x *BlobReplicatorConfiguration) String() string {
 func (*BlobReplicatorConfiguration) ProtoMessage() {}
 
 func (x *BlobReplicatorConfiguration) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[10]
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1086,7 +2166,7 @@ func (x *BlobReplicatorConfiguration) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BlobReplicatorConfiguration.ProtoReflect.Descriptor instead.
 func (*BlobReplicatorConfiguration) Descriptor() ([]byte, []int) {
-	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{10}
+	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *BlobReplicatorConfiguration) GetMode() isBlobReplicatorConfiguration_Mode {
@@ -1155,26 +2235,102 @@ type isBlobReplicatorConfiguration_Mode interface {
 }
 
 type BlobReplicatorConfiguration_Local struct {
+	// When blobs are only present in one backend, but not the other,
+	// they are copied by the client immediately.
+	//
+	// Because there is no orchestration between clients, this may for
+	// certain workloads cause multiple clients to all replicate the
+	// same objects. Especially for setups with many clients, this could
+	// put a lot of pressure on storage nodes.
+	//
+	// This strategy may still be acceptable for the Action Cache, even
+	// for larger setups. The Action Cache receives less load than the
+	// Content Addressable Storage. There is also a lower propbability
+	// of clients requesting the same object at around the same time.
+	// Action Cache objects also tend to be relatively small, meaning
+	// that little space and bandwidth is wasted when replicating
+	// objects unnecessarily.
 	Local *emptypb.Empty `protobuf:"bytes,1,opt,name=local,proto3,oneof"`
 }
 
 type BlobReplicatorConfiguration_Remote struct {
+	// Instruct an external gRPC service (bb_replicator) to perform
+	// replications. This is advised for setups with a larger number of
+	// clients, as a centralized replicator process may deduplicate
+	// replication actions. This reduces the load on storage nodes.
+	//
+	// This strategy is only supported for the Content Addressable
+	// Storage.
 	Remote *grpc.ClientConfiguration `protobuf:"bytes,2,opt,name=remote,proto3,oneof"`
 }
 
 type BlobReplicatorConfiguration_Queued struct {
+	// Queue and deduplicate all replication operations prior to
+	// executing them.
+	//
+	// In setups with a high volume of requests, it may normally be
+	// unsafe to restart a non-persistent storage node. Once the storage
+	// node would come back online, it would succumb to traffic
+	// generated by clients to replicate missing data.
+	//
+	// By executing all replication operations sequentially, the amount
+	// of pressure placed on storage nodes is bounded. By letting a
+	// dedicated bb_replicator instance use this strategy, replication
+	// throughput is bounded globally.
+	//
+	// TODO: This backend shares some overlap with 'deduplicating' and
+	// 'concurrency_limiting'. Should it be removed in favor of those?
+	// Right now this backend is more efficient for remote sinks,
+	// because it doesn't decompose requests for multiple objects.
 	Queued *QueuedBlobReplicatorConfiguration `protobuf:"bytes,3,opt,name=queued,proto3,oneof"`
 }
 
 type BlobReplicatorConfiguration_Noop struct {
+	// No replication will be performed. This can be useful when one
+	// or more of the backends have their contents managed externally.
 	Noop *emptypb.Empty `protobuf:"bytes,4,opt,name=noop,proto3,oneof"`
 }
 
 type BlobReplicatorConfiguration_Deduplicating struct {
+	// Ensure that blobs are not replicated redundantly. Replication
+	// requests for the same blob are merged. To deal with potential
+	// race conditions, double check whether the sink already contains a
+	// blob before copying.
+	//
+	// In order to guarantee responsiveness for all callers, this
+	// replicator decomposes requests for multiple blobs into one
+	// request per blob. To prevent callers from stalling the
+	// replication process, it also doesn't stream data back to the
+	// caller as it is being replicated. This means that blobs are fully
+	// replicated from the source to the sink, prior to letting the
+	// caller read the data from the sink at its own pace.
+	//
+	// This replicator has been designed to reduce the amount of traffic
+	// against the source to an absolute minimum, at the cost of
+	// generating more traffic against the sink. It is recommended to
+	// use this replicator when the sink is an instance of
+	// LocalBlobAccess that is embedded into the same process, and blobs
+	// are expected to be consumed locally.
+	//
+	// This strategy is only supported for the Content Addressable
+	// Storage (CAS) and Indirect Content Addressable Storage (ICAS).
 	Deduplicating *BlobReplicatorConfiguration `protobuf:"bytes,5,opt,name=deduplicating,proto3,oneof"`
 }
 
 type BlobReplicatorConfiguration_ConcurrencyLimiting struct {
+	// Ensure that the total number of concurrent replication requests
+	// remains bounded by a constant. By limiting the number of
+	// concurrent requests issues against a source, network starvation
+	// may be prevented.
+	//
+	// If this replicator is used in combination with 'deduplicating',
+	// it is recommended that 'deduplicating' is placed on the outside.
+	// More concretely:
+	//
+	//     { deduplicating: { concurrencyLimiting: { ... } }
+	//
+	// Otherwise, the concurrency limit will be applied against requests
+	// that haven't been deduplicated yet, leading to lower concurrency.
 	ConcurrencyLimiting *ConcurrencyLimitingBlobReplicatorConfiguration `protobuf:"bytes,6,opt,name=concurrency_limiting,json=concurrencyLimiting,proto3,oneof"`
 }
 
@@ -1191,8 +2347,11 @@ func (*BlobReplicatorConfiguration_Deduplicating) isBlobReplicatorConfiguration_
 func (*BlobReplicatorConfiguration_ConcurrencyLimiting) isBlobReplicatorConfiguration_Mode() {}
 
 type QueuedBlobReplicatorConfiguration struct {
-	state          protoimpl.MessageState              `protogen:"open.v1"`
-	Base           *BlobReplicatorConfiguration        `protobuf:"bytes,1,opt,name=base,proto3" json:"base,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Base replication strategy to which calls should be forwarded.
+	Base *BlobReplicatorConfiguration `protobuf:"bytes,1,opt,name=base,proto3" json:"base,omitempty"`
+	// Parameters for the cache data structure that is used to incoming
+	// deduplicate replication operations.
 	ExistenceCache *digest.ExistenceCacheConfiguration `protobuf:"bytes,2,opt,name=existence_cache,json=existenceCache,proto3" json:"existence_cache,omitempty"`
 	unknownFields  protoimpl.UnknownFields
 	sizeCache      protoimpl.SizeCache
@@ -1200,7 +2359,7 @@ type QueuedBlobReplicatorConfiguration struct {
 
 func (x *QueuedBlobReplicatorConfiguration) Reset() {
 	*x = QueuedBlobReplicatorConfiguration{}
-	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[11]
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1212,7 +2371,7 @@ func (x *QueuedBlobReplicatorConfiguration) String() string {
 func (*QueuedBlobReplicatorConfiguration) ProtoMessage() {}
 
 func (x *QueuedBlobReplicatorConfiguration) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[11]
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1225,7 +2384,7 @@ func (x *QueuedBlobReplicatorConfiguration) ProtoReflect() protoreflect.Message
 
 // Deprecated: Use QueuedBlobReplicatorConfiguration.ProtoReflect.Descriptor instead.
 func (*QueuedBlobReplicatorConfiguration) Descriptor() ([]byte, []int) {
-	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{11}
+	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{14}
 }
 
 func (x *QueuedBlobReplicatorConfiguration) GetBase() *BlobReplicatorConfiguration {
@@ -1243,16 +2402,19 @@ func (x *QueuedBlobReplicatorConfiguration) GetExistenceCache() *digest.Existenc
 }
 
 type ConcurrencyLimitingBlobReplicatorConfiguration struct {
-	state              protoimpl.MessageState       `protogen:"open.v1"`
-	Base               *BlobReplicatorConfiguration `protobuf:"bytes,1,opt,name=base,proto3" json:"base,omitempty"`
-	MaximumConcurrency int64                        `protobuf:"varint,2,opt,name=maximum_concurrency,json=maximumConcurrency,proto3" json:"maximum_concurrency,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Base replication strategy to which calls should be forwarded.
+	Base *BlobReplicatorConfiguration `protobuf:"bytes,1,opt,name=base,proto3" json:"base,omitempty"`
+	// The maximum number of concurrent replication requests that are
+	// forwarded to the base replication strategy.
+	MaximumConcurrency int64 `protobuf:"varint,2,opt,name=maximum_concurrency,json=maximumConcurrency,proto3" json:"maximum_concurrency,omitempty"`
 	unknownFields      protoimpl.UnknownFields
 	sizeCache          protoimpl.SizeCache
 }
 
 func (x *ConcurrencyLimitingBlobReplicatorConfiguration) Reset() {
 	*x = ConcurrencyLimitingBlobReplicatorConfiguration{}
-	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[12]
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1264,7 +2426,7 @@ func (x *ConcurrencyLimitingBlobReplicatorConfiguration) String() string {
 func (*ConcurrencyLimitingBlobReplicatorConfiguration) ProtoMessage() {}
 
 func (x *ConcurrencyLimitingBlobReplicatorConfiguration) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[12]
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1277,7 +2439,7 @@ func (x *ConcurrencyLimitingBlobReplicatorConfiguration) ProtoReflect() protoref
 
 // Deprecated: Use ConcurrencyLimitingBlobReplicatorConfiguration.ProtoReflect.Descriptor instead.
 func (*ConcurrencyLimitingBlobReplicatorConfiguration) Descriptor() ([]byte, []int) {
-	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{12}
+	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{15}
 }
 
 func (x *ConcurrencyLimitingBlobReplicatorConfiguration) GetBase() *BlobReplicatorConfiguration {
@@ -1295,7 +2457,20 @@ func (x *ConcurrencyLimitingBlobReplicatorConfiguration) GetMaximumConcurrency()
 }
 
 type DemultiplexingBlobAccessConfiguration struct {
-	state                protoimpl.MessageState                           `protogen:"open.v1"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Map of storage backends, where the key corresponds to the instance
+	// name prefix to match. In case of multiple matches, the storage
+	// backend with the longest matching prefix is used. The matching
+	// prefix is removed from the resulting instance name.
+	//
+	// For example, if storage backends for instance name prefixes
+	// "acmecorp" and "acmecorp/rockets" are declared, requests for
+	// instance name "acmecorp/rockets/mars" will be forwarded to the
+	// latter. This storage backend will receive requests with instance
+	// name "mars".
+	//
+	// The empty string can be used to match all instance names, thereby
+	// causing all requests to be forwarded to a single storage backend.
 	InstanceNamePrefixes map[string]*DemultiplexedBlobAccessConfiguration `protobuf:"bytes,1,rep,name=instance_name_prefixes,json=instanceNamePrefixes,proto3" json:"instance_name_prefixes,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
 	unknownFields        protoimpl.UnknownFields
 	sizeCache            protoimpl.SizeCache
@@ -1303,7 +2478,7 @@ type DemultiplexingBlobAccessConfiguration struct {
 
 func (x *DemultiplexingBlobAccessConfiguration) Reset() {
 	*x = DemultiplexingBlobAccessConfiguration{}
-	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[13]
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[16]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1315,7 +2490,7 @@ func (x *DemultiplexingBlobAccessConfiguration) String() string {
 func (*DemultiplexingBlobAccessConfiguration) ProtoMessage() {}
 
 func (x *DemultiplexingBlobAccessConfiguration) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[13]
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[16]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1328,7 +2503,7 @@ func (x *DemultiplexingBlobAccessConfiguration) ProtoReflect() protoreflect.Mess
 
 // Deprecated: Use DemultiplexingBlobAccessConfiguration.ProtoReflect.Descriptor instead.
 func (*DemultiplexingBlobAccessConfiguration) Descriptor() ([]byte, []int) {
-	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{13}
+	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{16}
 }
 
 func (x *DemultiplexingBlobAccessConfiguration) GetInstanceNamePrefixes() map[string]*DemultiplexedBlobAccessConfiguration {
@@ -1339,16 +2514,19 @@ func (x *DemultiplexingBlobAccessConfiguration) GetInstanceNamePrefixes() map[st
 }
 
 type DemultiplexedBlobAccessConfiguration struct {
-	state                 protoimpl.MessageState   `protogen:"open.v1"`
-	Backend               *BlobAccessConfiguration `protobuf:"bytes,1,opt,name=backend,proto3" json:"backend,omitempty"`
-	AddInstanceNamePrefix string                   `protobuf:"bytes,2,opt,name=add_instance_name_prefix,json=addInstanceNamePrefix,proto3" json:"add_instance_name_prefix,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The backend to which requests are forwarded.
+	Backend *BlobAccessConfiguration `protobuf:"bytes,1,opt,name=backend,proto3" json:"backend,omitempty"`
+	// Add a prefix to the instance name of all requests forwarded to this
+	// backend.
+	AddInstanceNamePrefix string `protobuf:"bytes,2,opt,name=add_instance_name_prefix,json=addInstanceNamePrefix,proto3" json:"add_instance_name_prefix,omitempty"`
 	unknownFields         protoimpl.UnknownFields
 	sizeCache             protoimpl.SizeCache
 }
 
 func (x *DemultiplexedBlobAccessConfiguration) Reset() {
 	*x = DemultiplexedBlobAccessConfiguration{}
-	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[14]
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[17]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1360,7 +2538,7 @@ func (x *DemultiplexedBlobAccessConfiguration) String() string {
 func (*DemultiplexedBlobAccessConfiguration) ProtoMessage() {}
 
 func (x *DemultiplexedBlobAccessConfiguration) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[14]
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[17]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1373,7 +2551,7 @@ func (x *DemultiplexedBlobAccessConfiguration) ProtoReflect() protoreflect.Messa
 
 // Deprecated: Use DemultiplexedBlobAccessConfiguration.ProtoReflect.Descriptor instead.
 func (*DemultiplexedBlobAccessConfiguration) Descriptor() ([]byte, []int) {
-	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{14}
+	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{17}
 }
 
 func (x *DemultiplexedBlobAccessConfiguration) GetBackend() *BlobAccessConfiguration {
@@ -1391,18 +2569,32 @@ func (x *DemultiplexedBlobAccessConfiguration) GetAddInstanceNamePrefix() string
 }
 
 type ActionResultExpiringBlobAccessConfiguration struct {
-	state                 protoimpl.MessageState   `protogen:"open.v1"`
-	Backend               *BlobAccessConfiguration `protobuf:"bytes,1,opt,name=backend,proto3" json:"backend,omitempty"`
-	MinimumValidity       *durationpb.Duration     `protobuf:"bytes,2,opt,name=minimum_validity,json=minimumValidity,proto3" json:"minimum_validity,omitempty"`
-	MaximumValidityJitter *durationpb.Duration     `protobuf:"bytes,3,opt,name=maximum_validity_jitter,json=maximumValidityJitter,proto3" json:"maximum_validity_jitter,omitempty"`
-	MinimumTimestamp      *timestamppb.Timestamp   `protobuf:"bytes,4,opt,name=minimum_timestamp,json=minimumTimestamp,proto3" json:"minimum_timestamp,omitempty"`
-	unknownFields         protoimpl.UnknownFields
-	sizeCache             protoimpl.SizeCache
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The backend to which requests are forwarded.
+	Backend *BlobAccessConfiguration `protobuf:"bytes,1,opt,name=backend,proto3" json:"backend,omitempty"`
+	// The minimum amount of time to pass before an ActionResult expires.
+	MinimumValidity *durationpb.Duration `protobuf:"bytes,2,opt,name=minimum_validity,json=minimumValidity,proto3" json:"minimum_validity,omitempty"`
+	// Maximum amount of jitter to be added to the expiration time. This
+	// ensures that actions that were built at around the same time don't
+	// also expire at around the same time, therefore amortizing the rate
+	// at which actions are rebuilt.
+	//
+	// The process for computing the jitter is deterministic, meaning that
+	// subsequent requests for the same ActionResult still yield the same
+	// expiration time.
+	MaximumValidityJitter *durationpb.Duration `protobuf:"bytes,3,opt,name=maximum_validity_jitter,json=maximumValidityJitter,proto3" json:"maximum_validity_jitter,omitempty"`
+	// The minimum value 'worker_completed_timestamp' should have for it
+	// to be considered valid. This can be used to fully invalidate the
+	// contents of the Action Cache (AC) in case its contents have become
+	// poisoned.
+	MinimumTimestamp *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=minimum_timestamp,json=minimumTimestamp,proto3" json:"minimum_timestamp,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
 }
 
 func (x *ActionResultExpiringBlobAccessConfiguration) Reset() {
 	*x = ActionResultExpiringBlobAccessConfiguration{}
-	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[15]
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[18]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1414,7 +2606,7 @@ func (x *ActionResultExpiringBlobAccessConfiguration) String() string {
 func (*ActionResultExpiringBlobAccessConfiguration) ProtoMessage() {}
 
 func (x *ActionResultExpiringBlobAccessConfiguration) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[15]
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[18]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1427,7 +2619,7 @@ func (x *ActionResultExpiringBlobAccessConfiguration) ProtoReflect() protoreflec
 
 // Deprecated: Use ActionResultExpiringBlobAccessConfiguration.ProtoReflect.Descriptor instead.
 func (*ActionResultExpiringBlobAccessConfiguration) Descriptor() ([]byte, []int) {
-	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{15}
+	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{18}
 }
 
 func (x *ActionResultExpiringBlobAccessConfiguration) GetBackend() *BlobAccessConfiguration {
@@ -1451,38 +2643,1295 @@ func (x *ActionResultExpiringBlobAccessConfiguration) GetMaximumValidityJitter()
 	return nil
 }
 
-func (x *ActionResultExpiringBlobAccessConfiguration) GetMinimumTimestamp() *timestamppb.Timestamp {
+func (x *ActionResultExpiringBlobAccessConfiguration) GetMinimumTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.MinimumTimestamp
+	}
+	return nil
+}
+
+type ReadCanaryingBlobAccessConfiguration struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The backend that is the source of truth.
+	Source *BlobAccessConfiguration `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`
+	// The backend that contains a read-only replica of the source.
+	Replica *BlobAccessConfiguration `protobuf:"bytes,2,opt,name=replica,proto3" json:"replica,omitempty"`
+	// Size of the cache that is used to track the availability of the
+	// replica on a per REv2 instance name basis. This ensures that if the
+	// replica uses features authoriation and demultiplexing based on
+	// instance names, availability is tracked accurately.
+	//
+	// Recommended value: 256
+	MaximumCacheSize int32 `protobuf:"varint,3,opt,name=maximum_cache_size,json=maximumCacheSize,proto3" json:"maximum_cache_size,omitempty"`
+	// The validity duration of cache entries. This controls how much time
+	// may pass without any read traffic before the backend falls back to
+	// the default state.
+	//
+	// Recommended value: 300s
+	MaximumCacheDuration *durationpb.Duration `protobuf:"bytes,4,opt,name=maximum_cache_duration,json=maximumCacheDuration,proto3" json:"maximum_cache_duration,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *ReadCanaryingBlobAccessConfiguration) Reset() {
+	*x = ReadCanaryingBlobAccessConfiguration{}
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadCanaryingBlobAccessConfiguration) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadCanaryingBlobAccessConfiguration) ProtoMessage() {}
+
+func (x *ReadCanaryingBlobAccessConfiguration) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadCanaryingBlobAccessConfiguration.ProtoReflect.Descriptor instead.
+func (*ReadCanaryingBlobAccessConfiguration) Descriptor() ([]byte, []int) {
+	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *ReadCanaryingBlobAccessConfiguration) GetSource() *BlobAccessConfiguration {
+	if x != nil {
+		return x.Source
+	}
+	return nil
+}
+
+func (x *ReadCanaryingBlobAccessConfiguration) GetReplica() *BlobAccessConfiguration {
+	if x != nil {
+		return x.Replica
+	}
+	return nil
+}
+
+func (x *ReadCanaryingBlobAccessConfiguration) GetMaximumCacheSize() int32 {
+	if x != nil {
+		return x.MaximumCacheSize
+	}
+	return 0
+}
+
+func (x *ReadCanaryingBlobAccessConfiguration) GetMaximumCacheDuration() *durationpb.Duration {
+	if x != nil {
+		return x.MaximumCacheDuration
+	}
+	return nil
+}
+
+type ZIPBlobAccessConfiguration struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Path of the ZIP file.
+	Path string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	// When set, temporarily cache the integrity of data after it's been
+	// read from the ZIP file. Once cached, uncompressed files in the ZIP
+	// file (i.e., ones stored with compression method STORE) may be
+	// randomly accessed quickly.
+	//
+	// The disadvantage of enabling this option is that data corruption in
+	// the ZIP file may not be detected. It is therefore recommended to
+	// set the cache duration to a limited value (e.g., "4h").
+	DataIntegrityValidationCache *digest.ExistenceCacheConfiguration `protobuf:"bytes,2,opt,name=data_integrity_validation_cache,json=dataIntegrityValidationCache,proto3" json:"data_integrity_validation_cache,omitempty"`
+	unknownFields                protoimpl.UnknownFields
+	sizeCache                    protoimpl.SizeCache
+}
+
+func (x *ZIPBlobAccessConfiguration) Reset() {
+	*x = ZIPBlobAccessConfiguration{}
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ZIPBlobAccessConfiguration) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ZIPBlobAccessConfiguration) ProtoMessage() {}
+
+func (x *ZIPBlobAccessConfiguration) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ZIPBlobAccessConfiguration.ProtoReflect.Descriptor instead.
+func (*ZIPBlobAccessConfiguration) Descriptor() ([]byte, []int) {
+	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *ZIPBlobAccessConfiguration) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *ZIPBlobAccessConfiguration) GetDataIntegrityValidationCache() *digest.ExistenceCacheConfiguration {
+	if x != nil {
+		return x.DataIntegrityValidationCache
+	}
+	return nil
+}
+
+type WithLabelsBlobAccessConfiguration struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The backend that should be created, having access to the declared
+	// labels.
+	Backend *BlobAccessConfiguration `protobuf:"bytes,1,opt,name=backend,proto3" json:"backend,omitempty"`
+	// A map of string labels to backends that can be referenced.
+	Labels        map[string]*BlobAccessConfiguration `protobuf:"bytes,2,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WithLabelsBlobAccessConfiguration) Reset() {
+	*x = WithLabelsBlobAccessConfiguration{}
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WithLabelsBlobAccessConfiguration) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WithLabelsBlobAccessConfiguration) ProtoMessage() {}
+
+func (x *WithLabelsBlobAccessConfiguration) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WithLabelsBlobAccessConfiguration.ProtoReflect.Descriptor instead.
+func (*WithLabelsBlobAccessConfiguration) Descriptor() ([]byte, []int) {
+	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *WithLabelsBlobAccessConfiguration) GetBackend() *BlobAccessConfiguration {
+	if x != nil {
+		return x.Backend
+	}
+	return nil
+}
+
+func (x *WithLabelsBlobAccessConfiguration) GetLabels() map[string]*BlobAccessConfiguration {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+type DeadlineEnforcingBlobAccess struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The duration to use for the timeout. After this duration, the
+	// context will be cancelled, so please ensure this is long enough
+	// for any operations you expect to execute to finish.
+	Timeout *durationpb.Duration `protobuf:"bytes,1,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	// The backend to which all operations are delegated.
+	Backend       *BlobAccessConfiguration `protobuf:"bytes,2,opt,name=backend,proto3" json:"backend,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeadlineEnforcingBlobAccess) Reset() {
+	*x = DeadlineEnforcingBlobAccess{}
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeadlineEnforcingBlobAccess) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeadlineEnforcingBlobAccess) ProtoMessage() {}
+
+func (x *DeadlineEnforcingBlobAccess) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeadlineEnforcingBlobAccess.ProtoReflect.Descriptor instead.
+func (*DeadlineEnforcingBlobAccess) Descriptor() ([]byte, []int) {
+	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *DeadlineEnforcingBlobAccess) GetTimeout() *durationpb.Duration {
+	if x != nil {
+		return x.Timeout
+	}
+	return nil
+}
+
+func (x *DeadlineEnforcingBlobAccess) GetBackend() *BlobAccessConfiguration {
+	if x != nil {
+		return x.Backend
+	}
+	return nil
+}
+
+type CircuitBreakingBlobAccessConfiguration struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The backend to which all operations are delegated.
+	Backend *BlobAccessConfiguration `protobuf:"bytes,1,opt,name=backend,proto3" json:"backend,omitempty"`
+	// Name of the circuit breaker, used as a label for the metric
+	// exposing its state. The name must be non-empty, and unique among
+	// all circuit breakers of the same storage type.
+	Name string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// The number of consecutive requests that need to fail with
+	// UNAVAILABLE or DEADLINE_EXCEEDED for the circuit breaker to open.
+	// Requests that fail because the client cancelled them or because
+	// the client's deadline expired are not counted.
+	FailureThreshold uint32 `protobuf:"varint,3,opt,name=failure_threshold,json=failureThreshold,proto3" json:"failure_threshold,omitempty"`
+	// The amount of time the circuit breaker remains open. Once
+	// elapsed, the circuit breaker becomes half-open, meaning a single
+	// request is forwarded to the backend. If it succeeds, the circuit
+	// breaker closes. Otherwise it reopens.
+	Cooldown *durationpb.Duration `protobuf:"bytes,4,opt,name=cooldown,proto3" json:"cooldown,omitempty"`
+	// If set, FindMissing() reports all objects as missing while the
+	// circuit breaker is open, instead of failing. Calls to Put() still
+	// fail while the circuit breaker is open. Clients are thus only
+	// capable of uploading these objects if they are also written to
+	// other backends, such as other shards of 'sharding' when
+	// 'replication_factor' is greater than one.
+	DegradedFindMissing bool `protobuf:"varint,5,opt,name=degraded_find_missing,json=degradedFindMissing,proto3" json:"degraded_find_missing,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *CircuitBreakingBlobAccessConfiguration) Reset() {
+	*x = CircuitBreakingBlobAccessConfiguration{}
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CircuitBreakingBlobAccessConfiguration) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CircuitBreakingBlobAccessConfiguration) ProtoMessage() {}
+
+func (x *CircuitBreakingBlobAccessConfiguration) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CircuitBreakingBlobAccessConfiguration.ProtoReflect.Descriptor instead.
+func (*CircuitBreakingBlobAccessConfiguration) Descriptor() ([]byte, []int) {
+	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *CircuitBreakingBlobAccessConfiguration) GetBackend() *BlobAccessConfiguration {
+	if x != nil {
+		return x.Backend
+	}
+	return nil
+}
+
+func (x *CircuitBreakingBlobAccessConfiguration) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CircuitBreakingBlobAccessConfiguration) GetFailureThreshold() uint32 {
+	if x != nil {
+		return x.FailureThreshold
+	}
+	return 0
+}
+
+func (x *CircuitBreakingBlobAccessConfiguration) GetCooldown() *durationpb.Duration {
+	if x != nil {
+		return x.Cooldown
+	}
+	return nil
+}
+
+func (x *CircuitBreakingBlobAccessConfiguration) GetDegradedFindMissing() bool {
+	if x != nil {
+		return x.DegradedFindMissing
+	}
+	return false
+}
+
+type RateLimitingBlobAccessConfiguration struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The backend to which all operations are delegated.
+	Backend *BlobAccessConfiguration `protobuf:"bytes,1,opt,name=backend,proto3" json:"backend,omitempty"`
+	// JMESPath expression that is used to compute the key by which
+	// requests are grouped. The expression is called with a JSON object
+	// that includes both the REv2 instance name and authentication
+	// metadata, in the form:
+	//
+	// {
+	//   "authenticationMetadata": ...,
+	//   "instanceName": "foo/bar"
+	// }
+	//
+	// Results that are not strings are converted to JSON.
+	KeyJmespathExpression *jmespath.Expression `protobuf:"bytes,2,opt,name=key_jmespath_expression,json=keyJmespathExpression,proto3" json:"key_jmespath_expression,omitempty"`
+	// Limits of Get() and GetFromComposite(). If unset, these
+	// operations are not limited.
+	Get *RateLimitingBlobAccessConfiguration_Limit `protobuf:"bytes,3,opt,name=get,proto3" json:"get,omitempty"`
+	// Limits of Put(). If unset, this operation is not limited.
+	Put *RateLimitingBlobAccessConfiguration_Limit `protobuf:"bytes,4,opt,name=put,proto3" json:"put,omitempty"`
+	// Limits of FindMissing(). If unset, this operation is not
+	// limited. Every call counts as a single request, regardless of
+	// the number of digests provided.
+	FindMissing *RateLimitingBlobAccessConfiguration_Limit `protobuf:"bytes,5,opt,name=find_missing,json=findMissing,proto3" json:"find_missing,omitempty"`
+	// The maximum number of keys for which state is tracked. When
+	// exceeded, the state of the least recently used key is discarded.
+	// As requests are counted by the metrics per key, this also bounds
+	// the cardinality of the metrics.
+	//
+	// Buckets are normally full when first used. To prevent clients
+	// from obtaining full buckets by causing their state to be
+	// discarded, keys for which state needs to be created by discarding
+	// the state of another key start with empty buckets, unless the
+	// buckets of the discarded key had refilled completely. This value
+	// should thus be well above the number of keys that are active
+	// simultaneously.
+	MaximumKeys   uint32 `protobuf:"varint,6,opt,name=maximum_keys,json=maximumKeys,proto3" json:"maximum_keys,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RateLimitingBlobAccessConfiguration) Reset() {
+	*x = RateLimitingBlobAccessConfiguration{}
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RateLimitingBlobAccessConfiguration) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RateLimitingBlobAccessConfiguration) ProtoMessage() {}
+
+func (x *RateLimitingBlobAccessConfiguration) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RateLimitingBlobAccessConfiguration.ProtoReflect.Descriptor instead.
+func (*RateLimitingBlobAccessConfiguration) Descriptor() ([]byte, []int) {
+	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *RateLimitingBlobAccessConfiguration) GetBackend() *BlobAccessConfiguration {
+	if x != nil {
+		return x.Backend
+	}
+	return nil
+}
+
+func (x *RateLimitingBlobAccessConfiguration) GetKeyJmespathExpression() *jmespath.Expression {
+	if x != nil {
+		return x.KeyJmespathExpression
+	}
+	return nil
+}
+
+func (x *RateLimitingBlobAccessConfiguration) GetGet() *RateLimitingBlobAccessConfiguration_Limit {
+	if x != nil {
+		return x.Get
+	}
+	return nil
+}
+
+func (x *RateLimitingBlobAccessConfiguration) GetPut() *RateLimitingBlobAccessConfiguration_Limit {
+	if x != nil {
+		return x.Put
+	}
+	return nil
+}
+
+func (x *RateLimitingBlobAccessConfiguration) GetFindMissing() *RateLimitingBlobAccessConfiguration_Limit {
+	if x != nil {
+		return x.FindMissing
+	}
+	return nil
+}
+
+func (x *RateLimitingBlobAccessConfiguration) GetMaximumKeys() uint32 {
+	if x != nil {
+		return x.MaximumKeys
+	}
+	return 0
+}
+
+type QuotaEnforcingBlobAccessConfiguration struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The backend to which all operations are delegated.
+	Backend *BlobAccessConfiguration `protobuf:"bytes,1,opt,name=backend,proto3" json:"backend,omitempty"`
+	// Name of the quota, used as a label for metrics and to identify it
+	// on the diagnostics HTTP server. Names must be unique.
+	Name string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// The method by which the tenant of a request is determined.
+	//
+	// Types that are valid to be assigned to Tenant:
+	//
+	//	*QuotaEnforcingBlobAccessConfiguration_TenantJmespathExpression
+	//	*QuotaEnforcingBlobAccessConfiguration_TenantInstanceNamePrefixComponents
+	Tenant isQuotaEnforcingBlobAccessConfiguration_Tenant `protobuf_oneof:"tenant"`
+	// The duration of the sliding window within which usage is tracked.
+	Window *durationpb.Duration `protobuf:"bytes,5,opt,name=window,proto3" json:"window,omitempty"`
+	// The maximum number of bytes each tenant may write within the
+	// sliding window, keyed by tenant name. A quota of zero means that
+	// the tenant is not limited.
+	TenantQuotaBytes map[string]uint64 `protobuf:"bytes,6,rep,name=tenant_quota_bytes,json=tenantQuotaBytes,proto3" json:"tenant_quota_bytes,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	// The quota of tenants not listed in 'tenant_quota_bytes'. If zero,
+	// these tenants are not limited.
+	DefaultQuotaBytes uint64 `protobuf:"varint,7,opt,name=default_quota_bytes,json=defaultQuotaBytes,proto3" json:"default_quota_bytes,omitempty"`
+	// If zero, writes of tenants that exceeded their quota are rejected
+	// with RESOURCE_EXHAUSTED. Otherwise, they are permitted, but the
+	// number of such writes that may be performed concurrently across
+	// all tenants is limited to this value.
+	DeprioritizedPutConcurrency int64 `protobuf:"varint,8,opt,name=deprioritized_put_concurrency,json=deprioritizedPutConcurrency,proto3" json:"deprioritized_put_concurrency,omitempty"`
+	unknownFields               protoimpl.UnknownFields
+	sizeCache                   protoimpl.SizeCache
+}
+
+func (x *QuotaEnforcingBlobAccessConfiguration) Reset() {
+	*x = QuotaEnforcingBlobAccessConfiguration{}
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QuotaEnforcingBlobAccessConfiguration) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QuotaEnforcingBlobAccessConfiguration) ProtoMessage() {}
+
+func (x *QuotaEnforcingBlobAccessConfiguration) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QuotaEnforcingBlobAccessConfiguration.ProtoReflect.Descriptor instead.
+func (*QuotaEnforcingBlobAccessConfiguration) Descriptor() ([]byte, []int) {
+	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *QuotaEnforcingBlobAccessConfiguration) GetBackend() *BlobAccessConfiguration {
+	if x != nil {
+		return x.Backend
+	}
+	return nil
+}
+
+func (x *QuotaEnforcingBlobAccessConfiguration) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *QuotaEnforcingBlobAccessConfiguration) GetTenant() isQuotaEnforcingBlobAccessConfiguration_Tenant {
+	if x != nil {
+		return x.Tenant
+	}
+	return nil
+}
+
+func (x *QuotaEnforcingBlobAccessConfiguration) GetTenantJmespathExpression() *jmespath.Expression {
+	if x != nil {
+		if x, ok := x.Tenant.(*QuotaEnforcingBlobAccessConfiguration_TenantJmespathExpression); ok {
+			return x.TenantJmespathExpression
+		}
+	}
+	return nil
+}
+
+func (x *QuotaEnforcingBlobAccessConfiguration) GetTenantInstanceNamePrefixComponents() uint32 {
+	if x != nil {
+		if x, ok := x.Tenant.(*QuotaEnforcingBlobAccessConfiguration_TenantInstanceNamePrefixComponents); ok {
+			return x.TenantInstanceNamePrefixComponents
+		}
+	}
+	return 0
+}
+
+func (x *QuotaEnforcingBlobAccessConfiguration) GetWindow() *durationpb.Duration {
+	if x != nil {
+		return x.Window
+	}
+	return nil
+}
+
+func (x *QuotaEnforcingBlobAccessConfiguration) GetTenantQuotaBytes() map[string]uint64 {
+	if x != nil {
+		return x.TenantQuotaBytes
+	}
+	return nil
+}
+
+func (x *QuotaEnforcingBlobAccessConfiguration) GetDefaultQuotaBytes() uint64 {
+	if x != nil {
+		return x.DefaultQuotaBytes
+	}
+	return 0
+}
+
+func (x *QuotaEnforcingBlobAccessConfiguration) GetDeprioritizedPutConcurrency() int64 {
+	if x != nil {
+		return x.DeprioritizedPutConcurrency
+	}
+	return 0
+}
+
+type isQuotaEnforcingBlobAccessConfiguration_Tenant interface {
+	isQuotaEnforcingBlobAccessConfiguration_Tenant()
+}
+
+type QuotaEnforcingBlobAccessConfiguration_TenantJmespathExpression struct {
+	// JMESPath expression that is used to compute the tenant of a
+	// request. The expression is called with a JSON object that
+	// includes both the REv2 instance name and authentication
+	// metadata, in the form:
+	//
+	// {
+	//   "authenticationMetadata": ...,
+	//   "instanceName": "foo/bar"
+	// }
+	//
+	// The expression must yield a string or null. Null is mapped to
+	// the empty tenant name.
+	TenantJmespathExpression *jmespath.Expression `protobuf:"bytes,3,opt,name=tenant_jmespath_expression,json=tenantJmespathExpression,proto3,oneof"`
+}
+
+type QuotaEnforcingBlobAccessConfiguration_TenantInstanceNamePrefixComponents struct {
+	// Use the leading components of the REv2 instance name as the
+	// tenant. For example, if set to 1, writes against instance names
+	// "foo/linux" and "foo/windows" both count towards the usage of
+	// tenant "foo".
+	TenantInstanceNamePrefixComponents uint32 `protobuf:"varint,4,opt,name=tenant_instance_name_prefix_components,json=tenantInstanceNamePrefixComponents,proto3,oneof"`
+}
+
+func (*QuotaEnforcingBlobAccessConfiguration_TenantJmespathExpression) isQuotaEnforcingBlobAccessConfiguration_Tenant() {
+}
+
+func (*QuotaEnforcingBlobAccessConfiguration_TenantInstanceNamePrefixComponents) isQuotaEnforcingBlobAccessConfiguration_Tenant() {
+}
+
+type S3BlobAccessConfiguration struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// AWS access options and credentials.
+	AwsSession *aws.SessionConfiguration `protobuf:"bytes,1,opt,name=aws_session,json=awsSession,proto3" json:"aws_session,omitempty"`
+	// Optional: URL of the S3 endpoint to use, overriding the one that
+	// is derived from the region (e.g., "http://minio:9000").
+	EndpointUrl string `protobuf:"bytes,2,opt,name=endpoint_url,json=endpointUrl,proto3" json:"endpoint_url,omitempty"`
+	// Use path-style addressing (i.e., "${endpoint}/${bucket}/${key}")
+	// instead of virtual-hosted-style addressing. This is typically
+	// needed when using S3 compatible object stores.
+	UsePathStyle bool `protobuf:"varint,3,opt,name=use_path_style,json=usePathStyle,proto3" json:"use_path_style,omitempty"`
+	// Name of the bucket in which objects are stored.
+	Bucket string `protobuf:"bytes,4,opt,name=bucket,proto3" json:"bucket,omitempty"`
+	// Optional: prefix to prepend to the keys of objects (e.g., "cas/").
+	// This permits storing multiple kinds of data in a single bucket.
+	KeyPrefix string `protobuf:"bytes,5,opt,name=key_prefix,json=keyPrefix,proto3" json:"key_prefix,omitempty"`
+	// Objects larger than this size are uploaded using multipart
+	// uploads, using parts of this size. S3 requires that all parts
+	// except the last are at least 5 MiB in size.
+	MultipartUploadPartSizeBytes int64 `protobuf:"varint,6,opt,name=multipart_upload_part_size_bytes,json=multipartUploadPartSizeBytes,proto3" json:"multipart_upload_part_size_bytes,omitempty"`
+	// The maximum number of HeadObject requests to issue in parallel
+	// when checking for the existence of objects.
+	FindMissingConcurrency int32 `protobuf:"varint,7,opt,name=find_missing_concurrency,json=findMissingConcurrency,proto3" json:"find_missing_concurrency,omitempty"`
+	// Optional: The maximum number of slices of objects to track, as
+	// reported by GetFromComposite(). This permits child objects, such
+	// as Directory objects contained in Tree objects, to be read using
+	// ranged reads, as opposed to downloading the parent object in its
+	// entirety. If zero, parent objects are always downloaded.
+	SliceCacheSize int64 `protobuf:"varint,8,opt,name=slice_cache_size,json=sliceCacheSize,proto3" json:"slice_cache_size,omitempty"`
+	// The cache replacement policy that should be applied to the slice
+	// cache. It is advised that this is set to LEAST_RECENTLY_USED.
+	SliceCacheReplacementPolicy eviction.CacheReplacementPolicy `protobuf:"varint,9,opt,name=slice_cache_replacement_policy,json=sliceCacheReplacementPolicy,proto3,enum=buildbarn.configuration.eviction.CacheReplacementPolicy" json:"slice_cache_replacement_policy,omitempty"`
+	unknownFields               protoimpl.UnknownFields
+	sizeCache                   protoimpl.SizeCache
+}
+
+func (x *S3BlobAccessConfiguration) Reset() {
+	*x = S3BlobAccessConfiguration{}
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *S3BlobAccessConfiguration) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*S3BlobAccessConfiguration) ProtoMessage() {}
+
+func (x *S3BlobAccessConfiguration) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use S3BlobAccessConfiguration.ProtoReflect.Descriptor instead.
+func (*S3BlobAccessConfiguration) Descriptor() ([]byte, []int) {
+	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *S3BlobAccessConfiguration) GetAwsSession() *aws.SessionConfiguration {
+	if x != nil {
+		return x.AwsSession
+	}
+	return nil
+}
+
+func (x *S3BlobAccessConfiguration) GetEndpointUrl() string {
+	if x != nil {
+		return x.EndpointUrl
+	}
+	return ""
+}
+
+func (x *S3BlobAccessConfiguration) GetUsePathStyle() bool {
+	if x != nil {
+		return x.UsePathStyle
+	}
+	return false
+}
+
+func (x *S3BlobAccessConfiguration) GetBucket() string {
+	if x != nil {
+		return x.Bucket
+	}
+	return ""
+}
+
+func (x *S3BlobAccessConfiguration) GetKeyPrefix() string {
+	if x != nil {
+		return x.KeyPrefix
+	}
+	return ""
+}
+
+func (x *S3BlobAccessConfiguration) GetMultipartUploadPartSizeBytes() int64 {
+	if x != nil {
+		return x.MultipartUploadPartSizeBytes
+	}
+	return 0
+}
+
+func (x *S3BlobAccessConfiguration) GetFindMissingConcurrency() int32 {
+	if x != nil {
+		return x.FindMissingConcurrency
+	}
+	return 0
+}
+
+func (x *S3BlobAccessConfiguration) GetSliceCacheSize() int64 {
+	if x != nil {
+		return x.SliceCacheSize
+	}
+	return 0
+}
+
+func (x *S3BlobAccessConfiguration) GetSliceCacheReplacementPolicy() eviction.CacheReplacementPolicy {
+	if x != nil {
+		return x.SliceCacheReplacementPolicy
+	}
+	return eviction.CacheReplacementPolicy(0)
+}
+
+type GCSBlobAccessConfiguration struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// GCP client options and credentials.
+	ClientOptions *gcp.ClientOptionsConfiguration `protobuf:"bytes,1,opt,name=client_options,json=clientOptions,proto3" json:"client_options,omitempty"`
+	// Name of the bucket in which objects are stored.
+	Bucket string `protobuf:"bytes,2,opt,name=bucket,proto3" json:"bucket,omitempty"`
+	// Optional: prefix to prepend to the names of objects (e.g., "cas/").
+	// This permits storing multiple kinds of data in a single bucket.
+	ObjectPrefix string `protobuf:"bytes,3,opt,name=object_prefix,json=objectPrefix,proto3" json:"object_prefix,omitempty"`
+	// Objects are uploaded using resumable uploads, using chunks of
+	// this size. Objects smaller than this size are uploaded using a
+	// single request. If zero, all objects are uploaded using a single
+	// request, meaning that failed uploads cannot be resumed.
+	ResumableUploadChunkSizeBytes int32 `protobuf:"varint,4,opt,name=resumable_upload_chunk_size_bytes,json=resumableUploadChunkSizeBytes,proto3" json:"resumable_upload_chunk_size_bytes,omitempty"`
+	// The minimum amount of time that needs to pass before the custom
+	// time of an object is updated when its existence is checked. This
+	// limits the number of metadata updates performed against objects
+	// that are used frequently. This value should be considerably
+	// smaller than the age used by bucket lifecycle rules.
+	CustomTimeRefreshInterval *durationpb.Duration `protobuf:"bytes,5,opt,name=custom_time_refresh_interval,json=customTimeRefreshInterval,proto3" json:"custom_time_refresh_interval,omitempty"`
+	// The maximum number of requests to issue in parallel when checking
+	// for the existence of objects.
+	FindMissingConcurrency int32 `protobuf:"varint,6,opt,name=find_missing_concurrency,json=findMissingConcurrency,proto3" json:"find_missing_concurrency,omitempty"`
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *GCSBlobAccessConfiguration) Reset() {
+	*x = GCSBlobAccessConfiguration{}
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GCSBlobAccessConfiguration) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GCSBlobAccessConfiguration) ProtoMessage() {}
+
+func (x *GCSBlobAccessConfiguration) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GCSBlobAccessConfiguration.ProtoReflect.Descriptor instead.
+func (*GCSBlobAccessConfiguration) Descriptor() ([]byte, []int) {
+	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *GCSBlobAccessConfiguration) GetClientOptions() *gcp.ClientOptionsConfiguration {
+	if x != nil {
+		return x.ClientOptions
+	}
+	return nil
+}
+
+func (x *GCSBlobAccessConfiguration) GetBucket() string {
+	if x != nil {
+		return x.Bucket
+	}
+	return ""
+}
+
+func (x *GCSBlobAccessConfiguration) GetObjectPrefix() string {
+	if x != nil {
+		return x.ObjectPrefix
+	}
+	return ""
+}
+
+func (x *GCSBlobAccessConfiguration) GetResumableUploadChunkSizeBytes() int32 {
+	if x != nil {
+		return x.ResumableUploadChunkSizeBytes
+	}
+	return 0
+}
+
+func (x *GCSBlobAccessConfiguration) GetCustomTimeRefreshInterval() *durationpb.Duration {
+	if x != nil {
+		return x.CustomTimeRefreshInterval
+	}
+	return nil
+}
+
+func (x *GCSBlobAccessConfiguration) GetFindMissingConcurrency() int32 {
+	if x != nil {
+		return x.FindMissingConcurrency
+	}
+	return 0
+}
+
+type DirectoryBlobAccessConfiguration struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Path of the directory in which objects are stored. The directory
+	// must already exist.
+	Path string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	// The maximum total size of all objects stored in the directory.
+	// When exceeded, objects are removed in the background, in the order
+	// determined by the cache replacement policy. The total size may
+	// temporarily exceed this limit while objects are being written.
+	MaximumSizeBytes int64 `protobuf:"varint,2,opt,name=maximum_size_bytes,json=maximumSizeBytes,proto3" json:"maximum_size_bytes,omitempty"`
+	// The cache replacement policy to use when removing objects. It is
+	// advised that this is set to LEAST_RECENTLY_USED.
+	CacheReplacementPolicy eviction.CacheReplacementPolicy `protobuf:"varint,3,opt,name=cache_replacement_policy,json=cacheReplacementPolicy,proto3,enum=buildbarn.configuration.eviction.CacheReplacementPolicy" json:"cache_replacement_policy,omitempty"`
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *DirectoryBlobAccessConfiguration) Reset() {
+	*x = DirectoryBlobAccessConfiguration{}
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DirectoryBlobAccessConfiguration) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DirectoryBlobAccessConfiguration) ProtoMessage() {}
+
+func (x *DirectoryBlobAccessConfiguration) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DirectoryBlobAccessConfiguration.ProtoReflect.Descriptor instead.
+func (*DirectoryBlobAccessConfiguration) Descriptor() ([]byte, []int) {
+	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *DirectoryBlobAccessConfiguration) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *DirectoryBlobAccessConfiguration) GetMaximumSizeBytes() int64 {
+	if x != nil {
+		return x.MaximumSizeBytes
+	}
+	return 0
+}
+
+func (x *DirectoryBlobAccessConfiguration) GetCacheReplacementPolicy() eviction.CacheReplacementPolicy {
+	if x != nil {
+		return x.CacheReplacementPolicy
+	}
+	return eviction.CacheReplacementPolicy(0)
+}
+
+type RedisBlobAccessConfiguration struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Addresses of the servers to connect to (e.g., "redis:6379"). If
+	// more than one address is provided, the servers are assumed to be
+	// part of a cluster.
+	Addresses []string `protobuf:"bytes,1,rep,name=addresses,proto3" json:"addresses,omitempty"`
+	// Use cluster mode, even if only a single address is provided. This
+	// is needed when connecting to a cluster through a single
+	// configuration endpoint.
+	ClusterMode bool `protobuf:"varint,2,opt,name=cluster_mode,json=clusterMode,proto3" json:"cluster_mode,omitempty"`
+	// Optional: credentials to use to authenticate against the server.
+	Username string `protobuf:"bytes,3,opt,name=username,proto3" json:"username,omitempty"`
+	Password string `protobuf:"bytes,4,opt,name=password,proto3" json:"password,omitempty"`
+	// Optional: the database to select. This option is not supported
+	// in cluster mode.
+	Database int32 `protobuf:"varint,5,opt,name=database,proto3" json:"database,omitempty"`
+	// Optional: TLS configuration to use when connecting to the server.
+	// If unset, connections are not encrypted.
+	Tls *tls.ClientConfiguration `protobuf:"bytes,6,opt,name=tls,proto3" json:"tls,omitempty"`
+	// Optional: prefix to prepend to keys (e.g., "ac:"). This permits
+	// storing multiple kinds of data in a single database.
+	KeyPrefix string `protobuf:"bytes,7,opt,name=key_prefix,json=keyPrefix,proto3" json:"key_prefix,omitempty"`
+	// Optional: the amount of time after which objects expire. The
+	// expiration time is extended whenever objects are accessed. If
+	// unset, objects do not expire, meaning that the server needs to be
+	// configured with an eviction policy such as 'allkeys-lru'.
+	Expiration *durationpb.Duration `protobuf:"bytes,8,opt,name=expiration,proto3" json:"expiration,omitempty"`
+	// The maximum size of objects that may be stored.
+	MaximumObjectSizeBytes int64 `protobuf:"varint,9,opt,name=maximum_object_size_bytes,json=maximumObjectSizeBytes,proto3" json:"maximum_object_size_bytes,omitempty"`
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *RedisBlobAccessConfiguration) Reset() {
+	*x = RedisBlobAccessConfiguration{}
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RedisBlobAccessConfiguration) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RedisBlobAccessConfiguration) ProtoMessage() {}
+
+func (x *RedisBlobAccessConfiguration) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RedisBlobAccessConfiguration.ProtoReflect.Descriptor instead.
+func (*RedisBlobAccessConfiguration) Descriptor() ([]byte, []int) {
+	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *RedisBlobAccessConfiguration) GetAddresses() []string {
+	if x != nil {
+		return x.Addresses
+	}
+	return nil
+}
+
+func (x *RedisBlobAccessConfiguration) GetClusterMode() bool {
+	if x != nil {
+		return x.ClusterMode
+	}
+	return false
+}
+
+func (x *RedisBlobAccessConfiguration) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *RedisBlobAccessConfiguration) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+func (x *RedisBlobAccessConfiguration) GetDatabase() int32 {
+	if x != nil {
+		return x.Database
+	}
+	return 0
+}
+
+func (x *RedisBlobAccessConfiguration) GetTls() *tls.ClientConfiguration {
+	if x != nil {
+		return x.Tls
+	}
+	return nil
+}
+
+func (x *RedisBlobAccessConfiguration) GetKeyPrefix() string {
+	if x != nil {
+		return x.KeyPrefix
+	}
+	return ""
+}
+
+func (x *RedisBlobAccessConfiguration) GetExpiration() *durationpb.Duration {
+	if x != nil {
+		return x.Expiration
+	}
+	return nil
+}
+
+func (x *RedisBlobAccessConfiguration) GetMaximumObjectSizeBytes() int64 {
+	if x != nil {
+		return x.MaximumObjectSizeBytes
+	}
+	return 0
+}
+
+type HTTPBlobAccessConfiguration struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// URL of the server, without a trailing slash (e.g.,
+	// "https://cache.example.com/bazel"). Objects are stored at URLs of
+	// the form ${address}/${instance_name}/${ac|cas}/${hash}. The
+	// instance name is omitted if empty.
+	Address string `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	// Options of the HTTP client that is used to communicate with the
+	// server, such as authentication and additional headers to set.
+	HttpClient *client.Configuration `protobuf:"bytes,2,opt,name=http_client,json=httpClient,proto3" json:"http_client,omitempty"`
+	// The maximum number of HEAD requests to issue in parallel when
+	// checking for the existence of objects.
+	FindMissingConcurrency int32 `protobuf:"varint,3,opt,name=find_missing_concurrency,json=findMissingConcurrency,proto3" json:"find_missing_concurrency,omitempty"`
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *HTTPBlobAccessConfiguration) Reset() {
+	*x = HTTPBlobAccessConfiguration{}
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HTTPBlobAccessConfiguration) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HTTPBlobAccessConfiguration) ProtoMessage() {}
+
+func (x *HTTPBlobAccessConfiguration) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HTTPBlobAccessConfiguration.ProtoReflect.Descriptor instead.
+func (*HTTPBlobAccessConfiguration) Descriptor() ([]byte, []int) {
+	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *HTTPBlobAccessConfiguration) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *HTTPBlobAccessConfiguration) GetHttpClient() *client.Configuration {
+	if x != nil {
+		return x.HttpClient
+	}
+	return nil
+}
+
+func (x *HTTPBlobAccessConfiguration) GetFindMissingConcurrency() int32 {
+	if x != nil {
+		return x.FindMissingConcurrency
+	}
+	return 0
+}
+
+type BoltBlobAccessConfiguration struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Path of the database file. The file is created if it does not
+	// exist.
+	Path string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	// The maximum size of objects that may be stored.
+	MaximumObjectSizeBytes int64 `protobuf:"varint,2,opt,name=maximum_object_size_bytes,json=maximumObjectSizeBytes,proto3" json:"maximum_object_size_bytes,omitempty"`
+	// Optional: the amount of time after which objects expire, measured
+	// from the time they were inserted. If unset, objects never expire.
+	//
+	// Expiration times are computed in the same way as the
+	// 'action_result_expiring' backend, except that the insertion time
+	// of the object is used instead of the ActionResult's
+	// 'worker_completed_timestamp'.
+	MinimumValidity *durationpb.Duration `protobuf:"bytes,3,opt,name=minimum_validity,json=minimumValidity,proto3" json:"minimum_validity,omitempty"`
+	// Optional: the maximum amount of jitter to add to the expiration
+	// time of objects.
+	MaximumValidityJitter *durationpb.Duration `protobuf:"bytes,4,opt,name=maximum_validity_jitter,json=maximumValidityJitter,proto3" json:"maximum_validity_jitter,omitempty"`
+	// Optional: the interval at which expired objects are removed from
+	// the database. Afterwards, the database file is compacted if at
+	// least half of it consists of free space. If unset, expired objects
+	// are not removed, and the database file never shrinks.
+	//
+	// Compaction copies all objects into a new database file. Reads and
+	// writes continue to be processed while objects are copied, though
+	// the database file may temporarily grow, as pages freed during the
+	// copy cannot be reused. Writes performed during the copy are
+	// replayed against the new database file, during which writes are
+	// blocked. Reads are only blocked while the existing database file
+	// is replaced by the new one. As the duration of the copy is proportional to the
+	// size of the database, this interval should be large compared to
+	// the time it takes to copy the database, e.g. at least an hour.
+	MaintenanceInterval *durationpb.Duration `protobuf:"bytes,5,opt,name=maintenance_interval,json=maintenanceInterval,proto3" json:"maintenance_interval,omitempty"`
+	// Optional: path at which consistent snapshots of the database are
+	// written, for the purpose of creating backups. Each snapshot is a
+	// database file that may be restored by placing it at 'path'.
+	SnapshotPath string `protobuf:"bytes,6,opt,name=snapshot_path,json=snapshotPath,proto3" json:"snapshot_path,omitempty"`
+	// The interval at which snapshots are written. This option is
+	// required if 'snapshot_path' is set.
+	SnapshotInterval *durationpb.Duration `protobuf:"bytes,7,opt,name=snapshot_interval,json=snapshotInterval,proto3" json:"snapshot_interval,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *BoltBlobAccessConfiguration) Reset() {
+	*x = BoltBlobAccessConfiguration{}
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BoltBlobAccessConfiguration) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BoltBlobAccessConfiguration) ProtoMessage() {}
+
+func (x *BoltBlobAccessConfiguration) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BoltBlobAccessConfiguration.ProtoReflect.Descriptor instead.
+func (*BoltBlobAccessConfiguration) Descriptor() ([]byte, []int) {
+	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *BoltBlobAccessConfiguration) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *BoltBlobAccessConfiguration) GetMaximumObjectSizeBytes() int64 {
+	if x != nil {
+		return x.MaximumObjectSizeBytes
+	}
+	return 0
+}
+
+func (x *BoltBlobAccessConfiguration) GetMinimumValidity() *durationpb.Duration {
+	if x != nil {
+		return x.MinimumValidity
+	}
+	return nil
+}
+
+func (x *BoltBlobAccessConfiguration) GetMaximumValidityJitter() *durationpb.Duration {
+	if x != nil {
+		return x.MaximumValidityJitter
+	}
+	return nil
+}
+
+func (x *BoltBlobAccessConfiguration) GetMaintenanceInterval() *durationpb.Duration {
+	if x != nil {
+		return x.MaintenanceInterval
+	}
+	return nil
+}
+
+func (x *BoltBlobAccessConfiguration) GetSnapshotPath() string {
+	if x != nil {
+		return x.SnapshotPath
+	}
+	return ""
+}
+
+func (x *BoltBlobAccessConfiguration) GetSnapshotInterval() *durationpb.Duration {
 	if x != nil {
-		return x.MinimumTimestamp
+		return x.SnapshotInterval
 	}
 	return nil
 }
 
-type ReadCanaryingBlobAccessConfiguration struct {
-	state                protoimpl.MessageState   `protogen:"open.v1"`
-	Source               *BlobAccessConfiguration `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`
-	Replica              *BlobAccessConfiguration `protobuf:"bytes,2,opt,name=replica,proto3" json:"replica,omitempty"`
-	MaximumCacheSize     int32                    `protobuf:"varint,3,opt,name=maximum_cache_size,json=maximumCacheSize,proto3" json:"maximum_cache_size,omitempty"`
-	MaximumCacheDuration *durationpb.Duration     `protobuf:"bytes,4,opt,name=maximum_cache_duration,json=maximumCacheDuration,proto3" json:"maximum_cache_duration,omitempty"`
-	unknownFields        protoimpl.UnknownFields
-	sizeCache            protoimpl.SizeCache
-}
-
-func (x *ReadCanaryingBlobAccessConfiguration) Reset() {
-	*x = ReadCanaryingBlobAccessConfiguration{}
-	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[16]
+type OCIBlobAccessConfiguration struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// URL of the registry, without a trailing slash (e.g.,
+	// "https://registry.example.com").
+	Address string `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	// Name of the repository in which blobs should be stored (e.g.,
+	// "buildbarn/cas").
+	Repository string `protobuf:"bytes,2,opt,name=repository,proto3" json:"repository,omitempty"`
+	// Options of the HTTP client that is used to communicate with the
+	// registry, such as authentication and additional headers to set.
+	HttpClient *client.Configuration `protobuf:"bytes,3,opt,name=http_client,json=httpClient,proto3" json:"http_client,omitempty"`
+	// The maximum size of chunks to upload. Objects that are larger
+	// than this size are uploaded using chunked uploads.
+	ChunkSizeBytes int64 `protobuf:"varint,4,opt,name=chunk_size_bytes,json=chunkSizeBytes,proto3" json:"chunk_size_bytes,omitempty"`
+	// The maximum number of HEAD requests to issue in parallel when
+	// checking for the existence of objects.
+	FindMissingConcurrency int32 `protobuf:"varint,5,opt,name=find_missing_concurrency,json=findMissingConcurrency,proto3" json:"find_missing_concurrency,omitempty"`
+	// Optional: The maximum number of slices of objects to track, as
+	// reported by GetFromComposite(). This permits child objects, such
+	// as Directory objects contained in Tree objects, to be read using
+	// ranged reads, as opposed to downloading the parent object in its
+	// entirety. If zero, parent objects are always downloaded.
+	SliceCacheSize int64 `protobuf:"varint,6,opt,name=slice_cache_size,json=sliceCacheSize,proto3" json:"slice_cache_size,omitempty"`
+	// The cache replacement policy that should be applied to the slice
+	// cache. It is advised that this is set to LEAST_RECENTLY_USED.
+	SliceCacheReplacementPolicy eviction.CacheReplacementPolicy `protobuf:"varint,7,opt,name=slice_cache_replacement_policy,json=sliceCacheReplacementPolicy,proto3,enum=buildbarn.configuration.eviction.CacheReplacementPolicy" json:"slice_cache_replacement_policy,omitempty"`
+	unknownFields               protoimpl.UnknownFields
+	sizeCache                   protoimpl.SizeCache
+}
+
+func (x *OCIBlobAccessConfiguration) Reset() {
+	*x = OCIBlobAccessConfiguration{}
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[32]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ReadCanaryingBlobAccessConfiguration) String() string {
+func (x *OCIBlobAccessConfiguration) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ReadCanaryingBlobAccessConfiguration) ProtoMessage() {}
+func (*OCIBlobAccessConfiguration) ProtoMessage() {}
 
-func (x *ReadCanaryingBlobAccessConfiguration) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[16]
+func (x *OCIBlobAccessConfiguration) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[32]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1493,62 +3942,118 @@ func (x *ReadCanaryingBlobAccessConfiguration) ProtoReflect() protoreflect.Messa
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ReadCanaryingBlobAccessConfiguration.ProtoReflect.Descriptor instead.
-func (*ReadCanaryingBlobAccessConfiguration) Descriptor() ([]byte, []int) {
-	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{16}
+// Deprecated: Use OCIBlobAccessConfiguration.ProtoReflect.Descriptor instead.
+func (*OCIBlobAccessConfiguration) Descriptor() ([]byte, []int) {
+	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{32}
 }
 
-func (x *ReadCanaryingBlobAccessConfiguration) GetSource() *BlobAccessConfiguration {
+func (x *OCIBlobAccessConfiguration) GetAddress() string {
 	if x != nil {
-		return x.Source
+		return x.Address
 	}
-	return nil
+	return ""
 }
 
-func (x *ReadCanaryingBlobAccessConfiguration) GetReplica() *BlobAccessConfiguration {
+func (x *OCIBlobAccessConfiguration) GetRepository() string {
 	if x != nil {
-		return x.Replica
+		return x.Repository
+	}
+	return ""
+}
+
+func (x *OCIBlobAccessConfiguration) GetHttpClient() *client.Configuration {
+	if x != nil {
+		return x.HttpClient
 	}
 	return nil
 }
 
-func (x *ReadCanaryingBlobAccessConfiguration) GetMaximumCacheSize() int32 {
+func (x *OCIBlobAccessConfiguration) GetChunkSizeBytes() int64 {
 	if x != nil {
-		return x.MaximumCacheSize
+		return x.ChunkSizeBytes
 	}
 	return 0
 }
 
-func (x *ReadCanaryingBlobAccessConfiguration) GetMaximumCacheDuration() *durationpb.Duration {
+func (x *OCIBlobAccessConfiguration) GetFindMissingConcurrency() int32 {
 	if x != nil {
-		return x.MaximumCacheDuration
+		return x.FindMissingConcurrency
 	}
-	return nil
+	return 0
 }
 
-type ZIPBlobAccessConfiguration struct {
-	state                        protoimpl.MessageState              `protogen:"open.v1"`
-	Path                         string                              `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
-	DataIntegrityValidationCache *digest.ExistenceCacheConfiguration `protobuf:"bytes,2,opt,name=data_integrity_validation_cache,json=dataIntegrityValidationCache,proto3" json:"data_integrity_validation_cache,omitempty"`
-	unknownFields                protoimpl.UnknownFields
-	sizeCache                    protoimpl.SizeCache
+func (x *OCIBlobAccessConfiguration) GetSliceCacheSize() int64 {
+	if x != nil {
+		return x.SliceCacheSize
+	}
+	return 0
 }
 
-func (x *ZIPBlobAccessConfiguration) Reset() {
-	*x = ZIPBlobAccessConfiguration{}
-	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[17]
+func (x *OCIBlobAccessConfiguration) GetSliceCacheReplacementPolicy() eviction.CacheReplacementPolicy {
+	if x != nil {
+		return x.SliceCacheReplacementPolicy
+	}
+	return eviction.CacheReplacementPolicy(0)
+}
+
+type ErasureCodingBlobAccessConfiguration struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Backends in which data fragments are stored. The number of
+	// backends determines the number of data fragments into which
+	// objects are split.
+	//
+	// Fragments are stored under the digest of the original object,
+	// meaning that their contents don't match their digests. Backends
+	// that validate objects against their digests can therefore not be
+	// used. This includes 'grpc' and 'compressed_grpc', as remote
+	// servers validate the objects that are written to them. Each
+	// fragment backend must thus be a backend that stores data itself
+	// (e.g., 'local'), optionally combined with backends such as
+	// 'sharding' or 'mirrored'.
+	//
+	// The order of backends is significant. Changing it causes
+	// existing objects to become unreadable.
+	DataBackends []*BlobAccessConfiguration `protobuf:"bytes,1,rep,name=data_backends,json=dataBackends,proto3" json:"data_backends,omitempty"`
+	// Backends in which parity fragments are stored. The number of
+	// backends determines the number of backends whose loss can be
+	// tolerated.
+	//
+	// Fragments that are absent or corrupted are recomputed and
+	// written back to their backend when detected by Get() or
+	// FindMissing(). When a backend is replaced, redundancy is thus
+	// restored as clients continue to use the objects.
+	ParityBackends []*BlobAccessConfiguration `protobuf:"bytes,2,rep,name=parity_backends,json=parityBackends,proto3" json:"parity_backends,omitempty"`
+	// The maximum size of objects. As objects need to be split into
+	// fragments and reconstructed in memory, this limits the amount of
+	// memory used per request.
+	MaximumObjectSizeBytes int64 `protobuf:"varint,3,opt,name=maximum_object_size_bytes,json=maximumObjectSizeBytes,proto3" json:"maximum_object_size_bytes,omitempty"`
+	// Repairs of absent or corrupted fragments are not performed as
+	// part of the Get() or FindMissing() call that detected them.
+	// Instead, objects are placed in a queue that is processed in the
+	// background, one object at a time. This option controls the
+	// maximum number of objects in this queue. Repairs are discarded
+	// if the queue is full, and are retried the next time the object
+	// is accessed.
+	RepairQueueSize int32 `protobuf:"varint,4,opt,name=repair_queue_size,json=repairQueueSize,proto3" json:"repair_queue_size,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ErasureCodingBlobAccessConfiguration) Reset() {
+	*x = ErasureCodingBlobAccessConfiguration{}
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[33]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ZIPBlobAccessConfiguration) String() string {
+func (x *ErasureCodingBlobAccessConfiguration) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ZIPBlobAccessConfiguration) ProtoMessage() {}
+func (*ErasureCodingBlobAccessConfiguration) ProtoMessage() {}
 
-func (x *ZIPBlobAccessConfiguration) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[17]
+func (x *ErasureCodingBlobAccessConfiguration) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[33]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1559,48 +4064,65 @@ func (x *ZIPBlobAccessConfiguration) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ZIPBlobAccessConfiguration.ProtoReflect.Descriptor instead.
-func (*ZIPBlobAccessConfiguration) Descriptor() ([]byte, []int) {
-	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{17}
+// Deprecated: Use ErasureCodingBlobAccessConfiguration.ProtoReflect.Descriptor instead.
+func (*ErasureCodingBlobAccessConfiguration) Descriptor() ([]byte, []int) {
+	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{33}
 }
 
-func (x *ZIPBlobAccessConfiguration) GetPath() string {
+func (x *ErasureCodingBlobAccessConfiguration) GetDataBackends() []*BlobAccessConfiguration {
 	if x != nil {
-		return x.Path
+		return x.DataBackends
 	}
-	return ""
+	return nil
 }
 
-func (x *ZIPBlobAccessConfiguration) GetDataIntegrityValidationCache() *digest.ExistenceCacheConfiguration {
+func (x *ErasureCodingBlobAccessConfiguration) GetParityBackends() []*BlobAccessConfiguration {
 	if x != nil {
-		return x.DataIntegrityValidationCache
+		return x.ParityBackends
 	}
 	return nil
 }
 
-type WithLabelsBlobAccessConfiguration struct {
-	state         protoimpl.MessageState              `protogen:"open.v1"`
-	Backend       *BlobAccessConfiguration            `protobuf:"bytes,1,opt,name=backend,proto3" json:"backend,omitempty"`
-	Labels        map[string]*BlobAccessConfiguration `protobuf:"bytes,2,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+func (x *ErasureCodingBlobAccessConfiguration) GetMaximumObjectSizeBytes() int64 {
+	if x != nil {
+		return x.MaximumObjectSizeBytes
+	}
+	return 0
+}
+
+func (x *ErasureCodingBlobAccessConfiguration) GetRepairQueueSize() int32 {
+	if x != nil {
+		return x.RepairQueueSize
+	}
+	return 0
+}
+
+type CompressedGrpcBlobAccessConfiguration struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The gRPC service to which requests should be forwarded.
+	Client *grpc.ClientConfiguration `protobuf:"bytes,1,opt,name=client,proto3" json:"client,omitempty"`
+	// The compression algorithm to use when transferring blobs. Valid
+	// values include DEFLATE and ZSTD.
+	Compressor    v2.Compressor_Value `protobuf:"varint,2,opt,name=compressor,proto3,enum=build.bazel.remote.execution.v2.Compressor_Value" json:"compressor,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *WithLabelsBlobAccessConfiguration) Reset() {
-	*x = WithLabelsBlobAccessConfiguration{}
-	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[18]
+func (x *CompressedGrpcBlobAccessConfiguration) Reset() {
+	*x = CompressedGrpcBlobAccessConfiguration{}
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[34]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *WithLabelsBlobAccessConfiguration) String() string {
+func (x *CompressedGrpcBlobAccessConfiguration) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*WithLabelsBlobAccessConfiguration) ProtoMessage() {}
+func (*CompressedGrpcBlobAccessConfiguration) ProtoMessage() {}
 
-func (x *WithLabelsBlobAccessConfiguration) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[18]
+func (x *CompressedGrpcBlobAccessConfiguration) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[34]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1611,48 +4133,77 @@ func (x *WithLabelsBlobAccessConfiguration) ProtoReflect() protoreflect.Message
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use WithLabelsBlobAccessConfiguration.ProtoReflect.Descriptor instead.
-func (*WithLabelsBlobAccessConfiguration) Descriptor() ([]byte, []int) {
-	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{18}
+// Deprecated: Use CompressedGrpcBlobAccessConfiguration.ProtoReflect.Descriptor instead.
+func (*CompressedGrpcBlobAccessConfiguration) Descriptor() ([]byte, []int) {
+	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{34}
 }
 
-func (x *WithLabelsBlobAccessConfiguration) GetBackend() *BlobAccessConfiguration {
+func (x *CompressedGrpcBlobAccessConfiguration) GetClient() *grpc.ClientConfiguration {
 	if x != nil {
-		return x.Backend
+		return x.Client
 	}
 	return nil
 }
 
-func (x *WithLabelsBlobAccessConfiguration) GetLabels() map[string]*BlobAccessConfiguration {
+func (x *CompressedGrpcBlobAccessConfiguration) GetCompressor() v2.Compressor_Value {
 	if x != nil {
-		return x.Labels
+		return x.Compressor
 	}
-	return nil
-}
-
-type DeadlineEnforcingBlobAccess struct {
-	state         protoimpl.MessageState   `protogen:"open.v1"`
-	Timeout       *durationpb.Duration     `protobuf:"bytes,1,opt,name=timeout,proto3" json:"timeout,omitempty"`
-	Backend       *BlobAccessConfiguration `protobuf:"bytes,2,opt,name=backend,proto3" json:"backend,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
-}
-
-func (x *DeadlineEnforcingBlobAccess) Reset() {
-	*x = DeadlineEnforcingBlobAccess{}
-	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[19]
+	return v2.Compressor_Value(0)
+}
+
+// Configuration for decomposing large blobs stored in the Content
+// Addressable Storage into smaller chunks using content-defined
+// chunking. This is used to implement the REv2 SplitBlob() and
+// SpliceBlob() operations.
+//
+// Chunk boundaries are computed using the FastCDC algorithm. Clients
+// that want to benefit from chunks created by the server should use
+// identical parameters.
+type ContentDefinedChunkingConfiguration struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The minimum size of a chunk. Blobs that are smaller than this size
+	// are not decomposed.
+	MinimumSizeBytes int64 `protobuf:"varint,1,opt,name=minimum_size_bytes,json=minimumSizeBytes,proto3" json:"minimum_size_bytes,omitempty"`
+	// The desired average size of a chunk. This value must be a power of
+	// two.
+	AverageSizeBytes int64 `protobuf:"varint,2,opt,name=average_size_bytes,json=averageSizeBytes,proto3" json:"average_size_bytes,omitempty"`
+	// The maximum size of a chunk. Chunk data is buffered in memory, so
+	// this value should not be set too high. It must not exceed the
+	// maximum message size, as clients may want to download chunks
+	// through ContentAddressableStorage.BatchReadBlobs().
+	MaximumSizeBytes int64 `protobuf:"varint,3,opt,name=maximum_size_bytes,json=maximumSizeBytes,proto3" json:"maximum_size_bytes,omitempty"`
+	// Storage backend in which chunk manifests are stored, keyed by the
+	// digest of the blob that was decomposed. Manifests allow repeated
+	// calls to SplitBlob() to complete without reading and chunking the
+	// blob again, and allow chunks to be reused after SpliceBlob().
+	//
+	// Manifests are stored in the form of REv2 SplitBlobResponse
+	// messages. As there is no gRPC service for accessing them, sharing
+	// manifests between instances of bb_storage requires the use of
+	// backends such as 'redis' or 's3'. When the same backend is also
+	// used to store other kinds of data, a key prefix needs to be
+	// configured to prevent collisions.
+	ManifestStorage *BlobAccessConfiguration `protobuf:"bytes,6,opt,name=manifest_storage,json=manifestStorage,proto3" json:"manifest_storage,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ContentDefinedChunkingConfiguration) Reset() {
+	*x = ContentDefinedChunkingConfiguration{}
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[35]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DeadlineEnforcingBlobAccess) String() string {
+func (x *ContentDefinedChunkingConfiguration) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DeadlineEnforcingBlobAccess) ProtoMessage() {}
+func (*ContentDefinedChunkingConfiguration) ProtoMessage() {}
 
-func (x *DeadlineEnforcingBlobAccess) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[19]
+func (x *ContentDefinedChunkingConfiguration) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[35]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1663,36 +4214,61 @@ func (x *DeadlineEnforcingBlobAccess) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DeadlineEnforcingBlobAccess.ProtoReflect.Descriptor instead.
-func (*DeadlineEnforcingBlobAccess) Descriptor() ([]byte, []int) {
-	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{19}
+// Deprecated: Use ContentDefinedChunkingConfiguration.ProtoReflect.Descriptor instead.
+func (*ContentDefinedChunkingConfiguration) Descriptor() ([]byte, []int) {
+	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{35}
 }
 
-func (x *DeadlineEnforcingBlobAccess) GetTimeout() *durationpb.Duration {
+func (x *ContentDefinedChunkingConfiguration) GetMinimumSizeBytes() int64 {
 	if x != nil {
-		return x.Timeout
+		return x.MinimumSizeBytes
 	}
-	return nil
+	return 0
 }
 
-func (x *DeadlineEnforcingBlobAccess) GetBackend() *BlobAccessConfiguration {
+func (x *ContentDefinedChunkingConfiguration) GetAverageSizeBytes() int64 {
 	if x != nil {
-		return x.Backend
+		return x.AverageSizeBytes
+	}
+	return 0
+}
+
+func (x *ContentDefinedChunkingConfiguration) GetMaximumSizeBytes() int64 {
+	if x != nil {
+		return x.MaximumSizeBytes
+	}
+	return 0
+}
+
+func (x *ContentDefinedChunkingConfiguration) GetManifestStorage() *BlobAccessConfiguration {
+	if x != nil {
+		return x.ManifestStorage
 	}
 	return nil
 }
 
 type ShardingBlobAccessConfiguration_Shard struct {
-	state         protoimpl.MessageState   `protogen:"open.v1"`
-	Backend       *BlobAccessConfiguration `protobuf:"bytes,1,opt,name=backend,proto3" json:"backend,omitempty"`
-	Weight        uint32                   `protobuf:"varint,2,opt,name=weight,proto3" json:"weight,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Storage backend that is used by this shard. Omitting this
+	// causes the implementation to assume this shard is drained.
+	// Requests to this shard will be spread out across the other
+	// shards.
+	Backend *BlobAccessConfiguration `protobuf:"bytes,1,opt,name=backend,proto3" json:"backend,omitempty"`
+	// Non-zero ratio of how many keys are allocated to this shard.
+	// When all shards have equal specifications (i.e., capacity and
+	// bandwidth), every shard may have a weight of one.
+	//
+	// For the backend selection algorithm to run quickly, it is not
+	// not advised to let the total weight of drained backends
+	// strongly exceed the total weight of undrained ones.
+	Weight        uint32 `protobuf:"varint,2,opt,name=weight,proto3" json:"weight,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ShardingBlobAccessConfiguration_Shard) Reset() {
 	*x = ShardingBlobAccessConfiguration_Shard{}
-	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[20]
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[36]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1704,7 +4280,7 @@ func (x *ShardingBlobAccessConfiguration_Shard) String() string {
 func (*ShardingBlobAccessConfiguration_Shard) ProtoMessage() {}
 
 func (x *ShardingBlobAccessConfiguration_Shard) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[20]
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[36]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1735,16 +4311,19 @@ func (x *ShardingBlobAccessConfiguration_Shard) GetWeight() uint32 {
 }
 
 type ShardingBlobAccessConfiguration_Legacy struct {
-	state              protoimpl.MessageState `protogen:"open.v1"`
-	ShardOrder         []string               `protobuf:"bytes,1,rep,name=shard_order,json=shardOrder,proto3" json:"shard_order,omitempty"`
-	HashInitialization uint64                 `protobuf:"varint,2,opt,name=hash_initialization,json=hashInitialization,proto3" json:"hash_initialization,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Order of the shards for the legacy schema. Each key here refers to
+	// a corresponding key in the 'shard_map' or null for drained backends.
+	ShardOrder []string `protobuf:"bytes,1,rep,name=shard_order,json=shardOrder,proto3" json:"shard_order,omitempty"`
+	// Hash initialization seed used for legacy schema.
+	HashInitialization uint64 `protobuf:"varint,2,opt,name=hash_initialization,json=hashInitialization,proto3" json:"hash_initialization,omitempty"`
 	unknownFields      protoimpl.UnknownFields
 	sizeCache          protoimpl.SizeCache
 }
 
 func (x *ShardingBlobAccessConfiguration_Legacy) Reset() {
 	*x = ShardingBlobAccessConfiguration_Legacy{}
-	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[21]
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[37]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1756,7 +4335,7 @@ func (x *ShardingBlobAccessConfiguration_Legacy) String() string {
 func (*ShardingBlobAccessConfiguration_Legacy) ProtoMessage() {}
 
 func (x *ShardingBlobAccessConfiguration_Legacy) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[21]
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[37]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1786,16 +4365,81 @@ func (x *ShardingBlobAccessConfiguration_Legacy) GetHashInitialization() uint64
 	return 0
 }
 
+type ReplicatedBlobAccessConfiguration_Replica struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The backend in which copies of objects are stored.
+	Backend *BlobAccessConfiguration `protobuf:"bytes,1,opt,name=backend,proto3" json:"backend,omitempty"`
+	// The replication strategy that should be used to copy objects
+	// from other replicas into this replica in case of
+	// inconsistencies. Objects are read from the other replicas in
+	// order, starting with the replica following this one.
+	Replicator    *BlobReplicatorConfiguration `protobuf:"bytes,2,opt,name=replicator,proto3" json:"replicator,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReplicatedBlobAccessConfiguration_Replica) Reset() {
+	*x = ReplicatedBlobAccessConfiguration_Replica{}
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplicatedBlobAccessConfiguration_Replica) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplicatedBlobAccessConfiguration_Replica) ProtoMessage() {}
+
+func (x *ReplicatedBlobAccessConfiguration_Replica) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplicatedBlobAccessConfiguration_Replica.ProtoReflect.Descriptor instead.
+func (*ReplicatedBlobAccessConfiguration_Replica) Descriptor() ([]byte, []int) {
+	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{5, 0}
+}
+
+func (x *ReplicatedBlobAccessConfiguration_Replica) GetBackend() *BlobAccessConfiguration {
+	if x != nil {
+		return x.Backend
+	}
+	return nil
+}
+
+func (x *ReplicatedBlobAccessConfiguration_Replica) GetReplicator() *BlobReplicatorConfiguration {
+	if x != nil {
+		return x.Replicator
+	}
+	return nil
+}
+
 type LocalBlobAccessConfiguration_KeyLocationMapInMemory struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Entries       int64                  `protobuf:"varint,1,opt,name=entries,proto3" json:"entries,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The key-location map is a hash table that is used by this storage
+	// backend to resolve digests to locations where data is stored.
+	// This option determines the size of this hash table. Because
+	// entries are small (about 64 bytes in size), it is recommended to
+	// make this map relatively large to reduce collisions.
+	//
+	// Recommended value: between 2 and 10 times the expected number of
+	// objects stored.
+	Entries       int64 `protobuf:"varint,1,opt,name=entries,proto3" json:"entries,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *LocalBlobAccessConfiguration_KeyLocationMapInMemory) Reset() {
 	*x = LocalBlobAccessConfiguration_KeyLocationMapInMemory{}
-	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[23]
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[40]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1807,7 +4451,7 @@ func (x *LocalBlobAccessConfiguration_KeyLocationMapInMemory) String() string {
 func (*LocalBlobAccessConfiguration_KeyLocationMapInMemory) ProtoMessage() {}
 
 func (x *LocalBlobAccessConfiguration_KeyLocationMapInMemory) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[23]
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[40]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1820,7 +4464,7 @@ func (x *LocalBlobAccessConfiguration_KeyLocationMapInMemory) ProtoReflect() pro
 
 // Deprecated: Use LocalBlobAccessConfiguration_KeyLocationMapInMemory.ProtoReflect.Descriptor instead.
 func (*LocalBlobAccessConfiguration_KeyLocationMapInMemory) Descriptor() ([]byte, []int) {
-	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{5, 0}
+	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{8, 0}
 }
 
 func (x *LocalBlobAccessConfiguration_KeyLocationMapInMemory) GetEntries() int64 {
@@ -1831,15 +4475,22 @@ func (x *LocalBlobAccessConfiguration_KeyLocationMapInMemory) GetEntries() int64
 }
 
 type LocalBlobAccessConfiguration_BlocksInMemory struct {
-	state          protoimpl.MessageState `protogen:"open.v1"`
-	BlockSizeBytes int64                  `protobuf:"varint,1,opt,name=block_size_bytes,json=blockSizeBytes,proto3" json:"block_size_bytes,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Data is stored in a list of blocks. The total number of blocks
+	// constant over time, with small fluctuations to deal with lingering
+	// requests when removing a block. This option sets the size of an
+	// individual block.
+	//
+	// Recommended value: (total space available) /
+	//                    (old_blocks + current_blocks + new_blocks)
+	BlockSizeBytes int64 `protobuf:"varint,1,opt,name=block_size_bytes,json=blockSizeBytes,proto3" json:"block_size_bytes,omitempty"`
 	unknownFields  protoimpl.UnknownFields
 	sizeCache      protoimpl.SizeCache
 }
 
 func (x *LocalBlobAccessConfiguration_BlocksInMemory) Reset() {
 	*x = LocalBlobAccessConfiguration_BlocksInMemory{}
-	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[24]
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[41]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1851,7 +4502,7 @@ func (x *LocalBlobAccessConfiguration_BlocksInMemory) String() string {
 func (*LocalBlobAccessConfiguration_BlocksInMemory) ProtoMessage() {}
 
 func (x *LocalBlobAccessConfiguration_BlocksInMemory) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[24]
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[41]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1864,7 +4515,7 @@ func (x *LocalBlobAccessConfiguration_BlocksInMemory) ProtoReflect() protoreflec
 
 // Deprecated: Use LocalBlobAccessConfiguration_BlocksInMemory.ProtoReflect.Descriptor instead.
 func (*LocalBlobAccessConfiguration_BlocksInMemory) Descriptor() ([]byte, []int) {
-	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{5, 1}
+	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{8, 1}
 }
 
 func (x *LocalBlobAccessConfiguration_BlocksInMemory) GetBlockSizeBytes() int64 {
@@ -1875,9 +4526,31 @@ func (x *LocalBlobAccessConfiguration_BlocksInMemory) GetBlockSizeBytes() int64
 }
 
 type LocalBlobAccessConfiguration_BlocksOnBlockDevice struct {
-	state                        protoimpl.MessageState              `protogen:"open.v1"`
-	Source                       *blockdevice.Configuration          `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`
-	SpareBlocks                  int32                               `protobuf:"varint,2,opt,name=spare_blocks,json=spareBlocks,proto3" json:"spare_blocks,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The block device where data needs to be stored.
+	Source *blockdevice.Configuration `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`
+	// To deal with lingering read requests, a small number of old
+	// blocks may need to be retained for a short period of time before
+	// being recycled to store new data. This option determines how many
+	// of such lingering blocks are allocated.
+	//
+	// Unlike in-memory storage, where the block size is configured
+	// explicitly, block device backed storage automatically infers an
+	// optimal block size. The block size is equal to:
+	//
+	// block_size = (size of block device) /
+	//              (spare_blocks + old_blocks + current_blocks + new_blocks)
+	//
+	// Recommended value: 3
+	SpareBlocks int32 `protobuf:"varint,2,opt,name=spare_blocks,json=spareBlocks,proto3" json:"spare_blocks,omitempty"`
+	// When set, temporarily cache the integrity of data after it's been
+	// read from the block device. This is a requirement for being able
+	// to randomly access objects quickly.
+	//
+	// The disadvantage of enabling this option is that data corruption
+	// on the block device may not be detected. It is therefore
+	// recommended to set the cache duration to a limited value (e.g.,
+	// "4h").
 	DataIntegrityValidationCache *digest.ExistenceCacheConfiguration `protobuf:"bytes,3,opt,name=data_integrity_validation_cache,json=dataIntegrityValidationCache,proto3" json:"data_integrity_validation_cache,omitempty"`
 	unknownFields                protoimpl.UnknownFields
 	sizeCache                    protoimpl.SizeCache
@@ -1885,7 +4558,7 @@ type LocalBlobAccessConfiguration_BlocksOnBlockDevice struct {
 
 func (x *LocalBlobAccessConfiguration_BlocksOnBlockDevice) Reset() {
 	*x = LocalBlobAccessConfiguration_BlocksOnBlockDevice{}
-	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[25]
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[42]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1897,7 +4570,7 @@ func (x *LocalBlobAccessConfiguration_BlocksOnBlockDevice) String() string {
 func (*LocalBlobAccessConfiguration_BlocksOnBlockDevice) ProtoMessage() {}
 
 func (x *LocalBlobAccessConfiguration_BlocksOnBlockDevice) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[25]
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[42]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1910,7 +4583,7 @@ func (x *LocalBlobAccessConfiguration_BlocksOnBlockDevice) ProtoReflect() protor
 
 // Deprecated: Use LocalBlobAccessConfiguration_BlocksOnBlockDevice.ProtoReflect.Descriptor instead.
 func (*LocalBlobAccessConfiguration_BlocksOnBlockDevice) Descriptor() ([]byte, []int) {
-	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{5, 2}
+	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{8, 2}
 }
 
 func (x *LocalBlobAccessConfiguration_BlocksOnBlockDevice) GetSource() *blockdevice.Configuration {
@@ -1935,16 +4608,44 @@ func (x *LocalBlobAccessConfiguration_BlocksOnBlockDevice) GetDataIntegrityValid
 }
 
 type LocalBlobAccessConfiguration_Persistent struct {
-	state                protoimpl.MessageState `protogen:"open.v1"`
-	StateDirectoryPath   string                 `protobuf:"bytes,1,opt,name=state_directory_path,json=stateDirectoryPath,proto3" json:"state_directory_path,omitempty"`
-	MinimumEpochInterval *durationpb.Duration   `protobuf:"bytes,2,opt,name=minimum_epoch_interval,json=minimumEpochInterval,proto3" json:"minimum_epoch_interval,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Path to a directory on disk where metadata can be stored to be
+	// able to persist. This metadata needs to be reloaded on startup to
+	// be able to access previous data.
+	//
+	// This directory will hold a single file named "state", containing
+	// a Protobuf message of type
+	// buildbarn.blobstore.local.PersistentState. It is not recommended
+	// to use this directory for any purpose other than storing the
+	// persistent state file, as fsync() is called on it regularly.
+	StateDirectoryPath string `protobuf:"bytes,1,opt,name=state_directory_path,json=stateDirectoryPath,proto3" json:"state_directory_path,omitempty"`
+	// The amount of time between fsync() calls against the block device
+	// used to store blocks of data. Setting this option to a lower
+	// value reduces the amount of data that may get lost across
+	// restarts.
+	//
+	// This option acts as a lower bound on the amount of time between
+	// fsync() calls. No calls to fsync() are made if the system is
+	// idle, nor are multiple calls performed in parallel in case they
+	// take longer to complete than the configured interval.
+	//
+	// Care should be taken that this value is not set too low. Every
+	// epoch that still references valid data consumes 16 bytes of
+	// memory and increases the size of the state file by a similar
+	// amount. This means that if this option is set to '300s', epoch
+	// bookkeeping consumes up to 12*24*365*16 B = ~1.68 MB of space if
+	// the system were to operate for a full year without blocks being
+	// released. Setting this to '1s' blows this up by a factor 300.
+	//
+	// Recommended value: '300s'
+	MinimumEpochInterval *durationpb.Duration `protobuf:"bytes,2,opt,name=minimum_epoch_interval,json=minimumEpochInterval,proto3" json:"minimum_epoch_interval,omitempty"`
 	unknownFields        protoimpl.UnknownFields
 	sizeCache            protoimpl.SizeCache
 }
 
 func (x *LocalBlobAccessConfiguration_Persistent) Reset() {
 	*x = LocalBlobAccessConfiguration_Persistent{}
-	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[26]
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[43]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1956,7 +4657,7 @@ func (x *LocalBlobAccessConfiguration_Persistent) String() string {
 func (*LocalBlobAccessConfiguration_Persistent) ProtoMessage() {}
 
 func (x *LocalBlobAccessConfiguration_Persistent) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[26]
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[43]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1969,7 +4670,7 @@ func (x *LocalBlobAccessConfiguration_Persistent) ProtoReflect() protoreflect.Me
 
 // Deprecated: Use LocalBlobAccessConfiguration_Persistent.ProtoReflect.Descriptor instead.
 func (*LocalBlobAccessConfiguration_Persistent) Descriptor() ([]byte, []int) {
-	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{5, 3}
+	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{8, 3}
 }
 
 func (x *LocalBlobAccessConfiguration_Persistent) GetStateDirectoryPath() string {
@@ -1986,14 +4687,90 @@ func (x *LocalBlobAccessConfiguration_Persistent) GetMinimumEpochInterval() *dur
 	return nil
 }
 
+type RateLimitingBlobAccessConfiguration_Limit struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The number of requests per second that may be performed. If
+	// zero, the number of requests is not limited.
+	RequestsPerSecond float64 `protobuf:"fixed64,1,opt,name=requests_per_second,json=requestsPerSecond,proto3" json:"requests_per_second,omitempty"`
+	// The number of requests that may be performed in a burst.
+	RequestBurst uint32 `protobuf:"varint,2,opt,name=request_burst,json=requestBurst,proto3" json:"request_burst,omitempty"`
+	// The number of bytes per second that may be transferred, based
+	// on the size of objects. If zero, the number of bytes is not
+	// limited.
+	BytesPerSecond float64 `protobuf:"fixed64,3,opt,name=bytes_per_second,json=bytesPerSecond,proto3" json:"bytes_per_second,omitempty"`
+	// The number of bytes that may be transferred in a burst. Objects
+	// larger than the burst size are permitted if the bucket is full.
+	ByteBurst     uint64 `protobuf:"varint,4,opt,name=byte_burst,json=byteBurst,proto3" json:"byte_burst,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RateLimitingBlobAccessConfiguration_Limit) Reset() {
+	*x = RateLimitingBlobAccessConfiguration_Limit{}
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[46]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RateLimitingBlobAccessConfiguration_Limit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RateLimitingBlobAccessConfiguration_Limit) ProtoMessage() {}
+
+func (x *RateLimitingBlobAccessConfiguration_Limit) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[46]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RateLimitingBlobAccessConfiguration_Limit.ProtoReflect.Descriptor instead.
+func (*RateLimitingBlobAccessConfiguration_Limit) Descriptor() ([]byte, []int) {
+	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescGZIP(), []int{24, 0}
+}
+
+func (x *RateLimitingBlobAccessConfiguration_Limit) GetRequestsPerSecond() float64 {
+	if x != nil {
+		return x.RequestsPerSecond
+	}
+	return 0
+}
+
+func (x *RateLimitingBlobAccessConfiguration_Limit) GetRequestBurst() uint32 {
+	if x != nil {
+		return x.RequestBurst
+	}
+	return 0
+}
+
+func (x *RateLimitingBlobAccessConfiguration_Limit) GetBytesPerSecond() float64 {
+	if x != nil {
+		return x.BytesPerSecond
+	}
+	return 0
+}
+
+func (x *RateLimitingBlobAccessConfiguration_Limit) GetByteBurst() uint64 {
+	if x != nil {
+		return x.ByteBurst
+	}
+	return 0
+}
+
 var File_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto protoreflect.FileDescriptor
 
 const file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDesc = "" +
 	"\n" +
-	"Qgithub.com/buildbarn/bb-storage/pkg/proto/configuration/blobstore/blobstore.proto\x12!buildbarn.configuration.blobstore\x1aUgithub.com/buildbarn/bb-storage/pkg/proto/configuration/blockdevice/blockdevice.proto\x1aKgithub.com/buildbarn/bb-storage/pkg/proto/configuration/cloud/aws/aws.proto\x1aKgithub.com/buildbarn/bb-storage/pkg/proto/configuration/cloud/gcp/gcp.proto\x1aKgithub.com/buildbarn/bb-storage/pkg/proto/configuration/digest/digest.proto\x1aGgithub.com/buildbarn/bb-storage/pkg/proto/configuration/grpc/grpc.proto\x1aPgithub.com/buildbarn/bb-storage/pkg/proto/configuration/http/client/client.proto\x1a\x1egoogle/protobuf/duration.proto\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x17google/rpc/status.proto\"\xf3\x01\n" +
+	"Qgithub.com/buildbarn/bb-storage/pkg/proto/configuration/blobstore/blobstore.proto\x12!buildbarn.configuration.blobstore\x1a6build/bazel/remote/execution/v2/remote_execution.proto\x1aUgithub.com/buildbarn/bb-storage/pkg/proto/configuration/blockdevice/blockdevice.proto\x1aKgithub.com/buildbarn/bb-storage/pkg/proto/configuration/cloud/aws/aws.proto\x1aKgithub.com/buildbarn/bb-storage/pkg/proto/configuration/cloud/gcp/gcp.proto\x1aKgithub.com/buildbarn/bb-storage/pkg/proto/configuration/digest/digest.proto\x1aOgithub.com/buildbarn/bb-storage/pkg/proto/configuration/eviction/eviction.proto\x1aGgithub.com/buildbarn/bb-storage/pkg/proto/configuration/grpc/grpc.proto\x1aPgithub.com/buildbarn/bb-storage/pkg/proto/configuration/http/client/client.proto\x1aOgithub.com/buildbarn/bb-storage/pkg/proto/configuration/jmespath/jmespath.proto\x1aEgithub.com/buildbarn/bb-storage/pkg/proto/configuration/tls/tls.proto\x1a\x1egoogle/protobuf/duration.proto\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x17google/rpc/status.proto\"\xf3\x01\n" +
 	"\x16BlobstoreConfiguration\x12z\n" +
 	"\x1bcontent_addressable_storage\x18\x01 \x01(\v2:.buildbarn.configuration.blobstore.BlobAccessConfigurationR\x19contentAddressableStorage\x12]\n" +
-	"\faction_cache\x18\x02 \x01(\v2:.buildbarn.configuration.blobstore.BlobAccessConfigurationR\vactionCache\"\xd6\x0f\n" +
+	"\faction_cache\x18\x02 \x01(\v2:.buildbarn.configuration.blobstore.BlobAccessConfigurationR\vactionCache\"\xad\x1b\n" +
 	"\x17BlobAccessConfiguration\x12j\n" +
 	"\fread_caching\x18\x04 \x01(\v2E.buildbarn.configuration.blobstore.ReadCachingBlobAccessConfigurationH\x00R\vreadCaching\x12G\n" +
 	"\x04grpc\x18\a \x01(\v21.buildbarn.configuration.grpc.ClientConfigurationH\x00R\x04grpc\x12*\n" +
@@ -2016,7 +4793,24 @@ const file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blo
 	"\vwith_labels\x18\x1a \x01(\v2D.buildbarn.configuration.blobstore.WithLabelsBlobAccessConfigurationH\x00R\n" +
 	"withLabels\x12\x16\n" +
 	"\x05label\x18\x1b \x01(\tH\x00R\x05label\x12o\n" +
-	"\x12deadline_enforcing\x18\x1c \x01(\v2>.buildbarn.configuration.blobstore.DeadlineEnforcingBlobAccessH\x00R\x11deadlineEnforcingB\t\n" +
+	"\x12deadline_enforcing\x18\x1c \x01(\v2>.buildbarn.configuration.blobstore.DeadlineEnforcingBlobAccessH\x00R\x11deadlineEnforcing\x12s\n" +
+	"\x0fcompressed_grpc\x18\x1d \x01(\v2H.buildbarn.configuration.blobstore.CompressedGrpcBlobAccessConfigurationH\x00R\x0ecompressedGrpc\x12N\n" +
+	"\x02s3\x18\x1e \x01(\v2<.buildbarn.configuration.blobstore.S3BlobAccessConfigurationH\x00R\x02s3\x12Q\n" +
+	"\x03gcs\x18\x1f \x01(\v2=.buildbarn.configuration.blobstore.GCSBlobAccessConfigurationH\x00R\x03gcs\x12c\n" +
+	"\tdirectory\x18  \x01(\v2C.buildbarn.configuration.blobstore.DirectoryBlobAccessConfigurationH\x00R\tdirectory\x12W\n" +
+	"\x05redis\x18! \x01(\v2?.buildbarn.configuration.blobstore.RedisBlobAccessConfigurationH\x00R\x05redis\x12T\n" +
+	"\x04http\x18\" \x01(\v2>.buildbarn.configuration.blobstore.HTTPBlobAccessConfigurationH\x00R\x04http\x12T\n" +
+	"\x04bolt\x18# \x01(\v2>.buildbarn.configuration.blobstore.BoltBlobAccessConfigurationH\x00R\x04bolt\x12Q\n" +
+	"\x03oci\x18$ \x01(\v2=.buildbarn.configuration.blobstore.OCIBlobAccessConfigurationH\x00R\x03oci\x12p\n" +
+	"\x0eerasure_coding\x18% \x01(\v2G.buildbarn.configuration.blobstore.ErasureCodingBlobAccessConfigurationH\x00R\rerasureCoding\x12f\n" +
+	"\n" +
+	"replicated\x18& \x01(\v2D.buildbarn.configuration.blobstore.ReplicatedBlobAccessConfigurationH\x00R\n" +
+	"replicated\x12]\n" +
+	"\ahedging\x18' \x01(\v2A.buildbarn.configuration.blobstore.HedgingBlobAccessConfigurationH\x00R\ahedging\x12m\n" +
+	"\rlatency_aware\x18( \x01(\v2F.buildbarn.configuration.blobstore.LatencyAwareBlobAccessConfigurationH\x00R\flatencyAware\x12t\n" +
+	"\x0fcircuit_breaker\x18) \x01(\v2I.buildbarn.configuration.blobstore.CircuitBreakingBlobAccessConfigurationH\x00R\x0ecircuitBreaker\x12m\n" +
+	"\rrate_limiting\x18* \x01(\v2F.buildbarn.configuration.blobstore.RateLimitingBlobAccessConfigurationH\x00R\frateLimiting\x12s\n" +
+	"\x0fquota_enforcing\x18+ \x01(\v2H.buildbarn.configuration.blobstore.QuotaEnforcingBlobAccessConfigurationH\x00R\x0equotaEnforcingB\t\n" +
 	"\abackendJ\x04\b\x02\x10\x03J\x04\b\x03\x10\x04J\x04\b\x05\x10\x06J\x04\b\x06\x10\aJ\x04\b\n" +
 	"\x10\v\"\xa4\x02\n" +
 	"\"ReadCachingBlobAccessConfiguration\x12N\n" +
@@ -2024,10 +4818,13 @@ const file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blo
 	"\x04fast\x18\x02 \x01(\v2:.buildbarn.configuration.blobstore.BlobAccessConfigurationR\x04fast\x12^\n" +
 	"\n" +
 	"replicator\x18\x03 \x01(\v2>.buildbarn.configuration.blobstore.BlobReplicatorConfigurationR\n" +
-	"replicator\"\xcb\x04\n" +
+	"replicator\"\x9b\x05\n" +
 	"\x1fShardingBlobAccessConfiguration\x12f\n" +
 	"\x06shards\x18\x02 \x03(\v2N.buildbarn.configuration.blobstore.ShardingBlobAccessConfiguration.ShardsEntryR\x06shards\x12a\n" +
-	"\x06legacy\x18\x03 \x01(\v2I.buildbarn.configuration.blobstore.ShardingBlobAccessConfiguration.LegacyR\x06legacy\x1au\n" +
+	"\x06legacy\x18\x03 \x01(\v2I.buildbarn.configuration.blobstore.ShardingBlobAccessConfiguration.LegacyR\x06legacy\x12-\n" +
+	"\x12replication_factor\x18\x04 \x01(\rR\x11replicationFactor\x12\x1f\n" +
+	"\vread_repair\x18\x05 \x01(\bR\n" +
+	"readRepair\x1au\n" +
 	"\x05Shard\x12T\n" +
 	"\abackend\x18\x01 \x01(\v2:.buildbarn.configuration.blobstore.BlobAccessConfigurationR\abackend\x12\x16\n" +
 	"\x06weight\x18\x02 \x01(\rR\x06weight\x1aZ\n" +
@@ -2042,7 +4839,28 @@ const file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blo
 	"\tbackend_a\x18\x01 \x01(\v2:.buildbarn.configuration.blobstore.BlobAccessConfigurationR\bbackendA\x12W\n" +
 	"\tbackend_b\x18\x02 \x01(\v2:.buildbarn.configuration.blobstore.BlobAccessConfigurationR\bbackendB\x12i\n" +
 	"\x11replicator_a_to_b\x18\x03 \x01(\v2>.buildbarn.configuration.blobstore.BlobReplicatorConfigurationR\x0ereplicatorAToB\x12i\n" +
-	"\x11replicator_b_to_a\x18\x04 \x01(\v2>.buildbarn.configuration.blobstore.BlobReplicatorConfigurationR\x0ereplicatorBToA\"\xb6\f\n" +
+	"\x11replicator_b_to_a\x18\x04 \x01(\v2>.buildbarn.configuration.blobstore.BlobReplicatorConfigurationR\x0ereplicatorBToA\"\x93\x03\n" +
+	"!ReplicatedBlobAccessConfiguration\x12h\n" +
+	"\breplicas\x18\x01 \x03(\v2L.buildbarn.configuration.blobstore.ReplicatedBlobAccessConfiguration.ReplicaR\breplicas\x12!\n" +
+	"\fwrite_quorum\x18\x02 \x01(\rR\vwriteQuorum\x12\x1f\n" +
+	"\vread_quorum\x18\x03 \x01(\rR\n" +
+	"readQuorum\x1a\xbf\x01\n" +
+	"\aReplica\x12T\n" +
+	"\abackend\x18\x01 \x01(\v2:.buildbarn.configuration.blobstore.BlobAccessConfigurationR\abackend\x12^\n" +
+	"\n" +
+	"replicator\x18\x02 \x01(\v2>.buildbarn.configuration.blobstore.BlobReplicatorConfigurationR\n" +
+	"replicator\"\xd3\x02\n" +
+	"\x1eHedgingBlobAccessConfiguration\x12V\n" +
+	"\bbackends\x18\x01 \x03(\v2:.buildbarn.configuration.blobstore.BlobAccessConfigurationR\bbackends\x12)\n" +
+	"\x10delay_percentile\x18\x02 \x01(\x01R\x0fdelayPercentile\x12.\n" +
+	"\x13latency_window_size\x18\x03 \x01(\rR\x11latencyWindowSize\x12>\n" +
+	"\rminimum_delay\x18\x04 \x01(\v2\x19.google.protobuf.DurationR\fminimumDelay\x12>\n" +
+	"\rmaximum_delay\x18\x05 \x01(\v2\x19.google.protobuf.DurationR\fmaximumDelay\"\x83\x02\n" +
+	"#LatencyAwareBlobAccessConfiguration\x12V\n" +
+	"\bbackends\x18\x01 \x03(\v2:.buildbarn.configuration.blobstore.BlobAccessConfigurationR\bbackends\x12)\n" +
+	"\x10smoothing_factor\x18\x02 \x01(\x01R\x0fsmoothingFactor\x12,\n" +
+	"\x12maximum_error_rate\x18\x03 \x01(\x01R\x10maximumErrorRate\x12+\n" +
+	"\x11probe_probability\x18\x04 \x01(\x01R\x10probeProbability\"\xb6\f\n" +
 	"\x1cLocalBlobAccessConfiguration\x12\x94\x01\n" +
 	"\x1akey_location_map_in_memory\x18\v \x01(\v2V.buildbarn.configuration.blobstore.LocalBlobAccessConfiguration.KeyLocationMapInMemoryH\x00R\x16keyLocationMapInMemory\x12{\n" +
 	" key_location_map_on_block_device\x18\f \x01(\v22.buildbarn.configuration.blockdevice.ConfigurationH\x00R\x1bkeyLocationMapOnBlockDevice\x12O\n" +
@@ -2137,7 +4955,114 @@ const file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blo
 	"\x05value\x18\x02 \x01(\v2:.buildbarn.configuration.blobstore.BlobAccessConfigurationR\x05value:\x028\x01\"\xa8\x01\n" +
 	"\x1bDeadlineEnforcingBlobAccess\x123\n" +
 	"\atimeout\x18\x01 \x01(\v2\x19.google.protobuf.DurationR\atimeout\x12T\n" +
-	"\abackend\x18\x02 \x01(\v2:.buildbarn.configuration.blobstore.BlobAccessConfigurationR\abackendBCZAgithub.com/buildbarn/bb-storage/pkg/proto/configuration/blobstoreb\x06proto3"
+	"\abackend\x18\x02 \x01(\v2:.buildbarn.configuration.blobstore.BlobAccessConfigurationR\abackend\"\xaa\x02\n" +
+	"&CircuitBreakingBlobAccessConfiguration\x12T\n" +
+	"\abackend\x18\x01 \x01(\v2:.buildbarn.configuration.blobstore.BlobAccessConfigurationR\abackend\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12+\n" +
+	"\x11failure_threshold\x18\x03 \x01(\rR\x10failureThreshold\x125\n" +
+	"\bcooldown\x18\x04 \x01(\v2\x19.google.protobuf.DurationR\bcooldown\x122\n" +
+	"\x15degraded_find_missing\x18\x05 \x01(\bR\x13degradedFindMissing\"\xdd\x05\n" +
+	"#RateLimitingBlobAccessConfiguration\x12T\n" +
+	"\abackend\x18\x01 \x01(\v2:.buildbarn.configuration.blobstore.BlobAccessConfigurationR\abackend\x12d\n" +
+	"\x17key_jmespath_expression\x18\x02 \x01(\v2,.buildbarn.configuration.jmespath.ExpressionR\x15keyJmespathExpression\x12^\n" +
+	"\x03get\x18\x03 \x01(\v2L.buildbarn.configuration.blobstore.RateLimitingBlobAccessConfiguration.LimitR\x03get\x12^\n" +
+	"\x03put\x18\x04 \x01(\v2L.buildbarn.configuration.blobstore.RateLimitingBlobAccessConfiguration.LimitR\x03put\x12o\n" +
+	"\ffind_missing\x18\x05 \x01(\v2L.buildbarn.configuration.blobstore.RateLimitingBlobAccessConfiguration.LimitR\vfindMissing\x12!\n" +
+	"\fmaximum_keys\x18\x06 \x01(\rR\vmaximumKeys\x1a\xa5\x01\n" +
+	"\x05Limit\x12.\n" +
+	"\x13requests_per_second\x18\x01 \x01(\x01R\x11requestsPerSecond\x12#\n" +
+	"\rrequest_burst\x18\x02 \x01(\rR\frequestBurst\x12(\n" +
+	"\x10bytes_per_second\x18\x03 \x01(\x01R\x0ebytesPerSecond\x12\x1d\n" +
+	"\n" +
+	"byte_burst\x18\x04 \x01(\x04R\tbyteBurst\"\xda\x05\n" +
+	"%QuotaEnforcingBlobAccessConfiguration\x12T\n" +
+	"\abackend\x18\x01 \x01(\v2:.buildbarn.configuration.blobstore.BlobAccessConfigurationR\abackend\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12l\n" +
+	"\x1atenant_jmespath_expression\x18\x03 \x01(\v2,.buildbarn.configuration.jmespath.ExpressionH\x00R\x18tenantJmespathExpression\x12T\n" +
+	"&tenant_instance_name_prefix_components\x18\x04 \x01(\rH\x00R\"tenantInstanceNamePrefixComponents\x121\n" +
+	"\x06window\x18\x05 \x01(\v2\x19.google.protobuf.DurationR\x06window\x12\x8c\x01\n" +
+	"\x12tenant_quota_bytes\x18\x06 \x03(\v2^.buildbarn.configuration.blobstore.QuotaEnforcingBlobAccessConfiguration.TenantQuotaBytesEntryR\x10tenantQuotaBytes\x12.\n" +
+	"\x13default_quota_bytes\x18\a \x01(\x04R\x11defaultQuotaBytes\x12B\n" +
+	"\x1ddeprioritized_put_concurrency\x18\b \x01(\x03R\x1bdeprioritizedPutConcurrency\x1aC\n" +
+	"\x15TenantQuotaBytesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x04R\x05value:\x028\x01B\b\n" +
+	"\x06tenant\"\xa0\x04\n" +
+	"\x19S3BlobAccessConfiguration\x12X\n" +
+	"\vaws_session\x18\x01 \x01(\v27.buildbarn.configuration.cloud.aws.SessionConfigurationR\n" +
+	"awsSession\x12!\n" +
+	"\fendpoint_url\x18\x02 \x01(\tR\vendpointUrl\x12$\n" +
+	"\x0euse_path_style\x18\x03 \x01(\bR\fusePathStyle\x12\x16\n" +
+	"\x06bucket\x18\x04 \x01(\tR\x06bucket\x12\x1d\n" +
+	"\n" +
+	"key_prefix\x18\x05 \x01(\tR\tkeyPrefix\x12F\n" +
+	" multipart_upload_part_size_bytes\x18\x06 \x01(\x03R\x1cmultipartUploadPartSizeBytes\x128\n" +
+	"\x18find_missing_concurrency\x18\a \x01(\x05R\x16findMissingConcurrency\x12(\n" +
+	"\x10slice_cache_size\x18\b \x01(\x03R\x0esliceCacheSize\x12}\n" +
+	"\x1eslice_cache_replacement_policy\x18\t \x01(\x0e28.buildbarn.configuration.eviction.CacheReplacementPolicyR\x1bsliceCacheReplacementPolicy\"\x9f\x03\n" +
+	"\x1aGCSBlobAccessConfiguration\x12d\n" +
+	"\x0eclient_options\x18\x01 \x01(\v2=.buildbarn.configuration.cloud.gcp.ClientOptionsConfigurationR\rclientOptions\x12\x16\n" +
+	"\x06bucket\x18\x02 \x01(\tR\x06bucket\x12#\n" +
+	"\robject_prefix\x18\x03 \x01(\tR\fobjectPrefix\x12H\n" +
+	"!resumable_upload_chunk_size_bytes\x18\x04 \x01(\x05R\x1dresumableUploadChunkSizeBytes\x12Z\n" +
+	"\x1ccustom_time_refresh_interval\x18\x05 \x01(\v2\x19.google.protobuf.DurationR\x19customTimeRefreshInterval\x128\n" +
+	"\x18find_missing_concurrency\x18\x06 \x01(\x05R\x16findMissingConcurrency\"\xd8\x01\n" +
+	" DirectoryBlobAccessConfiguration\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12,\n" +
+	"\x12maximum_size_bytes\x18\x02 \x01(\x03R\x10maximumSizeBytes\x12r\n" +
+	"\x18cache_replacement_policy\x18\x03 \x01(\x0e28.buildbarn.configuration.eviction.CacheReplacementPolicyR\x16cacheReplacementPolicy\"\x8c\x03\n" +
+	"\x1cRedisBlobAccessConfiguration\x12\x1c\n" +
+	"\taddresses\x18\x01 \x03(\tR\taddresses\x12!\n" +
+	"\fcluster_mode\x18\x02 \x01(\bR\vclusterMode\x12\x1a\n" +
+	"\busername\x18\x03 \x01(\tR\busername\x12\x1a\n" +
+	"\bpassword\x18\x04 \x01(\tR\bpassword\x12\x1a\n" +
+	"\bdatabase\x18\x05 \x01(\x05R\bdatabase\x12B\n" +
+	"\x03tls\x18\x06 \x01(\v20.buildbarn.configuration.tls.ClientConfigurationR\x03tls\x12\x1d\n" +
+	"\n" +
+	"key_prefix\x18\a \x01(\tR\tkeyPrefix\x129\n" +
+	"\n" +
+	"expiration\x18\b \x01(\v2\x19.google.protobuf.DurationR\n" +
+	"expiration\x129\n" +
+	"\x19maximum_object_size_bytes\x18\t \x01(\x03R\x16maximumObjectSizeBytes\"\xc6\x01\n" +
+	"\x1bHTTPBlobAccessConfiguration\x12\x18\n" +
+	"\aaddress\x18\x01 \x01(\tR\aaddress\x12S\n" +
+	"\vhttp_client\x18\x02 \x01(\v22.buildbarn.configuration.http.client.ConfigurationR\n" +
+	"httpClient\x128\n" +
+	"\x18find_missing_concurrency\x18\x03 \x01(\x05R\x16findMissingConcurrency\"\xc0\x03\n" +
+	"\x1bBoltBlobAccessConfiguration\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x129\n" +
+	"\x19maximum_object_size_bytes\x18\x02 \x01(\x03R\x16maximumObjectSizeBytes\x12D\n" +
+	"\x10minimum_validity\x18\x03 \x01(\v2\x19.google.protobuf.DurationR\x0fminimumValidity\x12Q\n" +
+	"\x17maximum_validity_jitter\x18\x04 \x01(\v2\x19.google.protobuf.DurationR\x15maximumValidityJitter\x12L\n" +
+	"\x14maintenance_interval\x18\x05 \x01(\v2\x19.google.protobuf.DurationR\x13maintenanceInterval\x12#\n" +
+	"\rsnapshot_path\x18\x06 \x01(\tR\fsnapshotPath\x12F\n" +
+	"\x11snapshot_interval\x18\a \x01(\v2\x19.google.protobuf.DurationR\x10snapshotInterval\"\xb8\x03\n" +
+	"\x1aOCIBlobAccessConfiguration\x12\x18\n" +
+	"\aaddress\x18\x01 \x01(\tR\aaddress\x12\x1e\n" +
+	"\n" +
+	"repository\x18\x02 \x01(\tR\n" +
+	"repository\x12S\n" +
+	"\vhttp_client\x18\x03 \x01(\v22.buildbarn.configuration.http.client.ConfigurationR\n" +
+	"httpClient\x12(\n" +
+	"\x10chunk_size_bytes\x18\x04 \x01(\x03R\x0echunkSizeBytes\x128\n" +
+	"\x18find_missing_concurrency\x18\x05 \x01(\x05R\x16findMissingConcurrency\x12(\n" +
+	"\x10slice_cache_size\x18\x06 \x01(\x03R\x0esliceCacheSize\x12}\n" +
+	"\x1eslice_cache_replacement_policy\x18\a \x01(\x0e28.buildbarn.configuration.eviction.CacheReplacementPolicyR\x1bsliceCacheReplacementPolicy\"\xd3\x02\n" +
+	"$ErasureCodingBlobAccessConfiguration\x12_\n" +
+	"\rdata_backends\x18\x01 \x03(\v2:.buildbarn.configuration.blobstore.BlobAccessConfigurationR\fdataBackends\x12c\n" +
+	"\x0fparity_backends\x18\x02 \x03(\v2:.buildbarn.configuration.blobstore.BlobAccessConfigurationR\x0eparityBackends\x129\n" +
+	"\x19maximum_object_size_bytes\x18\x03 \x01(\x03R\x16maximumObjectSizeBytes\x12*\n" +
+	"\x11repair_queue_size\x18\x04 \x01(\x05R\x0frepairQueueSize\"\xc5\x01\n" +
+	"%CompressedGrpcBlobAccessConfiguration\x12I\n" +
+	"\x06client\x18\x01 \x01(\v21.buildbarn.configuration.grpc.ClientConfigurationR\x06client\x12Q\n" +
+	"\n" +
+	"compressor\x18\x02 \x01(\x0e21.build.bazel.remote.execution.v2.Compressor.ValueR\n" +
+	"compressor\"\xa2\x02\n" +
+	"#ContentDefinedChunkingConfiguration\x12,\n" +
+	"\x12minimum_size_bytes\x18\x01 \x01(\x03R\x10minimumSizeBytes\x12,\n" +
+	"\x12average_size_bytes\x18\x02 \x01(\x03R\x10averageSizeBytes\x12,\n" +
+	"\x12maximum_size_bytes\x18\x03 \x01(\x03R\x10maximumSizeBytes\x12e\n" +
+	"\x10manifest_storage\x18\x06 \x01(\v2:.buildbarn.configuration.blobstore.BlobAccessConfigurationR\x0fmanifestStorageJ\x04\b\x04\x10\x05J\x04\b\x05\x10\x06BCZAgithub.com/buildbarn/bb-storage/pkg/proto/configuration/blobstoreb\x06proto3"
 
 var (
 	file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescOnce sync.Once
@@ -2151,129 +5076,204 @@ func file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blob
 	return file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDescData
 }
 
-var file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes = make([]protoimpl.MessageInfo, 29)
+var file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes = make([]protoimpl.MessageInfo, 48)
 var file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_goTypes = []any{
 	(*BlobstoreConfiguration)(nil),                         // 0: buildbarn.configuration.blobstore.BlobstoreConfiguration
 	(*BlobAccessConfiguration)(nil),                        // 1: buildbarn.configuration.blobstore.BlobAccessConfiguration
 	(*ReadCachingBlobAccessConfiguration)(nil),             // 2: buildbarn.configuration.blobstore.ReadCachingBlobAccessConfiguration
 	(*ShardingBlobAccessConfiguration)(nil),                // 3: buildbarn.configuration.blobstore.ShardingBlobAccessConfiguration
 	(*MirroredBlobAccessConfiguration)(nil),                // 4: buildbarn.configuration.blobstore.MirroredBlobAccessConfiguration
-	(*LocalBlobAccessConfiguration)(nil),                   // 5: buildbarn.configuration.blobstore.LocalBlobAccessConfiguration
-	(*ExistenceCachingBlobAccessConfiguration)(nil),        // 6: buildbarn.configuration.blobstore.ExistenceCachingBlobAccessConfiguration
-	(*CompletenessCheckingBlobAccessConfiguration)(nil),    // 7: buildbarn.configuration.blobstore.CompletenessCheckingBlobAccessConfiguration
-	(*ReadFallbackBlobAccessConfiguration)(nil),            // 8: buildbarn.configuration.blobstore.ReadFallbackBlobAccessConfiguration
-	(*ReferenceExpandingBlobAccessConfiguration)(nil),      // 9: buildbarn.configuration.blobstore.ReferenceExpandingBlobAccessConfiguration
-	(*BlobReplicatorConfiguration)(nil),                    // 10: buildbarn.configuration.blobstore.BlobReplicatorConfiguration
-	(*QueuedBlobReplicatorConfiguration)(nil),              // 11: buildbarn.configuration.blobstore.QueuedBlobReplicatorConfiguration
-	(*ConcurrencyLimitingBlobReplicatorConfiguration)(nil), // 12: buildbarn.configuration.blobstore.ConcurrencyLimitingBlobReplicatorConfiguration
-	(*DemultiplexingBlobAccessConfiguration)(nil),          // 13: buildbarn.configuration.blobstore.DemultiplexingBlobAccessConfiguration
-	(*DemultiplexedBlobAccessConfiguration)(nil),           // 14: buildbarn.configuration.blobstore.DemultiplexedBlobAccessConfiguration
-	(*ActionResultExpiringBlobAccessConfiguration)(nil),    // 15: buildbarn.configuration.blobstore.ActionResultExpiringBlobAccessConfiguration
-	(*ReadCanaryingBlobAccessConfiguration)(nil),           // 16: buildbarn.configuration.blobstore.ReadCanaryingBlobAccessConfiguration
-	(*ZIPBlobAccessConfiguration)(nil),                     // 17: buildbarn.configuration.blobstore.ZIPBlobAccessConfiguration
-	(*WithLabelsBlobAccessConfiguration)(nil),              // 18: buildbarn.configuration.blobstore.WithLabelsBlobAccessConfiguration
-	(*DeadlineEnforcingBlobAccess)(nil),                    // 19: buildbarn.configuration.blobstore.DeadlineEnforcingBlobAccess
-	(*ShardingBlobAccessConfiguration_Shard)(nil),          // 20: buildbarn.configuration.blobstore.ShardingBlobAccessConfiguration.Shard
-	(*ShardingBlobAccessConfiguration_Legacy)(nil),         // 21: buildbarn.configuration.blobstore.ShardingBlobAccessConfiguration.Legacy
-	nil, // 22: buildbarn.configuration.blobstore.ShardingBlobAccessConfiguration.ShardsEntry
-	(*LocalBlobAccessConfiguration_KeyLocationMapInMemory)(nil), // 23: buildbarn.configuration.blobstore.LocalBlobAccessConfiguration.KeyLocationMapInMemory
-	(*LocalBlobAccessConfiguration_BlocksInMemory)(nil),         // 24: buildbarn.configuration.blobstore.LocalBlobAccessConfiguration.BlocksInMemory
-	(*LocalBlobAccessConfiguration_BlocksOnBlockDevice)(nil),    // 25: buildbarn.configuration.blobstore.LocalBlobAccessConfiguration.BlocksOnBlockDevice
-	(*LocalBlobAccessConfiguration_Persistent)(nil),             // 26: buildbarn.configuration.blobstore.LocalBlobAccessConfiguration.Persistent
-	nil,                               // 27: buildbarn.configuration.blobstore.DemultiplexingBlobAccessConfiguration.InstanceNamePrefixesEntry
-	nil,                               // 28: buildbarn.configuration.blobstore.WithLabelsBlobAccessConfiguration.LabelsEntry
-	(*grpc.ClientConfiguration)(nil),  // 29: buildbarn.configuration.grpc.ClientConfiguration
-	(*status.Status)(nil),             // 30: google.rpc.Status
-	(*blockdevice.Configuration)(nil), // 31: buildbarn.configuration.blockdevice.Configuration
-	(*digest.ExistenceCacheConfiguration)(nil), // 32: buildbarn.configuration.digest.ExistenceCacheConfiguration
-	(*aws.SessionConfiguration)(nil),           // 33: buildbarn.configuration.cloud.aws.SessionConfiguration
-	(*client.Configuration)(nil),               // 34: buildbarn.configuration.http.client.Configuration
-	(*gcp.ClientOptionsConfiguration)(nil),     // 35: buildbarn.configuration.cloud.gcp.ClientOptionsConfiguration
-	(*emptypb.Empty)(nil),                      // 36: google.protobuf.Empty
-	(*durationpb.Duration)(nil),                // 37: google.protobuf.Duration
-	(*timestamppb.Timestamp)(nil),              // 38: google.protobuf.Timestamp
+	(*ReplicatedBlobAccessConfiguration)(nil),              // 5: buildbarn.configuration.blobstore.ReplicatedBlobAccessConfiguration
+	(*HedgingBlobAccessConfiguration)(nil),                 // 6: buildbarn.configuration.blobstore.HedgingBlobAccessConfiguration
+	(*LatencyAwareBlobAccessConfiguration)(nil),            // 7: buildbarn.configuration.blobstore.LatencyAwareBlobAccessConfiguration
+	(*LocalBlobAccessConfiguration)(nil),                   // 8: buildbarn.configuration.blobstore.LocalBlobAccessConfiguration
+	(*ExistenceCachingBlobAccessConfiguration)(nil),        // 9: buildbarn.configuration.blobstore.ExistenceCachingBlobAccessConfiguration
+	(*CompletenessCheckingBlobAccessConfiguration)(nil),    // 10: buildbarn.configuration.blobstore.CompletenessCheckingBlobAccessConfiguration
+	(*ReadFallbackBlobAccessConfiguration)(nil),            // 11: buildbarn.configuration.blobstore.ReadFallbackBlobAccessConfiguration
+	(*ReferenceExpandingBlobAccessConfiguration)(nil),      // 12: buildbarn.configuration.blobstore.ReferenceExpandingBlobAccessConfiguration
+	(*BlobReplicatorConfiguration)(nil),                    // 13: buildbarn.configuration.blobstore.BlobReplicatorConfiguration
+	(*QueuedBlobReplicatorConfiguration)(nil),              // 14: buildbarn.configuration.blobstore.QueuedBlobReplicatorConfiguration
+	(*ConcurrencyLimitingBlobReplicatorConfiguration)(nil), // 15: buildbarn.configuration.blobstore.ConcurrencyLimitingBlobReplicatorConfiguration
+	(*DemultiplexingBlobAccessConfiguration)(nil),          // 16: buildbarn.configuration.blobstore.DemultiplexingBlobAccessConfiguration
+	(*DemultiplexedBlobAccessConfiguration)(nil),           // 17: buildbarn.configuration.blobstore.DemultiplexedBlobAccessConfiguration
+	(*ActionResultExpiringBlobAccessConfiguration)(nil),    // 18: buildbarn.configuration.blobstore.ActionResultExpiringBlobAccessConfiguration
+	(*ReadCanaryingBlobAccessConfiguration)(nil),           // 19: buildbarn.configuration.blobstore.ReadCanaryingBlobAccessConfiguration
+	(*ZIPBlobAccessConfiguration)(nil),                     // 20: buildbarn.configuration.blobstore.ZIPBlobAccessConfiguration
+	(*WithLabelsBlobAccessConfiguration)(nil),              // 21: buildbarn.configuration.blobstore.WithLabelsBlobAccessConfiguration
+	(*DeadlineEnforcingBlobAccess)(nil),                    // 22: buildbarn.configuration.blobstore.DeadlineEnforcingBlobAccess
+	(*CircuitBreakingBlobAccessConfiguration)(nil),         // 23: buildbarn.configuration.blobstore.CircuitBreakingBlobAccessConfiguration
+	(*RateLimitingBlobAccessConfiguration)(nil),            // 24: buildbarn.configuration.blobstore.RateLimitingBlobAccessConfiguration
+	(*QuotaEnforcingBlobAccessConfiguration)(nil),          // 25: buildbarn.configuration.blobstore.QuotaEnforcingBlobAccessConfiguration
+	(*S3BlobAccessConfiguration)(nil),                      // 26: buildbarn.configuration.blobstore.S3BlobAccessConfiguration
+	(*GCSBlobAccessConfiguration)(nil),                     // 27: buildbarn.configuration.blobstore.GCSBlobAccessConfiguration
+	(*DirectoryBlobAccessConfiguration)(nil),               // 28: buildbarn.configuration.blobstore.DirectoryBlobAccessConfiguration
+	(*RedisBlobAccessConfiguration)(nil),                   // 29: buildbarn.configuration.blobstore.RedisBlobAccessConfiguration
+	(*HTTPBlobAccessConfiguration)(nil),                    // 30: buildbarn.configuration.blobstore.HTTPBlobAccessConfiguration
+	(*BoltBlobAccessConfiguration)(nil),                    // 31: buildbarn.configuration.blobstore.BoltBlobAccessConfiguration
+	(*OCIBlobAccessConfiguration)(nil),                     // 32: buildbarn.configuration.blobstore.OCIBlobAccessConfiguration
+	(*ErasureCodingBlobAccessConfiguration)(nil),           // 33: buildbarn.configuration.blobstore.ErasureCodingBlobAccessConfiguration
+	(*CompressedGrpcBlobAccessConfiguration)(nil),          // 34: buildbarn.configuration.blobstore.CompressedGrpcBlobAccessConfiguration
+	(*ContentDefinedChunkingConfiguration)(nil),            // 35: buildbarn.configuration.blobstore.ContentDefinedChunkingConfiguration
+	(*ShardingBlobAccessConfiguration_Shard)(nil),          // 36: buildbarn.configuration.blobstore.ShardingBlobAccessConfiguration.Shard
+	(*ShardingBlobAccessConfiguration_Legacy)(nil),         // 37: buildbarn.configuration.blobstore.ShardingBlobAccessConfiguration.Legacy
+	nil, // 38: buildbarn.configuration.blobstore.ShardingBlobAccessConfiguration.ShardsEntry
+	(*ReplicatedBlobAccessConfiguration_Replica)(nil),           // 39: buildbarn.configuration.blobstore.ReplicatedBlobAccessConfiguration.Replica
+	(*LocalBlobAccessConfiguration_KeyLocationMapInMemory)(nil), // 40: buildbarn.configuration.blobstore.LocalBlobAccessConfiguration.KeyLocationMapInMemory
+	(*LocalBlobAccessConfiguration_BlocksInMemory)(nil),         // 41: buildbarn.configuration.blobstore.LocalBlobAccessConfiguration.BlocksInMemory
+	(*LocalBlobAccessConfiguration_BlocksOnBlockDevice)(nil),    // 42: buildbarn.configuration.blobstore.LocalBlobAccessConfiguration.BlocksOnBlockDevice
+	(*LocalBlobAccessConfiguration_Persistent)(nil),             // 43: buildbarn.configuration.blobstore.LocalBlobAccessConfiguration.Persistent
+	nil, // 44: buildbarn.configuration.blobstore.DemultiplexingBlobAccessConfiguration.InstanceNamePrefixesEntry
+	nil, // 45: buildbarn.configuration.blobstore.WithLabelsBlobAccessConfiguration.LabelsEntry
+	(*RateLimitingBlobAccessConfiguration_Limit)(nil), // 46: buildbarn.configuration.blobstore.RateLimitingBlobAccessConfiguration.Limit
+	nil,                                        // 47: buildbarn.configuration.blobstore.QuotaEnforcingBlobAccessConfiguration.TenantQuotaBytesEntry
+	(*grpc.ClientConfiguration)(nil),           // 48: buildbarn.configuration.grpc.ClientConfiguration
+	(*status.Status)(nil),                      // 49: google.rpc.Status
+	(*durationpb.Duration)(nil),                // 50: google.protobuf.Duration
+	(*blockdevice.Configuration)(nil),          // 51: buildbarn.configuration.blockdevice.Configuration
+	(*digest.ExistenceCacheConfiguration)(nil), // 52: buildbarn.configuration.digest.ExistenceCacheConfiguration
+	(*aws.SessionConfiguration)(nil),           // 53: buildbarn.configuration.cloud.aws.SessionConfiguration
+	(*client.Configuration)(nil),               // 54: buildbarn.configuration.http.client.Configuration
+	(*gcp.ClientOptionsConfiguration)(nil),     // 55: buildbarn.configuration.cloud.gcp.ClientOptionsConfiguration
+	(*emptypb.Empty)(nil),                      // 56: google.protobuf.Empty
+	(*timestamppb.Timestamp)(nil),              // 57: google.protobuf.Timestamp
+	(*jmespath.Expression)(nil),                // 58: buildbarn.configuration.jmespath.Expression
+	(eviction.CacheReplacementPolicy)(0),       // 59: buildbarn.configuration.eviction.CacheReplacementPolicy
+	(*tls.ClientConfiguration)(nil),            // 60: buildbarn.configuration.tls.ClientConfiguration
+	(v2.Compressor_Value)(0),                   // 61: build.bazel.remote.execution.v2.Compressor.Value
 }
 var file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_depIdxs = []int32{
-	1,  // 0: buildbarn.configuration.blobstore.BlobstoreConfiguration.content_addressable_storage:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
-	1,  // 1: buildbarn.configuration.blobstore.BlobstoreConfiguration.action_cache:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
-	2,  // 2: buildbarn.configuration.blobstore.BlobAccessConfiguration.read_caching:type_name -> buildbarn.configuration.blobstore.ReadCachingBlobAccessConfiguration
-	29, // 3: buildbarn.configuration.blobstore.BlobAccessConfiguration.grpc:type_name -> buildbarn.configuration.grpc.ClientConfiguration
-	30, // 4: buildbarn.configuration.blobstore.BlobAccessConfiguration.error:type_name -> google.rpc.Status
-	3,  // 5: buildbarn.configuration.blobstore.BlobAccessConfiguration.sharding:type_name -> buildbarn.configuration.blobstore.ShardingBlobAccessConfiguration
-	4,  // 6: buildbarn.configuration.blobstore.BlobAccessConfiguration.mirrored:type_name -> buildbarn.configuration.blobstore.MirroredBlobAccessConfiguration
-	5,  // 7: buildbarn.configuration.blobstore.BlobAccessConfiguration.local:type_name -> buildbarn.configuration.blobstore.LocalBlobAccessConfiguration
-	6,  // 8: buildbarn.configuration.blobstore.BlobAccessConfiguration.existence_caching:type_name -> buildbarn.configuration.blobstore.ExistenceCachingBlobAccessConfiguration
-	7,  // 9: buildbarn.configuration.blobstore.BlobAccessConfiguration.completeness_checking:type_name -> buildbarn.configuration.blobstore.CompletenessCheckingBlobAccessConfiguration
-	8,  // 10: buildbarn.configuration.blobstore.BlobAccessConfiguration.read_fallback:type_name -> buildbarn.configuration.blobstore.ReadFallbackBlobAccessConfiguration
-	9,  // 11: buildbarn.configuration.blobstore.BlobAccessConfiguration.reference_expanding:type_name -> buildbarn.configuration.blobstore.ReferenceExpandingBlobAccessConfiguration
-	13, // 12: buildbarn.configuration.blobstore.BlobAccessConfiguration.demultiplexing:type_name -> buildbarn.configuration.blobstore.DemultiplexingBlobAccessConfiguration
-	1,  // 13: buildbarn.configuration.blobstore.BlobAccessConfiguration.hierarchical_instance_names:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
-	15, // 14: buildbarn.configuration.blobstore.BlobAccessConfiguration.action_result_expiring:type_name -> buildbarn.configuration.blobstore.ActionResultExpiringBlobAccessConfiguration
-	16, // 15: buildbarn.configuration.blobstore.BlobAccessConfiguration.read_canarying:type_name -> buildbarn.configuration.blobstore.ReadCanaryingBlobAccessConfiguration
-	17, // 16: buildbarn.configuration.blobstore.BlobAccessConfiguration.zip_reading:type_name -> buildbarn.configuration.blobstore.ZIPBlobAccessConfiguration
-	17, // 17: buildbarn.configuration.blobstore.BlobAccessConfiguration.zip_writing:type_name -> buildbarn.configuration.blobstore.ZIPBlobAccessConfiguration
-	18, // 18: buildbarn.configuration.blobstore.BlobAccessConfiguration.with_labels:type_name -> buildbarn.configuration.blobstore.WithLabelsBlobAccessConfiguration
-	19, // 19: buildbarn.configuration.blobstore.BlobAccessConfiguration.deadline_enforcing:type_name -> buildbarn.configuration.blobstore.DeadlineEnforcingBlobAccess
-	1,  // 20: buildbarn.configuration.blobstore.ReadCachingBlobAccessConfiguration.slow:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
-	1,  // 21: buildbarn.configuration.blobstore.ReadCachingBlobAccessConfiguration.fast:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
-	10, // 22: buildbarn.configuration.blobstore.ReadCachingBlobAccessConfiguration.replicator:type_name -> buildbarn.configuration.blobstore.BlobReplicatorConfiguration
-	22, // 23: buildbarn.configuration.blobstore.ShardingBlobAccessConfiguration.shards:type_name -> buildbarn.configuration.blobstore.ShardingBlobAccessConfiguration.ShardsEntry
-	21, // 24: buildbarn.configuration.blobstore.ShardingBlobAccessConfiguration.legacy:type_name -> buildbarn.configuration.blobstore.ShardingBlobAccessConfiguration.Legacy
-	1,  // 25: buildbarn.configuration.blobstore.MirroredBlobAccessConfiguration.backend_a:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
-	1,  // 26: buildbarn.configuration.blobstore.MirroredBlobAccessConfiguration.backend_b:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
-	10, // 27: buildbarn.configuration.blobstore.MirroredBlobAccessConfiguration.replicator_a_to_b:type_name -> buildbarn.configuration.blobstore.BlobReplicatorConfiguration
-	10, // 28: buildbarn.configuration.blobstore.MirroredBlobAccessConfiguration.replicator_b_to_a:type_name -> buildbarn.configuration.blobstore.BlobReplicatorConfiguration
-	23, // 29: buildbarn.configuration.blobstore.LocalBlobAccessConfiguration.key_location_map_in_memory:type_name -> buildbarn.configuration.blobstore.LocalBlobAccessConfiguration.KeyLocationMapInMemory
-	31, // 30: buildbarn.configuration.blobstore.LocalBlobAccessConfiguration.key_location_map_on_block_device:type_name -> buildbarn.configuration.blockdevice.Configuration
-	24, // 31: buildbarn.configuration.blobstore.LocalBlobAccessConfiguration.blocks_in_memory:type_name -> buildbarn.configuration.blobstore.LocalBlobAccessConfiguration.BlocksInMemory
-	25, // 32: buildbarn.configuration.blobstore.LocalBlobAccessConfiguration.blocks_on_block_device:type_name -> buildbarn.configuration.blobstore.LocalBlobAccessConfiguration.BlocksOnBlockDevice
-	26, // 33: buildbarn.configuration.blobstore.LocalBlobAccessConfiguration.persistent:type_name -> buildbarn.configuration.blobstore.LocalBlobAccessConfiguration.Persistent
-	1,  // 34: buildbarn.configuration.blobstore.ExistenceCachingBlobAccessConfiguration.backend:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
-	32, // 35: buildbarn.configuration.blobstore.ExistenceCachingBlobAccessConfiguration.existence_cache:type_name -> buildbarn.configuration.digest.ExistenceCacheConfiguration
-	1,  // 36: buildbarn.configuration.blobstore.CompletenessCheckingBlobAccessConfiguration.backend:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
-	1,  // 37: buildbarn.configuration.blobstore.ReadFallbackBlobAccessConfiguration.primary:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
-	1,  // 38: buildbarn.configuration.blobstore.ReadFallbackBlobAccessConfiguration.secondary:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
-	10, // 39: buildbarn.configuration.blobstore.ReadFallbackBlobAccessConfiguration.replicator:type_name -> buildbarn.configuration.blobstore.BlobReplicatorConfiguration
-	1,  // 40: buildbarn.configuration.blobstore.ReferenceExpandingBlobAccessConfiguration.indirect_content_addressable_storage:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
-	33, // 41: buildbarn.configuration.blobstore.ReferenceExpandingBlobAccessConfiguration.aws_session:type_name -> buildbarn.configuration.cloud.aws.SessionConfiguration
-	34, // 42: buildbarn.configuration.blobstore.ReferenceExpandingBlobAccessConfiguration.http_client:type_name -> buildbarn.configuration.http.client.Configuration
-	35, // 43: buildbarn.configuration.blobstore.ReferenceExpandingBlobAccessConfiguration.gcp_client_options:type_name -> buildbarn.configuration.cloud.gcp.ClientOptionsConfiguration
-	1,  // 44: buildbarn.configuration.blobstore.ReferenceExpandingBlobAccessConfiguration.content_addressable_storage:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
-	36, // 45: buildbarn.configuration.blobstore.BlobReplicatorConfiguration.local:type_name -> google.protobuf.Empty
-	29, // 46: buildbarn.configuration.blobstore.BlobReplicatorConfiguration.remote:type_name -> buildbarn.configuration.grpc.ClientConfiguration
-	11, // 47: buildbarn.configuration.blobstore.BlobReplicatorConfiguration.queued:type_name -> buildbarn.configuration.blobstore.QueuedBlobReplicatorConfiguration
-	36, // 48: buildbarn.configuration.blobstore.BlobReplicatorConfiguration.noop:type_name -> google.protobuf.Empty
-	10, // 49: buildbarn.configuration.blobstore.BlobReplicatorConfiguration.deduplicating:type_name -> buildbarn.configuration.blobstore.BlobReplicatorConfiguration
-	12, // 50: buildbarn.configuration.blobstore.BlobReplicatorConfiguration.concurrency_limiting:type_name -> buildbarn.configuration.blobstore.ConcurrencyLimitingBlobReplicatorConfiguration
-	10, // 51: buildbarn.configuration.blobstore.QueuedBlobReplicatorConfiguration.base:type_name -> buildbarn.configuration.blobstore.BlobReplicatorConfiguration
-	32, // 52: buildbarn.configuration.blobstore.QueuedBlobReplicatorConfiguration.existence_cache:type_name -> buildbarn.configuration.digest.ExistenceCacheConfiguration
-	10, // 53: buildbarn.configuration.blobstore.ConcurrencyLimitingBlobReplicatorConfiguration.base:type_name -> buildbarn.configuration.blobstore.BlobReplicatorConfiguration
-	27, // 54: buildbarn.configuration.blobstore.DemultiplexingBlobAccessConfiguration.instance_name_prefixes:type_name -> buildbarn.configuration.blobstore.DemultiplexingBlobAccessConfiguration.InstanceNamePrefixesEntry
-	1,  // 55: buildbarn.configuration.blobstore.DemultiplexedBlobAccessConfiguration.backend:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
-	1,  // 56: buildbarn.configuration.blobstore.ActionResultExpiringBlobAccessConfiguration.backend:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
-	37, // 57: buildbarn.configuration.blobstore.ActionResultExpiringBlobAccessConfiguration.minimum_validity:type_name -> google.protobuf.Duration
-	37, // 58: buildbarn.configuration.blobstore.ActionResultExpiringBlobAccessConfiguration.maximum_validity_jitter:type_name -> google.protobuf.Duration
-	38, // 59: buildbarn.configuration.blobstore.ActionResultExpiringBlobAccessConfiguration.minimum_timestamp:type_name -> google.protobuf.Timestamp
-	1,  // 60: buildbarn.configuration.blobstore.ReadCanaryingBlobAccessConfiguration.source:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
-	1,  // 61: buildbarn.configuration.blobstore.ReadCanaryingBlobAccessConfiguration.replica:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
-	37, // 62: buildbarn.configuration.blobstore.ReadCanaryingBlobAccessConfiguration.maximum_cache_duration:type_name -> google.protobuf.Duration
-	32, // 63: buildbarn.configuration.blobstore.ZIPBlobAccessConfiguration.data_integrity_validation_cache:type_name -> buildbarn.configuration.digest.ExistenceCacheConfiguration
-	1,  // 64: buildbarn.configuration.blobstore.WithLabelsBlobAccessConfiguration.backend:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
-	28, // 65: buildbarn.configuration.blobstore.WithLabelsBlobAccessConfiguration.labels:type_name -> buildbarn.configuration.blobstore.WithLabelsBlobAccessConfiguration.LabelsEntry
-	37, // 66: buildbarn.configuration.blobstore.DeadlineEnforcingBlobAccess.timeout:type_name -> google.protobuf.Duration
-	1,  // 67: buildbarn.configuration.blobstore.DeadlineEnforcingBlobAccess.backend:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
-	1,  // 68: buildbarn.configuration.blobstore.ShardingBlobAccessConfiguration.Shard.backend:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
-	20, // 69: buildbarn.configuration.blobstore.ShardingBlobAccessConfiguration.ShardsEntry.value:type_name -> buildbarn.configuration.blobstore.ShardingBlobAccessConfiguration.Shard
-	31, // 70: buildbarn.configuration.blobstore.LocalBlobAccessConfiguration.BlocksOnBlockDevice.source:type_name -> buildbarn.configuration.blockdevice.Configuration
-	32, // 71: buildbarn.configuration.blobstore.LocalBlobAccessConfiguration.BlocksOnBlockDevice.data_integrity_validation_cache:type_name -> buildbarn.configuration.digest.ExistenceCacheConfiguration
-	37, // 72: buildbarn.configuration.blobstore.LocalBlobAccessConfiguration.Persistent.minimum_epoch_interval:type_name -> google.protobuf.Duration
-	14, // 73: buildbarn.configuration.blobstore.DemultiplexingBlobAccessConfiguration.InstanceNamePrefixesEntry.value:type_name -> buildbarn.configuration.blobstore.DemultiplexedBlobAccessConfiguration
-	1,  // 74: buildbarn.configuration.blobstore.WithLabelsBlobAccessConfiguration.LabelsEntry.value:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
-	75, // [75:75] is the sub-list for method output_type
-	75, // [75:75] is the sub-list for method input_type
-	75, // [75:75] is the sub-list for extension type_name
-	75, // [75:75] is the sub-list for extension extendee
-	0,  // [0:75] is the sub-list for field type_name
+	1,   // 0: buildbarn.configuration.blobstore.BlobstoreConfiguration.content_addressable_storage:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
+	1,   // 1: buildbarn.configuration.blobstore.BlobstoreConfiguration.action_cache:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
+	2,   // 2: buildbarn.configuration.blobstore.BlobAccessConfiguration.read_caching:type_name -> buildbarn.configuration.blobstore.ReadCachingBlobAccessConfiguration
+	48,  // 3: buildbarn.configuration.blobstore.BlobAccessConfiguration.grpc:type_name -> buildbarn.configuration.grpc.ClientConfiguration
+	49,  // 4: buildbarn.configuration.blobstore.BlobAccessConfiguration.error:type_name -> google.rpc.Status
+	3,   // 5: buildbarn.configuration.blobstore.BlobAccessConfiguration.sharding:type_name -> buildbarn.configuration.blobstore.ShardingBlobAccessConfiguration
+	4,   // 6: buildbarn.configuration.blobstore.BlobAccessConfiguration.mirrored:type_name -> buildbarn.configuration.blobstore.MirroredBlobAccessConfiguration
+	8,   // 7: buildbarn.configuration.blobstore.BlobAccessConfiguration.local:type_name -> buildbarn.configuration.blobstore.LocalBlobAccessConfiguration
+	9,   // 8: buildbarn.configuration.blobstore.BlobAccessConfiguration.existence_caching:type_name -> buildbarn.configuration.blobstore.ExistenceCachingBlobAccessConfiguration
+	10,  // 9: buildbarn.configuration.blobstore.BlobAccessConfiguration.completeness_checking:type_name -> buildbarn.configuration.blobstore.CompletenessCheckingBlobAccessConfiguration
+	11,  // 10: buildbarn.configuration.blobstore.BlobAccessConfiguration.read_fallback:type_name -> buildbarn.configuration.blobstore.ReadFallbackBlobAccessConfiguration
+	12,  // 11: buildbarn.configuration.blobstore.BlobAccessConfiguration.reference_expanding:type_name -> buildbarn.configuration.blobstore.ReferenceExpandingBlobAccessConfiguration
+	16,  // 12: buildbarn.configuration.blobstore.BlobAccessConfiguration.demultiplexing:type_name -> buildbarn.configuration.blobstore.DemultiplexingBlobAccessConfiguration
+	1,   // 13: buildbarn.configuration.blobstore.BlobAccessConfiguration.hierarchical_instance_names:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
+	18,  // 14: buildbarn.configuration.blobstore.BlobAccessConfiguration.action_result_expiring:type_name -> buildbarn.configuration.blobstore.ActionResultExpiringBlobAccessConfiguration
+	19,  // 15: buildbarn.configuration.blobstore.BlobAccessConfiguration.read_canarying:type_name -> buildbarn.configuration.blobstore.ReadCanaryingBlobAccessConfiguration
+	20,  // 16: buildbarn.configuration.blobstore.BlobAccessConfiguration.zip_reading:type_name -> buildbarn.configuration.blobstore.ZIPBlobAccessConfiguration
+	20,  // 17: buildbarn.configuration.blobstore.BlobAccessConfiguration.zip_writing:type_name -> buildbarn.configuration.blobstore.ZIPBlobAccessConfiguration
+	21,  // 18: buildbarn.configuration.blobstore.BlobAccessConfiguration.with_labels:type_name -> buildbarn.configuration.blobstore.WithLabelsBlobAccessConfiguration
+	22,  // 19: buildbarn.configuration.blobstore.BlobAccessConfiguration.deadline_enforcing:type_name -> buildbarn.configuration.blobstore.DeadlineEnforcingBlobAccess
+	34,  // 20: buildbarn.configuration.blobstore.BlobAccessConfiguration.compressed_grpc:type_name -> buildbarn.configuration.blobstore.CompressedGrpcBlobAccessConfiguration
+	26,  // 21: buildbarn.configuration.blobstore.BlobAccessConfiguration.s3:type_name -> buildbarn.configuration.blobstore.S3BlobAccessConfiguration
+	27,  // 22: buildbarn.configuration.blobstore.BlobAccessConfiguration.gcs:type_name -> buildbarn.configuration.blobstore.GCSBlobAccessConfiguration
+	28,  // 23: buildbarn.configuration.blobstore.BlobAccessConfiguration.directory:type_name -> buildbarn.configuration.blobstore.DirectoryBlobAccessConfiguration
+	29,  // 24: buildbarn.configuration.blobstore.BlobAccessConfiguration.redis:type_name -> buildbarn.configuration.blobstore.RedisBlobAccessConfiguration
+	30,  // 25: buildbarn.configuration.blobstore.BlobAccessConfiguration.http:type_name -> buildbarn.configuration.blobstore.HTTPBlobAccessConfiguration
+	31,  // 26: buildbarn.configuration.blobstore.BlobAccessConfiguration.bolt:type_name -> buildbarn.configuration.blobstore.BoltBlobAccessConfiguration
+	32,  // 27: buildbarn.configuration.blobstore.BlobAccessConfiguration.oci:type_name -> buildbarn.configuration.blobstore.OCIBlobAccessConfiguration
+	33,  // 28: buildbarn.configuration.blobstore.BlobAccessConfiguration.erasure_coding:type_name -> buildbarn.configuration.blobstore.ErasureCodingBlobAccessConfiguration
+	5,   // 29: buildbarn.configuration.blobstore.BlobAccessConfiguration.replicated:type_name -> buildbarn.configuration.blobstore.ReplicatedBlobAccessConfiguration
+	6,   // 30: buildbarn.configuration.blobstore.BlobAccessConfiguration.hedging:type_name -> buildbarn.configuration.blobstore.HedgingBlobAccessConfiguration
+	7,   // 31: buildbarn.configuration.blobstore.BlobAccessConfiguration.latency_aware:type_name -> buildbarn.configuration.blobstore.LatencyAwareBlobAccessConfiguration
+	23,  // 32: buildbarn.configuration.blobstore.BlobAccessConfiguration.circuit_breaker:type_name -> buildbarn.configuration.blobstore.CircuitBreakingBlobAccessConfiguration
+	24,  // 33: buildbarn.configuration.blobstore.BlobAccessConfiguration.rate_limiting:type_name -> buildbarn.configuration.blobstore.RateLimitingBlobAccessConfiguration
+	25,  // 34: buildbarn.configuration.blobstore.BlobAccessConfiguration.quota_enforcing:type_name -> buildbarn.configuration.blobstore.QuotaEnforcingBlobAccessConfiguration
+	1,   // 35: buildbarn.configuration.blobstore.ReadCachingBlobAccessConfiguration.slow:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
+	1,   // 36: buildbarn.configuration.blobstore.ReadCachingBlobAccessConfiguration.fast:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
+	13,  // 37: buildbarn.configuration.blobstore.ReadCachingBlobAccessConfiguration.replicator:type_name -> buildbarn.configuration.blobstore.BlobReplicatorConfiguration
+	38,  // 38: buildbarn.configuration.blobstore.ShardingBlobAccessConfiguration.shards:type_name -> buildbarn.configuration.blobstore.ShardingBlobAccessConfiguration.ShardsEntry
+	37,  // 39: buildbarn.configuration.blobstore.ShardingBlobAccessConfiguration.legacy:type_name -> buildbarn.configuration.blobstore.ShardingBlobAccessConfiguration.Legacy
+	1,   // 40: buildbarn.configuration.blobstore.MirroredBlobAccessConfiguration.backend_a:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
+	1,   // 41: buildbarn.configuration.blobstore.MirroredBlobAccessConfiguration.backend_b:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
+	13,  // 42: buildbarn.configuration.blobstore.MirroredBlobAccessConfiguration.replicator_a_to_b:type_name -> buildbarn.configuration.blobstore.BlobReplicatorConfiguration
+	13,  // 43: buildbarn.configuration.blobstore.MirroredBlobAccessConfiguration.replicator_b_to_a:type_name -> buildbarn.configuration.blobstore.BlobReplicatorConfiguration
+	39,  // 44: buildbarn.configuration.blobstore.ReplicatedBlobAccessConfiguration.replicas:type_name -> buildbarn.configuration.blobstore.ReplicatedBlobAccessConfiguration.Replica
+	1,   // 45: buildbarn.configuration.blobstore.HedgingBlobAccessConfiguration.backends:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
+	50,  // 46: buildbarn.configuration.blobstore.HedgingBlobAccessConfiguration.minimum_delay:type_name -> google.protobuf.Duration
+	50,  // 47: buildbarn.configuration.blobstore.HedgingBlobAccessConfiguration.maximum_delay:type_name -> google.protobuf.Duration
+	1,   // 48: buildbarn.configuration.blobstore.LatencyAwareBlobAccessConfiguration.backends:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
+	40,  // 49: buildbarn.configuration.blobstore.LocalBlobAccessConfiguration.key_location_map_in_memory:type_name -> buildbarn.configuration.blobstore.LocalBlobAccessConfiguration.KeyLocationMapInMemory
+	51,  // 50: buildbarn.configuration.blobstore.LocalBlobAccessConfiguration.key_location_map_on_block_device:type_name -> buildbarn.configuration.blockdevice.Configuration
+	41,  // 51: buildbarn.configuration.blobstore.LocalBlobAccessConfiguration.blocks_in_memory:type_name -> buildbarn.configuration.blobstore.LocalBlobAccessConfiguration.BlocksInMemory
+	42,  // 52: buildbarn.configuration.blobstore.LocalBlobAccessConfiguration.blocks_on_block_device:type_name -> buildbarn.configuration.blobstore.LocalBlobAccessConfiguration.BlocksOnBlockDevice
+	43,  // 53: buildbarn.configuration.blobstore.LocalBlobAccessConfiguration.persistent:type_name -> buildbarn.configuration.blobstore.LocalBlobAccessConfiguration.Persistent
+	1,   // 54: buildbarn.configuration.blobstore.ExistenceCachingBlobAccessConfiguration.backend:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
+	52,  // 55: buildbarn.configuration.blobstore.ExistenceCachingBlobAccessConfiguration.existence_cache:type_name -> buildbarn.configuration.digest.ExistenceCacheConfiguration
+	1,   // 56: buildbarn.configuration.blobstore.CompletenessCheckingBlobAccessConfiguration.backend:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
+	1,   // 57: buildbarn.configuration.blobstore.ReadFallbackBlobAccessConfiguration.primary:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
+	1,   // 58: buildbarn.configuration.blobstore.ReadFallbackBlobAccessConfiguration.secondary:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
+	13,  // 59: buildbarn.configuration.blobstore.ReadFallbackBlobAccessConfiguration.replicator:type_name -> buildbarn.configuration.blobstore.BlobReplicatorConfiguration
+	1,   // 60: buildbarn.configuration.blobstore.ReferenceExpandingBlobAccessConfiguration.indirect_content_addressable_storage:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
+	53,  // 61: buildbarn.configuration.blobstore.ReferenceExpandingBlobAccessConfiguration.aws_session:type_name -> buildbarn.configuration.cloud.aws.SessionConfiguration
+	54,  // 62: buildbarn.configuration.blobstore.ReferenceExpandingBlobAccessConfiguration.http_client:type_name -> buildbarn.configuration.http.client.Configuration
+	55,  // 63: buildbarn.configuration.blobstore.ReferenceExpandingBlobAccessConfiguration.gcp_client_options:type_name -> buildbarn.configuration.cloud.gcp.ClientOptionsConfiguration
+	1,   // 64: buildbarn.configuration.blobstore.ReferenceExpandingBlobAccessConfiguration.content_addressable_storage:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
+	56,  // 65: buildbarn.configuration.blobstore.BlobReplicatorConfiguration.local:type_name -> google.protobuf.Empty
+	48,  // 66: buildbarn.configuration.blobstore.BlobReplicatorConfiguration.remote:type_name -> buildbarn.configuration.grpc.ClientConfiguration
+	14,  // 67: buildbarn.configuration.blobstore.BlobReplicatorConfiguration.queued:type_name -> buildbarn.configuration.blobstore.QueuedBlobReplicatorConfiguration
+	56,  // 68: buildbarn.configuration.blobstore.BlobReplicatorConfiguration.noop:type_name -> google.protobuf.Empty
+	13,  // 69: buildbarn.configuration.blobstore.BlobReplicatorConfiguration.deduplicating:type_name -> buildbarn.configuration.blobstore.BlobReplicatorConfiguration
+	15,  // 70: buildbarn.configuration.blobstore.BlobReplicatorConfiguration.concurrency_limiting:type_name -> buildbarn.configuration.blobstore.ConcurrencyLimitingBlobReplicatorConfiguration
+	13,  // 71: buildbarn.configuration.blobstore.QueuedBlobReplicatorConfiguration.base:type_name -> buildbarn.configuration.blobstore.BlobReplicatorConfiguration
+	52,  // 72: buildbarn.configuration.blobstore.QueuedBlobReplicatorConfiguration.existence_cache:type_name -> buildbarn.configuration.digest.ExistenceCacheConfiguration
+	13,  // 73: buildbarn.configuration.blobstore.ConcurrencyLimitingBlobReplicatorConfiguration.base:type_name -> buildbarn.configuration.blobstore.BlobReplicatorConfiguration
+	44,  // 74: buildbarn.configuration.blobstore.DemultiplexingBlobAccessConfiguration.instance_name_prefixes:type_name -> buildbarn.configuration.blobstore.DemultiplexingBlobAccessConfiguration.InstanceNamePrefixesEntry
+	1,   // 75: buildbarn.configuration.blobstore.DemultiplexedBlobAccessConfiguration.backend:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
+	1,   // 76: buildbarn.configuration.blobstore.ActionResultExpiringBlobAccessConfiguration.backend:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
+	50,  // 77: buildbarn.configuration.blobstore.ActionResultExpiringBlobAccessConfiguration.minimum_validity:type_name -> google.protobuf.Duration
+	50,  // 78: buildbarn.configuration.blobstore.ActionResultExpiringBlobAccessConfiguration.maximum_validity_jitter:type_name -> google.protobuf.Duration
+	57,  // 79: buildbarn.configuration.blobstore.ActionResultExpiringBlobAccessConfiguration.minimum_timestamp:type_name -> google.protobuf.Timestamp
+	1,   // 80: buildbarn.configuration.blobstore.ReadCanaryingBlobAccessConfiguration.source:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
+	1,   // 81: buildbarn.configuration.blobstore.ReadCanaryingBlobAccessConfiguration.replica:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
+	50,  // 82: buildbarn.configuration.blobstore.ReadCanaryingBlobAccessConfiguration.maximum_cache_duration:type_name -> google.protobuf.Duration
+	52,  // 83: buildbarn.configuration.blobstore.ZIPBlobAccessConfiguration.data_integrity_validation_cache:type_name -> buildbarn.configuration.digest.ExistenceCacheConfiguration
+	1,   // 84: buildbarn.configuration.blobstore.WithLabelsBlobAccessConfiguration.backend:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
+	45,  // 85: buildbarn.configuration.blobstore.WithLabelsBlobAccessConfiguration.labels:type_name -> buildbarn.configuration.blobstore.WithLabelsBlobAccessConfiguration.LabelsEntry
+	50,  // 86: buildbarn.configuration.blobstore.DeadlineEnforcingBlobAccess.timeout:type_name -> google.protobuf.Duration
+	1,   // 87: buildbarn.configuration.blobstore.DeadlineEnforcingBlobAccess.backend:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
+	1,   // 88: buildbarn.configuration.blobstore.CircuitBreakingBlobAccessConfiguration.backend:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
+	50,  // 89: buildbarn.configuration.blobstore.CircuitBreakingBlobAccessConfiguration.cooldown:type_name -> google.protobuf.Duration
+	1,   // 90: buildbarn.configuration.blobstore.RateLimitingBlobAccessConfiguration.backend:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
+	58,  // 91: buildbarn.configuration.blobstore.RateLimitingBlobAccessConfiguration.key_jmespath_expression:type_name -> buildbarn.configuration.jmespath.Expression
+	46,  // 92: buildbarn.configuration.blobstore.RateLimitingBlobAccessConfiguration.get:type_name -> buildbarn.configuration.blobstore.RateLimitingBlobAccessConfiguration.Limit
+	46,  // 93: buildbarn.configuration.blobstore.RateLimitingBlobAccessConfiguration.put:type_name -> buildbarn.configuration.blobstore.RateLimitingBlobAccessConfiguration.Limit
+	46,  // 94: buildbarn.configuration.blobstore.RateLimitingBlobAccessConfiguration.find_missing:type_name -> buildbarn.configuration.blobstore.RateLimitingBlobAccessConfiguration.Limit
+	1,   // 95: buildbarn.configuration.blobstore.QuotaEnforcingBlobAccessConfiguration.backend:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
+	58,  // 96: buildbarn.configuration.blobstore.QuotaEnforcingBlobAccessConfiguration.tenant_jmespath_expression:type_name -> buildbarn.configuration.jmespath.Expression
+	50,  // 97: buildbarn.configuration.blobstore.QuotaEnforcingBlobAccessConfiguration.window:type_name -> google.protobuf.Duration
+	47,  // 98: buildbarn.configuration.blobstore.QuotaEnforcingBlobAccessConfiguration.tenant_quota_bytes:type_name -> buildbarn.configuration.blobstore.QuotaEnforcingBlobAccessConfiguration.TenantQuotaBytesEntry
+	53,  // 99: buildbarn.configuration.blobstore.S3BlobAccessConfiguration.aws_session:type_name -> buildbarn.configuration.cloud.aws.SessionConfiguration
+	59,  // 100: buildbarn.configuration.blobstore.S3BlobAccessConfiguration.slice_cache_replacement_policy:type_name -> buildbarn.configuration.eviction.CacheReplacementPolicy
+	55,  // 101: buildbarn.configuration.blobstore.GCSBlobAccessConfiguration.client_options:type_name -> buildbarn.configuration.cloud.gcp.ClientOptionsConfiguration
+	50,  // 102: buildbarn.configuration.blobstore.GCSBlobAccessConfiguration.custom_time_refresh_interval:type_name -> google.protobuf.Duration
+	59,  // 103: buildbarn.configuration.blobstore.DirectoryBlobAccessConfiguration.cache_replacement_policy:type_name -> buildbarn.configuration.eviction.CacheReplacementPolicy
+	60,  // 104: buildbarn.configuration.blobstore.RedisBlobAccessConfiguration.tls:type_name -> buildbarn.configuration.tls.ClientConfiguration
+	50,  // 105: buildbarn.configuration.blobstore.RedisBlobAccessConfiguration.expiration:type_name -> google.protobuf.Duration
+	54,  // 106: buildbarn.configuration.blobstore.HTTPBlobAccessConfiguration.http_client:type_name -> buildbarn.configuration.http.client.Configuration
+	50,  // 107: buildbarn.configuration.blobstore.BoltBlobAccessConfiguration.minimum_validity:type_name -> google.protobuf.Duration
+	50,  // 108: buildbarn.configuration.blobstore.BoltBlobAccessConfiguration.maximum_validity_jitter:type_name -> google.protobuf.Duration
+	50,  // 109: buildbarn.configuration.blobstore.BoltBlobAccessConfiguration.maintenance_interval:type_name -> google.protobuf.Duration
+	50,  // 110: buildbarn.configuration.blobstore.BoltBlobAccessConfiguration.snapshot_interval:type_name -> google.protobuf.Duration
+	54,  // 111: buildbarn.configuration.blobstore.OCIBlobAccessConfiguration.http_client:type_name -> buildbarn.configuration.http.client.Configuration
+	59,  // 112: buildbarn.configuration.blobstore.OCIBlobAccessConfiguration.slice_cache_replacement_policy:type_name -> buildbarn.configuration.eviction.CacheReplacementPolicy
+	1,   // 113: buildbarn.configuration.blobstore.ErasureCodingBlobAccessConfiguration.data_backends:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
+	1,   // 114: buildbarn.configuration.blobstore.ErasureCodingBlobAccessConfiguration.parity_backends:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
+	48,  // 115: buildbarn.configuration.blobstore.CompressedGrpcBlobAccessConfiguration.client:type_name -> buildbarn.configuration.grpc.ClientConfiguration
+	61,  // 116: buildbarn.configuration.blobstore.CompressedGrpcBlobAccessConfiguration.compressor:type_name -> build.bazel.remote.execution.v2.Compressor.Value
+	1,   // 117: buildbarn.configuration.blobstore.ContentDefinedChunkingConfiguration.manifest_storage:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
+	1,   // 118: buildbarn.configuration.blobstore.ShardingBlobAccessConfiguration.Shard.backend:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
+	36,  // 119: buildbarn.configuration.blobstore.ShardingBlobAccessConfiguration.ShardsEntry.value:type_name -> buildbarn.configuration.blobstore.ShardingBlobAccessConfiguration.Shard
+	1,   // 120: buildbarn.configuration.blobstore.ReplicatedBlobAccessConfiguration.Replica.backend:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
+	13,  // 121: buildbarn.configuration.blobstore.ReplicatedBlobAccessConfiguration.Replica.replicator:type_name -> buildbarn.configuration.blobstore.BlobReplicatorConfiguration
+	51,  // 122: buildbarn.configuration.blobstore.LocalBlobAccessConfiguration.BlocksOnBlockDevice.source:type_name -> buildbarn.configuration.blockdevice.Configuration
+	52,  // 123: buildbarn.configuration.blobstore.LocalBlobAccessConfiguration.BlocksOnBlockDevice.data_integrity_validation_cache:type_name -> buildbarn.configuration.digest.ExistenceCacheConfiguration
+	50,  // 124: buildbarn.configuration.blobstore.LocalBlobAccessConfiguration.Persistent.minimum_epoch_interval:type_name -> google.protobuf.Duration
+	17,  // 125: buildbarn.configuration.blobstore.DemultiplexingBlobAccessConfiguration.InstanceNamePrefixesEntry.value:type_name -> buildbarn.configuration.blobstore.DemultiplexedBlobAccessConfiguration
+	1,   // 126: buildbarn.configuration.blobstore.WithLabelsBlobAccessConfiguration.LabelsEntry.value:type_name -> buildbarn.configuration.blobstore.BlobAccessConfiguration
+	127, // [127:127] is the sub-list for method output_type
+	127, // [127:127] is the sub-list for method input_type
+	127, // [127:127] is the sub-list for extension type_name
+	127, // [127:127] is the sub-list for extension extendee
+	0,   // [0:127] is the sub-list for field type_name
 }
 
 func init() {
@@ -2303,14 +5303,29 @@ func file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blob
 		(*BlobAccessConfiguration_WithLabels)(nil),
 		(*BlobAccessConfiguration_Label)(nil),
 		(*BlobAccessConfiguration_DeadlineEnforcing)(nil),
+		(*BlobAccessConfiguration_CompressedGrpc)(nil),
+		(*BlobAccessConfiguration_S3)(nil),
+		(*BlobAccessConfiguration_Gcs)(nil),
+		(*BlobAccessConfiguration_Directory)(nil),
+		(*BlobAccessConfiguration_Redis)(nil),
+		(*BlobAccessConfiguration_Http)(nil),
+		(*BlobAccessConfiguration_Bolt)(nil),
+		(*BlobAccessConfiguration_Oci)(nil),
+		(*BlobAccessConfiguration_ErasureCoding)(nil),
+		(*BlobAccessConfiguration_Replicated)(nil),
+		(*BlobAccessConfiguration_Hedging)(nil),
+		(*BlobAccessConfiguration_LatencyAware)(nil),
+		(*BlobAccessConfiguration_CircuitBreaker)(nil),
+		(*BlobAccessConfiguration_RateLimiting)(nil),
+		(*BlobAccessConfiguration_QuotaEnforcing)(nil),
 	}
-	file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[5].OneofWrappers = []any{
+	file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[8].OneofWrappers = []any{
 		(*LocalBlobAccessConfiguration_KeyLocationMapInMemory_)(nil),
 		(*LocalBlobAccessConfiguration_KeyLocationMapOnBlockDevice)(nil),
 		(*LocalBlobAccessConfiguration_BlocksInMemory_)(nil),
 		(*LocalBlobAccessConfiguration_BlocksOnBlockDevice_)(nil),
 	}
-	file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[10].OneofWrappers = []any{
+	file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[13].OneofWrappers = []any{
 		(*BlobReplicatorConfiguration_Local)(nil),
 		(*BlobReplicatorConfiguration_Remote)(nil),
 		(*BlobReplicatorConfiguration_Queued)(nil),
@@ -2318,13 +5333,17 @@ func file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blob
 		(*BlobReplicatorConfiguration_Deduplicating)(nil),
 		(*BlobReplicatorConfiguration_ConcurrencyLimiting)(nil),
 	}
+	file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_msgTypes[25].OneofWrappers = []any{
+		(*QuotaEnforcingBlobAccessConfiguration_TenantJmespathExpression)(nil),
+		(*QuotaEnforcingBlobAccessConfiguration_TenantInstanceNamePrefixComponents)(nil),
+	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDesc), len(file_github_com_buildbarn_bb_storage_pkg_proto_configuration_blobstore_blobstore_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   29,
+			NumMessages:   48,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -29,13 +29,29 @@ const (
 )
 
 type PrometheusPushgatewayConfiguration struct {
-	state                   protoimpl.MessageState                                       `protogen:"open.v1"`
-	Url                     string                                                       `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
-	Job                     string                                                       `protobuf:"bytes,2,opt,name=job,proto3" json:"job,omitempty"`
-	Grouping                map[string]string                                            `protobuf:"bytes,4,rep,name=grouping,proto3" json:"grouping,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	PushInterval            *durationpb.Duration                                         `protobuf:"bytes,5,opt,name=push_interval,json=pushInterval,proto3" json:"push_interval,omitempty"`
-	HttpClient              *client.Configuration                                        `protobuf:"bytes,6,opt,name=http_client,json=httpClient,proto3" json:"http_client,omitempty"`
-	PushTimeout             *durationpb.Duration                                         `protobuf:"bytes,7,opt,name=push_timeout,json=pushTimeout,proto3" json:"push_timeout,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// URL of the Prometheus Pushgateway server. Do not include the
+	// "/metrics/jobs/..." part in the URL.
+	Url string `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	// Name of the job to announce to the Prometheus Pushgateway.
+	Job string `protobuf:"bytes,2,opt,name=job,proto3" json:"job,omitempty"`
+	// Label pairs to use as the grouping key.
+	Grouping map[string]string `protobuf:"bytes,4,rep,name=grouping,proto3" json:"grouping,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Interval between metrics pushes.
+	PushInterval *durationpb.Duration `protobuf:"bytes,5,opt,name=push_interval,json=pushInterval,proto3" json:"push_interval,omitempty"`
+	// Optional: Options to be used by the HTTP client.
+	HttpClient *client.Configuration `protobuf:"bytes,6,opt,name=http_client,json=httpClient,proto3" json:"http_client,omitempty"`
+	// Maximum amount of time that pushing metrics may take.
+	PushTimeout *durationpb.Duration `protobuf:"bytes,7,opt,name=push_timeout,json=pushTimeout,proto3" json:"push_timeout,omitempty"`
+	// Scrape an additional set of target endpoints, and combine the
+	// resulting metrics with the ones that are generated by the current
+	// process before pushing them to Prometheus Pushgateway.
+	//
+	// One example use of this option is on workers that are hosted in
+	// networks that are not reachable externally. By running an instance
+	// of Prometheus Node Exporter on the same system, it's possible to
+	// augment bb_worker's metrics to contain operating system level
+	// metrics as well.
 	AdditionalScrapeTargets []*PrometheusPushgatewayConfiguration_AdditionalScrapeTarget `protobuf:"bytes,8,rep,name=additional_scrape_targets,json=additionalScrapeTargets,proto3" json:"additional_scrape_targets,omitempty"`
 	unknownFields           protoimpl.UnknownFields
 	sizeCache               protoimpl.SizeCache
@@ -121,12 +137,23 @@ func (x *PrometheusPushgatewayConfiguration) GetAdditionalScrapeTargets() []*Pro
 }
 
 type TracingConfiguration struct {
-	state              protoimpl.MessageState          `protogen:"open.v1"`
-	Backends           []*TracingConfiguration_Backend `protobuf:"bytes,1,rep,name=backends,proto3" json:"backends,omitempty"`
-	ResourceAttributes []*v1.KeyValue                  `protobuf:"bytes,2,rep,name=resource_attributes,json=resourceAttributes,proto3" json:"resource_attributes,omitempty"`
-	Sampler            *TracingConfiguration_Sampler   `protobuf:"bytes,3,opt,name=sampler,proto3" json:"sampler,omitempty"`
-	unknownFields      protoimpl.UnknownFields
-	sizeCache          protoimpl.SizeCache
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The backends to which to submit trace spans.
+	Backends []*TracingConfiguration_Backend `protobuf:"bytes,1,rep,name=backends,proto3" json:"backends,omitempty"`
+	// Resource attributes to announce to backends. These can be used to
+	// uniquely identify processes to the tracing system. Conventions
+	// about the naming of attributes exist. For example, an attribute
+	// with key "service.name" is generally required.
+	//
+	// More details can be found on the following pages:
+	// https://github.com/open-telemetry/opentelemetry-specification/blob/main/specification/resource/sdk.md
+	// https://github.com/open-telemetry/opentelemetry-specification/blob/main/specification/resource/semantic_conventions/README.md
+	// https://pkg.go.dev/go.opentelemetry.io/otel/semconv/v1.4.0
+	ResourceAttributes []*v1.KeyValue `protobuf:"bytes,2,rep,name=resource_attributes,json=resourceAttributes,proto3" json:"resource_attributes,omitempty"`
+	// The policy to determine how many traces are sampled.
+	Sampler       *TracingConfiguration_Sampler `protobuf:"bytes,3,opt,name=sampler,proto3" json:"sampler,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *TracingConfiguration) Reset() {
@@ -181,8 +208,9 @@ func (x *TracingConfiguration) GetSampler() *TracingConfiguration_Sampler {
 }
 
 type SetUmaskConfiguration struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Umask         uint32                 `protobuf:"varint,1,opt,name=umask,proto3" json:"umask,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The value of the file creation mode mask to be passed to umask().
+	Umask         uint32 `protobuf:"varint,1,opt,name=umask,proto3" json:"umask,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -225,8 +253,12 @@ func (x *SetUmaskConfiguration) GetUmask() uint32 {
 }
 
 type SetResourceLimitConfiguration struct {
-	state         protoimpl.MessageState  `protogen:"open.v1"`
-	SoftLimit     *wrapperspb.UInt64Value `protobuf:"bytes,1,opt,name=soft_limit,json=softLimit,proto3" json:"soft_limit,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// If set, configure a soft limit. If not set, no soft limit is
+	// assumed (RLIM_INFINITY).
+	SoftLimit *wrapperspb.UInt64Value `protobuf:"bytes,1,opt,name=soft_limit,json=softLimit,proto3" json:"soft_limit,omitempty"`
+	// If set, configure a hard limit. If not set, no hard limit is
+	// assumed (RLIM_INFINITY).
 	HardLimit     *wrapperspb.UInt64Value `protobuf:"bytes,2,opt,name=hard_limit,json=hardLimit,proto3" json:"hard_limit,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
@@ -277,18 +309,58 @@ func (x *SetResourceLimitConfiguration) GetHardLimit() *wrapperspb.UInt64Value {
 }
 
 type Configuration struct {
-	state                       protoimpl.MessageState                    `protogen:"open.v1"`
-	Tracing                     *TracingConfiguration                     `protobuf:"bytes,1,opt,name=tracing,proto3" json:"tracing,omitempty"`
-	MutexProfileFraction        int32                                     `protobuf:"varint,2,opt,name=mutex_profile_fraction,json=mutexProfileFraction,proto3" json:"mutex_profile_fraction,omitempty"`
-	PrometheusPushgateway       *PrometheusPushgatewayConfiguration       `protobuf:"bytes,3,opt,name=prometheus_pushgateway,json=prometheusPushgateway,proto3" json:"prometheus_pushgateway,omitempty"`
-	LogPaths                    []string                                  `protobuf:"bytes,5,rep,name=log_paths,json=logPaths,proto3" json:"log_paths,omitempty"`
-	DiagnosticsHttpServer       *DiagnosticsHTTPServerConfiguration       `protobuf:"bytes,6,opt,name=diagnostics_http_server,json=diagnosticsHttpServer,proto3" json:"diagnostics_http_server,omitempty"`
-	SetUmask                    *SetUmaskConfiguration                    `protobuf:"bytes,7,opt,name=set_umask,json=setUmask,proto3" json:"set_umask,omitempty"`
-	GrpcForwardAndReuseMetadata []string                                  `protobuf:"bytes,8,rep,name=grpc_forward_and_reuse_metadata,json=grpcForwardAndReuseMetadata,proto3" json:"grpc_forward_and_reuse_metadata,omitempty"`
-	GrpcKubernetesResolvers     map[string]*GRPCKubernetesResolver        `protobuf:"bytes,16,rep,name=grpc_kubernetes_resolvers,json=grpcKubernetesResolvers,proto3" json:"grpc_kubernetes_resolvers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	SetResourceLimits           map[string]*SetResourceLimitConfiguration `protobuf:"bytes,15,rep,name=set_resource_limits,json=setResourceLimits,proto3" json:"set_resource_limits,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	unknownFields               protoimpl.UnknownFields
-	sizeCache                   protoimpl.SizeCache
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Configuration for sending tracing data using OpenTelemetry.
+	Tracing *TracingConfiguration `protobuf:"bytes,1,opt,name=tracing,proto3" json:"tracing,omitempty"`
+	// Sets the runtime.SetMutexProfileFraction(), so that the HTTP debug
+	// endpoints used by pprof expose mutex profiling information.
+	MutexProfileFraction int32 `protobuf:"varint,2,opt,name=mutex_profile_fraction,json=mutexProfileFraction,proto3" json:"mutex_profile_fraction,omitempty"`
+	// Periodically push metrics to a Prometheus Pushgateway, as opposed
+	// to letting the Prometheus server scrape the metrics.
+	PrometheusPushgateway *PrometheusPushgatewayConfiguration `protobuf:"bytes,3,opt,name=prometheus_pushgateway,json=prometheusPushgateway,proto3" json:"prometheus_pushgateway,omitempty"`
+	// Pathnames where, in addition to stderr, application logs are
+	// written. Parent directories of the specified paths must exist.
+	LogPaths []string `protobuf:"bytes,5,rep,name=log_paths,json=logPaths,proto3" json:"log_paths,omitempty"`
+	// When set, enables a HTTP server that provides diagnostic information.
+	DiagnosticsHttpServer *DiagnosticsHTTPServerConfiguration `protobuf:"bytes,6,opt,name=diagnostics_http_server,json=diagnosticsHttpServer,proto3" json:"diagnostics_http_server,omitempty"`
+	// Call umask() on startup to set the file creation mode mask. It may
+	// be necessary to set this option in multi-user environments to
+	// ensure that files and UNIX sockets that are created are accessible
+	// by other processes.
+	//
+	// This option may only be set on POSIX-like systems.
+	SetUmask *SetUmaskConfiguration `protobuf:"bytes,7,opt,name=set_umask,json=setUmask,proto3" json:"set_umask,omitempty"`
+	// List of gRPC metadata headers to forward from gRPC clients to gRPC
+	// servers, and to reuse in successive requests.
+	//
+	// This option is useful when bb_storage is used as a personal proxy.
+	// It allows clients (e.g., Bazel) to inject credentials into
+	// bb_storage, thereby allowing other clients to access backends
+	// without any further authentication.
+	//
+	// NOTE: Using this option in networked and multi-user environments is
+	// strongly discouraged, as it allows users to hijack each other's
+	// credentials.
+	GrpcForwardAndReuseMetadata []string `protobuf:"bytes,8,rep,name=grpc_forward_and_reuse_metadata,json=grpcForwardAndReuseMetadata,proto3" json:"grpc_forward_and_reuse_metadata,omitempty"`
+	// Register gRPC load balancer resolvers that are capable of
+	// connecting to Kubernetes service endpoints.
+	//
+	// Map keys indicate the name of the URL schema to register. For
+	// example, if "kubernetes" is used you may use address
+	// "kubernetes://storage.buildbarn:8981" to connect to port 8981 of
+	// service "storage" in namespace "buildbarn".
+	//
+	// More details: https://github.com/sercand/kuberesolver
+	GrpcKubernetesResolvers map[string]*GRPCKubernetesResolver `protobuf:"bytes,16,rep,name=grpc_kubernetes_resolvers,json=grpcKubernetesResolvers,proto3" json:"grpc_kubernetes_resolvers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Resource limits to apply on startup using setrlimit(2) to ensure
+	// the program is capable of only consuming a finite number of file
+	// descriptors or amount of memory.
+	//
+	// Keys of this map correspond to the suffixes of the RLIMIT_*
+	// constants (e.g., "NOFILE" to limit the number of file descriptors).
+	SetResourceLimits map[string]*SetResourceLimitConfiguration `protobuf:"bytes,15,rep,name=set_resource_limits,json=setResourceLimits,proto3" json:"set_resource_limits,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
 }
 
 func (x *Configuration) Reset() {
@@ -385,11 +457,26 @@ func (x *Configuration) GetSetResourceLimits() map[string]*SetResourceLimitConfi
 }
 
 type DiagnosticsHTTPServerConfiguration struct {
-	state             protoimpl.MessageState  `protogen:"open.v1"`
-	HttpServers       []*server.Configuration `protobuf:"bytes,5,rep,name=http_servers,json=httpServers,proto3" json:"http_servers,omitempty"`
-	EnablePprof       bool                    `protobuf:"varint,2,opt,name=enable_pprof,json=enablePprof,proto3" json:"enable_pprof,omitempty"`
-	EnablePrometheus  bool                    `protobuf:"varint,3,opt,name=enable_prometheus,json=enablePrometheus,proto3" json:"enable_prometheus,omitempty"`
-	EnableActiveSpans bool                    `protobuf:"varint,4,opt,name=enable_active_spans,json=enableActiveSpans,proto3" json:"enable_active_spans,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Default endpoints:
+	// - /-/healthy: Returns HTTP 200 OK if the application managed to
+	//               start successfully.
+	//
+	// Binaries may provide additional endpoints. bb_storage provides:
+	// - /quota:     Returns the usage of tenants of storage backends of
+	//               type 'quota_enforcing' as JSON.
+	HttpServers []*server.Configuration `protobuf:"bytes,5,rep,name=http_servers,json=httpServers,proto3" json:"http_servers,omitempty"`
+	// Enables endpoints:
+	// - /debug/pprof/*: Endpoints for Go's pprof debug tool.
+	EnablePprof bool `protobuf:"varint,2,opt,name=enable_pprof,json=enablePprof,proto3" json:"enable_pprof,omitempty"`
+	// Enables endpoints:
+	// - /metrics: Metrics that can be scraped by Prometheus.
+	EnablePrometheus bool `protobuf:"varint,3,opt,name=enable_prometheus,json=enablePrometheus,proto3" json:"enable_prometheus,omitempty"`
+	// Enables endpoints:
+	// - /active_spans: List of active OpenTelemetry spans. This endpoint
+	//                  can be used independent of whether Configuration's
+	//                  'tracing' option is enabled.
+	EnableActiveSpans bool `protobuf:"varint,4,opt,name=enable_active_spans,json=enableActiveSpans,proto3" json:"enable_active_spans,omitempty"`
 	unknownFields     protoimpl.UnknownFields
 	sizeCache         protoimpl.SizeCache
 }
@@ -453,11 +540,14 @@ func (x *DiagnosticsHTTPServerConfiguration) GetEnableActiveSpans() bool {
 }
 
 type GRPCKubernetesResolver struct {
-	state               protoimpl.MessageState `protogen:"open.v1"`
-	ApiServerHttpClient *client.Configuration  `protobuf:"bytes,1,opt,name=api_server_http_client,json=apiServerHttpClient,proto3" json:"api_server_http_client,omitempty"`
-	ApiServerUrl        string                 `protobuf:"bytes,2,opt,name=api_server_url,json=apiServerUrl,proto3" json:"api_server_url,omitempty"`
-	unknownFields       protoimpl.UnknownFields
-	sizeCache           protoimpl.SizeCache
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Options to be used by the HTTP client to connect to the Kubernetes
+	// API server.
+	ApiServerHttpClient *client.Configuration `protobuf:"bytes,1,opt,name=api_server_http_client,json=apiServerHttpClient,proto3" json:"api_server_http_client,omitempty"`
+	// The URL of the Kubernetes API server.
+	ApiServerUrl  string `protobuf:"bytes,2,opt,name=api_server_url,json=apiServerUrl,proto3" json:"api_server_url,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *GRPCKubernetesResolver) Reset() {
@@ -505,10 +595,17 @@ func (x *GRPCKubernetesResolver) GetApiServerUrl() string {
 }
 
 type PrometheusPushgatewayConfiguration_AdditionalScrapeTarget struct {
-	state             protoimpl.MessageState `protogen:"open.v1"`
-	HttpClient        *client.Configuration  `protobuf:"bytes,1,opt,name=http_client,json=httpClient,proto3" json:"http_client,omitempty"`
-	Url               string                 `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
-	MetricNamePattern string                 `protobuf:"bytes,3,opt,name=metric_name_pattern,json=metricNamePattern,proto3" json:"metric_name_pattern,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Optional: Options to be used by the HTTP client that scrapes the
+	// target.
+	HttpClient *client.Configuration `protobuf:"bytes,1,opt,name=http_client,json=httpClient,proto3" json:"http_client,omitempty"`
+	// The URL of the target to scrape (e.g.,
+	// "http://localhost:9100/metrics").
+	Url string `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	// A regular expression to match names of metrics that should be
+	// extracted and forwarded to the Prometheus Pushgateway (e.g.,
+	// "^node_").
+	MetricNamePattern string `protobuf:"bytes,3,opt,name=metric_name_pattern,json=metricNamePattern,proto3" json:"metric_name_pattern,omitempty"`
 	unknownFields     protoimpl.UnknownFields
 	sizeCache         protoimpl.SizeCache
 }
@@ -665,10 +762,17 @@ type isTracingConfiguration_Backend_SpanExporter interface {
 }
 
 type TracingConfiguration_Backend_JaegerCollectorSpanExporter_ struct {
+	// Export spans to a Jaeger collector using jaeger.thrift over
+	// HTTP.
+	//
+	// NOTE: This option is deprecated, as Jaeger 1.35 and later
+	// provide native support for the OpenTelemetry protocol:
+	// https://medium.com/jaegertracing/introducing-native-support-for-opentelemetry-in-jaeger-eb661be8183c
 	JaegerCollectorSpanExporter *TracingConfiguration_Backend_JaegerCollectorSpanExporter `protobuf:"bytes,1,opt,name=jaeger_collector_span_exporter,json=jaegerCollectorSpanExporter,proto3,oneof"`
 }
 
 type TracingConfiguration_Backend_OtlpSpanExporter struct {
+	// Export spans over gRPC using the OpenTelemetry protocol.
 	OtlpSpanExporter *grpc.ClientConfiguration `protobuf:"bytes,4,opt,name=otlp_span_exporter,json=otlpSpanExporter,proto3,oneof"`
 }
 
@@ -682,10 +786,14 @@ type isTracingConfiguration_Backend_SpanProcessor interface {
 }
 
 type TracingConfiguration_Backend_SimpleSpanProcessor struct {
+	// Use a span processor that will synchronously send completed
+	// spans to the exporter immediately.
 	SimpleSpanProcessor *emptypb.Empty `protobuf:"bytes,2,opt,name=simple_span_processor,json=simpleSpanProcessor,proto3,oneof"`
 }
 
 type TracingConfiguration_Backend_BatchSpanProcessor_ struct {
+	// Use a span processor that will send completed span batches to
+	// the exporter.
 	BatchSpanProcessor *TracingConfiguration_Backend_BatchSpanProcessor `protobuf:"bytes,3,opt,name=batch_span_processor,json=batchSpanProcessor,proto3,oneof"`
 }
 
@@ -796,22 +904,30 @@ type isTracingConfiguration_Sampler_Policy interface {
 }
 
 type TracingConfiguration_Sampler_Always struct {
+	// Sample all traces.
 	Always *emptypb.Empty `protobuf:"bytes,1,opt,name=always,proto3,oneof"`
 }
 
 type TracingConfiguration_Sampler_Never struct {
+	// Don't sample any traces.
 	Never *emptypb.Empty `protobuf:"bytes,2,opt,name=never,proto3,oneof"`
 }
 
 type TracingConfiguration_Sampler_ParentBased_ struct {
+	// Let the presence or absence of a parent span, and whether it is
+	// sampled determine whether sampling takes place,
 	ParentBased *TracingConfiguration_Sampler_ParentBased `protobuf:"bytes,3,opt,name=parent_based,json=parentBased,proto3,oneof"`
 }
 
 type TracingConfiguration_Sampler_TraceIdRatioBased struct {
+	// Sample a given fraction of traces. Fractions >= 1 will always
+	// sample. Fractions < 0 are treated as zero.
 	TraceIdRatioBased float64 `protobuf:"fixed64,4,opt,name=trace_id_ratio_based,json=traceIdRatioBased,proto3,oneof"`
 }
 
 type TracingConfiguration_Sampler_MaximumRate_ struct {
+	// Sample traces at a maximum rate. This ensures that load on the
+	// tracing infrastructure remains bounded.
 	MaximumRate *TracingConfiguration_Sampler_MaximumRate `protobuf:"bytes,5,opt,name=maximum_rate,json=maximumRate,proto3,oneof"`
 }
 
@@ -826,11 +942,32 @@ func (*TracingConfiguration_Sampler_TraceIdRatioBased) isTracingConfiguration_Sa
 func (*TracingConfiguration_Sampler_MaximumRate_) isTracingConfiguration_Sampler_Policy() {}
 
 type TracingConfiguration_Backend_JaegerCollectorSpanExporter struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Endpoint      string                 `protobuf:"bytes,1,opt,name=endpoint,proto3" json:"endpoint,omitempty"`
-	HttpClient    *client.Configuration  `protobuf:"bytes,2,opt,name=http_client,json=httpClient,proto3" json:"http_client,omitempty"`
-	Password      string                 `protobuf:"bytes,3,opt,name=password,proto3" json:"password,omitempty"`
-	Username      string                 `protobuf:"bytes,4,opt,name=username,proto3" json:"username,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The URL of the Jaeger collector to which spans are sent.
+	//
+	// This option overrides any value set for the
+	// OTEL_EXPORTER_JAEGER_ENDPOINT environment variable. If this
+	// option is not passed and the environment variable is not set,
+	// "http://localhost:14268/api/traces" will be used by default.
+	Endpoint string `protobuf:"bytes,1,opt,name=endpoint,proto3" json:"endpoint,omitempty"`
+	// Optional: Options to be used by the HTTP client.
+	HttpClient *client.Configuration `protobuf:"bytes,2,opt,name=http_client,json=httpClient,proto3" json:"http_client,omitempty"`
+	// The password to be used in the authorization header sent for
+	// all requests to the collector.
+	//
+	// This option overrides any value set for the
+	// OTEL_EXPORTER_JAEGER_PASSWORD environment variable. If this
+	// option is not passed and the environment variable is not set,
+	// no password will be set.
+	Password string `protobuf:"bytes,3,opt,name=password,proto3" json:"password,omitempty"`
+	// The username to be used in the authorization header sent for
+	// all requests to the collector.
+	//
+	// This option overrides any value set for the
+	// OTEL_EXPORTER_JAEGER_USER environment variable. If this option
+	// is not passed and the environment variable is not set, no
+	// username will be set.
+	Username      string `protobuf:"bytes,4,opt,name=username,proto3" json:"username,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -894,14 +1031,35 @@ func (x *TracingConfiguration_Backend_JaegerCollectorSpanExporter) GetUsername()
 }
 
 type TracingConfiguration_Backend_BatchSpanProcessor struct {
-	state              protoimpl.MessageState `protogen:"open.v1"`
-	BatchTimeout       *durationpb.Duration   `protobuf:"bytes,1,opt,name=batch_timeout,json=batchTimeout,proto3" json:"batch_timeout,omitempty"`
-	Blocking           bool                   `protobuf:"varint,2,opt,name=blocking,proto3" json:"blocking,omitempty"`
-	ExportTimeout      *durationpb.Duration   `protobuf:"bytes,3,opt,name=export_timeout,json=exportTimeout,proto3" json:"export_timeout,omitempty"`
-	MaxExportBatchSize int64                  `protobuf:"varint,4,opt,name=max_export_batch_size,json=maxExportBatchSize,proto3" json:"max_export_batch_size,omitempty"`
-	MaxQueueSize       int64                  `protobuf:"varint,5,opt,name=max_queue_size,json=maxQueueSize,proto3" json:"max_queue_size,omitempty"`
-	unknownFields      protoimpl.UnknownFields
-	sizeCache          protoimpl.SizeCache
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The maximum duration for constructing a batch. The processor
+	// forcefully sends available spans when the timeout is reached.
+	//
+	// When not set, OpenTelemetry's default value is used.
+	BatchTimeout *durationpb.Duration `protobuf:"bytes,1,opt,name=batch_timeout,json=batchTimeout,proto3" json:"batch_timeout,omitempty"`
+	// Block if the queue is full. This option should be used
+	// carefully, as it can severely affect the performance of the
+	// application.
+	Blocking bool `protobuf:"varint,2,opt,name=blocking,proto3" json:"blocking,omitempty"`
+	// The maximum duration for exporting spans. If the timeout is
+	// reached, the exported will be canceled.
+	//
+	// When not set, OpenTelemetry's default value is used.
+	ExportTimeout *durationpb.Duration `protobuf:"bytes,3,opt,name=export_timeout,json=exportTimeout,proto3" json:"export_timeout,omitempty"`
+	// Maximum number of spans to process in a single batch. If there
+	// are more than one batch worth of spans, then it processes
+	// multiple batches of spans, one batch after the other, without
+	// any delay.
+	//
+	// When not set, OpenTelemetry's default value is used.
+	MaxExportBatchSize int64 `protobuf:"varint,4,opt,name=max_export_batch_size,json=maxExportBatchSize,proto3" json:"max_export_batch_size,omitempty"`
+	// Maximum queue size to buffer spans for delayed processing. If
+	// the queue gets full, it drops the spans.
+	//
+	// When not set, OpenTelemetry's default value is used.
+	MaxQueueSize  int64 `protobuf:"varint,5,opt,name=max_queue_size,json=maxQueueSize,proto3" json:"max_queue_size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *TracingConfiguration_Backend_BatchSpanProcessor) Reset() {
@@ -970,14 +1128,19 @@ func (x *TracingConfiguration_Backend_BatchSpanProcessor) GetMaxQueueSize() int6
 }
 
 type TracingConfiguration_Sampler_ParentBased struct {
-	state                  protoimpl.MessageState        `protogen:"open.v1"`
-	NoParent               *TracingConfiguration_Sampler `protobuf:"bytes,1,opt,name=no_parent,json=noParent,proto3" json:"no_parent,omitempty"`
-	LocalParentNotSampled  *TracingConfiguration_Sampler `protobuf:"bytes,2,opt,name=local_parent_not_sampled,json=localParentNotSampled,proto3" json:"local_parent_not_sampled,omitempty"`
-	LocalParentSampled     *TracingConfiguration_Sampler `protobuf:"bytes,3,opt,name=local_parent_sampled,json=localParentSampled,proto3" json:"local_parent_sampled,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The sampler to use in case no parent span exists.
+	NoParent *TracingConfiguration_Sampler `protobuf:"bytes,1,opt,name=no_parent,json=noParent,proto3" json:"no_parent,omitempty"`
+	// The sampler for the case of local parent which is not sampled.
+	LocalParentNotSampled *TracingConfiguration_Sampler `protobuf:"bytes,2,opt,name=local_parent_not_sampled,json=localParentNotSampled,proto3" json:"local_parent_not_sampled,omitempty"`
+	// The sampler for the case of sampled local parent.
+	LocalParentSampled *TracingConfiguration_Sampler `protobuf:"bytes,3,opt,name=local_parent_sampled,json=localParentSampled,proto3" json:"local_parent_sampled,omitempty"`
+	// The sampler for the case of remote parent which is not sampled.
 	RemoteParentNotSampled *TracingConfiguration_Sampler `protobuf:"bytes,4,opt,name=remote_parent_not_sampled,json=remoteParentNotSampled,proto3" json:"remote_parent_not_sampled,omitempty"`
-	RemoteParentSampled    *TracingConfiguration_Sampler `protobuf:"bytes,5,opt,name=remote_parent_sampled,json=remoteParentSampled,proto3" json:"remote_parent_sampled,omitempty"`
-	unknownFields          protoimpl.UnknownFields
-	sizeCache              protoimpl.SizeCache
+	// The sampler for the case of sampled remote parent.
+	RemoteParentSampled *TracingConfiguration_Sampler `protobuf:"bytes,5,opt,name=remote_parent_sampled,json=remoteParentSampled,proto3" json:"remote_parent_sampled,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
 }
 
 func (x *TracingConfiguration_Sampler_ParentBased) Reset() {
@@ -1046,11 +1209,13 @@ func (x *TracingConfiguration_Sampler_ParentBased) GetRemoteParentSampled() *Tra
 }
 
 type TracingConfiguration_Sampler_MaximumRate struct {
-	state           protoimpl.MessageState `protogen:"open.v1"`
-	SamplesPerEpoch int64                  `protobuf:"varint,1,opt,name=samples_per_epoch,json=samplesPerEpoch,proto3" json:"samples_per_epoch,omitempty"`
-	EpochDuration   *durationpb.Duration   `protobuf:"bytes,2,opt,name=epoch_duration,json=epochDuration,proto3" json:"epoch_duration,omitempty"`
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The number of traces sample within a single epoch.
+	SamplesPerEpoch int64 `protobuf:"varint,1,opt,name=samples_per_epoch,json=samplesPerEpoch,proto3" json:"samples_per_epoch,omitempty"`
+	// The duration of an epoch.
+	EpochDuration *durationpb.Duration `protobuf:"bytes,2,opt,name=epoch_duration,json=epochDuration,proto3" json:"epoch_duration,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *TracingConfiguration_Sampler_MaximumRate) Reset() {
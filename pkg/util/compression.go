@@ -0,0 +1,53 @@
+package util
+
+import (
+	"compress/flate"
+	"io"
+
+	remoteexecution "github.com/bazelbuild/remote-apis/build/bazel/remote/execution/v2"
+	"github.com/klauspost/compress/zstd"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// NewCompressingWriter creates an io.WriteCloser that compresses data
+// using one of the compression algorithms supported by REv2, and writes
+// it into an underlying io.Writer. Closing the io.WriteCloser causes
+// any pending data to be flushed.
+func NewCompressingWriter(w io.Writer, compressor remoteexecution.Compressor_Value) (io.WriteCloser, error) {
+	switch compressor {
+	case remoteexecution.Compressor_DEFLATE:
+		deflateWriter, err := flate.NewWriter(w, flate.DefaultCompression)
+		if err != nil {
+			return nil, StatusWrapWithCode(err, codes.Internal, "Failed to create deflate writer")
+		}
+		return deflateWriter, nil
+	case remoteexecution.Compressor_ZSTD:
+		zstdWriter, err := zstd.NewWriter(w, zstd.WithEncoderConcurrency(1))
+		if err != nil {
+			return nil, StatusWrapWithCode(err, codes.Internal, "Failed to create zstd writer")
+		}
+		return zstdWriter, nil
+	default:
+		return nil, status.Errorf(codes.Unimplemented, "Unsupported compression type: %s", compressor)
+	}
+}
+
+// NewDecompressingReader creates an io.ReadCloser that decompresses
+// data read from an underlying io.Reader using one of the compression
+// algorithms supported by REv2.
+func NewDecompressingReader(r io.Reader, compressor remoteexecution.Compressor_Value) (io.ReadCloser, error) {
+	switch compressor {
+	case remoteexecution.Compressor_DEFLATE:
+		return flate.NewReader(r), nil
+	case remoteexecution.Compressor_ZSTD:
+		zstdReader, err := NewZstdReadCloser(io.NopCloser(r), zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, StatusWrapWithCode(err, codes.Internal, "Failed to create zstd reader")
+		}
+		return zstdReader, nil
+	default:
+		return nil, status.Errorf(codes.Unimplemented, "Unsupported compression type: %s", compressor)
+	}
+}